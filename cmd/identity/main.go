@@ -2,22 +2,61 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/mibrahim2344/identity-service/docs"
+	"github.com/mibrahim2344/identity-service/internal/application"
+	"github.com/mibrahim2344/identity-service/internal/application/apikey"
+	"github.com/mibrahim2344/identity-service/internal/application/audit"
+	"github.com/mibrahim2344/identity-service/internal/application/bootstrap"
+	"github.com/mibrahim2344/identity-service/internal/application/cacheadmin"
 	"github.com/mibrahim2344/identity-service/internal/application/config"
+	"github.com/mibrahim2344/identity-service/internal/application/consent"
+	"github.com/mibrahim2344/identity-service/internal/application/jobs"
+	"github.com/mibrahim2344/identity-service/internal/application/mfa"
+	"github.com/mibrahim2344/identity-service/internal/application/moderation"
+	"github.com/mibrahim2344/identity-service/internal/application/oauth"
+	"github.com/mibrahim2344/identity-service/internal/application/organization"
+	"github.com/mibrahim2344/identity-service/internal/application/role"
+	"github.com/mibrahim2344/identity-service/internal/application/sso"
+	"github.com/mibrahim2344/identity-service/internal/application/tenant"
 	"github.com/mibrahim2344/identity-service/internal/application/user"
+	"github.com/mibrahim2344/identity-service/internal/application/webhook"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	domainservices "github.com/mibrahim2344/identity-service/internal/domain/services"
+	oauthclient "github.com/mibrahim2344/identity-service/internal/infrastructure/auth/oauth"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/auth/password"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/auth/token"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/auth/totp"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/auth/webauthn"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/email"
 	"github.com/mibrahim2344/identity-service/internal/infrastructure/events/kafka"
+	natspub "github.com/mibrahim2344/identity-service/internal/infrastructure/events/nats"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/events/rabbitmq"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/lifecycle"
 	"github.com/mibrahim2344/identity-service/internal/infrastructure/metrics"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/notary"
 	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/postgres"
 	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/redis"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/search"
 	infraservices "github.com/mibrahim2344/identity-service/internal/infrastructure/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/tracing"
+	infrawebhook "github.com/mibrahim2344/identity-service/internal/infrastructure/webhook"
+	grpcserver "github.com/mibrahim2344/identity-service/internal/interfaces/grpc/server"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/handlers"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/router"
 	"github.com/mibrahim2344/identity-service/internal/interfaces/http/server"
 	goredis "github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
@@ -25,11 +64,34 @@ import (
 	"gorm.io/gorm"
 )
 
-func main() {
-	// Force unbuffered output
-	os.Stdout.Sync()
+// Startup phases reported on /readyz
+const (
+	phaseConfig   = "config"
+	phaseDatabase = "database"
+	phaseRedis    = "redis"
+	phaseEvents   = "events"
+	phaseServices = "services"
+	phaseRoutes   = "routes"
+	// phaseShutdown is only registered once shutdown begins, failing
+	// readiness so that load balancers stop sending requests
+	phaseShutdown = "shutdown"
+)
+
+const (
+	// redisPoolStatsInterval is how often Redis connection pool statistics are exported
+	redisPoolStatsInterval = 15 * time.Second
+	// kafkaConsumerLagInterval is how often Kafka consumer lag is exported
+	kafkaConsumerLagInterval = 15 * time.Second
+	// searchIndexRetryInterval is how often creating the search index is retried
+	searchIndexRetryInterval = 10 * time.Second
+	// defaultShutdownTimeout bounds shutdown when none is configured
+	defaultShutdownTimeout = 15 * time.Second
+)
 
-	fmt.Println("Starting identity service...")
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
 
 	// Swagger docs info
 	docs.SwaggerInfo.Title = "Identity Service API"
@@ -44,7 +106,6 @@ func main() {
 	defer cancel()
 
 	// Initialize logger
-	fmt.Println("Initializing logger...")
 	logger, err := zap.NewDevelopment()
 	if err != nil {
 		log.Fatalf("failed to create logger: %v", err)
@@ -54,62 +115,266 @@ func main() {
 			logger.Error("failed to sync logger", zap.Error(err))
 		}
 	}()
+	logger.Info("starting identity service")
 
-	fmt.Println("Logger initialized successfully")
+	// Components are stopped in the reverse order they are registered in
+	shutdown := lifecycle.NewShutdown(logger)
+	// Background jobs run until ctx is cancelled; shutdown waits for them
+	background := &lifecycle.Background{}
+
+	// Every dependency is registered up front so /readyz lists what is still pending
+	tracker := lifecycle.NewTracker(logger,
+		phaseConfig,
+		phaseDatabase,
+		phaseRedis,
+		phaseEvents,
+		phaseServices,
+		phaseRoutes,
+	)
 
 	// Load configuration
-	fmt.Println("Loading configuration...")
+	tracker.Begin(phaseConfig)
 	cfg, err := config.LoadConfig("config/default.json")
 	if err != nil {
+		tracker.Fail(phaseConfig, err)
 		logger.Fatal("failed to load config", zap.Error(err))
 	}
-	fmt.Println("Configuration loaded successfully")
+	serviceMode, err := middleware.ParseServiceMode(cfg.Server.Mode)
+	if err != nil {
+		tracker.Fail(phaseConfig, err)
+		logger.Fatal("invalid server mode", zap.Error(err))
+	}
+	trustedProxies, ipRules, routeIPRules, err := networkRules(cfg.Network)
+	if err != nil {
+		tracker.Fail(phaseConfig, err)
+		logger.Fatal("invalid network configuration", zap.Error(err))
+	}
+	if len(trustedProxies) == 0 && (len(ipRules.Allow) > 0 || len(ipRules.Deny) > 0 || len(routeIPRules) > 0) {
+		logger.Warn("IP rules are configured without trusted proxies; clients can choose their IP through forwarding headers")
+	}
+	tracker.Complete(phaseConfig)
 
-	// Initialize database connection
-	fmt.Println("Connecting to database...")
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.DBName,
-		cfg.Database.SSLMode,
+	// Export spans before anything is instrumented; the exporter is stopped
+	// last so that it flushes the spans of every other component's shutdown
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			ServiceName: cfg.Tracing.ServiceName,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+		if err != nil {
+			logger.Fatal("failed to set up tracing", zap.Error(err))
+		}
+		shutdown.Register("tracing", shutdownTracing)
+	}
+
+	// Dependencies are registered with readiness checks as they connect
+	health := lifecycle.NewHealth(lifecycle.HealthConfig{
+		Timeout:  time.Duration(cfg.Health.CheckTimeoutMs) * time.Millisecond,
+		Interval: time.Duration(cfg.Health.CheckIntervalSeconds) * time.Second,
+	}, logger)
+
+	// Start the HTTP server early so probes can observe the remaining phases
+	httpServer := server.NewServer(
+		server.Config{
+			Host:           cfg.Server.Host,
+			Port:           cfg.Server.Port,
+			ReadTimeout:    10 * time.Second, // default timeout
+			WriteTimeout:   10 * time.Second, // default timeout
+			MaxHeaderBytes: 1 << 20,          // default 1MB
+			Router: router.Config{
+				CORS: middleware.CORSConfig{
+					AllowedOrigins:    cfg.CORS.AllowedOrigins,
+					AllowedMethods:    cfg.CORS.AllowedMethods,
+					AllowedHeaders:    cfg.CORS.AllowedHeaders,
+					CredentialOrigins: cfg.CORS.CredentialOrigins,
+					MaxAge:            time.Duration(cfg.CORS.MaxAgeSeconds) * time.Second,
+				},
+				VerifyEmailRedirect: handlers.VerifyEmailRedirectConfig{
+					SuccessURL:   cfg.WebApp.VerifyEmailSuccessURL,
+					FailureURL:   cfg.WebApp.VerifyEmailFailureURL,
+					AllowedHosts: cfg.WebApp.AllowedRedirectHosts,
+				},
+				Mode:               serviceMode,
+				MaintenanceMessage: cfg.Server.MaintenanceMessage,
+				Cookies: handlers.CookieConfig{
+					Enabled:  cfg.Cookies.Enabled,
+					Domain:   cfg.Cookies.Domain,
+					Path:     cfg.Cookies.Path,
+					Secure:   cfg.Cookies.Secure,
+					SameSite: handlers.ParseSameSite(cfg.Cookies.SameSite),
+				},
+				OAuthLoginURL: cfg.OIDC.LoginURL,
+				SocialLogin: handlers.SocialLoginConfig{
+					SuccessURL: cfg.Federation.SuccessURL,
+					FailureURL: cfg.Federation.FailureURL,
+				},
+				TrustedProxies:              trustedProxies,
+				IPRules:                     ipRules,
+				RouteIPRules:                routeIPRules,
+				CountryHeader:               cfg.Network.CountryHeader,
+				APIKeyRoutes:                cfg.APIKeys.Routes,
+				Tracing:                     cfg.Tracing.Enabled,
+				OpenMetrics:                 cfg.Tracing.Enabled,
+				PublicProfileRateLimit:      cfg.PublicProfile.RequestsPerMinute,
+				SoftRateLimit:               cfg.RateLimits.SoftRequestsPerMinute,
+				RuntimeConfig:               cfg.Redacted(),
+				AdminUI:                     cfg.AdminUI.Enabled,
+				VerificationResendRateLimit: cfg.Account.VerificationResendsPerHour,
+				PublicProfileMaxAge:         time.Duration(cfg.PublicProfile.CacheSeconds) * time.Second,
+				Avatars: handlers.AvatarConfig{
+					Gravatar:     cfg.Avatars.Gravatar,
+					DefaultImage: cfg.Avatars.GravatarDefault,
+					Size:         cfg.Avatars.GravatarSizePixels,
+				},
+			},
+		},
+		tracker,
+		health,
+		logger,
 	)
+	errChan := make(chan error, 1)
+	go func() {
+		if err := httpServer.Start(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Server failed to start", zap.Error(err))
+			errChan <- err
+		}
+	}()
+
+	var metricsOptions []metrics.Option
+	if cfg.Tracing.Enabled {
+		metricsOptions = append(metricsOptions, metrics.WithTraceExemplars())
+	}
+	metricsCollector := metrics.NewMetricsService(metricsOptions...)
+
+	// Development and demo deployments run without external stores
+	if cfg.InMemoryStorage() {
+		runInMemory(ctx, cancel, cfg, httpServer, errChan, shutdown, background, tracker, metricsCollector, logger)
+		return
+	}
+
+	// Initialize database connection
+	tracker.Begin(phaseDatabase)
+	if err := models.SetIDVersion(cfg.PrimaryKeys.UUIDVersion); err != nil {
+		logger.Fatal("invalid primary key UUID version", zap.Error(err))
+	}
 	db, err := gorm.Open(pgdriver.New(pgdriver.Config{
-		DSN:                  dsn,
+		DSN:                  cfg.DatabaseDSN(),
 		PreferSimpleProtocol: true,
 	}), &gorm.Config{})
 	if err != nil {
+		tracker.Fail(phaseDatabase, err)
 		logger.Fatal("failed to connect to database", zap.Error(err))
 	}
-	fmt.Println("Database connection established successfully")
 
-	// Get underlying SQL DB
-	fmt.Println("Getting underlying SQL DB...")
+	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
+		tracker.Fail(phaseDatabase, err)
 		logger.Fatal("failed to get underlying sql.DB", zap.Error(err))
 	}
-	fmt.Println("Underlying SQL DB retrieved successfully")
-
-	// Configure connection pool
-	fmt.Println("Configuring connection pool...")
 	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
 	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
 	sqlDB.SetConnMaxLifetime(time.Duration(cfg.Database.ConnMaxLifetimeMinutes) * time.Minute)
-	fmt.Println("Connection pool configured successfully")
-
-	// Initialize Redis client
-	fmt.Println("Initializing Redis client...")
-	redisClient := goredis.NewClient(&goredis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
-		Password: cfg.Redis.Password,
-		DB:       0,
-	})
-	fmt.Println("Redis client initialized successfully")
+	shutdown.RegisterCloser("database", sqlDB.Close)
+	health.Register("postgres", sqlDB.PingContext)
+
+	// Record query metrics and log slow queries
+	if err := db.Use(postgres.NewQueryInstrumentation(
+		postgres.InstrumentationConfig{
+			SlowQueryThreshold:  time.Duration(cfg.SlowQueryLog.ThresholdMs) * time.Millisecond,
+			ErrorQueryThreshold: time.Duration(cfg.SlowQueryLog.ErrorThresholdMs) * time.Millisecond,
+			MethodThresholds:    millisecondThresholds(cfg.SlowQueryLog.MethodThresholdsMs),
+		},
+		metricsCollector,
+		logger,
+	)); err != nil {
+		tracker.Fail(phaseDatabase, err)
+		logger.Fatal("failed to instrument database", zap.Error(err))
+	}
+
+	if cfg.Tracing.Enabled {
+		if err := db.Use(postgres.NewQueryTracing()); err != nil {
+			tracker.Fail(phaseDatabase, err)
+			logger.Fatal("failed to trace database queries", zap.Error(err))
+		}
+	}
+
+	// Bound query durations per operation class
+	if err := db.Use(postgres.NewQueryTimeouts(postgres.TimeoutConfig{
+		Read:  time.Duration(cfg.Timeouts.DatabaseReadMs) * time.Millisecond,
+		Write: time.Duration(cfg.Timeouts.DatabaseWriteMs) * time.Millisecond,
+	})); err != nil {
+		tracker.Fail(phaseDatabase, err)
+		logger.Fatal("failed to configure query timeouts", zap.Error(err))
+	}
+
+	// Serve user lookups and listings from read replicas
+	if len(cfg.Database.Replicas) > 0 {
+		replicas := make([]gorm.ConnPool, 0, len(cfg.Database.Replicas))
+		for _, replica := range cfg.Database.Replicas {
+			replicaDB, err := openDatabasePool(cfg.ReplicaDSN(replica), cfg)
+			if err != nil {
+				tracker.Fail(phaseDatabase, err)
+				logger.Fatal("failed to connect to database replica", zap.String("host", replica.Host), zap.Error(err))
+			}
+			shutdown.RegisterCloser("database replica "+replica.Host, replicaDB.Close)
+			replicas = append(replicas, replicaDB)
+		}
+		staleness := time.Duration(cfg.Database.ReplicaStalenessMs) * time.Millisecond
+		if err := db.Use(postgres.NewReplicaRouting(replicas, staleness)); err != nil {
+			tracker.Fail(phaseDatabase, err)
+			logger.Fatal("failed to configure replica routing", zap.Error(err))
+		}
+		logger.Info("read replicas enabled", zap.Int("replicas", len(replicas)))
+	}
+
+	// Read data homed in other regions from their clusters
+	if cfg.Residency.Region != "" {
+		clusters := make(map[string]gorm.ConnPool, len(cfg.Residency.Clusters))
+		for region, cluster := range cfg.Residency.Clusters {
+			clusterDB, err := openDatabasePool(cluster.DSN(), cfg)
+			if err != nil {
+				tracker.Fail(phaseDatabase, err)
+				logger.Fatal("failed to connect to region cluster", zap.String("region", region), zap.Error(err))
+			}
+			shutdown.RegisterCloser("database "+region, clusterDB.Close)
+			clusters[region] = clusterDB
+		}
+		if err := db.Use(postgres.NewRegionRouting(cfg.Residency.Region, clusters)); err != nil {
+			tracker.Fail(phaseDatabase, err)
+			logger.Fatal("failed to configure region routing", zap.Error(err))
+		}
+		logger.Info("data residency enabled",
+			zap.String("region", cfg.Residency.Region),
+			zap.Int("clusters", len(clusters)))
+	}
+	tracker.Complete(phaseDatabase)
 
-	// Initialize cache service with config
-	fmt.Println("Initializing cache service...")
+	// Initialize Redis client and cache service
+	tracker.Begin(phaseRedis)
+	redisOptions, err := redis.NewOptions(cfg.Redis.ClientConfig())
+	if err != nil {
+		tracker.Fail(phaseRedis, err)
+		logger.Fatal("failed to configure Redis client", zap.Error(err))
+	}
+	redisClient := goredis.NewClient(redisOptions)
+	redisClient.AddHook(redis.NewCommandInstrumentation(metricsCollector))
+	if cfg.Tracing.Enabled {
+		redisClient.AddHook(redis.NewCommandTracing())
+	}
+	if cfg.Timeouts.CacheMs > 0 {
+		redisClient.AddHook(redis.NewCommandTimeout(time.Duration(cfg.Timeouts.CacheMs) * time.Millisecond))
+	}
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		tracker.Fail(phaseRedis, err)
+		logger.Fatal("failed to connect to Redis", zap.Error(err))
+	}
+	shutdown.RegisterCloser("redis", redisClient.Close)
+	health.Register("redis", func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
 	cacheConfig := redis.NewCacheConfig(
 		cfg.Cache.DefaultTTL,
 		cfg.Cache.MaxEntries,
@@ -117,44 +382,304 @@ func main() {
 		cfg.Cache.Namespace,
 	)
 	cacheService := redis.NewCacheService(redisClient, cacheConfig)
-	fmt.Println("Cache service initialized successfully")
-
-	// Initialize Kafka producer
-	fmt.Println("Initializing Kafka producer...")
-	kafkaProducer := kafka.NewPublisher(cfg.Kafka.Brokers)
-	defer kafkaProducer.Close()
-	fmt.Println("Kafka producer initialized successfully")
+	background.Go(func() { redis.NewPoolStatsCollector(redisClient, metricsCollector).Start(ctx, redisPoolStatsInterval) })
+	tracker.Complete(phaseRedis)
 
-	// Initialize metrics collector
-	fmt.Println("Initializing metrics collector...")
-	metricsCollector := metrics.NewMetricsService()
-	fmt.Println("Metrics collector initialized successfully")
+	// Initialize the event publisher of the configured broker
+	tracker.Begin(phaseEvents)
+	var eventPublisher domainservices.EventPublisher
+	switch cfg.EventsDriver() {
+	case application.EventsDriverNATS:
+		natsPublisher, err := natspub.NewPublisher(ctx, cfg.Events.NATS.PublisherConfig(), natspub.WithMetrics(metricsCollector))
+		if err != nil {
+			tracker.Fail(phaseEvents, err)
+			logger.Fatal("failed to create NATS publisher", zap.Error(err))
+		}
+		// Draining the connection delivers the messages it still buffers
+		shutdown.RegisterCloser("nats publisher", natsPublisher.Close)
+		health.Register("nats", natsPublisher.Ping)
+		eventPublisher = natsPublisher
+		logger.Info("publishing events to NATS", zap.String("stream", cfg.Events.NATS.Stream))
+	case application.EventsDriverRabbitMQ:
+		rabbitPublisher, err := rabbitmq.NewPublisher(cfg.Events.RabbitMQ.PublisherConfig(), rabbitmq.WithMetrics(metricsCollector))
+		if err != nil {
+			tracker.Fail(phaseEvents, err)
+			logger.Fatal("failed to create RabbitMQ publisher", zap.Error(err))
+		}
+		shutdown.RegisterCloser("rabbitmq publisher", rabbitPublisher.Close)
+		health.Register("rabbitmq", rabbitPublisher.Ping)
+		eventPublisher = rabbitPublisher
+		logger.Info("publishing events to RabbitMQ", zap.String("exchange", cfg.Events.RabbitMQ.Exchange))
+	default:
+		publisherOptions := []kafka.PublisherOption{
+			kafka.WithMetrics(metricsCollector),
+			kafka.WithTenantRouting(cfg.Kafka.TenantRoutes(), application.UserOrganizations(postgres.NewRepository(db))),
+		}
+		if cfg.Degradation.Kafka.SpoolEnabled() {
+			spool, err := kafka.NewSpool(cfg.Degradation.Kafka.SpoolDir)
+			if err != nil {
+				tracker.Fail(phaseEvents, err)
+				logger.Fatal("failed to create event spool", zap.Error(err))
+			}
+			publisherOptions = append(publisherOptions, kafka.WithSpool(spool))
+		}
+		if cfg.Kafka.AvroEnabled() {
+			registry, err := kafka.NewSchemaRegistry(cfg.Kafka.SchemaRegistry.RegistryConfig())
+			if err != nil {
+				tracker.Fail(phaseEvents, err)
+				logger.Fatal("failed to create schema registry client", zap.Error(err))
+			}
+			// An incompatible schema fails the startup rather than the first publish
+			serializer, err := kafka.NewAvroSerializer(ctx, registry, logger)
+			if err != nil {
+				tracker.Fail(phaseEvents, err)
+				logger.Fatal("failed to register avro event schemas", zap.Error(err))
+			}
+			publisherOptions = append(publisherOptions, kafka.WithAvro(serializer))
+		}
+		kafkaProducer, err := kafka.NewPublisher(cfg.Kafka.PublisherConfig(), publisherOptions...)
+		if err != nil {
+			tracker.Fail(phaseEvents, err)
+			logger.Fatal("failed to create Kafka producer", zap.Error(err))
+		}
+		// Closing the writer delivers the events it still buffers
+		shutdown.RegisterCloser("kafka producer", kafkaProducer.Close)
+		// Events are spooled while Kafka is down when a spool is configured
+		if cfg.Degradation.Kafka.SpoolEnabled() {
+			health.RegisterOptional("kafka", kafkaProducer.Ping)
+		} else {
+			health.Register("kafka", kafkaProducer.Ping)
+		}
+		if cfg.Degradation.Kafka.SpoolEnabled() {
+			interval := time.Duration(cfg.Degradation.Kafka.ReplayIntervalSeconds) * time.Second
+			if interval == 0 {
+				interval = 30 * time.Second
+			}
+			background.Go(func() { kafkaProducer.StartSpoolReplay(ctx, interval, logger) })
+			logger.Info("event spool enabled",
+				zap.String("dir", cfg.Degradation.Kafka.SpoolDir),
+				zap.Duration("replayInterval", interval))
+		}
+		eventPublisher = kafkaProducer
+	}
+	tracker.Complete(phaseEvents)
 
-	// Initialize user repository
-	fmt.Println("Initializing user repository...")
+	// Initialize repositories, infrastructure and application services
+	tracker.Begin(phaseServices)
 	userRepo := postgres.NewRepository(db)
-	fmt.Println("User repository initialized successfully")
-
-	// Initialize infrastructure services
-	fmt.Println("Initializing infrastructure services...")
+	securityActivityRepo := postgres.NewSecurityActivityRepository(db)
+	tokenConfig := cfg.TokenConfig()
 	services := infraservices.NewServices(
-		db,                  // *gorm.DB
-		cacheService,        // services.CacheService
-		kafkaProducer,       // services.EventPublisher
-		metricsCollector,    // MetricsCollector
-		userRepo,            // repositories.UserRepository
-		cfg.Auth.SigningKey, // tokenSecret string
-		time.Duration(cfg.Auth.AccessTokenDuration)*time.Second,  // accessTokenExpiry time.Duration
-		time.Duration(cfg.Auth.RefreshTokenDuration)*time.Second, // refreshTokenExpiry time.Duration
+		db,               // *gorm.DB
+		cacheService,     // services.CacheService
+		eventPublisher,   // services.EventPublisher
+		metricsCollector, // MetricsCollector
+		userRepo,         // repositories.UserRepository
+		tokenConfig,
+	)
+	// Deployments add their own claims to access and ID tokens
+	claimsEnrichers := cfg.ClaimsEnrichers(userRepo, postgres.NewOrganizationRepository(db))
+	if len(claimsEnrichers) > 0 {
+		services.Token = infraservices.NewTokenService(tokenConfig, cacheService, infraservices.WithTokenClaimsEnrichers(claimsEnrichers...))
+	}
+	// RS256 and ES256 sign with generated key pairs stored in the database
+	// instead of the static secret; their public keys are served as JWKS.
+	// Replicas load the keys before serving and reload them on rotation.
+	if cfg.SigningKeys.UsesAsymmetricAlgorithm() {
+		keyManager := token.NewDistributedKeyManager(postgres.NewSigningKeyRepository(db), redis.NewSigningKeyNotifier(redisClient), cacheService, domainservices.SystemClock)
+		if err := keyManager.Sync(ctx); err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to load signing keys", zap.Error(err))
+		}
+		background.Go(func() { keyManager.Watch(ctx, time.Duration(cfg.SigningKeys.SyncIntervalSeconds)*time.Second, logger) })
+		services.Token = token.NewService(tokenConfig, cacheService, keyManager,
+			token.WithClaimsEnrichers(claimsEnrichers...))
+		logger.Info("signing tokens with managed asymmetric keys")
+	}
+	passwordHasher, err := cfg.PasswordHashing.Hasher(cfg.Auth.HashingCost)
+	if err != nil {
+		tracker.Fail(phaseServices, err)
+		logger.Fatal("failed to configure password hashing", zap.Error(err))
+	}
+	services.Password = infraservices.NewPasswordService(passwordHasher)
+	// Suppress marketing-relevant events about users who opted out of marketing
+	if len(cfg.Consent.MarketingEvents) > 0 {
+		services.EventPublisher = consent.NewEventPublisher(services.EventPublisher, userRepo, cfg.Consent.MarketingEvents, logger)
+	}
+	// Record every published event in the tamper-evident audit log
+	var auditLog *audit.Log
+	var auditLogService domainservices.AuditLogService
+	if cfg.AuditLog.Enabled {
+		auditLog = audit.NewLog(postgres.NewAuditLogRepository(db), logger)
+		auditLogService = auditLog
+		// Append in the background, so requests do not wait for the chain lock
+		auditWriter := audit.NewWriter(auditLog, cfg.AuditLog.BufferSize, logger)
+		background.Go(func() { auditWriter.Run(ctx) })
+		services.EventPublisher = audit.NewEventPublisher(services.EventPublisher, auditWriter, logger)
+		logger.Info("audit log enabled")
+	}
+	// Publish an audit event for every signing key rotation
+	tokenService := audit.NewTokenService(services.Token, services.EventPublisher, logger)
+
+	// Organizations may override the password policy and token lifetimes
+	tenantSettings := tenant.NewService(
+		postgres.NewOrganizationSettingsRepository(db),
+		cacheService,
+		time.Duration(cfg.Tenants.SettingsCacheSeconds)*time.Second,
+		logger,
 	)
-	fmt.Println("Infrastructure services initialized successfully")
 
-	// Initialize user application service
-	fmt.Println("Initializing user application service...")
+	// Roles grant permissions, which access tokens carry and admin routes require
+	roleService := role.NewService(postgres.NewRoleRepository(db), userRepo, tokenService, cacheService, services.EventPublisher, logger)
+
+	// Members of organizations manage them according to their organization roles.
+	// Organizations prove they own email domains with DNS records.
+	organizationRepo := postgres.NewOrganizationRepository(db)
+	organizationService := organization.NewService(organizationRepo, userRepo, tokenService, net.DefaultResolver, services.EventPublisher, logger)
+
+	// Policies require second factors of the users they cover from a date on
+	mfaPolicies := mfa.NewPolicyService(postgres.NewMFAPolicyRepository(db), tenantSettings, cacheService, logger)
+
+	captchaVerifier, err := cfg.CaptchaVerifier()
+	if err != nil {
+		tracker.Fail(phaseServices, err)
+		logger.Fatal("failed to create captcha verifier", zap.Error(err))
+	}
+	userOptions := append(userPolicyOptions(cfg, captchaVerifier, logger),
+		user.WithUsernameHistory(postgres.NewUsernameHistoryRepository(db), user.UsernamePolicy{
+			ChangeCooldown:    time.Duration(cfg.Account.UsernameChangeCooldownDays) * 24 * time.Hour,
+			ReservationPeriod: time.Duration(cfg.Account.UsernameReservationDays) * 24 * time.Hour,
+		}),
+		user.WithRoles(roleService),
+		user.WithEmailVerification(postgres.NewEmailVerificationRepository(db), cfg.Account.MaxVerificationEmailsPerDay),
+		user.WithSecurityActivity(securityActivityRepo),
+		user.WithLoginAnomalyDetection(user.LoginAnomalyPolicy{
+			Enabled:             cfg.LoginAnomalies.Enabled,
+			RequireConfirmation: cfg.LoginAnomalies.RequireConfirmation,
+			ConfirmationTTL:     time.Duration(cfg.LoginAnomalies.ConfirmationTTLMinutes) * time.Minute,
+		}),
+		user.WithTenantSettings(tenantSettings),
+		user.WithExternalIdentities(postgres.NewUserIdentityRepository(db)),
+		user.WithOrganizations(organizationRepo),
+		user.WithMFA(postgres.NewTOTPCredentialRepository(db), totp.NewService(cfg.MFA.Issuer), mfaPolicies),
+		user.WithSessionLimit(redis.NewSessionRepository(redisClient, domainservices.SystemClock), user.SessionLimitPolicy{
+			MaxSessions: cfg.Sessions.MaxConcurrent,
+			Action:      models.SessionLimitAction(cfg.Sessions.OnLimit),
+		}),
+	)
+
+	// Sign in with the configured external identity providers
+	var federation domainservices.FederationService
+	if len(cfg.Federation.Providers) > 0 {
+		socialLogin, err := oauthclient.NewFederation(cfg.Federation.ProviderConfigs(), cacheService, cfg.Egress.ClientConfig(), logger)
+		if err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to configure identity providers", zap.Error(err))
+		}
+		federation = socialLogin
+		logger.Info("social login enabled", zap.Strings("providers", socialLogin.Providers()))
+	}
+
+	// Organizations sign their members in with their own identity providers
+	var ssoService domainservices.SSOService
+	if cfg.SSO.CallbackURL != "" {
+		connector, err := oauthclient.NewSSO(cfg.SSO.ConnectorConfig(), cacheService, cfg.Egress.ClientConfig(), logger)
+		if err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to configure SSO connections", zap.Error(err))
+		}
+		ssoService = sso.NewService(postgres.NewSSOConnectionRepository(db), organizationRepo, connector, net.DefaultResolver, services.EventPublisher, logger)
+		logger.Info("SSO connections enabled", zap.String("callbackURL", cfg.SSO.CallbackURL))
+	}
+
+	// Users sign in with passkeys instead of a password
+	if cfg.Passkeys.Enabled {
+		verifier, err := webauthn.NewVerifier(webauthn.Config{
+			RPID:    cfg.Passkeys.RPID,
+			RPName:  cfg.Passkeys.RPName,
+			Origins: cfg.Passkeys.Origins,
+		})
+		if err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to configure passkeys", zap.Error(err))
+		}
+		userOptions = append(userOptions, user.WithPasskeys(postgres.NewWebAuthnCredentialRepository(db), verifier))
+		logger.Info("passkeys enabled", zap.String("rpID", cfg.Passkeys.RPID))
+	}
+
+	// Users recover their accounts by answering security questions they chose
+	if cfg.KnowledgeFactors.Enabled {
+		userOptions = append(userOptions, user.WithKnowledgeFactors(
+			postgres.NewKnowledgeFactorRepository(db),
+			password.NewBCryptHasher(cfg.Auth.HashingCost),
+			user.KnowledgeFactorPolicy{
+				Questions:       cfg.KnowledgeFactors.Questions,
+				RequiredAnswers: cfg.KnowledgeFactors.RequiredAnswers,
+				MaxAttempts:     cfg.KnowledgeFactors.MaxAttempts,
+			},
+		))
+		logger.Info("security question recovery enabled", zap.Int("questions", len(cfg.KnowledgeFactors.Questions)))
+	}
+
+	// Service accounts authenticate machine-to-machine requests with API keys
+	var apiKeyService domainservices.APIKeyService
+	if cfg.APIKeys.Enabled {
+		apiKeyService = apikey.NewService(postgres.NewAPIKeyRepository(db), userRepo, services.EventPublisher, logger)
+		logger.Info("service account API keys enabled", zap.Strings("routes", cfg.APIKeys.Routes))
+	}
+
+	// Admins inspect the cache and invalidate cached entries for debugging
+	var cacheAdminService domainservices.CacheAdminService
+	if cfg.CacheAdmin.Enabled {
+		cacheAdminService = cacheadmin.NewService(
+			redis.NewCacheInspector(redisClient),
+			services.EventPublisher,
+			cfg.CacheAdmin.ScanLimit,
+			logger,
+		)
+	}
+
+	// Abuse reports restrict the tokens of flagged accounts
+	var moderationService domainservices.ModerationService
+	if cfg.Moderation.Enabled {
+		moderationService = moderation.NewService(
+			postgres.NewAccountFlagRepository(db),
+			userRepo,
+			services.EventPublisher,
+			cfg.Moderation.ReportThreshold,
+			logger,
+		)
+		userOptions = append(userOptions, user.WithModeration(moderationService))
+	}
+
+	// Sync the optional user search index from the event stream
+	if cfg.Search.Enabled {
+		searchIndex, err := search.NewClient(cfg.Search.ClientConfig(), cfg.Egress.ClientConfig())
+		if err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to create search client", zap.Error(err))
+		}
+		searchSync := jobs.NewSearchIndexSync(userRepo, searchIndex, logger)
+		consumer, err := kafka.NewConsumer(cfg.Kafka.PublisherConfig(), cfg.Search.ConsumerGroup, events.Topics(jobs.SearchSyncEventTypes), searchSync.HandleEvent, logger)
+		if err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to create search index consumer", zap.Error(err))
+		}
+		shutdown.RegisterCloser("search index consumer", consumer.Close)
+		background.Go(func() {
+			kafka.NewConsumerLagCollector(metricsCollector, consumer.Reader()).Start(ctx, kafkaConsumerLagInterval)
+		})
+		background.Go(func() { runSearchSync(ctx, background, searchIndex, searchSync, consumer, logger) })
+		userOptions = append(userOptions, user.WithSearchIndex(searchIndex))
+		logger.Info("search index sync started",
+			zap.String("index", cfg.Search.Index),
+			zap.String("consumerGroup", cfg.Search.ConsumerGroup))
+	}
+
 	userApp := user.NewService(
 		services.UserRepository,
 		services.Password,
-		services.Token,
+		tokenService,
 		services.Cache,
 		services.EventPublisher,
 		logger,
@@ -165,53 +690,480 @@ func main() {
 			cfg.Cache.Namespace,
 		),
 		cfg.WebApp.URL,
+		userOptions...,
 	)
-	fmt.Println("User application service initialized successfully")
 
-	// Initialize HTTP server
-	fmt.Println("Initializing HTTP server...")
-	httpServer := server.NewServer(
-		server.Config{
-			Host:           cfg.Server.Host,
-			Port:           cfg.Server.Port,
-			ReadTimeout:    10 * time.Second, // default timeout
-			WriteTimeout:   10 * time.Second, // default timeout
-			MaxHeaderBytes: 1 << 20,          // default 1MB
-			AllowedOrigins: []string{"*"},    // allow all origins
-			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowedHeaders: []string{"Content-Type", "Authorization"},
+	// The OAuth 2.0 / OpenID Connect provider issues tokens through the
+	// managed asymmetric keys, which config validation requires
+	var oauthApp domainservices.OAuthService
+	if cfg.OIDC.Enabled {
+		oauthApp = oauth.NewService(
+			oauth.Config{
+				Issuer:               cfg.OIDC.Issuer,
+				AuthorizationCodeTTL: time.Duration(cfg.OIDC.AuthorizationCodeTTLSeconds) * time.Second,
+				SigningAlgorithm:     tokenConfig.KeyPolicy(domainservices.TokenTypeAccess).Algorithm,
+				MaxBatchTokens:       cfg.OIDC.MaxBatchIntrospectionTokens,
+			},
+			postgres.NewOAuthClientRepository(db),
+			userRepo,
+			tokenService,
+			cacheService,
+			services.EventPublisher,
+			moderationService,
+			domainservices.SystemClock,
+			logger,
+		)
+		logger.Info("OpenID Connect provider enabled", zap.String("issuer", cfg.OIDC.Issuer))
+	}
+
+	// Start monthly security summary job; it also runs when disabled so that
+	// organizations can turn summaries on for their users
+	summaryJob := jobs.NewSecuritySummaryJob(
+		userRepo,
+		securityActivityRepo,
+		tenantSettings,
+		services.EventPublisher,
+		cacheService,
+		domainservices.SystemClock,
+		logger,
+		time.Duration(cfg.Auth.RefreshTokenDuration)*time.Second,
+		cfg.SecuritySummary.Enabled,
+	)
+	summaryInterval := time.Duration(cfg.SecuritySummary.CheckIntervalMinutes) * time.Minute
+	if summaryInterval == 0 {
+		summaryInterval = time.Hour
+	}
+	background.Go(func() { summaryJob.Start(ctx, summaryInterval) })
+	logger.Info("security summary job started",
+		zap.Bool("enabled", cfg.SecuritySummary.Enabled),
+		zap.Duration("checkInterval", summaryInterval))
+
+	// Process forgot-password requests off the request path
+	background.Go(func() { userApp.RunPasswordResetWorkers(ctx) })
+
+	// Purge the accounts of users who deleted them once the grace period ends
+	purgeJob := jobs.NewDeactivationPurgeJob(userApp, cacheService, logger)
+	purgeInterval := time.Duration(cfg.Account.DeactivationPurgeIntervalMinutes) * time.Minute
+	if purgeInterval == 0 {
+		purgeInterval = time.Hour
+	}
+	background.Go(func() { purgeJob.Start(ctx, purgeInterval) })
+	logger.Info("deactivation purge job started", zap.Duration("interval", purgeInterval))
+
+	// Start signing key rotation job
+	if cfg.SigningKeys.AutoRotate {
+		rotationJob := jobs.NewKeyRotationJob(tokenService, cacheService, logger)
+		interval := time.Duration(cfg.SigningKeys.CheckIntervalMinutes) * time.Minute
+		if interval == 0 {
+			interval = time.Hour
+		}
+		background.Go(func() { rotationJob.Start(ctx, interval) })
+		logger.Info("signing key rotation job started", zap.Duration("checkInterval", interval))
+	}
+
+	// Start audit log notarization job
+	if auditLog != nil && cfg.AuditLog.NotaryURL != "" {
+		auditNotary, err := notary.NewHTTPNotary(cfg.AuditLog.NotaryConfig(), cfg.Egress.ClientConfig())
+		if err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to create audit log notary", zap.Error(err))
+		}
+		notarizationJob := jobs.NewAuditNotarizationJob(auditLog, auditNotary, logger)
+		interval := time.Duration(cfg.AuditLog.NotarizeIntervalMinutes) * time.Minute
+		if interval == 0 {
+			interval = time.Hour
+		}
+		background.Go(func() { notarizationJob.Start(ctx, interval) })
+		logger.Info("audit log notarization job started", zap.Duration("interval", interval))
+	}
+
+	// Respond to breached credentials reported on the breach topic
+	if cfg.BreachResponse.Enabled {
+		breachResponder := jobs.NewBreachResponder(userApp, logger)
+		consumer, err := kafka.NewConsumer(cfg.Kafka.PublisherConfig(), cfg.BreachResponse.ConsumerGroup,
+			[]string{cfg.BreachResponse.Topic}, breachResponder.HandleEvent, logger)
+		if err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to create breach response consumer", zap.Error(err))
+		}
+		shutdown.RegisterCloser("breach response consumer", consumer.Close)
+		background.Go(func() {
+			kafka.NewConsumerLagCollector(metricsCollector, consumer.Reader()).Start(ctx, kafkaConsumerLagInterval)
+		})
+		background.Go(func() {
+			if err := consumer.Run(ctx); err != nil {
+				logger.Error("breach response consumer stopped", zap.Error(err))
+			}
+		})
+		logger.Info("breach response consumer started",
+			zap.String("topic", cfg.BreachResponse.Topic),
+			zap.String("consumerGroup", cfg.BreachResponse.ConsumerGroup))
+	}
+
+	// Notify the webhooks registered on accounts of their logins and
+	// security changes
+	var webhookService domainservices.NotificationWebhookService
+	if cfg.Webhooks.Enabled {
+		webhookRepo := postgres.NewNotificationWebhookRepository(db)
+		sender, err := infrawebhook.NewSender(cfg.Webhooks.SenderConfig(), cfg.Egress.ClientConfig())
+		if err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to create webhook sender", zap.Error(err))
+		}
+		topics := make([]string, 0, len(webhook.EventTypes))
+		for _, eventType := range webhook.EventTypes {
+			topics = append(topics, string(eventType))
+		}
+		notifier := jobs.NewSecurityNotifier(webhookRepo, sender, metricsCollector, logger)
+		consumer, err := kafka.NewConsumer(cfg.Kafka.PublisherConfig(), cfg.Webhooks.ConsumerGroup, topics, notifier.HandleEvent, logger)
+		if err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to create webhook consumer", zap.Error(err))
+		}
+		shutdown.RegisterCloser("webhook consumer", consumer.Close)
+		background.Go(func() {
+			kafka.NewConsumerLagCollector(metricsCollector, consumer.Reader()).Start(ctx, kafkaConsumerLagInterval)
+		})
+		background.Go(func() {
+			if err := consumer.Run(ctx); err != nil {
+				logger.Error("webhook consumer stopped", zap.Error(err))
+			}
+		})
+		webhookService = webhook.NewService(webhookRepo, userRepo, cfg.Webhooks.MaxPerUser, logger)
+		logger.Info("notification webhooks enabled", zap.String("consumerGroup", cfg.Webhooks.ConsumerGroup))
+	}
+
+	// Send welcome, verification and password reset emails over SMTP
+	if cfg.Email.Enabled {
+		sender, err := email.NewSender(cfg.Email.SenderConfig())
+		if err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to create email sender", zap.Error(err))
+		}
+		topics := make([]string, 0, len(jobs.EmailEventTemplates))
+		for eventType := range jobs.EmailEventTemplates {
+			topics = append(topics, string(eventType))
+		}
+		emailDelivery := jobs.NewEmailDelivery(userRepo, tenantSettings, sender, cacheService, metricsCollector, logger)
+		consumer, err := kafka.NewConsumer(cfg.Kafka.PublisherConfig(), cfg.Email.ConsumerGroup, topics, emailDelivery.HandleEvent, logger)
+		if err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to create email consumer", zap.Error(err))
+		}
+		shutdown.RegisterCloser("email consumer", consumer.Close)
+		background.Go(func() {
+			kafka.NewConsumerLagCollector(metricsCollector, consumer.Reader()).Start(ctx, kafkaConsumerLagInterval)
+		})
+		background.Go(func() {
+			if err := consumer.Run(ctx); err != nil {
+				logger.Error("email consumer stopped", zap.Error(err))
+			}
+		})
+		logger.Info("email delivery started",
+			zap.String("smtpHost", cfg.Email.Host),
+			zap.String("consumerGroup", cfg.Email.ConsumerGroup))
+	}
+	// A deployment without users hands out a one-time token for its setup
+	var bootstrapService domainservices.BootstrapService
+	if cfg.Bootstrap.Enabled {
+		bootstrapApp := bootstrap.NewService(userRepo, userApp, roleService, organizationService, tenantSettings, cacheService, services.EventPublisher,
+			domainservices.SystemClock, time.Duration(cfg.Bootstrap.TokenTTLMinutes)*time.Minute, logger)
+		if err := issueBootstrapToken(ctx, bootstrapApp, cfg.Bootstrap.TokenFile, logger); err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to bootstrap", zap.Error(err))
+		}
+		bootstrapService = bootstrapApp
+	}
+	// Background jobs and consumers stop before the stores they use close
+	shutdown.Register("background jobs", background.Stop(cancel))
+	tracker.Complete(phaseServices)
+
+	// Mount the API routes
+	tracker.Begin(phaseRoutes)
+	httpServer.Mount(userApp, tokenService, oauthApp, auditLogService, tenantSettings, federation, mfaPolicies, webhookService, moderationService, apiKeyService, cacheAdminService, roleService, organizationService, ssoService, bootstrapService, cacheService, services.MetricsCollector)
+	shutdown.Register("http server", httpServer.Stop)
+	// Follow service mode switches made on other replicas
+	background.Go(func() { httpServer.ModeController().Watch(ctx) })
+
+	// Serve the gRPC API on its own port once the services are ready
+	if err := startGRPCServer(cfg, userApp, tokenService, services.MetricsCollector, shutdown, errChan, logger); err != nil {
+		tracker.Fail(phaseRoutes, err)
+		logger.Fatal("failed to create gRPC server", zap.Error(err))
+	}
+	tracker.Complete(phaseRoutes)
+	logger.Info("identity service is ready")
+
+	awaitShutdown(ctx, cfg, errChan, shutdown, tracker, logger)
+}
+
+// deviceBindingPolicy converts the configured device binding roles into a policy
+// runSearchSync creates the search index once the cluster is reachable and
+// then keeps it in sync from the event stream until ctx is cancelled. The
+// instance that creates the index also fills it from the database.
+func runSearchSync(ctx context.Context, background *lifecycle.Background, index *search.Client, searchSync *jobs.SearchIndexSync, consumer *kafka.Consumer, logger *zap.Logger) {
+	for {
+		created, err := index.EnsureIndex(ctx)
+		if err == nil {
+			if created {
+				background.Go(func() {
+					indexed, err := searchSync.Reindex(ctx)
+					if err != nil {
+						logger.Error("failed to fill search index", zap.Int("indexed", indexed), zap.Error(err))
+						return
+					}
+					logger.Info("filled search index", zap.Int("indexed", indexed))
+				})
+			}
+			break
+		}
+		logger.Warn("failed to prepare search index, retrying", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(searchIndexRetryInterval):
+		}
+	}
+
+	if err := consumer.Run(ctx); err != nil {
+		logger.Error("search index sync stopped", zap.Error(err))
+	}
+}
+
+func deviceBindingPolicy(enabled bool, roles []string) user.DeviceBindingPolicy {
+	policy := user.DeviceBindingPolicy{Enabled: enabled}
+	for _, role := range roles {
+		policy.Roles = append(policy.Roles, models.Role(strings.TrimSpace(role)))
+	}
+	return policy
+}
+
+// openDatabasePool connects to another Postgres server, such as a read
+// replica or the cluster of another region, with the connection pool
+// settings of the deployment's own database
+func openDatabasePool(dsn string, cfg application.Config) (*sql.DB, error) {
+	clusterDB, err := gorm.Open(pgdriver.New(pgdriver.Config{
+		DSN:                  dsn,
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := clusterDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.Database.ConnMaxLifetimeMinutes) * time.Minute)
+	return sqlDB, nil
+}
+
+// millisecondThresholds converts per-method thresholds from milliseconds
+func millisecondThresholds(thresholds map[string]int) map[string]time.Duration {
+	durations := make(map[string]time.Duration, len(thresholds))
+	for method, ms := range thresholds {
+		durations[method] = time.Duration(ms) * time.Millisecond
+	}
+	return durations
+}
+
+// networkRules parses the trusted proxies and IP rules. Route rules are
+// ordered by name so decisions are evaluated deterministically.
+func networkRules(cfg application.NetworkConfig) ([]*net.IPNet, middleware.IPRules, []middleware.RouteIPRules, error) {
+	trustedProxies, err := middleware.ParseNetworks(cfg.TrustedProxies)
+	if err != nil {
+		return nil, middleware.IPRules{}, nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+	rules, err := ipRules(cfg.Allow, cfg.Deny)
+	if err != nil {
+		return nil, middleware.IPRules{}, nil, err
+	}
+
+	names := make([]string, 0, len(cfg.Routes))
+	for name := range cfg.Routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	routes := make([]middleware.RouteIPRules, 0, len(names))
+	for _, name := range names {
+		route := cfg.Routes[name]
+		routeRules, err := ipRules(route.Allow, route.Deny)
+		if err != nil {
+			return nil, middleware.IPRules{}, nil, fmt.Errorf("route %s: %w", name, err)
+		}
+		routes = append(routes, middleware.RouteIPRules{Name: name, PathPrefix: route.PathPrefix, IPRules: routeRules})
+	}
+	return trustedProxies, rules, routes, nil
+}
+
+func ipRules(allow, deny []string) (middleware.IPRules, error) {
+	allowed, err := middleware.ParseNetworks(allow)
+	if err != nil {
+		return middleware.IPRules{}, err
+	}
+	denied, err := middleware.ParseNetworks(deny)
+	if err != nil {
+		return middleware.IPRules{}, err
+	}
+	return middleware.IPRules{Allow: allowed, Deny: denied}, nil
+}
+
+// writeBootstrapToken writes the bootstrap token to a file only its owner
+// can read, or to stderr when no file is configured. The token is kept out
+// of the logs, which are usually shipped elsewhere.
+func writeBootstrapToken(token *bootstrap.Token, path string) error {
+	if path == "" {
+		_, err := fmt.Fprintf(os.Stderr, "Bootstrap token (expires %s): %s\n", token.ExpiresAt.Format(time.RFC3339), token.Token)
+		return err
+	}
+	return os.WriteFile(path, []byte(token.Token+"\n"), 0o600)
+}
+
+// issueBootstrapToken hands out the one-time setup token of a deployment
+// without users
+func issueBootstrapToken(ctx context.Context, bootstrapApp *bootstrap.Service, path string, logger *zap.Logger) error {
+	token, err := bootstrapApp.IssueToken(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to issue bootstrap token: %w", err)
+	}
+	if token == nil {
+		return nil
+	}
+	if err := writeBootstrapToken(token, path); err != nil {
+		return fmt.Errorf("failed to write bootstrap token: %w", err)
+	}
+	logger.Warn("bootstrap token issued",
+		zap.Time("expiresAt", token.ExpiresAt),
+		zap.String("tokenFile", path))
+	return nil
+}
+
+// userPolicyOptions returns the user service options that only depend on
+// the configuration, whichever storage backs the service
+func userPolicyOptions(cfg application.Config, captchaVerifier domainservices.CaptchaVerifier, logger *zap.Logger) []user.Option {
+	options := []user.Option{
+		user.WithMaxActiveResetTokens(cfg.Account.MaxActiveResetTokens),
+		user.WithAdminPasswordResetLimit(cfg.Account.AdminPasswordResetsPerHour),
+		user.WithPurgeApprovalWindow(time.Duration(cfg.Account.PurgeApprovalMinutes) * time.Minute),
+		user.WithDeactivationGracePeriod(time.Duration(cfg.Account.DeactivationGraceDays) * 24 * time.Hour),
+		user.WithVerifiedEmailRequired(cfg.Account.RequireVerifiedEmail),
+		user.WithPasswordResetWorkers(cfg.Account.PasswordResetWorkers, cfg.Account.PasswordResetQueueSize),
+		user.WithPasswordResetLimits(cfg.Account.PasswordResetsPerIPPerHour, cfg.Account.PasswordResetsPerAddressPerHour),
+		user.WithRegistrationRisk(cfg.RegistrationRiskScorer(), user.RegistrationRiskPolicy{
+			ChallengeScore: cfg.RegistrationRisk.ChallengeScore,
+			ReviewScore:    cfg.RegistrationRisk.ReviewScore,
+		}),
+		user.WithAccountRisk(cfg.AccountRiskPolicy(), captchaVerifier),
+		user.WithDeviceBinding(deviceBindingPolicy(cfg.DeviceBinding.Enabled, cfg.DeviceBinding.Roles)),
+		user.WithProfileClaims(user.ProfileClaimsPolicy{
+			Enabled: cfg.ProfileClaims.Enabled,
+			MaxAge:  time.Duration(cfg.ProfileClaims.MaxAgeMinutes) * time.Minute,
+		}),
+		user.WithPublicProfileCache(time.Duration(cfg.PublicProfile.CacheSeconds) * time.Second),
+		user.WithProfilePolicy(user.ProfilePolicy{
+			Fields:     cfg.Profile.Fields,
+			Required:   cfg.Profile.RequiredFields,
+			Thresholds: cfg.Profile.CompletenessThresholds,
+		}),
+	}
+
+	// Users sign in with single-use links emailed to them
+	if cfg.MagicLink.Enabled {
+		options = append(options, user.WithMagicLinks())
+		logger.Info("magic link login enabled")
+	}
+
+	// Users and service accounts mint access tokens for other internal APIs
+	if len(cfg.AudienceTokens.Audiences) > 0 {
+		audiences := make(map[string]user.AudiencePolicy, len(cfg.AudienceTokens.Audiences))
+		for name, audience := range cfg.AudienceTokens.Audiences {
+			audiences[name] = user.AudiencePolicy{
+				Scopes:   audience.Scopes,
+				Roles:    audience.Roles,
+				Lifetime: time.Duration(audience.LifetimeMinutes) * time.Minute,
+			}
+		}
+		options = append(options, user.WithAudienceTokens(audiences))
+		logger.Info("audience tokens enabled", zap.Int("audiences", len(audiences)))
+	}
+	return options
+}
+
+// startGRPCServer serves the gRPC API on its own port when it is enabled
+func startGRPCServer(
+	cfg application.Config,
+	userApp domainservices.UserService,
+	tokenService domainservices.TokenService,
+	metricsService domainservices.MetricsService,
+	shutdown *lifecycle.Shutdown,
+	errChan chan<- error,
+	logger *zap.Logger,
+) error {
+	if !cfg.GRPC.Enabled {
+		return nil
+	}
+	grpcServer, err := grpcserver.NewServer(
+		grpcserver.Config{
+			Host:         cfg.Server.Host,
+			Port:         cfg.GRPC.Port,
+			CertFile:     cfg.GRPC.CertFile,
+			KeyFile:      cfg.GRPC.KeyFile,
+			ClientCAFile: cfg.GRPC.ClientCAFile,
 		},
 		userApp,
-		services.Token,
-		services.MetricsCollector,
+		tokenService,
+		metricsService,
 		logger,
 	)
-	fmt.Println("HTTP server initialized successfully")
-
-	// Start HTTP server
-	fmt.Println("Starting HTTP server...")
-	errChan := make(chan error, 1)
+	if err != nil {
+		return err
+	}
+	if cfg.GRPC.ClientCAFile == "" {
+		logger.Warn("gRPC callers are not authenticated")
+	}
+	shutdown.Register("grpc server", grpcServer.Stop)
 	go func() {
-		if err := httpServer.Start(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Server failed to start", zap.Error(err))
+		if err := grpcServer.Start(); err != nil {
+			logger.Error("gRPC server failed", zap.Error(err))
 			errChan <- err
 		}
 	}()
+	return nil
+}
 
-	// Wait for interrupt signal or error
+// awaitShutdown blocks until a signal or server error arrives and then
+// shuts the service down gracefully: stop taking requests, stop background
+// work, deliver buffered events and close connections
+func awaitShutdown(ctx context.Context, cfg application.Config, errChan <-chan error, shutdown *lifecycle.Shutdown, tracker *lifecycle.Tracker, logger *zap.Logger) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	exitCode := 0
 	select {
 	case err := <-errChan:
 		logger.Error("Server error", zap.Error(err))
-		os.Exit(1)
+		exitCode = 1
 	case sig := <-sigChan:
 		logger.Info("Received signal", zap.String("signal", sig.String()))
 	case <-ctx.Done():
 		logger.Info("Context cancelled")
 	}
+	signal.Stop(sigChan)
 
-	fmt.Println("Server is running. Press Ctrl+C to stop.")
-	<-sigChan
+	tracker.Begin(phaseShutdown)
+	timeout := time.Duration(cfg.Server.ShutdownTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	if err := shutdown.Run(timeout); err != nil {
+		logger.Error("failed to shut down cleanly", zap.Error(err))
+		exitCode = 1
+	}
+	logger.Info("identity service stopped")
+	// Metrics are scraped rather than pushed, so the logger is the only
+	// buffer left to flush
+	_ = logger.Sync()
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
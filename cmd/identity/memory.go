@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/application"
+	"github.com/mibrahim2344/identity-service/internal/application/audit"
+	"github.com/mibrahim2344/identity-service/internal/application/bootstrap"
+	"github.com/mibrahim2344/identity-service/internal/application/consent"
+	"github.com/mibrahim2344/identity-service/internal/application/jobs"
+	"github.com/mibrahim2344/identity-service/internal/application/mfa"
+	"github.com/mibrahim2344/identity-service/internal/application/organization"
+	"github.com/mibrahim2344/identity-service/internal/application/role"
+	"github.com/mibrahim2344/identity-service/internal/application/tenant"
+	"github.com/mibrahim2344/identity-service/internal/application/user"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	domainservices "github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/auth/totp"
+	memoryevents "github.com/mibrahim2344/identity-service/internal/infrastructure/events/memory"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/lifecycle"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/memory"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/redis"
+	infraservices "github.com/mibrahim2344/identity-service/internal/infrastructure/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/server"
+	"go.uber.org/zap"
+)
+
+// runInMemory starts the service without Postgres, Redis and Kafka. Users,
+// roles, organizations and second factors are kept in process memory and
+// lost on restart; features that need the external stores are skipped.
+// It is meant for local development, demos and tests.
+func runInMemory(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	cfg application.Config,
+	httpServer *server.Server,
+	errChan chan error,
+	shutdown *lifecycle.Shutdown,
+	background *lifecycle.Background,
+	tracker *lifecycle.Tracker,
+	metricsCollector domainservices.MetricsService,
+	logger *zap.Logger,
+) {
+	logger.Warn("using in-memory storage; data is lost when the service stops")
+	if unsupported := cfg.UnsupportedInMemory(); len(unsupported) > 0 {
+		logger.Warn("features that need postgres, redis or kafka are disabled", zap.Strings("features", unsupported))
+	}
+
+	// The stores are in process, so their phases complete immediately
+	tracker.Begin(phaseDatabase)
+	if err := models.SetIDVersion(cfg.PrimaryKeys.UUIDVersion); err != nil {
+		logger.Fatal("invalid primary key UUID version", zap.Error(err))
+	}
+	store := memory.NewStore()
+	tracker.Complete(phaseDatabase)
+	tracker.Begin(phaseRedis)
+	cacheService := memory.NewCacheService()
+	tracker.Complete(phaseRedis)
+	tracker.Begin(phaseEvents)
+	var eventPublisher domainservices.EventPublisher = memoryevents.NewPublisher(0, logger)
+	tracker.Complete(phaseEvents)
+
+	// Initialize repositories and application services
+	tracker.Begin(phaseServices)
+	userRepo := memory.NewUserRepository(store)
+	organizationRepo := memory.NewOrganizationRepository(store)
+	// Suppress marketing-relevant events about users who opted out of marketing
+	if len(cfg.Consent.MarketingEvents) > 0 {
+		eventPublisher = consent.NewEventPublisher(eventPublisher, userRepo, cfg.Consent.MarketingEvents, logger)
+	}
+	tokenService := audit.NewTokenService(
+		infraservices.NewTokenService(cfg.TokenConfig(), cacheService, infraservices.WithTokenClaimsEnrichers(cfg.ClaimsEnrichers(userRepo, organizationRepo)...)),
+		eventPublisher,
+		logger,
+	)
+	passwordHasher, err := cfg.PasswordHashing.Hasher(cfg.Auth.HashingCost)
+	if err != nil {
+		tracker.Fail(phaseServices, err)
+		logger.Fatal("failed to configure password hashing", zap.Error(err))
+	}
+
+	tenantSettings := tenant.NewService(
+		memory.NewOrganizationSettingsRepository(store),
+		cacheService,
+		time.Duration(cfg.Tenants.SettingsCacheSeconds)*time.Second,
+		logger,
+	)
+	roleService := role.NewService(memory.NewRoleRepository(store), userRepo, tokenService, cacheService, eventPublisher, logger)
+	organizationService := organization.NewService(organizationRepo, userRepo, tokenService, net.DefaultResolver, eventPublisher, logger)
+	mfaPolicies := mfa.NewPolicyService(memory.NewMFAPolicyRepository(store), tenantSettings, cacheService, logger)
+
+	captchaVerifier, err := cfg.CaptchaVerifier()
+	if err != nil {
+		tracker.Fail(phaseServices, err)
+		logger.Fatal("failed to create captcha verifier", zap.Error(err))
+	}
+	userOptions := append(userPolicyOptions(cfg, captchaVerifier, logger),
+		user.WithRoles(roleService),
+		user.WithTenantSettings(tenantSettings),
+		user.WithOrganizations(organizationRepo),
+		user.WithMFA(memory.NewTOTPCredentialRepository(store), totp.NewService(cfg.MFA.Issuer), mfaPolicies),
+	)
+	userApp := user.NewService(
+		userRepo,
+		infraservices.NewPasswordService(passwordHasher),
+		tokenService,
+		cacheService,
+		eventPublisher,
+		logger,
+		redis.NewCacheConfig(
+			cfg.Cache.DefaultTTL,
+			cfg.Cache.MaxEntries,
+			cfg.Cache.Prefix,
+			cfg.Cache.Namespace,
+		),
+		cfg.WebApp.URL,
+		userOptions...,
+	)
+
+	// Process forgot-password requests off the request path
+	background.Go(func() { userApp.RunPasswordResetWorkers(ctx) })
+
+	// Purge the accounts of users who deleted them once the grace period ends
+	purgeInterval := time.Duration(cfg.Account.DeactivationPurgeIntervalMinutes) * time.Minute
+	if purgeInterval == 0 {
+		purgeInterval = time.Hour
+	}
+	background.Go(func() { jobs.NewDeactivationPurgeJob(userApp, cacheService, logger).Start(ctx, purgeInterval) })
+
+	// Start signing key rotation job
+	if cfg.SigningKeys.AutoRotate {
+		interval := time.Duration(cfg.SigningKeys.CheckIntervalMinutes) * time.Minute
+		if interval == 0 {
+			interval = time.Hour
+		}
+		background.Go(func() { jobs.NewKeyRotationJob(tokenService, cacheService, logger).Start(ctx, interval) })
+	}
+
+	// Every start is a deployment without users, so setup needs a new token
+	var bootstrapService domainservices.BootstrapService
+	if cfg.Bootstrap.Enabled {
+		bootstrapApp := bootstrap.NewService(userRepo, userApp, roleService, organizationService, tenantSettings, cacheService, eventPublisher,
+			domainservices.SystemClock, time.Duration(cfg.Bootstrap.TokenTTLMinutes)*time.Minute, logger)
+		if err := issueBootstrapToken(ctx, bootstrapApp, cfg.Bootstrap.TokenFile, logger); err != nil {
+			tracker.Fail(phaseServices, err)
+			logger.Fatal("failed to bootstrap", zap.Error(err))
+		}
+		bootstrapService = bootstrapApp
+	}
+	shutdown.Register("background jobs", background.Stop(cancel))
+	tracker.Complete(phaseServices)
+
+	// Mount the API routes; the routes of disabled features are not served
+	tracker.Begin(phaseRoutes)
+	httpServer.Mount(userApp, tokenService, nil, nil, tenantSettings, nil, mfaPolicies, nil, nil, nil, nil, roleService, organizationService, nil, bootstrapService, nil, metricsCollector)
+	shutdown.Register("http server", httpServer.Stop)
+	if err := startGRPCServer(cfg, userApp, tokenService, metricsCollector, shutdown, errChan, logger); err != nil {
+		tracker.Fail(phaseRoutes, err)
+		logger.Fatal("failed to create gRPC server", zap.Error(err))
+	}
+	tracker.Complete(phaseRoutes)
+	logger.Info("identity service is ready", zap.String("storage", application.StorageDriverMemory))
+
+	awaitShutdown(ctx, cfg, errChan, shutdown, tracker, logger)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mibrahim2344/identity-service/internal/application"
+	"github.com/mibrahim2344/identity-service/internal/application/config"
+	"go.uber.org/zap"
+)
+
+// runMigrate implements "identity migrate", which applies or rolls back the
+// schema migrations built into the binary and exits. The service never
+// migrates on its own, so deployments run it before rolling out a release.
+func runMigrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	path := flags.String("path", "", "directory of the migration files; empty uses the files built into the binary")
+	steps := flags.Int("steps", 0, "number of migrations to apply or roll back; 0 applies all, and down requires it")
+	version := flags.Int("version", 0, "version force records as applied")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: identity migrate [-path dir] [-steps n] [-version n] up|down|force|version\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create logger: %v\n", err)
+		return 1
+	}
+	cfg, err := config.LoadConfig("config/default.json")
+	if err != nil {
+		logger.Error("failed to load config", zap.Error(err))
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	factory := application.NewFactory(cfg, logger)
+	defer func() {
+		if err := factory.Close(); err != nil {
+			logger.Warn("failed to close the database connection", zap.Error(err))
+		}
+	}()
+
+	status, err := factory.Migrate(ctx, flags.Arg(0), application.MigrationOptions{
+		SourceDir: *path,
+		Steps:     *steps,
+		Version:   *version,
+	})
+	if err != nil {
+		logger.Error("migration failed", zap.Error(err))
+		return 1
+	}
+	logger.Info("migration finished", zap.Stringer("status", status))
+	return 0
+}
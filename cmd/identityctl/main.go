@@ -0,0 +1,429 @@
+// Command identityctl manages an identity service deployment from the command
+// line. It connects to the service's database, Redis and Kafka with the
+// service's configuration, so that operators do not need hand-written SQL.
+//
+// Usage:
+//
+//	identityctl [-config path] <command> [flags]
+//
+// Passwords are read from the first line of standard input so that they do
+// not end up in the shell history; pipe them in from a secret store.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/application"
+	"github.com/mibrahim2344/identity-service/internal/application/config"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// exportBatchSize is the number of users read from the database at a time
+const exportBatchSize = 500
+
+// command is a subcommand of identityctl
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, factory *application.Factory, cfg application.Config, args []string) error
+}
+
+var commands = []command{
+	{"create-admin", "create an admin user", createAdmin},
+	{"reset-password", "set a user's password and revoke their sessions", resetPassword},
+	{"revoke-sessions", "revoke every session of a user", revokeSessions},
+	{"migrate", "apply or roll back database migrations", runMigrations},
+	{"rotate-keys", "rotate signing keys", rotateKeys},
+	{"export-users", "write every user to standard output", exportUsers},
+}
+
+func main() {
+	flag.Usage = usage
+	configPath := flag.String("config", "config/default.json", "path of the service configuration")
+	verbose := flag.Bool("v", false, "log at debug level")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == flag.Arg(0) {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "identityctl: unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	logConfig := zap.NewDevelopmentConfig()
+	logConfig.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	if *verbose {
+		logConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+	}
+	logger, err := logConfig.Build()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "identityctl: failed to create logger: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "identityctl: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	factory := application.NewFactory(cfg, logger)
+	err = cmd.run(ctx, factory, cfg, flag.Args()[1:])
+	if closeErr := factory.Close(); closeErr != nil {
+		logger.Warn("failed to close connections", zap.Error(closeErr))
+	}
+	stop()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "identityctl %s: %v\n", cmd.name, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: identityctl [-config path] <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun identityctl <command> -h for the flags of a command.\n\nGlobal flags:\n")
+	flag.PrintDefaults()
+}
+
+// newFlagSet creates the flag set of a command
+func newFlagSet(name, arguments string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: identityctl %s %s\n", name, arguments)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// createAdmin registers a user and gives them the admin role. The user is
+// sent the usual verification email.
+func createAdmin(ctx context.Context, factory *application.Factory, _ application.Config, args []string) error {
+	flags := newFlagSet("create-admin", "-email address -username name < password")
+	email := flags.String("email", "", "email address of the admin")
+	username := flags.String("username", "", "username of the admin")
+	firstName := flags.String("first-name", "", "first name of the admin")
+	lastName := flags.String("last-name", "", "last name of the admin")
+	flags.Parse(args)
+	if *email == "" || *username == "" {
+		flags.Usage()
+		return errors.New("email and username are required")
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	userService, err := factory.CreateUserService()
+	if err != nil {
+		return err
+	}
+	roleService, err := factory.CreateRoleService()
+	if err != nil {
+		return err
+	}
+
+	user, err := userService.RegisterUser(ctx, services.RegisterUserInput{
+		Email:     *email,
+		Username:  *username,
+		Password:  password,
+		FirstName: *firstName,
+		LastName:  *lastName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register user: %w", err)
+	}
+	// No admin assigns the role, so the change is attributed to the nil ID
+	if _, err := roleService.AssignRole(ctx, user.ID, models.RoleAdmin, uuid.Nil); err != nil {
+		return fmt.Errorf("registered user %s but failed to make them an admin: %w", user.ID, err)
+	}
+
+	fmt.Println(user.ID)
+	return nil
+}
+
+// resetPassword sets a user's password
+func resetPassword(ctx context.Context, factory *application.Factory, _ application.Config, args []string) error {
+	flags := newFlagSet("reset-password", "-user id|email|username < password")
+	identifier := flags.String("user", "", "ID, email or username of the user")
+	flags.Parse(args)
+	if *identifier == "" {
+		flags.Usage()
+		return errors.New("user is required")
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	user, err := findUser(ctx, factory, *identifier)
+	if err != nil {
+		return err
+	}
+	userService, err := factory.CreateUserService()
+	if err != nil {
+		return err
+	}
+	if err := userService.SetPassword(ctx, user.ID, password); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "set the password of %s and revoked their sessions\n", user.ID)
+	return nil
+}
+
+// revokeSessions revokes every session of a user
+func revokeSessions(ctx context.Context, factory *application.Factory, _ application.Config, args []string) error {
+	flags := newFlagSet("revoke-sessions", "-user id|email|username")
+	identifier := flags.String("user", "", "ID, email or username of the user")
+	flags.Parse(args)
+	if *identifier == "" {
+		flags.Usage()
+		return errors.New("user is required")
+	}
+
+	user, err := findUser(ctx, factory, *identifier)
+	if err != nil {
+		return err
+	}
+	userService, err := factory.CreateUserService()
+	if err != nil {
+		return err
+	}
+	if err := userService.RevokeSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "revoked the sessions of %s\n", user.ID)
+	return nil
+}
+
+// runMigrations applies or rolls back migrations. Versions are tracked like
+// the migrate service of docker-compose.yml does, so both can be used.
+func runMigrations(ctx context.Context, factory *application.Factory, _ application.Config, args []string) error {
+	flags := newFlagSet("migrate", "[-path dir] [-steps n] [-version n] up|down|force|version")
+	path := flags.String("path", "", "directory of the migration files; empty uses the files built into identityctl")
+	steps := flags.Int("steps", 0, "number of migrations to apply or roll back; 0 applies all, and down requires it")
+	version := flags.Int("version", 0, "version force records as applied")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected up, down, force or version")
+	}
+
+	status, err := factory.Migrate(ctx, flags.Arg(0), application.MigrationOptions{
+		SourceDir: *path,
+		Steps:     *steps,
+		Version:   *version,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(status)
+	return nil
+}
+
+// rotateKeys rotates the signing keys of the given token types, by default
+// of every type signed with a managed asymmetric key
+func rotateKeys(ctx context.Context, factory *application.Factory, cfg application.Config, args []string) error {
+	flags := newFlagSet("rotate-keys", "[-type access,refresh,...]")
+	types := flags.String("type", "", "comma-separated token types; empty rotates every asymmetric key")
+	flags.Parse(args)
+
+	tokenConfig := cfg.TokenConfig()
+	var tokenTypes []services.TokenType
+	if *types == "" {
+		for _, tokenType := range services.SigningKeyTokenTypes {
+			if services.IsAsymmetricAlgorithm(tokenConfig.KeyPolicy(tokenType).Algorithm) {
+				tokenTypes = append(tokenTypes, tokenType)
+			}
+		}
+		if len(tokenTypes) == 0 {
+			return services.ErrKeyRotationUnsupported
+		}
+	} else {
+		for _, name := range strings.Split(*types, ",") {
+			tokenType := services.TokenType(strings.TrimSpace(name))
+			if !isSigningKeyTokenType(tokenType) {
+				return fmt.Errorf("unknown token type %q", tokenType)
+			}
+			tokenTypes = append(tokenTypes, tokenType)
+		}
+	}
+
+	tokenService, err := factory.CreateTokenService()
+	if err != nil {
+		return err
+	}
+	for _, tokenType := range tokenTypes {
+		key, err := tokenService.RotateSigningKey(ctx, tokenType)
+		if err != nil {
+			return fmt.Errorf("failed to rotate the %s key: %w", tokenType, err)
+		}
+		fmt.Printf("%s\t%s\t%s\n", key.TokenType, key.Algorithm, key.KeyID)
+	}
+	return nil
+}
+
+// exportUsers writes every user, including service accounts, to standard
+// output. Password hashes are never exported.
+func exportUsers(ctx context.Context, factory *application.Factory, _ application.Config, args []string) error {
+	flags := newFlagSet("export-users", "[-format jsonl|csv]")
+	format := flags.String("format", "jsonl", "output format: jsonl, one JSON object per line, or csv")
+	flags.Parse(args)
+
+	var write func(*models.User) error
+	var flush func() error
+	out := bufio.NewWriter(os.Stdout)
+	switch *format {
+	case "jsonl":
+		encoder := json.NewEncoder(out)
+		write = func(user *models.User) error { return encoder.Encode(user) }
+		flush = out.Flush
+	case "csv":
+		writer := csv.NewWriter(out)
+		if err := writer.Write(csvHeader); err != nil {
+			return err
+		}
+		write = func(user *models.User) error { return writer.Write(csvRecord(user)) }
+		flush = func() error {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+			return out.Flush()
+		}
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	userRepo, err := factory.CreateUserRepository()
+	if err != nil {
+		return err
+	}
+	exported := 0
+	for offset := 0; ; offset += exportBatchSize {
+		users, err := userRepo.List(ctx, offset, exportBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list users: %w", err)
+		}
+		for _, user := range users {
+			if err := write(user); err != nil {
+				return err
+			}
+		}
+		exported += len(users)
+		if len(users) < exportBatchSize {
+			break
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d users\n", exported)
+	return nil
+}
+
+var csvHeader = []string{
+	"id", "email", "username", "status", "role", "email_verified", "first_name", "last_name",
+	"organization_id", "service_account", "created_at", "last_login_at",
+}
+
+func csvRecord(user *models.User) []string {
+	organizationID := ""
+	if user.OrganizationID != nil {
+		organizationID = user.OrganizationID.String()
+	}
+	lastLoginAt := ""
+	if user.LastLoginAt != nil {
+		lastLoginAt = user.LastLoginAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		user.ID.String(),
+		user.Email,
+		user.Username,
+		string(user.Status),
+		string(user.Role),
+		strconv.FormatBool(user.EmailVerified),
+		user.FirstName,
+		user.LastName,
+		organizationID,
+		strconv.FormatBool(user.ServiceAccount),
+		user.CreatedAt.UTC().Format(time.RFC3339),
+		lastLoginAt,
+	}
+}
+
+// findUser looks a user up by ID, email or username
+func findUser(ctx context.Context, factory *application.Factory, identifier string) (*models.User, error) {
+	userRepo, err := factory.CreateUserRepository()
+	if err != nil {
+		return nil, err
+	}
+	var user *models.User
+	if id, parseErr := uuid.Parse(identifier); parseErr == nil {
+		user, err = userRepo.GetByID(ctx, id)
+	} else {
+		user, err = userRepo.GetByIdentifier(ctx, identifier)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("user %q not found: %w", identifier, err)
+	}
+	return user, nil
+}
+
+// readPassword reads a password from the first line of standard input
+func readPassword() (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password (echoed): ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password is required on standard input")
+	}
+	return password, nil
+}
+
+// isSigningKeyTokenType reports whether tokenType has its own signing key
+func isSigningKeyTokenType(tokenType services.TokenType) bool {
+	for _, t := range services.SigningKeyTokenTypes {
+		if t == tokenType {
+			return true
+		}
+	}
+	return false
+}
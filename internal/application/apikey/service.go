@@ -0,0 +1,248 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// keyPrefix marks API keys of this service, e.g. for secret scanners
+	keyPrefix = "isk_"
+	// displayPrefixLength is how much of a key is kept to tell keys apart
+	displayPrefixLength = 12
+	// maxNameLength bounds the name of a key
+	maxNameLength = 100
+	// lastUsedPrecision is how stale the last use of a key may be before
+	// a request records it again
+	lastUsedPrecision = time.Minute
+)
+
+// Service manages service accounts and their API keys
+type Service struct {
+	repo           repositories.APIKeyRepository
+	userRepo       repositories.UserRepository
+	eventPublisher services.EventPublisher
+	logger         *zap.Logger
+}
+
+var _ services.APIKeyService = (*Service)(nil)
+
+// NewService creates a new API key service
+func NewService(
+	repo repositories.APIKeyRepository,
+	userRepo repositories.UserRepository,
+	eventPublisher services.EventPublisher,
+	logger *zap.Logger,
+) *Service {
+	return &Service{
+		repo:           repo,
+		userRepo:       userRepo,
+		eventPublisher: eventPublisher,
+		logger:         logger,
+	}
+}
+
+// CreateServiceAccount creates an active user without a password that only
+// authenticates with API keys
+func (s *Service) CreateServiceAccount(ctx context.Context, input services.CreateServiceAccountInput) (*models.User, error) {
+	username := strings.TrimSpace(input.Username)
+	email := strings.TrimSpace(input.Email)
+	role := input.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	if username == "" {
+		return nil, fmt.Errorf("%w: username is required", errors.ErrInvalidInput)
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, fmt.Errorf("%w: email must be a valid address", errors.ErrInvalidInput)
+	}
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return nil, fmt.Errorf("%w: role must be user or admin", errors.ErrInvalidInput)
+	}
+
+	if existing, err := s.userRepo.GetByIdentifier(ctx, email); err == nil && existing != nil {
+		return nil, services.ErrUserAlreadyExists
+	}
+	if existing, err := s.userRepo.GetByUsername(ctx, username); err == nil && existing != nil {
+		return nil, services.ErrUsernameAlreadyExists
+	}
+
+	user := models.NewUser(email, username, role)
+	user.Status = models.UserStatusActive
+	user.ServiceAccount = true
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create service account: %w", err)
+	}
+
+	s.logger.Info("created service account",
+		zap.String("userID", user.ID.String()),
+		zap.String("createdBy", input.CreatedBy.String()))
+	s.publish(ctx, events.ServiceAccountCreated, events.NewServiceAccountCreatedEvent(
+		user.ID, user.Username, string(user.Role), input.CreatedBy))
+
+	return user, nil
+}
+
+// CreateKey creates an API key for a service account
+func (s *Service) CreateKey(ctx context.Context, input services.CreateAPIKeyInput) (*services.CreatedAPIKey, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > maxNameLength {
+		return nil, fmt.Errorf("%w: name must be 1 to %d characters", errors.ErrInvalidInput, maxNameLength)
+	}
+	if input.ExpiresIn < 0 {
+		return nil, fmt.Errorf("%w: expiry must not be in the past", errors.ErrInvalidInput)
+	}
+	if _, err := s.serviceAccount(ctx, input.UserID); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	secret := keyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	key := &models.APIKey{
+		UserID:    input.UserID,
+		Name:      name,
+		Prefix:    secret[:displayPrefixLength],
+		KeyHash:   hashKey(secret),
+		CreatedBy: input.CreatedBy,
+	}
+	activatesAt := time.Now()
+	if input.NotBefore.After(activatesAt) {
+		activatesAt = input.NotBefore
+		key.NotBefore = &activatesAt
+	}
+	if input.ExpiresIn > 0 {
+		expiresAt := activatesAt.Add(input.ExpiresIn)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	s.logger.Info("created API key",
+		zap.String("keyID", key.ID.String()),
+		zap.String("userID", key.UserID.String()),
+		zap.String("createdBy", input.CreatedBy.String()))
+	s.publish(ctx, events.APIKeyCreated, events.NewAPIKeyEvent(
+		events.APIKeyCreated, key.ID, key.UserID, key.Prefix, input.CreatedBy))
+
+	return &services.CreatedAPIKey{APIKey: key, Key: secret}, nil
+}
+
+// ListKeys returns the API keys of a service account
+func (s *Service) ListKeys(ctx context.Context, userID uuid.UUID) ([]*models.APIKey, error) {
+	if _, err := s.serviceAccount(ctx, userID); err != nil {
+		return nil, err
+	}
+	keys, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey revokes an API key of a service account. Requests with the key
+// are refused from then on.
+func (s *Service) RevokeKey(ctx context.Context, userID, id, revokedBy uuid.UUID) error {
+	if err := s.repo.Revoke(ctx, userID, id, time.Now()); err != nil {
+		return err
+	}
+
+	s.logger.Info("revoked API key",
+		zap.String("keyID", id.String()),
+		zap.String("userID", userID.String()),
+		zap.String("revokedBy", revokedBy.String()))
+	s.publish(ctx, events.APIKeyRevoked, events.NewAPIKeyEvent(
+		events.APIKeyRevoked, id, userID, "", revokedBy))
+	return nil
+}
+
+// Authenticate returns the service account an API key belongs to. Keys
+// are refused before their activation like expired ones.
+func (s *Service) Authenticate(ctx context.Context, secret string) (*models.User, error) {
+	if !strings.HasPrefix(secret, keyPrefix) {
+		return nil, services.ErrAPIKeyInvalid
+	}
+	key, err := s.repo.GetByHash(ctx, hashKey(secret))
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return nil, services.ErrAPIKeyInvalid
+		}
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+	now := time.Now()
+	if !key.IsValid(now) {
+		return nil, services.ErrAPIKeyInvalid
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if err != nil {
+		if stderrors.Is(err, errors.ErrUserNotFound) {
+			return nil, services.ErrAPIKeyInvalid
+		}
+		return nil, fmt.Errorf("failed to look up service account: %w", err)
+	}
+	if !user.ServiceAccount || !user.Status.CanAuthenticate() {
+		return nil, services.ErrAPIKeyInvalid
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedPrecision {
+		if err := s.repo.RecordUse(ctx, key.ID, now); err != nil {
+			s.logger.Warn("failed to record API key use",
+				zap.String("keyID", key.ID.String()),
+				zap.Error(err))
+		}
+	}
+	return user, nil
+}
+
+// serviceAccount returns a user that must be a service account
+func (s *Service) serviceAccount(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.ServiceAccount {
+		return nil, services.ErrNotServiceAccount
+	}
+	return user, nil
+}
+
+// publish attributes an event to the actor of the request and publishes it
+func (s *Service) publish(ctx context.Context, eventType events.EventType, event interface{}) {
+	if e, ok := event.(interface{ SetMetadata(events.Metadata) }); ok {
+		e.SetMetadata(events.MetadataFromContext(ctx))
+	}
+
+	if err := s.eventPublisher.PublishUserEvent(ctx, string(eventType), event); err != nil {
+		s.logger.Error("failed to publish event",
+			zap.String("eventType", string(eventType)),
+			zap.Error(err))
+	}
+}
+
+// hashKey returns the hex SHA-256 of an API key, under which it is stored
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
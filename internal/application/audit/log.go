@@ -0,0 +1,233 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// verifyBatchSize is the number of entries loaded per page when verifying
+	verifyBatchSize = 500
+	// defaultListLimit and maxListLimit bound the pages of List
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// redactedFields are the event fields holding personal data or secrets.
+// Entries cannot be changed once recorded and outlive purged accounts, so
+// these values are not stored; the payload hash still ties an entry to the
+// event as it was published.
+var redactedFields = map[string]bool{
+	"email":            true,
+	"recoveryEmail":    true,
+	"username":         true,
+	"firstName":        true,
+	"lastName":         true,
+	"ipAddress":        true,
+	"clientIp":         true,
+	"userAgent":        true,
+	"deviceId":         true,
+	"verificationLink": true,
+	"confirmationLink": true,
+	"resetLink":        true,
+	"resetToken":       true,
+	"loginLink":        true,
+}
+
+const redactedValue = "[redacted]"
+
+// auditedEvent holds the fields of a published event recorded outside the payload
+type auditedEvent struct {
+	ID       string `json:"id"`
+	Metadata struct {
+		ActorID string `json:"actorId"`
+	} `json:"metadata"`
+}
+
+// Log is a hash-chained, append-only record of published events
+type Log struct {
+	repo   repositories.AuditLogRepository
+	logger *zap.Logger
+}
+
+var _ services.AuditLogService = (*Log)(nil)
+
+// NewLog creates a new audit log
+func NewLog(repo repositories.AuditLogRepository, logger *zap.Logger) *Log {
+	return &Log{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Record appends an event to the log
+func (l *Log) Record(ctx context.Context, eventType string, payload interface{}) error {
+	entry, err := newEntry(eventType, payload)
+	if err != nil {
+		return err
+	}
+	if err := l.repo.Append(ctx, entry); err != nil {
+		return fmt.Errorf("failed to append audit log entry: %w", err)
+	}
+	return nil
+}
+
+// newEntry builds the entry of an event, with its personal data redacted
+func newEntry(eventType string, payload interface{}) (*models.AuditLogEntry, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	var event auditedEvent
+	_ = json.Unmarshal(data, &event)
+
+	redacted, err := redactPayload(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redact audit event: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return &models.AuditLogEntry{
+		EventID:     event.ID,
+		EventType:   eventType,
+		ActorID:     event.Metadata.ActorID,
+		Payload:     redacted,
+		PayloadHash: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// redactPayload replaces the values of redacted fields, at any depth, in an
+// encoded event
+func redactPayload(data []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	redact(value)
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(redacted), nil
+}
+
+func redact(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if s, ok := field.(string); ok && s != "" && redactedFields[key] {
+				v[key] = redactedValue
+				continue
+			}
+			redact(field)
+		}
+	case []interface{}:
+		for _, item := range v {
+			redact(item)
+		}
+	}
+}
+
+// Head returns a checkpoint of the latest entry, or nil when the log is empty
+func (l *Log) Head(ctx context.Context) (*models.AuditLogCheckpoint, error) {
+	latest, err := l.repo.Latest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest audit log entry: %w", err)
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	return checkpoint(latest), nil
+}
+
+// Verify recomputes the hash chain of the whole log
+func (l *Log) Verify(ctx context.Context) (*models.AuditLogVerification, error) {
+	result := &models.AuditLogVerification{Valid: true}
+	var previous *models.AuditLogEntry
+	for {
+		after := int64(0)
+		if previous != nil {
+			after = previous.Sequence
+		}
+		entries, err := l.repo.ListAfter(ctx, after, verifyBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list audit log entries: %w", err)
+		}
+
+		for _, entry := range entries {
+			if reason := chainError(previous, entry); reason != "" {
+				result.Valid = false
+				result.BrokenAt = entry.Sequence
+				result.Reason = reason
+				l.logger.Error("audit log hash chain is broken",
+					zap.Int64("sequence", entry.Sequence),
+					zap.String("reason", reason))
+				return result, nil
+			}
+			result.Entries++
+			result.Head = checkpoint(entry)
+			previous = entry
+		}
+		if len(entries) < verifyBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// List returns up to limit entries preceding the given sequence number,
+// newest first; 0 starts from the latest entry
+func (l *Log) List(ctx context.Context, before int64, limit int) ([]*models.AuditLogEntry, error) {
+	if before < 0 || limit < 0 {
+		return nil, fmt.Errorf("%w: before and limit must not be negative", errors.ErrInvalidInput)
+	}
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	entries, err := l.repo.ListBefore(ctx, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log entries: %w", err)
+	}
+	return entries, nil
+}
+
+// chainError describes why entry does not follow previous, or returns an
+// empty string when it does
+func chainError(previous, entry *models.AuditLogEntry) string {
+	expectedSequence, expectedPrevious := int64(1), models.AuditLogGenesisHash
+	if previous != nil {
+		expectedSequence, expectedPrevious = previous.Sequence+1, previous.Hash
+	}
+
+	switch {
+	case entry.Sequence != expectedSequence:
+		return fmt.Sprintf("expected sequence %d", expectedSequence)
+	case entry.PreviousHash != expectedPrevious:
+		return "previous hash does not match the preceding entry"
+	case entry.ComputeHash() != entry.Hash:
+		return "entry content does not match its hash"
+	}
+	return ""
+}
+
+func checkpoint(entry *models.AuditLogEntry) *models.AuditLogCheckpoint {
+	return &models.AuditLogCheckpoint{
+		Sequence:   entry.Sequence,
+		Hash:       entry.Hash,
+		RecordedAt: entry.CreatedAt,
+	}
+}
@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryAuditLogRepository chains entries in memory like the postgres
+// repository, counting appends
+type memoryAuditLogRepository struct {
+	mu      sync.Mutex
+	entries []*models.AuditLogEntry
+	appends int
+}
+
+func (r *memoryAuditLogRepository) Append(ctx context.Context, entries ...*models.AuditLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.appends++
+	for _, entry := range entries {
+		var previous *models.AuditLogEntry
+		if len(r.entries) > 0 {
+			previous = r.entries[len(r.entries)-1]
+		}
+		entry.Chain(previous)
+		r.entries = append(r.entries, entry)
+	}
+	return nil
+}
+
+func (r *memoryAuditLogRepository) Latest(ctx context.Context) (*models.AuditLogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) == 0 {
+		return nil, nil
+	}
+	return r.entries[len(r.entries)-1], nil
+}
+
+func (r *memoryAuditLogRepository) ListAfter(ctx context.Context, sequence int64, limit int) ([]*models.AuditLogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []*models.AuditLogEntry
+	for _, entry := range r.entries {
+		if entry.Sequence > sequence && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (r *memoryAuditLogRepository) ListBefore(ctx context.Context, sequence int64, limit int) ([]*models.AuditLogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []*models.AuditLogEntry
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		if sequence == 0 || r.entries[i].Sequence < sequence {
+			entries = append(entries, r.entries[i])
+		}
+	}
+	return entries, nil
+}
+
+func TestLogRecordRedactsPersonalData(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryAuditLogRepository{}
+	log := NewLog(repo, zap.NewNop())
+
+	event := events.NewUserPasswordResetEvent(uuid.New(), "user@example.com", "https://app.example.com/reset?token=secret")
+	require.NoError(t, log.Record(ctx, string(event.Type), event))
+	require.Len(t, repo.entries, 1)
+	entry := repo.entries[0]
+
+	assert.NotContains(t, entry.Payload, "user@example.com")
+	assert.NotContains(t, entry.Payload, "secret")
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(entry.Payload), &payload))
+	assert.Equal(t, redactedValue, payload["email"])
+	assert.Equal(t, event.ID, entry.EventID)
+
+	published, err := json.Marshal(event)
+	require.NoError(t, err)
+	sum := sha256.Sum256(published)
+	assert.Equal(t, hex.EncodeToString(sum[:]), entry.PayloadHash)
+
+	verification, err := log.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, verification.Valid)
+}
+
+func TestRedactPayloadNested(t *testing.T) {
+	redacted, err := redactPayload([]byte(`{"id":"e1","count":12345678901234567890,"metadata":{"clientIp":"203.0.113.7","userAgent":""},"items":[{"email":"a@example.com"}]}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"e1","count":12345678901234567890,"metadata":{"clientIp":"[redacted]","userAgent":""},"items":[{"email":"[redacted]"}]}`, redacted)
+}
+
+func TestWriterAppendsInBackground(t *testing.T) {
+	repo := &memoryAuditLogRepository{}
+	log := NewLog(repo, zap.NewNop())
+	writer := NewWriter(log, 1000, zap.NewNop())
+
+	// Entries queued before the writer runs are appended in batches
+	for i := 0; i < 250; i++ {
+		event := events.NewUserVerifiedEvent(uuid.New(), "user@example.com")
+		require.NoError(t, writer.Record(context.Background(), string(event.Type), event))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		writer.Run(ctx)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		return len(repo.entries) == 250
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 3, repo.appends)
+
+	// Entries queued at shutdown are appended before Run returns
+	event := events.NewUserVerifiedEvent(uuid.New(), "user@example.com")
+	require.NoError(t, writer.Record(context.Background(), string(event.Type), event))
+	cancel()
+	<-done
+	assert.Len(t, repo.entries, 251)
+
+	verification, err := log.Verify(context.Background())
+	require.NoError(t, err)
+	assert.True(t, verification.Valid)
+}
+
+func TestWriterBufferFull(t *testing.T) {
+	writer := NewWriter(NewLog(&memoryAuditLogRepository{}, zap.NewNop()), 1, zap.NewNop())
+	event := events.NewUserVerifiedEvent(uuid.New(), "user@example.com")
+	require.NoError(t, writer.Record(context.Background(), string(event.Type), event))
+	assert.ErrorIs(t, writer.Record(context.Background(), string(event.Type), event), errWriterBufferFull)
+}
@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// Recorder records events in the audit log: a Log appends them right away,
+// a Writer in the background
+type Recorder interface {
+	Record(ctx context.Context, eventType string, payload interface{}) error
+}
+
+// EventPublisher decorates an event publisher to record every event in the
+// audit log before publishing it
+type EventPublisher struct {
+	services.EventPublisher
+	recorder Recorder
+	logger   *zap.Logger
+}
+
+// NewEventPublisher creates a new auditing event publisher
+func NewEventPublisher(eventPublisher services.EventPublisher, recorder Recorder, logger *zap.Logger) *EventPublisher {
+	return &EventPublisher{
+		EventPublisher: eventPublisher,
+		recorder:       recorder,
+		logger:         logger,
+	}
+}
+
+// PublishUserEvent records the event in the audit log and publishes it. The
+// event is published even when it cannot be recorded.
+func (p *EventPublisher) PublishUserEvent(ctx context.Context, eventType string, payload interface{}) error {
+	if err := p.recorder.Record(ctx, eventType, payload); err != nil {
+		p.logger.Error("failed to record audit log entry",
+			zap.String("eventType", eventType),
+			zap.Error(err))
+	}
+	return p.EventPublisher.PublishUserEvent(ctx, eventType, payload)
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// TokenService decorates a token service to publish a security audit event
+// for every signing key rotation, attributed to the actor in the context
+type TokenService struct {
+	services.TokenService
+	eventPublisher services.EventPublisher
+	logger         *zap.Logger
+}
+
+// NewTokenService creates a new auditing token service
+func NewTokenService(
+	tokenService services.TokenService,
+	eventPublisher services.EventPublisher,
+	logger *zap.Logger,
+) *TokenService {
+	return &TokenService{
+		TokenService:   tokenService,
+		eventPublisher: eventPublisher,
+		logger:         logger,
+	}
+}
+
+// RotateSigningKey rotates the signing key of the given token type and
+// publishes a signing key rotated event. The rotation is not undone when
+// the event cannot be published.
+func (s *TokenService) RotateSigningKey(ctx context.Context, tokenType services.TokenType) (*services.SigningKeyInfo, error) {
+	previousKeyID := ""
+	if keys, err := s.TokenService.ListSigningKeys(ctx); err == nil {
+		for _, key := range keys {
+			if key.TokenType == tokenType {
+				previousKeyID = key.KeyID
+			}
+		}
+	}
+
+	info, err := s.TokenService.RotateSigningKey(ctx, tokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	event := events.NewSigningKeyRotatedEvent(string(tokenType), previousKeyID, info.KeyID, info.Algorithm)
+	event.SetMetadata(events.MetadataFromContext(ctx))
+	if err := s.eventPublisher.PublishUserEvent(ctx, string(events.SigningKeyRotated), event); err != nil {
+		s.logger.Error("failed to publish signing key rotation audit event",
+			zap.String("tokenType", string(tokenType)),
+			zap.String("keyId", info.KeyID),
+			zap.Error(err))
+	}
+	return info, nil
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultWriterBufferSize = 4096
+	// writerBatchSize bounds the entries chained under one lock
+	writerBatchSize = 100
+	// writerAppendTimeout bounds one batch, also while draining on shutdown
+	writerAppendTimeout = 10 * time.Second
+)
+
+// errWriterBufferFull is returned when events arrive faster than the audit
+// log can store them
+var errWriterBufferFull = errors.New("audit log buffer is full")
+
+// Writer records events in the audit log in the background, so that
+// publishing an event does not wait for the lock that keeps the chain from
+// forking. Queued entries are appended in batches.
+type Writer struct {
+	log     *Log
+	entries chan *models.AuditLogEntry
+	logger  *zap.Logger
+}
+
+// NewWriter creates a background writer to the log buffering up to
+// bufferSize entries; 0 uses 4096
+func NewWriter(log *Log, bufferSize int, logger *zap.Logger) *Writer {
+	if bufferSize <= 0 {
+		bufferSize = defaultWriterBufferSize
+	}
+	return &Writer{
+		log:     log,
+		entries: make(chan *models.AuditLogEntry, bufferSize),
+		logger:  logger,
+	}
+}
+
+// Record queues an event for the audit log. The entry is built right away,
+// so later changes to the payload are not recorded.
+func (w *Writer) Record(ctx context.Context, eventType string, payload interface{}) error {
+	entry, err := newEntry(eventType, payload)
+	if err != nil {
+		return err
+	}
+	select {
+	case w.entries <- entry:
+		return nil
+	default:
+		return errWriterBufferFull
+	}
+}
+
+// Run appends queued entries until ctx is cancelled, then appends the
+// entries still queued
+func (w *Writer) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			w.drain()
+			return
+		case entry := <-w.entries:
+			w.append(w.batch(entry))
+		}
+	}
+}
+
+// batch collects the entries queued after first, up to the batch size
+func (w *Writer) batch(first *models.AuditLogEntry) []*models.AuditLogEntry {
+	entries := []*models.AuditLogEntry{first}
+	for len(entries) < writerBatchSize {
+		select {
+		case entry := <-w.entries:
+			entries = append(entries, entry)
+		default:
+			return entries
+		}
+	}
+	return entries
+}
+
+func (w *Writer) drain() {
+	for {
+		select {
+		case entry := <-w.entries:
+			w.append(w.batch(entry))
+		default:
+			return
+		}
+	}
+}
+
+func (w *Writer) append(entries []*models.AuditLogEntry) {
+	ctx, cancel := context.WithTimeout(context.Background(), writerAppendTimeout)
+	defer cancel()
+	if err := w.log.repo.Append(ctx, entries...); err != nil {
+		w.logger.Error("failed to append audit log entries",
+			zap.Int("count", len(entries)),
+			zap.Error(err))
+	}
+}
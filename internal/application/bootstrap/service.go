@@ -0,0 +1,255 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// Ensure Service implements services.BootstrapService
+var _ services.BootstrapService = (*Service)(nil)
+
+// DefaultTokenTTL is how long a bootstrap token can be used when no
+// lifetime is configured
+const DefaultTokenTTL = time.Hour
+
+// Cache keys of the bootstrap token. The issued key keeps other instances
+// starting at the same time from issuing tokens of their own.
+const (
+	issuedKey      = "bootstrap_token_issued"
+	tokenKeyPrefix = "bootstrap_token:"
+	redeemedPrefix = "bootstrap_token_redeemed:"
+)
+
+// tokenEntry is an issued bootstrap token, cached by the hash of the token
+// until it expires
+type tokenEntry struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Token is a bootstrap token issued at the first start of a deployment
+type Token struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Service issues the one-time bootstrap token of a new deployment and
+// carries out the initial setup it authorizes
+type Service struct {
+	userRepo       repositories.UserRepository
+	userService    services.UserService
+	roles          services.RoleService
+	organizations  services.OrganizationService
+	tenantSettings services.TenantSettingsService
+	cacheService   services.CacheService
+	eventPublisher services.EventPublisher
+	clock          services.Clock
+	ttl            time.Duration
+	logger         *zap.Logger
+}
+
+// NewService creates a new bootstrap service issuing tokens valid for ttl;
+// 0 uses DefaultTokenTTL
+func NewService(
+	userRepo repositories.UserRepository,
+	userService services.UserService,
+	roles services.RoleService,
+	organizations services.OrganizationService,
+	tenantSettings services.TenantSettingsService,
+	cacheService services.CacheService,
+	eventPublisher services.EventPublisher,
+	clock services.Clock,
+	ttl time.Duration,
+	logger *zap.Logger,
+) *Service {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	return &Service{
+		userRepo:       userRepo,
+		userService:    userService,
+		roles:          roles,
+		organizations:  organizations,
+		tenantSettings: tenantSettings,
+		cacheService:   cacheService,
+		eventPublisher: eventPublisher,
+		clock:          clock,
+		ttl:            ttl,
+		logger:         logger,
+	}
+}
+
+// IssueToken issues a bootstrap token if the deployment has no users yet
+// and no unexpired token was issued before. It returns nil otherwise. Only
+// the hash of the token is stored.
+func (s *Service) IssueToken(ctx context.Context) (*Token, error) {
+	users, err := s.userRepo.List(ctx, 0, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for users: %w", err)
+	}
+	if len(users) > 0 {
+		return nil, nil
+	}
+
+	issued, err := s.cacheService.SetNX(ctx, issuedKey, true, s.ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue bootstrap token: %w", err)
+	}
+	if !issued {
+		return nil, nil
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate bootstrap token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	expiresAt := s.clock.Now().Add(s.ttl)
+	if err := s.cacheService.Set(ctx, tokenKey(token), tokenEntry{ExpiresAt: expiresAt}, s.ttl); err != nil {
+		if err := s.cacheService.Delete(ctx, issuedKey); err != nil {
+			s.logger.Warn("failed to release bootstrap token lock", zap.Error(err))
+		}
+		return nil, fmt.Errorf("failed to store bootstrap token: %w", err)
+	}
+
+	s.publish(ctx, events.BootstrapTokenIssued, events.NewBootstrapEvent(events.BootstrapTokenIssued, expiresAt, uuid.Nil, uuid.Nil))
+	return &Token{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// Setup creates the first admin and optionally an organization with the
+// bootstrap token. The token can be retried while the admin cannot be
+// created, e.g. for a password the policy refuses, and is destroyed once
+// the admin exists, even when the organization then fails.
+func (s *Service) Setup(ctx context.Context, token string, input services.BootstrapInput) (*services.BootstrapResult, error) {
+	if err := s.redeem(ctx, token); err != nil {
+		return nil, err
+	}
+
+	admin, err := s.userService.RegisterUser(ctx, input.Admin)
+	if err != nil {
+		if err := s.cacheService.Delete(ctx, redeemedPrefix+hashToken(token)); err != nil {
+			s.logger.Warn("failed to release bootstrap token", zap.Error(err))
+		}
+		return nil, err
+	}
+	if err := s.cacheService.Delete(ctx, tokenKey(token)); err != nil {
+		s.logger.Warn("failed to delete used bootstrap token", zap.Error(err))
+	}
+	if admin, err = s.promoteAdmin(ctx, admin); err != nil {
+		return nil, err
+	}
+
+	result := &services.BootstrapResult{Admin: admin}
+	organizationID := uuid.Nil
+	if input.Organization != "" {
+		organization, err := s.createOrganization(ctx, admin.ID, input.Organization, input.Settings)
+		if err != nil {
+			return nil, fmt.Errorf("created admin %s but not the organization: %w", admin.ID, err)
+		}
+		result.Organization = organization
+		organizationID = organization.ID
+	}
+
+	s.logger.Info("bootstrapped deployment",
+		zap.String("adminID", admin.ID.String()),
+		zap.String("organizationID", organizationID.String()))
+	s.publish(events.WithActor(ctx, admin.ID.String()), events.BootstrapCompleted,
+		events.NewBootstrapEvent(events.BootstrapCompleted, time.Time{}, admin.ID, organizationID))
+
+	return result, nil
+}
+
+// redeem checks a bootstrap token and marks it used, so that concurrent
+// setups cannot both proceed
+func (s *Service) redeem(ctx context.Context, token string) error {
+	if token == "" {
+		return services.ErrInvalidToken
+	}
+	var entry tokenEntry
+	if err := s.cacheService.Get(ctx, tokenKey(token), &entry); err != nil {
+		if stderrors.Is(err, services.ErrCacheKeyNotFound) {
+			return services.ErrInvalidToken
+		}
+		return fmt.Errorf("failed to get bootstrap token: %w", err)
+	}
+	now := s.clock.Now()
+	if !now.Before(entry.ExpiresAt) {
+		return services.ErrInvalidToken
+	}
+
+	redeemed, err := s.cacheService.SetNX(ctx, redeemedPrefix+hashToken(token), true, entry.ExpiresAt.Sub(now)+time.Minute)
+	if err != nil {
+		return fmt.Errorf("failed to redeem bootstrap token: %w", err)
+	}
+	if !redeemed {
+		return services.ErrInvalidToken
+	}
+	return nil
+}
+
+// promoteAdmin makes the first user an active admin. The token holder
+// stands in for the admin, so the email counts as verified.
+func (s *Service) promoteAdmin(ctx context.Context, user *models.User) (*models.User, error) {
+	// No admin assigns the role, so the change is attributed to the nil ID
+	admin, err := s.roles.AssignRole(ctx, user.ID, models.RoleAdmin, uuid.Nil)
+	if err != nil {
+		return nil, fmt.Errorf("registered user %s but failed to make them an admin: %w", user.ID, err)
+	}
+
+	admin.EmailVerified = true
+	if err := admin.TransitionTo(models.UserStatusActive); err != nil {
+		return nil, errors.WrapError("Bootstrap", err)
+	}
+	if err := s.userRepo.Update(ctx, admin); err != nil {
+		return nil, fmt.Errorf("failed to activate admin %s: %w", admin.ID, err)
+	}
+	return admin, nil
+}
+
+// createOrganization creates the first organization owned by the admin,
+// with the given tenant settings
+func (s *Service) createOrganization(ctx context.Context, adminID uuid.UUID, name string, settings *models.OrganizationSettings) (*models.Organization, error) {
+	organization, err := s.organizations.CreateOrganization(ctx, name, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if settings != nil {
+		settings.OrganizationID = organization.ID
+		if err := s.tenantSettings.SaveOverrides(ctx, settings); err != nil {
+			return nil, fmt.Errorf("created organization %s but not its settings: %w", organization.ID, err)
+		}
+	}
+	return organization, nil
+}
+
+// publish publishes a bootstrap event; failures are logged
+func (s *Service) publish(ctx context.Context, eventType events.EventType, event *events.BootstrapEvent) {
+	event.SetMetadata(events.MetadataFromContext(ctx))
+	if err := s.eventPublisher.PublishUserEvent(ctx, string(eventType), event); err != nil {
+		s.logger.Error("failed to publish event",
+			zap.String("eventType", string(eventType)),
+			zap.Error(err))
+	}
+}
+
+func tokenKey(token string) string {
+	return tokenKeyPrefix + hashToken(token)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
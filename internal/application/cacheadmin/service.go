@@ -0,0 +1,105 @@
+package cacheadmin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// DefaultScanLimit is how many keys a stats request counts when no limit
+// is configured
+const DefaultScanLimit = 100000
+
+// invalidatableNamespaces are the namespaces holding plain caches of data
+// kept elsewhere, which are rebuilt on the next read. Every other namespace
+// holds revocations, single-use markers, locks, sessions or signing keys,
+// and deleting them would weaken security or lose state.
+var invalidatableNamespaces = map[string]bool{
+	"public_profile":  true,
+	"tenant_settings": true,
+	"mfa_policies":    true,
+}
+
+// Service inspects the cache and invalidates cached entries for admins
+type Service struct {
+	inspector      services.CacheInspector
+	eventPublisher services.EventPublisher
+	scanLimit      int
+	logger         *zap.Logger
+}
+
+var _ services.CacheAdminService = (*Service)(nil)
+
+// NewService creates a new cache admin service; a scanLimit of 0 or less
+// uses DefaultScanLimit
+func NewService(
+	inspector services.CacheInspector,
+	eventPublisher services.EventPublisher,
+	scanLimit int,
+	logger *zap.Logger,
+) *Service {
+	if scanLimit <= 0 {
+		scanLimit = DefaultScanLimit
+	}
+	return &Service{
+		inspector:      inspector,
+		eventPublisher: eventPublisher,
+		scanLimit:      scanLimit,
+		logger:         logger,
+	}
+}
+
+// Stats reports key counts by namespace, revoked tokens and the hit ratio
+// of the cache
+func (s *Service) Stats(ctx context.Context) (*services.CacheStats, error) {
+	stats, err := s.inspector.Stats(ctx, s.scanLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect cache: %w", err)
+	}
+	return stats, nil
+}
+
+// Invalidate deletes the keys matching a "<namespace>:<glob>" pattern, or
+// the single key of a namespace without a ':'. The namespace must be
+// spelled out and be one of the invalidatable namespaces.
+func (s *Service) Invalidate(ctx context.Context, pattern string) (int64, error) {
+	namespace, _, _ := strings.Cut(pattern, ":")
+	if namespace == "" || strings.ContainsAny(namespace, `*?[]\`) {
+		return 0, fmt.Errorf("%w: pattern must start with a namespace, e.g. public_profile:*", errors.ErrInvalidInput)
+	}
+	if !invalidatableNamespaces[namespace] {
+		return 0, fmt.Errorf("%w: %s", services.ErrCacheNamespaceProtected, namespace)
+	}
+
+	deleted, err := s.inspector.DeleteMatching(ctx, pattern)
+	if err != nil {
+		// Keys deleted before the failure are still recorded
+		s.publish(ctx, events.CacheInvalidated, events.NewCacheInvalidatedEvent(pattern, deleted))
+		return deleted, fmt.Errorf("failed to invalidate cache keys: %w", err)
+	}
+
+	s.logger.Info("invalidated cache keys",
+		zap.String("pattern", pattern),
+		zap.Int64("deleted", deleted))
+	s.publish(ctx, events.CacheInvalidated, events.NewCacheInvalidatedEvent(pattern, deleted))
+
+	return deleted, nil
+}
+
+// publish publishes an event with the metadata of the request
+func (s *Service) publish(ctx context.Context, eventType events.EventType, event interface{}) {
+	if e, ok := event.(interface{ SetMetadata(events.Metadata) }); ok {
+		e.SetMetadata(events.MetadataFromContext(ctx))
+	}
+
+	if err := s.eventPublisher.PublishUserEvent(ctx, string(eventType), event); err != nil {
+		s.logger.Error("failed to publish event",
+			zap.String("eventType", string(eventType)),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,76 @@
+package claims
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// Ensure OrganizationEnricher implements services.ClaimsEnricher
+var _ services.ClaimsEnricher = (*OrganizationEnricher)(nil)
+
+// OrganizationEnricher adds the organization of a token's subject to its
+// claims: org_name to access tokens, which carry the ID as tenant_id, and
+// org_id and org_name to ID tokens. Subjects outside organizations get no
+// claims.
+type OrganizationEnricher struct {
+	users         repositories.UserRepository
+	organizations repositories.OrganizationRepository
+}
+
+// NewOrganizationEnricher creates a new organization claims enricher
+func NewOrganizationEnricher(users repositories.UserRepository, organizations repositories.OrganizationRepository) *OrganizationEnricher {
+	return &OrganizationEnricher{
+		users:         users,
+		organizations: organizations,
+	}
+}
+
+// EnrichClaims adds the organization claims to access and ID tokens
+func (e *OrganizationEnricher) EnrichClaims(ctx context.Context, tokenType services.TokenType, claims map[string]interface{}) error {
+	var organizationID uuid.UUID
+	switch tokenType {
+	case services.TokenTypeAccess:
+		tenantID, _ := claims["tenant_id"].(string)
+		id, err := uuid.Parse(tenantID)
+		if err != nil {
+			return nil
+		}
+		organizationID = id
+	case services.TokenTypeID:
+		subject, _ := claims["sub"].(string)
+		userID, err := uuid.Parse(subject)
+		if err != nil {
+			return nil
+		}
+		user, err := e.users.GetByID(ctx, userID)
+		if err != nil {
+			if stderrors.Is(err, errors.ErrUserNotFound) {
+				return nil
+			}
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user.OrganizationID == nil {
+			return nil
+		}
+		organizationID = *user.OrganizationID
+		claims["org_id"] = organizationID.String()
+	default:
+		return nil
+	}
+
+	organization, err := e.organizations.GetByID(ctx, organizationID)
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get organization: %w", err)
+	}
+	claims["org_name"] = organization.Name
+	return nil
+}
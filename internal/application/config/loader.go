@@ -3,12 +3,22 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/google/uuid"
 	"github.com/mibrahim2344/identity-service/internal/application"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/auth/password"
 )
 
 // LoadConfig loads configuration from environment variables and/or config file
@@ -67,6 +77,11 @@ func loadFromFile(path string, config *application.Config) error {
 
 // loadFromEnv loads configuration from environment variables
 func loadFromEnv(config *application.Config) {
+	// Storage configuration
+	if driver := os.Getenv("STORAGE_DRIVER"); driver != "" {
+		config.Storage.Driver = driver
+	}
+
 	// Database configuration
 	if host := os.Getenv("DB_HOST"); host != "" {
 		config.Database.Host = host
@@ -103,6 +118,32 @@ func loadFromEnv(config *application.Config) {
 			config.Database.ConnMaxLifetimeMinutes = cml
 		}
 	}
+	if replicas := os.Getenv("DB_REPLICAS"); replicas != "" {
+		// Comma-separated host:port addresses
+		config.Database.Replicas = nil
+		for _, replica := range strings.Split(replicas, ",") {
+			host, port, err := net.SplitHostPort(strings.TrimSpace(replica))
+			if err != nil {
+				continue
+			}
+			if p, err := strconv.Atoi(port); err == nil {
+				config.Database.Replicas = append(config.Database.Replicas, application.ReplicaConfig{Host: host, Port: p})
+			}
+		}
+	}
+	if staleness := os.Getenv("DB_REPLICA_STALENESS_MS"); staleness != "" {
+		if ms, err := strconv.Atoi(staleness); err == nil {
+			config.Database.ReplicaStalenessMs = ms
+		}
+	}
+	if uuidVersion := os.Getenv("PRIMARY_KEY_UUID_VERSION"); uuidVersion != "" {
+		if v, err := strconv.Atoi(uuidVersion); err == nil {
+			config.PrimaryKeys.UUIDVersion = v
+		}
+	}
+	if region := os.Getenv("RESIDENCY_REGION"); region != "" {
+		config.Residency.Region = region
+	}
 
 	// Redis configuration
 	if host := os.Getenv("REDIS_HOST"); host != "" {
@@ -113,6 +154,9 @@ func loadFromEnv(config *application.Config) {
 			config.Redis.Port = p
 		}
 	}
+	if username := os.Getenv("REDIS_USERNAME"); username != "" {
+		config.Redis.Username = username
+	}
 	if password := os.Getenv("REDIS_PASSWORD"); password != "" {
 		config.Redis.Password = password
 	}
@@ -121,6 +165,7 @@ func loadFromEnv(config *application.Config) {
 			config.Redis.DB = d
 		}
 	}
+	loadTLSFromEnv("REDIS", &config.Redis.TLS)
 
 	// Kafka configuration
 	if brokers := os.Getenv("KAFKA_BROKERS"); brokers != "" {
@@ -129,6 +174,100 @@ func loadFromEnv(config *application.Config) {
 	if topic := os.Getenv("KAFKA_TOPIC"); topic != "" {
 		config.Kafka.Topic = topic
 	}
+	if acks := os.Getenv("KAFKA_REQUIRED_ACKS"); acks != "" {
+		config.Kafka.RequiredAcks = acks
+	}
+	if batchSize := os.Getenv("KAFKA_BATCH_SIZE"); batchSize != "" {
+		if bs, err := strconv.Atoi(batchSize); err == nil {
+			config.Kafka.BatchSize = bs
+		}
+	}
+	if batchBytes := os.Getenv("KAFKA_BATCH_BYTES"); batchBytes != "" {
+		if bb, err := strconv.ParseInt(batchBytes, 10, 64); err == nil {
+			config.Kafka.BatchBytes = bb
+		}
+	}
+	if batchTimeout := os.Getenv("KAFKA_BATCH_TIMEOUT_MS"); batchTimeout != "" {
+		if bt, err := strconv.Atoi(batchTimeout); err == nil {
+			config.Kafka.BatchTimeoutMs = bt
+		}
+	}
+	if compression := os.Getenv("KAFKA_COMPRESSION"); compression != "" {
+		config.Kafka.Compression = compression
+	}
+	if writeTimeout := os.Getenv("KAFKA_WRITE_TIMEOUT"); writeTimeout != "" {
+		if wt, err := strconv.Atoi(writeTimeout); err == nil {
+			config.Kafka.WriteTimeout = wt
+		}
+	}
+	if maxAttempts := os.Getenv("KAFKA_MAX_ATTEMPTS"); maxAttempts != "" {
+		if ma, err := strconv.Atoi(maxAttempts); err == nil {
+			config.Kafka.MaxAttempts = ma
+		}
+	}
+	loadTLSFromEnv("KAFKA", &config.Kafka.TLS)
+	if mechanism := os.Getenv("KAFKA_SASL_MECHANISM"); mechanism != "" {
+		config.Kafka.SASL.Mechanism = mechanism
+	}
+	if username := os.Getenv("KAFKA_SASL_USERNAME"); username != "" {
+		config.Kafka.SASL.Username = username
+	}
+	if password := os.Getenv("KAFKA_SASL_PASSWORD"); password != "" {
+		config.Kafka.SASL.Password = password
+	}
+	if serialization := os.Getenv("KAFKA_SERIALIZATION"); serialization != "" {
+		config.Kafka.Serialization = serialization
+	}
+	if url := os.Getenv("KAFKA_SCHEMA_REGISTRY_URL"); url != "" {
+		config.Kafka.SchemaRegistry.URL = url
+	}
+	if username := os.Getenv("KAFKA_SCHEMA_REGISTRY_USERNAME"); username != "" {
+		config.Kafka.SchemaRegistry.Username = username
+	}
+	if password := os.Getenv("KAFKA_SCHEMA_REGISTRY_PASSWORD"); password != "" {
+		config.Kafka.SchemaRegistry.Password = password
+	}
+	if timeout := os.Getenv("KAFKA_SCHEMA_REGISTRY_TIMEOUT_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Kafka.SchemaRegistry.TimeoutMs = t
+		}
+	}
+	loadTLSFromEnv("KAFKA_SCHEMA_REGISTRY", &config.Kafka.SchemaRegistry.TLS)
+
+	// Events configuration
+	if driver := os.Getenv("EVENTS_DRIVER"); driver != "" {
+		config.Events.Driver = driver
+	}
+	if url := os.Getenv("NATS_URL"); url != "" {
+		config.Events.NATS.URL = url
+	}
+	if stream := os.Getenv("NATS_STREAM"); stream != "" {
+		config.Events.NATS.Stream = stream
+	}
+	if file := os.Getenv("NATS_CREDENTIALS_FILE"); file != "" {
+		config.Events.NATS.CredentialsFile = file
+	}
+	if token := os.Getenv("NATS_TOKEN"); token != "" {
+		config.Events.NATS.Token = token
+	}
+	if timeout := os.Getenv("NATS_PUBLISH_TIMEOUT_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Events.NATS.PublishTimeoutMs = t
+		}
+	}
+	loadTLSFromEnv("NATS", &config.Events.NATS.TLS)
+	if url := os.Getenv("RABBITMQ_URL"); url != "" {
+		config.Events.RabbitMQ.URL = url
+	}
+	if exchange := os.Getenv("RABBITMQ_EXCHANGE"); exchange != "" {
+		config.Events.RabbitMQ.Exchange = exchange
+	}
+	if timeout := os.Getenv("RABBITMQ_PUBLISH_TIMEOUT_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Events.RabbitMQ.PublishTimeoutMs = t
+		}
+	}
+	loadTLSFromEnv("RABBITMQ", &config.Events.RabbitMQ.TLS)
 
 	// Auth configuration
 	if duration := os.Getenv("AUTH_ACCESS_TOKEN_DURATION"); duration != "" {
@@ -149,53 +288,1716 @@ func loadFromEnv(config *application.Config) {
 			config.Auth.HashingCost = c
 		}
 	}
-}
 
-// validateConfig validates the configuration
-func validateConfig(config application.Config) error {
-	// Database validation
-	if config.Database.Host == "" {
-		return fmt.Errorf("database host is required")
+	// Password hashing configuration
+	if algorithm := os.Getenv("PASSWORD_HASHING_ALGORITHM"); algorithm != "" {
+		config.PasswordHashing.Algorithm = algorithm
 	}
-	if config.Database.Port == 0 {
-		return fmt.Errorf("database port is required")
+	if memory := os.Getenv("PASSWORD_ARGON2_MEMORY_KIB"); memory != "" {
+		if m, err := strconv.Atoi(memory); err == nil {
+			config.PasswordHashing.Argon2MemoryKiB = m
+		}
 	}
-	if config.Database.User == "" {
-		return fmt.Errorf("database user is required")
+	if iterations := os.Getenv("PASSWORD_ARGON2_ITERATIONS"); iterations != "" {
+		if i, err := strconv.Atoi(iterations); err == nil {
+			config.PasswordHashing.Argon2Iterations = i
+		}
 	}
-	if config.Database.DBName == "" {
-		return fmt.Errorf("database name is required")
+	if parallelism := os.Getenv("PASSWORD_ARGON2_PARALLELISM"); parallelism != "" {
+		if p, err := strconv.Atoi(parallelism); err == nil {
+			config.PasswordHashing.Argon2Parallelism = p
+		}
 	}
 
-	// Redis validation
-	if config.Redis.Host == "" {
-		return fmt.Errorf("redis host is required")
+	// Cookie configuration
+	if enabled := os.Getenv("COOKIES_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.Cookies.Enabled = e
+		}
 	}
-	if config.Redis.Port == 0 {
-		return fmt.Errorf("redis port is required")
+	if domain := os.Getenv("COOKIES_DOMAIN"); domain != "" {
+		config.Cookies.Domain = domain
+	}
+	if path := os.Getenv("COOKIES_PATH"); path != "" {
+		config.Cookies.Path = path
+	}
+	if secure := os.Getenv("COOKIES_SECURE"); secure != "" {
+		if s, err := strconv.ParseBool(secure); err == nil {
+			config.Cookies.Secure = s
+		}
+	}
+	if sameSite := os.Getenv("COOKIES_SAME_SITE"); sameSite != "" {
+		config.Cookies.SameSite = sameSite
 	}
 
-	// Kafka validation
-	if len(config.Kafka.Brokers) == 0 {
-		return fmt.Errorf("kafka brokers are required")
+	// CORS configuration
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		config.CORS.AllowedOrigins = strings.Split(origins, ",")
 	}
-	if config.Kafka.Topic == "" {
-		return fmt.Errorf("kafka topic is required")
+	if methods := os.Getenv("CORS_ALLOWED_METHODS"); methods != "" {
+		config.CORS.AllowedMethods = strings.Split(methods, ",")
+	}
+	if headers := os.Getenv("CORS_ALLOWED_HEADERS"); headers != "" {
+		config.CORS.AllowedHeaders = strings.Split(headers, ",")
+	}
+	if origins := os.Getenv("CORS_CREDENTIAL_ORIGINS"); origins != "" {
+		config.CORS.CredentialOrigins = strings.Split(origins, ",")
+	}
+	if maxAge := os.Getenv("CORS_MAX_AGE_SECONDS"); maxAge != "" {
+		if m, err := strconv.Atoi(maxAge); err == nil {
+			config.CORS.MaxAgeSeconds = m
+		}
 	}
 
-	// Auth validation
-	if config.Auth.AccessTokenDuration == 0 {
-		return fmt.Errorf("access token duration is required")
+	// Search configuration
+	if enabled := os.Getenv("SEARCH_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.Search.Enabled = e
+		}
 	}
-	if config.Auth.RefreshTokenDuration == 0 {
-		return fmt.Errorf("refresh token duration is required")
+	if searchURL := os.Getenv("SEARCH_URL"); searchURL != "" {
+		config.Search.URL = searchURL
 	}
-	if config.Auth.SigningKey == "" {
-		return fmt.Errorf("auth signing key is required")
+	if index := os.Getenv("SEARCH_INDEX"); index != "" {
+		config.Search.Index = index
 	}
-	if config.Auth.HashingCost == 0 {
-		config.Auth.HashingCost = 10 // Set default bcrypt cost
+	if username := os.Getenv("SEARCH_USERNAME"); username != "" {
+		config.Search.Username = username
+	}
+	if password := os.Getenv("SEARCH_PASSWORD"); password != "" {
+		config.Search.Password = password
+	}
+	if timeout := os.Getenv("SEARCH_TIMEOUT_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Search.TimeoutMs = t
+		}
+	}
+	loadTLSFromEnv("SEARCH", &config.Search.TLS)
+	if group := os.Getenv("SEARCH_CONSUMER_GROUP"); group != "" {
+		config.Search.ConsumerGroup = group
 	}
 
-	return nil
+	// Egress configuration
+	if proxy := os.Getenv("EGRESS_HTTP_PROXY"); proxy != "" {
+		config.Egress.HTTPProxy = proxy
+	}
+	if proxy := os.Getenv("EGRESS_HTTPS_PROXY"); proxy != "" {
+		config.Egress.HTTPSProxy = proxy
+	}
+	if noProxy := os.Getenv("EGRESS_NO_PROXY"); noProxy != "" {
+		config.Egress.NoProxy = noProxy
+	}
+	if caFile := os.Getenv("EGRESS_CA_FILE"); caFile != "" {
+		config.Egress.CAFile = caFile
+	}
+	if timeout := os.Getenv("EGRESS_TIMEOUT_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Egress.TimeoutMs = t
+		}
+	}
+	if timeout := os.Getenv("EGRESS_DIAL_TIMEOUT_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Egress.DialTimeoutMs = t
+		}
+	}
+	if timeout := os.Getenv("EGRESS_TLS_HANDSHAKE_TIMEOUT_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Egress.TLSHandshakeTimeoutMs = t
+		}
+	}
+
+	// Avatar configuration
+	if gravatar := os.Getenv("AVATARS_GRAVATAR"); gravatar != "" {
+		if g, err := strconv.ParseBool(gravatar); err == nil {
+			config.Avatars.Gravatar = g
+		}
+	}
+	if def := os.Getenv("AVATARS_GRAVATAR_DEFAULT"); def != "" {
+		config.Avatars.GravatarDefault = def
+	}
+	if size := os.Getenv("AVATARS_GRAVATAR_SIZE_PIXELS"); size != "" {
+		if s, err := strconv.Atoi(size); err == nil {
+			config.Avatars.GravatarSizePixels = s
+		}
+	}
+
+	// Device binding configuration
+	if enabled := os.Getenv("DEVICE_BINDING_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.DeviceBinding.Enabled = e
+		}
+	}
+	if roles := os.Getenv("DEVICE_BINDING_ROLES"); roles != "" {
+		config.DeviceBinding.Roles = strings.Split(roles, ",")
+	}
+
+	// Login anomaly configuration
+	if enabled := os.Getenv("LOGIN_ANOMALIES_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.LoginAnomalies.Enabled = e
+		}
+	}
+	if require := os.Getenv("LOGIN_ANOMALIES_REQUIRE_CONFIRMATION"); require != "" {
+		if r, err := strconv.ParseBool(require); err == nil {
+			config.LoginAnomalies.RequireConfirmation = r
+		}
+	}
+	if minutes := os.Getenv("LOGIN_ANOMALIES_CONFIRMATION_TTL_MINUTES"); minutes != "" {
+		if m, err := strconv.Atoi(minutes); err == nil {
+			config.LoginAnomalies.ConfirmationTTLMinutes = m
+		}
+	}
+
+	// Registration risk configuration
+	if enabled := os.Getenv("REGISTRATION_RISK_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.RegistrationRisk.Enabled = e
+		}
+	}
+	if score := os.Getenv("REGISTRATION_RISK_CHALLENGE_SCORE"); score != "" {
+		if sc, err := strconv.Atoi(score); err == nil {
+			config.RegistrationRisk.ChallengeScore = sc
+		}
+	}
+	if score := os.Getenv("REGISTRATION_RISK_REVIEW_SCORE"); score != "" {
+		if sc, err := strconv.Atoi(score); err == nil {
+			config.RegistrationRisk.ReviewScore = sc
+		}
+	}
+	if domains := os.Getenv("REGISTRATION_RISK_DISPOSABLE_EMAIL_DOMAINS"); domains != "" {
+		config.RegistrationRisk.DisposableEmailDomains = strings.Split(domains, ",")
+	}
+	if limit := os.Getenv("REGISTRATION_RISK_IP_VELOCITY_LIMIT"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			config.RegistrationRisk.IPVelocityLimit = l
+		}
+	}
+	if limit := os.Getenv("REGISTRATION_RISK_DEVICE_VELOCITY_LIMIT"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			config.RegistrationRisk.DeviceVelocityLimit = l
+		}
+	}
+
+	// Account risk configuration
+	if enabled := os.Getenv("ACCOUNT_RISK_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.AccountRisk.Enabled = e
+		}
+	}
+	if failures := os.Getenv("ACCOUNT_RISK_WATCH_AFTER_FAILURES"); failures != "" {
+		if f, err := strconv.Atoi(failures); err == nil {
+			config.AccountRisk.WatchAfterFailures = f
+		}
+	}
+	if failures := os.Getenv("ACCOUNT_RISK_RESTRICT_AFTER_FAILURES"); failures != "" {
+		if f, err := strconv.Atoi(failures); err == nil {
+			config.AccountRisk.RestrictAfterFailures = f
+		}
+	}
+	if window := os.Getenv("ACCOUNT_RISK_FAILURE_WINDOW_MINUTES"); window != "" {
+		if w, err := strconv.Atoi(window); err == nil {
+			config.AccountRisk.FailureWindowMinutes = w
+		}
+	}
+	if ttl := os.Getenv("ACCOUNT_RISK_STATE_TTL_MINUTES"); ttl != "" {
+		if t, err := strconv.Atoi(ttl); err == nil {
+			config.AccountRisk.StateTTLMinutes = t
+		}
+	}
+	if verifyURL := os.Getenv("ACCOUNT_RISK_CAPTCHA_VERIFY_URL"); verifyURL != "" {
+		config.AccountRisk.CaptchaVerifyURL = verifyURL
+	}
+	if secret := os.Getenv("ACCOUNT_RISK_CAPTCHA_SECRET"); secret != "" {
+		config.AccountRisk.CaptchaSecret = secret
+	}
+
+	// Profile claims configuration
+	if enabled := os.Getenv("PROFILE_CLAIMS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.ProfileClaims.Enabled = e
+		}
+	}
+	if maxAge := os.Getenv("PROFILE_CLAIMS_MAX_AGE_MINUTES"); maxAge != "" {
+		if m, err := strconv.Atoi(maxAge); err == nil {
+			config.ProfileClaims.MaxAgeMinutes = m
+		}
+	}
+
+	// Organization claims configuration
+	if enabled := os.Getenv("ORGANIZATION_CLAIMS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.OrganizationClaims.Enabled = e
+		}
+	}
+
+	// Session limit configuration
+	if maxConcurrent := os.Getenv("SESSIONS_MAX_CONCURRENT"); maxConcurrent != "" {
+		if m, err := strconv.Atoi(maxConcurrent); err == nil {
+			config.Sessions.MaxConcurrent = m
+		}
+	}
+	if onLimit := os.Getenv("SESSIONS_ON_LIMIT"); onLimit != "" {
+		config.Sessions.OnLimit = onLimit
+	}
+
+	// Profile completeness configuration
+	if fields := os.Getenv("PROFILE_FIELDS"); fields != "" {
+		config.Profile.Fields = strings.Split(fields, ",")
+	}
+	if fields := os.Getenv("PROFILE_REQUIRED_FIELDS"); fields != "" {
+		config.Profile.RequiredFields = strings.Split(fields, ",")
+	}
+	if thresholds := os.Getenv("PROFILE_COMPLETENESS_THRESHOLDS"); thresholds != "" {
+		config.Profile.CompletenessThresholds = nil
+		for _, threshold := range strings.Split(thresholds, ",") {
+			if t, err := strconv.Atoi(strings.TrimSpace(threshold)); err == nil {
+				config.Profile.CompletenessThresholds = append(config.Profile.CompletenessThresholds, t)
+			}
+		}
+	}
+
+	// Slow query log configuration
+	if threshold := os.Getenv("SLOW_QUERY_LOG_THRESHOLD_MS"); threshold != "" {
+		if t, err := strconv.Atoi(threshold); err == nil {
+			config.SlowQueryLog.ThresholdMs = t
+		}
+	}
+	if threshold := os.Getenv("SLOW_QUERY_LOG_ERROR_THRESHOLD_MS"); threshold != "" {
+		if t, err := strconv.Atoi(threshold); err == nil {
+			config.SlowQueryLog.ErrorThresholdMs = t
+		}
+	}
+
+	// Timeout configuration
+	if timeout := os.Getenv("TIMEOUTS_DATABASE_READ_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Timeouts.DatabaseReadMs = t
+		}
+	}
+	if timeout := os.Getenv("TIMEOUTS_DATABASE_WRITE_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Timeouts.DatabaseWriteMs = t
+		}
+	}
+	if timeout := os.Getenv("TIMEOUTS_CACHE_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Timeouts.CacheMs = t
+		}
+	}
+
+	// Signing key configuration
+	if interval := os.Getenv("SIGNING_KEYS_ROTATION_INTERVAL_DAYS"); interval != "" {
+		if i, err := strconv.Atoi(interval); err == nil {
+			config.SigningKeys.RotationIntervalDays = i
+		}
+	}
+	if autoRotate := os.Getenv("SIGNING_KEYS_AUTO_ROTATE"); autoRotate != "" {
+		if a, err := strconv.ParseBool(autoRotate); err == nil {
+			config.SigningKeys.AutoRotate = a
+		}
+	}
+	if interval := os.Getenv("SIGNING_KEYS_CHECK_INTERVAL_MINUTES"); interval != "" {
+		if i, err := strconv.Atoi(interval); err == nil {
+			config.SigningKeys.CheckIntervalMinutes = i
+		}
+	}
+	if interval := os.Getenv("SIGNING_KEYS_SYNC_INTERVAL_SECONDS"); interval != "" {
+		if i, err := strconv.Atoi(interval); err == nil {
+			config.SigningKeys.SyncIntervalSeconds = i
+		}
+	}
+	for _, tokenType := range services.SigningKeyTokenTypes {
+		prefix := "SIGNING_KEYS_" + strings.ToUpper(string(tokenType)) + "_"
+		algorithm := os.Getenv(prefix + "ALGORITHM")
+		interval := os.Getenv(prefix + "ROTATION_INTERVAL_DAYS")
+		if algorithm == "" && interval == "" {
+			continue
+		}
+
+		if config.SigningKeys.Types == nil {
+			config.SigningKeys.Types = make(map[string]application.SigningKeyConfig)
+		}
+		key := config.SigningKeys.Types[string(tokenType)]
+		if algorithm != "" {
+			key.Algorithm = algorithm
+		}
+		if i, err := strconv.Atoi(interval); err == nil {
+			key.RotationIntervalDays = i
+		}
+		config.SigningKeys.Types[string(tokenType)] = key
+	}
+
+	// Degradation configuration
+	if onFailure := os.Getenv("DEGRADATION_REDIS_ON_FAILURE"); onFailure != "" {
+		config.Degradation.Redis.OnFailure = onFailure
+	}
+	if maxAge := os.Getenv("DEGRADATION_REDIS_MAX_TOKEN_AGE_SECONDS"); maxAge != "" {
+		if m, err := strconv.Atoi(maxAge); err == nil {
+			config.Degradation.Redis.MaxTokenAgeSeconds = m
+		}
+	}
+	if onFailure := os.Getenv("DEGRADATION_KAFKA_ON_FAILURE"); onFailure != "" {
+		config.Degradation.Kafka.OnFailure = onFailure
+	}
+	if spoolDir := os.Getenv("DEGRADATION_KAFKA_SPOOL_DIR"); spoolDir != "" {
+		config.Degradation.Kafka.SpoolDir = spoolDir
+	}
+	if interval := os.Getenv("DEGRADATION_KAFKA_REPLAY_INTERVAL_SECONDS"); interval != "" {
+		if i, err := strconv.Atoi(interval); err == nil {
+			config.Degradation.Kafka.ReplayIntervalSeconds = i
+		}
+	}
+
+	// Security summary configuration
+	if enabled := os.Getenv("SECURITY_SUMMARY_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.SecuritySummary.Enabled = e
+		}
+	}
+	if interval := os.Getenv("SECURITY_SUMMARY_CHECK_INTERVAL_MINUTES"); interval != "" {
+		if i, err := strconv.Atoi(interval); err == nil {
+			config.SecuritySummary.CheckIntervalMinutes = i
+		}
+	}
+
+	// Account configuration
+	if cooldown := os.Getenv("ACCOUNT_USERNAME_CHANGE_COOLDOWN_DAYS"); cooldown != "" {
+		if c, err := strconv.Atoi(cooldown); err == nil {
+			config.Account.UsernameChangeCooldownDays = c
+		}
+	}
+	if reservation := os.Getenv("ACCOUNT_USERNAME_RESERVATION_DAYS"); reservation != "" {
+		if r, err := strconv.Atoi(reservation); err == nil {
+			config.Account.UsernameReservationDays = r
+		}
+	}
+	if maxTokens := os.Getenv("ACCOUNT_MAX_ACTIVE_RESET_TOKENS"); maxTokens != "" {
+		if m, err := strconv.Atoi(maxTokens); err == nil {
+			config.Account.MaxActiveResetTokens = m
+		}
+	}
+	if maxEmails := os.Getenv("ACCOUNT_MAX_VERIFICATION_EMAILS_PER_DAY"); maxEmails != "" {
+		if m, err := strconv.Atoi(maxEmails); err == nil {
+			config.Account.MaxVerificationEmailsPerDay = m
+		}
+	}
+	if resends := os.Getenv("ACCOUNT_VERIFICATION_RESENDS_PER_HOUR"); resends != "" {
+		if r, err := strconv.Atoi(resends); err == nil {
+			config.Account.VerificationResendsPerHour = r
+		}
+	}
+	if resets := os.Getenv("ACCOUNT_ADMIN_PASSWORD_RESETS_PER_HOUR"); resets != "" {
+		if m, err := strconv.Atoi(resets); err == nil {
+			config.Account.AdminPasswordResetsPerHour = m
+		}
+	}
+	if required := os.Getenv("ACCOUNT_REQUIRE_VERIFIED_EMAIL"); required != "" {
+		if r, err := strconv.ParseBool(required); err == nil {
+			config.Account.RequireVerifiedEmail = r
+		}
+	}
+	if minutes := os.Getenv("ACCOUNT_PURGE_APPROVAL_MINUTES"); minutes != "" {
+		if m, err := strconv.Atoi(minutes); err == nil {
+			config.Account.PurgeApprovalMinutes = m
+		}
+	}
+	if days := os.Getenv("ACCOUNT_DEACTIVATION_GRACE_DAYS"); days != "" {
+		if d, err := strconv.Atoi(days); err == nil {
+			config.Account.DeactivationGraceDays = d
+		}
+	}
+	if minutes := os.Getenv("ACCOUNT_DEACTIVATION_PURGE_INTERVAL_MINUTES"); minutes != "" {
+		if m, err := strconv.Atoi(minutes); err == nil {
+			config.Account.DeactivationPurgeIntervalMinutes = m
+		}
+	}
+	if workers := os.Getenv("ACCOUNT_PASSWORD_RESET_WORKERS"); workers != "" {
+		if w, err := strconv.Atoi(workers); err == nil {
+			config.Account.PasswordResetWorkers = w
+		}
+	}
+	if size := os.Getenv("ACCOUNT_PASSWORD_RESET_QUEUE_SIZE"); size != "" {
+		if q, err := strconv.Atoi(size); err == nil {
+			config.Account.PasswordResetQueueSize = q
+		}
+	}
+	if limit := os.Getenv("ACCOUNT_PASSWORD_RESETS_PER_IP_PER_HOUR"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			config.Account.PasswordResetsPerIPPerHour = l
+		}
+	}
+	if limit := os.Getenv("ACCOUNT_PASSWORD_RESETS_PER_ADDRESS_PER_HOUR"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			config.Account.PasswordResetsPerAddressPerHour = l
+		}
+	}
+
+	// Public profile configuration
+	if requests := os.Getenv("PUBLIC_PROFILE_REQUESTS_PER_MINUTE"); requests != "" {
+		if r, err := strconv.Atoi(requests); err == nil {
+			config.PublicProfile.RequestsPerMinute = r
+		}
+	}
+	if cacheSeconds := os.Getenv("PUBLIC_PROFILE_CACHE_SECONDS"); cacheSeconds != "" {
+		if c, err := strconv.Atoi(cacheSeconds); err == nil {
+			config.PublicProfile.CacheSeconds = c
+		}
+	}
+
+	// Rate limit configuration
+	if requests := os.Getenv("RATE_LIMIT_SOFT_REQUESTS_PER_MINUTE"); requests != "" {
+		if r, err := strconv.Atoi(requests); err == nil {
+			config.RateLimits.SoftRequestsPerMinute = r
+		}
+	}
+
+	// OIDC configuration
+	if enabled := os.Getenv("OIDC_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.OIDC.Enabled = e
+		}
+	}
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		config.OIDC.Issuer = issuer
+	}
+	if loginURL := os.Getenv("OIDC_LOGIN_URL"); loginURL != "" {
+		config.OIDC.LoginURL = loginURL
+	}
+	if ttl := os.Getenv("OIDC_AUTHORIZATION_CODE_TTL_SECONDS"); ttl != "" {
+		if t, err := strconv.Atoi(ttl); err == nil {
+			config.OIDC.AuthorizationCodeTTLSeconds = t
+		}
+	}
+	if maxTokens := os.Getenv("OIDC_MAX_BATCH_INTROSPECTION_TOKENS"); maxTokens != "" {
+		if m, err := strconv.Atoi(maxTokens); err == nil {
+			config.OIDC.MaxBatchIntrospectionTokens = m
+		}
+	}
+
+	// Audit log configuration
+	if enabled := os.Getenv("AUDIT_LOG_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.AuditLog.Enabled = e
+		}
+	}
+	if notaryURL := os.Getenv("AUDIT_LOG_NOTARY_URL"); notaryURL != "" {
+		config.AuditLog.NotaryURL = notaryURL
+	}
+	if token := os.Getenv("AUDIT_LOG_NOTARY_BEARER_TOKEN"); token != "" {
+		config.AuditLog.NotaryBearerToken = token
+	}
+	if interval := os.Getenv("AUDIT_LOG_NOTARIZE_INTERVAL_MINUTES"); interval != "" {
+		if i, err := strconv.Atoi(interval); err == nil {
+			config.AuditLog.NotarizeIntervalMinutes = i
+		}
+	}
+	if size := os.Getenv("AUDIT_LOG_BUFFER_SIZE"); size != "" {
+		if i, err := strconv.Atoi(size); err == nil {
+			config.AuditLog.BufferSize = i
+		}
+	}
+
+	// MFA configuration
+	if issuer := os.Getenv("MFA_TOTP_ISSUER"); issuer != "" {
+		config.MFA.Issuer = issuer
+	}
+
+	// Passkey configuration
+	if enabled := os.Getenv("PASSKEYS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.Passkeys.Enabled = e
+		}
+	}
+	if rpID := os.Getenv("PASSKEYS_RP_ID"); rpID != "" {
+		config.Passkeys.RPID = rpID
+	}
+	if rpName := os.Getenv("PASSKEYS_RP_NAME"); rpName != "" {
+		config.Passkeys.RPName = rpName
+	}
+	if origins := os.Getenv("PASSKEYS_ORIGINS"); origins != "" {
+		config.Passkeys.Origins = strings.Split(origins, ",")
+	}
+
+	// Magic link configuration
+	if enabled := os.Getenv("MAGIC_LINK_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.MagicLink.Enabled = e
+		}
+	}
+	if ttl := os.Getenv("MAGIC_LINK_TOKEN_TTL_MINUTES"); ttl != "" {
+		if i, err := strconv.Atoi(ttl); err == nil {
+			config.MagicLink.TokenTTLMinutes = i
+		}
+	}
+
+	// Knowledge factor configuration; questions are separated by "|" as
+	// they may contain commas
+	if enabled := os.Getenv("KNOWLEDGE_FACTORS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.KnowledgeFactors.Enabled = e
+		}
+	}
+	if questions := os.Getenv("KNOWLEDGE_FACTORS_QUESTIONS"); questions != "" {
+		config.KnowledgeFactors.Questions = nil
+		for _, question := range strings.Split(questions, "|") {
+			config.KnowledgeFactors.Questions = append(config.KnowledgeFactors.Questions, strings.TrimSpace(question))
+		}
+	}
+	if required := os.Getenv("KNOWLEDGE_FACTORS_REQUIRED_ANSWERS"); required != "" {
+		if i, err := strconv.Atoi(required); err == nil {
+			config.KnowledgeFactors.RequiredAnswers = i
+		}
+	}
+	if attempts := os.Getenv("KNOWLEDGE_FACTORS_MAX_ATTEMPTS"); attempts != "" {
+		if i, err := strconv.Atoi(attempts); err == nil {
+			config.KnowledgeFactors.MaxAttempts = i
+		}
+	}
+
+	// Breach response configuration
+	if enabled := os.Getenv("BREACH_RESPONSE_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.BreachResponse.Enabled = e
+		}
+	}
+	if topic := os.Getenv("BREACH_RESPONSE_TOPIC"); topic != "" {
+		config.BreachResponse.Topic = topic
+	}
+	if group := os.Getenv("BREACH_RESPONSE_CONSUMER_GROUP"); group != "" {
+		config.BreachResponse.ConsumerGroup = group
+	}
+
+	// Webhook configuration
+	if enabled := os.Getenv("WEBHOOKS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.Webhooks.Enabled = e
+		}
+	}
+	if group := os.Getenv("WEBHOOKS_CONSUMER_GROUP"); group != "" {
+		config.Webhooks.ConsumerGroup = group
+	}
+	if limit := os.Getenv("WEBHOOKS_MAX_PER_USER"); limit != "" {
+		if m, err := strconv.Atoi(limit); err == nil {
+			config.Webhooks.MaxPerUser = m
+		}
+	}
+	if timeout := os.Getenv("WEBHOOKS_TIMEOUT_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Webhooks.TimeoutMs = t
+		}
+	}
+	if allow := os.Getenv("WEBHOOKS_ALLOW_PRIVATE_NETWORKS"); allow != "" {
+		if a, err := strconv.ParseBool(allow); err == nil {
+			config.Webhooks.AllowPrivateNetworks = a
+		}
+	}
+
+	// Tracing configuration
+	if enabled := os.Getenv("TRACING_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.Tracing.Enabled = e
+		}
+	}
+	if endpoint := os.Getenv("TRACING_ENDPOINT"); endpoint != "" {
+		config.Tracing.Endpoint = endpoint
+	}
+	if insecure := os.Getenv("TRACING_INSECURE"); insecure != "" {
+		if i, err := strconv.ParseBool(insecure); err == nil {
+			config.Tracing.Insecure = i
+		}
+	}
+	if serviceName := os.Getenv("TRACING_SERVICE_NAME"); serviceName != "" {
+		config.Tracing.ServiceName = serviceName
+	}
+	if ratio := os.Getenv("TRACING_SAMPLE_RATIO"); ratio != "" {
+		if r, err := strconv.ParseFloat(ratio, 64); err == nil {
+			config.Tracing.SampleRatio = r
+		}
+	}
+
+	// API key configuration
+	if enabled := os.Getenv("API_KEYS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.APIKeys.Enabled = e
+		}
+	}
+	if routes := os.Getenv("API_KEYS_ROUTES"); routes != "" {
+		config.APIKeys.Routes = strings.Split(routes, ",")
+	}
+
+	// Moderation configuration
+	if enabled := os.Getenv("MODERATION_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.Moderation.Enabled = e
+		}
+	}
+	if threshold := os.Getenv("MODERATION_REPORT_THRESHOLD"); threshold != "" {
+		if t, err := strconv.Atoi(threshold); err == nil {
+			config.Moderation.ReportThreshold = t
+		}
+	}
+
+	// Cache admin configuration
+	if enabled := os.Getenv("CACHE_ADMIN_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.CacheAdmin.Enabled = e
+		}
+	}
+	if limit := os.Getenv("CACHE_ADMIN_SCAN_LIMIT"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			config.CacheAdmin.ScanLimit = l
+		}
+	}
+
+	// Admin UI configuration
+	if enabled := os.Getenv("ADMIN_UI_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.AdminUI.Enabled = e
+		}
+	}
+
+	// Consent configuration
+	if marketingEvents := os.Getenv("CONSENT_MARKETING_EVENTS"); marketingEvents != "" {
+		config.Consent.MarketingEvents = strings.Split(marketingEvents, ",")
+	}
+
+	// Bootstrap configuration
+	if enabled := os.Getenv("BOOTSTRAP_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.Bootstrap.Enabled = e
+		}
+	}
+	if minutes := os.Getenv("BOOTSTRAP_TOKEN_TTL_MINUTES"); minutes != "" {
+		if m, err := strconv.Atoi(minutes); err == nil {
+			config.Bootstrap.TokenTTLMinutes = m
+		}
+	}
+	if tokenFile := os.Getenv("BOOTSTRAP_TOKEN_FILE"); tokenFile != "" {
+		config.Bootstrap.TokenFile = tokenFile
+	}
+
+	// Email configuration
+	if enabled := os.Getenv("EMAIL_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.Email.Enabled = e
+		}
+	}
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		config.Email.Host = host
+	}
+	if port := os.Getenv("SMTP_PORT"); port != "" {
+		if p, err := strconv.Atoi(port); err == nil {
+			config.Email.Port = p
+		}
+	}
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		config.Email.Username = username
+	}
+	if password := os.Getenv("SMTP_PASSWORD"); password != "" {
+		config.Email.Password = password
+	}
+	if from := os.Getenv("EMAIL_FROM"); from != "" {
+		config.Email.From = from
+	}
+	if fromName := os.Getenv("EMAIL_FROM_NAME"); fromName != "" {
+		config.Email.FromName = fromName
+	}
+	if mode := os.Getenv("SMTP_TLS_MODE"); mode != "" {
+		config.Email.TLSMode = mode
+	}
+	loadTLSFromEnv("SMTP", &config.Email.TLS)
+	if timeout := os.Getenv("SMTP_TIMEOUT_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Email.TimeoutMs = t
+		}
+	}
+	if attempts := os.Getenv("SMTP_MAX_ATTEMPTS"); attempts != "" {
+		if a, err := strconv.Atoi(attempts); err == nil {
+			config.Email.MaxAttempts = a
+		}
+	}
+	if group := os.Getenv("EMAIL_CONSUMER_GROUP"); group != "" {
+		config.Email.ConsumerGroup = group
+	}
+
+	// Federation configuration
+	if successURL := os.Getenv("FEDERATION_SUCCESS_URL"); successURL != "" {
+		config.Federation.SuccessURL = successURL
+	}
+	if failureURL := os.Getenv("FEDERATION_FAILURE_URL"); failureURL != "" {
+		config.Federation.FailureURL = failureURL
+	}
+	if providers := os.Getenv("FEDERATION_PROVIDERS"); providers != "" {
+		for _, name := range strings.Split(providers, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if config.Federation.Providers == nil {
+				config.Federation.Providers = make(map[string]application.IdentityProviderConfig)
+			}
+			prefix := "FEDERATION_" + strings.ToUpper(name) + "_"
+			provider := config.Federation.Providers[name]
+			if providerType := os.Getenv(prefix + "TYPE"); providerType != "" {
+				provider.Type = providerType
+			} else if provider.Type == "" {
+				provider.Type = name
+			}
+			if clientID := os.Getenv(prefix + "CLIENT_ID"); clientID != "" {
+				provider.ClientID = clientID
+			}
+			if clientSecret := os.Getenv(prefix + "CLIENT_SECRET"); clientSecret != "" {
+				provider.ClientSecret = clientSecret
+			}
+			if redirectURL := os.Getenv(prefix + "REDIRECT_URL"); redirectURL != "" {
+				provider.RedirectURL = redirectURL
+			}
+			if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+				provider.Scopes = strings.Split(scopes, ",")
+			}
+			if authURL := os.Getenv(prefix + "AUTH_URL"); authURL != "" {
+				provider.AuthURL = authURL
+			}
+			if tokenURL := os.Getenv(prefix + "TOKEN_URL"); tokenURL != "" {
+				provider.TokenURL = tokenURL
+			}
+			if userInfoURL := os.Getenv(prefix + "USERINFO_URL"); userInfoURL != "" {
+				provider.UserInfoURL = userInfoURL
+			}
+			if tenant := os.Getenv(prefix + "TENANT"); tenant != "" {
+				provider.Tenant = tenant
+			}
+			if trustEmail := os.Getenv(prefix + "TRUST_EMAIL"); trustEmail != "" {
+				if t, err := strconv.ParseBool(trustEmail); err == nil {
+					provider.TrustEmail = t
+				}
+			}
+			config.Federation.Providers[name] = provider
+		}
+	}
+
+	// SSO configuration
+	if callbackURL := os.Getenv("SSO_CALLBACK_URL"); callbackURL != "" {
+		config.SSO.CallbackURL = callbackURL
+	}
+	if allow := os.Getenv("SSO_ALLOW_PRIVATE_NETWORKS"); allow != "" {
+		if a, err := strconv.ParseBool(allow); err == nil {
+			config.SSO.AllowPrivateNetworks = a
+		}
+	}
+
+	// Tenant configuration
+	if cacheSeconds := os.Getenv("TENANT_SETTINGS_CACHE_SECONDS"); cacheSeconds != "" {
+		if c, err := strconv.Atoi(cacheSeconds); err == nil {
+			config.Tenants.SettingsCacheSeconds = c
+		}
+	}
+
+	// Health check configuration
+	if timeout := os.Getenv("HEALTH_CHECK_TIMEOUT_MS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Health.CheckTimeoutMs = t
+		}
+	}
+	if interval := os.Getenv("HEALTH_CHECK_INTERVAL_SECONDS"); interval != "" {
+		if i, err := strconv.Atoi(interval); err == nil {
+			config.Health.CheckIntervalSeconds = i
+		}
+	}
+
+	// Server configuration
+	if mode := os.Getenv("SERVER_MODE"); mode != "" {
+		config.Server.Mode = mode
+	}
+	if message := os.Getenv("SERVER_MAINTENANCE_MESSAGE"); message != "" {
+		config.Server.MaintenanceMessage = message
+	}
+	if timeout := os.Getenv("SERVER_SHUTDOWN_TIMEOUT_SECONDS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil {
+			config.Server.ShutdownTimeout = t
+		}
+	}
+
+	// gRPC configuration
+	if enabled := os.Getenv("GRPC_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			config.GRPC.Enabled = e
+		}
+	}
+	if port := os.Getenv("GRPC_PORT"); port != "" {
+		if p, err := strconv.Atoi(port); err == nil {
+			config.GRPC.Port = p
+		}
+	}
+	if certFile := os.Getenv("GRPC_CERT_FILE"); certFile != "" {
+		config.GRPC.CertFile = certFile
+	}
+	if keyFile := os.Getenv("GRPC_KEY_FILE"); keyFile != "" {
+		config.GRPC.KeyFile = keyFile
+	}
+	if caFile := os.Getenv("GRPC_CLIENT_CA_FILE"); caFile != "" {
+		config.GRPC.ClientCAFile = caFile
+	}
+	if insecure := os.Getenv("GRPC_INSECURE"); insecure != "" {
+		if i, err := strconv.ParseBool(insecure); err == nil {
+			config.GRPC.Insecure = i
+		}
+	}
+
+	// Network configuration
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		config.Network.TrustedProxies = strings.Split(proxies, ",")
+	}
+	if allow := os.Getenv("IP_ALLOW"); allow != "" {
+		config.Network.Allow = strings.Split(allow, ",")
+	}
+	if deny := os.Getenv("IP_DENY"); deny != "" {
+		config.Network.Deny = strings.Split(deny, ",")
+	}
+	if header := os.Getenv("CLIENT_COUNTRY_HEADER"); header != "" {
+		config.Network.CountryHeader = header
+	}
+	if routes := os.Getenv("IP_FILTER_ROUTES"); routes != "" {
+		for _, name := range strings.Split(routes, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if config.Network.Routes == nil {
+				config.Network.Routes = make(map[string]application.IPRouteConfig)
+			}
+			prefix := "IP_FILTER_" + strings.ToUpper(name) + "_"
+			route := config.Network.Routes[name]
+			if path := os.Getenv(prefix + "PATH"); path != "" {
+				route.PathPrefix = path
+			}
+			if allow := os.Getenv(prefix + "ALLOW"); allow != "" {
+				route.Allow = strings.Split(allow, ",")
+			}
+			if deny := os.Getenv(prefix + "DENY"); deny != "" {
+				route.Deny = strings.Split(deny, ",")
+			}
+			config.Network.Routes[name] = route
+		}
+	}
+
+	// Web app configuration
+	if webAppURL := os.Getenv("WEBAPP_URL"); webAppURL != "" {
+		config.WebApp.URL = webAppURL
+	}
+	if successURL := os.Getenv("WEBAPP_VERIFY_EMAIL_SUCCESS_URL"); successURL != "" {
+		config.WebApp.VerifyEmailSuccessURL = successURL
+	}
+	if failureURL := os.Getenv("WEBAPP_VERIFY_EMAIL_FAILURE_URL"); failureURL != "" {
+		config.WebApp.VerifyEmailFailureURL = failureURL
+	}
+	if hosts := os.Getenv("WEBAPP_ALLOWED_REDIRECT_HOSTS"); hosts != "" {
+		config.WebApp.AllowedRedirectHosts = strings.Split(hosts, ",")
+	}
+}
+
+// loadTLSFromEnv loads TLS settings from environment variables with the given prefix
+func loadTLSFromEnv(prefix string, tlsConfig *application.TLSConfig) {
+	if enabled := os.Getenv(prefix + "_TLS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			tlsConfig.Enabled = e
+		}
+	}
+	if caFile := os.Getenv(prefix + "_TLS_CA_FILE"); caFile != "" {
+		tlsConfig.CAFile = caFile
+	}
+	if certFile := os.Getenv(prefix + "_TLS_CERT_FILE"); certFile != "" {
+		tlsConfig.CertFile = certFile
+	}
+	if keyFile := os.Getenv(prefix + "_TLS_KEY_FILE"); keyFile != "" {
+		tlsConfig.KeyFile = keyFile
+	}
+	if serverName := os.Getenv(prefix + "_TLS_SERVER_NAME"); serverName != "" {
+		tlsConfig.ServerName = serverName
+	}
+	if skipVerify := os.Getenv(prefix + "_TLS_INSECURE_SKIP_VERIFY"); skipVerify != "" {
+		if sv, err := strconv.ParseBool(skipVerify); err == nil {
+			tlsConfig.InsecureSkipVerify = sv
+		}
+	}
+}
+
+// validateTLS validates TLS settings for the named dependency
+func validateTLS(name string, tlsConfig application.TLSConfig) error {
+	if !tlsConfig.Enabled {
+		return nil
+	}
+	if (tlsConfig.CertFile == "") != (tlsConfig.KeyFile == "") {
+		return fmt.Errorf("%s TLS certificate and key files must be set together", name)
+	}
+	return nil
+}
+
+// validateRedirectURL ensures an optional redirect URL is an absolute http(s) URL
+func validateRedirectURL(name, rawURL string) error {
+	if rawURL == "" {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s redirect URL must be an absolute http(s) URL", name)
+	}
+	return nil
+}
+
+// validateCORS ensures the CORS origins are bare origins and credentials are
+// only shared with allowed, named origins
+func validateCORS(config application.Config) error {
+	allowed := make(map[string]bool, len(config.CORS.AllowedOrigins))
+	for _, origin := range config.CORS.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin != "*" && !isOrigin(origin) {
+			return fmt.Errorf("CORS origin %q must be * or scheme://host[:port]", origin)
+		}
+		allowed[origin] = true
+	}
+	for _, origin := range config.CORS.CredentialOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if !isOrigin(origin) {
+			return fmt.Errorf("CORS credential origin %q must be scheme://host[:port]", origin)
+		}
+		if !allowed[origin] && !allowed["*"] {
+			return fmt.Errorf("CORS credential origin %q must be an allowed origin", origin)
+		}
+	}
+	if config.CORS.MaxAgeSeconds < 0 {
+		return fmt.Errorf("CORS max age must not be negative")
+	}
+	return nil
+}
+
+// isOrigin reports whether s is an http(s) origin without path, query or
+// fragment
+func isOrigin(s string) bool {
+	u, err := url.Parse(strings.TrimSuffix(s, "/"))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.Path == "" && u.RawQuery == "" && u.Fragment == "" && u.User == nil
+}
+
+// validateIPRanges ensures each range is a CIDR range or a single address
+func validateIPRanges(name string, ranges []string) error {
+	for _, r := range ranges {
+		r = strings.TrimSpace(r)
+		if _, _, err := net.ParseCIDR(r); err == nil {
+			continue
+		}
+		if net.ParseIP(r) == nil {
+			return fmt.Errorf("%s must be CIDR ranges or IP addresses: %q", name, r)
+		}
+	}
+	return nil
+}
+
+// validateConfig validates the configuration
+func validateConfig(config application.Config) error {
+	// Storage validation
+	switch strings.ToLower(config.Storage.Driver) {
+	case "", application.StorageDriverPostgres:
+		if err := validateStores(config); err != nil {
+			return err
+		}
+	case application.StorageDriverMemory:
+		// Key pairs are shared through the database
+		if config.SigningKeys.UsesAsymmetricAlgorithm() {
+			return fmt.Errorf("asymmetric signing keys need postgres storage")
+		}
+	default:
+		return fmt.Errorf("storage driver must be postgres or memory")
+	}
+	if v := config.PrimaryKeys.UUIDVersion; v != 0 && v != 4 && v != 7 {
+		return fmt.Errorf("primary key UUID version must be 4 or 7")
+	}
+
+	// Auth validation
+	if config.Auth.AccessTokenDuration == 0 {
+		return fmt.Errorf("access token duration is required")
+	}
+	if config.Auth.RefreshTokenDuration == 0 {
+		return fmt.Errorf("refresh token duration is required")
+	}
+	if config.Auth.SigningKey == "" {
+		return fmt.Errorf("auth signing key is required")
+	}
+	if config.Auth.HashingCost == 0 {
+		config.Auth.HashingCost = 10 // Set default bcrypt cost
+	}
+
+	// Password hashing validation
+	if _, err := password.ParseHashingAlgorithm(config.PasswordHashing.Algorithm); err != nil {
+		return fmt.Errorf("password hashing: %w", err)
+	}
+	if config.PasswordHashing.Argon2MemoryKiB < 0 || config.PasswordHashing.Argon2Iterations < 0 ||
+		config.PasswordHashing.Argon2Parallelism < 0 || config.PasswordHashing.Argon2Parallelism > 255 {
+		return fmt.Errorf("argon2 memory and iterations must not be negative, and parallelism must be 0 to 255")
+	}
+
+	// Cookie validation
+	switch strings.ToLower(config.Cookies.SameSite) {
+	case "", "lax", "strict":
+	case "none":
+		if !config.Cookies.Secure {
+			return fmt.Errorf("cookies with SameSite=None must be secure")
+		}
+	default:
+		return fmt.Errorf("cookie SameSite must be one of lax, strict or none")
+	}
+
+	// CORS validation
+	if err := validateCORS(config); err != nil {
+		return err
+	}
+
+	// Search validation
+	if config.Search.Enabled {
+		if config.Search.URL == "" || config.Search.Index == "" {
+			return fmt.Errorf("search URL and index are required when search is enabled")
+		}
+		if config.Search.ConsumerGroup == "" {
+			return fmt.Errorf("search consumer group is required when search is enabled")
+		}
+		if config.Search.TimeoutMs < 0 {
+			return fmt.Errorf("search timeout must not be negative")
+		}
+		if err := validateTLS("search", config.Search.TLS); err != nil {
+			return err
+		}
+	}
+
+	// Egress validation
+	for _, proxy := range []string{config.Egress.HTTPProxy, config.Egress.HTTPSProxy} {
+		if proxy == "" {
+			continue
+		}
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			return fmt.Errorf("egress proxies must be http, https or socks5 URLs")
+		}
+	}
+	if config.Egress.TimeoutMs < 0 || config.Egress.DialTimeoutMs < 0 || config.Egress.TLSHandshakeTimeoutMs < 0 {
+		return fmt.Errorf("egress timeouts must not be negative")
+	}
+
+	// Avatar validation
+	if config.Avatars.GravatarSizePixels < 0 || config.Avatars.GravatarSizePixels > 2048 {
+		return fmt.Errorf("gravatar size must be between 1 and 2048 pixels")
+	}
+
+	// Device binding validation
+	for _, role := range config.DeviceBinding.Roles {
+		switch models.Role(strings.TrimSpace(role)) {
+		case models.RoleAdmin, models.RoleUser:
+		default:
+			return fmt.Errorf("unknown device binding role: %s", role)
+		}
+	}
+
+	// Login anomaly validation
+	if config.LoginAnomalies.ConfirmationTTLMinutes < 0 {
+		return fmt.Errorf("login confirmation TTL must not be negative")
+	}
+	if config.LoginAnomalies.RequireConfirmation && !config.LoginAnomalies.Enabled {
+		return fmt.Errorf("login confirmation requires login anomaly detection to be enabled")
+	}
+
+	// Registration risk validation
+	if config.RegistrationRisk.ChallengeScore < 0 || config.RegistrationRisk.ChallengeScore > 100 ||
+		config.RegistrationRisk.ReviewScore < 0 || config.RegistrationRisk.ReviewScore > 100 {
+		return fmt.Errorf("registration risk scores must be between 0 and 100")
+	}
+	if config.RegistrationRisk.ChallengeScore > 0 && config.RegistrationRisk.ReviewScore > 0 &&
+		config.RegistrationRisk.ReviewScore < config.RegistrationRisk.ChallengeScore {
+		return fmt.Errorf("registration risk review score must not be below the challenge score")
+	}
+	if config.RegistrationRisk.IPVelocityLimit < 0 || config.RegistrationRisk.DeviceVelocityLimit < 0 {
+		return fmt.Errorf("registration velocity limits must not be negative")
+	}
+
+	// Account risk validation
+	if config.AccountRisk.WatchAfterFailures < 0 || config.AccountRisk.RestrictAfterFailures < 0 ||
+		config.AccountRisk.FailureWindowMinutes < 0 || config.AccountRisk.StateTTLMinutes < 0 {
+		return fmt.Errorf("account risk thresholds and durations must not be negative")
+	}
+	if config.AccountRisk.WatchAfterFailures > 0 && config.AccountRisk.RestrictAfterFailures > 0 &&
+		config.AccountRisk.RestrictAfterFailures < config.AccountRisk.WatchAfterFailures {
+		return fmt.Errorf("account risk restrict threshold must not be below the watch threshold")
+	}
+	if config.AccountRisk.CaptchaVerifyURL != "" {
+		if _, err := url.ParseRequestURI(config.AccountRisk.CaptchaVerifyURL); err != nil {
+			return fmt.Errorf("invalid account risk captcha verify URL: %w", err)
+		}
+		if config.AccountRisk.CaptchaSecret == "" {
+			return fmt.Errorf("account risk captcha secret is required with a verify URL")
+		}
+	}
+
+	// Profile claims validation
+	if config.ProfileClaims.MaxAgeMinutes < 0 {
+		return fmt.Errorf("profile claims max age must not be negative")
+	}
+
+	// Session limit validation
+	if config.Sessions.MaxConcurrent < 0 {
+		return fmt.Errorf("maximum concurrent sessions must not be negative")
+	}
+	if action := config.Sessions.OnLimit; action != "" && !models.SessionLimitAction(action).IsValid() {
+		return fmt.Errorf("session limit action must be deny or evict_oldest")
+	}
+
+	// Profile completeness validation
+	for _, field := range config.Profile.Fields {
+		if !models.IsProfileField(field) {
+			return fmt.Errorf("unknown profile field: %s", field)
+		}
+	}
+	for _, field := range config.Profile.RequiredFields {
+		measured := config.Profile.Fields
+		if len(measured) == 0 {
+			measured = models.ProfileFields
+		}
+		if !slices.Contains(measured, field) {
+			return fmt.Errorf("required profile field %s is not a measured profile field", field)
+		}
+	}
+	for _, threshold := range config.Profile.CompletenessThresholds {
+		if threshold < 1 || threshold > 100 {
+			return fmt.Errorf("profile completeness thresholds must be between 1 and 100")
+		}
+	}
+
+	// Slow query log validation
+	if config.SlowQueryLog.ThresholdMs < 0 || config.SlowQueryLog.ErrorThresholdMs < 0 {
+		return fmt.Errorf("slow query thresholds must not be negative")
+	}
+	for method, threshold := range config.SlowQueryLog.MethodThresholdsMs {
+		if threshold < 0 {
+			return fmt.Errorf("slow query threshold for %s must not be negative", method)
+		}
+	}
+
+	// Tracing validation
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample ratio must be between 0 and 1")
+	}
+
+	// Timeout validation
+	if config.Timeouts.DatabaseReadMs < 0 || config.Timeouts.DatabaseWriteMs < 0 || config.Timeouts.CacheMs < 0 {
+		return fmt.Errorf("database and cache timeouts must not be negative")
+	}
+
+	// Signing key validation
+	if config.SigningKeys.RotationIntervalDays < 0 || config.SigningKeys.CheckIntervalMinutes < 0 || config.SigningKeys.SyncIntervalSeconds < 0 {
+		return fmt.Errorf("signing key rotation, check and sync intervals must not be negative")
+	}
+	for tokenType, key := range config.SigningKeys.Types {
+		if !isSigningKeyTokenType(services.TokenType(tokenType)) {
+			return fmt.Errorf("unknown signing key token type: %s", tokenType)
+		}
+		if key.Algorithm != "" && !services.IsSigningAlgorithm(strings.ToUpper(key.Algorithm)) {
+			return fmt.Errorf("unsupported signing algorithm for %s tokens: %s", tokenType, key.Algorithm)
+		}
+		if key.RotationIntervalDays < 0 {
+			return fmt.Errorf("signing key rotation interval for %s tokens must not be negative", tokenType)
+		}
+	}
+
+	// Degradation validation
+	switch config.Degradation.Redis.RevocationFailurePolicy() {
+	case services.RevocationFailClosed, services.RevocationSkipCheck:
+	default:
+		return fmt.Errorf("redis degradation policy must be one of fail_closed or skip_revocation_check")
+	}
+	if config.Degradation.Redis.MaxTokenAgeSeconds < 0 {
+		return fmt.Errorf("degraded max token age must not be negative")
+	}
+	switch strings.ToLower(config.Degradation.Kafka.OnFailure) {
+	case "", "fail":
+	case "spool":
+		if config.Degradation.Kafka.SpoolDir == "" {
+			return fmt.Errorf("kafka spool directory is required when spooling events")
+		}
+	default:
+		return fmt.Errorf("kafka degradation policy must be one of fail or spool")
+	}
+	if config.Degradation.Kafka.ReplayIntervalSeconds < 0 {
+		return fmt.Errorf("kafka spool replay interval must not be negative")
+	}
+
+	// Security summary validation
+	if config.SecuritySummary.CheckIntervalMinutes < 0 {
+		return fmt.Errorf("security summary check interval must not be negative")
+	}
+
+	// Account validation
+	if config.Account.UsernameChangeCooldownDays < 0 || config.Account.UsernameReservationDays < 0 {
+		return fmt.Errorf("username cooldown and reservation periods must not be negative")
+	}
+	if config.Account.MaxActiveResetTokens < 0 {
+		return fmt.Errorf("max active reset tokens must not be negative")
+	}
+	if config.Account.MaxVerificationEmailsPerDay < 0 {
+		return fmt.Errorf("max verification emails per day must not be negative")
+	}
+	if config.Account.VerificationResendsPerHour < 0 {
+		return fmt.Errorf("verification resends per hour must not be negative")
+	}
+	if config.Account.AdminPasswordResetsPerHour < 0 {
+		return fmt.Errorf("admin password resets per hour must not be negative")
+	}
+	if config.Account.PurgeApprovalMinutes < 0 || config.Account.PurgeApprovalMinutes > 24*60 {
+		return fmt.Errorf("purge approval window must be between 0 and 1440 minutes")
+	}
+	if config.Account.DeactivationGraceDays < 0 || config.Account.DeactivationPurgeIntervalMinutes < 0 {
+		return fmt.Errorf("deactivation grace period and purge interval must not be negative")
+	}
+	if config.Account.PasswordResetWorkers < 0 || config.Account.PasswordResetQueueSize < 0 {
+		return fmt.Errorf("password reset workers and queue size must not be negative")
+	}
+	if config.Account.PasswordResetsPerIPPerHour < 0 || config.Account.PasswordResetsPerAddressPerHour < 0 {
+		return fmt.Errorf("password reset limits must not be negative")
+	}
+
+	// Bootstrap validation
+	if config.Bootstrap.TokenTTLMinutes < 0 {
+		return fmt.Errorf("bootstrap token TTL must not be negative")
+	}
+
+	// Public profile validation
+	if config.PublicProfile.RequestsPerMinute < 0 || config.PublicProfile.CacheSeconds < 0 {
+		return fmt.Errorf("public profile rate limit and cache duration must not be negative")
+	}
+
+	// Rate limit validation
+	if config.RateLimits.SoftRequestsPerMinute < 0 {
+		return fmt.Errorf("soft rate limit must not be negative")
+	}
+
+	// OIDC validation
+	if config.OIDC.Enabled {
+		issuer, err := url.Parse(config.OIDC.Issuer)
+		if err != nil || (issuer.Scheme != "http" && issuer.Scheme != "https") || issuer.Host == "" ||
+			issuer.RawQuery != "" || issuer.Fragment != "" {
+			return fmt.Errorf("OIDC issuer must be an absolute http(s) URL without query or fragment")
+		}
+		accessAlgorithm := strings.ToUpper(config.SigningKeys.Types[string(services.TokenTypeAccess)].Algorithm)
+		if !services.IsAsymmetricAlgorithm(accessAlgorithm) {
+			return fmt.Errorf("OIDC requires access tokens to be signed with RS256 or ES256")
+		}
+		if err := validateRedirectURL("OIDC login", config.OIDC.LoginURL); err != nil {
+			return err
+		}
+		if config.OIDC.AuthorizationCodeTTLSeconds < 0 {
+			return fmt.Errorf("OIDC authorization code TTL must not be negative")
+		}
+		if config.OIDC.MaxBatchIntrospectionTokens < 0 {
+			return fmt.Errorf("OIDC batch introspection limit must not be negative")
+		}
+	}
+
+	// Audit log validation
+	if config.AuditLog.NotaryURL != "" {
+		notaryURL, err := url.Parse(config.AuditLog.NotaryURL)
+		if err != nil || (notaryURL.Scheme != "http" && notaryURL.Scheme != "https") || notaryURL.Host == "" {
+			return fmt.Errorf("audit log notary URL must be an absolute http(s) URL")
+		}
+	}
+	if config.AuditLog.NotarizeIntervalMinutes < 0 {
+		return fmt.Errorf("audit log notarize interval must not be negative")
+	}
+	if config.AuditLog.BufferSize < 0 {
+		return fmt.Errorf("audit log buffer size must not be negative")
+	}
+
+	// MFA validation
+	if strings.Contains(config.MFA.Issuer, ":") {
+		return fmt.Errorf("MFA issuer must not contain a colon")
+	}
+
+	// Passkey validation
+	if config.Passkeys.Enabled {
+		if config.Passkeys.RPID == "" || len(config.Passkeys.Origins) == 0 {
+			return fmt.Errorf("passkey relying party ID and origins are required when passkeys are enabled")
+		}
+		for _, origin := range config.Passkeys.Origins {
+			u, err := url.Parse(origin)
+			if err != nil || (u.Scheme != "https" && u.Hostname() != "localhost") {
+				return fmt.Errorf("passkey origin %q must be an https URL", origin)
+			}
+			if host := u.Hostname(); host != config.Passkeys.RPID && !strings.HasSuffix(host, "."+config.Passkeys.RPID) {
+				return fmt.Errorf("passkey origin %q is not on relying party %s", origin, config.Passkeys.RPID)
+			}
+		}
+	}
+
+	// Magic link validation; links stay short-lived since they sign in
+	// without a password
+	if config.MagicLink.TokenTTLMinutes < 0 || config.MagicLink.TokenTTLMinutes > 60 {
+		return fmt.Errorf("magic link token TTL must be between 0 and 60 minutes")
+	}
+
+	// Audience token validation; names and scopes end up in token claims
+	// and space-separated scope lists
+	for name, audience := range config.AudienceTokens.Audiences {
+		if name == "" || strings.ContainsFunc(name, unicode.IsSpace) {
+			return fmt.Errorf("audience names must be non-empty without spaces")
+		}
+		if len(audience.Scopes) == 0 {
+			return fmt.Errorf("audience %s needs at least one scope", name)
+		}
+		for _, scope := range audience.Scopes {
+			if scope == "" || strings.ContainsFunc(scope, unicode.IsSpace) {
+				return fmt.Errorf("audience %s scopes must be non-empty without spaces", name)
+			}
+		}
+		if audience.LifetimeMinutes < 0 {
+			return fmt.Errorf("audience %s token lifetime must not be negative", name)
+		}
+	}
+
+	// Knowledge factor validation; users must be able to pick enough
+	// distinct questions, each short enough to store
+	if config.KnowledgeFactors.RequiredAnswers < 0 || config.KnowledgeFactors.MaxAttempts < 0 {
+		return fmt.Errorf("knowledge factor required answers and max attempts must not be negative")
+	}
+	if config.KnowledgeFactors.Enabled {
+		required := config.KnowledgeFactors.RequiredAnswers
+		if required == 0 {
+			required = 3
+		}
+		seen := make(map[string]bool)
+		for _, question := range config.KnowledgeFactors.Questions {
+			if question == "" || len(question) > 255 {
+				return fmt.Errorf("knowledge factor questions must be 1 to 255 bytes")
+			}
+			seen[question] = true
+		}
+		if len(seen) < required {
+			return fmt.Errorf("knowledge factors need at least %d distinct questions", required)
+		}
+	}
+
+	// Breach response validation
+	if config.BreachResponse.Enabled {
+		if config.BreachResponse.Topic == "" || config.BreachResponse.ConsumerGroup == "" {
+			return fmt.Errorf("breach response topic and consumer group are required when breach response is enabled")
+		}
+		// The event published for a breach must not be reported again
+		if config.BreachResponse.Topic == string(events.UserCredentialsBreached) {
+			return fmt.Errorf("breach response topic must not be the %s event", events.UserCredentialsBreached)
+		}
+	}
+
+	// Webhook validation
+	if config.Webhooks.Enabled && config.Webhooks.ConsumerGroup == "" {
+		return fmt.Errorf("webhook consumer group is required when webhooks are enabled")
+	}
+	if config.Webhooks.MaxPerUser < 0 || config.Webhooks.TimeoutMs < 0 {
+		return fmt.Errorf("webhook limit and timeout must not be negative")
+	}
+
+	// API key validation
+	for _, route := range config.APIKeys.Routes {
+		if !strings.HasPrefix(route, "/") {
+			return fmt.Errorf("API key route %q must be a path starting with /", route)
+		}
+	}
+
+	// Moderation validation
+	if config.Moderation.ReportThreshold < 0 {
+		return fmt.Errorf("moderation report threshold must not be negative")
+	}
+
+	// Cache admin validation
+	if config.CacheAdmin.ScanLimit < 0 {
+		return fmt.Errorf("cache admin scan limit must not be negative")
+	}
+
+	// Email validation
+	if config.Email.Enabled {
+		if config.Email.Host == "" || config.Email.Port <= 0 || config.Email.Port > 65535 {
+			return fmt.Errorf("SMTP host and a valid port are required when email is enabled")
+		}
+		if _, err := mail.ParseAddress(config.Email.From); err != nil {
+			return fmt.Errorf("email sender address is invalid: %w", err)
+		}
+		if config.Email.ConsumerGroup == "" {
+			return fmt.Errorf("email consumer group is required when email is enabled")
+		}
+		switch strings.ToLower(config.Email.TLSMode) {
+		case "", "starttls", "implicit":
+		case "none":
+			if config.Email.Username != "" {
+				return fmt.Errorf("SMTP credentials require TLS")
+			}
+		default:
+			return fmt.Errorf("SMTP TLS mode must be one of starttls, implicit or none")
+		}
+	}
+	if config.Email.TimeoutMs < 0 || config.Email.MaxAttempts < 0 {
+		return fmt.Errorf("SMTP timeout and attempts must not be negative")
+	}
+
+	// Federation validation
+	for name, provider := range config.Federation.ProviderConfigs() {
+		if err := provider.Validate(); err != nil {
+			return fmt.Errorf("identity provider %s: %w", name, err)
+		}
+		if err := validateRedirectURL("identity provider "+name, provider.RedirectURL); err != nil {
+			return err
+		}
+	}
+	if err := validateRedirectURL("social login success", config.Federation.SuccessURL); err != nil {
+		return err
+	}
+	if err := validateRedirectURL("social login failure", config.Federation.FailureURL); err != nil {
+		return err
+	}
+	if config.Federation.SuccessURL != "" && !config.Cookies.Enabled {
+		return fmt.Errorf("social login success URL requires cookie mode")
+	}
+
+	// SSO validation
+	if err := validateRedirectURL("SSO callback", config.SSO.CallbackURL); err != nil {
+		return err
+	}
+
+	// Tenant validation
+	if config.Tenants.SettingsCacheSeconds < 0 {
+		return fmt.Errorf("tenant settings cache duration must not be negative")
+	}
+
+	// Health check validation
+	if config.Health.CheckTimeoutMs < 0 || config.Health.CheckIntervalSeconds < 0 {
+		return fmt.Errorf("health check timeout and interval must not be negative")
+	}
+
+	// Server validation
+	switch strings.ToLower(config.Server.Mode) {
+	case "", "normal", "read_only", "maintenance":
+	default:
+		return fmt.Errorf("server mode must be one of normal, read_only or maintenance")
+	}
+
+	// gRPC validation
+	if config.GRPC.Enabled {
+		if config.GRPC.Port <= 0 || config.GRPC.Port > 65535 {
+			return fmt.Errorf("gRPC port must be between 1 and 65535")
+		}
+		if config.GRPC.Port == config.Server.Port {
+			return fmt.Errorf("gRPC port must differ from the HTTP port")
+		}
+		if (config.GRPC.CertFile == "") != (config.GRPC.KeyFile == "") {
+			return fmt.Errorf("gRPC certificate and key files must be set together")
+		}
+		if config.GRPC.ClientCAFile != "" && config.GRPC.CertFile == "" {
+			return fmt.Errorf("gRPC client authentication requires a server certificate")
+		}
+		if config.GRPC.ClientCAFile == "" && !config.GRPC.Insecure {
+			return fmt.Errorf("gRPC client CA file is required unless gRPC is insecure")
+		}
+	}
+
+	// Network validation
+	if err := validateIPRanges("trusted proxies", config.Network.TrustedProxies); err != nil {
+		return err
+	}
+	// Clients could otherwise name their own country
+	if config.Network.CountryHeader != "" && len(config.Network.TrustedProxies) == 0 {
+		return fmt.Errorf("country header requires trusted proxies")
+	}
+	if err := validateIPRanges("allowed IPs", config.Network.Allow); err != nil {
+		return err
+	}
+	if err := validateIPRanges("denied IPs", config.Network.Deny); err != nil {
+		return err
+	}
+	for name, route := range config.Network.Routes {
+		if !strings.HasPrefix(route.PathPrefix, "/") {
+			return fmt.Errorf("IP filter route %s requires a path prefix starting with /", name)
+		}
+		if err := validateIPRanges("allowed IPs of route "+name, route.Allow); err != nil {
+			return err
+		}
+		if err := validateIPRanges("denied IPs of route "+name, route.Deny); err != nil {
+			return err
+		}
+	}
+
+	// Web app validation
+	if err := validateRedirectURL("email verification success", config.WebApp.VerifyEmailSuccessURL); err != nil {
+		return err
+	}
+	if err := validateRedirectURL("email verification failure", config.WebApp.VerifyEmailFailureURL); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateStores validates the connections to Postgres, Redis and the
+// event broker
+func validateStores(config application.Config) error {
+	// Database validation
+	if config.Database.Host == "" {
+		return fmt.Errorf("database host is required")
+	}
+	if config.Database.Port == 0 {
+		return fmt.Errorf("database port is required")
+	}
+	if config.Database.User == "" {
+		return fmt.Errorf("database user is required")
+	}
+	if config.Database.DBName == "" {
+		return fmt.Errorf("database name is required")
+	}
+	for _, replica := range config.Database.Replicas {
+		if replica.Host == "" || replica.Port <= 0 {
+			return fmt.Errorf("database replicas need a host and port")
+		}
+	}
+	if config.Database.ReplicaStalenessMs < 0 {
+		return fmt.Errorf("database replica staleness must not be negative")
+	}
+	if len(config.Residency.Clusters) > 0 && config.Residency.Region == "" {
+		return fmt.Errorf("residency clusters require the region of the deployment")
+	}
+	for region, cluster := range config.Residency.Clusters {
+		if region == config.Residency.Region {
+			return fmt.Errorf("residency cluster of region %s is the deployment's own database", region)
+		}
+		if cluster.Host == "" || cluster.Port == 0 || cluster.User == "" || cluster.DBName == "" {
+			return fmt.Errorf("residency cluster of region %s needs a host, port, user and database name", region)
+		}
+	}
+
+	// Redis validation
+	if config.Redis.Host == "" {
+		return fmt.Errorf("redis host is required")
+	}
+	if config.Redis.Port == 0 {
+		return fmt.Errorf("redis port is required")
+	}
+	if err := validateTLS("redis", config.Redis.TLS); err != nil {
+		return err
+	}
+
+	// Events validation
+	switch config.EventsDriver() {
+	case application.EventsDriverKafka:
+		return validateKafka(config)
+	case application.EventsDriverNATS:
+		if config.Events.NATS.URL == "" || config.Events.NATS.Stream == "" {
+			return fmt.Errorf("nats URL and stream are required")
+		}
+		if config.Events.NATS.PublishTimeoutMs < 0 {
+			return fmt.Errorf("nats publish timeout must not be negative")
+		}
+		if err := validateTLS("nats", config.Events.NATS.TLS); err != nil {
+			return err
+		}
+	case application.EventsDriverRabbitMQ:
+		if config.Events.RabbitMQ.URL == "" || config.Events.RabbitMQ.Exchange == "" {
+			return fmt.Errorf("rabbitmq URL and exchange are required")
+		}
+		if config.Events.RabbitMQ.PublishTimeoutMs < 0 {
+			return fmt.Errorf("rabbitmq publish timeout must not be negative")
+		}
+		if err := validateTLS("rabbitmq", config.Events.RabbitMQ.TLS); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("events driver must be kafka, nats or rabbitmq")
+	}
+
+	// Only the Kafka publisher routes tenant topics and spools events, and
+	// the service's own consumers read Kafka
+	if consumers := config.KafkaConsumers(); len(consumers) > 0 {
+		return fmt.Errorf("%s consume events from kafka and need the kafka events driver", strings.Join(consumers, ", "))
+	}
+	if len(config.Kafka.TenantTopics) > 0 {
+		return fmt.Errorf("kafka tenant topics need the kafka events driver")
+	}
+	if config.Degradation.Kafka.SpoolEnabled() {
+		return fmt.Errorf("the event spool needs the kafka events driver")
+	}
+	return nil
+}
+
+// validateKafka validates the Kafka connection and producer settings
+func validateKafka(config application.Config) error {
+	if len(config.Kafka.Brokers) == 0 {
+		return fmt.Errorf("kafka brokers are required")
+	}
+	if config.Kafka.Topic == "" {
+		return fmt.Errorf("kafka topic is required")
+	}
+	switch strings.ToLower(config.Kafka.RequiredAcks) {
+	case "", "none", "leader", "one", "all":
+	default:
+		return fmt.Errorf("kafka required acks must be one of none, leader or all")
+	}
+	switch strings.ToLower(config.Kafka.Compression) {
+	case "", "none", "gzip", "snappy", "lz4", "zstd":
+	default:
+		return fmt.Errorf("kafka compression must be one of none, gzip, snappy, lz4 or zstd")
+	}
+	if err := validateTLS("kafka", config.Kafka.TLS); err != nil {
+		return err
+	}
+	if config.Kafka.SASL.Mechanism != "" {
+		switch strings.ToLower(config.Kafka.SASL.Mechanism) {
+		case "plain", "scram-sha-256", "scram-sha-512":
+		default:
+			return fmt.Errorf("unsupported kafka SASL mechanism: %s", config.Kafka.SASL.Mechanism)
+		}
+		if config.Kafka.SASL.Username == "" {
+			return fmt.Errorf("kafka SASL username is required")
+		}
+	}
+	for organizationID, route := range config.Kafka.TenantTopics {
+		if _, err := uuid.Parse(organizationID); err != nil {
+			return fmt.Errorf("kafka tenant topics must be keyed by organization ID: %s", organizationID)
+		}
+		if (route.Topic == "") == (route.Prefix == "") {
+			return fmt.Errorf("kafka tenant topic of organization %s needs exactly one of topic or prefix", organizationID)
+		}
+	}
+
+	switch strings.ToLower(config.Kafka.Serialization) {
+	case "", "json":
+	case "avro":
+		if config.Kafka.SchemaRegistry.URL == "" {
+			return fmt.Errorf("avro serialization requires a schema registry URL")
+		}
+		if config.Kafka.SchemaRegistry.TimeoutMs < 0 {
+			return fmt.Errorf("schema registry timeout must not be negative")
+		}
+		if err := validateTLS("schema registry", config.Kafka.SchemaRegistry.TLS); err != nil {
+			return err
+		}
+		// The service's own consumers and tenant routing read JSON events
+		if consumers := config.KafkaConsumers(); len(consumers) > 0 {
+			return fmt.Errorf("%s consume JSON events and cannot be used with avro serialization", strings.Join(consumers, ", "))
+		}
+		if len(config.Kafka.TenantTopics) > 0 {
+			return fmt.Errorf("kafka tenant topics cannot be used with avro serialization")
+		}
+	default:
+		return fmt.Errorf("kafka serialization must be json or avro")
+	}
+	return nil
+}
+
+// isSigningKeyTokenType reports whether tokenType has its own signing key
+func isSigningKeyTokenType(tokenType services.TokenType) bool {
+	for _, t := range services.SigningKeyTokenTypes {
+		if t == tokenType {
+			return true
+		}
+	}
+	return false
 }
@@ -30,12 +30,29 @@ func TestLoadConfig(t *testing.T) {
 		"redis": {
 			"host": "redis.example.com",
 			"port": 6379,
+			"username": "identity",
 			"password": "redis_password",
-			"db": 1
+			"db": 1,
+			"tls": {
+				"enabled": true,
+				"serverName": "redis.example.com"
+			}
 		},
 		"kafka": {
 			"brokers": ["kafka1:9092", "kafka2:9092"],
-			"topic": "test_topic"
+			"topic": "test_topic",
+			"requiredAcks": "all",
+			"batchTimeoutMs": 5,
+			"compression": "snappy",
+			"writeTimeout": 10,
+			"tls": {
+				"enabled": true
+			},
+			"sasl": {
+				"mechanism": "scram-sha-512",
+				"username": "kafka_user",
+				"password": "kafka_password"
+			}
 		},
 		"auth": {
 			"accessTokenDuration": 30,
@@ -67,9 +84,20 @@ func TestLoadConfig(t *testing.T) {
 		assert.Equal(t, 6379, config.Redis.Port)
 		assert.Equal(t, "redis_password", config.Redis.Password)
 		assert.Equal(t, 1, config.Redis.DB)
+		assert.Equal(t, "identity", config.Redis.Username)
+		assert.True(t, config.Redis.TLS.Enabled)
+		assert.Equal(t, "redis.example.com", config.Redis.TLS.ServerName)
 
 		assert.Equal(t, []string{"kafka1:9092", "kafka2:9092"}, config.Kafka.Brokers)
 		assert.Equal(t, "test_topic", config.Kafka.Topic)
+		assert.Equal(t, "all", config.Kafka.RequiredAcks)
+		assert.Equal(t, 5, config.Kafka.BatchTimeoutMs)
+		assert.Equal(t, "snappy", config.Kafka.Compression)
+		assert.Equal(t, 10, config.Kafka.WriteTimeout)
+		assert.True(t, config.Kafka.TLS.Enabled)
+		assert.Equal(t, "scram-sha-512", config.Kafka.SASL.Mechanism)
+		assert.Equal(t, "kafka_user", config.Kafka.SASL.Username)
+		assert.Equal(t, "kafka_password", config.Kafka.SASL.Password)
 
 		assert.Equal(t, 30, config.Auth.AccessTokenDuration)
 		assert.Equal(t, 20160, config.Auth.RefreshTokenDuration)
@@ -133,172 +161,520 @@ func TestLoadConfig(t *testing.T) {
 	})
 }
 
+// validConfig returns a configuration that passes validation, which test
+// cases change to break one rule
+func validConfig() application.Config {
+	c := application.Config{}
+	c.Database.Host = "localhost"
+	c.Database.Port = 5432
+	c.Database.User = "user"
+	c.Database.DBName = "dbname"
+	c.Redis.Host = "localhost"
+	c.Redis.Port = 6379
+	c.Kafka.Brokers = []string{"localhost:9092"}
+	c.Kafka.Topic = "topic"
+	c.Auth.AccessTokenDuration = 15
+	c.Auth.RefreshTokenDuration = 10080
+	c.Auth.SigningKey = "key"
+	return c
+}
+
 func TestValidateConfig(t *testing.T) {
 	tests := []struct {
-		name        string
-		config      func() application.Config
-		expectError bool
-		errorMsg    string
+		name     string
+		mutate   func(c *application.Config) // nil validates validConfig as is
+		errorMsg string                      // empty when the config is valid
 	}{
 		{
 			name: "Valid config",
-			config: func() application.Config {
-				return application.Config{
-					Database: struct {
-						Host                   string
-						Port                   int
-						User                   string
-						Password               string
-						DBName                 string
-						SSLMode                string
-						MaxIdleConns           int
-						MaxOpenConns           int
-						ConnMaxLifetimeMinutes int
-					}{
-						Host:                   "localhost",
-						Port:                   5432,
-						User:                   "user",
-						DBName:                 "dbname",
-						MaxIdleConns:           10,
-						MaxOpenConns:           100,
-						ConnMaxLifetimeMinutes: 60,
-					},
-					Redis: struct {
-						Host     string
-						Port     int
-						Password string
-						DB       int
-					}{
-						Host: "localhost",
-						Port: 6379,
-					},
-					Kafka: struct {
-						Brokers []string
-						Topic   string
-					}{
-						Brokers: []string{"localhost:9092"},
-						Topic:   "topic",
-					},
-					Auth: struct {
-						AccessTokenDuration  int
-						RefreshTokenDuration int
-						SigningKey           string
-						HashingCost          int
-					}{
-						AccessTokenDuration:  15,
-						RefreshTokenDuration: 10080,
-						SigningKey:           "key",
-					},
-				}
+			mutate: func(c *application.Config) {
+				c.Database.MaxIdleConns = 10
+				c.Database.MaxOpenConns = 100
+				c.Database.ConnMaxLifetimeMinutes = 60
 			},
-			expectError: false,
 		},
 		{
 			name: "Missing database host",
-			config: func() application.Config {
-				c := application.Config{}
-				c.Database.Port = 5432
-				c.Database.User = "user"
-				c.Database.DBName = "dbname"
-				c.Redis.Host = "localhost"
-				c.Redis.Port = 6379
-				c.Kafka.Brokers = []string{"localhost:9092"}
-				c.Kafka.Topic = "topic"
-				c.Auth.AccessTokenDuration = 15
-				c.Auth.RefreshTokenDuration = 10080
-				c.Auth.SigningKey = "key"
-				return c
-			},
-			expectError: true,
-			errorMsg:    "database host is required",
+			mutate: func(c *application.Config) {
+				c.Database.Host = ""
+			},
+			errorMsg: "database host is required",
 		},
 		{
 			name: "Missing database port",
-			config: func() application.Config {
-				c := application.Config{}
-				c.Database.Host = "localhost"
-				c.Database.User = "user"
-				c.Database.DBName = "dbname"
-				c.Redis.Host = "localhost"
-				c.Redis.Port = 6379
-				c.Kafka.Brokers = []string{"localhost:9092"}
-				c.Kafka.Topic = "topic"
-				c.Auth.AccessTokenDuration = 15
-				c.Auth.RefreshTokenDuration = 10080
-				c.Auth.SigningKey = "key"
-				return c
-			},
-			expectError: true,
-			errorMsg:    "database port is required",
+			mutate: func(c *application.Config) {
+				c.Database.Port = 0
+			},
+			errorMsg: "database port is required",
 		},
 		{
 			name: "Missing redis host",
-			config: func() application.Config {
-				c := application.Config{}
-				c.Database.Host = "localhost"
-				c.Database.Port = 5432
-				c.Database.User = "user"
-				c.Database.DBName = "dbname"
-				c.Redis.Port = 6379
-				c.Kafka.Brokers = []string{"localhost:9092"}
-				c.Kafka.Topic = "topic"
-				c.Auth.AccessTokenDuration = 15
-				c.Auth.RefreshTokenDuration = 10080
-				c.Auth.SigningKey = "key"
-				return c
-			},
-			expectError: true,
-			errorMsg:    "redis host is required",
+			mutate: func(c *application.Config) {
+				c.Redis.Host = ""
+			},
+			errorMsg: "redis host is required",
 		},
 		{
 			name: "Missing kafka brokers",
-			config: func() application.Config {
-				c := application.Config{}
-				c.Database.Host = "localhost"
-				c.Database.Port = 5432
-				c.Database.User = "user"
-				c.Database.DBName = "dbname"
-				c.Redis.Host = "localhost"
-				c.Redis.Port = 6379
-				c.Kafka.Topic = "topic"
-				c.Auth.AccessTokenDuration = 15
-				c.Auth.RefreshTokenDuration = 10080
-				c.Auth.SigningKey = "key"
-				return c
-			},
-			expectError: true,
-			errorMsg:    "kafka brokers are required",
+			mutate: func(c *application.Config) {
+				c.Kafka.Brokers = nil
+			},
+			errorMsg: "kafka brokers are required",
+		},
+		{
+			name: "Invalid kafka compression",
+			mutate: func(c *application.Config) {
+				c.Kafka.Compression = "brotli"
+			},
+			errorMsg: "kafka compression must be one of",
+		},
+		{
+			name: "Kafka SASL without username",
+			mutate: func(c *application.Config) {
+				c.Kafka.SASL.Mechanism = "plain"
+			},
+			errorMsg: "kafka SASL username is required",
+		},
+		{
+			name: "Redis TLS certificate without key",
+			mutate: func(c *application.Config) {
+				c.Redis.TLS.Enabled = true
+				c.Redis.TLS.CertFile = "client.pem"
+			},
+			errorMsg: "redis TLS certificate and key files must be set together",
+		},
+		{
+			name: "Relative email verification redirect URL",
+			mutate: func(c *application.Config) {
+				c.WebApp.VerifyEmailSuccessURL = "/verified"
+			},
+			errorMsg: "email verification success redirect URL must be an absolute http(s) URL",
+		},
+		{
+			name: "SameSite=None cookies without secure",
+			mutate: func(c *application.Config) {
+				c.Cookies.Enabled = true
+				c.Cookies.SameSite = "none"
+			},
+			errorMsg: "cookies with SameSite=None must be secure",
+		},
+		{
+			name: "Unknown device binding role",
+			mutate: func(c *application.Config) {
+				c.DeviceBinding.Enabled = true
+				c.DeviceBinding.Roles = []string{"superuser"}
+			},
+			errorMsg: "unknown device binding role: superuser",
+		},
+		{
+			name: "Unsupported signing algorithm",
+			mutate: func(c *application.Config) {
+				c.SigningKeys.Types = map[string]application.SigningKeyConfig{
+					"access": {Algorithm: "none"},
+				}
+			},
+			errorMsg: "unsupported signing algorithm for access tokens: none",
+		},
+		{
+			name: "Kafka spool without directory",
+			mutate: func(c *application.Config) {
+				c.Degradation.Kafka.OnFailure = "spool"
+			},
+			errorMsg: "kafka spool directory is required when spooling events",
+		},
+		{
+			name: "Search enabled without URL",
+			mutate: func(c *application.Config) {
+				c.Search.Enabled = true
+				c.Search.Index = "users"
+				c.Search.ConsumerGroup = "search"
+			},
+			errorMsg: "search URL and index are required when search is enabled",
+		},
+		{
+			name: "Egress proxy without scheme",
+			mutate: func(c *application.Config) {
+				c.Egress.HTTPSProxy = "proxy.internal:3128"
+			},
+			errorMsg: "egress proxies must be http, https or socks5 URLs",
+		},
+		{
+			name: "OIDC with HMAC access tokens",
+			mutate: func(c *application.Config) {
+				c.OIDC.Enabled = true
+				c.OIDC.Issuer = "https://id.example.com"
+			},
+			errorMsg: "OIDC requires access tokens to be signed with RS256 or ES256",
+		},
+		{
+			name: "Audit log notary URL without scheme",
+			mutate: func(c *application.Config) {
+				c.AuditLog.Enabled = true
+				c.AuditLog.NotaryURL = "audit-bucket/checkpoints"
+			},
+			errorMsg: "audit log notary URL must be an absolute http(s) URL",
+		},
+		{
+			name: "Negative tenant settings cache duration",
+			mutate: func(c *application.Config) {
+				c.Tenants.SettingsCacheSeconds = -1
+			},
+			errorMsg: "tenant settings cache duration must not be negative",
+		},
+		{
+			name: "Negative health check interval",
+			mutate: func(c *application.Config) {
+				c.Health.CheckIntervalSeconds = -1
+			},
+			errorMsg: "health check timeout and interval must not be negative",
+		},
+		{
+			name: "Identity provider without client secret",
+			mutate: func(c *application.Config) {
+				c.Federation.Providers = map[string]application.IdentityProviderConfig{
+					"google": {
+						Type:        "google",
+						ClientID:    "client",
+						RedirectURL: "https://id.example.com/api/v1/auth/oauth/google/callback",
+					},
+				}
+			},
+			errorMsg: "identity provider google: client ID and secret are required",
+		},
+		{
+			name: "SSO callback URL not absolute",
+			mutate: func(c *application.Config) {
+				c.SSO.CallbackURL = "/api/v1/auth/sso"
+			},
+			errorMsg: "SSO callback redirect URL must be an absolute http(s) URL",
+		},
+		{
+			name: "MFA issuer with colon",
+			mutate: func(c *application.Config) {
+				c.MFA.Issuer = "Acme: Identity"
+			},
+			errorMsg: "MFA issuer must not contain a colon",
+		},
+		{
+			name: "Passkey origin outside relying party",
+			mutate: func(c *application.Config) {
+				c.Passkeys.Enabled = true
+				c.Passkeys.RPID = "example.com"
+				c.Passkeys.Origins = []string{"https://example.org"}
+			},
+			errorMsg: `passkey origin "https://example.org" is not on relying party example.com`,
+		},
+		{
+			name: "Magic link token TTL too long",
+			mutate: func(c *application.Config) {
+				c.MagicLink.Enabled = true
+				c.MagicLink.TokenTTLMinutes = 120
+			},
+			errorMsg: "magic link token TTL must be between 0 and 60 minutes",
+		},
+		{
+			name: "Knowledge factors with too few questions",
+			mutate: func(c *application.Config) {
+				c.KnowledgeFactors.Enabled = true
+				c.KnowledgeFactors.Questions = []string{"What was the name of your first school?", "What was the name of your first school?"}
+				c.KnowledgeFactors.RequiredAnswers = 2
+			},
+			errorMsg: "knowledge factors need at least 2 distinct questions",
+		},
+		{
+			name: "Breach response without consumer group",
+			mutate: func(c *application.Config) {
+				c.BreachResponse.Enabled = true
+				c.BreachResponse.Topic = "credentials.breached"
+			},
+			errorMsg: "breach response topic and consumer group are required when breach response is enabled",
+		},
+		{
+			name: "Webhooks without consumer group",
+			mutate: func(c *application.Config) {
+				c.Webhooks.Enabled = true
+			},
+			errorMsg: "webhook consumer group is required when webhooks are enabled",
+		},
+		{
+			name: "Email with unknown SMTP TLS mode",
+			mutate: func(c *application.Config) {
+				c.Email.Enabled = true
+				c.Email.Host = "smtp.example.com"
+				c.Email.Port = 587
+				c.Email.From = "no-reply@example.com"
+				c.Email.ConsumerGroup = "identity-service-email"
+				c.Email.TLSMode = "ssl"
+			},
+			errorMsg: "SMTP TLS mode must be one of starttls, implicit or none",
+		},
+		{
+			name: "gRPC without client authentication",
+			mutate: func(c *application.Config) {
+				c.GRPC.Enabled = true
+				c.GRPC.Port = 9090
+			},
+			errorMsg: "gRPC client CA file is required unless gRPC is insecure",
+		},
+		{
+			name: "Invalid allowed IP range",
+			mutate: func(c *application.Config) {
+				c.Network.Routes = map[string]application.IPRouteConfig{
+					"admin": {PathPrefix: "/api/v1/admin", Allow: []string{"10.0.0.0/33"}},
+				}
+			},
+			errorMsg: "allowed IPs of route admin must be CIDR ranges or IP addresses: \"10.0.0.0/33\"",
+		},
+		{
+			name: "Unknown session limit action",
+			mutate: func(c *application.Config) {
+				c.Sessions.MaxConcurrent = 3
+				c.Sessions.OnLimit = "evict_newest"
+			},
+			errorMsg: "session limit action must be deny or evict_oldest",
+		},
+		{
+			name: "Required profile field not measured",
+			mutate: func(c *application.Config) {
+				c.Profile.Fields = []string{"first_name", "last_name"}
+				c.Profile.RequiredFields = []string{"phone_number"}
+			},
+			errorMsg: "required profile field phone_number is not a measured profile field",
+		},
+		{
+			name: "Purge approval window too long",
+			mutate: func(c *application.Config) {
+				c.Account.PurgeApprovalMinutes = 2880
+			},
+			errorMsg: "purge approval window must be between 0 and 1440 minutes",
+		},
+		{
+			name: "Negative deactivation grace period",
+			mutate: func(c *application.Config) {
+				c.Account.DeactivationGraceDays = -1
+			},
+			errorMsg: "deactivation grace period and purge interval must not be negative",
+		},
+		{
+			name: "Negative bootstrap token TTL",
+			mutate: func(c *application.Config) {
+				c.Bootstrap.TokenTTLMinutes = -1
+			},
+			errorMsg: "bootstrap token TTL must not be negative",
+		},
+		{
+			name: "Login confirmation without anomaly detection",
+			mutate: func(c *application.Config) {
+				c.LoginAnomalies.RequireConfirmation = true
+			},
+			errorMsg: "login confirmation requires login anomaly detection to be enabled",
+		},
+		{
+			name: "Residency clusters without region",
+			mutate: func(c *application.Config) {
+				c.Residency.Clusters = map[string]application.RegionClusterConfig{
+					"us": {Host: "db.us.example.com", Port: 5432, User: "user", DBName: "dbname"},
+				}
+			},
+			errorMsg: "residency clusters require the region of the deployment",
+		},
+		{
+			name: "Database replica without port",
+			mutate: func(c *application.Config) {
+				c.Database.Replicas = []application.ReplicaConfig{{Host: "replica.example.com"}}
+			},
+			errorMsg: "database replicas need a host and port",
+		},
+		{
+			name: "Account risk captcha URL without secret",
+			mutate: func(c *application.Config) {
+				c.AccountRisk.CaptchaVerifyURL = "https://captcha.example.com/siteverify"
+			},
+			errorMsg: "account risk captcha secret is required with a verify URL",
+		},
+		{
+			name: "NATS events without stream",
+			mutate: func(c *application.Config) {
+				c.Kafka.Brokers = nil
+				c.Kafka.Topic = ""
+				c.Events.Driver = "nats"
+				c.Events.NATS.URL = "nats://localhost:4222"
+			},
+			errorMsg: "nats URL and stream are required",
+		},
+		{
+			name: "Email delivery with RabbitMQ events",
+			mutate: func(c *application.Config) {
+				c.Kafka.Brokers = nil
+				c.Kafka.Topic = ""
+				c.Events.Driver = "rabbitmq"
+				c.Events.RabbitMQ.URL = "amqp://localhost:5672/"
+				c.Events.RabbitMQ.Exchange = "events"
+				c.Email.Enabled = true
+			},
+			errorMsg: "email delivery consume events from kafka and need the kafka events driver",
+		},
+		{
+			name: "Memory storage without stores",
+			mutate: func(c *application.Config) {
+				// Memory storage needs none of the external stores
+				*c = application.Config{Auth: c.Auth}
+				c.Storage.Driver = "memory"
+			},
+		},
+		{
+			name: "Unknown storage driver",
+			mutate: func(c *application.Config) {
+				// Memory storage needs none of the external stores
+				*c = application.Config{Auth: c.Auth}
+				c.Storage.Driver = "sqlite"
+			},
+			errorMsg: "storage driver must be postgres or memory",
+		},
+		{
+			name: "Registration risk review score below challenge score",
+			mutate: func(c *application.Config) {
+				c.RegistrationRisk.ChallengeScore = 70
+				c.RegistrationRisk.ReviewScore = 60
+			},
+			errorMsg: "registration risk review score must not be below the challenge score",
+		},
+		{
+			name: "CORS credential origin not allowed",
+			mutate: func(c *application.Config) {
+				c.CORS.AllowedOrigins = []string{"https://app.example.com"}
+				c.CORS.CredentialOrigins = []string{"https://admin.example.com"}
+			},
+			errorMsg: "CORS credential origin \"https://admin.example.com\" must be an allowed origin",
+		},
+		{
+			name: "Negative admin password reset limit",
+			mutate: func(c *application.Config) {
+				c.Account.AdminPasswordResetsPerHour = -1
+			},
+			errorMsg: "admin password resets per hour must not be negative",
+		},
+		{
+			name: "Negative password reset queue size",
+			mutate: func(c *application.Config) {
+				c.Account.PasswordResetQueueSize = -1
+			},
+			errorMsg: "password reset workers and queue size must not be negative",
+		},
+		{
+			name: "Negative password reset limit",
+			mutate: func(c *application.Config) {
+				c.Account.PasswordResetsPerAddressPerHour = -1
+			},
+			errorMsg: "password reset limits must not be negative",
+		},
+		{
+			name: "Negative moderation report threshold",
+			mutate: func(c *application.Config) {
+				c.Moderation.ReportThreshold = -1
+			},
+			errorMsg: "moderation report threshold must not be negative",
+		},
+		{
+			name: "Negative cache admin scan limit",
+			mutate: func(c *application.Config) {
+				c.CacheAdmin.ScanLimit = -1
+			},
+			errorMsg: "cache admin scan limit must not be negative",
+		},
+		{
+			name: "API key route not a path",
+			mutate: func(c *application.Config) {
+				c.APIKeys.Routes = []string{"api/v1/admin/users/"}
+			},
+			errorMsg: `API key route "api/v1/admin/users/" must be a path starting with /`,
+		},
+		{
+			name: "Gravatar size out of range",
+			mutate: func(c *application.Config) {
+				c.Avatars.Gravatar = true
+				c.Avatars.GravatarSizePixels = 4096
+			},
+			errorMsg: "gravatar size must be between 1 and 2048 pixels",
+		},
+		{
+			name: "Unknown password hashing algorithm",
+			mutate: func(c *application.Config) {
+				c.PasswordHashing.Algorithm = "scrypt"
+			},
+			errorMsg: "unsupported hashing algorithm",
+		},
+		{
+			name: "Audience without scopes",
+			mutate: func(c *application.Config) {
+				c.AudienceTokens.Audiences = map[string]application.AudienceTokenConfig{
+					"billing-api": {},
+				}
+			},
+			errorMsg: "audience billing-api needs at least one scope",
+		},
+		{
+			name: "Negative profile claims max age",
+			mutate: func(c *application.Config) {
+				c.ProfileClaims.Enabled = true
+				c.ProfileClaims.MaxAgeMinutes = -5
+			},
+			errorMsg: "profile claims max age must not be negative",
+		},
+		{
+			name: "Unsupported primary key UUID version",
+			mutate: func(c *application.Config) {
+				c.PrimaryKeys.UUIDVersion = 1
+			},
+			errorMsg: "primary key UUID version must be 4 or 7",
+		},
+		{
+			name: "Tracing sample ratio above one",
+			mutate: func(c *application.Config) {
+				c.Tracing.SampleRatio = 1.5
+			},
+			errorMsg: "tracing sample ratio must be between 0 and 1",
+		},
+		{
+			name: "Negative soft rate limit",
+			mutate: func(c *application.Config) {
+				c.RateLimits.SoftRequestsPerMinute = -1
+			},
+			errorMsg: "soft rate limit must not be negative",
+		},
+		{
+			name: "Tenant topic with both topic and prefix",
+			mutate: func(c *application.Config) {
+				c.Kafka.TenantTopics = map[string]application.TenantTopicConfig{
+					"5b0e8a1c-3f4d-4a7e-9c2b-1d6f8e9a0b3c": {Topic: "acme.events", Prefix: "acme."},
+				}
+			},
+			errorMsg: "needs exactly one of topic or prefix",
+		},
+		{
+			name: "Avro serialization without schema registry",
+			mutate: func(c *application.Config) {
+				c.Kafka.Serialization = "avro"
+			},
+			errorMsg: "avro serialization requires a schema registry URL",
 		},
 		{
 			name: "Default hashing cost",
-			config: func() application.Config {
-				c := application.Config{}
-				c.Database.Host = "localhost"
-				c.Database.Port = 5432
-				c.Database.User = "user"
-				c.Database.DBName = "dbname"
-				c.Redis.Host = "localhost"
-				c.Redis.Port = 6379
-				c.Kafka.Brokers = []string{"localhost:9092"}
-				c.Kafka.Topic = "topic"
-				c.Auth.AccessTokenDuration = 15
-				c.Auth.RefreshTokenDuration = 10080
-				c.Auth.SigningKey = "key"
-				return c
-			},
-			expectError: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateConfig(tt.config())
-			if tt.expectError {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tt.errorMsg)
-			} else {
+			c := validConfig()
+			if tt.mutate != nil {
+				tt.mutate(&c)
+			}
+			err := validateConfig(c)
+			if tt.errorMsg == "" {
 				assert.NoError(t, err)
+				return
 			}
+			assert.ErrorContains(t, err, tt.errorMsg)
 		})
 	}
 }
@@ -0,0 +1,72 @@
+package consent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// EventPublisher decorates an event publisher to suppress marketing-relevant
+// events about users who opted out of marketing. Other events, and events
+// naming no user, are published unchanged.
+type EventPublisher struct {
+	services.EventPublisher
+	userRepo        repositories.UserRepository
+	marketingEvents map[string]bool
+	logger          *zap.Logger
+}
+
+// NewEventPublisher creates a new consent-aware event publisher suppressing
+// the given marketing-relevant event types
+func NewEventPublisher(eventPublisher services.EventPublisher, userRepo repositories.UserRepository, marketingEvents []string, logger *zap.Logger) *EventPublisher {
+	p := &EventPublisher{
+		EventPublisher:  eventPublisher,
+		userRepo:        userRepo,
+		marketingEvents: make(map[string]bool, len(marketingEvents)),
+		logger:          logger,
+	}
+	for _, eventType := range marketingEvents {
+		p.marketingEvents[eventType] = true
+	}
+	return p
+}
+
+// eventSubject holds the field of an event payload naming its user
+type eventSubject struct {
+	UserID uuid.UUID `json:"userId"`
+}
+
+// PublishUserEvent publishes the event unless it is marketing-relevant and
+// its user opted out. Marketing-relevant events are not published when the
+// user's consent cannot be checked.
+func (p *EventPublisher) PublishUserEvent(ctx context.Context, eventType string, payload interface{}) error {
+	if !p.marketingEvents[eventType] {
+		return p.EventPublisher.PublishUserEvent(ctx, eventType, payload)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	var subject eventSubject
+	if err := json.Unmarshal(data, &subject); err != nil || subject.UserID == uuid.Nil {
+		return p.EventPublisher.PublishUserEvent(ctx, eventType, payload)
+	}
+
+	user, err := p.userRepo.GetByID(ctx, subject.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to check marketing consent: %w", err)
+	}
+	if user.MarketingOptOut {
+		p.logger.Debug("suppressed event of user who opted out of marketing",
+			zap.String("eventType", eventType),
+			zap.String("userID", subject.UserID.String()))
+		return nil
+	}
+	return p.EventPublisher.PublishUserEvent(ctx, eventType, payload)
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/application/audit"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// AuditNotarizationJob periodically stores a checkpoint of the audit log
+// head with a notary, so that rewriting the log including its hashes is
+// detected by comparing against the notarized checkpoints
+type AuditNotarizationJob struct {
+	log           *audit.Log
+	notary        services.AuditNotary
+	logger        *zap.Logger
+	lastNotarized int64
+}
+
+// NewAuditNotarizationJob creates a new audit log notarization job
+func NewAuditNotarizationJob(log *audit.Log, notary services.AuditNotary, logger *zap.Logger) *AuditNotarizationJob {
+	return &AuditNotarizationJob{
+		log:    log,
+		notary: notary,
+		logger: logger,
+	}
+}
+
+// Start notarizes the audit log head every interval. It blocks until ctx is
+// cancelled.
+func (j *AuditNotarizationJob) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if checkpoint, err := j.Run(ctx); err != nil {
+			j.logger.Error("audit log notarization failed", zap.Error(err))
+		} else if checkpoint != nil {
+			j.logger.Info("notarized audit log checkpoint",
+				zap.Int64("sequence", checkpoint.Sequence),
+				zap.String("hash", checkpoint.Hash))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run notarizes the audit log head unless this job already notarized it and
+// returns the notarized checkpoint. Instances notarizing the same head
+// concurrently store the same checkpoint, which is harmless.
+func (j *AuditNotarizationJob) Run(ctx context.Context) (*models.AuditLogCheckpoint, error) {
+	checkpoint, err := j.log.Head(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil || checkpoint.Sequence <= j.lastNotarized {
+		return nil, nil
+	}
+
+	if err := j.notary.Notarize(ctx, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to notarize checkpoint %d: %w", checkpoint.Sequence, err)
+	}
+	j.lastNotarized = checkpoint.Sequence
+	return checkpoint, nil
+}
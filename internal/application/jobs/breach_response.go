@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// CredentialBreachReport is a message of the breach report topic. The user
+// is identified by ID, or by email when no ID is given.
+type CredentialBreachReport struct {
+	UserID uuid.UUID `json:"userId"`
+	Email  string    `json:"email"`
+	Source string    `json:"source"` // e.g. the breach feed that found the credentials
+}
+
+// BreachResponder responds to the breach reports of a topic by revoking the
+// sessions of the reported users and requiring a password reset
+type BreachResponder struct {
+	userService services.UserService
+	logger      *zap.Logger
+}
+
+// NewBreachResponder creates a new breach responder
+func NewBreachResponder(userService services.UserService, logger *zap.Logger) *BreachResponder {
+	return &BreachResponder{
+		userService: userService,
+		logger:      logger,
+	}
+}
+
+// HandleEvent responds to a breach report. Reports that cannot be decoded
+// or refer to an unknown user are skipped; any other failure is returned so
+// the report is retried.
+func (j *BreachResponder) HandleEvent(ctx context.Context, topic string, value []byte) error {
+	var report CredentialBreachReport
+	if err := json.Unmarshal(value, &report); err != nil || (report.UserID == uuid.Nil && report.Email == "") {
+		j.logger.Warn("skipping undecodable breach report",
+			zap.String("topic", topic),
+			zap.Error(err))
+		return nil
+	}
+	if report.Source == "" {
+		report.Source = topic
+	}
+
+	notified, err := j.userService.RespondToCredentialBreach(ctx, services.CredentialBreach{
+		UserID: report.UserID,
+		Email:  report.Email,
+		Source: report.Source,
+	})
+	if err != nil {
+		if stderrors.Is(err, errors.ErrUserNotFound) {
+			j.logger.Warn("skipping breach report of unknown user",
+				zap.String("topic", topic),
+				zap.String("source", report.Source))
+			return nil
+		}
+		return fmt.Errorf("failed to respond to credential breach: %w", err)
+	}
+
+	j.logger.Info("responded to credential breach",
+		zap.String("source", report.Source),
+		zap.Bool("notified", notified))
+	return nil
+}
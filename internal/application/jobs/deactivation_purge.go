@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// deactivationPurgeLockKey makes one instance purge at a time
+	deactivationPurgeLockKey = "deactivation_purge"
+	// deactivationPurgeActor is the actor recorded for purges after the
+	// grace period
+	deactivationPurgeActor = "system:deactivation-purge-job"
+)
+
+// DeactivationPurgeJob permanently deletes the accounts of users who deleted
+// them and did not restore them within the grace period
+type DeactivationPurgeJob struct {
+	userService  services.UserService
+	cacheService services.CacheService
+	logger       *zap.Logger
+}
+
+// NewDeactivationPurgeJob creates a new deactivation purge job
+func NewDeactivationPurgeJob(
+	userService services.UserService,
+	cacheService services.CacheService,
+	logger *zap.Logger,
+) *DeactivationPurgeJob {
+	return &DeactivationPurgeJob{
+		userService:  userService,
+		cacheService: cacheService,
+		logger:       logger,
+	}
+}
+
+// Start purges expired deactivations every interval. It blocks until ctx is
+// cancelled.
+func (j *DeactivationPurgeJob) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		j.run(ctx, interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run purges expired deactivations on one instance, using a cache lock held
+// for the interval
+func (j *DeactivationPurgeJob) run(ctx context.Context, interval time.Duration) {
+	acquired, err := j.cacheService.SetNX(ctx, deactivationPurgeLockKey, true, interval)
+	if err != nil {
+		j.logger.Error("failed to acquire deactivation purge lock", zap.Error(err))
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	purged, err := j.userService.PurgeDeactivatedUsers(events.WithActor(ctx, deactivationPurgeActor))
+	if err != nil {
+		j.logger.Error("failed to purge deactivated users", zap.Int("purged", purged), zap.Error(err))
+		return
+	}
+	if purged > 0 {
+		j.logger.Info("purged deactivated users", zap.Int("purged", purged))
+	}
+}
@@ -0,0 +1,224 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// emailClaimTTL is how long an instance may take to send an email before
+	// another may try again
+	emailClaimTTL = 5 * time.Minute
+	// emailIdempotencyTTL is how long redelivered events are recognized as
+	// already sent
+	emailIdempotencyTTL = 7 * 24 * time.Hour
+
+	emailClaimed = "sending"
+	emailSent    = "sent"
+)
+
+// errEmailInProgress is returned while another consumer sends an email, so
+// the event is retried once it finished or gave up
+var errEmailInProgress = stderrors.New("email is being sent by another consumer")
+
+// EmailEventTemplates maps the events that request an email to the
+// template it is rendered from
+var EmailEventTemplates = map[events.EventType]string{
+	events.UserRegistered:            models.EmailTemplateWelcome,
+	events.UserVerificationRequested: models.EmailTemplateVerification,
+	events.UserPasswordReset:         models.EmailTemplatePasswordReset,
+	events.UserMagicLinkRequested:    models.EmailTemplateMagicLink,
+	events.UserLoginNewDevice:        models.EmailTemplateNewDevice,
+
+	events.UserRecoveryEmailVerificationRequested: models.EmailTemplateRecoveryEmail,
+}
+
+// EmailDelivery sends the welcome, verification, password reset, magic link,
+// recovery email verification and new device emails requested by events, in the template of the user's
+// organization when it has one. Events are delivered at least once; each email is keyed by its
+// event ID so a redelivered event does not send it again.
+type EmailDelivery struct {
+	userRepo       repositories.UserRepository
+	tenantSettings services.TenantSettingsService
+	sender         services.EmailSender
+	cache          services.CacheService
+	metrics        services.MetricsService
+	logger         *zap.Logger
+}
+
+// NewEmailDelivery creates a new email delivery job
+func NewEmailDelivery(
+	userRepo repositories.UserRepository,
+	tenantSettings services.TenantSettingsService,
+	sender services.EmailSender,
+	cache services.CacheService,
+	metrics services.MetricsService,
+	logger *zap.Logger,
+) *EmailDelivery {
+	return &EmailDelivery{
+		userRepo:       userRepo,
+		tenantSettings: tenantSettings,
+		sender:         sender,
+		cache:          cache,
+		metrics:        metrics,
+		logger:         logger,
+	}
+}
+
+// HandleEvent sends the email an event requests. The topic is the event
+// type. Events that cannot be decoded, refer to an unknown user, were
+// already sent or are rejected by the mail server are skipped; any other
+// failure is returned so the event is retried.
+func (j *EmailDelivery) HandleEvent(ctx context.Context, topic string, value []byte) error {
+	name, ok := EmailEventTemplates[events.EventType(topic)]
+	if !ok {
+		return nil
+	}
+
+	var event struct {
+		ID               string    `json:"id"`
+		UserID           uuid.UUID `json:"userId"`
+		Email            string    `json:"email"`
+		VerificationLink string    `json:"verificationLink"`
+		ResetLink        string    `json:"resetLink"`
+		LoginLink        string    `json:"loginLink"`
+		ConfirmationLink string    `json:"confirmationLink"`
+		UserAgent        string    `json:"userAgent"`
+		IPAddress        string    `json:"ipAddress"`
+		Country          string    `json:"country"`
+		ExpiresAt        time.Time `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(value, &event); err != nil || event.UserID == uuid.Nil || event.Email == "" {
+		j.logger.Warn("skipping undecodable email event",
+			zap.String("topic", topic),
+			zap.Error(err))
+		return nil
+	}
+
+	user, err := j.userRepo.GetByID(ctx, event.UserID)
+	if err != nil {
+		if stderrors.Is(err, errors.ErrUserNotFound) || services.IsNotFoundError(err) {
+			j.logger.Warn("skipping email for unknown user",
+				zap.String("topic", topic),
+				zap.String("userID", event.UserID.String()))
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	settings, err := j.tenantSettings.Resolve(ctx, user.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve tenant settings: %w", err)
+	}
+
+	// The event ID is the idempotency key of the email
+	key := emailIdempotencyKey(event.ID, value)
+	claimed, err := j.claim(ctx, key)
+	if err != nil || !claimed {
+		return err
+	}
+
+	message := services.EmailMessage{
+		To:       event.Email,
+		Template: name,
+		Data: services.EmailData{
+			Email:     event.Email,
+			FirstName: user.FirstName,
+			Username:  user.Username,
+			Link:      event.VerificationLink,
+			ExpiresAt: event.ExpiresAt,
+		},
+	}
+	switch name {
+	case models.EmailTemplatePasswordReset:
+		message.Data.Link = event.ResetLink
+	case models.EmailTemplateMagicLink:
+		message.Data.Link = event.LoginLink
+	case models.EmailTemplateNewDevice:
+		message.Data.Link = event.ConfirmationLink
+		message.Data.Device = event.UserAgent
+		message.Data.IPAddress = event.IPAddress
+		message.Data.Country = event.Country
+	}
+	if tmpl, ok := settings.EmailTemplates[name]; ok {
+		message.Override = &tmpl
+	}
+
+	result := "sent"
+	err = j.sender.Send(ctx, message)
+	switch {
+	case err == nil:
+	case stderrors.Is(err, services.ErrEmailRejected):
+		result = "rejected"
+		j.logger.Error("email rejected",
+			zap.String("template", name),
+			zap.String("userID", event.UserID.String()),
+			zap.Error(err))
+	default:
+		j.release(ctx, key)
+		j.metrics.IncrementCounter("emails_total", map[string]string{"template": name, "result": "failed"})
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := j.cache.Set(ctx, key, emailSent, emailIdempotencyTTL); err != nil {
+		j.logger.Warn("failed to record sent email; a redelivered event sends it again",
+			zap.String("key", key),
+			zap.Error(err))
+	}
+	j.metrics.IncrementCounter("emails_total", map[string]string{"template": name, "result": result})
+	return nil
+}
+
+// claim reserves an email for this consumer. It returns false when the
+// email was already sent, and errEmailInProgress while another consumer
+// holds the claim.
+func (j *EmailDelivery) claim(ctx context.Context, key string) (bool, error) {
+	claimed, err := j.cache.SetNX(ctx, key, emailClaimed, emailClaimTTL)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim email: %w", err)
+	}
+	if claimed {
+		return true, nil
+	}
+
+	var state string
+	if err := j.cache.Get(ctx, key, &state); err != nil && !stderrors.Is(err, services.ErrCacheKeyNotFound) {
+		return false, fmt.Errorf("failed to check email state: %w", err)
+	}
+	if state == emailSent {
+		j.logger.Debug("skipping email already sent", zap.String("key", key))
+		return false, nil
+	}
+	return false, errEmailInProgress
+}
+
+// release drops the claim of an email that could not be sent so the retry
+// can claim it again
+func (j *EmailDelivery) release(ctx context.Context, key string) {
+	if err := j.cache.Delete(ctx, key); err != nil {
+		j.logger.Warn("failed to release email claim",
+			zap.String("key", key),
+			zap.Error(err))
+	}
+}
+
+// emailIdempotencyKey returns the cache key of the email an event requests.
+// Events without an ID are keyed by their content.
+func emailIdempotencyKey(eventID string, value []byte) string {
+	if eventID == "" {
+		sum := sha256.Sum256(value)
+		eventID = hex.EncodeToString(sum[:])
+	}
+	return "email_delivery:" + eventID
+}
@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// rotationLockTTL bounds how long a rotation lock is held, so that a
+	// crashed instance cannot block rotation indefinitely
+	rotationLockTTL = 10 * time.Minute
+	// keyRotationActor is the actor recorded for scheduled rotations
+	keyRotationActor = "system:key-rotation-job"
+)
+
+// KeyRotationJob rotates signing keys once they are older than the rotation
+// interval of their token type
+type KeyRotationJob struct {
+	tokenService services.TokenService
+	cacheService services.CacheService
+	logger       *zap.Logger
+}
+
+// NewKeyRotationJob creates a new signing key rotation job
+func NewKeyRotationJob(
+	tokenService services.TokenService,
+	cacheService services.CacheService,
+	logger *zap.Logger,
+) *KeyRotationJob {
+	return &KeyRotationJob{
+		tokenService: tokenService,
+		cacheService: cacheService,
+		logger:       logger,
+	}
+}
+
+// Start checks every interval for signing keys that are due for rotation. It
+// blocks until ctx is cancelled or the token service cannot rotate keys.
+func (j *KeyRotationJob) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.Run(ctx); err != nil {
+			if errors.Is(err, services.ErrKeyRotationUnsupported) {
+				j.logger.Warn("signing key rotation is not supported by the token service, stopping rotation job")
+				return
+			}
+			j.logger.Error("signing key rotation failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run rotates every signing key that is due for rotation and returns the
+// token types that were rotated. Each token type is rotated by one instance
+// only, using a cache lock.
+func (j *KeyRotationJob) Run(ctx context.Context) ([]services.TokenType, error) {
+	ctx = events.WithActor(ctx, keyRotationActor)
+	keys, err := j.tokenService.ListSigningKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+
+	var rotated []services.TokenType
+	for _, key := range keys {
+		if key.Status != services.SigningKeyRotationDue {
+			continue
+		}
+
+		lockKey := fmt.Sprintf("signing_key_rotation:%s:%s", key.TokenType, key.KeyID)
+		acquired, err := j.cacheService.SetNX(ctx, lockKey, true, rotationLockTTL)
+		if err != nil {
+			return rotated, fmt.Errorf("failed to acquire rotation lock: %w", err)
+		}
+		if !acquired {
+			continue
+		}
+
+		info, err := j.tokenService.RotateSigningKey(ctx, key.TokenType)
+		if err != nil {
+			if err := j.cacheService.Delete(ctx, lockKey); err != nil {
+				j.logger.Error("failed to release rotation lock", zap.Error(err))
+			}
+			return rotated, fmt.Errorf("failed to rotate %s signing key: %w", key.TokenType, err)
+		}
+
+		j.logger.Info("rotated signing key",
+			zap.String("tokenType", string(key.TokenType)),
+			zap.String("previousKeyId", key.KeyID),
+			zap.String("keyId", info.KeyID),
+			zap.String("algorithm", info.Algorithm))
+		rotated = append(rotated, key.TokenType)
+	}
+
+	return rotated, nil
+}
@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// reindexBatchSize is the number of users loaded per page when reindexing
+const reindexBatchSize = 100
+
+// SearchSyncEventTypes are the events after which a user's search document
+// is refreshed
+var SearchSyncEventTypes = []events.EventType{
+	events.UserRegistered,
+	events.UserVerified,
+	events.UserUpdated,
+	events.UserActivated,
+	events.UserSuspended,
+	events.UserPendingVerification,
+	events.UserDeactivated,
+	events.UserRestored,
+	events.UserDeleted,
+	events.UserPurged,
+	events.UserDeactivationPurged,
+}
+
+// SearchIndexSync keeps the user search index in sync with the event
+// stream. Events only identify the user; the document is always rebuilt
+// from the database, so replayed and reordered events are harmless.
+type SearchIndexSync struct {
+	userRepo repositories.UserRepository
+	index    services.UserSearchIndex
+	logger   *zap.Logger
+}
+
+// NewSearchIndexSync creates a new search index sync
+func NewSearchIndexSync(
+	userRepo repositories.UserRepository,
+	index services.UserSearchIndex,
+	logger *zap.Logger,
+) *SearchIndexSync {
+	return &SearchIndexSync{
+		userRepo: userRepo,
+		index:    index,
+		logger:   logger,
+	}
+}
+
+// Reindex indexes every user in the database, e.g. to fill a new index. It
+// can run while events are handled, since a document is never replaced by
+// an older version of itself.
+func (j *SearchIndexSync) Reindex(ctx context.Context) (int, error) {
+	indexed := 0
+	for offset := 0; ; offset += reindexBatchSize {
+		users, err := j.userRepo.List(ctx, offset, reindexBatchSize)
+		if err != nil {
+			return indexed, fmt.Errorf("failed to list users: %w", err)
+		}
+		for _, user := range users {
+			if err := j.index.IndexUser(ctx, services.NewUserDocument(user)); err != nil {
+				return indexed, fmt.Errorf("failed to index user %s: %w", user.ID, err)
+			}
+			indexed++
+		}
+		if len(users) < reindexBatchSize {
+			return indexed, nil
+		}
+	}
+}
+
+// HandleEvent refreshes the search document of the user an event refers to.
+// Events that cannot be decoded are skipped; any other failure is returned
+// so the event is retried.
+func (j *SearchIndexSync) HandleEvent(ctx context.Context, topic string, value []byte) error {
+	var event struct {
+		UserID uuid.UUID `json:"userId"`
+	}
+	if err := json.Unmarshal(value, &event); err != nil || event.UserID == uuid.Nil {
+		j.logger.Warn("skipping undecodable event for search index",
+			zap.String("topic", topic),
+			zap.Error(err))
+		return nil
+	}
+
+	user, err := j.userRepo.GetByID(ctx, event.UserID)
+	if err != nil && !stderrors.Is(err, errors.ErrUserNotFound) {
+		return fmt.Errorf("failed to load user for search index: %w", err)
+	}
+	if user == nil || user.Status == models.UserStatusDeleted {
+		return j.index.DeleteUser(ctx, event.UserID)
+	}
+	return j.index.IndexUser(ctx, services.NewUserDocument(user))
+}
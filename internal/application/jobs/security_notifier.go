@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// SecurityNotifier notifies the webhooks registered on an account of the
+// logins and security changes of the account. Deliveries are attempted
+// once: retrying the event would notify the webhooks that did receive it
+// again.
+type SecurityNotifier struct {
+	webhooks repositories.NotificationWebhookRepository
+	sender   services.WebhookSender
+	metrics  services.MetricsService
+	logger   *zap.Logger
+}
+
+// NewSecurityNotifier creates a new security notifier
+func NewSecurityNotifier(
+	webhooks repositories.NotificationWebhookRepository,
+	sender services.WebhookSender,
+	metrics services.MetricsService,
+	logger *zap.Logger,
+) *SecurityNotifier {
+	return &SecurityNotifier{
+		webhooks: webhooks,
+		sender:   sender,
+		metrics:  metrics,
+		logger:   logger,
+	}
+}
+
+// HandleEvent notifies the webhooks of the user an event refers to. The
+// topic is the event type. Events that cannot be decoded are skipped;
+// failing to load the webhooks is returned so the event is retried.
+func (j *SecurityNotifier) HandleEvent(ctx context.Context, topic string, value []byte) error {
+	var event struct {
+		ID        string          `json:"id"`
+		Timestamp time.Time       `json:"timestamp"`
+		UserID    uuid.UUID       `json:"userId"`
+		Email     string          `json:"email"`
+		Metadata  events.Metadata `json:"metadata"`
+	}
+	if err := json.Unmarshal(value, &event); err != nil || event.UserID == uuid.Nil {
+		j.logger.Warn("skipping undecodable event for webhooks",
+			zap.String("topic", topic),
+			zap.Error(err))
+		return nil
+	}
+
+	webhooks, err := j.webhooks.ListByUser(ctx, event.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	notification := services.WebhookNotification{
+		ID:         event.ID,
+		Event:      topic,
+		UserID:     event.UserID,
+		Email:      event.Email,
+		OccurredAt: event.Timestamp,
+		ClientIP:   event.Metadata.ClientIP,
+		UserAgent:  event.Metadata.UserAgent,
+	}
+	for _, webhook := range webhooks {
+		if !webhook.Wants(topic) {
+			continue
+		}
+
+		result := "delivered"
+		if err := j.sender.Send(ctx, webhook, notification); err != nil {
+			result = "failed"
+			j.logger.Warn("failed to deliver webhook notification",
+				zap.String("webhookID", webhook.ID.String()),
+				zap.String("userID", event.UserID.String()),
+				zap.String("event", topic),
+				zap.Error(err))
+		}
+		j.metrics.IncrementCounter("webhook_deliveries_total", map[string]string{
+			"event":  topic,
+			"format": string(webhook.Format),
+			"result": result,
+		})
+	}
+	return nil
+}
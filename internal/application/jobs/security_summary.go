@@ -0,0 +1,179 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// summaryBatchSize is the number of users loaded per page
+const summaryBatchSize = 100
+
+// SecuritySummaryJob publishes a monthly security summary event per active
+// user covering new devices, password changes and active sessions
+type SecuritySummaryJob struct {
+	userRepo        repositories.UserRepository
+	activityRepo    repositories.SecurityActivityRepository
+	tenantSettings  services.TenantSettingsService
+	eventPublisher  services.EventPublisher
+	cacheService    services.CacheService
+	clock           services.Clock
+	logger          *zap.Logger
+	sessionLifetime time.Duration
+	enabled         bool
+}
+
+// NewSecuritySummaryJob creates a new security summary job. Users are
+// summarized when enabled, unless their organization's settings turn
+// summaries off; organizations may also turn them on when not enabled.
+// Sessions started within sessionLifetime of the end of a period count as
+// active.
+func NewSecuritySummaryJob(
+	userRepo repositories.UserRepository,
+	activityRepo repositories.SecurityActivityRepository,
+	tenantSettings services.TenantSettingsService,
+	eventPublisher services.EventPublisher,
+	cacheService services.CacheService,
+	clock services.Clock,
+	logger *zap.Logger,
+	sessionLifetime time.Duration,
+	enabled bool,
+) *SecuritySummaryJob {
+	return &SecuritySummaryJob{
+		userRepo:        userRepo,
+		activityRepo:    activityRepo,
+		tenantSettings:  tenantSettings,
+		eventPublisher:  eventPublisher,
+		cacheService:    cacheService,
+		clock:           clock,
+		logger:          logger,
+		sessionLifetime: sessionLifetime,
+		enabled:         enabled,
+	}
+}
+
+// Start checks every interval whether the previous month has been summarized
+// and runs the job if not. It blocks until ctx is cancelled.
+func (j *SecuritySummaryJob) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		j.runPreviousMonth(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runPreviousMonth summarizes the previous calendar month once across all
+// instances, using a cache lock keyed by the month
+func (j *SecuritySummaryJob) runPreviousMonth(ctx context.Context) {
+	now := j.clock.Now().UTC()
+	periodEnd := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	periodStart := periodEnd.AddDate(0, -1, 0)
+
+	lockKey := fmt.Sprintf("security_summary:%s", periodStart.Format("2006-01"))
+	acquired, err := j.cacheService.SetNX(ctx, lockKey, true, 40*24*time.Hour)
+	if err != nil {
+		j.logger.Error("failed to acquire security summary lock", zap.Error(err))
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	if err := j.Run(ctx, periodStart, periodEnd); err != nil {
+		j.logger.Error("security summary job failed",
+			zap.Time("periodStart", periodStart),
+			zap.Error(err))
+		// Release the lock so the next check retries the month
+		if err := j.cacheService.Delete(ctx, lockKey); err != nil {
+			j.logger.Error("failed to release security summary lock", zap.Error(err))
+		}
+	}
+}
+
+// Run publishes a security summary event for every active user for the
+// period between from and to
+func (j *SecuritySummaryJob) Run(ctx context.Context, from, to time.Time) error {
+	j.logger.Info("running security summary job",
+		zap.Time("periodStart", from),
+		zap.Time("periodEnd", to))
+
+	published := 0
+	for offset := 0; ; offset += summaryBatchSize {
+		users, err := j.userRepo.List(ctx, offset, summaryBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list users: %w", err)
+		}
+
+		for _, user := range users {
+			if user.Status != models.UserStatusActive {
+				continue
+			}
+			enabled, err := j.summariesEnabled(ctx, user)
+			if err != nil {
+				return err
+			}
+			if !enabled {
+				continue
+			}
+
+			summary, err := j.activityRepo.Summarize(ctx, user.ID, from, to, to.Add(-j.sessionLifetime))
+			if err != nil {
+				return fmt.Errorf("failed to summarize activity for user %s: %w", user.ID, err)
+			}
+
+			event := events.NewUserSecuritySummaryEvent(
+				user.ID,
+				user.Email,
+				user.Username,
+				summary.PeriodStart,
+				summary.PeriodEnd,
+				summary.NewDevices,
+				summary.PasswordChanges,
+				summary.ActiveSessions,
+			)
+			if err := j.eventPublisher.PublishUserEvent(ctx, string(events.UserSecuritySummary), event); err != nil {
+				j.logger.Error("failed to publish security summary",
+					zap.String("userID", user.ID.String()),
+					zap.Error(err))
+				continue
+			}
+			published++
+		}
+
+		if len(users) < summaryBatchSize {
+			break
+		}
+	}
+
+	j.logger.Info("security summary job completed", zap.Int("published", published))
+	return nil
+}
+
+// summariesEnabled reports whether a user is summarized, by the settings of
+// their organization or else the service-wide setting
+func (j *SecuritySummaryJob) summariesEnabled(ctx context.Context, user *models.User) (bool, error) {
+	if user.OrganizationID == nil || j.tenantSettings == nil {
+		return j.enabled, nil
+	}
+	settings, err := j.tenantSettings.Resolve(ctx, user.OrganizationID)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve settings for user %s: %w", user.ID, err)
+	}
+	if settings.SecuritySummaries != nil {
+		return *settings.SecuritySummaries, nil
+	}
+	return j.enabled, nil
+}
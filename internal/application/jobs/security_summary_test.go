@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/application/tenant"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// emptyActivityRepository summarizes every user with no activity
+type emptyActivityRepository struct {
+	repositories.SecurityActivityRepository
+}
+
+func (emptyActivityRepository) Summarize(ctx context.Context, userID uuid.UUID, from, to, sessionsSince time.Time) (*models.SecuritySummary, error) {
+	return &models.SecuritySummary{UserID: userID, PeriodStart: from, PeriodEnd: to}, nil
+}
+
+type recordingPublisher struct {
+	mu      sync.Mutex
+	userIDs []uuid.UUID
+}
+
+func (p *recordingPublisher) PublishUserEvent(ctx context.Context, eventType string, payload interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.userIDs = append(p.userIDs, payload.(*events.UserSecuritySummaryEvent).UserID)
+	return nil
+}
+
+func TestSecuritySummaryTenantOverride(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	tenantSettings := tenant.NewService(memory.NewOrganizationSettingsRepository(store), memory.NewCacheService(), 0, zap.NewNop())
+
+	optedIn, optedOut := uuid.New(), uuid.New()
+	enabled, disabled := true, false
+	require.NoError(t, tenantSettings.SaveOverrides(ctx, &models.OrganizationSettings{OrganizationID: optedIn, SecuritySummaries: &enabled}))
+	require.NoError(t, tenantSettings.SaveOverrides(ctx, &models.OrganizationSettings{OrganizationID: optedOut, SecuritySummaries: &disabled}))
+
+	newUser := func(organizationID *uuid.UUID) uuid.UUID {
+		id := uuid.New()
+		require.NoError(t, userRepo.Create(ctx, &models.User{
+			ID:             id,
+			Email:          id.String() + "@example.com",
+			Username:       id.String(),
+			Status:         models.UserStatusActive,
+			OrganizationID: organizationID,
+		}))
+		return id
+	}
+	withoutOrganization := newUser(nil)
+	inOptedIn := newUser(&optedIn)
+	newUser(&optedOut)
+
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, -1, 0)
+	for _, tt := range []struct {
+		name    string
+		enabled bool
+		want    []uuid.UUID
+	}{
+		{name: "enabled service-wide", enabled: true, want: []uuid.UUID{withoutOrganization, inOptedIn}},
+		{name: "disabled service-wide", enabled: false, want: []uuid.UUID{inOptedIn}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingPublisher{}
+			job := NewSecuritySummaryJob(userRepo, emptyActivityRepository{}, tenantSettings, publisher,
+				memory.NewCacheService(), services.SystemClock, zap.NewNop(), time.Hour, tt.enabled)
+			require.NoError(t, job.Run(ctx, from, to))
+			assert.ElementsMatch(t, tt.want, publisher.userIDs)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package mfa
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// policiesKey caches the policy list, which every password login evaluates
+	policiesKey = "mfa_policies"
+	// policiesCacheTTL bounds how long other instances keep enforcing a
+	// changed policy in its old form
+	policiesCacheTTL = time.Minute
+)
+
+// PolicyService manages MFA enforcement policies and evaluates them for
+// users, together with the RequireMFA setting of their organization
+type PolicyService struct {
+	repo           repositories.MFAPolicyRepository
+	tenantSettings services.TenantSettingsService
+	cache          services.CacheService
+	logger         *zap.Logger
+}
+
+var _ services.MFAPolicyService = (*PolicyService)(nil)
+
+// NewPolicyService creates a new MFA policy service. tenantSettings may be
+// nil when organizations cannot require MFA.
+func NewPolicyService(
+	repo repositories.MFAPolicyRepository,
+	tenantSettings services.TenantSettingsService,
+	cache services.CacheService,
+	logger *zap.Logger,
+) *PolicyService {
+	return &PolicyService{
+		repo:           repo,
+		tenantSettings: tenantSettings,
+		cache:          cache,
+		logger:         logger,
+	}
+}
+
+// ListPolicies returns all policies, earliest enforcement first
+func (s *PolicyService) ListPolicies(ctx context.Context) ([]*models.MFAPolicy, error) {
+	return s.repo.List(ctx)
+}
+
+// GetPolicy retrieves a policy by ID
+func (s *PolicyService) GetPolicy(ctx context.Context, id uuid.UUID) (*models.MFAPolicy, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// CreatePolicy validates and stores a new policy
+func (s *PolicyService) CreatePolicy(ctx context.Context, policy *models.MFAPolicy) error {
+	if err := validatePolicy(policy); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, policy); err != nil {
+		return fmt.Errorf("failed to create MFA policy: %w", err)
+	}
+	s.invalidate(ctx)
+
+	s.logger.Info("created MFA policy",
+		zap.String("policyID", policy.ID.String()),
+		zap.Time("enforceAfter", policy.EnforceAfter))
+	return nil
+}
+
+// UpdatePolicy validates and replaces a policy
+func (s *PolicyService) UpdatePolicy(ctx context.Context, policy *models.MFAPolicy) error {
+	if err := validatePolicy(policy); err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, policy); err != nil {
+		return err
+	}
+	s.invalidate(ctx)
+
+	s.logger.Info("updated MFA policy",
+		zap.String("policyID", policy.ID.String()),
+		zap.Time("enforceAfter", policy.EnforceAfter))
+	return nil
+}
+
+// DeletePolicy removes a policy
+func (s *PolicyService) DeletePolicy(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate(ctx)
+
+	s.logger.Info("deleted MFA policy", zap.String("policyID", id.String()))
+	return nil
+}
+
+// Requirement evaluates the policies covering a user. An organization
+// requiring MFA counts as a policy in effect since forever.
+func (s *PolicyService) Requirement(ctx context.Context, user *models.User) (services.MFARequirement, error) {
+	var requirement services.MFARequirement
+
+	if s.tenantSettings != nil && user.OrganizationID != nil {
+		settings, err := s.tenantSettings.Resolve(ctx, user.OrganizationID)
+		if err != nil {
+			return requirement, fmt.Errorf("failed to resolve organization settings: %w", err)
+		}
+		if settings.RequireMFA {
+			return services.MFARequirement{Required: true}, nil
+		}
+	}
+
+	policies, err := s.cachedPolicies(ctx)
+	if err != nil {
+		return requirement, err
+	}
+	// Policies are ordered by enforcement, so the first match is the earliest
+	for _, policy := range policies {
+		if policy.AppliesTo(user) {
+			return services.MFARequirement{Required: true, EnforceAfter: policy.EnforceAfter}, nil
+		}
+	}
+	return requirement, nil
+}
+
+func (s *PolicyService) cachedPolicies(ctx context.Context) ([]*models.MFAPolicy, error) {
+	var policies []*models.MFAPolicy
+	err := s.cache.Get(ctx, policiesKey, &policies)
+	if err == nil {
+		return policies, nil
+	}
+	if !stderrors.Is(err, services.ErrCacheKeyNotFound) {
+		s.logger.Warn("failed to read cached MFA policies", zap.Error(err))
+	}
+
+	policies, err = s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MFA policies: %w", err)
+	}
+	if policies == nil {
+		policies = []*models.MFAPolicy{}
+	}
+	if err := s.cache.Set(ctx, policiesKey, policies, policiesCacheTTL); err != nil {
+		s.logger.Warn("failed to cache MFA policies", zap.Error(err))
+	}
+	return policies, nil
+}
+
+func (s *PolicyService) invalidate(ctx context.Context) {
+	if err := s.cache.Delete(ctx, policiesKey); err != nil {
+		s.logger.Warn("failed to invalidate cached MFA policies", zap.Error(err))
+	}
+}
+
+// validatePolicy checks that a policy can be evaluated
+func validatePolicy(policy *models.MFAPolicy) error {
+	policy.Name = strings.TrimSpace(policy.Name)
+	if policy.Name == "" {
+		return fmt.Errorf("%w: policy name is required", errors.ErrInvalidInput)
+	}
+	if policy.OrganizationID != nil && *policy.OrganizationID == uuid.Nil {
+		return fmt.Errorf("%w: invalid organization ID", errors.ErrInvalidInput)
+	}
+	for _, role := range policy.Roles {
+		if !role.IsValid() {
+			return fmt.Errorf("%w: invalid role %q", errors.ErrInvalidInput, role)
+		}
+	}
+	if policy.EnforceAfter.IsZero() {
+		return fmt.Errorf("%w: enforcement date is required", errors.ErrInvalidInput)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"go.uber.org/zap"
+)
+
+// Migration commands
+const (
+	MigrateUp      = "up"      // apply pending migrations
+	MigrateDown    = "down"    // roll back applied migrations
+	MigrateForce   = "force"   // record a version as applied after fixing a failed migration by hand
+	MigrateVersion = "version" // report the applied version only
+)
+
+// MigrationOptions holds the arguments of a migration command
+type MigrationOptions struct {
+	SourceDir string // directory of the migration files; empty uses the embedded files
+	// Steps is the number of migrations to apply or roll back; 0 applies
+	// every pending migration and is refused by down, which would drop
+	// every table
+	Steps   int
+	Version int // version recorded by force
+}
+
+// MigrationStatus is the schema version after a migration command
+type MigrationStatus struct {
+	Version uint // 0 when no migration was applied
+	Dirty   bool // the last migration failed part way and must be fixed by hand
+}
+
+// Migrate runs a migration command. Cancelling ctx stops after the current
+// migration.
+func (f *Factory) Migrate(ctx context.Context, command string, opts MigrationOptions) (MigrationStatus, error) {
+	migrator, err := f.CreateMigrator(opts.SourceDir)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			migrator.GracefulStop <- true
+		case <-done:
+		}
+	}()
+
+	switch command {
+	case MigrateUp:
+		if opts.Steps > 0 {
+			err = migrator.Steps(opts.Steps)
+		} else {
+			err = migrator.Up()
+		}
+	case MigrateDown:
+		if opts.Steps <= 0 {
+			return MigrationStatus{}, errors.New("rolling back requires a number of steps")
+		}
+		err = migrator.Steps(-opts.Steps)
+	case MigrateForce:
+		if opts.Version <= 0 {
+			return MigrationStatus{}, errors.New("forcing requires a version")
+		}
+		err = migrator.Force(opts.Version)
+	case MigrateVersion:
+	default:
+		return MigrationStatus{}, fmt.Errorf("unknown migration command %q", command)
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return MigrationStatus{}, err
+	}
+
+	version, dirty, err := migrator.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return MigrationStatus{}, nil
+	}
+	if err != nil {
+		return MigrationStatus{}, fmt.Errorf("failed to read the schema version: %w", err)
+	}
+	return MigrationStatus{Version: version, Dirty: dirty}, nil
+}
+
+// String describes the status for operators
+func (s MigrationStatus) String() string {
+	switch {
+	case s.Dirty:
+		return fmt.Sprintf("version %d (dirty: the last migration failed and must be fixed by hand, then forced)", s.Version)
+	case s.Version == 0:
+		return "no migrations applied"
+	default:
+		return fmt.Sprintf("version %d", s.Version)
+	}
+}
+
+// migrationLogger logs the progress of migrations
+type migrationLogger struct {
+	logger *zap.Logger
+}
+
+// Printf implements migrate.Logger
+func (l migrationLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+// Verbose implements migrate.Logger
+func (l migrationLogger) Verbose() bool {
+	return false
+}
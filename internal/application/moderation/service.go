@@ -0,0 +1,230 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// DefaultReportThreshold is used when no report threshold is configured
+const DefaultReportThreshold = 3
+
+const (
+	// maxReasonLength bounds the reason given for a flag
+	maxReasonLength = 2000
+
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// Service handles abuse reports and the restrictions of flagged accounts.
+// An account is restricted while it has a confirmed flag, an open flag
+// raised by an admin, or open reports from reportThreshold different users.
+type Service struct {
+	repo            repositories.AccountFlagRepository
+	userRepo        repositories.UserRepository
+	eventPublisher  services.EventPublisher
+	reportThreshold int
+	logger          *zap.Logger
+}
+
+var _ services.ModerationService = (*Service)(nil)
+
+// NewService creates a new moderation service. A reportThreshold of 0 uses
+// DefaultReportThreshold.
+func NewService(
+	repo repositories.AccountFlagRepository,
+	userRepo repositories.UserRepository,
+	eventPublisher services.EventPublisher,
+	reportThreshold int,
+	logger *zap.Logger,
+) *Service {
+	if reportThreshold <= 0 {
+		reportThreshold = DefaultReportThreshold
+	}
+	return &Service{
+		repo:            repo,
+		userRepo:        userRepo,
+		eventPublisher:  eventPublisher,
+		reportThreshold: reportThreshold,
+		logger:          logger,
+	}
+}
+
+// FlagAccount reports an account for review
+func (s *Service) FlagAccount(ctx context.Context, input services.FlagAccountInput) (*models.AccountFlag, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if !input.Category.IsValid() {
+		return nil, fmt.Errorf("%w: category must be spam, fraud, abuse or other", errors.ErrInvalidInput)
+	}
+	if reason == "" || len(reason) > maxReasonLength {
+		return nil, fmt.Errorf("%w: reason must be 1 to %d characters", errors.ErrInvalidInput, maxReasonLength)
+	}
+	if input.UserID == input.ReportedBy {
+		return nil, fmt.Errorf("%w: users cannot report themselves", errors.ErrInvalidInput)
+	}
+	if _, err := s.userRepo.GetByID(ctx, input.UserID); err != nil {
+		return nil, err
+	}
+
+	active, err := s.repo.ListActiveByUser(ctx, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flags: %w", err)
+	}
+	for _, flag := range active {
+		if flag.ReportedBy == input.ReportedBy && flag.ByAdmin == input.ByAdmin {
+			return nil, services.ErrAccountAlreadyReported
+		}
+	}
+	wasRestricted := s.restricted(active)
+
+	flag := &models.AccountFlag{
+		UserID:     input.UserID,
+		Category:   input.Category,
+		Reason:     reason,
+		ReportedBy: input.ReportedBy,
+		ByAdmin:    input.ByAdmin,
+		Status:     models.FlagStatusOpen,
+	}
+	if err := s.repo.Create(ctx, flag); err != nil {
+		return nil, fmt.Errorf("failed to create flag: %w", err)
+	}
+
+	s.logger.Info("flagged account",
+		zap.String("flagID", flag.ID.String()),
+		zap.String("userID", flag.UserID.String()),
+		zap.String("category", string(flag.Category)),
+		zap.Bool("byAdmin", flag.ByAdmin))
+	s.publish(ctx, events.AccountFlagged, events.NewAccountFlaggedEvent(
+		flag.ID, flag.UserID, string(flag.Category), flag.ReportedBy, flag.ByAdmin))
+	s.publishRestriction(ctx, flag, wasRestricted, s.restricted(append(active, flag)))
+
+	return flag, nil
+}
+
+// ListFlags returns the review queue, oldest first. A limit of 0 returns
+// the default page size; larger limits are capped.
+func (s *Service) ListFlags(ctx context.Context, filter repositories.AccountFlagFilter, offset, limit int) ([]*models.AccountFlag, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: status must be open, confirmed or dismissed", errors.ErrInvalidInput)
+	}
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("%w: offset and limit must not be negative", errors.ErrInvalidInput)
+	}
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	flags, err := s.repo.List(ctx, filter, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flags: %w", err)
+	}
+	return flags, nil
+}
+
+// ReviewFlag confirms or dismisses a flag, which may restrict the account
+// or lift its restriction
+func (s *Service) ReviewFlag(ctx context.Context, id uuid.UUID, input services.ReviewFlagInput) (*models.AccountFlag, error) {
+	if input.Status != models.FlagStatusConfirmed && input.Status != models.FlagStatusDismissed {
+		return nil, fmt.Errorf("%w: status must be confirmed or dismissed", errors.ErrInvalidInput)
+	}
+	flag, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.repo.ListActiveByUser(ctx, flag.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flags: %w", err)
+	}
+	wasRestricted := s.restricted(active)
+
+	if !flag.Review(input.Status, input.ReviewerID, strings.TrimSpace(input.Note)) {
+		return nil, services.ErrFlagAlreadyReviewed
+	}
+	if err := s.repo.Update(ctx, flag); err != nil {
+		return nil, fmt.Errorf("failed to update flag: %w", err)
+	}
+
+	// The reviewed flag replaces its stored state among the active flags
+	reviewed := make([]*models.AccountFlag, 0, len(active))
+	for _, f := range active {
+		if f.ID != flag.ID {
+			reviewed = append(reviewed, f)
+		}
+	}
+	if flag.IsActive() {
+		reviewed = append(reviewed, flag)
+	}
+
+	s.logger.Info("reviewed account flag",
+		zap.String("flagID", flag.ID.String()),
+		zap.String("userID", flag.UserID.String()),
+		zap.String("status", string(flag.Status)),
+		zap.String("reviewedBy", input.ReviewerID.String()))
+	s.publish(ctx, events.AccountFlagReviewed, events.NewAccountFlagReviewedEvent(
+		flag.ID, flag.UserID, string(flag.Status), input.ReviewerID))
+	s.publishRestriction(ctx, flag, wasRestricted, s.restricted(reviewed))
+
+	return flag, nil
+}
+
+// IsRestricted reports whether an account is restricted because of its
+// flags
+func (s *Service) IsRestricted(ctx context.Context, userID uuid.UUID) (bool, error) {
+	active, err := s.repo.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list flags: %w", err)
+	}
+	return s.restricted(active), nil
+}
+
+// restricted reports whether the active flags of an account restrict it
+func (s *Service) restricted(active []*models.AccountFlag) bool {
+	reporters := make(map[uuid.UUID]struct{})
+	for _, flag := range active {
+		switch {
+		case flag.Status == models.FlagStatusConfirmed:
+			return true
+		case flag.ByAdmin:
+			return true
+		default:
+			reporters[flag.ReportedBy] = struct{}{}
+		}
+	}
+	return len(reporters) >= s.reportThreshold
+}
+
+// publishRestriction publishes whether a flag restricted an account or
+// lifted its restriction
+func (s *Service) publishRestriction(ctx context.Context, flag *models.AccountFlag, was, is bool) {
+	switch {
+	case !was && is:
+		s.publish(ctx, events.AccountRestricted, events.NewAccountRestrictionEvent(events.AccountRestricted, flag.UserID, flag.ID))
+	case was && !is:
+		s.publish(ctx, events.AccountUnrestricted, events.NewAccountRestrictionEvent(events.AccountUnrestricted, flag.UserID, flag.ID))
+	}
+}
+
+// publish attributes an event to the actor of the request and publishes it
+func (s *Service) publish(ctx context.Context, eventType events.EventType, event interface{}) {
+	if e, ok := event.(interface{ SetMetadata(events.Metadata) }); ok {
+		e.SetMetadata(events.MetadataFromContext(ctx))
+	}
+
+	if err := s.eventPublisher.PublishUserEvent(ctx, string(eventType), event); err != nil {
+		s.logger.Error("failed to publish event",
+			zap.String("eventType", string(eventType)),
+			zap.Error(err))
+	}
+}
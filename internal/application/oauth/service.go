@@ -0,0 +1,789 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	clientIDBytes          = 16
+	clientSecretBytes      = 32
+	authorizationCodeBytes = 32
+
+	// DefaultAuthorizationCodeTTL is used when no code lifetime is configured
+	DefaultAuthorizationCodeTTL = time.Minute
+	// DefaultMaxBatchTokens is used when no batch introspection limit is
+	// configured
+	DefaultMaxBatchTokens = 100
+	// MaxSecretOverlap bounds how long a rotated client secret keeps working
+	MaxSecretOverlap = 30 * 24 * time.Hour
+)
+
+// Config holds the provider settings
+type Config struct {
+	// Issuer is the public base URL of the provider, which the endpoint
+	// URLs are derived from
+	Issuer               string
+	AuthorizationCodeTTL time.Duration // 0 uses DefaultAuthorizationCodeTTL
+	// SigningAlgorithm is the algorithm of ID tokens, which are signed with
+	// the access token key
+	SigningAlgorithm string
+	// MaxBatchTokens limits how many tokens a batch introspection request
+	// may carry; 0 uses DefaultMaxBatchTokens
+	MaxBatchTokens int
+}
+
+// Service implements the domain.OAuthService interface on top of the token
+// service: access and ID tokens are signed with the access token key and
+// authorization codes are kept in the cache.
+type Service struct {
+	config         Config
+	clients        repositories.OAuthClientRepository
+	userRepo       repositories.UserRepository
+	tokenService   services.TokenService
+	cacheService   services.CacheService
+	eventPublisher services.EventPublisher
+	moderation     services.ModerationService
+	clock          services.Clock
+	logger         *zap.Logger
+}
+
+var _ services.OAuthService = (*Service)(nil)
+
+// NewService creates a new OAuth service. Clients get only the openid scope
+// of accounts moderation restricts; a nil moderation service restricts none.
+func NewService(
+	config Config,
+	clients repositories.OAuthClientRepository,
+	userRepo repositories.UserRepository,
+	tokenService services.TokenService,
+	cacheService services.CacheService,
+	eventPublisher services.EventPublisher,
+	moderation services.ModerationService,
+	clock services.Clock,
+	logger *zap.Logger,
+) *Service {
+	config.Issuer = strings.TrimRight(config.Issuer, "/")
+	if config.AuthorizationCodeTTL <= 0 {
+		config.AuthorizationCodeTTL = DefaultAuthorizationCodeTTL
+	}
+	return &Service{
+		config:         config,
+		clients:        clients,
+		userRepo:       userRepo,
+		tokenService:   tokenService,
+		cacheService:   cacheService,
+		eventPublisher: eventPublisher,
+		moderation:     moderation,
+		clock:          clock,
+		logger:         logger,
+	}
+}
+
+// authorizationCode is what an issued code grants. It is cached under the
+// hash of the code, so the cache never holds usable codes.
+type authorizationCode struct {
+	ClientID      string    `json:"clientId"`
+	UserID        uuid.UUID `json:"userId"`
+	RedirectURI   string    `json:"redirectUri"`
+	Scope         string    `json:"scope"`
+	Nonce         string    `json:"nonce,omitempty"`
+	CodeChallenge string    `json:"codeChallenge,omitempty"`
+	AuthTime      time.Time `json:"authTime,omitempty"` // of the user's last sign-in
+}
+
+func authorizationCodeKey(codeHash string) string {
+	return fmt.Sprintf("oauth_code:%s", codeHash)
+}
+
+func authorizationCodeUsedKey(codeHash string) string {
+	return fmt.Sprintf("oauth_code_used:%s", codeHash)
+}
+
+// RegisterClient registers a client and returns it with its secret
+func (s *Service) RegisterClient(ctx context.Context, registration services.OAuthClientRegistration) (*models.OAuthClient, string, error) {
+	if err := validateRegistration(registration); err != nil {
+		return nil, "", err
+	}
+
+	clientID, err := randomToken(clientIDBytes, hex.EncodeToString)
+	if err != nil {
+		return nil, "", err
+	}
+	client := &models.OAuthClient{
+		ClientID:     clientID,
+		Name:         strings.TrimSpace(registration.Name),
+		RedirectURIs: registration.RedirectURIs,
+		GrantTypes:   registration.GrantTypes,
+		Scopes:       registration.Scopes,
+	}
+
+	secret := ""
+	if !registration.Public {
+		secret, err = randomToken(clientSecretBytes, base64.RawURLEncoding.EncodeToString)
+		if err != nil {
+			return nil, "", err
+		}
+		client.SetSecret(secret)
+	}
+
+	if err := s.clients.Create(ctx, client); err != nil {
+		return nil, "", fmt.Errorf("failed to register client: %w", err)
+	}
+
+	s.logger.Info("registered OAuth client",
+		zap.String("clientId", client.ClientID),
+		zap.String("name", client.Name))
+	s.publishClientEvent(ctx, events.OAuthClientRegistered, client)
+	return client, secret, nil
+}
+
+// validateRegistration checks that a client can use the grants it asks for
+func validateRegistration(registration services.OAuthClientRegistration) error {
+	if strings.TrimSpace(registration.Name) == "" {
+		return fmt.Errorf("%w: client name is required", errors.ErrInvalidInput)
+	}
+	if len(registration.GrantTypes) == 0 {
+		return fmt.Errorf("%w: at least one grant type is required", errors.ErrInvalidInput)
+	}
+	for _, grantType := range registration.GrantTypes {
+		switch grantType {
+		case models.GrantAuthorizationCode:
+			if len(registration.RedirectURIs) == 0 {
+				return fmt.Errorf("%w: the authorization code grant requires a redirect URI", errors.ErrInvalidInput)
+			}
+		case models.GrantClientCredentials:
+			if registration.Public {
+				return fmt.Errorf("%w: public clients cannot use the client credentials grant", errors.ErrInvalidInput)
+			}
+		default:
+			return fmt.Errorf("%w: unsupported grant type %q", errors.ErrInvalidInput, grantType)
+		}
+	}
+	for _, redirectURI := range registration.RedirectURIs {
+		u, err := url.Parse(redirectURI)
+		if err != nil || !u.IsAbs() || u.Host == "" || u.Fragment != "" {
+			return fmt.Errorf("%w: redirect URI must be an absolute URL without a fragment: %s", errors.ErrInvalidInput, redirectURI)
+		}
+	}
+	for _, scope := range registration.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \t\n\"\\") {
+			return fmt.Errorf("%w: invalid scope %q", errors.ErrInvalidInput, scope)
+		}
+	}
+	return nil
+}
+
+// ListClients retrieves every registered client
+func (s *Service) ListClients(ctx context.Context) ([]*models.OAuthClient, error) {
+	clients, err := s.clients.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clients: %w", err)
+	}
+	return clients, nil
+}
+
+// UpdateClient changes the name, redirect URIs or scopes of a client. The
+// changed client must still be able to use its grants.
+func (s *Service) UpdateClient(ctx context.Context, clientID string, update services.OAuthClientUpdate) (*models.OAuthClient, error) {
+	client, err := s.clients.GetByClientID(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("client not found: %w", err)
+	}
+
+	if name := strings.TrimSpace(update.Name); name != "" {
+		client.Name = name
+	}
+	if update.RedirectURIs != nil {
+		client.RedirectURIs = update.RedirectURIs
+	}
+	if update.Scopes != nil {
+		client.Scopes = update.Scopes
+	}
+	if err := validateRegistration(services.OAuthClientRegistration{
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		GrantTypes:   client.GrantTypes,
+		Scopes:       client.Scopes,
+		Public:       !client.Confidential(),
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.clients.Update(ctx, client); err != nil {
+		return nil, fmt.Errorf("failed to update client: %w", err)
+	}
+
+	s.logger.Info("updated OAuth client", zap.String("clientId", client.ClientID))
+	s.publishClientEvent(ctx, events.OAuthClientUpdated, client)
+	return client, nil
+}
+
+// DisableClient stops a client from authenticating and signing users in.
+// Access tokens already issued to it are reported inactive by introspection
+// while it is disabled; resource servers that validate tokens themselves
+// accept them until they expire.
+func (s *Service) DisableClient(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	client, err := s.clients.GetByClientID(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("client not found: %w", err)
+	}
+	if client.Disabled() {
+		return client, nil
+	}
+
+	now := s.clock.Now()
+	client.DisabledAt = &now
+	if err := s.clients.Update(ctx, client); err != nil {
+		return nil, fmt.Errorf("failed to update client: %w", err)
+	}
+
+	s.logger.Info("disabled OAuth client", zap.String("clientId", client.ClientID))
+	s.publishClientEvent(ctx, events.OAuthClientDisabled, client)
+	return client, nil
+}
+
+// EnableClient lets a disabled client authenticate and sign users in again
+func (s *Service) EnableClient(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	client, err := s.clients.GetByClientID(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("client not found: %w", err)
+	}
+	if !client.Disabled() {
+		return client, nil
+	}
+
+	client.DisabledAt = nil
+	if err := s.clients.Update(ctx, client); err != nil {
+		return nil, fmt.Errorf("failed to update client: %w", err)
+	}
+
+	s.logger.Info("enabled OAuth client", zap.String("clientId", client.ClientID))
+	s.publishClientEvent(ctx, events.OAuthClientEnabled, client)
+	return client, nil
+}
+
+// DeleteClient removes a client
+func (s *Service) DeleteClient(ctx context.Context, clientID string) error {
+	client, err := s.clients.GetByClientID(ctx, clientID)
+	if err != nil {
+		return fmt.Errorf("client not found: %w", err)
+	}
+	if err := s.clients.Delete(ctx, clientID); err != nil {
+		return fmt.Errorf("failed to delete client: %w", err)
+	}
+	s.logger.Info("deleted OAuth client", zap.String("clientId", clientID))
+	s.publishClientEvent(ctx, events.OAuthClientDeleted, client)
+	return nil
+}
+
+// RegenerateClientSecret replaces the secret of a confidential client and
+// publishes an audit event attributed to the actor in the context. The old
+// secret keeps working for overlap; a secret replaced before by a rotation
+// with overlap stops working immediately.
+func (s *Service) RegenerateClientSecret(ctx context.Context, clientID string, overlap time.Duration) (*models.OAuthClient, string, error) {
+	if overlap < 0 || overlap > MaxSecretOverlap {
+		return nil, "", fmt.Errorf("%w: secret overlap must be between 0 and %s", errors.ErrInvalidInput, MaxSecretOverlap)
+	}
+	client, err := s.clients.GetByClientID(ctx, clientID)
+	if err != nil {
+		return nil, "", fmt.Errorf("client not found: %w", err)
+	}
+	if !client.Confidential() {
+		return nil, "", fmt.Errorf("%w: public clients have no secret", errors.ErrInvalidInput)
+	}
+
+	secret, err := randomToken(clientSecretBytes, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, "", err
+	}
+	client.RotateSecret(secret, overlap)
+	if err := s.clients.Update(ctx, client); err != nil {
+		return nil, "", fmt.Errorf("failed to update client: %w", err)
+	}
+
+	s.publish(ctx, events.OAuthClientSecretRegenerated,
+		events.NewOAuthClientSecretRegeneratedEvent(client.ClientID, client.Name, client.PreviousSecretExpiresAt))
+	return client, secret, nil
+}
+
+// publishClientEvent publishes an audit event about a client
+func (s *Service) publishClientEvent(ctx context.Context, eventType events.EventType, client *models.OAuthClient) {
+	s.publish(ctx, eventType, events.NewOAuthClientEvent(eventType, client.ClientID, client.Name,
+		!client.Confidential(), client.RedirectURIs, client.Scopes))
+}
+
+// publish publishes an audit event attributed to the actor in the context
+func (s *Service) publish(ctx context.Context, eventType events.EventType, event interface{ SetMetadata(events.Metadata) }) {
+	event.SetMetadata(events.MetadataFromContext(ctx))
+	if err := s.eventPublisher.PublishUserEvent(ctx, string(eventType), event); err != nil {
+		s.logger.Error("failed to publish OAuth client audit event",
+			zap.String("eventType", string(eventType)),
+			zap.Error(err))
+	}
+}
+
+// ValidateRedirect checks the client and redirect URI of an authorization request
+func (s *Service) ValidateRedirect(ctx context.Context, request services.AuthorizationRequest) error {
+	client, err := s.clients.GetByClientID(ctx, request.ClientID)
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return services.NewOAuthError(services.OAuthInvalidRequest, "unknown client_id")
+		}
+		return fmt.Errorf("failed to get client: %w", err)
+	}
+	if client.Disabled() {
+		return services.NewOAuthError(services.OAuthInvalidRequest, "client is disabled")
+	}
+	if !client.AllowsRedirectURI(request.RedirectURI) {
+		return services.NewOAuthError(services.OAuthInvalidRequest, "redirect_uri is not registered for the client")
+	}
+	return nil
+}
+
+// Authorize grants an authorization code to the client for the signed-in
+// user. The request must have passed ValidateRedirect.
+func (s *Service) Authorize(ctx context.Context, userID uuid.UUID, request services.AuthorizationRequest) (string, error) {
+	if request.ResponseType != "code" {
+		return "", services.NewOAuthError(services.OAuthUnsupportedResponseType, "only the code response type is supported")
+	}
+
+	client, err := s.clients.GetByClientID(ctx, request.ClientID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get client: %w", err)
+	}
+	if client.Disabled() {
+		return "", services.NewOAuthError(services.OAuthUnauthorizedClient, "client is disabled")
+	}
+	if !client.AllowsGrant(models.GrantAuthorizationCode) {
+		return "", services.NewOAuthError(services.OAuthUnauthorizedClient, "client may not use the authorization code grant")
+	}
+	if !client.AllowsScopes(strings.Fields(request.Scope)) {
+		return "", services.NewOAuthError(services.OAuthInvalidScope, "scope is not registered for the client")
+	}
+	if request.CodeChallenge != "" && request.CodeChallengeMethod != "S256" {
+		return "", services.NewOAuthError(services.OAuthInvalidRequest, "code_challenge_method must be S256")
+	}
+	if request.CodeChallenge == "" && !client.Confidential() {
+		return "", services.NewOAuthError(services.OAuthInvalidRequest, "public clients must use PKCE")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("user not found: %w", err)
+	}
+	if !user.Status.CanAuthenticate() {
+		return "", services.NewOAuthError(services.OAuthAccessDenied, "account is disabled")
+	}
+
+	code, err := randomToken(authorizationCodeBytes, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+	grant := authorizationCode{
+		ClientID:      client.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   request.RedirectURI,
+		Scope:         request.Scope,
+		Nonce:         request.Nonce,
+		CodeChallenge: request.CodeChallenge,
+	}
+	if user.LastLoginAt != nil {
+		grant.AuthTime = *user.LastLoginAt
+	}
+	if err := s.cacheService.Set(ctx, authorizationCodeKey(hashToken(code)), grant, s.config.AuthorizationCodeTTL); err != nil {
+		return "", fmt.Errorf("failed to store authorization code: %w", err)
+	}
+	return code, nil
+}
+
+// Token exchanges an authorization code or client credentials for tokens
+func (s *Service) Token(ctx context.Context, request services.OAuthTokenRequest) (*services.OAuthTokens, error) {
+	client, err := s.authenticateClient(ctx, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch models.OAuthGrantType(request.GrantType) {
+	case models.GrantAuthorizationCode:
+		if !client.AllowsGrant(models.GrantAuthorizationCode) {
+			return nil, services.NewOAuthError(services.OAuthUnauthorizedClient, "client may not use the authorization code grant")
+		}
+		return s.exchangeAuthorizationCode(ctx, client, request)
+	case models.GrantClientCredentials:
+		if !client.AllowsGrant(models.GrantClientCredentials) || !client.Confidential() {
+			return nil, services.NewOAuthError(services.OAuthUnauthorizedClient, "client may not use the client credentials grant")
+		}
+		return s.issueClientCredentials(ctx, client, request.Scope)
+	default:
+		return nil, services.NewOAuthError(services.OAuthUnsupportedGrantType, "")
+	}
+}
+
+// authenticateClient looks up the client of a token request. Confidential
+// clients must present their secret; public clients must not have one.
+func (s *Service) authenticateClient(ctx context.Context, clientID, secret string) (*models.OAuthClient, error) {
+	if clientID == "" {
+		return nil, services.NewOAuthError(services.OAuthInvalidClient, "client authentication is required")
+	}
+	client, err := s.clients.GetByClientID(ctx, clientID)
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return nil, services.NewOAuthError(services.OAuthInvalidClient, "client authentication failed")
+		}
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+	if client.Confidential() && !client.VerifySecret(secret) || !client.Confidential() && secret != "" {
+		return nil, services.NewOAuthError(services.OAuthInvalidClient, "client authentication failed")
+	}
+	if client.Disabled() {
+		return nil, services.NewOAuthError(services.OAuthInvalidClient, "client is disabled")
+	}
+	return client, nil
+}
+
+// exchangeAuthorizationCode redeems a code. Each code is redeemed at most once.
+func (s *Service) exchangeAuthorizationCode(ctx context.Context, client *models.OAuthClient, request services.OAuthTokenRequest) (*services.OAuthTokens, error) {
+	invalidGrant := services.NewOAuthError(services.OAuthInvalidGrant, "authorization code is invalid or expired")
+	if request.Code == "" {
+		return nil, invalidGrant
+	}
+	codeHash := hashToken(request.Code)
+
+	var grant authorizationCode
+	if err := s.cacheService.Get(ctx, authorizationCodeKey(codeHash), &grant); err != nil {
+		if stderrors.Is(err, services.ErrCacheKeyNotFound) {
+			return nil, invalidGrant
+		}
+		return nil, fmt.Errorf("failed to get authorization code: %w", err)
+	}
+	redeemed, err := s.cacheService.SetNX(ctx, authorizationCodeUsedKey(codeHash), true, s.config.AuthorizationCodeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem authorization code: %w", err)
+	}
+	if !redeemed {
+		return nil, invalidGrant
+	}
+	if err := s.cacheService.Delete(ctx, authorizationCodeKey(codeHash)); err != nil {
+		s.logger.Warn("failed to delete redeemed authorization code", zap.Error(err))
+	}
+
+	if grant.ClientID != client.ClientID || grant.RedirectURI != request.RedirectURI {
+		return nil, invalidGrant
+	}
+	if grant.CodeChallenge != "" && !verifyCodeChallenge(grant.CodeChallenge, request.CodeVerifier) {
+		return nil, services.NewOAuthError(services.OAuthInvalidGrant, "code_verifier does not match the code challenge")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, grant.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if !user.Status.CanAuthenticate() {
+		return nil, invalidGrant
+	}
+	if s.moderation != nil {
+		restricted, err := s.moderation.IsRestricted(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check account restrictions: %w", err)
+		}
+		// Clients may only sign in flagged accounts, not act on their data
+		if restricted && hasScope(strings.Fields(grant.Scope), services.ScopeOpenID) {
+			grant.Scope = services.ScopeOpenID
+		} else if restricted {
+			grant.Scope = ""
+		}
+	}
+
+	// Tokens issued to clients carry no role, so they never grant access to
+	// the admin API of this service
+	claims := services.TokenClaims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Username:  user.Username,
+		TokenType: services.TokenTypeAccess,
+		ClientID:  client.ClientID,
+		Scope:     grant.Scope,
+	}
+	if user.OrganizationID != nil {
+		claims.TenantID = user.OrganizationID.String()
+	}
+	accessToken, err := s.tokenService.GenerateAccessToken(ctx, claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	tokens := &services.OAuthTokens{
+		AccessToken: accessToken,
+		ExpiresIn:   s.tokenService.TokenDuration(services.TokenTypeAccess),
+		Scope:       grant.Scope,
+	}
+	scopes := strings.Fields(grant.Scope)
+	if hasScope(scopes, services.ScopeOpenID) {
+		tokens.IDToken, err = s.tokenService.GenerateIDToken(ctx, services.IDTokenClaims{
+			Issuer:   s.config.Issuer,
+			Audience: client.ClientID,
+			Nonce:    grant.Nonce,
+			AuthTime: grant.AuthTime,
+			UserInfo: userInfo(user, scopes),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ID token: %w", err)
+		}
+	}
+	return tokens, nil
+}
+
+// issueClientCredentials issues an access token to the client itself
+func (s *Service) issueClientCredentials(ctx context.Context, client *models.OAuthClient, scope string) (*services.OAuthTokens, error) {
+	if !client.AllowsScopes(strings.Fields(scope)) {
+		return nil, services.NewOAuthError(services.OAuthInvalidScope, "scope is not registered for the client")
+	}
+
+	accessToken, err := s.tokenService.GenerateAccessToken(ctx, services.TokenClaims{
+		TokenType: services.TokenTypeAccess,
+		ClientID:  client.ClientID,
+		Scope:     scope,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+	return &services.OAuthTokens{
+		AccessToken: accessToken,
+		ExpiresIn:   s.tokenService.TokenDuration(services.TokenTypeAccess),
+		Scope:       scope,
+	}, nil
+}
+
+// Introspect describes an access token to a confidential client. Tokens
+// are active while their signature, lifetime and revocation state check
+// out; tokens that cannot be checked are reported inactive.
+func (s *Service) Introspect(ctx context.Context, request services.TokenRequest) (*services.TokenIntrospection, error) {
+	client, err := s.authenticateIntrospector(ctx, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if request.Token == "" {
+		return nil, services.NewOAuthError(services.OAuthInvalidRequest, "token is required")
+	}
+	return s.introspect(ctx, client, request.Token), nil
+}
+
+// IntrospectBatch describes up to the configured number of access tokens
+// at once, for consumers such as log processors checking many tokens. The
+// client is authenticated once, and each token is checked like Introspect
+// does.
+func (s *Service) IntrospectBatch(ctx context.Context, request services.BatchTokenRequest) ([]services.TokenIntrospection, error) {
+	client, err := s.authenticateIntrospector(ctx, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if len(request.Tokens) == 0 {
+		return nil, services.NewOAuthError(services.OAuthInvalidRequest, "token is required")
+	}
+	maxTokens := s.config.MaxBatchTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxBatchTokens
+	}
+	if len(request.Tokens) > maxTokens {
+		return nil, services.NewOAuthError(services.OAuthInvalidRequest, fmt.Sprintf("at most %d tokens may be introspected at once", maxTokens))
+	}
+
+	introspections := make([]services.TokenIntrospection, len(request.Tokens))
+	for i, token := range request.Tokens {
+		introspections[i] = *s.introspect(ctx, client, token)
+	}
+	return introspections, nil
+}
+
+// authenticateIntrospector authenticates a client that may introspect
+// tokens. Introspection tells who a token belongs to, so it is reserved for
+// clients that can keep a secret, such as resource servers.
+func (s *Service) authenticateIntrospector(ctx context.Context, clientID, clientSecret string) (*models.OAuthClient, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !client.Confidential() {
+		return nil, services.NewOAuthError(services.OAuthUnauthorizedClient, "public clients may not introspect tokens")
+	}
+	return client, nil
+}
+
+// introspect describes an access token; tokens that fail validation,
+// including empty ones, and tokens issued to clients that are now disabled
+// or deleted are inactive
+func (s *Service) introspect(ctx context.Context, client *models.OAuthClient, token string) *services.TokenIntrospection {
+	claims, err := s.tokenService.ValidateToken(ctx, token, services.TokenTypeAccess)
+	if err == nil && claims.ClientID != "" {
+		err = s.checkIssuedClient(ctx, claims.ClientID)
+	}
+	if err != nil {
+		s.logger.Debug("introspected inactive token",
+			zap.String("clientId", client.ClientID),
+			zap.Error(err))
+		return &services.TokenIntrospection{Active: false}
+	}
+
+	introspection := &services.TokenIntrospection{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Username:  claims.Username,
+		Subject:   claims.UserID.String(),
+		Issuer:    s.config.Issuer,
+		IssuedAt:  claims.IssuedAt,
+		NotBefore: claims.NotBefore,
+		ExpiresAt: claims.ExpiresAt,
+		Audience:  claims.Audience,
+	}
+	if claims.UserID == uuid.Nil {
+		introspection.Subject = claims.ClientID
+	}
+	return introspection
+}
+
+// checkIssuedClient fails when the client a token was issued to can no
+// longer use its tokens
+func (s *Service) checkIssuedClient(ctx context.Context, clientID string) error {
+	client, err := s.clients.GetByClientID(ctx, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to get client: %w", err)
+	}
+	if client.Disabled() {
+		return fmt.Errorf("client %s is disabled", clientID)
+	}
+	return nil
+}
+
+// Revoke revokes an access token issued to the client. Tokens are revoked
+// in the revocation store until they would have expired.
+func (s *Service) Revoke(ctx context.Context, request services.TokenRequest) error {
+	client, err := s.authenticateClient(ctx, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return err
+	}
+	if request.Token == "" {
+		return services.NewOAuthError(services.OAuthInvalidRequest, "token is required")
+	}
+
+	// Invalid, expired and already revoked tokens need no revoking (RFC 7009
+	// section 2.2), and the hint only says where to look first
+	claims, err := s.tokenService.ValidateToken(ctx, request.Token, services.TokenTypeAccess)
+	if err != nil {
+		return nil
+	}
+	if claims.ClientID != client.ClientID {
+		return services.NewOAuthError(services.OAuthUnauthorizedClient, "token was not issued to the client")
+	}
+
+	if err := s.tokenService.RevokeToken(ctx, request.Token); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	s.logger.Info("revoked OAuth access token", zap.String("clientId", client.ClientID))
+	return nil
+}
+
+// UserInfo returns the claims about a user released for the granted scope
+func (s *Service) UserInfo(ctx context.Context, userID uuid.UUID, scope string) (*services.UserInfo, error) {
+	scopes := strings.Fields(scope)
+	if !hasScope(scopes, services.ScopeOpenID) {
+		return nil, services.NewOAuthError(services.OAuthInsufficientScope, "the openid scope is required")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if !user.Status.CanAuthenticate() {
+		return nil, errors.WrapError("UserInfo", errors.ErrUserNotFound)
+	}
+
+	info := userInfo(user, scopes)
+	return &info, nil
+}
+
+// Metadata describes the provider for discovery
+func (s *Service) Metadata() services.ProviderMetadata {
+	return services.ProviderMetadata{
+		Issuer:                           s.config.Issuer,
+		AuthorizationEndpoint:            s.config.Issuer + services.OAuthAuthorizePath,
+		TokenEndpoint:                    s.config.Issuer + services.OAuthTokenPath,
+		UserInfoEndpoint:                 s.config.Issuer + services.OAuthUserInfoPath,
+		IntrospectionEndpoint:            s.config.Issuer + services.OAuthIntrospectPath,
+		RevocationEndpoint:               s.config.Issuer + services.OAuthRevokePath,
+		JWKSURI:                          s.config.Issuer + services.JWKSPath,
+		ScopesSupported:                  []string{services.ScopeOpenID, services.ScopeProfile, services.ScopeEmail},
+		ResponseTypesSupported:           []string{"code"},
+		GrantTypesSupported:              []string{string(models.GrantAuthorizationCode), string(models.GrantClientCredentials)},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{s.config.SigningAlgorithm},
+		TokenEndpointAuthMethods:         []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:    []string{"S256"},
+	}
+}
+
+// userInfo releases the claims of a user covered by the granted scopes
+func userInfo(user *models.User, scopes []string) services.UserInfo {
+	info := services.UserInfo{Subject: user.ID.String()}
+	if hasScope(scopes, services.ScopeEmail) {
+		info.Email = user.Email
+		info.EmailVerified = user.EmailVerified
+	}
+	if hasScope(scopes, services.ScopeProfile) {
+		info.PreferredUsername = user.Username
+		info.GivenName = user.FirstName
+		info.FamilyName = user.LastName
+		info.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+	return info
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyCodeChallenge checks a PKCE code verifier against its S256 challenge
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken returns size random bytes in the given encoding
+func randomToken(size int, encode func([]byte) string) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return encode(b), nil
+}
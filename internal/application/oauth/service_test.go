@@ -0,0 +1,266 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/memory"
+	infraservices "github.com/mibrahim2344/identity-service/internal/infrastructure/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testRedirectURI = "https://client.example.com/callback"
+
+type memoryClientRepository struct {
+	repositories.OAuthClientRepository
+	mu      sync.Mutex
+	clients map[string]*models.OAuthClient
+}
+
+func (r *memoryClientRepository) Create(ctx context.Context, client *models.OAuthClient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[client.ClientID] = client
+	return nil
+}
+
+func (r *memoryClientRepository) Update(ctx context.Context, client *models.OAuthClient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[client.ClientID] = client
+	return nil
+}
+
+func (r *memoryClientRepository) GetByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	client, ok := r.clients[clientID]
+	if !ok {
+		return nil, services.ErrNotFound
+	}
+	return client, nil
+}
+
+type nopPublisher struct{}
+
+func (nopPublisher) PublishUserEvent(ctx context.Context, eventType string, payload interface{}) error {
+	return nil
+}
+
+// testNow is the time of the test clock
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestService returns a service with a registered user and a public
+// client allowed the authorization code grant
+func newTestService(t *testing.T) (*Service, *models.OAuthClient, uuid.UUID) {
+	ctx := context.Background()
+	cache := memory.NewCacheService()
+	userRepo := memory.NewUserRepository(memory.NewStore())
+	tokenService := infraservices.NewTokenService(services.TokenConfig{
+		AccessTokenDuration:  15 * time.Minute,
+		RefreshTokenDuration: 24 * time.Hour,
+		SigningKey:           []byte("0123456789abcdef0123456789abcdef"),
+	}, cache)
+	s := NewService(Config{Issuer: "https://id.example.com"},
+		&memoryClientRepository{clients: make(map[string]*models.OAuthClient)},
+		userRepo, tokenService, cache, nopPublisher{}, nil,
+		services.ClockFunc(func() time.Time { return testNow }), zap.NewNop())
+
+	client, _, err := s.RegisterClient(ctx, services.OAuthClientRegistration{
+		Name:         "Test client",
+		RedirectURIs: []string{testRedirectURI},
+		GrantTypes:   []models.OAuthGrantType{models.GrantAuthorizationCode},
+		Scopes:       []string{"profile"},
+		Public:       true,
+	})
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	require.NoError(t, userRepo.Create(ctx, &models.User{
+		ID:       userID,
+		Email:    "user@example.com",
+		Username: "user",
+		Status:   models.UserStatusActive,
+	}))
+	return s, client, userID
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func assertOAuthError(t *testing.T, err error, code string) {
+	t.Helper()
+	var oauthErr *services.OAuthError
+	require.True(t, stderrors.As(err, &oauthErr), "expected an OAuth error, got %v", err)
+	assert.Equal(t, code, oauthErr.Code)
+}
+
+func TestAuthorizationCodeExchange(t *testing.T) {
+	ctx := context.Background()
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	authorize := func(t *testing.T, s *Service, client *models.OAuthClient, userID uuid.UUID) string {
+		code, err := s.Authorize(ctx, userID, services.AuthorizationRequest{
+			ResponseType:        "code",
+			ClientID:            client.ClientID,
+			RedirectURI:         testRedirectURI,
+			Scope:               "profile",
+			CodeChallenge:       codeChallenge(verifier),
+			CodeChallengeMethod: "S256",
+		})
+		require.NoError(t, err)
+		return code
+	}
+	exchange := func(s *Service, client *models.OAuthClient, code, redirectURI, codeVerifier string) (*services.OAuthTokens, error) {
+		return s.Token(ctx, services.OAuthTokenRequest{
+			GrantType:    string(models.GrantAuthorizationCode),
+			ClientID:     client.ClientID,
+			Code:         code,
+			RedirectURI:  redirectURI,
+			CodeVerifier: codeVerifier,
+		})
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		s, client, userID := newTestService(t)
+		tokens, err := exchange(s, client, authorize(t, s, client, userID), testRedirectURI, verifier)
+		require.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.Equal(t, "profile", tokens.Scope)
+	})
+
+	t.Run("code reuse", func(t *testing.T) {
+		s, client, userID := newTestService(t)
+		code := authorize(t, s, client, userID)
+		_, err := exchange(s, client, code, testRedirectURI, verifier)
+		require.NoError(t, err)
+
+		_, err = exchange(s, client, code, testRedirectURI, verifier)
+		assertOAuthError(t, err, services.OAuthInvalidGrant)
+	})
+
+	t.Run("PKCE mismatch", func(t *testing.T) {
+		s, client, userID := newTestService(t)
+		code := authorize(t, s, client, userID)
+		_, err := exchange(s, client, code, testRedirectURI, "another-verifier-of-sufficient-length-000000")
+		assertOAuthError(t, err, services.OAuthInvalidGrant)
+
+		// A failed attempt uses up the code
+		_, err = exchange(s, client, code, testRedirectURI, verifier)
+		assertOAuthError(t, err, services.OAuthInvalidGrant)
+	})
+
+	t.Run("missing code verifier", func(t *testing.T) {
+		s, client, userID := newTestService(t)
+		_, err := exchange(s, client, authorize(t, s, client, userID), testRedirectURI, "")
+		assertOAuthError(t, err, services.OAuthInvalidGrant)
+	})
+
+	t.Run("redirect mismatch", func(t *testing.T) {
+		s, client, userID := newTestService(t)
+		_, err := exchange(s, client, authorize(t, s, client, userID), "https://client.example.com/other", verifier)
+		assertOAuthError(t, err, services.OAuthInvalidGrant)
+	})
+}
+
+func TestAuthorizeRequiresRegisteredRedirect(t *testing.T) {
+	s, client, _ := newTestService(t)
+	for _, redirectURI := range []string{
+		"https://client.example.com/other",
+		"https://evil.example.net/callback",
+		testRedirectURI + "?next=https://evil.example.net",
+	} {
+		err := s.ValidateRedirect(context.Background(), services.AuthorizationRequest{
+			ResponseType: "code",
+			ClientID:     client.ClientID,
+			RedirectURI:  redirectURI,
+		})
+		assertOAuthError(t, err, services.OAuthInvalidRequest)
+	}
+}
+
+func TestAuthorizeRequiresPKCEForPublicClients(t *testing.T) {
+	s, client, userID := newTestService(t)
+	_, err := s.Authorize(context.Background(), userID, services.AuthorizationRequest{
+		ResponseType: "code",
+		ClientID:     client.ClientID,
+		RedirectURI:  testRedirectURI,
+		Scope:        "profile",
+	})
+	assertOAuthError(t, err, services.OAuthInvalidRequest)
+
+	_, err = s.Authorize(context.Background(), userID, services.AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            client.ClientID,
+		RedirectURI:         testRedirectURI,
+		Scope:               "profile",
+		CodeChallenge:       "challenge",
+		CodeChallengeMethod: "plain",
+	})
+	assertOAuthError(t, err, services.OAuthInvalidRequest)
+}
+
+func TestDisableClient(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newTestService(t)
+	client, secret, err := s.RegisterClient(ctx, services.OAuthClientRegistration{
+		Name:       "Resource server",
+		GrantTypes: []models.OAuthGrantType{models.GrantClientCredentials},
+		Scopes:     []string{"profile"},
+	})
+	require.NoError(t, err)
+	tokens, err := s.Token(ctx, services.OAuthTokenRequest{
+		GrantType:    string(models.GrantClientCredentials),
+		ClientID:     client.ClientID,
+		ClientSecret: secret,
+		Scope:        "profile",
+	})
+	require.NoError(t, err)
+
+	introspect := func() bool {
+		introspection, err := s.Introspect(ctx, services.TokenRequest{
+			ClientID:     client.ClientID,
+			ClientSecret: secret,
+			Token:        tokens.AccessToken,
+		})
+		require.NoError(t, err)
+		return introspection.Active
+	}
+	require.True(t, introspect())
+
+	disabled, err := s.DisableClient(ctx, client.ClientID)
+	require.NoError(t, err)
+	require.NotNil(t, disabled.DisabledAt)
+	assert.True(t, disabled.DisabledAt.Equal(testNow))
+
+	// A disabled client cannot introspect, so another one asks
+	introspector, introspectorSecret, err := s.RegisterClient(ctx, services.OAuthClientRegistration{
+		Name:       "Gateway",
+		GrantTypes: []models.OAuthGrantType{models.GrantClientCredentials},
+	})
+	require.NoError(t, err)
+	introspection, err := s.Introspect(ctx, services.TokenRequest{
+		ClientID:     introspector.ClientID,
+		ClientSecret: introspectorSecret,
+		Token:        tokens.AccessToken,
+	})
+	require.NoError(t, err)
+	assert.False(t, introspection.Active)
+
+	_, err = s.EnableClient(ctx, client.ClientID)
+	require.NoError(t, err)
+	assert.True(t, introspect())
+}
@@ -0,0 +1,249 @@
+package organization
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// SetDomainJoinPolicy sets what happens to users whose verified email is in
+// one of the organization's verified domains
+func (s *Service) SetDomainJoinPolicy(ctx context.Context, id uuid.UUID, policy models.DomainJoinPolicy, actorID uuid.UUID) (*models.Organization, error) {
+	if !policy.IsValid() {
+		return nil, fmt.Errorf("%w: unknown domain join policy %q", errors.ErrInvalidInput, policy)
+	}
+	if err := s.authorizeManager(ctx, id, actorID); err != nil {
+		return nil, err
+	}
+	organization, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	organization.DomainJoinPolicy = policy
+	if err := s.repo.Update(ctx, organization); err != nil {
+		return nil, fmt.Errorf("failed to update organization: %w", err)
+	}
+
+	s.logger.Info("set organization domain join policy",
+		zap.String("organizationID", id.String()),
+		zap.String("policy", string(policy)),
+		zap.String("actorID", actorID.String()))
+	return organization, nil
+}
+
+// ListDomains returns the email domains an organization claimed
+func (s *Service) ListDomains(ctx context.Context, id, actorID uuid.UUID) ([]*models.OrganizationDomain, error) {
+	if err := s.authorizeManager(ctx, id, actorID); err != nil {
+		return nil, err
+	}
+	domains, err := s.repo.ListDomains(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization domains: %w", err)
+	}
+	return domains, nil
+}
+
+// AddDomain claims an email domain for an organization with a new
+// verification token
+func (s *Service) AddDomain(ctx context.Context, id uuid.UUID, domain string, actorID uuid.UUID) (*models.OrganizationDomain, error) {
+	if err := s.authorizeManager(ctx, id, actorID); err != nil {
+		return nil, err
+	}
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := s.repo.GetByVerifiedDomain(ctx, domain)
+	switch {
+	case err == nil && owner.ID != id:
+		return nil, services.NewConflictError("domain is verified by another organization")
+	case err != nil && !stderrors.Is(err, services.ErrNotFound):
+		return nil, fmt.Errorf("failed to look up domain: %w", err)
+	}
+
+	token, err := verificationToken()
+	if err != nil {
+		return nil, err
+	}
+	claim := &models.OrganizationDomain{
+		OrganizationID:    id,
+		Domain:            domain,
+		VerificationToken: token,
+	}
+	if err := s.repo.AddDomain(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("added organization domain",
+		zap.String("organizationID", id.String()),
+		zap.String("domain", domain))
+	return claim, nil
+}
+
+// VerifyDomain checks DNS for the TXT record holding the verification token
+// of a claimed domain
+func (s *Service) VerifyDomain(ctx context.Context, id uuid.UUID, domain string, actorID uuid.UUID) (*models.OrganizationDomain, error) {
+	if err := s.authorizeManager(ctx, id, actorID); err != nil {
+		return nil, err
+	}
+	claim, err := s.domain(ctx, id, domain)
+	if err != nil {
+		return nil, err
+	}
+	if claim.IsVerified() {
+		return claim, nil
+	}
+
+	name, value := claim.VerificationRecord()
+	records, err := s.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		s.logger.Info("failed to look up organization domain verification record",
+			zap.String("domain", claim.Domain),
+			zap.Error(err))
+		return nil, services.ErrDomainUnverified
+	}
+	if !slices.Contains(records, value) {
+		return nil, services.ErrDomainUnverified
+	}
+
+	now := time.Now()
+	if err := s.repo.VerifyDomain(ctx, id, claim.Domain, now); err != nil {
+		return nil, err
+	}
+	claim.VerifiedAt = &now
+
+	s.logger.Info("verified organization domain",
+		zap.String("organizationID", id.String()),
+		zap.String("domain", claim.Domain))
+	s.publish(ctx, events.OrganizationDomainVerified, events.NewOrganizationDomainEvent(
+		events.OrganizationDomainVerified, id, claim.Domain, actorID))
+	return claim, nil
+}
+
+// RemoveDomain removes a domain claim from an organization
+func (s *Service) RemoveDomain(ctx context.Context, id uuid.UUID, domain string, actorID uuid.UUID) error {
+	if err := s.authorizeManager(ctx, id, actorID); err != nil {
+		return err
+	}
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.RemoveDomain(ctx, id, domain); err != nil {
+		return err
+	}
+
+	s.logger.Info("removed organization domain",
+		zap.String("organizationID", id.String()),
+		zap.String("domain", domain))
+	return nil
+}
+
+// ListInvitations returns the organizations a user is invited into
+func (s *Service) ListInvitations(ctx context.Context, userID uuid.UUID) ([]*services.OrganizationInvitation, error) {
+	invitations, err := s.repo.ListInvitationsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	result := make([]*services.OrganizationInvitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		organization, err := s.repo.GetByID(ctx, invitation.OrganizationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get organization: %w", err)
+		}
+		result = append(result, &services.OrganizationInvitation{Organization: organization, Invitation: invitation})
+	}
+	return result, nil
+}
+
+// AcceptInvitation makes a user a member of an organization they are
+// invited into
+func (s *Service) AcceptInvitation(ctx context.Context, id, userID uuid.UUID) (*models.OrganizationMembership, error) {
+	invitation, err := s.repo.GetInvitation(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	membership, err := s.repo.AcceptInvitation(ctx, invitation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	s.logger.Info("accepted organization invitation",
+		zap.String("organizationID", id.String()),
+		zap.String("userID", userID.String()))
+	s.publish(ctx, events.OrganizationMemberJoined, events.NewOrganizationMemberEvent(
+		events.OrganizationMemberJoined, id, userID, string(membership.Role), userID))
+	return membership, nil
+}
+
+// DeclineInvitation removes the invitation of a user into an organization
+func (s *Service) DeclineInvitation(ctx context.Context, id, userID uuid.UUID) error {
+	if err := s.repo.RemoveInvitation(ctx, id, userID); err != nil {
+		return err
+	}
+	s.logger.Info("declined organization invitation",
+		zap.String("organizationID", id.String()),
+		zap.String("userID", userID.String()))
+	return nil
+}
+
+// authorizeManager returns ErrNotFound for users who are not members of an
+// organization and ErrUnauthorized for members who may not manage its
+// members. Domains decide who joins the organization, so they are managed
+// by those who manage its members.
+func (s *Service) authorizeManager(ctx context.Context, id, actorID uuid.UUID) error {
+	membership, err := s.repo.GetMember(ctx, id, actorID)
+	if err != nil {
+		return err
+	}
+	if !membership.Role.CanManageMembers() {
+		return errors.ErrUnauthorized
+	}
+	return nil
+}
+
+// domain retrieves a domain claimed by an organization
+func (s *Service) domain(ctx context.Context, id uuid.UUID, domain string) (*models.OrganizationDomain, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	domains, err := s.repo.ListDomains(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization domains: %w", err)
+	}
+	index := slices.IndexFunc(domains, func(d *models.OrganizationDomain) bool { return d.Domain == domain })
+	if index < 0 {
+		return nil, services.ErrNotFound
+	}
+	return domains[index], nil
+}
+
+// normalizeDomain lowercases a domain and checks it is a valid host name
+func normalizeDomain(domain string) (string, error) {
+	normalized, ok := models.NormalizeDomain(domain)
+	if !ok {
+		return "", fmt.Errorf("%w: %q is not a valid domain", errors.ErrInvalidInput, normalized)
+	}
+	return normalized, nil
+}
+
+func verificationToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
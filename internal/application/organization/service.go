@@ -0,0 +1,248 @@
+package organization
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxNameLength bounds the name of an organization
+	maxNameLength = 100
+	// defaultPageSize and maxPageSize bound pages of members
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Service manages organizations and their members
+type Service struct {
+	repo           repositories.OrganizationRepository
+	userRepo       repositories.UserRepository
+	tokenService   services.TokenService
+	resolver       services.TXTResolver
+	eventPublisher services.EventPublisher
+	logger         *zap.Logger
+}
+
+var _ services.OrganizationService = (*Service)(nil)
+
+// NewService creates a new organization service
+func NewService(
+	repo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	tokenService services.TokenService,
+	resolver services.TXTResolver,
+	eventPublisher services.EventPublisher,
+	logger *zap.Logger,
+) *Service {
+	return &Service{
+		repo:           repo,
+		userRepo:       userRepo,
+		tokenService:   tokenService,
+		resolver:       resolver,
+		eventPublisher: eventPublisher,
+		logger:         logger,
+	}
+}
+
+// CreateOrganization creates an organization owned by the given user
+func (s *Service) CreateOrganization(ctx context.Context, name string, ownerID uuid.UUID) (*models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return nil, fmt.Errorf("%w: organization names are 1 to %d characters", errors.ErrInvalidInput, maxNameLength)
+	}
+
+	organization := &models.Organization{Name: name, CreatedBy: &ownerID}
+	owner := &models.OrganizationMembership{UserID: ownerID, Role: models.OrganizationRoleOwner}
+	if err := s.repo.Create(ctx, organization, owner); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	s.logger.Info("created organization",
+		zap.String("organizationID", organization.ID.String()),
+		zap.String("ownerID", ownerID.String()))
+	s.publish(ctx, events.OrganizationCreated, events.NewOrganizationCreatedEvent(organization.ID, organization.Name, ownerID))
+
+	return organization, nil
+}
+
+// ListOrganizations returns the organizations a user is a member of
+func (s *Service) ListOrganizations(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// GetOrganization retrieves an organization for one of its members
+func (s *Service) GetOrganization(ctx context.Context, id, actorID uuid.UUID) (*models.Organization, error) {
+	if _, err := s.repo.GetMember(ctx, id, actorID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListMembers returns a page of the members of an organization for one of
+// its members
+func (s *Service) ListMembers(ctx context.Context, id, actorID uuid.UUID, offset, limit int) ([]*services.OrganizationMember, error) {
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	if offset < 0 || limit < 0 || limit > maxPageSize {
+		return nil, fmt.Errorf("%w: offset must not be negative and limit at most %d", errors.ErrInvalidInput, maxPageSize)
+	}
+	if _, err := s.repo.GetMember(ctx, id, actorID); err != nil {
+		return nil, err
+	}
+
+	users, err := s.userRepo.List(repositories.WithOrganizationScope(ctx, id), offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	memberships, err := s.repo.ListMembers(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memberships: %w", err)
+	}
+	byUser := make(map[uuid.UUID]*models.OrganizationMembership, len(memberships))
+	for _, membership := range memberships {
+		byUser[membership.UserID] = membership
+	}
+
+	members := make([]*services.OrganizationMember, 0, len(users))
+	for _, user := range users {
+		// Members removed between the two queries are left out
+		if membership, ok := byUser[user.ID]; ok {
+			members = append(members, &services.OrganizationMember{User: user, Membership: membership})
+		}
+	}
+	return members, nil
+}
+
+// InviteMember adds the user with the given email to an organization
+func (s *Service) InviteMember(ctx context.Context, id uuid.UUID, email string, role models.OrganizationRole, actorID uuid.UUID) (*services.OrganizationMember, error) {
+	if role == "" {
+		role = models.OrganizationRoleMember
+	}
+	if !role.IsValid() {
+		return nil, fmt.Errorf("%w: unknown organization role %q", errors.ErrInvalidInput, role)
+	}
+	actor, err := s.repo.GetMember(ctx, id, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.Role.CanManageMembers() || (role == models.OrganizationRoleOwner && actor.Role != models.OrganizationRoleOwner) {
+		return nil, errors.ErrUnauthorized
+	}
+
+	// Identifier lookups also match usernames, which invitations do not
+	user, err := s.userRepo.GetByIdentifier(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if models.NormalizeIdentifier(user.Email) != models.NormalizeIdentifier(email) {
+		return nil, errors.WrapError("InviteMember", errors.ErrUserNotFound)
+	}
+
+	membership := &models.OrganizationMembership{
+		OrganizationID: id,
+		UserID:         user.ID,
+		Role:           role,
+		InvitedBy:      &actorID,
+	}
+	if err := s.repo.AddMember(ctx, membership); err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+	if user.OrganizationID == nil {
+		user.OrganizationID = &id
+	}
+
+	s.logger.Info("invited organization member",
+		zap.String("organizationID", id.String()),
+		zap.String("userID", user.ID.String()),
+		zap.String("role", string(role)),
+		zap.String("invitedBy", actorID.String()))
+	s.publish(ctx, events.OrganizationMemberInvited, events.NewOrganizationMemberEvent(
+		events.OrganizationMemberInvited, id, user.ID, string(role), actorID))
+
+	return &services.OrganizationMember{User: user, Membership: membership}, nil
+}
+
+// RemoveMember removes a user from an organization
+func (s *Service) RemoveMember(ctx context.Context, id, userID, actorID uuid.UUID) error {
+	actor, err := s.repo.GetMember(ctx, id, actorID)
+	if err != nil {
+		return err
+	}
+	member, err := s.repo.GetMember(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if userID != actorID {
+		if !actor.Role.CanManageMembers() || (member.Role == models.OrganizationRoleOwner && actor.Role != models.OrganizationRoleOwner) {
+			return errors.ErrUnauthorized
+		}
+	}
+	if member.Role == models.OrganizationRoleOwner {
+		if err := s.ensureAnotherOwner(ctx, id, userID); err != nil {
+			return err
+		}
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if err := s.repo.RemoveMember(ctx, id, userID); err != nil {
+		return err
+	}
+	// Tokens name the user's organization as their tenant
+	if user.OrganizationID != nil && *user.OrganizationID == id {
+		if err := s.tokenService.RevokeUserSessions(ctx, userID); err != nil {
+			s.logger.Error("failed to revoke sessions after organization removal",
+				zap.String("userID", userID.String()),
+				zap.Error(err))
+		}
+	}
+
+	s.logger.Info("removed organization member",
+		zap.String("organizationID", id.String()),
+		zap.String("userID", userID.String()),
+		zap.String("removedBy", actorID.String()))
+	s.publish(ctx, events.OrganizationMemberRemoved, events.NewOrganizationMemberEvent(
+		events.OrganizationMemberRemoved, id, userID, string(member.Role), actorID))
+	return nil
+}
+
+// ensureAnotherOwner returns ErrOrganizationLastOwner unless an organization
+// has an owner besides the given user
+func (s *Service) ensureAnotherOwner(ctx context.Context, id, userID uuid.UUID) error {
+	memberships, err := s.repo.ListMembers(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to list memberships: %w", err)
+	}
+	for _, membership := range memberships {
+		if membership.Role == models.OrganizationRoleOwner && membership.UserID != userID {
+			return nil
+		}
+	}
+	return services.ErrOrganizationLastOwner
+}
+
+// publish attributes an event to the actor of the request and publishes it
+func (s *Service) publish(ctx context.Context, eventType events.EventType, event interface{}) {
+	if e, ok := event.(interface{ SetMetadata(events.Metadata) }); ok {
+		e.SetMetadata(events.MetadataFromContext(ctx))
+	}
+
+	if err := s.eventPublisher.PublishUserEvent(ctx, string(eventType), event); err != nil {
+		s.logger.Error("failed to publish event",
+			zap.String("eventType", string(eventType)),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,98 @@
+package application
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// redactedValue replaces secrets in configuration dumps
+const redactedValue = "********"
+
+// secretFieldSuffixes identify configuration fields holding credentials
+var secretFieldSuffixes = []string{"Password", "Secret", "Token", "SigningKey"}
+
+// Redacted returns the configuration as nested maps keyed by field name,
+// with passwords, secrets and tokens masked and the passwords of URLs
+// removed. Unset secrets stay empty, so that operators can tell them apart.
+func (c Config) Redacted() map[string]any {
+	dump, _ := redact(reflect.ValueOf(c)).(map[string]any)
+	return dump
+}
+
+// redact converts a configuration value, masking the secrets it holds
+func redact(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := make(map[string]any, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Type.Kind() == reflect.String && isSecretField(field.Name) {
+				fields[field.Name] = redactString(v.Field(i).String())
+				continue
+			}
+			fields[field.Name] = redact(v.Field(i))
+		}
+		return fields
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		entries := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			entries[iter.Key().String()] = redact(iter.Value())
+		}
+		return entries
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = redact(v.Index(i))
+		}
+		return items
+	case reflect.String:
+		return redactURL(v.String())
+	default:
+		return v.Interface()
+	}
+}
+
+// isSecretField reports whether a configuration field holds a credential
+func isSecretField(name string) bool {
+	for _, suffix := range secretFieldSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactString masks a secret unless it is unset
+func redactString(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// redactURL masks the password of a URL, e.g. of a proxy, and returns other
+// strings unchanged
+func redactURL(s string) string {
+	if !strings.Contains(s, "@") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	if _, ok := u.User.Password(); !ok {
+		return s
+	}
+	return u.Redacted()
+}
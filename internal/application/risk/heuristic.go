@@ -0,0 +1,132 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// Ensure HeuristicScorer implements services.RegistrationRiskScorer
+var _ services.RegistrationRiskScorer = (*HeuristicScorer)(nil)
+
+// Score contributions of the heuristic signals
+const (
+	disposableEmailScore  = 50
+	missingUserAgentScore = 20
+	ipVelocityScore       = 40
+	deviceVelocityScore   = 40
+	maxScore              = 100
+)
+
+// Velocity limits used when none are configured
+const (
+	DefaultIPVelocityLimit     = 3
+	DefaultDeviceVelocityLimit = 2
+)
+
+// disposableDomains are well-known providers of throwaway mailboxes
+var disposableDomains = []string{
+	"10minutemail.com",
+	"dispostable.com",
+	"getnada.com",
+	"guerrillamail.com",
+	"maildrop.cc",
+	"mailinator.com",
+	"sharklasers.com",
+	"temp-mail.org",
+	"tempmail.com",
+	"throwawaymail.com",
+	"trashmail.com",
+	"yopmail.com",
+}
+
+// HeuristicConfig configures the heuristic scorer
+type HeuristicConfig struct {
+	// DisposableDomains are added to the built-in disposable email domains
+	DisposableDomains   []string
+	IPVelocityLimit     int // registrations per IP address and hour; 0 uses 3
+	DeviceVelocityLimit int // registrations per device and hour; 0 uses 2
+}
+
+// HeuristicScorer is the default registration risk scorer. It adds up
+// fixed scores for disposable email domains, clients without a user agent
+// and IP addresses or devices registering more accounts than the limits.
+type HeuristicScorer struct {
+	disposable  map[string]bool
+	ipLimit     int
+	deviceLimit int
+}
+
+// NewHeuristicScorer creates a new heuristic registration risk scorer
+func NewHeuristicScorer(config HeuristicConfig) *HeuristicScorer {
+	disposable := make(map[string]bool, len(disposableDomains)+len(config.DisposableDomains))
+	for _, domain := range disposableDomains {
+		disposable[domain] = true
+	}
+	for _, domain := range config.DisposableDomains {
+		disposable[strings.ToLower(strings.TrimSpace(domain))] = true
+	}
+	ipLimit := config.IPVelocityLimit
+	if ipLimit <= 0 {
+		ipLimit = DefaultIPVelocityLimit
+	}
+	deviceLimit := config.DeviceVelocityLimit
+	if deviceLimit <= 0 {
+		deviceLimit = DefaultDeviceVelocityLimit
+	}
+	return &HeuristicScorer{
+		disposable:  disposable,
+		ipLimit:     ipLimit,
+		deviceLimit: deviceLimit,
+	}
+}
+
+// Score scores a registration attempt
+func (s *HeuristicScorer) Score(ctx context.Context, input services.RegistrationRiskInput) (*services.RegistrationRisk, error) {
+	risk := &services.RegistrationRisk{}
+	add := func(score int, reason string) {
+		risk.Score += score
+		risk.Reasons = append(risk.Reasons, reason)
+	}
+
+	if s.isDisposable(input.Email) {
+		add(disposableEmailScore, "disposable email domain")
+	}
+	if strings.TrimSpace(input.UserAgent) == "" {
+		add(missingUserAgentScore, "no user agent")
+	}
+	if input.IPAddress != "" && input.RecentFromIP >= s.ipLimit {
+		add(ipVelocityScore, fmt.Sprintf("%d registrations from the IP address in the last hour", input.RecentFromIP))
+	}
+	if input.RecentFromDevice >= s.deviceLimit {
+		add(deviceVelocityScore, fmt.Sprintf("%d registrations from the device in the last hour", input.RecentFromDevice))
+	}
+
+	if risk.Score > maxScore {
+		risk.Score = maxScore
+	}
+	return risk, nil
+}
+
+// isDisposable reports whether an email address belongs to a disposable
+// domain or one of its subdomains
+func (s *HeuristicScorer) isDisposable(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(strings.TrimSuffix(email[at+1:], "."))
+	for domain != "" {
+		if s.disposable[domain] {
+			return true
+		}
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+	return false
+}
@@ -0,0 +1,293 @@
+package role
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// permissionsCacheTTL bounds how long other instances keep granting the
+	// old permissions of a changed role
+	permissionsCacheTTL = time.Minute
+	// maxDescriptionLength bounds the description of a role
+	maxDescriptionLength = 255
+)
+
+// adminPermissions are kept by the admin role so that roles can always be
+// managed by someone
+var adminPermissions = []models.Permission{models.PermissionRolesRead, models.PermissionRolesWrite}
+
+func permissionsKey(name models.Role) string {
+	return fmt.Sprintf("role_permissions:%s", name)
+}
+
+// Service manages roles, the permissions they grant and the roles of users
+type Service struct {
+	repo           repositories.RoleRepository
+	userRepo       repositories.UserRepository
+	tokenService   services.TokenService
+	cache          services.CacheService
+	eventPublisher services.EventPublisher
+	logger         *zap.Logger
+}
+
+var _ services.RoleService = (*Service)(nil)
+
+// NewService creates a new role service
+func NewService(
+	repo repositories.RoleRepository,
+	userRepo repositories.UserRepository,
+	tokenService services.TokenService,
+	cache services.CacheService,
+	eventPublisher services.EventPublisher,
+	logger *zap.Logger,
+) *Service {
+	return &Service{
+		repo:           repo,
+		userRepo:       userRepo,
+		tokenService:   tokenService,
+		cache:          cache,
+		eventPublisher: eventPublisher,
+		logger:         logger,
+	}
+}
+
+// ListRoles returns all roles with their permissions, ordered by name
+func (s *Service) ListRoles(ctx context.Context) ([]*models.RoleDefinition, error) {
+	return s.repo.List(ctx)
+}
+
+// GetRole retrieves a role with its permissions
+func (s *Service) GetRole(ctx context.Context, name models.Role) (*models.RoleDefinition, error) {
+	return s.repo.GetByName(ctx, name)
+}
+
+// CreateRole validates and stores a new role
+func (s *Service) CreateRole(ctx context.Context, role *models.RoleDefinition) error {
+	role.Name = models.Role(strings.TrimSpace(string(role.Name)))
+	role.Description = strings.TrimSpace(role.Description)
+	if !role.Name.IsValid() {
+		return fmt.Errorf("%w: role names are 1 to 50 lowercase letters, digits, '_' or '-' starting with a letter", errors.ErrInvalidInput)
+	}
+	if len(role.Description) > maxDescriptionLength {
+		return fmt.Errorf("%w: description must be at most %d characters", errors.ErrInvalidInput, maxDescriptionLength)
+	}
+	permissions, err := normalizePermissions(role.Permissions)
+	if err != nil {
+		return err
+	}
+	role.Permissions = permissions
+
+	if err := s.repo.Create(ctx, role); err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+	s.invalidate(ctx, role.Name)
+
+	s.logger.Info("created role",
+		zap.String("role", string(role.Name)),
+		zap.Int("permissions", len(role.Permissions)))
+	s.publish(ctx, events.RoleCreated, events.NewRoleEvent(
+		events.RoleCreated, string(role.Name), permissionStrings(role.Permissions)))
+	return nil
+}
+
+// DeleteRole removes a role no user holds
+func (s *Service) DeleteRole(ctx context.Context, name models.Role) error {
+	if name.IsBuiltIn() {
+		return services.ErrRoleBuiltIn
+	}
+	holders, err := s.repo.CountUsers(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to count role holders: %w", err)
+	}
+	if holders > 0 {
+		return services.ErrRoleInUse
+	}
+
+	if err := s.repo.Delete(ctx, name); err != nil {
+		return err
+	}
+	s.invalidate(ctx, name)
+
+	s.logger.Info("deleted role", zap.String("role", string(name)))
+	s.publish(ctx, events.RoleDeleted, events.NewRoleEvent(events.RoleDeleted, string(name), nil))
+	return nil
+}
+
+// SetPermissions replaces the permissions a role grants
+func (s *Service) SetPermissions(ctx context.Context, name models.Role, permissions []models.Permission) (*models.RoleDefinition, error) {
+	permissions, err := normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+	if name == models.RoleAdmin {
+		for _, required := range adminPermissions {
+			if !slices.Contains(permissions, required) {
+				return nil, fmt.Errorf("%w: the admin role must keep %s", services.ErrRoleBuiltIn, required)
+			}
+		}
+	}
+
+	if err := s.repo.SetPermissions(ctx, name, permissions); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, name)
+
+	s.logger.Info("changed role permissions",
+		zap.String("role", string(name)),
+		zap.Int("permissions", len(permissions)))
+	s.publish(ctx, events.RolePermissionsChanged, events.NewRoleEvent(
+		events.RolePermissionsChanged, string(name), permissionStrings(permissions)))
+
+	return s.repo.GetByName(ctx, name)
+}
+
+// GrantPermission adds a permission to a role
+func (s *Service) GrantPermission(ctx context.Context, name models.Role, permission models.Permission) (*models.RoleDefinition, error) {
+	role, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(role.Permissions, permission) {
+		return role, nil
+	}
+	return s.SetPermissions(ctx, name, append(role.Permissions, permission))
+}
+
+// RevokePermission removes a permission from a role
+func (s *Service) RevokePermission(ctx context.Context, name models.Role, permission models.Permission) (*models.RoleDefinition, error) {
+	role, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(role.Permissions, permission) {
+		return role, nil
+	}
+	return s.SetPermissions(ctx, name, slices.DeleteFunc(role.Permissions, func(p models.Permission) bool {
+		return p == permission
+	}))
+}
+
+// AssignRole gives a user another role on behalf of an admin
+func (s *Service) AssignRole(ctx context.Context, userID uuid.UUID, name models.Role, assignedBy uuid.UUID) (*models.User, error) {
+	// Admins could otherwise lock themselves out of role management
+	if userID == assignedBy {
+		return nil, fmt.Errorf("%w: admins cannot change their own role", errors.ErrInvalidInput)
+	}
+	if _, err := s.repo.GetByName(ctx, name); err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return nil, fmt.Errorf("%w: unknown role %q", errors.ErrInvalidInput, name)
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if user.Role == name {
+		return user, nil
+	}
+
+	previous := user.Role
+	user.Role = name
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	if err := s.tokenService.RevokeUserSessions(ctx, user.ID); err != nil {
+		s.logger.Error("failed to revoke sessions after role change",
+			zap.String("userID", user.ID.String()),
+			zap.Error(err))
+	}
+
+	s.logger.Info("changed user role",
+		zap.String("userID", user.ID.String()),
+		zap.String("previousRole", string(previous)),
+		zap.String("role", string(name)),
+		zap.String("assignedBy", assignedBy.String()))
+	s.publish(ctx, events.UserRoleChanged, events.NewUserRoleChangedEvent(
+		user.ID, string(previous), string(name), assignedBy))
+
+	return user, nil
+}
+
+// Permissions returns the permissions a role grants
+func (s *Service) Permissions(ctx context.Context, name models.Role) ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := s.cache.Get(ctx, permissionsKey(name), &permissions)
+	if err == nil {
+		return permissions, nil
+	}
+	if !stderrors.Is(err, services.ErrCacheKeyNotFound) {
+		s.logger.Warn("failed to read cached role permissions", zap.Error(err))
+	}
+
+	role, err := s.repo.GetByName(ctx, name)
+	switch {
+	case err == nil:
+		permissions = role.Permissions
+	case stderrors.Is(err, services.ErrNotFound):
+		permissions = []models.Permission{}
+	default:
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	if err := s.cache.Set(ctx, permissionsKey(name), permissions, permissionsCacheTTL); err != nil {
+		s.logger.Warn("failed to cache role permissions", zap.Error(err))
+	}
+	return permissions, nil
+}
+
+func (s *Service) invalidate(ctx context.Context, name models.Role) {
+	if err := s.cache.Delete(ctx, permissionsKey(name)); err != nil {
+		s.logger.Warn("failed to invalidate cached role permissions", zap.Error(err))
+	}
+}
+
+// publish attributes an event to the actor of the request and publishes it
+func (s *Service) publish(ctx context.Context, eventType events.EventType, event interface{}) {
+	if e, ok := event.(interface{ SetMetadata(events.Metadata) }); ok {
+		e.SetMetadata(events.MetadataFromContext(ctx))
+	}
+
+	if err := s.eventPublisher.PublishUserEvent(ctx, string(eventType), event); err != nil {
+		s.logger.Error("failed to publish event",
+			zap.String("eventType", string(eventType)),
+			zap.Error(err))
+	}
+}
+
+// normalizePermissions checks every permission is known and drops duplicates
+func normalizePermissions(permissions []models.Permission) ([]models.Permission, error) {
+	normalized := make([]models.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		if !permission.IsValid() {
+			return nil, fmt.Errorf("%w: unknown permission %q", errors.ErrInvalidInput, permission)
+		}
+		if !slices.Contains(normalized, permission) {
+			normalized = append(normalized, permission)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized, nil
+}
+
+func permissionStrings(permissions []models.Permission) []string {
+	values := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		values = append(values, string(permission))
+	}
+	return values
+}
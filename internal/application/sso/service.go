@@ -0,0 +1,467 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxNameLength and maxClientIDLength bound the settings of a connection
+	maxNameLength     = 100
+	maxClientIDLength = 255
+)
+
+// Service manages the SSO connections of organizations and signs users in
+// through them
+type Service struct {
+	repo           repositories.SSOConnectionRepository
+	organizations  repositories.OrganizationRepository
+	connector      services.SSOConnector
+	resolver       services.TXTResolver
+	eventPublisher services.EventPublisher
+	logger         *zap.Logger
+}
+
+var _ services.SSOService = (*Service)(nil)
+
+// NewService creates a new SSO service
+func NewService(
+	repo repositories.SSOConnectionRepository,
+	organizations repositories.OrganizationRepository,
+	connector services.SSOConnector,
+	resolver services.TXTResolver,
+	eventPublisher services.EventPublisher,
+	logger *zap.Logger,
+) *Service {
+	return &Service{
+		repo:           repo,
+		organizations:  organizations,
+		connector:      connector,
+		resolver:       resolver,
+		eventPublisher: eventPublisher,
+		logger:         logger,
+	}
+}
+
+// ListConnections returns the connections of an organization
+func (s *Service) ListConnections(ctx context.Context, organizationID, actorID uuid.UUID) ([]*services.SSOConnectionDetails, error) {
+	if err := s.authorize(ctx, organizationID, actorID); err != nil {
+		return nil, err
+	}
+
+	connections, err := s.repo.ListByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SSO connections: %w", err)
+	}
+	details := make([]*services.SSOConnectionDetails, 0, len(connections))
+	for _, connection := range connections {
+		detail, err := s.details(ctx, connection)
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, detail)
+	}
+	return details, nil
+}
+
+// GetConnection retrieves a connection of an organization
+func (s *Service) GetConnection(ctx context.Context, organizationID, connectionID, actorID uuid.UUID) (*services.SSOConnectionDetails, error) {
+	if err := s.authorize(ctx, organizationID, actorID); err != nil {
+		return nil, err
+	}
+	connection, err := s.connection(ctx, organizationID, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.details(ctx, connection)
+}
+
+// CreateConnection validates and stores a new connection
+func (s *Service) CreateConnection(ctx context.Context, organizationID uuid.UUID, input services.SSOConnectionInput, actorID uuid.UUID) (*services.SSOConnectionDetails, error) {
+	if err := s.authorize(ctx, organizationID, actorID); err != nil {
+		return nil, err
+	}
+	if input.ClientSecret == "" {
+		return nil, fmt.Errorf("%w: client secret is required", errors.ErrInvalidInput)
+	}
+
+	connection := &models.SSOConnection{
+		OrganizationID: organizationID,
+		Protocol:       models.SSOProtocolOIDC,
+		CreatedBy:      &actorID,
+	}
+	if err := s.apply(ctx, connection, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, connection); err != nil {
+		return nil, fmt.Errorf("failed to create SSO connection: %w", err)
+	}
+
+	s.logger.Info("created SSO connection",
+		zap.String("organizationID", organizationID.String()),
+		zap.String("connectionID", connection.ID.String()),
+		zap.String("issuer", connection.Issuer))
+	s.publish(ctx, events.SSOConnectionCreated, events.NewSSOConnectionEvent(
+		events.SSOConnectionCreated, organizationID, connection.ID, "", actorID))
+
+	return s.details(ctx, connection)
+}
+
+// UpdateConnection replaces the settings of a connection. The endpoints of
+// the issuer are discovered again.
+func (s *Service) UpdateConnection(ctx context.Context, organizationID, connectionID uuid.UUID, input services.SSOConnectionInput, actorID uuid.UUID) (*services.SSOConnectionDetails, error) {
+	if err := s.authorize(ctx, organizationID, actorID); err != nil {
+		return nil, err
+	}
+	connection, err := s.connection(ctx, organizationID, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if input.ClientSecret == "" {
+		input.ClientSecret = connection.ClientSecret
+	}
+
+	if err := s.apply(ctx, connection, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, connection); err != nil {
+		return nil, fmt.Errorf("failed to update SSO connection: %w", err)
+	}
+
+	s.logger.Info("updated SSO connection",
+		zap.String("organizationID", organizationID.String()),
+		zap.String("connectionID", connection.ID.String()),
+		zap.Bool("enabled", connection.Enabled))
+	s.publish(ctx, events.SSOConnectionUpdated, events.NewSSOConnectionEvent(
+		events.SSOConnectionUpdated, organizationID, connection.ID, "", actorID))
+
+	return s.details(ctx, connection)
+}
+
+// DeleteConnection removes a connection with its domains
+func (s *Service) DeleteConnection(ctx context.Context, organizationID, connectionID, actorID uuid.UUID) error {
+	if err := s.authorize(ctx, organizationID, actorID); err != nil {
+		return err
+	}
+	if _, err := s.connection(ctx, organizationID, connectionID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, connectionID); err != nil {
+		return err
+	}
+
+	s.logger.Info("deleted SSO connection",
+		zap.String("organizationID", organizationID.String()),
+		zap.String("connectionID", connectionID.String()))
+	s.publish(ctx, events.SSOConnectionDeleted, events.NewSSOConnectionEvent(
+		events.SSOConnectionDeleted, organizationID, connectionID, "", actorID))
+	return nil
+}
+
+// AddDomain claims an email domain for a connection with a new
+// verification token
+func (s *Service) AddDomain(ctx context.Context, organizationID, connectionID uuid.UUID, domain string, actorID uuid.UUID) (*models.SSODomain, error) {
+	if err := s.authorize(ctx, organizationID, actorID); err != nil {
+		return nil, err
+	}
+	if _, err := s.connection(ctx, organizationID, connectionID); err != nil {
+		return nil, err
+	}
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := s.repo.GetByVerifiedDomain(ctx, domain)
+	switch {
+	case err == nil && owner.ID != connectionID:
+		return nil, services.NewConflictError("domain is verified by another connection")
+	case err != nil && !stderrors.Is(err, services.ErrNotFound):
+		return nil, fmt.Errorf("failed to look up domain: %w", err)
+	}
+
+	token, err := verificationToken()
+	if err != nil {
+		return nil, err
+	}
+	claim := &models.SSODomain{
+		ConnectionID:      connectionID,
+		Domain:            domain,
+		VerificationToken: token,
+	}
+	if err := s.repo.AddDomain(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("added SSO domain",
+		zap.String("connectionID", connectionID.String()),
+		zap.String("domain", domain))
+	return claim, nil
+}
+
+// VerifyDomain checks DNS for the TXT record holding the verification token
+// of a claimed domain
+func (s *Service) VerifyDomain(ctx context.Context, organizationID, connectionID uuid.UUID, domain string, actorID uuid.UUID) (*models.SSODomain, error) {
+	if err := s.authorize(ctx, organizationID, actorID); err != nil {
+		return nil, err
+	}
+	if _, err := s.connection(ctx, organizationID, connectionID); err != nil {
+		return nil, err
+	}
+	claim, err := s.domain(ctx, connectionID, domain)
+	if err != nil {
+		return nil, err
+	}
+	if claim.IsVerified() {
+		return claim, nil
+	}
+
+	name, value := claim.VerificationRecord()
+	records, err := s.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		s.logger.Info("failed to look up SSO domain verification record",
+			zap.String("domain", claim.Domain),
+			zap.Error(err))
+		return nil, services.ErrDomainUnverified
+	}
+	if !slices.Contains(records, value) {
+		return nil, services.ErrDomainUnverified
+	}
+
+	now := time.Now()
+	if err := s.repo.VerifyDomain(ctx, connectionID, claim.Domain, now); err != nil {
+		return nil, err
+	}
+	claim.VerifiedAt = &now
+
+	s.logger.Info("verified SSO domain",
+		zap.String("connectionID", connectionID.String()),
+		zap.String("domain", claim.Domain))
+	s.publish(ctx, events.SSODomainVerified, events.NewSSOConnectionEvent(
+		events.SSODomainVerified, organizationID, connectionID, claim.Domain, actorID))
+	return claim, nil
+}
+
+// RemoveDomain removes a domain from a connection
+func (s *Service) RemoveDomain(ctx context.Context, organizationID, connectionID uuid.UUID, domain string, actorID uuid.UUID) error {
+	if err := s.authorize(ctx, organizationID, actorID); err != nil {
+		return err
+	}
+	if _, err := s.connection(ctx, organizationID, connectionID); err != nil {
+		return err
+	}
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.RemoveDomain(ctx, connectionID, domain); err != nil {
+		return err
+	}
+
+	s.logger.Info("removed SSO domain",
+		zap.String("connectionID", connectionID.String()),
+		zap.String("domain", domain))
+	return nil
+}
+
+// Discover returns the enabled connection that verified the domain of an email
+func (s *Service) Discover(ctx context.Context, email string) (*models.SSOConnection, error) {
+	domain := models.EmailDomain(email)
+	if domain == "" {
+		return nil, fmt.Errorf("%w: a valid email is required", errors.ErrInvalidInput)
+	}
+
+	connection, err := s.repo.GetByVerifiedDomain(ctx, domain)
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return nil, services.ErrSSOConnectionNotFound
+		}
+		return nil, fmt.Errorf("failed to look up SSO connection: %w", err)
+	}
+	if !connection.Enabled {
+		return nil, services.ErrSSOConnectionNotFound
+	}
+	return connection, nil
+}
+
+// BeginLogin starts a login with an enabled connection
+func (s *Service) BeginLogin(ctx context.Context, connectionID uuid.UUID) (*services.FederatedLogin, error) {
+	connection, err := s.enabledConnection(ctx, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.connector.BeginLogin(ctx, connection)
+}
+
+// CompleteLogin completes a login with a connection. Connections only sign
+// in users of the domains their organization proved it owns, so that one
+// organization's identity provider cannot sign in another's users.
+func (s *Service) CompleteLogin(ctx context.Context, connectionID uuid.UUID, state, code string) (*services.ExternalIdentity, error) {
+	connection, err := s.enabledConnection(ctx, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := s.connector.CompleteLogin(ctx, connection, state, code)
+	if err != nil {
+		return nil, err
+	}
+
+	domains, err := s.repo.ListDomains(ctx, connection.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SSO domains: %w", err)
+	}
+	domain := models.EmailDomain(identity.Email)
+	if !slices.ContainsFunc(domains, func(d *models.SSODomain) bool { return d.IsVerified() && d.Domain == domain }) {
+		s.logger.Warn("SSO login with an email outside the connection's domains",
+			zap.String("connectionID", connection.ID.String()),
+			zap.String("domain", domain))
+		return nil, services.ErrSSODomainMismatch
+	}
+
+	identity.OrganizationID = &connection.OrganizationID
+	return identity, nil
+}
+
+// authorize returns ErrNotFound for users who are not members of an
+// organization and ErrUnauthorized for members who may not manage it.
+// Connections decide who joins the organization, so they are managed by
+// those who manage its members.
+func (s *Service) authorize(ctx context.Context, organizationID, actorID uuid.UUID) error {
+	membership, err := s.organizations.GetMember(ctx, organizationID, actorID)
+	if err != nil {
+		return err
+	}
+	if !membership.Role.CanManageMembers() {
+		return errors.ErrUnauthorized
+	}
+	return nil
+}
+
+// connection retrieves a connection of an organization
+func (s *Service) connection(ctx context.Context, organizationID, connectionID uuid.UUID) (*models.SSOConnection, error) {
+	connection, err := s.repo.GetByID(ctx, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if connection.OrganizationID != organizationID {
+		return nil, services.ErrNotFound
+	}
+	return connection, nil
+}
+
+// enabledConnection retrieves a connection users may sign in with
+func (s *Service) enabledConnection(ctx context.Context, connectionID uuid.UUID) (*models.SSOConnection, error) {
+	connection, err := s.repo.GetByID(ctx, connectionID)
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return nil, services.ErrSSOConnectionNotFound
+		}
+		return nil, fmt.Errorf("failed to get SSO connection: %w", err)
+	}
+	if !connection.Enabled {
+		return nil, services.ErrSSOConnectionNotFound
+	}
+	return connection, nil
+}
+
+// domain retrieves a domain claimed by a connection
+func (s *Service) domain(ctx context.Context, connectionID uuid.UUID, domain string) (*models.SSODomain, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	domains, err := s.repo.ListDomains(ctx, connectionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SSO domains: %w", err)
+	}
+	index := slices.IndexFunc(domains, func(d *models.SSODomain) bool { return d.Domain == domain })
+	if index < 0 {
+		return nil, services.ErrNotFound
+	}
+	return domains[index], nil
+}
+
+// details adds the domains and callback URL to a connection
+func (s *Service) details(ctx context.Context, connection *models.SSOConnection) (*services.SSOConnectionDetails, error) {
+	domains, err := s.repo.ListDomains(ctx, connection.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SSO domains: %w", err)
+	}
+	return &services.SSOConnectionDetails{
+		Connection:  connection,
+		Domains:     domains,
+		RedirectURL: s.connector.RedirectURL(connection),
+	}, nil
+}
+
+// apply validates input and sets it on a connection, discovering the
+// endpoints of its issuer
+func (s *Service) apply(ctx context.Context, connection *models.SSOConnection, input services.SSOConnectionInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return fmt.Errorf("%w: connection names are 1 to %d characters", errors.ErrInvalidInput, maxNameLength)
+	}
+	clientID := strings.TrimSpace(input.ClientID)
+	if clientID == "" || len(clientID) > maxClientIDLength {
+		return fmt.Errorf("%w: client IDs are 1 to %d characters", errors.ErrInvalidInput, maxClientIDLength)
+	}
+	issuer := strings.TrimSuffix(strings.TrimSpace(input.Issuer), "/")
+	endpoints, err := s.connector.Discover(ctx, issuer)
+	if err != nil {
+		return fmt.Errorf("%w: failed to discover issuer: %v", errors.ErrInvalidInput, err)
+	}
+
+	connection.Name = name
+	connection.Issuer = issuer
+	connection.ClientID = clientID
+	connection.ClientSecret = input.ClientSecret
+	connection.AuthURL = endpoints.AuthURL
+	connection.TokenURL = endpoints.TokenURL
+	connection.UserInfoURL = endpoints.UserInfoURL
+	connection.Enabled = input.Enabled
+	return nil
+}
+
+// publish attributes an event to the actor of the request and publishes it
+func (s *Service) publish(ctx context.Context, eventType events.EventType, event interface{}) {
+	if e, ok := event.(interface{ SetMetadata(events.Metadata) }); ok {
+		e.SetMetadata(events.MetadataFromContext(ctx))
+	}
+
+	if err := s.eventPublisher.PublishUserEvent(ctx, string(eventType), event); err != nil {
+		s.logger.Error("failed to publish event",
+			zap.String("eventType", string(eventType)),
+			zap.Error(err))
+	}
+}
+
+// normalizeDomain lowercases a domain and checks it is a valid host name
+func normalizeDomain(domain string) (string, error) {
+	normalized, ok := models.NormalizeDomain(domain)
+	if !ok {
+		return "", fmt.Errorf("%w: %q is not a valid domain", errors.ErrInvalidInput, normalized)
+	}
+	return normalized, nil
+}
+
+func verificationToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
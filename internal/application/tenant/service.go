@@ -0,0 +1,231 @@
+package tenant
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultCacheTTL is how long resolved overrides are cached when no TTL is configured
+	defaultCacheTTL = time.Minute
+	// minPasswordLength is the shortest minimum length an organization may set
+	minPasswordLength = 8
+	// maxPasswordLength is the longest maximum length an organization may
+	// set; bcrypt ignores everything after 72 bytes
+	maxPasswordLength = 72
+)
+
+func settingsKey(organizationID uuid.UUID) string {
+	return fmt.Sprintf("tenant_settings:%s", organizationID)
+}
+
+// Service resolves per-organization configuration overrides on top of the
+// service-wide configuration. Overrides are cached; instances other than
+// the one saving a change see it once their cache entry expires.
+type Service struct {
+	repo     repositories.OrganizationSettingsRepository
+	cache    services.CacheService
+	cacheTTL time.Duration
+	logger   *zap.Logger
+}
+
+var _ services.TenantSettingsService = (*Service)(nil)
+
+// NewService creates a new tenant settings service. cacheTTL 0 uses one minute.
+func NewService(
+	repo repositories.OrganizationSettingsRepository,
+	cache services.CacheService,
+	cacheTTL time.Duration,
+	logger *zap.Logger,
+) *Service {
+	if cacheTTL <= 0 {
+		cacheTTL = defaultCacheTTL
+	}
+	return &Service{
+		repo:     repo,
+		cache:    cache,
+		cacheTTL: cacheTTL,
+		logger:   logger,
+	}
+}
+
+// Resolve returns the settings in effect for an organization
+func (s *Service) Resolve(ctx context.Context, organizationID *uuid.UUID) (*services.TenantSettings, error) {
+	settings := &services.TenantSettings{}
+	if organizationID == nil {
+		return settings, nil
+	}
+
+	overrides, err := s.cachedOverrides(ctx, *organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if overrides == nil {
+		return settings, nil
+	}
+
+	if policy := overrides.PasswordPolicy; policy != nil {
+		settings.PasswordPolicy = &services.PasswordConfig{
+			MinLength:           policy.MinLength,
+			MaxLength:           policy.MaxLength,
+			RequireUppercase:    policy.RequireUppercase,
+			RequireLowercase:    policy.RequireLowercase,
+			RequireNumbers:      policy.RequireNumbers,
+			RequireSpecialChars: policy.RequireSpecialChars,
+		}
+	}
+	// Token lifetimes may only shorten the configured ones, so that
+	// revocation entries outlive every token; the token service caps them
+	if overrides.AccessTokenTTLSeconds != nil {
+		settings.AccessTokenLifetime = time.Duration(*overrides.AccessTokenTTLSeconds) * time.Second
+	}
+	if overrides.RefreshTokenTTLSeconds != nil {
+		settings.RefreshTokenLifetime = time.Duration(*overrides.RefreshTokenTTLSeconds) * time.Second
+	}
+	if overrides.RequireMFA != nil {
+		settings.RequireMFA = *overrides.RequireMFA
+	}
+	if overrides.MaxSessions != nil {
+		settings.MaxSessions = *overrides.MaxSessions
+	}
+	if overrides.SessionLimitAction != nil {
+		settings.SessionLimitAction = *overrides.SessionLimitAction
+	}
+	settings.EmailTemplates = overrides.EmailTemplates
+	settings.AccessPolicy = overrides.AccessPolicy
+	settings.SecuritySummaries = overrides.SecuritySummaries
+	return settings, nil
+}
+
+// cachedOverrides returns the overrides of an organization, or nil when it
+// has none. Organizations without overrides are cached as well.
+func (s *Service) cachedOverrides(ctx context.Context, organizationID uuid.UUID) (*models.OrganizationSettings, error) {
+	var cached models.OrganizationSettings
+	err := s.cache.Get(ctx, settingsKey(organizationID), &cached)
+	if err == nil {
+		if cached.OrganizationID == uuid.Nil {
+			return nil, nil
+		}
+		return &cached, nil
+	}
+	if !stderrors.Is(err, services.ErrCacheKeyNotFound) {
+		s.logger.Warn("failed to read cached organization settings",
+			zap.String("organizationID", organizationID.String()),
+			zap.Error(err))
+	}
+
+	overrides, err := s.repo.Get(ctx, organizationID)
+	if err != nil && !stderrors.Is(err, services.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get organization settings: %w", err)
+	}
+	toCache := overrides
+	if toCache == nil {
+		toCache = &models.OrganizationSettings{}
+	}
+	if err := s.cache.Set(ctx, settingsKey(organizationID), toCache, s.cacheTTL); err != nil {
+		s.logger.Warn("failed to cache organization settings",
+			zap.String("organizationID", organizationID.String()),
+			zap.Error(err))
+	}
+	return overrides, nil
+}
+
+// GetOverrides retrieves the overrides of an organization
+func (s *Service) GetOverrides(ctx context.Context, organizationID uuid.UUID) (*models.OrganizationSettings, error) {
+	return s.repo.Get(ctx, organizationID)
+}
+
+// SaveOverrides validates and replaces the overrides of an organization
+func (s *Service) SaveOverrides(ctx context.Context, settings *models.OrganizationSettings) error {
+	if err := validateOverrides(settings); err != nil {
+		return err
+	}
+	if err := s.repo.Save(ctx, settings); err != nil {
+		return fmt.Errorf("failed to save organization settings: %w", err)
+	}
+	s.invalidate(ctx, settings.OrganizationID)
+
+	s.logger.Info("saved organization settings",
+		zap.String("organizationID", settings.OrganizationID.String()))
+	return nil
+}
+
+// DeleteOverrides removes the overrides of an organization
+func (s *Service) DeleteOverrides(ctx context.Context, organizationID uuid.UUID) error {
+	if err := s.repo.Delete(ctx, organizationID); err != nil {
+		return err
+	}
+	s.invalidate(ctx, organizationID)
+
+	s.logger.Info("deleted organization settings",
+		zap.String("organizationID", organizationID.String()))
+	return nil
+}
+
+func (s *Service) invalidate(ctx context.Context, organizationID uuid.UUID) {
+	if err := s.cache.Delete(ctx, settingsKey(organizationID)); err != nil {
+		s.logger.Warn("failed to invalidate cached organization settings",
+			zap.String("organizationID", organizationID.String()),
+			zap.Error(err))
+	}
+}
+
+// validateOverrides checks that overrides leave a usable configuration
+func validateOverrides(settings *models.OrganizationSettings) error {
+	if settings.OrganizationID == uuid.Nil {
+		return fmt.Errorf("%w: organization ID is required", errors.ErrInvalidInput)
+	}
+	if policy := settings.PasswordPolicy; policy != nil {
+		if policy.MinLength < minPasswordLength {
+			return fmt.Errorf("%w: minimum password length must be at least %d", errors.ErrInvalidInput, minPasswordLength)
+		}
+		if policy.MaxLength < policy.MinLength || policy.MaxLength > maxPasswordLength {
+			return fmt.Errorf("%w: maximum password length must be between the minimum length and %d",
+				errors.ErrInvalidInput, maxPasswordLength)
+		}
+	}
+	for name, ttl := range map[string]*int{
+		"access token":  settings.AccessTokenTTLSeconds,
+		"refresh token": settings.RefreshTokenTTLSeconds,
+	} {
+		if ttl != nil && *ttl <= 0 {
+			return fmt.Errorf("%w: %s lifetime must be positive", errors.ErrInvalidInput, name)
+		}
+	}
+	if settings.MaxSessions != nil && *settings.MaxSessions <= 0 {
+		return fmt.Errorf("%w: maximum concurrent sessions must be positive", errors.ErrInvalidInput)
+	}
+	if action := settings.SessionLimitAction; action != nil && !action.IsValid() {
+		return fmt.Errorf("%w: session limit action must be deny or evict_oldest", errors.ErrInvalidInput)
+	}
+	if policy := settings.AccessPolicy; policy != nil {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("%w: access policy: %v", errors.ErrInvalidInput, err)
+		}
+	}
+	for name, tmpl := range settings.EmailTemplates {
+		if !slices.Contains(models.EmailTemplates, name) {
+			return fmt.Errorf("%w: unknown email template %q", errors.ErrInvalidInput, name)
+		}
+		if tmpl.Subject == "" || (tmpl.HTMLBody == "" && tmpl.TextBody == "") {
+			return fmt.Errorf("%w: email template %q needs a subject and a body", errors.ErrInvalidInput, name)
+		}
+		for _, text := range []string{tmpl.Subject, tmpl.HTMLBody, tmpl.TextBody} {
+			if _, err := template.New(name).Parse(text); err != nil {
+				return fmt.Errorf("%w: email template %q does not parse: %v", errors.ErrInvalidInput, name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package user
+
+import (
+	"context"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// checkAccessPolicy denies a login the access policy of the user's
+// organization does not allow from the client's country or at this time.
+// Denials are published so they are recorded in the audit log.
+func (s *Service) checkAccessPolicy(ctx context.Context, user *models.User) error {
+	if user.OrganizationID == nil {
+		return nil
+	}
+	settings, err := s.resolveTenantSettings(ctx, user.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if settings == nil || settings.AccessPolicy == nil {
+		return nil
+	}
+
+	metadata := events.MetadataFromContext(ctx)
+	code := settings.AccessPolicy.Evaluate(metadata.Country, s.clock.Now())
+	if code == "" {
+		return nil
+	}
+
+	s.logger.Info("login denied by access policy",
+		zap.String("userID", user.ID.String()),
+		zap.String("organizationID", user.OrganizationID.String()),
+		zap.String("code", code),
+		zap.String("country", metadata.Country))
+	s.publishUserEvent(ctx, string(events.AccessPolicyViolated),
+		events.NewAccessPolicyViolatedEvent(user.ID, user.Email, *user.OrganizationID, code))
+	return &services.AccessPolicyViolation{Code: code}
+}
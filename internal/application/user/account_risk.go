@@ -0,0 +1,247 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// Account risk settings used when none are configured
+const (
+	defaultWatchAfterFailures    = 3
+	defaultRestrictAfterFailures = 10
+	defaultLoginFailureWindow    = 15 * time.Minute
+	defaultAccountRiskTTL        = time.Hour
+)
+
+// AccountRiskPolicy controls the account risk state machine. Failed logins
+// within the window put an account on watch and then restrict it, and a
+// login from a new device or country puts it on watch. A raised state
+// lasts for StateTTL after it was last raised, then the account is back to
+// normal.
+//
+// As the risk rises, logins escalate from a CAPTCHA while an account is
+// watched to a CAPTCHA and a second factor while it is restricted, which
+// blocks users without a second factor. Without a CAPTCHA verifier, only
+// restricted accounts are escalated.
+type AccountRiskPolicy struct {
+	Enabled               bool
+	WatchAfterFailures    int           // 0 uses 3
+	RestrictAfterFailures int           // 0 uses 10
+	FailureWindow         time.Duration // 0 uses 15 minutes
+	StateTTL              time.Duration // 0 uses 1 hour
+}
+
+func (p AccountRiskPolicy) watchAfterFailures() int {
+	if p.WatchAfterFailures <= 0 {
+		return defaultWatchAfterFailures
+	}
+	return p.WatchAfterFailures
+}
+
+func (p AccountRiskPolicy) restrictAfterFailures() int {
+	if p.RestrictAfterFailures <= 0 {
+		return defaultRestrictAfterFailures
+	}
+	return p.RestrictAfterFailures
+}
+
+func (p AccountRiskPolicy) failureWindow() time.Duration {
+	if p.FailureWindow <= 0 {
+		return defaultLoginFailureWindow
+	}
+	return p.FailureWindow
+}
+
+func (p AccountRiskPolicy) stateTTL() time.Duration {
+	if p.StateTTL <= 0 {
+		return defaultAccountRiskTTL
+	}
+	return p.StateTTL
+}
+
+func accountRiskKey(userID uuid.UUID) string {
+	return fmt.Sprintf("account_risk:%s", userID)
+}
+
+func loginFailuresKey(userID uuid.UUID) string {
+	return fmt.Sprintf("login_failure_count:%s", userID)
+}
+
+// accountRisk returns the risk state of a user's account. Accounts are
+// treated as normal while their state cannot be read, so that a cache
+// outage does not lock every user out.
+func (s *Service) accountRisk(ctx context.Context, userID uuid.UUID) models.AccountRisk {
+	normal := models.AccountRisk{State: models.AccountRiskNormal}
+	if !s.accountRiskPolicy.Enabled {
+		return normal
+	}
+	var risk models.AccountRisk
+	if err := s.cacheService.Get(ctx, accountRiskKey(userID), &risk); err != nil {
+		if !stderrors.Is(err, services.ErrCacheKeyNotFound) {
+			s.logger.Error("failed to get account risk",
+				zap.String("userID", userID.String()),
+				zap.Error(err))
+		}
+		return normal
+	}
+	return risk
+}
+
+// raiseAccountRisk moves a user's account to the given state unless it is
+// at a higher one. Raising it to its current state extends how long the
+// state lasts.
+func (s *Service) raiseAccountRisk(ctx context.Context, userID uuid.UUID, state models.AccountRiskState, reason string) {
+	if !s.accountRiskPolicy.Enabled {
+		return
+	}
+	current := s.accountRisk(ctx, userID)
+	if current.State.Exceeds(state) {
+		return
+	}
+
+	ttl := s.accountRiskPolicy.stateTTL()
+	until := s.clock.Now().Add(ttl)
+	risk := models.AccountRisk{State: state, Reason: reason, Until: &until}
+	if err := s.cacheService.Set(ctx, accountRiskKey(userID), risk, ttl); err != nil {
+		s.logger.Error("failed to raise account risk",
+			zap.String("userID", userID.String()),
+			zap.String("state", string(state)),
+			zap.Error(err))
+		return
+	}
+	if state != current.State {
+		s.publishUserEvent(ctx, string(events.AccountRiskChanged), events.NewAccountRiskChangedEvent(
+			userID, string(current.State), string(state), reason))
+	}
+}
+
+// recordLoginFailure counts a failed login of a user and raises the risk of
+// their account once the failures reach the policy's thresholds. The count
+// starts at the first failure and is dropped once the window has passed.
+func (s *Service) recordLoginFailure(ctx context.Context, userID uuid.UUID) {
+	if !s.accountRiskPolicy.Enabled {
+		return
+	}
+	count, err := s.cacheService.Incr(ctx, loginFailuresKey(userID), s.accountRiskPolicy.failureWindow())
+	if err != nil {
+		s.logger.Error("failed to record login failure",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+		return
+	}
+
+	switch {
+	case count >= int64(s.accountRiskPolicy.restrictAfterFailures()):
+		s.raiseAccountRisk(ctx, userID, models.AccountRiskRestricted, fmt.Sprintf("%d failed logins", count))
+	case count >= int64(s.accountRiskPolicy.watchAfterFailures()):
+		s.raiseAccountRisk(ctx, userID, models.AccountRiskWatch, fmt.Sprintf("%d failed logins", count))
+	}
+}
+
+// clearLoginFailures forgets the failed logins of a user after they signed
+// in. The risk state of their account is left to expire.
+func (s *Service) clearLoginFailures(ctx context.Context, userID uuid.UUID) {
+	if !s.accountRiskPolicy.Enabled {
+		return
+	}
+	if err := s.cacheService.Delete(ctx, loginFailuresKey(userID)); err != nil {
+		s.logger.Warn("failed to clear login failures",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+	}
+}
+
+// loginRisk returns the ID and risk state of the account a login is for.
+// Logins for unknown identifiers are left to fail the password check.
+func (s *Service) loginRisk(ctx context.Context, identifier string) (uuid.UUID, models.AccountRisk, error) {
+	if !s.accountRiskPolicy.Enabled {
+		return uuid.Nil, models.AccountRisk{State: models.AccountRiskNormal}, nil
+	}
+	user, err := s.userRepo.GetByIdentifier(ctx, identifier)
+	if err != nil {
+		if stderrors.Is(err, errors.ErrUserNotFound) {
+			return uuid.Nil, models.AccountRisk{State: models.AccountRiskNormal}, nil
+		}
+		return uuid.Nil, models.AccountRisk{}, fmt.Errorf("failed to look up user: %w", err)
+	}
+	return user.ID, s.accountRisk(ctx, user.ID), nil
+}
+
+// checkLoginCaptcha requires a valid CAPTCHA response for logins to
+// accounts at raised risk. It runs before the password is checked, so that
+// the response does not reveal whether a guessed password was right.
+func (s *Service) checkLoginCaptcha(ctx context.Context, risk models.AccountRisk, response string) error {
+	if s.captcha == nil || risk.State == models.AccountRiskNormal {
+		return nil
+	}
+	if response == "" {
+		return services.ErrCaptchaRequired
+	}
+	valid, err := s.captcha.Verify(ctx, response, events.MetadataFromContext(ctx).ClientIP)
+	if err != nil {
+		return fmt.Errorf("failed to verify captcha: %w", err)
+	}
+	if !valid {
+		return services.ErrCaptchaInvalid
+	}
+	return nil
+}
+
+// checkLoginSecondFactor blocks logins to restricted accounts unless the
+// user has a second factor. Completing a login with a password, magic link
+// or external identity then challenges it; a passkey already proves
+// possession of a device.
+func (s *Service) checkLoginSecondFactor(ctx context.Context, user *models.User, risk models.AccountRisk) error {
+	if risk.State != models.AccountRiskRestricted {
+		return nil
+	}
+	if s.totpCredentials == nil {
+		return services.ErrLoginBlocked
+	}
+	credential, err := s.totpCredentials.GetByUserID(ctx, user.ID)
+	if err != nil && !stderrors.Is(err, services.ErrNotFound) {
+		return fmt.Errorf("failed to get MFA credential: %w", err)
+	}
+	if credential == nil || !credential.Confirmed() {
+		return services.ErrLoginBlocked
+	}
+	return nil
+}
+
+// GetAccountRisk returns the risk state of a user's account
+func (s *Service) GetAccountRisk(ctx context.Context, userID uuid.UUID) (*models.AccountRisk, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	risk := s.accountRisk(ctx, userID)
+	return &risk, nil
+}
+
+// ResetAccountRisk returns a user's account to the normal risk state and
+// forgets their failed logins
+func (s *Service) ResetAccountRisk(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+	current := s.accountRisk(ctx, userID)
+	if err := s.cacheService.Delete(ctx, accountRiskKey(userID)); err != nil {
+		return fmt.Errorf("failed to reset account risk: %w", err)
+	}
+	if err := s.cacheService.Delete(ctx, loginFailuresKey(userID)); err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	if current.State != models.AccountRiskNormal {
+		s.publishUserEvent(ctx, string(events.AccountRiskChanged), events.NewAccountRiskChangedEvent(
+			userID, string(current.State), string(models.AccountRiskNormal), "reset by admin"))
+	}
+	return nil
+}
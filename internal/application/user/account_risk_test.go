@@ -0,0 +1,53 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type nopPublisher struct{}
+
+func (nopPublisher) PublishUserEvent(ctx context.Context, eventType string, payload interface{}) error {
+	return nil
+}
+
+func TestRecordLoginFailure(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(nil, nil, nil, memory.NewCacheService(), nopPublisher{}, zap.NewNop(),
+		nil, "https://app.example.com", WithAccountRisk(AccountRiskPolicy{
+			Enabled:               true,
+			WatchAfterFailures:    3,
+			RestrictAfterFailures: 10,
+		}, nil))
+	userID := uuid.New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.recordLoginFailure(ctx, userID)
+		}()
+	}
+	wg.Wait()
+
+	var count int64
+	require.NoError(t, s.cacheService.Get(ctx, loginFailuresKey(userID), &count))
+	assert.Equal(t, int64(25), count)
+
+	s.recordLoginFailure(ctx, userID)
+	assert.Equal(t, models.AccountRiskRestricted, s.accountRisk(ctx, userID).State)
+
+	s.clearLoginFailures(ctx, userID)
+	s.recordLoginFailure(ctx, userID)
+	require.NoError(t, s.cacheService.Get(ctx, loginFailuresKey(userID), &count))
+	assert.Equal(t, int64(1), count)
+}
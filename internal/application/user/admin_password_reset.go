@@ -0,0 +1,135 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// defaultAdminPasswordResetLimit is used when no limit has been configured
+const defaultAdminPasswordResetLimit = 10
+
+// adminPasswordResetWindow is the period over which an admin's password
+// resets are limited
+const adminPasswordResetWindow = time.Hour
+
+// Reasons recorded when an admin password reset is refused
+const (
+	adminResetRejectedRateLimited = "rate_limited"
+	adminResetRejectedStepUp      = "step_up_failed"
+)
+
+func adminPasswordResetsKey(adminID uuid.UUID) string {
+	return fmt.Sprintf("admin_password_reset_count:%s", adminID)
+}
+
+func (s *Service) adminPasswordResetLimitPerHour() int {
+	if s.adminPasswordResetLimit <= 0 {
+		return defaultAdminPasswordResetLimit
+	}
+	return s.adminPasswordResetLimit
+}
+
+// AdminRequestPasswordReset sends a user the password reset email on an
+// admin's behalf. The admin re-authenticates first, and every attempt counts
+// toward their hourly limit so that the step-up cannot be guessed at. Both
+// sent and refused requests are published for the audit trail.
+func (s *Service) AdminRequestPasswordReset(ctx context.Context, userID, adminID uuid.UUID, stepUp services.StepUpInput) error {
+	reject := func(reason string, err error) error {
+		s.publishUserEvent(ctx, string(events.AdminPasswordResetRejected),
+			events.NewAdminPasswordResetEvent(events.AdminPasswordResetRejected, userID, adminID, reason))
+		return err
+	}
+
+	allowed, err := s.countAdminPasswordReset(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return reject(adminResetRejectedRateLimited, services.ErrAdminPasswordResetLimitReached)
+	}
+
+	admin, err := s.userRepo.GetByID(ctx, adminID)
+	if err != nil {
+		return fmt.Errorf("admin not found: %w", err)
+	}
+	if err := s.verifyStepUp(ctx, admin, stepUp); err != nil {
+		if stderrors.Is(err, services.ErrStepUpFailed) {
+			return reject(adminResetRejectedStepUp, err)
+		}
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	// Service accounts have no password to reset
+	if user.ServiceAccount {
+		return fmt.Errorf("%w: service accounts have no password", errors.ErrInvalidInput)
+	}
+
+	resetLink, err := s.issueResetLink(ctx, user)
+	if err != nil {
+		return err
+	}
+	s.publishUserEvent(ctx, string(events.UserPasswordReset), events.NewUserPasswordResetEvent(
+		user.ID,
+		user.Email,
+		resetLink,
+	))
+	s.publishUserEvent(ctx, string(events.AdminPasswordResetRequested),
+		events.NewAdminPasswordResetEvent(events.AdminPasswordResetRequested, user.ID, adminID, ""))
+
+	return nil
+}
+
+// countAdminPasswordReset records an attempt of an admin and reports whether
+// it is within their hourly limit. Attempts are counted before the step-up
+// is checked, so that parallel requests cannot get past the limit.
+func (s *Service) countAdminPasswordReset(ctx context.Context, adminID uuid.UUID) (bool, error) {
+	count, err := s.cacheService.Incr(ctx, adminPasswordResetsKey(adminID), adminPasswordResetWindow)
+	if err != nil {
+		return false, fmt.Errorf("failed to record admin password reset: %w", err)
+	}
+	return count <= int64(s.adminPasswordResetLimitPerHour()), nil
+}
+
+// verifyStepUp re-authenticates a user with their password, and with a
+// current authenticator code when they set one up
+func (s *Service) verifyStepUp(ctx context.Context, user *models.User, input services.StepUpInput) error {
+	if input.Password == "" || user.PasswordHash == "" {
+		return services.ErrStepUpFailed
+	}
+	if err := s.passwordService.VerifyPassword(ctx, input.Password, user.PasswordHash); err != nil {
+		return services.ErrStepUpFailed
+	}
+	if s.totpCredentials == nil {
+		return nil
+	}
+
+	credential, err := s.totpCredentials.GetByUserID(ctx, user.ID)
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get MFA credential: %w", err)
+	}
+	if !credential.Confirmed() {
+		return nil
+	}
+	if err := s.verifyTOTPCode(ctx, credential, input.Code); err != nil {
+		if stderrors.Is(err, services.ErrMFACodeInvalid) {
+			return services.ErrStepUpFailed
+		}
+		return err
+	}
+	return nil
+}
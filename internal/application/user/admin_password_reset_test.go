@@ -0,0 +1,32 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountAdminPasswordReset(t *testing.T) {
+	s := newPasswordResetTestService(WithAdminPasswordResetLimit(4))
+	adminID := uuid.New()
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := s.countAdminPasswordReset(context.Background(), adminID)
+			assert.NoError(t, err)
+			if ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(4), allowed.Load())
+}
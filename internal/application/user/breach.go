@@ -0,0 +1,72 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// RespondToCredentialBreach revokes the sessions of a user whose credentials
+// were breached, requires a password reset and sends them a reset link.
+// Reports are handled at least once, so a repeated report only revokes the
+// sessions again, which also covers sessions started without the password
+// since the first report.
+func (s *Service) RespondToCredentialBreach(ctx context.Context, breach services.CredentialBreach) (bool, error) {
+	user, err := s.breachedUser(ctx, breach)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.tokenService.RevokeUserSessions(ctx, user.ID); err != nil {
+		return false, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	s.endAllSessions(ctx, user.ID)
+	if user.PasswordResetRequired {
+		return false, nil
+	}
+
+	// Reset links sent before the breach may have leaked along with the
+	// password. The new link is issued before the reset is required, so a
+	// failure leaves the report to be retried in full.
+	if err := s.invalidateResetTokens(ctx, user.ID); err != nil {
+		return false, fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+	resetLink, err := s.issueResetLink(ctx, user)
+	if err != nil {
+		return false, err
+	}
+
+	user.PasswordResetRequired = true
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return false, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	s.logger.Warn("credentials breached, password reset required",
+		zap.String("userID", user.ID.String()),
+		zap.String("source", breach.Source))
+	s.publishUserEvent(ctx, string(events.UserCredentialsBreached), events.NewUserCredentialsBreachedEvent(
+		user.ID,
+		user.Email,
+		breach.Source,
+		resetLink,
+	))
+
+	return true, nil
+}
+
+// breachedUser loads the user a breach report refers to
+func (s *Service) breachedUser(ctx context.Context, breach services.CredentialBreach) (*models.User, error) {
+	if breach.UserID != uuid.Nil {
+		return s.userRepo.GetByID(ctx, breach.UserID)
+	}
+	if breach.Email == "" {
+		return nil, fmt.Errorf("%w: breach report identifies no user", errors.ErrInvalidInput)
+	}
+	return s.userRepo.GetByIdentifier(ctx, breach.Email)
+}
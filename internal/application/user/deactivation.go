@@ -0,0 +1,194 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// defaultDeactivationGracePeriod is how long a deactivated account can be
+// restored when no grace period is configured
+const defaultDeactivationGracePeriod = 30 * 24 * time.Hour
+
+// deactivationPurgeBatchSize is the number of expired deactivations purged
+// per query
+const deactivationPurgeBatchSize = 100
+
+// deactivationGracePeriod returns how long a deactivated account can be restored
+func (s *Service) deactivationGracePeriod() time.Duration {
+	if s.deactivationGrace <= 0 {
+		return defaultDeactivationGracePeriod
+	}
+	return s.deactivationGrace
+}
+
+// purgeAfter returns when a deactivated user is purged
+func (s *Service) purgeAfter(user *models.User) time.Time {
+	if user.DeactivatedAt == nil {
+		return time.Time{}
+	}
+	return user.DeactivatedAt.Add(s.deactivationGracePeriod())
+}
+
+// DeleteUser deactivates a user's account and revokes their sessions. The
+// account can be restored until the grace period ends, after which
+// PurgeDeactivatedUsers deletes it permanently.
+func (s *Service) DeleteUser(ctx context.Context, id uuid.UUID) (*services.AccountDeactivation, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if err := s.deactivateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &services.AccountDeactivation{
+		UserID:        user.ID,
+		DeactivatedAt: *user.DeactivatedAt,
+		PurgeAfter:    s.purgeAfter(user),
+	}, nil
+}
+
+// deactivateUser moves a user to the deactivated status
+func (s *Service) deactivateUser(ctx context.Context, user *models.User) error {
+	if user.Status == models.UserStatusDeactivated {
+		return nil
+	}
+	if err := user.Deactivate(s.clock.Now().UTC()); err != nil {
+		return errors.WrapError("DeleteUser", err)
+	}
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	// Deactivated users can no longer sign in, so nothing of their
+	// sessions may outlive the deactivation
+	if err := s.RevokeSessions(ctx, user.ID); err != nil {
+		s.logger.Error("failed to revoke sessions of deactivated user",
+			zap.String("userID", user.ID.String()),
+			zap.Error(err))
+	}
+	s.invalidatePublicProfile(ctx, user.ID)
+
+	s.publishUserEvent(ctx, string(events.UserDeactivated), events.NewUserDeactivationEvent(
+		events.UserDeactivated, user.ID, user.Email, user.Username, *user.DeactivatedAt, s.purgeAfter(user), uuid.Nil))
+
+	return nil
+}
+
+// RestoreAccount restores the deactivated account of a user who proves it is
+// theirs with their credentials. Accounts that are not deactivated fail with
+// errors.ErrInvalidStatusTransition.
+func (s *Service) RestoreAccount(ctx context.Context, identifier, password string) (*models.User, error) {
+	if identifier == "" || password == "" {
+		return nil, services.ErrInvalidCredentials
+	}
+	user, err := s.AuthenticateUser(ctx, identifier, password)
+	if err != nil {
+		return nil, err
+	}
+	return s.restoreUser(ctx, user, uuid.Nil)
+}
+
+// RestoreUser restores a deactivated account on behalf of its user
+func (s *Service) RestoreUser(ctx context.Context, userID, adminID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return s.restoreUser(ctx, user, adminID)
+}
+
+// restoreUser reactivates a deactivated user; restoredBy is the admin who
+// restored the account, if not the user
+func (s *Service) restoreUser(ctx context.Context, user *models.User, restoredBy uuid.UUID) (*models.User, error) {
+	if user.Status == models.UserStatusDeactivated && !s.clock.Now().Before(s.purgeAfter(user)) {
+		// The purge job has not caught up with the account yet
+		return nil, errors.WrapError("RestoreUser", errors.ErrUserNotFound)
+	}
+	deactivatedAt, purgeAfter := user.DeactivatedAt, s.purgeAfter(user)
+	if err := user.Restore(); err != nil {
+		return nil, errors.WrapError("RestoreUser", err)
+	}
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	s.invalidatePublicProfile(ctx, user.ID)
+
+	s.publishUserEvent(ctx, string(events.UserRestored), events.NewUserDeactivationEvent(
+		events.UserRestored, user.ID, user.Email, user.Username, *deactivatedAt, purgeAfter, restoredBy))
+
+	return user, nil
+}
+
+// PurgeDeactivatedUsers permanently deletes the users whose deactivation
+// grace period has ended and returns how many were purged. Users who cannot
+// be purged are logged and retried on the next run.
+func (s *Service) PurgeDeactivatedUsers(ctx context.Context) (int, error) {
+	before := s.clock.Now().Add(-s.deactivationGracePeriod())
+	purged := 0
+	for {
+		users, err := s.userRepo.ListDeactivatedBefore(ctx, before, deactivationPurgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list deactivated users: %w", err)
+		}
+
+		failed := 0
+		for _, user := range users {
+			if err := s.purgeDeactivatedUser(ctx, user); err != nil {
+				s.logger.Error("failed to purge deactivated user",
+					zap.String("userID", user.ID.String()),
+					zap.Error(err))
+				failed++
+				continue
+			}
+			purged++
+		}
+		// Stop on a short page, or on a page of failures that would be
+		// listed again
+		if len(users) < deactivationPurgeBatchSize || failed == len(users) {
+			return purged, nil
+		}
+	}
+}
+
+// purgeDeactivatedUser permanently deletes a user whose deactivation grace
+// period has ended
+func (s *Service) purgeDeactivatedUser(ctx context.Context, user *models.User) error {
+	if err := s.userRepo.Purge(ctx, user.ID); err != nil {
+		if stderrors.Is(err, errors.ErrUserNotFound) {
+			// Purged by another instance
+			return nil
+		}
+		return err
+	}
+
+	// The username is free to be registered again, including usernames
+	// the user had reserved by renaming
+	if s.usernameHistory != nil {
+		if err := s.usernameHistory.ReleaseReservations(ctx, user.ID); err != nil {
+			s.logger.Error("failed to release username reservations",
+				zap.String("userID", user.ID.String()),
+				zap.Error(err))
+		}
+	}
+	s.invalidatePublicProfile(ctx, user.ID)
+	if err := s.invalidateResetTokens(ctx, user.ID); err != nil {
+		s.logger.Error("failed to invalidate reset tokens of purged user",
+			zap.String("userID", user.ID.String()),
+			zap.Error(err))
+	}
+
+	s.publishUserEvent(ctx, string(events.UserDeactivationPurged), events.NewUserDeactivationEvent(
+		events.UserDeactivationPurged, user.ID, user.Email, user.Username, *user.DeactivatedAt, s.purgeAfter(user), uuid.Nil))
+
+	return nil
+}
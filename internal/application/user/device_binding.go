@@ -0,0 +1,52 @@
+package user
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// DeviceBindingPolicy controls which users get refresh tokens bound to the
+// device they logged in from. Roles act as security tiers; an empty list
+// binds every role. Users of bound roles must send a device ID to sign in
+// and to refresh.
+type DeviceBindingPolicy struct {
+	Enabled bool
+	Roles   []models.Role
+}
+
+// appliesTo reports whether device binding is enforced for the given role
+func (p DeviceBindingPolicy) appliesTo(role models.Role) bool {
+	if !p.Enabled {
+		return false
+	}
+	if len(p.Roles) == 0 {
+		return true
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// deviceFingerprint hashes the device ID of the current request so that the
+// raw identifier is never embedded in tokens. It is empty without a device ID.
+func deviceFingerprint(ctx context.Context) string {
+	deviceID := events.MetadataFromContext(ctx).DeviceID
+	if deviceID == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(deviceID))
+	return hex.EncodeToString(sum[:])
+}
+
+// sameDevice compares device fingerprints in constant time
+func sameDevice(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
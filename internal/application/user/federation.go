@@ -0,0 +1,190 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	stderrors "errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// usernameAttempts is how many suffixed usernames are tried for a new
+	// federated user before giving up
+	usernameAttempts  = 5
+	maxUsernameLength = 30
+	minUsernameLength = 3
+)
+
+// LoginWithExternalIdentity signs in the user linked to an external
+// identity, linking or creating the user on the identity's first login
+func (s *Service) LoginWithExternalIdentity(ctx context.Context, identity *services.ExternalIdentity) (*services.LoginResponse, error) {
+	if s.identities == nil {
+		return nil, services.ErrUnknownIdentityProvider
+	}
+
+	user, link, err := s.userForIdentity(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Status.CanAuthenticate() {
+		return nil, services.ErrAccountDisabled
+	}
+	if identity.OrganizationID != nil {
+		if err := s.joinOrganization(ctx, user, *identity.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.identities.RecordLogin(ctx, link.ID); err != nil {
+		s.logger.Warn("failed to record external identity login",
+			zap.String("provider", identity.Provider),
+			zap.Error(err))
+	}
+	return s.completeLogin(ctx, user)
+}
+
+// userForIdentity returns the user linked to an identity along with the
+// link, creating both when needed
+func (s *Service) userForIdentity(ctx context.Context, identity *services.ExternalIdentity) (*models.User, *models.UserIdentity, error) {
+	link, err := s.identities.GetByProviderSubject(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(ctx, link.UserID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("user not found: %w", err)
+		}
+		return user, link, nil
+	}
+	if !stderrors.Is(err, services.ErrNotFound) {
+		return nil, nil, fmt.Errorf("failed to look up external identity: %w", err)
+	}
+
+	// Linking and registration rely on the provider vouching for the email
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, nil, services.ErrExternalEmailUnverified
+	}
+
+	user, err := s.userRepo.GetByIdentifier(ctx, identity.Email)
+	switch {
+	case err == nil && user.ServiceAccount:
+		return nil, nil, services.ErrAccountDisabled
+	case err == nil:
+		// Whoever registered an unverified email may not own it, so the
+		// account must prove ownership before it can be taken over
+		if !user.EmailVerified {
+			return nil, nil, services.ErrAccountLinkRequiresVerification
+		}
+	case stderrors.Is(err, errors.ErrUserNotFound):
+		if user, err = s.registerExternalUser(ctx, identity); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	link = &models.UserIdentity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	if err := s.identities.Create(ctx, link); err != nil {
+		return nil, nil, fmt.Errorf("failed to link external identity: %w", err)
+	}
+	s.logger.Info("linked external identity",
+		zap.String("userID", user.ID.String()),
+		zap.String("provider", identity.Provider))
+	return user, link, nil
+}
+
+// registerExternalUser creates an active user for an external identity. The
+// user has no password until they reset one.
+func (s *Service) registerExternalUser(ctx context.Context, identity *services.ExternalIdentity) (*models.User, error) {
+	username, err := s.availableUsername(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.NewUser(identity.Email, username, models.RoleUser)
+	user.FirstName = identity.FirstName
+	user.LastName = identity.LastName
+	if err := user.VerifyEmail(); err != nil {
+		return nil, errors.WrapError("registerExternalUser", err)
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserRegistered), events.NewUserRegisteredEvent(
+		user.ID,
+		user.Email,
+		user.Username,
+		user.FirstName,
+		user.LastName,
+	))
+	s.publishUserEvent(ctx, string(events.UserVerified), events.NewUserVerifiedEvent(
+		user.ID,
+		user.Email,
+	))
+	s.publishProfileThresholds(ctx, user, 0)
+	s.applyDomainJoinPolicy(ctx, user)
+	return user, nil
+}
+
+// availableUsername derives an unused username from the identity's
+// suggested username or email, adding a random suffix when it is taken
+func (s *Service) availableUsername(ctx context.Context, identity *services.ExternalIdentity) (string, error) {
+	base := sanitizeUsername(identity.Username)
+	if base == "" {
+		base = sanitizeUsername(strings.SplitN(identity.Email, "@", 2)[0])
+	}
+	for len(base) < minUsernameLength {
+		base += "user"
+	}
+
+	candidate := base
+	for attempt := 0; attempt < usernameAttempts; attempt++ {
+		if attempt > 0 {
+			suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+			if err != nil {
+				return "", fmt.Errorf("failed to generate username: %w", err)
+			}
+			candidate = fmt.Sprintf("%s%04d", base[:min(len(base), maxUsernameLength-4)], suffix.Int64())
+		}
+
+		if _, err := s.userRepo.GetByUsername(ctx, candidate); err == nil {
+			continue
+		}
+		reserved, err := s.isUsernameReserved(ctx, candidate, uuid.Nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to check username reservation: %w", err)
+		}
+		if !reserved {
+			return candidate, nil
+		}
+	}
+	return "", services.ErrUsernameAlreadyExists
+}
+
+// sanitizeUsername keeps the letters, digits, dots, dashes and underscores
+// of a suggested username, lowercased
+func sanitizeUsername(suggested string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(suggested) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '_' {
+			b.WriteRune(r)
+		}
+		if b.Len() == maxUsernameLength {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// GetLoginMethods returns the password, passkeys and external identities a
+// user can sign in with
+func (s *Service) GetLoginMethods(ctx context.Context, userID uuid.UUID) (*services.LoginMethods, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return s.loginMethods(ctx, user)
+}
+
+// loginMethods collects the ways a user can sign in. Magic links and
+// password resets are not counted: they only recover an account whose email
+// the user still controls.
+func (s *Service) loginMethods(ctx context.Context, user *models.User) (*services.LoginMethods, error) {
+	methods := &services.LoginMethods{HasPassword: user.PasswordHash != ""}
+	if s.passkeys != nil {
+		credentials, err := s.passkeyCredentials.ListByUser(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list passkeys: %w", err)
+		}
+		methods.Passkeys = credentials
+	}
+	if s.identities != nil {
+		identities, err := s.identities.ListByUser(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list external identities: %w", err)
+		}
+		methods.Identities = identities
+	}
+	return methods, nil
+}
+
+// LinkExternalIdentity links an external identity to a signed-in user.
+// Linking an identity the user already has returns the existing link.
+func (s *Service) LinkExternalIdentity(ctx context.Context, userID uuid.UUID, identity *services.ExternalIdentity) (*models.UserIdentity, error) {
+	if s.identities == nil {
+		return nil, services.ErrUnknownIdentityProvider
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	existing, err := s.identities.GetByProviderSubject(ctx, identity.Provider, identity.Subject)
+	switch {
+	case err == nil && existing.UserID == user.ID:
+		return existing, nil
+	case err == nil:
+		return nil, services.ErrIdentityAlreadyLinked
+	case !stderrors.Is(err, services.ErrNotFound):
+		return nil, fmt.Errorf("failed to look up external identity: %w", err)
+	}
+
+	link := &models.UserIdentity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	if err := s.identities.Create(ctx, link); err != nil {
+		// Another user linked the identity since the lookup
+		if stderrors.Is(err, services.ErrConflict) {
+			return nil, services.ErrIdentityAlreadyLinked
+		}
+		return nil, fmt.Errorf("failed to link external identity: %w", err)
+	}
+	s.logger.Info("linked external identity",
+		zap.String("userID", user.ID.String()),
+		zap.String("provider", identity.Provider))
+
+	s.publishUserEvent(ctx, string(events.UserIdentityLinked), events.NewUserIdentityEvent(
+		events.UserIdentityLinked, user.ID, user.Email, link.ID, link.Provider))
+	return link, nil
+}
+
+// UnlinkExternalIdentity removes an external identity of a user, unless it
+// is the only way left for them to sign in
+func (s *Service) UnlinkExternalIdentity(ctx context.Context, userID, id uuid.UUID) error {
+	if s.identities == nil {
+		return services.ErrUnknownIdentityProvider
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	methods, err := s.loginMethods(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	var link *models.UserIdentity
+	for _, identity := range methods.Identities {
+		if identity.ID == id {
+			link = identity
+			break
+		}
+	}
+	if link == nil {
+		return services.ErrNotFound
+	}
+	if methods.Count() <= 1 {
+		return services.ErrLastLoginMethod
+	}
+
+	if err := s.identities.Delete(ctx, user.ID, id); err != nil {
+		return fmt.Errorf("failed to unlink external identity: %w", err)
+	}
+	s.logger.Info("unlinked external identity",
+		zap.String("userID", user.ID.String()),
+		zap.String("provider", link.Provider))
+
+	s.publishUserEvent(ctx, string(events.UserIdentityUnlinked), events.NewUserIdentityEvent(
+		events.UserIdentityUnlinked, user.ID, user.Email, link.ID, link.Provider))
+	return nil
+}
@@ -0,0 +1,283 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultKnowledgeFactorAnswers and defaultKnowledgeFactorAttempts are
+	// used when the policy leaves them unset
+	defaultKnowledgeFactorAnswers  = 3
+	defaultKnowledgeFactorAttempts = 5
+	// knowledgeFactorAttemptWindow is the period over which recoveries of
+	// an account are limited
+	knowledgeFactorAttemptWindow = 24 * time.Hour
+	// minAnswerLength and maxAnswerLength bound normalized answers
+	minAnswerLength = 3
+	maxAnswerLength = 64
+)
+
+func knowledgeFactorAttemptsKey(subject string) string {
+	return fmt.Sprintf("knowledge_factor_attempt_count:%s", subject)
+}
+
+func (p KnowledgeFactorPolicy) requiredAnswers() int {
+	if p.RequiredAnswers <= 0 {
+		return defaultKnowledgeFactorAnswers
+	}
+	return p.RequiredAnswers
+}
+
+func (p KnowledgeFactorPolicy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return defaultKnowledgeFactorAttempts
+	}
+	return p.MaxAttempts
+}
+
+// normalizeAnswer makes answers match regardless of case and spacing
+func normalizeAnswer(answer string) string {
+	return strings.ToLower(strings.Join(strings.Fields(answer), " "))
+}
+
+// GetKnowledgeFactors returns the configured security questions and the ones
+// a user answered
+func (s *Service) GetKnowledgeFactors(ctx context.Context, userID uuid.UUID) (*services.KnowledgeFactorStatus, error) {
+	if s.knowledgeFactors == nil {
+		return nil, services.ErrKnowledgeFactorsNotEnabled
+	}
+
+	factors, err := s.knowledgeFactors.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list security questions: %w", err)
+	}
+	status := &services.KnowledgeFactorStatus{
+		Questions:       s.knowledgeFactorPolicy.Questions,
+		RequiredAnswers: s.knowledgeFactorPolicy.requiredAnswers(),
+		Answered:        make([]string, 0, len(factors)),
+	}
+	for _, factor := range factors {
+		status.Answered = append(status.Answered, factor.Question)
+	}
+	return status, nil
+}
+
+// SetKnowledgeFactors replaces a user's security question answers. The user
+// re-authenticates first since the answers are enough to reset the password.
+func (s *Service) SetKnowledgeFactors(ctx context.Context, userID uuid.UUID, answers []services.KnowledgeFactorAnswer, stepUp services.StepUpInput) error {
+	if s.knowledgeFactors == nil {
+		return services.ErrKnowledgeFactorsNotEnabled
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	// Service accounts have no password to recover
+	if user.ServiceAccount {
+		return fmt.Errorf("%w: service accounts have no password", errors.ErrInvalidInput)
+	}
+	if err := s.verifyStepUp(ctx, user, stepUp); err != nil {
+		return err
+	}
+
+	required := s.knowledgeFactorPolicy.requiredAnswers()
+	if len(answers) != required {
+		return fmt.Errorf("%w: answer exactly %d security questions", errors.ErrInvalidInput, required)
+	}
+	now := s.clock.Now()
+	factors := make([]*models.KnowledgeFactor, 0, len(answers))
+	for _, answer := range answers {
+		if !slices.Contains(s.knowledgeFactorPolicy.Questions, answer.Question) {
+			return fmt.Errorf("%w: unknown security question %q", errors.ErrInvalidInput, answer.Question)
+		}
+		if slices.ContainsFunc(factors, func(f *models.KnowledgeFactor) bool { return f.Question == answer.Question }) {
+			return fmt.Errorf("%w: each security question can be answered once", errors.ErrInvalidInput)
+		}
+		normalized := normalizeAnswer(answer.Answer)
+		if length := utf8.RuneCountInString(normalized); length < minAnswerLength || length > maxAnswerLength {
+			return fmt.Errorf("%w: answers must be %d to %d characters", errors.ErrInvalidInput, minAnswerLength, maxAnswerLength)
+		}
+
+		hash, err := s.knowledgeFactorHasher.Hash(normalized)
+		if err != nil {
+			return fmt.Errorf("failed to hash answer: %w", err)
+		}
+		factors = append(factors, &models.KnowledgeFactor{
+			UserID:     user.ID,
+			Question:   answer.Question,
+			AnswerHash: hash,
+			CreatedAt:  now,
+		})
+	}
+
+	if err := s.knowledgeFactors.Replace(ctx, user.ID, factors); err != nil {
+		return fmt.Errorf("failed to store security questions: %w", err)
+	}
+
+	s.logger.Info("set security questions", zap.String("userID", user.ID.String()))
+	s.publishUserEvent(ctx, string(events.UserKnowledgeFactorsSet), events.NewUserKnowledgeFactorsEvent(
+		events.UserKnowledgeFactorsSet, user.ID, user.Email))
+	return nil
+}
+
+// RemoveKnowledgeFactors removes a user's security question answers
+func (s *Service) RemoveKnowledgeFactors(ctx context.Context, userID uuid.UUID) error {
+	if s.knowledgeFactors == nil {
+		return services.ErrKnowledgeFactorsNotEnabled
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if err := s.knowledgeFactors.DeleteByUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	s.logger.Info("removed security questions", zap.String("userID", user.ID.String()))
+	s.publishUserEvent(ctx, string(events.UserKnowledgeFactorsRemoved), events.NewUserKnowledgeFactorsEvent(
+		events.UserKnowledgeFactorsRemoved, user.ID, user.Email))
+	return nil
+}
+
+// GetRecoveryQuestions returns the security questions to answer to recover
+// an account. Unknown accounts and accounts without answers get questions
+// picked from the configured ones by the identifier, so that repeated calls
+// agree and callers cannot tell them apart.
+func (s *Service) GetRecoveryQuestions(ctx context.Context, identifier string) ([]string, error) {
+	if s.knowledgeFactors == nil {
+		return nil, services.ErrKnowledgeFactorsNotEnabled
+	}
+
+	user, err := s.userRepo.GetByIdentifier(ctx, identifier)
+	switch {
+	case err == nil && !user.ServiceAccount:
+		factors, err := s.knowledgeFactors.ListByUser(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list security questions: %w", err)
+		}
+		if len(factors) > 0 {
+			questions := make([]string, 0, len(factors))
+			for _, factor := range factors {
+				questions = append(questions, factor.Question)
+			}
+			return questions, nil
+		}
+	case err != nil && !stderrors.Is(err, errors.ErrUserNotFound):
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return s.decoyQuestions(identifier), nil
+}
+
+// decoyQuestions picks as many questions as users answer from the configured
+// ones, in the order answered questions are listed in
+func (s *Service) decoyQuestions(identifier string) []string {
+	identifier = models.NormalizeIdentifier(identifier)
+	rank := func(question string) string {
+		return hashToken(identifier + "\n" + question)
+	}
+
+	questions := slices.Clone(s.knowledgeFactorPolicy.Questions)
+	slices.SortFunc(questions, func(a, b string) int {
+		return strings.Compare(rank(a), rank(b))
+	})
+	questions = questions[:min(len(questions), s.knowledgeFactorPolicy.requiredAnswers())]
+	slices.Sort(questions)
+	return questions
+}
+
+// RecoverWithKnowledgeFactors issues a password reset token to whoever
+// answers an account's security questions. Every attempt counts toward the
+// account's daily limit, including attempts on unknown accounts, so that
+// the answers cannot be guessed at and the limit does not reveal accounts.
+func (s *Service) RecoverWithKnowledgeFactors(ctx context.Context, identifier string, answers []services.KnowledgeFactorAnswer) (*services.KnowledgeFactorRecovery, error) {
+	if s.knowledgeFactors == nil {
+		return nil, services.ErrKnowledgeFactorsNotEnabled
+	}
+
+	user, err := s.userRepo.GetByIdentifier(ctx, identifier)
+	if err != nil && !stderrors.Is(err, errors.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	subject := hashToken(models.NormalizeIdentifier(identifier))
+	if user != nil {
+		subject = user.ID.String()
+	}
+
+	allowed, err := s.countKnowledgeFactorAttempt(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, services.ErrKnowledgeFactorAttemptsExceeded
+	}
+	if user == nil || user.ServiceAccount || !user.Status.CanAuthenticate() {
+		return nil, services.ErrKnowledgeFactorsInvalid
+	}
+
+	factors, err := s.knowledgeFactors.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list security questions: %w", err)
+	}
+	if len(factors) == 0 || !s.verifyKnowledgeFactors(factors, answers) {
+		s.logger.Warn("failed security question recovery", zap.String("userID", user.ID.String()))
+		return nil, services.ErrKnowledgeFactorsInvalid
+	}
+
+	token, err := s.issueResetToken(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("recovered account with security questions", zap.String("userID", user.ID.String()))
+	s.publishUserEvent(ctx, string(events.UserKnowledgeFactorsRecovery), events.NewUserKnowledgeFactorsEvent(
+		events.UserKnowledgeFactorsRecovery, user.ID, user.Email))
+
+	return &services.KnowledgeFactorRecovery{
+		ResetToken: token,
+		ExpiresAt:  s.clock.Now().Add(s.tokenService.TokenDuration(services.TokenTypeReset)),
+	}, nil
+}
+
+// verifyKnowledgeFactors reports whether answers match every stored answer.
+// All answers are checked so that the time taken does not tell which failed.
+func (s *Service) verifyKnowledgeFactors(factors []*models.KnowledgeFactor, answers []services.KnowledgeFactorAnswer) bool {
+	valid := true
+	for _, factor := range factors {
+		index := slices.IndexFunc(answers, func(a services.KnowledgeFactorAnswer) bool { return a.Question == factor.Question })
+		if index < 0 {
+			valid = false
+			continue
+		}
+		if err := s.knowledgeFactorHasher.Verify(normalizeAnswer(answers[index].Answer), factor.AnswerHash); err != nil {
+			valid = false
+		}
+	}
+	return valid
+}
+
+// countKnowledgeFactorAttempt records a recovery attempt and reports whether
+// it is within the limit. Attempts are counted before the answers are
+// checked, so that parallel guesses cannot get past the limit. The count
+// starts over once the window has passed.
+func (s *Service) countKnowledgeFactorAttempt(ctx context.Context, subject string) (bool, error) {
+	count, err := s.cacheService.Incr(ctx, knowledgeFactorAttemptsKey(subject), knowledgeFactorAttemptWindow)
+	if err != nil {
+		return false, fmt.Errorf("failed to record security question attempt: %w", err)
+	}
+	return count <= int64(s.knowledgeFactorPolicy.maxAttempts()), nil
+}
@@ -0,0 +1,35 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountKnowledgeFactorAttempt(t *testing.T) {
+	s := newPasswordResetTestService(WithKnowledgeFactors(nil, nil, KnowledgeFactorPolicy{MaxAttempts: 3}))
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := s.countKnowledgeFactorAttempt(context.Background(), "subject")
+			assert.NoError(t, err)
+			if ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), allowed.Load())
+
+	ok, err := s.countKnowledgeFactorAttempt(context.Background(), "other")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
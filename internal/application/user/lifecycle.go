@@ -0,0 +1,86 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// statusEventTypes maps the status a user moved to onto the event published
+// for the transition. Deletion, deactivation and restoring publish their
+// dedicated events.
+var statusEventTypes = map[models.UserStatus]events.EventType{
+	models.UserStatusActive:    events.UserActivated,
+	models.UserStatusSuspended: events.UserSuspended,
+	models.UserStatusPending:   events.UserPendingVerification,
+}
+
+// publishStatusChange publishes the transition event if the user's status
+// differs from previous
+func (s *Service) publishStatusChange(ctx context.Context, user *models.User, previous models.UserStatus, reason string) {
+	if user.Status == previous {
+		return
+	}
+
+	eventType, ok := statusEventTypes[user.Status]
+	if !ok {
+		return
+	}
+
+	s.publishUserEvent(ctx, string(eventType), events.NewUserStatusChangedEvent(
+		eventType,
+		user.ID,
+		user.Email,
+		string(previous),
+		string(user.Status),
+		reason,
+	))
+}
+
+// ChangeUserStatus moves a user to another lifecycle status. Transitions the
+// lifecycle does not allow fail with errors.ErrInvalidStatusTransition.
+func (s *Service) ChangeUserStatus(ctx context.Context, id uuid.UUID, status models.UserStatus, reason string) (*models.User, error) {
+	if !status.IsValid() {
+		return nil, errors.WrapError("ChangeUserStatus", errors.ErrInvalidInput)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	switch {
+	case status == models.UserStatusDeleted:
+		if err := s.deleteUser(ctx, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	case status == models.UserStatusDeactivated:
+		if err := s.deactivateUser(ctx, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	case user.Status == models.UserStatusDeactivated && status != user.Status:
+		// Deactivated accounts are restored with RestoreUser, which
+		// clears the deactivation
+		return nil, errors.WrapError("ChangeUserStatus", &models.StatusTransitionError{From: user.Status, To: status})
+	}
+
+	previous := user.Status
+	if err := user.TransitionTo(status); err != nil {
+		return nil, errors.WrapError("ChangeUserStatus", err)
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	s.invalidatePublicProfile(ctx, user.ID)
+
+	s.publishStatusChange(ctx, user, previous, reason)
+
+	return user, nil
+}
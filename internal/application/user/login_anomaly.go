@@ -0,0 +1,170 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultLoginConfirmationTTL is how long a confirmation link works
+	// when no lifetime is configured
+	defaultLoginConfirmationTTL = 15 * time.Minute
+	// loginConfirmationCooldown is how long repeated logins from the same
+	// new device wait for another confirmation email
+	loginConfirmationCooldown = time.Minute
+)
+
+// LoginAnomalyPolicy controls what happens when a user signs in from a
+// device or country they have not signed in from before. Such logins are
+// published for an email notification; with RequireConfirmation, no tokens
+// are issued until the user confirms the login with the emailed link.
+type LoginAnomalyPolicy struct {
+	Enabled             bool
+	RequireConfirmation bool
+	ConfirmationTTL     time.Duration // 0 uses 15 minutes
+}
+
+func (p LoginAnomalyPolicy) confirmationTTL() time.Duration {
+	if p.ConfirmationTTL <= 0 {
+		return defaultLoginConfirmationTTL
+	}
+	return p.ConfirmationTTL
+}
+
+// loginConfirmationEntry is a login held back until the user confirms it,
+// cached by the hash of the emailed token
+type loginConfirmationEntry struct {
+	UserID    uuid.UUID `json:"userId"`
+	DeviceID  string    `json:"deviceId,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	IPAddress string    `json:"ipAddress,omitempty"`
+	Country   string    `json:"country,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func loginConfirmationKey(tokenHash string) string {
+	return fmt.Sprintf("login_confirmation:%s", tokenHash)
+}
+
+func loginConfirmationCooldownKey(userID uuid.UUID, origin string) string {
+	return fmt.Sprintf("login_confirmation_cooldown:%s:%s", userID, hashToken(origin))
+}
+
+// checkLoginAnomaly publishes a login from a new device or country and,
+// when the policy requires it, holds the login back with
+// ErrLoginConfirmationRequired until the user confirms it. A user's first
+// login is never an anomaly.
+func (s *Service) checkLoginAnomaly(ctx context.Context, user *models.User) error {
+	if !s.loginAnomalies.Enabled || s.securityActivity == nil || user.ServiceAccount {
+		return nil
+	}
+
+	metadata := events.MetadataFromContext(ctx)
+	history, err := s.securityActivity.LoginHistory(ctx, user.ID, metadata.DeviceID, metadata.UserAgent, metadata.Country)
+	if err != nil {
+		// Logins are only held back while the history can be read when
+		// confirmation is required
+		if s.loginAnomalies.RequireConfirmation {
+			return fmt.Errorf("failed to check login history: %w", err)
+		}
+		s.logger.Error("failed to check login history",
+			zap.String("userID", user.ID.String()),
+			zap.Error(err))
+		return nil
+	}
+	if !history.HasLogins || (history.KnownDevice && history.KnownCountry) {
+		return nil
+	}
+	s.raiseAccountRisk(ctx, user.ID, models.AccountRiskWatch, "login from a new device or country")
+
+	if !s.loginAnomalies.RequireConfirmation {
+		s.publishUserEvent(ctx, string(events.UserLoginNewDevice), events.NewUserLoginNewDeviceEvent(
+			user.ID, user.Email, !history.KnownDevice, !history.KnownCountry,
+			metadata.UserAgent, metadata.ClientIP, metadata.Country, "", nil))
+		return nil
+	}
+
+	device := metadata.DeviceID
+	if device == "" {
+		device = metadata.UserAgent
+	}
+	allowed, err := s.cacheService.SetNX(ctx, loginConfirmationCooldownKey(user.ID, device+"|"+metadata.Country), true, loginConfirmationCooldown)
+	if err != nil {
+		return fmt.Errorf("failed to check login confirmation cooldown: %w", err)
+	}
+	if !allowed {
+		return services.ErrLoginConfirmationRequired
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Errorf("failed to generate login confirmation token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	ttl := s.loginAnomalies.confirmationTTL()
+	entry := loginConfirmationEntry{
+		UserID:    user.ID,
+		DeviceID:  metadata.DeviceID,
+		UserAgent: metadata.UserAgent,
+		IPAddress: metadata.ClientIP,
+		Country:   metadata.Country,
+		ExpiresAt: s.clock.Now().Add(ttl),
+	}
+	if err := s.cacheService.Set(ctx, loginConfirmationKey(hashToken(token)), entry, ttl); err != nil {
+		return fmt.Errorf("failed to store login confirmation: %w", err)
+	}
+
+	confirmationLink := fmt.Sprintf("%s/confirm-login?token=%s", s.webAppURL, token)
+	s.publishUserEvent(ctx, string(events.UserLoginNewDevice), events.NewUserLoginNewDeviceEvent(
+		user.ID, user.Email, !history.KnownDevice, !history.KnownCountry,
+		metadata.UserAgent, metadata.ClientIP, metadata.Country, confirmationLink, &entry.ExpiresAt))
+
+	return services.ErrLoginConfirmationRequired
+}
+
+// ConfirmLogin redeems the token emailed for a held back login, recording
+// its device and country as confirmed so that the user can sign in from
+// them
+func (s *Service) ConfirmLogin(ctx context.Context, token string) error {
+	if token == "" || s.securityActivity == nil {
+		return services.ErrInvalidToken
+	}
+
+	key := loginConfirmationKey(hashToken(token))
+	var entry loginConfirmationEntry
+	if err := s.cacheService.Get(ctx, key, &entry); err != nil {
+		if stderrors.Is(err, services.ErrCacheKeyNotFound) {
+			return services.ErrInvalidToken
+		}
+		return fmt.Errorf("failed to get login confirmation: %w", err)
+	}
+	if !s.clock.Now().Before(entry.ExpiresAt) {
+		return services.ErrInvalidToken
+	}
+	if err := s.cacheService.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to redeem login confirmation: %w", err)
+	}
+
+	activity := &models.SecurityActivity{
+		UserID:    entry.UserID,
+		Type:      models.SecurityActivityLoginConfirmed,
+		DeviceID:  entry.DeviceID,
+		UserAgent: entry.UserAgent,
+		IPAddress: entry.IPAddress,
+		Country:   entry.Country,
+	}
+	if err := s.securityActivity.Create(ctx, activity); err != nil {
+		return fmt.Errorf("failed to record login confirmation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// magicLinkCooldown is how long a user waits between login links, so that
+// requests cannot flood their inbox
+const magicLinkCooldown = time.Minute
+
+func magicLinkCooldownKey(userID uuid.UUID) string {
+	return fmt.Sprintf("magic_link_cooldown:%s", userID)
+}
+
+func magicLinkUsedKey(tokenHash string) string {
+	return fmt.Sprintf("magic_link_used:%s", tokenHash)
+}
+
+// RequestMagicLink emails a single-use login link to the user with the given
+// address. Unknown addresses, service accounts, accounts that cannot sign in
+// and repeated requests within the cooldown are ignored so that callers
+// cannot probe which addresses are registered.
+func (s *Service) RequestMagicLink(ctx context.Context, email string) error {
+	if !s.magicLinks {
+		return services.ErrMagicLinksNotEnabled
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		s.logger.Debug("magic link requested for unknown email")
+		return nil
+	}
+	if user.ServiceAccount || !user.Status.CanAuthenticate() {
+		s.logger.Debug("magic link requested for user who cannot sign in",
+			zap.String("userID", user.ID.String()))
+		return nil
+	}
+
+	allowed, err := s.cacheService.SetNX(ctx, magicLinkCooldownKey(user.ID), true, magicLinkCooldown)
+	if err != nil {
+		return fmt.Errorf("failed to check magic link cooldown: %w", err)
+	}
+	if !allowed {
+		s.logger.Debug("magic link requested during cooldown",
+			zap.String("userID", user.ID.String()))
+		return nil
+	}
+
+	token, err := s.tokenService.GenerateMagicLinkToken(ctx, services.TokenClaims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Username:  user.Username,
+		Role:      string(user.Role),
+		TokenType: services.TokenTypeMagicLink,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate magic link token: %w", err)
+	}
+
+	loginLink := fmt.Sprintf("%s/magic-link?token=%s", s.webAppURL, token)
+	expiresAt := s.clock.Now().Add(s.tokenService.TokenDuration(services.TokenTypeMagicLink))
+	s.publishUserEvent(ctx, string(events.UserMagicLinkRequested), events.NewUserMagicLinkRequestedEvent(
+		user.ID,
+		user.Email,
+		loginLink,
+		expiresAt,
+	))
+
+	return nil
+}
+
+// LoginWithMagicLink redeems the token of a login link and signs in its
+// user. Opening the link proves access to the mailbox like a password reset
+// does, so a second factor set up by the user is still asked for.
+func (s *Service) LoginWithMagicLink(ctx context.Context, token string) (*services.LoginResponse, error) {
+	if !s.magicLinks {
+		return nil, services.ErrMagicLinksNotEnabled
+	}
+	if token == "" {
+		return nil, services.ErrInvalidToken
+	}
+
+	claims, err := s.tokenService.ValidateToken(ctx, token, services.TokenTypeMagicLink)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", services.ErrInvalidToken, err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, services.ErrInvalidToken
+	}
+	// A link sent to an address the user has since changed no longer signs in
+	if user.ServiceAccount || user.Email != claims.Email {
+		return nil, services.ErrInvalidToken
+	}
+
+	// Claim the token atomically so concurrent redemptions cannot both succeed
+	tokenHash := hashToken(token)
+	claimed, err := s.cacheService.SetNX(ctx, magicLinkUsedKey(tokenHash), true,
+		s.tokenService.TokenDuration(services.TokenTypeMagicLink))
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem magic link: %w", err)
+	}
+	if !claimed {
+		return nil, services.ErrInvalidToken
+	}
+
+	if !user.Status.CanAuthenticate() {
+		return nil, services.ErrAccountDisabled
+	}
+	return s.completeLogin(ctx, user)
+}
@@ -0,0 +1,460 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// mfaVerifyTTL bounds how long a login waits for a code
+	mfaVerifyTTL = 5 * time.Minute
+	// mfaEnrollTTL bounds how long a login waits for an authenticator to be
+	// set up, which takes longer than entering a code
+	mfaEnrollTTL = 15 * time.Minute
+	// maxMFAAttempts is how many codes a challenge, or a user changing
+	// their authenticator, may enter
+	maxMFAAttempts = 5
+	// mfaAttemptWindow is how long codes entered to confirm or disable an
+	// authenticator count against a user
+	mfaAttemptWindow = 15 * time.Minute
+	// mfaMethodTOTP names authenticator apps in MFA events
+	mfaMethodTOTP = "totp"
+)
+
+// mfaChallengeEntry is a login waiting for a second factor, cached by the
+// hash of its token
+type mfaChallengeEntry struct {
+	UserID    uuid.UUID          `json:"userId"`
+	Action    services.MFAAction `json:"action"`
+	ExpiresAt time.Time          `json:"expiresAt"`
+}
+
+func mfaChallengeKey(tokenHash string) string {
+	return fmt.Sprintf("mfa_challenge:%s", tokenHash)
+}
+
+func mfaChallengeAttemptsKey(tokenHash string) string {
+	return fmt.Sprintf("mfa_challenge_attempts:%s", tokenHash)
+}
+
+func mfaUserAttemptsKey(userID uuid.UUID) string {
+	return fmt.Sprintf("mfa_attempts:%s", userID)
+}
+
+// completeLogin starts a session for a user who proved their first factor,
+// unless their organization's access policy denies the login or they need
+// to present or set up a second factor first
+func (s *Service) completeLogin(ctx context.Context, user *models.User) (*services.LoginResponse, error) {
+	if err := s.checkAccessPolicy(ctx, user); err != nil {
+		return nil, err
+	}
+	if s.totpCredentials == nil {
+		return s.startSession(ctx, user)
+	}
+
+	credential, err := s.totpCredentials.GetByUserID(ctx, user.ID)
+	if err != nil && !stderrors.Is(err, services.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get MFA credential: %w", err)
+	}
+	if credential != nil && credential.Confirmed() {
+		return s.issueMFAChallenge(ctx, user, services.MFAActionVerify, mfaVerifyTTL)
+	}
+
+	// Policies are evaluated fail closed: a login they may forbid is not
+	// let through while they cannot be read
+	requirement, err := s.mfaRequirement(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if requirement.Enforced(s.clock.Now()) {
+		return s.issueMFAChallenge(ctx, user, services.MFAActionEnroll, mfaEnrollTTL)
+	}
+
+	response, err := s.startSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if requirement.Required {
+		deadline := requirement.EnforceAfter
+		response.MFAEnrollmentDeadline = &deadline
+	}
+	return response, nil
+}
+
+func (s *Service) mfaRequirement(ctx context.Context, user *models.User) (services.MFARequirement, error) {
+	if s.mfaPolicies == nil {
+		return services.MFARequirement{}, nil
+	}
+	requirement, err := s.mfaPolicies.Requirement(ctx, user)
+	if err != nil {
+		return requirement, fmt.Errorf("failed to evaluate MFA policies: %w", err)
+	}
+	return requirement, nil
+}
+
+// issueMFAChallenge holds back the session until the user completes action
+func (s *Service) issueMFAChallenge(ctx context.Context, user *models.User, action services.MFAAction, ttl time.Duration) (*services.LoginResponse, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate MFA token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	entry := mfaChallengeEntry{
+		UserID:    user.ID,
+		Action:    action,
+		ExpiresAt: s.clock.Now().Add(ttl),
+	}
+	if err := s.cacheService.Set(ctx, mfaChallengeKey(hashToken(token)), entry, ttl); err != nil {
+		return nil, fmt.Errorf("failed to store MFA challenge: %w", err)
+	}
+
+	return &services.LoginResponse{
+		User: user,
+		MFAChallenge: &services.MFAChallenge{
+			Token:     token,
+			Action:    action,
+			ExpiresAt: entry.ExpiresAt,
+		},
+	}, nil
+}
+
+// mfaChallenge returns the challenge of a token
+func (s *Service) mfaChallenge(ctx context.Context, token string) (*mfaChallengeEntry, error) {
+	if s.totpCredentials == nil || token == "" {
+		return nil, services.ErrMFATokenInvalid
+	}
+	var entry mfaChallengeEntry
+	if err := s.cacheService.Get(ctx, mfaChallengeKey(hashToken(token)), &entry); err != nil {
+		if stderrors.Is(err, services.ErrCacheKeyNotFound) {
+			return nil, services.ErrMFATokenInvalid
+		}
+		return nil, fmt.Errorf("failed to get MFA challenge: %w", err)
+	}
+	return &entry, nil
+}
+
+// reserveMFAAttempt counts an attempt to enter a code before the code is
+// checked, so that parallel requests cannot enter more codes than allowed.
+// The count starts over once the window has passed.
+func (s *Service) reserveMFAAttempt(ctx context.Context, key string, window time.Duration) (bool, error) {
+	count, err := s.cacheService.Incr(ctx, key, window)
+	if err != nil {
+		return false, fmt.Errorf("failed to count MFA attempt: %w", err)
+	}
+	return count <= maxMFAAttempts, nil
+}
+
+// reserveChallengeAttempt counts an attempt to complete a challenge,
+// dropping the challenge once it has seen too many so that codes cannot be
+// guessed
+func (s *Service) reserveChallengeAttempt(ctx context.Context, token string, entry *mfaChallengeEntry) error {
+	tokenHash := hashToken(token)
+	ttl := entry.ExpiresAt.Sub(s.clock.Now())
+	if ttl <= 0 {
+		return services.ErrMFATokenInvalid
+	}
+	ok, err := s.reserveMFAAttempt(ctx, mfaChallengeAttemptsKey(tokenHash), ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := s.cacheService.Delete(ctx, mfaChallengeKey(tokenHash)); err != nil {
+			s.logger.Warn("failed to drop MFA challenge",
+				zap.String("userID", entry.UserID.String()),
+				zap.Error(err))
+		}
+		return services.ErrMFATokenInvalid
+	}
+	return nil
+}
+
+// reserveUserAttempt counts an attempt of a signed-in user to enter a code
+func (s *Service) reserveUserAttempt(ctx context.Context, userID uuid.UUID) error {
+	ok, err := s.reserveMFAAttempt(ctx, mfaUserAttemptsKey(userID), mfaAttemptWindow)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return services.ErrMFATooManyAttempts
+	}
+	return nil
+}
+
+// resetUserAttempts forgets the codes a user entered once one was accepted
+func (s *Service) resetUserAttempts(ctx context.Context, userID uuid.UUID) {
+	if err := s.cacheService.Delete(ctx, mfaUserAttemptsKey(userID)); err != nil {
+		s.logger.Warn("failed to reset MFA attempts",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+	}
+}
+
+// CompleteMFALogin finishes a login waiting for a second factor
+func (s *Service) CompleteMFALogin(ctx context.Context, mfaToken, code string) (*services.LoginResponse, error) {
+	entry, err := s.mfaChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, entry.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if !user.Status.CanAuthenticate() {
+		return nil, services.ErrAccountDisabled
+	}
+
+	credential, err := s.totpCredentials.GetByUserID(ctx, user.ID)
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return nil, services.ErrMFANotEnabled
+		}
+		return nil, fmt.Errorf("failed to get MFA credential: %w", err)
+	}
+	// An enroll challenge is completed by confirming the new authenticator
+	if entry.Action == services.MFAActionVerify && !credential.Confirmed() {
+		return nil, services.ErrMFANotEnabled
+	}
+
+	if err := s.reserveChallengeAttempt(ctx, mfaToken, entry); err != nil {
+		return nil, err
+	}
+	if err := s.verifyTOTPCode(ctx, credential, code); err != nil {
+		return nil, err
+	}
+	tokenHash := hashToken(mfaToken)
+	if err := s.cacheService.Delete(ctx, mfaChallengeKey(tokenHash)); err != nil {
+		s.logger.Warn("failed to delete completed MFA challenge", zap.Error(err))
+	}
+	if err := s.cacheService.Delete(ctx, mfaChallengeAttemptsKey(tokenHash)); err != nil {
+		s.logger.Warn("failed to delete MFA challenge attempts", zap.Error(err))
+	}
+
+	if !credential.Confirmed() {
+		if err := s.confirmTOTPCredential(ctx, user, credential); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// BeginChallengeEnrollment starts setting up an authenticator for the user
+// of an enroll challenge
+func (s *Service) BeginChallengeEnrollment(ctx context.Context, mfaToken string) (*services.TOTPEnrollment, error) {
+	entry, err := s.mfaChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Action != services.MFAActionEnroll {
+		return nil, services.ErrMFATokenInvalid
+	}
+
+	user, err := s.userRepo.GetByID(ctx, entry.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return s.beginTOTPEnrollment(ctx, user)
+}
+
+// GetMFAStatus returns a user's second factor and policy requirement
+func (s *Service) GetMFAStatus(ctx context.Context, userID uuid.UUID) (*services.MFAStatus, error) {
+	status := &services.MFAStatus{}
+	if s.totpCredentials == nil {
+		return status, nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	credential, err := s.totpCredentials.GetByUserID(ctx, userID)
+	if err != nil && !stderrors.Is(err, services.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get MFA credential: %w", err)
+	}
+	if credential != nil && credential.Confirmed() {
+		status.TOTPEnabled = true
+		status.EnabledAt = credential.ConfirmedAt
+	}
+
+	status.Requirement, err = s.mfaRequirement(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// BeginTOTPEnrollment starts setting up an authenticator app
+func (s *Service) BeginTOTPEnrollment(ctx context.Context, userID uuid.UUID) (*services.TOTPEnrollment, error) {
+	if s.totpCredentials == nil {
+		return nil, services.ErrMFANotEnabled
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return s.beginTOTPEnrollment(ctx, user)
+}
+
+func (s *Service) beginTOTPEnrollment(ctx context.Context, user *models.User) (*services.TOTPEnrollment, error) {
+	existing, err := s.totpCredentials.GetByUserID(ctx, user.ID)
+	if err != nil && !stderrors.Is(err, services.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get MFA credential: %w", err)
+	}
+	if existing != nil && existing.Confirmed() {
+		return nil, services.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := s.totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.totpCredentials.Save(ctx, &models.TOTPCredential{
+		UserID: user.ID,
+		Secret: secret,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save MFA credential: %w", err)
+	}
+
+	return &services.TOTPEnrollment{
+		Secret: secret,
+		URI:    s.totp.KeyURI(secret, user.Email),
+	}, nil
+}
+
+// ConfirmTOTPEnrollment enables the authenticator app once the user entered a valid code
+func (s *Service) ConfirmTOTPEnrollment(ctx context.Context, userID uuid.UUID, code string) error {
+	if s.totpCredentials == nil {
+		return services.ErrMFANotEnabled
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	credential, err := s.totpCredentials.GetByUserID(ctx, userID)
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return services.ErrMFANotEnabled
+		}
+		return fmt.Errorf("failed to get MFA credential: %w", err)
+	}
+	if credential.Confirmed() {
+		return services.ErrMFAAlreadyEnabled
+	}
+
+	if err := s.reserveUserAttempt(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.verifyTOTPCode(ctx, credential, code); err != nil {
+		return err
+	}
+	s.resetUserAttempts(ctx, userID)
+	return s.confirmTOTPCredential(ctx, user, credential)
+}
+
+// DisableTOTP removes the authenticator app after checking a current code
+func (s *Service) DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	if s.totpCredentials == nil {
+		return services.ErrMFANotEnabled
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	credential, err := s.totpCredentials.GetByUserID(ctx, userID)
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return services.ErrMFANotEnabled
+		}
+		return fmt.Errorf("failed to get MFA credential: %w", err)
+	}
+	if !credential.Confirmed() {
+		return services.ErrMFANotEnabled
+	}
+
+	requirement, err := s.mfaRequirement(ctx, user)
+	if err != nil {
+		return err
+	}
+	if requirement.Enforced(s.clock.Now()) {
+		return services.ErrMFARequiredByPolicy
+	}
+
+	if err := s.reserveUserAttempt(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.verifyTOTPCode(ctx, credential, code); err != nil {
+		return err
+	}
+	s.resetUserAttempts(ctx, userID)
+	if err := s.totpCredentials.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete MFA credential: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserMFADisabled), events.NewUserMFAChangedEvent(
+		events.UserMFADisabled, user.ID, user.Email, mfaMethodTOTP, ""))
+	return nil
+}
+
+// ResetMFA removes a user's authenticator without a code. Policies still
+// apply, so a covered user sets up a new one at their next login.
+func (s *Service) ResetMFA(ctx context.Context, userID uuid.UUID) error {
+	if s.totpCredentials == nil {
+		return services.ErrMFANotEnabled
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if err := s.totpCredentials.Delete(ctx, userID); err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return services.ErrMFANotEnabled
+		}
+		return fmt.Errorf("failed to delete MFA credential: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserMFADisabled), events.NewUserMFAChangedEvent(
+		events.UserMFADisabled, user.ID, user.Email, mfaMethodTOTP, "reset"))
+	return nil
+}
+
+// verifyTOTPCode checks a code against a credential. Each code is accepted
+// once; a replayed code is rejected like a wrong one.
+func (s *Service) verifyTOTPCode(ctx context.Context, credential *models.TOTPCredential, code string) error {
+	step, ok := s.totp.Validate(credential.Secret, code, s.clock.Now())
+	if !ok {
+		return services.ErrMFACodeInvalid
+	}
+	used, err := s.totpCredentials.UseStep(ctx, credential.UserID, step)
+	if err != nil {
+		return fmt.Errorf("failed to record MFA code: %w", err)
+	}
+	if !used {
+		return services.ErrMFACodeInvalid
+	}
+	credential.LastUsedStep = step
+	return nil
+}
+
+func (s *Service) confirmTOTPCredential(ctx context.Context, user *models.User, credential *models.TOTPCredential) error {
+	now := s.clock.Now()
+	credential.ConfirmedAt = &now
+	if err := s.totpCredentials.Save(ctx, credential); err != nil {
+		return fmt.Errorf("failed to save MFA credential: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserMFAEnabled), events.NewUserMFAChangedEvent(
+		events.UserMFAEnabled, user.ID, user.Email, mfaMethodTOTP, ""))
+	return nil
+}
@@ -0,0 +1,287 @@
+package user
+
+import (
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// Option configures optional dependencies of the user service
+type Option func(*Service)
+
+// UsernamePolicy controls how often a username can be changed and how long
+// a previous username stays reserved for its former owner
+type UsernamePolicy struct {
+	ChangeCooldown    time.Duration
+	ReservationPeriod time.Duration
+}
+
+// WithMaxActiveResetTokens limits how many password reset tokens a user may
+// hold at once; issuing another one invalidates the oldest
+func WithMaxActiveResetTokens(limit int) Option {
+	return func(s *Service) {
+		s.maxActiveResetTokens = limit
+	}
+}
+
+// WithAdminPasswordResetLimit limits how many password resets each admin may
+// request for other users per hour; 0 uses the default of 10
+func WithAdminPasswordResetLimit(limit int) Option {
+	return func(s *Service) {
+		s.adminPasswordResetLimit = limit
+	}
+}
+
+// WithSecurityActivity enables recording of logins and password changes for
+// security summaries
+func WithSecurityActivity(repo repositories.SecurityActivityRepository) Option {
+	return func(s *Service) {
+		s.securityActivity = repo
+	}
+}
+
+// WithLoginAnomalyDetection tells users by email about logins from devices
+// and countries they have not signed in from before. It needs
+// WithSecurityActivity.
+func WithLoginAnomalyDetection(policy LoginAnomalyPolicy) Option {
+	return func(s *Service) {
+		s.loginAnomalies = policy
+	}
+}
+
+// WithAccountRisk escalates what logins require as the risk state of the
+// user's account rises. verifier checks the CAPTCHA responses of logins to
+// watched and restricted accounts; nil skips the CAPTCHA.
+func WithAccountRisk(policy AccountRiskPolicy, verifier services.CaptchaVerifier) Option {
+	return func(s *Service) {
+		s.accountRiskPolicy = policy
+		s.captcha = verifier
+	}
+}
+
+// WithRegistrationRisk scores registrations with the scorer. Risky
+// registrations must verify their email before signing in, and the riskiest
+// are flagged for manual review when WithModeration is also given. It needs
+// WithSecurityActivity to count registrations per IP address and device.
+func WithRegistrationRisk(scorer services.RegistrationRiskScorer, policy RegistrationRiskPolicy) Option {
+	return func(s *Service) {
+		s.registrationRisk = scorer
+		s.registrationRiskPolicy = policy
+	}
+}
+
+// WithDeviceBinding binds refresh tokens to the device ID presented at login
+// for the roles covered by the policy
+func WithDeviceBinding(policy DeviceBindingPolicy) Option {
+	return func(s *Service) {
+		s.deviceBinding = policy
+	}
+}
+
+// WithEmailVerification enables tracking of verification emails, allowing at
+// most dailyLimit of them per user in any 24 hours
+func WithEmailVerification(repo repositories.EmailVerificationRepository, dailyLimit int) Option {
+	return func(s *Service) {
+		s.emailVerifications = repo
+		s.verificationDailyLimit = dailyLimit
+	}
+}
+
+// WithVerifiedEmailRequired refuses to start sessions for users who have not
+// verified their email, however they sign in. Without it only users whose
+// registration was challenged have to verify first.
+func WithVerifiedEmailRequired(required bool) Option {
+	return func(s *Service) {
+		s.requireVerifiedEmail = required
+	}
+}
+
+// WithPublicProfileCache caches public profiles for ttl; 0 disables caching
+func WithPublicProfileCache(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.publicProfileTTL = ttl
+	}
+}
+
+// WithSearchIndex enables user search backed by the given index
+func WithSearchIndex(index services.UserSearchIndex) Option {
+	return func(s *Service) {
+		s.searchIndex = index
+	}
+}
+
+// WithExternalIdentities enables sign-in with external identity providers,
+// linking their users through repo
+func WithExternalIdentities(repo repositories.UserIdentityRepository) Option {
+	return func(s *Service) {
+		s.identities = repo
+	}
+}
+
+// WithOrganizations makes users signed in through an organization's SSO
+// connection members of the organization, and applies the domain join
+// policy of the organization that verified the domain of a user's email once
+// the email is verified. Memberships and invitations are recorded in repo.
+func WithOrganizations(repo repositories.OrganizationRepository) Option {
+	return func(s *Service) {
+		s.organizations = repo
+	}
+}
+
+// WithMFA enables authenticator app second factors, required at login of
+// users who set one up and of users covered by an enforced policy. policies
+// may be nil.
+func WithMFA(credentials repositories.TOTPCredentialRepository, totp services.TOTPService, policies services.MFAPolicyService) Option {
+	return func(s *Service) {
+		s.totpCredentials = credentials
+		s.totp = totp
+		s.mfaPolicies = policies
+	}
+}
+
+// WithPasskeys enables signing in with passkeys, which users register
+// with the authenticators the verifier accepts
+func WithPasskeys(credentials repositories.WebAuthnCredentialRepository, verifier services.WebAuthnVerifier) Option {
+	return func(s *Service) {
+		s.passkeyCredentials = credentials
+		s.passkeys = verifier
+	}
+}
+
+// WithMagicLinks enables signing in with single-use links emailed to users
+func WithMagicLinks() Option {
+	return func(s *Service) {
+		s.magicLinks = true
+	}
+}
+
+// KnowledgeFactorPolicy configures recovering accounts by answering
+// security questions
+type KnowledgeFactorPolicy struct {
+	Questions       []string // the questions users choose from
+	RequiredAnswers int      // questions each user answers; 0 uses 3
+	MaxAttempts     int      // recoveries per account and day; 0 uses 5
+}
+
+// WithKnowledgeFactors enables recovering accounts by answering security
+// questions, whose answers are stored as hashes of hasher
+func WithKnowledgeFactors(repo repositories.KnowledgeFactorRepository, hasher services.SecretHasher, policy KnowledgeFactorPolicy) Option {
+	return func(s *Service) {
+		s.knowledgeFactors = repo
+		s.knowledgeFactorHasher = hasher
+		s.knowledgeFactorPolicy = policy
+	}
+}
+
+// AudiencePolicy configures the tokens minted for another internal API
+type AudiencePolicy struct {
+	Scopes   []string      // the scopes tokens may carry
+	Roles    []string      // roles that may mint tokens; empty allows every role
+	Lifetime time.Duration // 0 uses the access token lifetime, which it can only shorten
+}
+
+// WithAudienceTokens enables minting access tokens for other internal APIs,
+// keyed by the audience names they are requested with
+func WithAudienceTokens(audiences map[string]AudiencePolicy) Option {
+	return func(s *Service) {
+		s.audiences = audiences
+	}
+}
+
+// WithRoles resolves the permissions embedded in access tokens from the
+// roles table rather than the defaults of the built-in roles
+func WithRoles(roles services.RoleService) Option {
+	return func(s *Service) {
+		s.roles = roles
+	}
+}
+
+// WithTenantSettings applies the overrides of a user's organization to the
+// password policy and token lifetimes
+func WithTenantSettings(tenantSettings services.TenantSettingsService) Option {
+	return func(s *Service) {
+		s.tenantSettings = tenantSettings
+	}
+}
+
+// SessionLimitPolicy bounds the concurrent sessions of each user.
+// Organizations may override both settings.
+type SessionLimitPolicy struct {
+	MaxSessions int                       // 0 disables the limit
+	Action      models.SessionLimitAction // empty denies the new login
+}
+
+// WithSessionLimit tracks the sessions of users in repo and enforces the
+// concurrent session limit of policy at login
+func WithSessionLimit(repo repositories.SessionRepository, policy SessionLimitPolicy) Option {
+	return func(s *Service) {
+		s.sessions = repo
+		s.sessionLimit = policy
+	}
+}
+
+// ProfilePolicy sets which fields profile completeness is measured over,
+// which of them users must provide eventually and the completeness
+// percentages whose crossing is published as an event
+type ProfilePolicy struct {
+	Fields     []string // empty measures all models.ProfileFields
+	Required   []string
+	Thresholds []int
+}
+
+// WithProfilePolicy measures profile completeness with the given policy
+func WithProfilePolicy(policy ProfilePolicy) Option {
+	return func(s *Service) {
+		s.profilePolicy = policy
+	}
+}
+
+// WithProfileClaims adds the user's name and email verification status to
+// access tokens with the given policy
+func WithProfileClaims(policy ProfileClaimsPolicy) Option {
+	return func(s *Service) {
+		s.profileClaims = policy
+	}
+}
+
+// WithPurgeApprovalWindow sets how long an admin's approval to permanently
+// delete a user can be redeemed by a second admin; 0 uses 15 minutes
+func WithPurgeApprovalWindow(window time.Duration) Option {
+	return func(s *Service) {
+		s.purgeWindow = window
+	}
+}
+
+// WithDeactivationGracePeriod sets how long users can restore their account
+// after deleting it before it is purged; 0 uses 30 days
+func WithDeactivationGracePeriod(period time.Duration) Option {
+	return func(s *Service) {
+		s.deactivationGrace = period
+	}
+}
+
+// WithModeration restricts the tokens issued to accounts that are
+// restricted because of abuse reports
+func WithModeration(moderation services.ModerationService) Option {
+	return func(s *Service) {
+		s.moderation = moderation
+	}
+}
+
+// WithClock sets the clock expiry, session and attempt limits are measured
+// with; the default is the system clock
+func WithClock(clock services.Clock) Option {
+	return func(s *Service) {
+		s.clock = clock
+	}
+}
+
+// WithUsernameHistory enables username change tracking with the given policy
+func WithUsernameHistory(repo repositories.UsernameHistoryRepository, policy UsernamePolicy) Option {
+	return func(s *Service) {
+		s.usernameHistory = repo
+		s.usernamePolicy = policy
+	}
+}
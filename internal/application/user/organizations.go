@@ -0,0 +1,129 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// joinOrganization makes a user a member of an organization, before tokens
+// naming their organization as the tenant are issued. Users who are already
+// members are left as they are.
+func (s *Service) joinOrganization(ctx context.Context, user *models.User, organizationID uuid.UUID) error {
+	if s.organizations == nil {
+		return fmt.Errorf("organization memberships are not configured")
+	}
+	member, err := s.isOrganizationMember(ctx, user, organizationID)
+	if err != nil || member {
+		return err
+	}
+
+	err = s.organizations.AddMember(ctx, &models.OrganizationMembership{
+		OrganizationID: organizationID,
+		UserID:         user.ID,
+		Role:           models.OrganizationRoleMember,
+	})
+	if err != nil {
+		// A concurrent login may have added the membership first
+		if stderrors.Is(err, services.ErrConflict) {
+			return nil
+		}
+		return fmt.Errorf("failed to add organization member: %w", err)
+	}
+	if user.OrganizationID == nil {
+		user.OrganizationID = &organizationID
+	}
+
+	s.logger.Info("added user to organization",
+		zap.String("userID", user.ID.String()),
+		zap.String("organizationID", organizationID.String()))
+	s.publishUserEvent(ctx, string(events.OrganizationMemberJoined), events.NewOrganizationMemberEvent(
+		events.OrganizationMemberJoined, organizationID, user.ID, string(models.OrganizationRoleMember), user.ID))
+	return nil
+}
+
+// inviteToOrganization invites a user into an organization unless they are
+// a member or invited already
+func (s *Service) inviteToOrganization(ctx context.Context, user *models.User, organizationID uuid.UUID) error {
+	member, err := s.isOrganizationMember(ctx, user, organizationID)
+	if err != nil || member {
+		return err
+	}
+
+	err = s.organizations.AddInvitation(ctx, &models.OrganizationInvitation{
+		OrganizationID: organizationID,
+		UserID:         user.ID,
+		Role:           models.OrganizationRoleMember,
+	})
+	if err != nil {
+		if stderrors.Is(err, services.ErrConflict) {
+			return nil
+		}
+		return fmt.Errorf("failed to add organization invitation: %w", err)
+	}
+
+	s.logger.Info("invited user to organization",
+		zap.String("userID", user.ID.String()),
+		zap.String("organizationID", organizationID.String()))
+	s.publishUserEvent(ctx, string(events.OrganizationInvitationCreated), events.NewOrganizationMemberEvent(
+		events.OrganizationInvitationCreated, organizationID, user.ID, string(models.OrganizationRoleMember), user.ID))
+	return nil
+}
+
+// isOrganizationMember reports whether a user is a member of an organization
+func (s *Service) isOrganizationMember(ctx context.Context, user *models.User, organizationID uuid.UUID) (bool, error) {
+	_, err := s.organizations.GetMember(ctx, organizationID, user.ID)
+	if err == nil {
+		return true, nil
+	}
+	if !stderrors.Is(err, services.ErrNotFound) {
+		return false, fmt.Errorf("failed to get organization membership: %w", err)
+	}
+	return false, nil
+}
+
+// applyDomainJoinPolicy joins or invites a user with a verified email into
+// the organization that verified the email's domain, as the organization's
+// domain join policy says. Failures are logged rather than returned so that
+// they do not undo the verification.
+func (s *Service) applyDomainJoinPolicy(ctx context.Context, user *models.User) {
+	if s.organizations == nil || !user.EmailVerified || user.ServiceAccount {
+		return
+	}
+	domain := models.EmailDomain(user.Email)
+	if domain == "" {
+		return
+	}
+
+	organization, err := s.organizations.GetByVerifiedDomain(ctx, domain)
+	if err != nil {
+		if !stderrors.Is(err, services.ErrNotFound) {
+			s.logger.Error("failed to look up organization by email domain",
+				zap.String("domain", domain),
+				zap.Error(err))
+		}
+		return
+	}
+
+	switch organization.DomainJoinPolicy {
+	case models.DomainJoinPolicyJoin:
+		err = s.joinOrganization(ctx, user, organization.ID)
+	case models.DomainJoinPolicyInvite:
+		err = s.inviteToOrganization(ctx, user, organization.ID)
+	default:
+		return
+	}
+	if err != nil {
+		s.logger.Error("failed to apply organization domain join policy",
+			zap.String("userID", user.ID.String()),
+			zap.String("organizationID", organization.ID.String()),
+			zap.String("policy", string(organization.DomainJoinPolicy)),
+			zap.Error(err))
+	}
+}
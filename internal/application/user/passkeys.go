@@ -0,0 +1,323 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// passkeyCeremonyTTL bounds how long a registration or sign-in waits for
+	// the authenticator, which includes the user unlocking it
+	passkeyCeremonyTTL = 5 * time.Minute
+	// passkeyChallengeSize is the size of the random challenges authenticators sign
+	passkeyChallengeSize = 32
+	// maxPasskeyNameLength bounds the name users give a passkey
+	maxPasskeyNameLength = 100
+	// defaultPasskeyName names passkeys registered without a name
+	defaultPasskeyName = "Passkey"
+)
+
+// passkeyCeremonyEntry is a started passkey registration or sign-in,
+// cached by the hash of its token
+type passkeyCeremonyEntry struct {
+	Challenge    []byte    `json:"challenge"`
+	UserID       uuid.UUID `json:"userId"` // the registering user; nil for sign-ins
+	Registration bool      `json:"registration"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+func passkeyCeremonyKey(tokenHash string) string {
+	return fmt.Sprintf("passkey_ceremony:%s", tokenHash)
+}
+
+func passkeyCeremonyUsedKey(tokenHash string) string {
+	return fmt.Sprintf("passkey_ceremony_used:%s", tokenHash)
+}
+
+// BeginPasskeyRegistration starts registering a passkey for a user
+func (s *Service) BeginPasskeyRegistration(ctx context.Context, userID uuid.UUID) (*services.PasskeyRegistration, error) {
+	if s.passkeys == nil {
+		return nil, services.ErrPasskeysNotEnabled
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	existing, err := s.passkeyCredentials.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passkeys: %w", err)
+	}
+
+	token, entry, err := s.startPasskeyCeremony(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	displayName := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if displayName == "" {
+		displayName = user.Username
+	}
+	options := services.PasskeyCreationOptions{
+		Challenge:       entry.Challenge,
+		RelyingParty:    s.passkeys.RelyingParty(),
+		UserHandle:      user.ID[:],
+		UserName:        user.Email,
+		UserDisplayName: displayName,
+		Timeout:         passkeyCeremonyTTL,
+	}
+	for _, credential := range existing {
+		options.ExcludeCredentials = append(options.ExcludeCredentials, credential.CredentialID)
+	}
+
+	return &services.PasskeyRegistration{
+		Token:     token,
+		ExpiresAt: entry.ExpiresAt,
+		Options:   options,
+	}, nil
+}
+
+// FinishPasskeyRegistration stores the passkey an authenticator created for
+// a registration the user started
+func (s *Service) FinishPasskeyRegistration(ctx context.Context, userID uuid.UUID, token, name string, attestation services.PasskeyAttestation) (*models.WebAuthnCredential, error) {
+	if s.passkeys == nil {
+		return nil, services.ErrPasskeysNotEnabled
+	}
+	name = strings.TrimSpace(name)
+	if len(name) > maxPasskeyNameLength {
+		return nil, fmt.Errorf("%w: name must be at most %d characters", errors.ErrInvalidInput, maxPasskeyNameLength)
+	}
+	if name == "" {
+		name = defaultPasskeyName
+	}
+
+	entry, err := s.redeemPasskeyCeremony(ctx, token, true)
+	if err != nil {
+		return nil, err
+	}
+	if entry.UserID != userID {
+		return nil, services.ErrPasskeyChallengeInvalid
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	attested, err := s.passkeys.VerifyRegistration(entry.Challenge, attestation)
+	if err != nil {
+		return nil, err
+	}
+	credential := &models.WebAuthnCredential{
+		UserID:       user.ID,
+		CredentialID: attested.CredentialID,
+		PublicKey:    attested.PublicKey,
+		SignCount:    int64(attested.SignCount),
+		AAGUID:       attested.AAGUID,
+		Name:         name,
+	}
+	if err := s.passkeyCredentials.Create(ctx, credential); err != nil {
+		return nil, fmt.Errorf("failed to save passkey: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserPasskeyRegistered), events.NewUserPasskeyEvent(
+		events.UserPasskeyRegistered, user.ID, user.Email, credential.ID, credential.Name))
+
+	return credential, nil
+}
+
+// ListPasskeys returns the passkeys of a user
+func (s *Service) ListPasskeys(ctx context.Context, userID uuid.UUID) ([]*models.WebAuthnCredential, error) {
+	if s.passkeys == nil {
+		return nil, services.ErrPasskeysNotEnabled
+	}
+	credentials, err := s.passkeyCredentials.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passkeys: %w", err)
+	}
+	return credentials, nil
+}
+
+// DeletePasskey removes a passkey of a user, unless it is the only way
+// left for them to sign in
+func (s *Service) DeletePasskey(ctx context.Context, userID, id uuid.UUID) error {
+	if s.passkeys == nil {
+		return services.ErrPasskeysNotEnabled
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	methods, err := s.loginMethods(ctx, user)
+	if err != nil {
+		return err
+	}
+	for _, credential := range methods.Passkeys {
+		if credential.ID == id && methods.Count() <= 1 {
+			return services.ErrLastLoginMethod
+		}
+	}
+	if err := s.passkeyCredentials.Delete(ctx, userID, id); err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserPasskeyRemoved), events.NewUserPasskeyEvent(
+		events.UserPasskeyRemoved, user.ID, user.Email, id, ""))
+	return nil
+}
+
+// BeginPasskeyLogin starts a sign-in with a passkey. Passkeys are
+// registered as discoverable credentials, so the user picks theirs on the
+// authenticator and does not name their account first.
+func (s *Service) BeginPasskeyLogin(ctx context.Context) (*services.PasskeyLogin, error) {
+	if s.passkeys == nil {
+		return nil, services.ErrPasskeysNotEnabled
+	}
+	token, entry, err := s.startPasskeyCeremony(ctx, uuid.Nil, false)
+	if err != nil {
+		return nil, err
+	}
+	return &services.PasskeyLogin{
+		Token:     token,
+		ExpiresAt: entry.ExpiresAt,
+		Options: services.PasskeyRequestOptions{
+			Challenge: entry.Challenge,
+			RPID:      s.passkeys.RelyingParty().ID,
+			Timeout:   passkeyCeremonyTTL,
+		},
+	}, nil
+}
+
+// FinishPasskeyLogin signs in the user of the passkey an assertion was made
+// with. Passkeys verify the user, so no further factor is asked for.
+func (s *Service) FinishPasskeyLogin(ctx context.Context, token string, assertion services.PasskeyAssertion) (*services.LoginResponse, error) {
+	if s.passkeys == nil {
+		return nil, services.ErrPasskeysNotEnabled
+	}
+	entry, err := s.redeemPasskeyCeremony(ctx, token, false)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.passkeyCredentials.GetByCredentialID(ctx, assertion.CredentialID)
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return nil, services.ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to get passkey: %w", err)
+	}
+	if len(assertion.UserHandle) > 0 && !bytes.Equal(assertion.UserHandle, credential.UserID[:]) {
+		return nil, services.ErrInvalidCredentials
+	}
+
+	signCount, err := s.passkeys.VerifyAssertion(entry.Challenge, credential.PublicKey, assertion)
+	if err != nil {
+		if stderrors.Is(err, services.ErrPasskeyInvalid) {
+			s.logger.Info("passkey assertion rejected",
+				zap.String("passkeyID", credential.ID.String()),
+				zap.Error(err))
+			return nil, services.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if signCountRegressed(credential.SignCount, signCount) {
+		s.logger.Warn("passkey signature counter did not increase, possibly cloned",
+			zap.String("passkeyID", credential.ID.String()),
+			zap.String("userID", credential.UserID.String()))
+		return nil, services.ErrInvalidCredentials
+	}
+	if err := s.passkeyCredentials.RecordUse(ctx, credential.ID, int64(signCount), s.clock.Now()); err != nil {
+		return nil, fmt.Errorf("failed to record passkey use: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, credential.UserID)
+	if err != nil {
+		return nil, services.ErrInvalidCredentials
+	}
+	if user.ServiceAccount {
+		return nil, services.ErrInvalidCredentials
+	}
+	if !user.Status.CanAuthenticate() {
+		return nil, services.ErrAccountDisabled
+	}
+
+	if err := s.checkAccessPolicy(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, user)
+}
+
+// startPasskeyCeremony caches a new challenge for a registration or sign-in
+// and returns the token identifying it
+func (s *Service) startPasskeyCeremony(ctx context.Context, userID uuid.UUID, registration bool) (string, *passkeyCeremonyEntry, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate passkey token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	challenge := make([]byte, passkeyChallengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", nil, fmt.Errorf("failed to generate passkey challenge: %w", err)
+	}
+
+	entry := &passkeyCeremonyEntry{
+		Challenge:    challenge,
+		UserID:       userID,
+		Registration: registration,
+		ExpiresAt:    s.clock.Now().Add(passkeyCeremonyTTL),
+	}
+	if err := s.cacheService.Set(ctx, passkeyCeremonyKey(hashToken(token)), entry, passkeyCeremonyTTL); err != nil {
+		return "", nil, fmt.Errorf("failed to store passkey challenge: %w", err)
+	}
+	return token, entry, nil
+}
+
+// redeemPasskeyCeremony returns the registration or sign-in of a token and
+// marks it used, so each challenge is answered once
+func (s *Service) redeemPasskeyCeremony(ctx context.Context, token string, registration bool) (*passkeyCeremonyEntry, error) {
+	if token == "" {
+		return nil, services.ErrPasskeyChallengeInvalid
+	}
+	tokenHash := hashToken(token)
+	var entry passkeyCeremonyEntry
+	if err := s.cacheService.Get(ctx, passkeyCeremonyKey(tokenHash), &entry); err != nil {
+		if stderrors.Is(err, services.ErrCacheKeyNotFound) {
+			return nil, services.ErrPasskeyChallengeInvalid
+		}
+		return nil, fmt.Errorf("failed to get passkey challenge: %w", err)
+	}
+	if entry.Registration != registration || s.clock.Now().After(entry.ExpiresAt) {
+		return nil, services.ErrPasskeyChallengeInvalid
+	}
+
+	redeemed, err := s.cacheService.SetNX(ctx, passkeyCeremonyUsedKey(tokenHash), true, passkeyCeremonyTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem passkey challenge: %w", err)
+	}
+	if !redeemed {
+		return nil, services.ErrPasskeyChallengeInvalid
+	}
+	if err := s.cacheService.Delete(ctx, passkeyCeremonyKey(tokenHash)); err != nil {
+		s.logger.Warn("failed to delete redeemed passkey challenge", zap.Error(err))
+	}
+	return &entry, nil
+}
+
+// signCountRegressed reports whether the signature counter of a passkey did
+// not increase since it was last used. That means another authenticator
+// holds a copy of the key; authenticators that count nothing always report 0.
+func signCountRegressed(stored int64, signCount uint32) bool {
+	return (signCount != 0 || stored != 0) && int64(signCount) <= stored
+}
@@ -0,0 +1,27 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignCountRegressed(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		stored    int64
+		signCount uint32
+		want      bool
+	}{
+		{name: "authenticator without counter", stored: 0, signCount: 0, want: false},
+		{name: "first use", stored: 0, signCount: 1, want: false},
+		{name: "counter increased", stored: 41, signCount: 42, want: false},
+		{name: "counter repeated", stored: 42, signCount: 42, want: true},
+		{name: "counter went back", stored: 42, signCount: 7, want: true},
+		{name: "counter reset to zero", stored: 42, signCount: 0, want: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, signCountRegressed(tt.stored, tt.signCount))
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPasswordResetWorkers   = 4
+	defaultPasswordResetQueueSize = 1024
+
+	// Forgot-password requests accepted per hour when no limits are set
+	defaultPasswordResetsPerIP      = 20
+	defaultPasswordResetsPerAddress = 3
+	passwordResetLimitWindow        = time.Hour
+
+	// passwordResetTimeout bounds the lookup, token and event work of one
+	// forgot-password request
+	passwordResetTimeout = 30 * time.Second
+)
+
+// passwordResetQueue hands forgot-password requests to background workers,
+// so that responses take the same time whether or not the account exists
+type passwordResetQueue struct {
+	workers  int
+	requests chan passwordResetRequest
+}
+
+// passwordResetRequest is a queued forgot-password request. It keeps the
+// values of the request context, such as the event metadata and organization
+// scope, but not its cancellation, since the response is sent before the
+// request is processed.
+type passwordResetRequest struct {
+	ctx   context.Context
+	email string
+	// recovery requests links for the accounts using email as their
+	// verified recovery address
+	recovery bool
+}
+
+func passwordResetsByIPKey(ip string) string {
+	return fmt.Sprintf("password_resets_ip:%s", hashToken(ip))
+}
+
+func passwordResetsByAddressKey(email string) string {
+	return fmt.Sprintf("password_resets_address:%s", hashToken(strings.ToLower(email)))
+}
+
+// WithPasswordResetWorkers sets how many workers process forgot-password
+// requests and how many requests may wait for them; 0 uses the defaults of
+// 4 workers and 1024 requests
+func WithPasswordResetWorkers(workers, queueSize int) Option {
+	return func(s *Service) {
+		s.passwordResets = newPasswordResetQueue(workers, queueSize)
+	}
+}
+
+// WithPasswordResetLimits limits how many forgot-password requests are
+// accepted per hour from each client IP and for each email address; 0 uses
+// the defaults of 20 and 3. Requests over a limit are dropped, with the same
+// response as accepted ones.
+func WithPasswordResetLimits(perIP, perAddress int) Option {
+	return func(s *Service) {
+		s.passwordResetsPerIP = perIP
+		s.passwordResetsPerAddress = perAddress
+	}
+}
+
+func newPasswordResetQueue(workers, queueSize int) *passwordResetQueue {
+	if workers <= 0 {
+		workers = defaultPasswordResetWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = defaultPasswordResetQueueSize
+	}
+	return &passwordResetQueue{
+		workers:  workers,
+		requests: make(chan passwordResetRequest, queueSize),
+	}
+}
+
+// RequestPasswordReset queues a password reset for the account with the
+// email. It succeeds whether or not the account exists; the lookup, the
+// reset token and the event are handled by RunPasswordResetWorkers.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	s.queuePasswordReset(ctx, email, false)
+	return nil
+}
+
+// queuePasswordReset queues a forgot-password request unless its client or
+// address exceeded their limit or the queue is full. Dropped requests are
+// only logged, so that the response never tells them apart from accepted
+// ones and a full queue does not fail the endpoint for everyone.
+func (s *Service) queuePasswordReset(ctx context.Context, email string, recovery bool) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return
+	}
+	if !s.allowPasswordReset(ctx, email) {
+		s.logger.Info("dropped password reset request over its rate limit")
+		return
+	}
+	request := passwordResetRequest{ctx: context.WithoutCancel(ctx), email: email, recovery: recovery}
+	if !s.passwordResets.enqueue(request) {
+		s.logger.Warn("dropped password reset request, queue is full")
+	}
+}
+
+// allowPasswordReset counts a forgot-password request against the limits of
+// its client IP and address. Requests are let through while the counters
+// cannot be updated, since the queue still bounds the work they cause.
+func (s *Service) allowPasswordReset(ctx context.Context, email string) bool {
+	perIP := s.passwordResetsPerIP
+	if perIP <= 0 {
+		perIP = defaultPasswordResetsPerIP
+	}
+	perAddress := s.passwordResetsPerAddress
+	if perAddress <= 0 {
+		perAddress = defaultPasswordResetsPerAddress
+	}
+
+	if ip := events.MetadataFromContext(ctx).ClientIP; ip != "" {
+		count, err := s.cacheService.Incr(ctx, passwordResetsByIPKey(ip), passwordResetLimitWindow)
+		if err != nil {
+			s.logger.Error("failed to count password reset request", zap.Error(err))
+		} else if count > int64(perIP) {
+			return false
+		}
+	}
+	count, err := s.cacheService.Incr(ctx, passwordResetsByAddressKey(email), passwordResetLimitWindow)
+	if err != nil {
+		s.logger.Error("failed to count password reset request", zap.Error(err))
+		return true
+	}
+	return count <= int64(perAddress)
+}
+
+// enqueue queues a request without blocking and reports whether it fit
+func (q *passwordResetQueue) enqueue(request passwordResetRequest) bool {
+	select {
+	case q.requests <- request:
+		return true
+	default:
+		return false
+	}
+}
+
+// RunPasswordResetWorkers processes queued forgot-password requests until
+// ctx is canceled. Requests still queued then are dropped.
+func (s *Service) RunPasswordResetWorkers(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < s.passwordResets.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runPasswordResetWorker(ctx)
+		}()
+	}
+	wg.Wait()
+
+	if pending := len(s.passwordResets.requests); pending > 0 {
+		s.logger.Warn("dropped queued password reset requests on shutdown", zap.Int("count", pending))
+	}
+}
+
+func (s *Service) runPasswordResetWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case request := <-s.passwordResets.requests:
+			if request.recovery {
+				s.processRecoveryPasswordReset(request.ctx, request.email)
+			} else {
+				s.processPasswordReset(request.ctx, request.email)
+			}
+		}
+	}
+}
+
+// processPasswordReset issues a reset link to the account with the email and
+// publishes it for delivery. Usernames are not looked up, and accounts that
+// cannot sign in get no link. Failures are logged, since the requester was
+// already told that a link is on its way if the account exists.
+func (s *Service) processPasswordReset(ctx context.Context, email string) {
+	ctx, cancel := context.WithTimeout(ctx, passwordResetTimeout)
+	defer cancel()
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil || user.ServiceAccount {
+		s.logger.Debug("ignored password reset request for unknown account")
+		return
+	}
+	if !user.Status.CanAuthenticate() {
+		s.logger.Info("ignored password reset request for account that cannot sign in",
+			zap.String("userID", user.ID.String()),
+			zap.String("status", string(user.Status)))
+		return
+	}
+
+	resetLink, err := s.issueResetLink(ctx, user)
+	if err != nil {
+		s.logger.Error("failed to issue password reset link",
+			zap.String("userID", user.ID.String()),
+			zap.Error(err))
+		return
+	}
+
+	// Publish password reset requested event
+	s.publishUserEvent(ctx, string(events.UserPasswordReset), events.NewUserPasswordResetEvent(
+		user.ID,
+		user.Email,
+		resetLink,
+	))
+}
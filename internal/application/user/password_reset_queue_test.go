@@ -0,0 +1,76 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newPasswordResetTestService(opts ...Option) *Service {
+	return NewService(nil, nil, nil, memory.NewCacheService(), nil, zap.NewNop(),
+		nil, "https://app.example.com", opts...)
+}
+
+func clientContext(ip string) context.Context {
+	return events.WithMetadata(context.Background(), events.Metadata{ClientIP: ip})
+}
+
+func TestQueuePasswordReset(t *testing.T) {
+	t.Run("limits requests per address", func(t *testing.T) {
+		s := newPasswordResetTestService(WithPasswordResetLimits(100, 2))
+		for i := 0; i < 3; i++ {
+			require.NoError(t, s.RequestPasswordReset(clientContext("203.0.113.1"), "User@example.com"))
+		}
+		require.NoError(t, s.RequestRecoveryPasswordReset(clientContext("203.0.113.2"), "user@example.com"))
+		require.NoError(t, s.RequestPasswordReset(clientContext("203.0.113.1"), "other@example.com"))
+		assert.Len(t, s.passwordResets.requests, 3)
+	})
+
+	t.Run("limits requests per client IP", func(t *testing.T) {
+		s := newPasswordResetTestService(WithPasswordResetLimits(2, 100))
+		for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			require.NoError(t, s.RequestPasswordReset(clientContext("203.0.113.1"), email))
+		}
+		require.NoError(t, s.RequestPasswordReset(clientContext("203.0.113.2"), "d@example.com"))
+		assert.Len(t, s.passwordResets.requests, 3)
+	})
+
+	t.Run("drops requests when the queue is full", func(t *testing.T) {
+		s := newPasswordResetTestService(WithPasswordResetWorkers(1, 1))
+		assert.NoError(t, s.RequestPasswordReset(clientContext("203.0.113.1"), "a@example.com"))
+		assert.NoError(t, s.RequestPasswordReset(clientContext("203.0.113.1"), "b@example.com"))
+		assert.Len(t, s.passwordResets.requests, 1)
+	})
+
+	t.Run("queues recovery address requests", func(t *testing.T) {
+		s := newPasswordResetTestService()
+		require.NoError(t, s.RequestRecoveryPasswordReset(clientContext("203.0.113.1"), " backup@example.com "))
+		require.Len(t, s.passwordResets.requests, 1)
+		request := <-s.passwordResets.requests
+		assert.True(t, request.recovery)
+		assert.Equal(t, "backup@example.com", request.email)
+	})
+}
+
+func TestProcessPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	s, userRepo := newTestService(publisher)
+	createTestUser(t, userRepo, "active@example.com", "active", models.UserStatusActive)
+	createTestUser(t, userRepo, "suspended@example.com", "suspended", models.UserStatusSuspended)
+	createTestUser(t, userRepo, "deactivated@example.com", "deactivated", models.UserStatusDeactivated)
+
+	for _, email := range []string{"active", "suspended@example.com", "deactivated@example.com", "unknown@example.com"} {
+		s.processPasswordReset(ctx, email)
+	}
+	assert.Empty(t, publisher.published())
+
+	s.processPasswordReset(ctx, "active@example.com")
+	assert.Equal(t, []string{string(events.UserPasswordReset)}, publisher.published())
+}
@@ -0,0 +1,34 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// GetEffectivePermissions resolves the permissions a user currently holds
+func (s *Service) GetEffectivePermissions(ctx context.Context, id uuid.UUID) (*models.EffectivePermissions, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	permissions, err := s.rolePermissions(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	return user.EffectivePermissions(map[models.Role][]models.Permission{user.Role: permissions}), nil
+}
+
+// rolePermissions returns the permissions a role grants
+func (s *Service) rolePermissions(ctx context.Context, role models.Role) ([]models.Permission, error) {
+	if s.roles == nil {
+		return role.DefaultPermissions(), nil
+	}
+	permissions, err := s.roles.Permissions(ctx, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve role permissions: %w", err)
+	}
+	return permissions, nil
+}
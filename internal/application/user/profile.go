@@ -0,0 +1,120 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// UpdateProfile fills in or changes a user's profile fields
+func (s *Service) UpdateProfile(ctx context.Context, id uuid.UUID, input services.UpdateProfileInput) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	previous := s.profileCompleteness(user).Percent
+
+	var changedFields []string
+	set := func(field string, target *string, value *string) {
+		if value == nil {
+			return
+		}
+		if v := strings.TrimSpace(*value); v != *target {
+			*target = v
+			changedFields = append(changedFields, field)
+		}
+	}
+	set(models.ProfileFieldFirstName, &user.FirstName, input.FirstName)
+	set(models.ProfileFieldLastName, &user.LastName, input.LastName)
+	set(models.ProfileFieldPhoneNumber, &user.PhoneNumber, input.PhoneNumber)
+	set(models.ProfileFieldLocale, &user.Locale, input.Locale)
+	set(models.ProfileFieldTimeZone, &user.TimeZone, input.TimeZone)
+	set(models.ProfileFieldAvatarURL, &user.AvatarURL, input.AvatarURL)
+
+	if user.PhoneNumber != "" && !models.IsValidPhoneNumber(user.PhoneNumber) {
+		return nil, errors.WrapError("UpdateProfile", errors.ErrInvalidInput)
+	}
+	if user.Locale != "" && !models.IsValidLocale(user.Locale) {
+		return nil, errors.WrapError("UpdateProfile", errors.ErrInvalidInput)
+	}
+	if user.TimeZone != "" && !models.IsValidTimeZone(user.TimeZone) {
+		return nil, errors.WrapError("UpdateProfile", errors.ErrInvalidInput)
+	}
+	if user.AvatarURL != "" && !models.IsValidAvatarURL(user.AvatarURL) {
+		return nil, errors.WrapError("UpdateProfile", errors.ErrInvalidInput)
+	}
+	if len(changedFields) == 0 {
+		return user, nil
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserUpdated), events.NewUserUpdatedEvent(
+		user.ID, user.Email, user.Username, changedFields))
+	s.publishProfileThresholds(ctx, user, previous)
+
+	return user, nil
+}
+
+// SetMarketingConsent records whether marketing-relevant events may be
+// published about a user. Withdrawing consent is itself published, so that
+// downstream systems forget the user's marketing preferences too.
+func (s *Service) SetMarketingConsent(ctx context.Context, id uuid.UUID, consent bool) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if user.MarketingOptOut == !consent {
+		return user, nil
+	}
+
+	user.MarketingOptOut = !consent
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserUpdated), events.NewUserUpdatedEvent(
+		user.ID, user.Email, user.Username, []string{models.ProfileFieldMarketingOptOut}))
+
+	return user, nil
+}
+
+// GetProfileCompleteness reports how much of a user's profile is filled in
+// and which fields the user must still provide
+func (s *Service) GetProfileCompleteness(ctx context.Context, id uuid.UUID) (*models.ProfileCompleteness, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return s.profileCompleteness(user), nil
+}
+
+// profileCompleteness measures a user's profile with the profile policy
+func (s *Service) profileCompleteness(user *models.User) *models.ProfileCompleteness {
+	fields := s.profilePolicy.Fields
+	if len(fields) == 0 {
+		fields = models.ProfileFields
+	}
+	return user.ProfileCompleteness(fields, s.profilePolicy.Required)
+}
+
+// publishProfileThresholds publishes an event for every threshold the
+// user's profile completeness crossed since it was previous percent
+func (s *Service) publishProfileThresholds(ctx context.Context, user *models.User, previous int) {
+	current := s.profileCompleteness(user).Percent
+	for _, threshold := range s.profilePolicy.Thresholds {
+		if (previous < threshold) == (current < threshold) {
+			continue
+		}
+		s.publishUserEvent(ctx, string(events.UserProfileThreshold),
+			events.NewUserProfileThresholdEvent(user.ID, threshold, previous, current))
+	}
+}
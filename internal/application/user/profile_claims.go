@@ -0,0 +1,31 @@
+package user
+
+import (
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// ProfileClaimsPolicy controls whether access tokens carry the user's name
+// and email verification status. Tokens can be read by whoever holds them,
+// so the claims are left out unless enabled.
+type ProfileClaimsPolicy struct {
+	Enabled bool
+	// MaxAge caps the lifetime of access tokens carrying the claims. The
+	// claims are read again on refresh, so profile changes reach
+	// downstream services within it. 0 keeps the configured lifetime.
+	MaxAge time.Duration
+}
+
+// addProfileClaims sets the profile claims of an access token for a user
+// when enabled
+func (p ProfileClaimsPolicy) addProfileClaims(claims *services.TokenClaims, user *models.User) {
+	if !p.Enabled {
+		return
+	}
+	emailVerified := user.EmailVerified
+	claims.GivenName = user.FirstName
+	claims.FamilyName = user.LastName
+	claims.EmailVerified = &emailVerified
+}
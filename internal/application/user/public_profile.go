@@ -0,0 +1,66 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+func publicProfileKey(userID uuid.UUID) string {
+	return fmt.Sprintf("public_profile:%s", userID)
+}
+
+// GetPublicProfile retrieves the public profile of a user. Only users that
+// can sign in have one; for any other user errors.ErrUserNotFound is
+// returned so that suspended and deleted accounts are not revealed.
+func (s *Service) GetPublicProfile(ctx context.Context, id uuid.UUID) (*models.PublicProfile, error) {
+	if s.publicProfileTTL > 0 {
+		var profile models.PublicProfile
+		err := s.cacheService.Get(ctx, publicProfileKey(id), &profile)
+		if err == nil {
+			return &profile, nil
+		}
+		if !stderrors.Is(err, services.ErrCacheKeyNotFound) {
+			s.logger.Warn("failed to read cached public profile",
+				zap.String("userID", id.String()),
+				zap.Error(err))
+		}
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if !user.Status.CanAuthenticate() {
+		return nil, errors.WrapError("GetPublicProfile", errors.ErrUserNotFound)
+	}
+
+	profile := user.PublicProfile()
+	if s.publicProfileTTL > 0 {
+		if err := s.cacheService.Set(ctx, publicProfileKey(id), profile, s.publicProfileTTL); err != nil {
+			s.logger.Warn("failed to cache public profile",
+				zap.String("userID", id.String()),
+				zap.Error(err))
+		}
+	}
+	return profile, nil
+}
+
+// invalidatePublicProfile drops the cached public profile of a user after a
+// change to their username, email or status
+func (s *Service) invalidatePublicProfile(ctx context.Context, userID uuid.UUID) {
+	if s.publicProfileTTL <= 0 {
+		return
+	}
+	if err := s.cacheService.Delete(ctx, publicProfileKey(userID)); err != nil {
+		s.logger.Error("failed to invalidate cached public profile",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+	}
+}
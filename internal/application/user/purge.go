@@ -0,0 +1,165 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// defaultPurgeApprovalWindow is how long a purge approval can be redeemed
+// when no window is configured
+const defaultPurgeApprovalWindow = 15 * time.Minute
+
+// Reasons recorded when a purge is refused
+const (
+	purgeRejectedInvalidApproval = "invalid_approval"
+	purgeRejectedSelfApproval    = "self_approval"
+)
+
+// purgeApprovalEntry is an approval to purge a user, cached by the hash of
+// its token until it expires
+type purgeApprovalEntry struct {
+	UserID     uuid.UUID `json:"userId"`
+	ApproverID uuid.UUID `json:"approverId"`
+	ApprovedAt time.Time `json:"approvedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+func purgeApprovalKey(tokenHash string) string {
+	return fmt.Sprintf("user_purge_approval:%s", tokenHash)
+}
+
+func purgeRedemptionKey(tokenHash string) string {
+	return fmt.Sprintf("user_purge_redeemed:%s", tokenHash)
+}
+
+// purgeApprovalWindow returns how long a purge approval can be redeemed
+func (s *Service) purgeApprovalWindow() time.Duration {
+	if s.purgeWindow <= 0 {
+		return defaultPurgeApprovalWindow
+	}
+	return s.purgeWindow
+}
+
+// ApproveUserPurge records an admin's approval to permanently delete a user,
+// including a soft-deleted one
+func (s *Service) ApproveUserPurge(ctx context.Context, userID, approverID uuid.UUID) (*services.PurgeApproval, error) {
+	if _, err := s.userRepo.GetByIDIncludingDeleted(ctx, userID); err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate purge approval token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	window := s.purgeApprovalWindow()
+	now := s.clock.Now()
+	entry := purgeApprovalEntry{
+		UserID:     userID,
+		ApproverID: approverID,
+		ApprovedAt: now,
+		ExpiresAt:  now.Add(window),
+	}
+	if err := s.cacheService.Set(ctx, purgeApprovalKey(hashToken(token)), entry, window); err != nil {
+		return nil, fmt.Errorf("failed to store purge approval: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserPurgeApproved),
+		events.NewUserPurgeApprovedEvent(userID, approverID, entry.ExpiresAt))
+
+	return &services.PurgeApproval{
+		Token:      token,
+		UserID:     userID,
+		ApproverID: approverID,
+		ExpiresAt:  entry.ExpiresAt,
+	}, nil
+}
+
+// PurgeUser permanently deletes a user and every record referring to them
+// with an approval a second admin issued for the user. Refused attempts are
+// published for the audit trail.
+func (s *Service) PurgeUser(ctx context.Context, userID, adminID uuid.UUID, approvalToken string) error {
+	entry, err := s.redeemPurgeApproval(ctx, userID, adminID, approvalToken)
+	if err != nil {
+		return err
+	}
+
+	tokenHash := hashToken(approvalToken)
+	user, err := s.userRepo.GetByIDIncludingDeleted(ctx, userID)
+	if err == nil {
+		err = s.userRepo.Purge(ctx, userID)
+	}
+	if err != nil {
+		// The approval stays valid for another attempt
+		if err := s.cacheService.Delete(ctx, purgeRedemptionKey(tokenHash)); err != nil {
+			s.logger.Warn("failed to release purge approval", zap.Error(err))
+		}
+		return fmt.Errorf("failed to purge user: %w", err)
+	}
+	if err := s.cacheService.Delete(ctx, purgeApprovalKey(tokenHash)); err != nil {
+		s.logger.Warn("failed to delete redeemed purge approval", zap.Error(err))
+	}
+
+	// Nothing of the user may outlive the record
+	s.endAllSessions(ctx, userID)
+	s.invalidatePublicProfile(ctx, userID)
+	if err := s.invalidateResetTokens(ctx, userID); err != nil {
+		s.logger.Error("failed to invalidate reset tokens of purged user",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+	}
+
+	s.publishUserEvent(ctx, string(events.UserPurged), events.NewUserPurgedEvent(
+		user.ID, user.Email, user.Username, adminID, entry.ApproverID, entry.ApprovedAt))
+
+	return nil
+}
+
+// redeemPurgeApproval checks an approval token and marks it used. Only
+// admins other than the approver can redeem it, once and for the user it
+// was issued for; a failed purge releases it again.
+func (s *Service) redeemPurgeApproval(ctx context.Context, userID, adminID uuid.UUID, token string) (*purgeApprovalEntry, error) {
+	reject := func(reason string, err error) (*purgeApprovalEntry, error) {
+		s.publishUserEvent(ctx, string(events.UserPurgeRejected),
+			events.NewUserPurgeRejectedEvent(userID, adminID, reason))
+		return nil, err
+	}
+	if token == "" {
+		return reject(purgeRejectedInvalidApproval, services.ErrPurgeApprovalInvalid)
+	}
+
+	tokenHash := hashToken(token)
+	var entry purgeApprovalEntry
+	if err := s.cacheService.Get(ctx, purgeApprovalKey(tokenHash), &entry); err != nil {
+		if stderrors.Is(err, services.ErrCacheKeyNotFound) {
+			return reject(purgeRejectedInvalidApproval, services.ErrPurgeApprovalInvalid)
+		}
+		return nil, fmt.Errorf("failed to get purge approval: %w", err)
+	}
+	if entry.UserID != userID || s.clock.Now().After(entry.ExpiresAt) {
+		return reject(purgeRejectedInvalidApproval, services.ErrPurgeApprovalInvalid)
+	}
+	if entry.ApproverID == adminID {
+		return reject(purgeRejectedSelfApproval, services.ErrPurgeApprovalSelf)
+	}
+
+	// Concurrent purges with the same approval must not both proceed
+	redeemed, err := s.cacheService.SetNX(ctx, purgeRedemptionKey(tokenHash), adminID.String(), entry.ExpiresAt.Sub(s.clock.Now())+time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem purge approval: %w", err)
+	}
+	if !redeemed {
+		return reject(purgeRejectedInvalidApproval, services.ErrPurgeApprovalInvalid)
+	}
+	return &entry, nil
+}
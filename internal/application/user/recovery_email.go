@@ -0,0 +1,225 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// recoveryEmailVerificationTTL is how long a recovery email verification
+	// link can be used
+	recoveryEmailVerificationTTL = 24 * time.Hour
+	// recoveryEmailCooldown is how long a user waits between verification
+	// emails, so that requests cannot flood another inbox
+	recoveryEmailCooldown = time.Minute
+	// maxRecoveryResetsPerRequest bounds the reset emails one request sends
+	// when several accounts share a recovery address
+	maxRecoveryResetsPerRequest = 5
+)
+
+// recoveryEmailVerificationEntry is a pending recovery email, cached by the
+// hash of its verification token
+type recoveryEmailVerificationEntry struct {
+	UserID    uuid.UUID `json:"userId"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func recoveryEmailVerificationKey(tokenHash string) string {
+	return fmt.Sprintf("recovery_email_verification:%s", tokenHash)
+}
+
+func recoveryEmailVerificationUsedKey(tokenHash string) string {
+	return fmt.Sprintf("recovery_email_verification_used:%s", tokenHash)
+}
+
+func recoveryEmailCooldownKey(userID uuid.UUID) string {
+	return fmt.Sprintf("recovery_email_cooldown:%s", userID)
+}
+
+// RequestRecoveryEmail sends a verification link to the address a user wants
+// as their recovery email. The address only replaces the current one once
+// the link is opened.
+func (s *Service) RequestRecoveryEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || address.Address != strings.TrimSpace(email) {
+		return fmt.Errorf("%w: invalid email address", errors.ErrInvalidInput)
+	}
+	email = address.Address
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if user.ServiceAccount {
+		return fmt.Errorf("%w: service accounts have no recovery email", errors.ErrInvalidInput)
+	}
+	if strings.EqualFold(email, user.Email) {
+		return fmt.Errorf("%w: recovery email must differ from the primary email", errors.ErrInvalidInput)
+	}
+
+	allowed, err := s.cacheService.SetNX(ctx, recoveryEmailCooldownKey(user.ID), true, recoveryEmailCooldown)
+	if err != nil {
+		return fmt.Errorf("failed to check recovery email cooldown: %w", err)
+	}
+	if !allowed {
+		return services.ErrVerificationLimitReached
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Errorf("failed to generate recovery email token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	entry := &recoveryEmailVerificationEntry{
+		UserID:    user.ID,
+		Email:     email,
+		ExpiresAt: s.clock.Now().Add(recoveryEmailVerificationTTL),
+	}
+	if err := s.cacheService.Set(ctx, recoveryEmailVerificationKey(hashToken(token)), entry, recoveryEmailVerificationTTL); err != nil {
+		return fmt.Errorf("failed to store recovery email token: %w", err)
+	}
+
+	verificationLink := fmt.Sprintf("%s/verify-recovery-email?token=%s", s.webAppURL, token)
+	s.publishUserEvent(ctx, string(events.UserRecoveryEmailVerificationRequested),
+		events.NewUserRecoveryEmailVerificationRequestedEvent(user.ID, email, verificationLink, entry.ExpiresAt))
+
+	return nil
+}
+
+// VerifyRecoveryEmail redeems a recovery email verification link and makes
+// its address the user's recovery email
+func (s *Service) VerifyRecoveryEmail(ctx context.Context, token string) error {
+	if token == "" {
+		return services.ErrInvalidToken
+	}
+	tokenHash := hashToken(token)
+
+	var entry recoveryEmailVerificationEntry
+	if err := s.cacheService.Get(ctx, recoveryEmailVerificationKey(tokenHash), &entry); err != nil {
+		if stderrors.Is(err, services.ErrCacheKeyNotFound) {
+			return services.ErrInvalidToken
+		}
+		return fmt.Errorf("failed to get recovery email token: %w", err)
+	}
+	if s.clock.Now().After(entry.ExpiresAt) {
+		return services.ErrInvalidToken
+	}
+
+	// Claim the token atomically so concurrent redemptions cannot both succeed
+	claimed, err := s.cacheService.SetNX(ctx, recoveryEmailVerificationUsedKey(tokenHash), true, recoveryEmailVerificationTTL)
+	if err != nil {
+		return fmt.Errorf("failed to redeem recovery email token: %w", err)
+	}
+	if !claimed {
+		return services.ErrInvalidToken
+	}
+	if err := s.cacheService.Delete(ctx, recoveryEmailVerificationKey(tokenHash)); err != nil {
+		s.logger.Warn("failed to delete redeemed recovery email token", zap.Error(err))
+	}
+
+	user, err := s.userRepo.GetByID(ctx, entry.UserID)
+	if err != nil {
+		return services.ErrInvalidToken
+	}
+	// The primary address may have changed to this one since the link was sent
+	if strings.EqualFold(entry.Email, user.Email) {
+		return services.ErrInvalidToken
+	}
+
+	user.SetRecoveryEmail(entry.Email)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserRecoveryEmailVerified), events.NewUserRecoveryEmailEvent(
+		events.UserRecoveryEmailVerified, user.ID, user.Email, entry.Email))
+
+	return nil
+}
+
+// RemoveRecoveryEmail removes the recovery email of a user
+func (s *Service) RemoveRecoveryEmail(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if user.RecoveryEmail == "" {
+		return services.ErrNotFound
+	}
+
+	recoveryEmail := user.RecoveryEmail
+	user.ClearRecoveryEmail()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserRecoveryEmailRemoved), events.NewUserRecoveryEmailEvent(
+		events.UserRecoveryEmailRemoved, user.ID, user.Email, recoveryEmail))
+
+	return nil
+}
+
+// RequestRecoveryPasswordReset queues password reset links to the verified
+// recovery address of the accounts using it, for users who cannot reach
+// their primary address. Like RequestPasswordReset it succeeds whether or
+// not the address is registered, and the workers do the lookup, so that
+// callers cannot probe which addresses are registered.
+func (s *Service) RequestRecoveryPasswordReset(ctx context.Context, recoveryEmail string) error {
+	s.queuePasswordReset(ctx, recoveryEmail, true)
+	return nil
+}
+
+// processRecoveryPasswordReset issues reset links to the accounts using a
+// verified recovery address and publishes them for delivery there. Unknown
+// addresses and accounts that cannot sign in are ignored.
+func (s *Service) processRecoveryPasswordReset(ctx context.Context, recoveryEmail string) {
+	ctx, cancel := context.WithTimeout(ctx, passwordResetTimeout)
+	defer cancel()
+
+	users, err := s.userRepo.ListByRecoveryEmail(ctx, recoveryEmail)
+	if err != nil {
+		s.logger.Error("failed to find users by recovery email", zap.Error(err))
+		return
+	}
+
+	sent := 0
+	for _, user := range users {
+		if sent == maxRecoveryResetsPerRequest {
+			s.logger.Warn("recovery email shared by too many accounts",
+				zap.Int("accounts", len(users)))
+			break
+		}
+		if user.ServiceAccount || user.RecoveryEmailVerifiedAt == nil || !user.Status.CanAuthenticate() {
+			continue
+		}
+
+		resetLink, err := s.issueResetLink(ctx, user)
+		if err != nil {
+			s.logger.Error("failed to issue password reset link",
+				zap.String("userID", user.ID.String()),
+				zap.Error(err))
+			continue
+		}
+		// The email goes to the recovery address rather than the primary one
+		s.publishUserEvent(ctx, string(events.UserPasswordReset), events.NewUserPasswordResetEvent(
+			user.ID,
+			user.RecoveryEmail,
+			resetLink,
+		))
+		sent++
+	}
+}
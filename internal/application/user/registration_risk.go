@@ -0,0 +1,140 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// Score thresholds used when none are configured
+const (
+	defaultChallengeScore = 50
+	defaultReviewScore    = 80
+)
+
+// registrationVelocityWindow is the period over which registrations from
+// the same IP address or device are counted
+const registrationVelocityWindow = time.Hour
+
+// RegistrationRiskPolicy maps registration risk scores to decisions.
+// Registrations scoring at least ChallengeScore must verify their email
+// before signing in; those scoring at least ReviewScore are also flagged
+// for manual review, which restricts the account until a moderator
+// reviews the flag.
+type RegistrationRiskPolicy struct {
+	ChallengeScore int // 0 uses 50
+	ReviewScore    int // 0 uses 80
+}
+
+// registrationDecision is what happens to a scored registration
+type registrationDecision string
+
+const (
+	registrationApproved   registrationDecision = "approve"
+	registrationChallenged registrationDecision = "challenge"
+	registrationReview     registrationDecision = "review"
+)
+
+func (p RegistrationRiskPolicy) decide(score int) registrationDecision {
+	challengeScore, reviewScore := p.ChallengeScore, p.ReviewScore
+	if challengeScore <= 0 {
+		challengeScore = defaultChallengeScore
+	}
+	if reviewScore <= 0 {
+		reviewScore = defaultReviewScore
+	}
+	switch {
+	case score >= reviewScore:
+		return registrationReview
+	case score >= challengeScore:
+		return registrationChallenged
+	default:
+		return registrationApproved
+	}
+}
+
+// assessRegistration scores a registration from the request metadata.
+// Registrations are approved when no scorer is configured or scoring
+// fails, so that an unavailable scorer does not stop sign-ups.
+func (s *Service) assessRegistration(ctx context.Context, email string) (*services.RegistrationRisk, registrationDecision) {
+	if s.registrationRisk == nil {
+		return nil, registrationApproved
+	}
+
+	metadata := events.MetadataFromContext(ctx)
+	input := services.RegistrationRiskInput{
+		Email:     email,
+		IPAddress: metadata.ClientIP,
+		DeviceID:  metadata.DeviceID,
+		UserAgent: metadata.UserAgent,
+	}
+	if s.securityActivity != nil {
+		since := s.clock.Now().Add(-registrationVelocityWindow)
+		fromIP, fromDevice, err := s.securityActivity.RecentRegistrations(ctx, metadata.ClientIP, metadata.DeviceID, since)
+		if err != nil {
+			s.logger.Error("failed to count recent registrations", zap.Error(err))
+		}
+		input.RecentFromIP, input.RecentFromDevice = fromIP, fromDevice
+	}
+
+	risk, err := s.registrationRisk.Score(ctx, input)
+	if err != nil {
+		s.logger.Error("failed to score registration", zap.Error(err))
+		return nil, registrationApproved
+	}
+	return risk, s.registrationRiskPolicy.decide(risk.Score)
+}
+
+// applyRegistrationRisk records where a new user registered from and acts
+// on the decision about their registration
+func (s *Service) applyRegistrationRisk(ctx context.Context, user *models.User, risk *services.RegistrationRisk, decision registrationDecision) {
+	if s.registrationRisk == nil {
+		return
+	}
+	s.recordSecurityActivity(ctx, user.ID, models.SecurityActivityRegistration, "")
+	if decision == registrationApproved {
+		return
+	}
+
+	s.logger.Info("risky registration",
+		zap.String("userID", user.ID.String()),
+		zap.Int("score", risk.Score),
+		zap.String("decision", string(decision)),
+		zap.Strings("reasons", risk.Reasons))
+	s.publishUserEvent(ctx, string(events.RegistrationRiskAssessed), events.NewRegistrationRiskAssessedEvent(
+		user.ID, user.Email, risk.Score, string(decision), risk.Reasons))
+
+	if decision != registrationReview {
+		return
+	}
+	if s.moderation == nil {
+		s.logger.Warn("cannot queue risky registration for review without moderation",
+			zap.String("userID", user.ID.String()))
+		return
+	}
+	// The flag is raised by the service rather than a user, so it counts
+	// as an admin's and restricts the account until it is reviewed
+	reason := fmt.Sprintf("registration risk score %d", risk.Score)
+	if len(risk.Reasons) > 0 {
+		reason += ": " + strings.Join(risk.Reasons, ", ")
+	}
+	_, err := s.moderation.FlagAccount(ctx, services.FlagAccountInput{
+		UserID:     user.ID,
+		Category:   models.FlagCategoryFraud,
+		Reason:     reason,
+		ReportedBy: uuid.Nil,
+		ByAdmin:    true,
+	})
+	if err != nil {
+		s.logger.Error("failed to queue risky registration for review",
+			zap.String("userID", user.ID.String()),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,116 @@
+package user
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// defaultMaxActiveResetTokens is used when no limit has been configured
+const defaultMaxActiveResetTokens = 3
+
+// resetTokenEntry records an issued password reset token by its hash only
+type resetTokenEntry struct {
+	Hash      string    `json:"hash"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func resetTokensKey(userID uuid.UUID) string {
+	return fmt.Sprintf("password_reset:%s", userID)
+}
+
+// hashToken hashes a token for storage so that the token itself is never kept
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// activeResetTokens returns the unexpired reset tokens issued to a user
+func (s *Service) activeResetTokens(ctx context.Context, userID uuid.UUID) ([]resetTokenEntry, error) {
+	var entries []resetTokenEntry
+	if err := s.cacheService.Get(ctx, resetTokensKey(userID), &entries); err != nil {
+		if stderrors.Is(err, services.ErrCacheKeyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	active := entries[:0]
+	for _, entry := range entries {
+		if entry.ExpiresAt.After(now) {
+			active = append(active, entry)
+		}
+	}
+	return active, nil
+}
+
+// storeResetToken records the hash of a newly issued reset token, dropping
+// the oldest ones once the per-user limit is reached
+func (s *Service) storeResetToken(ctx context.Context, userID uuid.UUID, token string) error {
+	entries, err := s.activeResetTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	limit := s.maxActiveResetTokens
+	if limit <= 0 {
+		limit = defaultMaxActiveResetTokens
+	}
+	if len(entries) >= limit {
+		entries = entries[len(entries)-limit+1:]
+	}
+
+	ttl := s.tokenService.TokenDuration(services.TokenTypeReset)
+	entries = append(entries, resetTokenEntry{
+		Hash:      hashToken(token),
+		ExpiresAt: s.clock.Now().Add(ttl),
+	})
+
+	return s.cacheService.Set(ctx, resetTokensKey(userID), entries, ttl)
+}
+
+// redeemResetToken consumes a reset token. It fails if the token was never
+// issued, has been superseded, or was already redeemed.
+func (s *Service) redeemResetToken(ctx context.Context, userID uuid.UUID, token string) error {
+	hash := hashToken(token)
+
+	entries, err := s.activeResetTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, entry := range entries {
+		if entry.Hash == hash {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return services.ErrInvalidToken
+	}
+
+	// Claim the token atomically so concurrent redemptions cannot both succeed
+	claimed, err := s.cacheService.SetNX(ctx, fmt.Sprintf("password_reset_used:%s", hash), true,
+		s.tokenService.TokenDuration(services.TokenTypeReset))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return services.ErrInvalidToken
+	}
+
+	return nil
+}
+
+// invalidateResetTokens discards every outstanding reset token of a user
+func (s *Service) invalidateResetTokens(ctx context.Context, userID uuid.UUID) error {
+	return s.cacheService.Delete(ctx, resetTokensKey(userID))
+}
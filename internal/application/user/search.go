@@ -0,0 +1,36 @@
+package user
+
+import (
+	"context"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchUsers searches users in the search index. It fails with
+// services.ErrSearchUnavailable when no search index is configured.
+func (s *Service) SearchUsers(ctx context.Context, query services.UserSearchQuery) (*services.UserSearchResult, error) {
+	if s.searchIndex == nil {
+		return nil, services.ErrSearchUnavailable
+	}
+	if query.Offset < 0 || query.Limit < 0 || (query.Status != "" && !query.Status.IsValid()) {
+		return nil, errors.WrapError("SearchUsers", errors.ErrInvalidInput)
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+
+	result, err := s.searchIndex.SearchUsers(ctx, query)
+	if err != nil {
+		return nil, errors.WrapError("SearchUsers", err)
+	}
+	return result, nil
+}
@@ -2,7 +2,10 @@ package user
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mibrahim2344/identity-service/internal/domain/errors"
@@ -23,6 +26,72 @@ type Service struct {
 	logger          *zap.Logger
 	config          services.CacheConfig
 	webAppURL       string
+	usernameHistory repositories.UsernameHistoryRepository
+	usernamePolicy  UsernamePolicy
+
+	maxActiveResetTokens    int
+	adminPasswordResetLimit int
+
+	securityActivity repositories.SecurityActivityRepository
+	deviceBinding    DeviceBindingPolicy
+	loginAnomalies   LoginAnomalyPolicy
+
+	accountRiskPolicy AccountRiskPolicy
+	captcha           services.CaptchaVerifier
+
+	registrationRisk       services.RegistrationRiskScorer
+	registrationRiskPolicy RegistrationRiskPolicy
+
+	emailVerifications     repositories.EmailVerificationRepository
+	requireVerifiedEmail   bool
+	verificationDailyLimit int
+
+	publicProfileTTL time.Duration
+
+	searchIndex services.UserSearchIndex
+
+	tenantSettings services.TenantSettingsService
+
+	identities repositories.UserIdentityRepository
+	// organizations adds users signed in by an organization's SSO
+	// connection, or with verified emails in its domains, to the
+	// organization
+	organizations repositories.OrganizationRepository
+
+	totpCredentials repositories.TOTPCredentialRepository
+	totp            services.TOTPService
+	mfaPolicies     services.MFAPolicyService
+
+	passkeys           services.WebAuthnVerifier
+	passkeyCredentials repositories.WebAuthnCredentialRepository
+
+	magicLinks bool
+
+	knowledgeFactors      repositories.KnowledgeFactorRepository
+	knowledgeFactorHasher services.SecretHasher
+	knowledgeFactorPolicy KnowledgeFactorPolicy
+
+	audiences map[string]AudiencePolicy
+
+	// roles resolves the permissions of roles; nil uses the built-in defaults
+	roles services.RoleService
+
+	sessions     repositories.SessionRepository
+	sessionLimit SessionLimitPolicy
+
+	profilePolicy ProfilePolicy
+	profileClaims ProfileClaimsPolicy
+
+	purgeWindow       time.Duration
+	deactivationGrace time.Duration
+
+	moderation services.ModerationService
+
+	passwordResets           *passwordResetQueue
+	passwordResetsPerIP      int
+	passwordResetsPerAddress int
+
+	clock services.Clock
 }
 
 // NewService creates a new user service
@@ -35,8 +104,9 @@ func NewService(
 	logger *zap.Logger,
 	config services.CacheConfig,
 	webAppURL string,
+	opts ...Option,
 ) *Service {
-	return &Service{
+	s := &Service{
 		userRepo:        userRepo,
 		passwordService: passwordService,
 		tokenService:    tokenService,
@@ -45,12 +115,23 @@ func NewService(
 		logger:          logger,
 		config:          config,
 		webAppURL:       webAppURL,
+		passwordResets:  newPasswordResetQueue(0, 0),
+		clock:           services.SystemClock,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Helper methods for common operations
 
 func (s *Service) publishUserEvent(ctx context.Context, eventType string, event interface{}) {
+	// Attribute the event to the actor and request that triggered it
+	if e, ok := event.(interface{ SetMetadata(events.Metadata) }); ok {
+		e.SetMetadata(events.MetadataFromContext(ctx))
+	}
+
 	if err := s.eventPublisher.PublishUserEvent(ctx, eventType, event); err != nil {
 		s.logger.Error("failed to publish event",
 			zap.String("eventType", eventType),
@@ -74,10 +155,26 @@ func (s *Service) validateTokenAndGetUser(ctx context.Context, token string, tok
 
 // RegisterUser registers a new user
 func (s *Service) RegisterUser(ctx context.Context, input services.RegisterUserInput) (*models.User, error) {
-	// Check if user exists
-	existingUser, err := s.userRepo.GetByIdentifier(ctx, input.Email)
-	if err == nil && existingUser != nil {
-		return nil, services.ErrUserAlreadyExists
+	// Check both identifiers at once. Identifiers of deleted users can be
+	// registered again.
+	emailTaken, usernameTaken, err := s.userRepo.IdentifiersTaken(ctx, input.Email, input.Username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for existing users: %w", err)
+	}
+	if emailTaken {
+		return nil, services.ErrEmailAlreadyExists
+	}
+	if usernameTaken {
+		return nil, services.ErrUsernameAlreadyExists
+	}
+
+	// Previous usernames stay reserved for their former owners
+	reserved, err := s.isUsernameReserved(ctx, input.Username, uuid.Nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check username reservation: %w", err)
+	}
+	if reserved {
+		return nil, services.ErrUsernameAlreadyExists
 	}
 
 	// Validate password
@@ -91,23 +188,40 @@ func (s *Service) RegisterUser(ctx context.Context, input services.RegisterUserI
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
+	risk, decision := s.assessRegistration(ctx, input.Email)
+
 	// Create user
 	user := models.NewUser(input.Email, input.Username, models.RoleUser)
 	user.PasswordHash = hashedPassword
+	// Only the credentials are required; the profile can be completed later
+	user.FirstName = strings.TrimSpace(input.FirstName)
+	user.LastName = strings.TrimSpace(input.LastName)
+	user.VerificationRequired = decision != registrationApproved
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
+		// Another registration may have claimed the email or username
+		// meanwhile, which the unique indexes report like the check above
+		if stderrors.Is(err, services.ErrEmailAlreadyExists) || stderrors.Is(err, services.ErrUsernameAlreadyExists) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	// Send verification email
-	if err := s.eventPublisher.PublishUserEvent(ctx, string(events.UserRegistered), events.NewUserRegisteredEvent(
+	s.publishUserEvent(ctx, string(events.UserRegistered), events.NewUserRegisteredEvent(
 		user.ID,
 		user.Email,
 		user.Username,
 		input.FirstName,
 		input.LastName,
-	)); err != nil {
-		s.logger.Error("failed to publish user registered event", zap.Error(err))
+	))
+	s.publishProfileThresholds(ctx, user, 0)
+	s.applyRegistrationRisk(ctx, user, risk, decision)
+
+	// Send verification email
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		s.logger.Error("failed to send verification email",
+			zap.String("userID", user.ID.String()),
+			zap.Error(err))
 	}
 
 	return user, nil
@@ -115,44 +229,98 @@ func (s *Service) RegisterUser(ctx context.Context, input services.RegisterUserI
 
 // Login authenticates a user and returns access and refresh tokens
 func (s *Service) Login(ctx context.Context, input services.LoginUserInput) (*services.LoginResponse, error) {
-	// Find user
-	var user *models.User
-	var err error
-
-	if input.Email != "" {
-		user, err = s.userRepo.GetByIdentifier(ctx, input.Email)
-	} else if input.Username != "" {
-		user, err = s.userRepo.GetByIdentifier(ctx, input.Username)
-	} else {
+	if input.Identifier == "" || input.Password == "" {
 		return nil, services.ErrInvalidCredentials
 	}
 
-	if err != nil || user == nil {
-		return nil, services.ErrInvalidCredentials
+	accountID, risk, err := s.loginRisk(ctx, input.Identifier)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLoginCaptcha(ctx, risk, input.CaptchaToken); err != nil {
+		return nil, err
 	}
 
-	// Verify password
-	if err := s.passwordService.VerifyPassword(ctx, input.Password, user.PasswordHash); err != nil {
-		return nil, services.ErrInvalidCredentials
+	user, err := s.AuthenticateUser(ctx, input.Identifier, input.Password)
+	if err != nil {
+		if stderrors.Is(err, services.ErrInvalidCredentials) && accountID != uuid.Nil {
+			s.recordLoginFailure(ctx, accountID)
+		}
+		return nil, err
+	}
+
+	if user.Status == models.UserStatusDeactivated {
+		return nil, services.ErrAccountDeactivated
+	}
+	if !user.Status.CanAuthenticate() {
+		return nil, services.ErrAccountDisabled
+	}
+	if err := s.checkEmailVerified(user); err != nil {
+		return nil, err
+	}
+	s.clearLoginFailures(ctx, user.ID)
+
+	return s.completeLogin(ctx, user)
+}
+
+// checkEmailVerified refuses sign-ins of users who must verify their email
+// first. Service accounts have no mailbox to verify.
+func (s *Service) checkEmailVerified(user *models.User) error {
+	if user.EmailVerified || user.ServiceAccount {
+		return nil
+	}
+	if user.VerificationRequired {
+		return services.ErrEmailVerificationRequired
+	}
+	if s.requireVerifiedEmail {
+		return services.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// startSession issues a token pair for a new session of an authenticated
+// user. Every way of signing in ends here, so users who must verify their
+// email first and restricted accounts without a second factor are refused,
+// and logins from a new device or country are reported, here too.
+func (s *Service) startSession(ctx context.Context, user *models.User) (*services.LoginResponse, error) {
+	if err := s.checkEmailVerified(user); err != nil {
+		return nil, err
+	}
+	// Users of bound roles cannot opt out of binding by omitting the device
+	if s.deviceBinding.appliesTo(user.Role) && deviceFingerprint(ctx) == "" {
+		return nil, services.ErrDeviceIDRequired
+	}
+	if err := s.checkLoginSecondFactor(ctx, user, s.accountRisk(ctx, user.ID)); err != nil {
+		return nil, err
+	}
+	if err := s.checkLoginAnomaly(ctx, user); err != nil {
+		return nil, err
 	}
 
 	// Generate tokens
 	claims := services.TokenClaims{
 		UserID:    user.ID,
 		Email:     user.Email,
+		Username:  user.Username,
 		Role:      string(user.Role),
 		TokenType: services.TokenTypeAccess,
+		SessionID: uuid.New().String(),
 	}
-
-	accessToken, err := s.tokenService.GenerateAccessToken(ctx, claims)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	s.profileClaims.addProfileClaims(&claims, user)
+	if s.deviceBinding.appliesTo(user.Role) {
+		claims.DeviceFingerprint = deviceFingerprint(ctx)
 	}
 
-	refreshToken, err := s.tokenService.GenerateRefreshToken(ctx, claims)
+	tokens, err := s.issueTokenPair(ctx, user.OrganizationID, claims)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, err
 	}
+	if err := s.registerSession(ctx, user, claims.SessionID, tokens.RefreshTokenExpiresAt); err != nil {
+		return nil, err
+	}
+
+	s.recordSecurityActivity(ctx, user.ID, models.SecurityActivityLogin, claims.SessionID)
+	s.publishUserEvent(ctx, string(events.UserLoggedIn), events.NewUserLoggedInEvent(user.ID, user.Email, claims.SessionID))
 
 	// Update last login
 	user.UpdateLastLogin()
@@ -161,35 +329,159 @@ func (s *Service) Login(ctx context.Context, input services.LoginUserInput) (*se
 	}
 
 	return &services.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         user,
+		AccessToken:           tokens.AccessToken,
+		RefreshToken:          tokens.RefreshToken,
+		TokenType:             tokens.TokenType,
+		AccessTokenExpiresAt:  tokens.AccessTokenExpiresAt,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+		User:                  user,
 	}, nil
 }
 
-// AuthenticateUser authenticates a user with email/username and password
-func (s *Service) AuthenticateUser(ctx context.Context, emailOrUsername, password string) (*models.User, error) {
-	var user *models.User
-	var err error
+// issueTokenPair generates an access and refresh token pair for the given
+// claims, with the token lifetimes of the user's organization
+func (s *Service) issueTokenPair(ctx context.Context, organizationID *uuid.UUID, claims services.TokenClaims) (*services.TokenResponse, error) {
+	issuedAt := s.clock.Now()
+
+	// Both tokens share a session ID so they can be revoked together
+	if claims.SessionID == "" {
+		claims.SessionID = uuid.New().String()
+	}
 
-	// Try to find user by email first
-	user, err = s.userRepo.GetByIdentifier(ctx, emailOrUsername)
+	settings, err := s.resolveTenantSettings(ctx, organizationID)
 	if err != nil {
-		// If not found by email, try username
-		user, err = s.userRepo.GetByIdentifier(ctx, emailOrUsername)
+		return nil, err
+	}
+	if organizationID != nil {
+		claims.TenantID = organizationID.String()
+	}
+	if s.moderation != nil {
+		restricted, err := s.moderation.IsRestricted(ctx, claims.UserID)
 		if err != nil {
+			return nil, fmt.Errorf("failed to check account restrictions: %w", err)
+		}
+		// Flagged accounts get read-only tokens until the flags are reviewed
+		if restricted {
+			claims.Scope = services.ScopeRestricted
+		}
+	}
+	accessLifetime := s.tokenService.TokenDuration(services.TokenTypeAccess)
+	refreshLifetime := s.tokenService.TokenDuration(services.TokenTypeRefresh)
+	if settings != nil {
+		claims.Lifetime = settings.AccessTokenLifetime
+		accessLifetime = effectiveLifetime(accessLifetime, settings.AccessTokenLifetime)
+		refreshLifetime = effectiveLifetime(refreshLifetime, settings.RefreshTokenLifetime)
+	}
+	// Profile claims go stale when the profile changes, so tokens carrying
+	// them may be kept short-lived
+	if claims.EmailVerified != nil && s.profileClaims.MaxAge > 0 {
+		accessLifetime = effectiveLifetime(accessLifetime, s.profileClaims.MaxAge)
+		claims.Lifetime = accessLifetime
+	}
+
+	// Access tokens carry the permissions of the user's role, which refresh
+	// tokens leave to be resolved again on refresh
+	permissions, err := s.rolePermissions(ctx, models.Role(claims.Role))
+	if err != nil {
+		return nil, err
+	}
+	claims.Permissions = make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		claims.Permissions = append(claims.Permissions, string(permission))
+	}
+
+	accessToken, err := s.tokenService.GenerateAccessToken(ctx, claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	refreshClaims := claims
+	refreshClaims.TokenType = services.TokenTypeRefresh
+	refreshClaims.Permissions = nil
+	refreshClaims.GivenName = ""
+	refreshClaims.FamilyName = ""
+	refreshClaims.EmailVerified = nil
+	refreshClaims.Lifetime = 0
+	if settings != nil {
+		refreshClaims.Lifetime = settings.RefreshTokenLifetime
+	}
+	refreshToken, err := s.tokenService.GenerateRefreshToken(ctx, refreshClaims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return &services.TokenResponse{
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		TokenType:             services.BearerTokenType,
+		AccessTokenExpiresAt:  issuedAt.Add(accessLifetime),
+		RefreshTokenExpiresAt: issuedAt.Add(refreshLifetime),
+	}, nil
+}
+
+// effectiveLifetime mirrors the token service: an override only shortens
+// the configured lifetime
+func effectiveLifetime(configured, override time.Duration) time.Duration {
+	if override > 0 && override < configured {
+		return override
+	}
+	return configured
+}
+
+// AuthenticateUser authenticates a user with email/username and password
+func (s *Service) AuthenticateUser(ctx context.Context, emailOrUsername, password string) (*models.User, error) {
+	user, err := s.userRepo.GetByIdentifier(ctx, emailOrUsername)
+	if err != nil {
+		if stderrors.Is(err, errors.ErrUserNotFound) {
 			return nil, services.ErrInvalidCredentials
 		}
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	// Service accounts only authenticate with API keys
+	if user.ServiceAccount {
+		return nil, services.ErrInvalidCredentials
 	}
 
 	// Verify password
 	if err := s.passwordService.VerifyPassword(ctx, password, user.PasswordHash); err != nil {
 		return nil, services.ErrInvalidCredentials
 	}
+	s.upgradePasswordHash(ctx, user, password)
+
+	// A breached password only unlocks the reset link sent to the user
+	if user.PasswordResetRequired {
+		return nil, services.ErrPasswordResetRequired
+	}
 
 	return user, nil
 }
 
+// upgradePasswordHash replaces a hash made with another algorithm or other
+// parameters than the configured ones while the password is known. Failures
+// are logged and leave the old hash, which still verifies.
+func (s *Service) upgradePasswordHash(ctx context.Context, user *models.User, password string) {
+	if !s.passwordService.NeedsRehash(user.PasswordHash) {
+		return
+	}
+	hash, err := s.passwordService.HashPassword(ctx, password)
+	if err != nil {
+		s.logger.Warn("failed to rehash password",
+			zap.String("userID", user.ID.String()),
+			zap.Error(err))
+		return
+	}
+	previous := user.PasswordHash
+	user.PasswordHash = hash
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		user.PasswordHash = previous
+		s.logger.Warn("failed to store rehashed password",
+			zap.String("userID", user.ID.String()),
+			zap.Error(err))
+		return
+	}
+	s.logger.Info("upgraded password hash", zap.String("userID", user.ID.String()))
+}
+
 // VerifyEmail verifies a user's email address
 func (s *Service) VerifyEmail(ctx context.Context, token string) error {
 	claims, err := s.tokenService.ValidateToken(ctx, token, services.TokenTypeVerification)
@@ -197,32 +489,48 @@ func (s *Service) VerifyEmail(ctx context.Context, token string) error {
 		return fmt.Errorf("invalid verification token: %w", err)
 	}
 
+	if err := s.redeemVerificationToken(ctx, token); err != nil {
+		return fmt.Errorf("invalid verification token: %w", err)
+	}
+
 	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
 		return fmt.Errorf("user not found: %w", err)
 	}
 
-	user.VerifyEmail()
+	previous := user.Status
+	previousCompleteness := s.profileCompleteness(user).Percent
+	if err := user.VerifyEmail(); err != nil {
+		return errors.WrapError("VerifyEmail", err)
+	}
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
+	s.publishStatusChange(ctx, user, previous, "email verified")
 
 	// Publish email verified event
 	s.publishUserEvent(ctx, string(events.UserVerified), events.NewUserVerifiedEvent(
 		user.ID,
 		user.Email,
 	))
+	s.publishProfileThresholds(ctx, user, previousCompleteness)
+	s.applyDomainJoinPolicy(ctx, user)
 
 	return nil
 }
 
-// RequestPasswordReset initiates the password reset process
-func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
-	user, err := s.userRepo.GetByIdentifier(ctx, email)
+// issueResetLink generates a reset token for a user and returns the link
+// redeeming it
+func (s *Service) issueResetLink(ctx context.Context, user *models.User) (string, error) {
+	token, err := s.issueResetToken(ctx, user)
 	if err != nil {
-		return services.ErrNotFound
+		return "", err
 	}
+	return fmt.Sprintf("%s/reset-password?token=%s", s.webAppURL, token), nil
+}
 
+// issueResetToken generates a reset token for a user
+func (s *Service) issueResetToken(ctx context.Context, user *models.User) (string, error) {
 	claims := services.TokenClaims{
 		UserID:    user.ID,
 		Email:     user.Email,
@@ -231,36 +539,37 @@ func (s *Service) RequestPasswordReset(ctx context.Context, email string) error
 
 	token, err := s.tokenService.GenerateResetToken(ctx, claims)
 	if err != nil {
-		return fmt.Errorf("failed to generate reset token: %w", err)
+		return "", fmt.Errorf("failed to generate reset token: %w", err)
 	}
 
-	// Publish password reset requested event
-	resetLink := fmt.Sprintf("%s/reset-password?token=%s", s.webAppURL, token)
-	s.publishUserEvent(ctx, string(events.UserPasswordReset), events.NewUserPasswordResetEvent(
-		user.ID,
-		user.Email,
-		resetLink,
-	))
-
-	return nil
+	// Only a hash of the token is kept, for single-use enforcement
+	if err := s.storeResetToken(ctx, user.ID, token); err != nil {
+		return "", fmt.Errorf("failed to store reset token: %w", err)
+	}
+	return token, nil
 }
 
-// ResetPassword resets a user's password using a reset token
+// ResetPassword resets a user's password using a reset token and revokes
+// every session of the user
 func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
 	claims, err := s.tokenService.ValidateToken(ctx, token, services.TokenTypeReset)
 	if err != nil {
 		return fmt.Errorf("invalid reset token: %w", err)
 	}
 
-	if err := s.passwordService.ValidatePassword(ctx, newPassword); err != nil {
-		return fmt.Errorf("invalid password: %w", err)
-	}
-
 	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
 		return fmt.Errorf("user not found: %w", err)
 	}
 
+	if err := s.validatePassword(ctx, user.OrganizationID, newPassword); err != nil {
+		return fmt.Errorf("invalid password: %w", err)
+	}
+
+	if err := s.redeemResetToken(ctx, claims.UserID, token); err != nil {
+		return fmt.Errorf("invalid reset token: %w", err)
+	}
+
 	hashedPassword, err := s.passwordService.HashPassword(ctx, newPassword)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
@@ -276,13 +585,54 @@ func (s *Service) ResetPassword(ctx context.Context, token, newPassword string)
 		user.ID,
 		user.Email,
 	))
+	s.recordSecurityActivity(ctx, user.ID, models.SecurityActivityPasswordChange, "")
 
-	// Revoke all existing tokens
+	// Revoke the reset token, other reset links and every session, so that
+	// whoever knew the old password is signed out
 	if err := s.tokenService.RevokeToken(ctx, token); err != nil {
 		s.logger.Error("failed to revoke reset token", zap.Error(err))
 	}
+	if err := s.invalidateResetTokens(ctx, user.ID); err != nil {
+		s.logger.Error("failed to invalidate reset tokens", zap.Error(err))
+	}
+	return s.RevokeSessions(ctx, user.ID)
+}
 
-	return nil
+// SetPassword sets a user's password without a reset token. Outstanding
+// reset links and every session of the user are revoked, as after a reset.
+func (s *Service) SetPassword(ctx context.Context, id uuid.UUID, newPassword string) error {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	// Service accounts have no password to set
+	if user.ServiceAccount {
+		return fmt.Errorf("%w: service accounts have no password", errors.ErrInvalidInput)
+	}
+
+	if err := s.validatePassword(ctx, user.OrganizationID, newPassword); err != nil {
+		return fmt.Errorf("invalid password: %w", err)
+	}
+	hashedPassword, err := s.passwordService.HashPassword(ctx, newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user.UpdatePassword(hashedPassword)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserPasswordChange), events.NewUserPasswordChangedEvent(
+		user.ID,
+		user.Email,
+	))
+	s.recordSecurityActivity(ctx, user.ID, models.SecurityActivityPasswordChange, "")
+
+	if err := s.invalidateResetTokens(ctx, user.ID); err != nil {
+		s.logger.Error("failed to invalidate reset tokens", zap.Error(err))
+	}
+	return s.RevokeSessions(ctx, user.ID)
 }
 
 // RefreshToken refreshes an access token using a refresh token
@@ -300,39 +650,108 @@ func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*servi
 		return nil, services.ErrTokenRevoked
 	}
 
-	newClaims := services.TokenClaims{
-		UserID:    claims.UserID,
-		Email:     claims.Email,
-		Role:      claims.Role,
-		TokenType: services.TokenTypeAccess,
+	// The user is read from the region that issued the token
+	if claims.Region != "" {
+		ctx = repositories.WithRegion(ctx, claims.Region)
 	}
 
-	accessToken, err := s.tokenService.GenerateAccessToken(ctx, newClaims)
+	// Suspended or deleted users cannot keep their sessions alive
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if !user.Status.CanAuthenticate() {
+		return nil, services.ErrAccountDisabled
+	}
+
+	// Tokens of bound roles may only be refreshed from the device they were
+	// bound to at login. Tokens issued before the user's role was bound are
+	// refused too, so that the user signs in again from a device.
+	if s.deviceBinding.appliesTo(user.Role) || s.deviceBinding.appliesTo(models.Role(claims.Role)) {
+		fingerprint := deviceFingerprint(ctx)
+		if fingerprint == "" {
+			return nil, services.ErrDeviceIDRequired
+		}
+		if claims.DeviceFingerprint == "" || !sameDevice(claims.DeviceFingerprint, fingerprint) {
+			s.logger.Warn("refresh attempted from a different device",
+				zap.String("userID", claims.UserID.String()))
+			return nil, services.ErrDeviceMismatch
+		}
+	}
+
+	// The role and profile are read again so that changes apply on refresh
+	newClaims := services.TokenClaims{
+		UserID:            claims.UserID,
+		Email:             claims.Email,
+		Username:          claims.Username,
+		Role:              string(user.Role),
+		TokenType:         services.TokenTypeAccess,
+		SessionID:         claims.SessionID,
+		DeviceFingerprint: claims.DeviceFingerprint,
+		Region:            claims.Region,
 	}
+	s.profileClaims.addProfileClaims(&newClaims, user)
 
-	newRefreshToken, err := s.tokenService.GenerateRefreshToken(ctx, newClaims)
+	tokens, err := s.issueTokenPair(ctx, user.OrganizationID, newClaims)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, err
 	}
+	s.extendSession(ctx, claims.UserID, claims.SessionID, tokens.RefreshTokenExpiresAt)
 
 	// Revoke old refresh token
 	if err := s.tokenService.RevokeToken(ctx, refreshToken); err != nil {
 		s.logger.Error("failed to revoke old refresh token", zap.Error(err))
 	}
 
-	return &services.TokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
-	}, nil
+	return tokens, nil
 }
 
-// Logout invalidates a user's tokens
-func (s *Service) Logout(ctx context.Context, accessToken string) error {
-	if err := s.tokenService.RevokeToken(ctx, accessToken); err != nil {
-		return fmt.Errorf("failed to revoke token: %w", err)
+// Logout revokes the given access and refresh tokens along with the session
+// they belong to, so every token issued for the session stops working
+func (s *Service) Logout(ctx context.Context, input services.LogoutInput) error {
+	sessions := make(map[string]uuid.UUID)
+	revoked := 0
+
+	for _, t := range []struct {
+		token     string
+		tokenType services.TokenType
+	}{
+		{input.AccessToken, services.TokenTypeAccess},
+		{input.RefreshToken, services.TokenTypeRefresh},
+	} {
+		if t.token == "" {
+			continue
+		}
+
+		claims, err := s.tokenService.ValidateToken(ctx, t.token, t.tokenType)
+		if err != nil {
+			s.logger.Debug("skipping invalid token on logout",
+				zap.String("tokenType", string(t.tokenType)),
+				zap.Error(err))
+			continue
+		}
+
+		if err := s.tokenService.RevokeToken(ctx, t.token); err != nil {
+			return fmt.Errorf("failed to revoke token: %w", err)
+		}
+		revoked++
+
+		if claims.SessionID != "" {
+			sessions[claims.SessionID] = claims.UserID
+		}
+	}
+
+	if revoked == 0 {
+		return services.ErrInvalidToken
+	}
+
+	for sessionID, userID := range sessions {
+		if err := s.tokenService.RevokeSession(ctx, sessionID); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		s.endSession(ctx, userID, sessionID)
 	}
+
 	return nil
 }
 
@@ -352,20 +771,44 @@ func (s *Service) UpdateUser(ctx context.Context, id uuid.UUID, input services.U
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
 
+	previousStatus := user.Status
+	previousCompleteness := s.profileCompleteness(user).Percent
+	emailChanged := false
 	if input.Email != "" && input.Email != user.Email {
 		existingUser, err := s.userRepo.GetByIdentifier(ctx, input.Email)
 		if err == nil && existingUser != nil && existingUser.ID != user.ID {
 			return nil, services.ErrEmailAlreadyExists
 		}
 		user.Email = input.Email
-		user.Status = models.UserStatusPending // Require email verification again
+		// Require email verification again
+		if err := user.RequireReverification(); err != nil {
+			return nil, errors.WrapError("UpdateUser", err)
+		}
+		emailChanged = true
+	}
+
+	if input.Status != "" {
+		if !input.Status.IsValid() || input.Status == models.UserStatusDeleted || input.Status == models.UserStatusDeactivated {
+			return nil, errors.WrapError("UpdateUser", errors.ErrInvalidInput)
+		}
+		if user.Status == models.UserStatusDeactivated && input.Status != user.Status {
+			return nil, errors.WrapError("UpdateUser", &models.StatusTransitionError{From: user.Status, To: input.Status})
+		}
+		if err := user.TransitionTo(input.Status); err != nil {
+			return nil, errors.WrapError("UpdateUser", err)
+		}
 	}
 
+	var previousUsername string
 	if input.Username != "" && input.Username != user.Username {
 		existingUser, err := s.userRepo.GetByIdentifier(ctx, input.Username)
 		if err == nil && existingUser != nil && existingUser.ID != user.ID {
 			return nil, services.ErrUsernameAlreadyExists
 		}
+		if err := s.checkUsernameChangeAllowed(ctx, user.ID, input.Username); err != nil {
+			return nil, err
+		}
+		previousUsername = user.Username
 		user.Username = input.Username
 	}
 
@@ -373,22 +816,156 @@ func (s *Service) UpdateUser(ctx context.Context, id uuid.UUID, input services.U
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
+	if previousUsername != "" {
+		s.recordUsernameChange(ctx, user.ID, previousUsername, user.Username)
+	}
+	s.invalidatePublicProfile(ctx, user.ID)
+
+	var changedFields []string
+	if emailChanged {
+		changedFields = append(changedFields, "email")
+	}
+	if previousUsername != "" {
+		changedFields = append(changedFields, "username")
+	}
+	if len(changedFields) > 0 {
+		s.publishUserEvent(ctx, string(events.UserUpdated), events.NewUserUpdatedEvent(
+			user.ID, user.Email, user.Username, changedFields))
+	}
+
+	reason := ""
+	if emailChanged {
+		reason = "email changed"
+	}
+	s.publishStatusChange(ctx, user, previousStatus, reason)
+	s.publishProfileThresholds(ctx, user, previousCompleteness)
+
+	if emailChanged {
+		if err := s.sendVerificationEmail(ctx, user); err != nil {
+			s.logger.Error("failed to send verification email",
+				zap.String("userID", user.ID.String()),
+				zap.Error(err))
+		}
+	}
+
 	return user, nil
 }
 
-// DeleteUser soft deletes a user account
-func (s *Service) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	user, err := s.userRepo.GetByID(ctx, id)
+// GetUsernameHistory retrieves a user's previous usernames, most recent first
+func (s *Service) GetUsernameHistory(ctx context.Context, id uuid.UUID) ([]*models.UsernameChange, error) {
+	if s.usernameHistory == nil {
+		return []*models.UsernameChange{}, nil
+	}
+
+	changes, err := s.usernameHistory.ListByUser(ctx, id)
 	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
+		return nil, errors.WrapError("GetUsernameHistory", err)
+	}
+	return changes, nil
+}
+
+// checkUsernameChangeAllowed enforces the username change cooldown and
+// rejects usernames still reserved by their former owners
+func (s *Service) checkUsernameChangeAllowed(ctx context.Context, userID uuid.UUID, username string) error {
+	if s.usernameHistory == nil {
+		return nil
+	}
+
+	if s.usernamePolicy.ChangeCooldown > 0 {
+		changes, err := s.usernameHistory.ListByUser(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get username history: %w", err)
+		}
+		if len(changes) > 0 && s.clock.Now().Sub(changes[0].ChangedAt) < s.usernamePolicy.ChangeCooldown {
+			return services.ErrUsernameChangeCooldown
+		}
+	}
+
+	reserved, err := s.isUsernameReserved(ctx, username, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check username reservation: %w", err)
+	}
+	if reserved {
+		return services.ErrUsernameAlreadyExists
+	}
+
+	return nil
+}
+
+// isUsernameReserved checks whether a username is reserved by a user other than the given one
+func (s *Service) isUsernameReserved(ctx context.Context, username string, userID uuid.UUID) (bool, error) {
+	if s.usernameHistory == nil || username == "" {
+		return false, nil
+	}
+	return s.usernameHistory.IsReserved(ctx, username, userID)
+}
+
+// recordUsernameChange stores a username change in the history
+func (s *Service) recordUsernameChange(ctx context.Context, userID uuid.UUID, oldUsername, newUsername string) {
+	if s.usernameHistory == nil {
+		return
+	}
+
+	change := models.NewUsernameChange(userID, oldUsername, newUsername, s.usernamePolicy.ReservationPeriod)
+	if err := s.usernameHistory.Create(ctx, change); err != nil {
+		s.logger.Error("failed to record username change",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+	}
+}
+
+// recordSecurityActivity stores a security activity for the monthly summary,
+// capturing the device and client details of the current request
+func (s *Service) recordSecurityActivity(ctx context.Context, userID uuid.UUID, activityType models.SecurityActivityType, sessionID string) {
+	if s.securityActivity == nil {
+		return
 	}
 
-	if err := s.userRepo.Delete(ctx, id); err != nil {
+	metadata := events.MetadataFromContext(ctx)
+	activity := &models.SecurityActivity{
+		UserID:    userID,
+		Type:      activityType,
+		DeviceID:  metadata.DeviceID,
+		UserAgent: metadata.UserAgent,
+		IPAddress: metadata.ClientIP,
+		Country:   metadata.Country,
+		SessionID: sessionID,
+	}
+	if err := s.securityActivity.Create(ctx, activity); err != nil {
+		s.logger.Error("failed to record security activity",
+			zap.String("userID", userID.String()),
+			zap.String("type", string(activityType)),
+			zap.Error(err))
+	}
+}
+
+// deleteUser moves a user to the deleted status and soft deletes the record
+// right away, without the grace period of deactivated accounts
+func (s *Service) deleteUser(ctx context.Context, user *models.User) error {
+	if err := user.TransitionTo(models.UserStatusDeleted); err != nil {
+		return errors.WrapError("DeleteUser", err)
+	}
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if err := s.userRepo.Delete(ctx, user.ID); err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
+	s.invalidatePublicProfile(ctx, user.ID)
+
+	// The email and username are free to be registered again once the user
+	// is deleted, including usernames the user had reserved by renaming
+	if s.usernameHistory != nil {
+		if err := s.usernameHistory.ReleaseReservations(ctx, user.ID); err != nil {
+			s.logger.Error("failed to release username reservations",
+				zap.String("userID", user.ID.String()),
+				zap.Error(err))
+		}
+	}
 
 	// Publish user deleted event
-	s.publishUserEvent(ctx, "user.deleted", events.NewUserDeletedEvent(user.ID, user.Email))
+	s.publishUserEvent(ctx, string(events.UserDeleted), events.NewUserDeletedEvent(user.ID, user.Email))
 
 	return nil
 }
@@ -404,6 +981,10 @@ func (s *Service) ChangePassword(ctx context.Context, id uuid.UUID, currentPassw
 		return errors.WrapError("ChangePassword", errors.ErrInvalidCredentials)
 	}
 
+	if err := s.validatePassword(ctx, user.OrganizationID, newPassword); err != nil {
+		return errors.WrapError("ChangePassword", fmt.Errorf("%w: %v", errors.ErrInvalidInput, err))
+	}
+
 	hashedPassword, err := s.passwordService.HashPassword(ctx, newPassword)
 	if err != nil {
 		return errors.WrapError("ChangePassword", err)
@@ -414,10 +995,16 @@ func (s *Service) ChangePassword(ctx context.Context, id uuid.UUID, currentPassw
 		return errors.WrapError("ChangePassword", err)
 	}
 
+	// Outstanding reset links must not outlive the password they were issued for
+	if err := s.invalidateResetTokens(ctx, user.ID); err != nil {
+		s.logger.Error("failed to invalidate reset tokens", zap.Error(err))
+	}
+
 	s.publishUserEvent(ctx, string(events.UserPasswordChange), events.NewUserPasswordChangedEvent(
 		user.ID,
 		user.Email,
 	))
+	s.recordSecurityActivity(ctx, user.ID, models.SecurityActivityPasswordChange, "")
 
 	return nil
 }
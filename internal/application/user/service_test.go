@@ -0,0 +1,87 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/auth/password"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/memory"
+	infraservices "github.com/mibrahim2344/identity-service/internal/infrastructure/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type recordingPublisher struct {
+	mu         sync.Mutex
+	eventTypes []string
+}
+
+func (p *recordingPublisher) PublishUserEvent(ctx context.Context, eventType string, payload interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.eventTypes = append(p.eventTypes, eventType)
+	return nil
+}
+
+func (p *recordingPublisher) published() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.eventTypes...)
+}
+
+// newTestService returns a service backed by in-memory storage and a real
+// token service, along with its user repository
+func newTestService(publisher services.EventPublisher, opts ...Option) (*Service, repositories.UserRepository) {
+	cache := memory.NewCacheService()
+	userRepo := memory.NewUserRepository(memory.NewStore())
+	tokenService := infraservices.NewTokenService(services.TokenConfig{
+		AccessTokenDuration:  15 * time.Minute,
+		RefreshTokenDuration: 24 * time.Hour,
+		SigningKey:           []byte("0123456789abcdef0123456789abcdef"),
+	}, cache)
+	s := NewService(userRepo, infraservices.NewPasswordService(password.NewBCryptHasher(4)), tokenService,
+		cache, publisher, zap.NewNop(), nil, "https://app.example.com", opts...)
+	return s, userRepo
+}
+
+func createTestUser(t *testing.T, userRepo repositories.UserRepository, email, username string, status models.UserStatus) *models.User {
+	user := &models.User{
+		ID:       uuid.New(),
+		Email:    email,
+		Username: username,
+		Status:   status,
+	}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+	return user
+}
+
+func TestResetPasswordRevokesSessions(t *testing.T) {
+	ctx := context.Background()
+	s, userRepo := newTestService(nopPublisher{})
+	user := createTestUser(t, userRepo, "user@example.com", "user", models.UserStatusActive)
+
+	refreshToken, err := s.tokenService.GenerateRefreshToken(ctx, services.TokenClaims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenType: services.TokenTypeRefresh,
+	})
+	require.NoError(t, err)
+	_, err = s.tokenService.ValidateToken(ctx, refreshToken, services.TokenTypeRefresh)
+	require.NoError(t, err)
+
+	resetToken, err := s.issueResetToken(ctx, user)
+	require.NoError(t, err)
+	require.NoError(t, s.ResetPassword(ctx, resetToken, "Correct-Horse-Battery-9"))
+
+	_, err = s.RefreshToken(ctx, refreshToken)
+	assert.Error(t, err)
+	_, err = s.tokenService.ValidateToken(ctx, refreshToken, services.TokenTypeRefresh)
+	assert.Error(t, err)
+}
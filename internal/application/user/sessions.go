@@ -0,0 +1,126 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// registerSession records a new session of a user within the concurrent
+// session limit in effect for them. When the limit denies the login the
+// session's tokens are discarded unused; when it evicts, the evicted
+// sessions are revoked.
+func (s *Service) registerSession(ctx context.Context, user *models.User, sessionID string, expiresAt time.Time) error {
+	if s.sessions == nil {
+		return nil
+	}
+
+	policy, err := s.effectiveSessionLimit(ctx, user.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	started, evicted, err := s.sessions.Start(ctx, &models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		CreatedAt: s.clock.Now(),
+		ExpiresAt: expiresAt,
+	}, policy.MaxSessions, policy.Action == models.SessionLimitEvictOldest)
+	if err != nil {
+		return fmt.Errorf("failed to register session: %w", err)
+	}
+	if !started {
+		s.logger.Info("login denied by concurrent session limit",
+			zap.String("userID", user.ID.String()),
+			zap.Int("maxSessions", policy.MaxSessions))
+		return services.ErrSessionLimitReached
+	}
+
+	for _, evictedID := range evicted {
+		if err := s.tokenService.RevokeSession(ctx, evictedID); err != nil {
+			s.logger.Error("failed to revoke evicted session",
+				zap.String("userID", user.ID.String()),
+				zap.String("sessionID", evictedID),
+				zap.Error(err))
+			continue
+		}
+		s.logger.Info("session evicted by concurrent session limit",
+			zap.String("userID", user.ID.String()),
+			zap.String("sessionID", evictedID))
+	}
+	return nil
+}
+
+// effectiveSessionLimit returns the session limit policy with the overrides
+// of the user's organization applied
+func (s *Service) effectiveSessionLimit(ctx context.Context, organizationID *uuid.UUID) (SessionLimitPolicy, error) {
+	policy := s.sessionLimit
+	settings, err := s.resolveTenantSettings(ctx, organizationID)
+	if err != nil {
+		return policy, err
+	}
+	if settings != nil {
+		if settings.MaxSessions > 0 {
+			policy.MaxSessions = settings.MaxSessions
+		}
+		if settings.SessionLimitAction != "" {
+			policy.Action = settings.SessionLimitAction
+		}
+	}
+	return policy, nil
+}
+
+// extendSession keeps a refreshed session active as long as its new refresh
+// token. Sessions started before tracking was enabled are not tracked.
+func (s *Service) extendSession(ctx context.Context, userID uuid.UUID, sessionID string, expiresAt time.Time) {
+	if s.sessions == nil || sessionID == "" {
+		return
+	}
+	if _, err := s.sessions.Extend(ctx, userID, sessionID, expiresAt); err != nil {
+		s.logger.Warn("failed to extend session",
+			zap.String("userID", userID.String()),
+			zap.String("sessionID", sessionID),
+			zap.Error(err))
+	}
+}
+
+// endSession frees the place of a session that has been revoked
+func (s *Service) endSession(ctx context.Context, userID uuid.UUID, sessionID string) {
+	if s.sessions == nil {
+		return
+	}
+	if err := s.sessions.End(ctx, userID, sessionID); err != nil {
+		s.logger.Warn("failed to end session",
+			zap.String("userID", userID.String()),
+			zap.String("sessionID", sessionID),
+			zap.Error(err))
+	}
+}
+
+// RevokeSessions revokes every access and refresh token issued to a user so
+// far and frees the places of their sessions
+func (s *Service) RevokeSessions(ctx context.Context, id uuid.UUID) error {
+	if err := s.tokenService.RevokeUserSessions(ctx, id); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	s.endAllSessions(ctx, id)
+	return nil
+}
+
+// endAllSessions frees the places of the sessions of a user whose sessions
+// have all been revoked
+func (s *Service) endAllSessions(ctx context.Context, userID uuid.UUID) {
+	if s.sessions == nil {
+		return
+	}
+	if err := s.sessions.EndAll(ctx, userID); err != nil {
+		s.logger.Warn("failed to end sessions",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+	}
+}
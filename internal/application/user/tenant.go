@@ -0,0 +1,56 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// resolveTenantSettings returns the overrides of an organization, or nil
+// when tenant settings are not enabled
+func (s *Service) resolveTenantSettings(ctx context.Context, organizationID *uuid.UUID) (*services.TenantSettings, error) {
+	if s.tenantSettings == nil {
+		return nil, nil
+	}
+	settings, err := s.tenantSettings.Resolve(ctx, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve organization settings: %w", err)
+	}
+	return settings, nil
+}
+
+// validatePassword validates password strength against the policy of the
+// given organization
+func (s *Service) validatePassword(ctx context.Context, organizationID *uuid.UUID, password string) error {
+	settings, err := s.resolveTenantSettings(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+	if settings != nil && settings.PasswordPolicy != nil {
+		return s.passwordService.ValidatePasswordPolicy(ctx, password, *settings.PasswordPolicy)
+	}
+	return s.passwordService.ValidatePassword(ctx, password)
+}
+
+// SetOrganization moves a user into an organization, whose settings then
+// apply to them, or out of any organization when organizationID is nil
+func (s *Service) SetOrganization(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	user.OrganizationID = organizationID
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	s.publishUserEvent(ctx, string(events.UserUpdated), events.NewUserUpdatedEvent(
+		user.ID, user.Email, user.Username, []string{"organization"}))
+
+	return user, nil
+}
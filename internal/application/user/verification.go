@@ -0,0 +1,166 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// defaultVerificationDailyLimit is used when no daily limit has been configured
+const defaultVerificationDailyLimit = 5
+
+// verificationWindow is the period over which verification emails are capped
+const verificationWindow = 24 * time.Hour
+
+func (s *Service) verificationLimit() int {
+	if s.verificationDailyLimit <= 0 {
+		return defaultVerificationDailyLimit
+	}
+	return s.verificationDailyLimit
+}
+
+// sendVerificationEmail issues a verification token and publishes the event
+// that triggers the email. When tracking is enabled, earlier links are
+// expired and the daily limit is enforced.
+func (s *Service) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	attempt := 1
+	if s.emailVerifications != nil {
+		sent, err := s.emailVerifications.CountSince(ctx, user.ID, s.clock.Now().Add(-verificationWindow))
+		if err != nil {
+			return fmt.Errorf("failed to count verification emails: %w", err)
+		}
+		if sent >= s.verificationLimit() {
+			return services.ErrVerificationLimitReached
+		}
+		attempt = sent + 1
+	}
+
+	token, err := s.tokenService.GenerateVerificationToken(ctx, services.TokenClaims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Username:  user.Username,
+		Role:      string(user.Role),
+		TokenType: services.TokenTypeVerification,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	ttl := s.tokenService.TokenDuration(services.TokenTypeVerification)
+	if s.emailVerifications != nil {
+		// Only the latest link can be used
+		if err := s.emailVerifications.ExpirePending(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to expire previous verification emails: %w", err)
+		}
+		verification := models.NewEmailVerification(user.ID, user.Email, hashToken(token), ttl)
+		if err := s.emailVerifications.Create(ctx, verification); err != nil {
+			return fmt.Errorf("failed to record verification email: %w", err)
+		}
+	}
+
+	verificationLink := fmt.Sprintf("%s/verify-email?token=%s", s.webAppURL, token)
+	s.publishUserEvent(ctx, string(events.UserVerificationRequested), events.NewUserVerificationRequestedEvent(
+		user.ID,
+		user.Email,
+		verificationLink,
+		attempt,
+		s.clock.Now().Add(ttl),
+	))
+
+	return nil
+}
+
+// redeemVerificationToken marks the verification email of a token as clicked.
+// It fails if the token was superseded, has expired or was already used.
+func (s *Service) redeemVerificationToken(ctx context.Context, token string) error {
+	if s.emailVerifications == nil {
+		return nil
+	}
+
+	verification, err := s.emailVerifications.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if stderrors.Is(err, services.ErrNotFound) {
+			return services.ErrInvalidToken
+		}
+		return fmt.Errorf("failed to get verification email: %w", err)
+	}
+
+	if verification.CurrentStatus() != models.EmailVerificationSent {
+		return services.ErrInvalidToken
+	}
+
+	verification.MarkClicked()
+	if err := s.emailVerifications.Update(ctx, verification); err != nil {
+		return fmt.Errorf("failed to update verification email: %w", err)
+	}
+	return nil
+}
+
+// ResendVerificationEmail sends a new verification email to an unverified
+// user. Unknown and already verified addresses are ignored, and so are
+// requests over the daily limit, so that callers cannot probe which
+// addresses are registered.
+func (s *Service) ResendVerificationEmail(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		s.logger.Debug("verification resend requested for unknown email")
+		return nil
+	}
+
+	if user.EmailVerified {
+		s.logger.Debug("verification resend requested for verified user",
+			zap.String("userID", user.ID.String()))
+		return nil
+	}
+
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		if stderrors.Is(err, services.ErrVerificationLimitReached) {
+			s.logger.Info("verification resend over the daily limit",
+				zap.String("userID", user.ID.String()))
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// GetEmailVerificationState retrieves the verification emails sent to a user
+func (s *Service) GetEmailVerificationState(ctx context.Context, id uuid.UUID) (*models.EmailVerificationState, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	state := &models.EmailVerificationState{
+		UserID:        user.ID,
+		EmailVerified: user.EmailVerified,
+		DailyLimit:    s.verificationLimit(),
+		Attempts:      []*models.EmailVerification{},
+	}
+	if s.emailVerifications == nil {
+		return state, nil
+	}
+
+	attempts, err := s.emailVerifications.ListByUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list verification emails: %w", err)
+	}
+
+	windowStart := s.clock.Now().Add(-verificationWindow)
+	for _, attempt := range attempts {
+		attempt.Status = attempt.CurrentStatus()
+		if attempt.SentAt.After(windowStart) {
+			state.SentToday++
+		}
+	}
+	state.Attempts = attempts
+
+	return state, nil
+}
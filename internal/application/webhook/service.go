@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// DefaultMaxPerUser is used when no webhook limit per user is configured
+const DefaultMaxPerUser = 5
+
+// EventTypes are the events on an account that notification webhooks
+// receive
+var EventTypes = []events.EventType{
+	events.UserLoggedIn,
+	events.UserPasswordChange,
+	events.UserPasswordReset,
+	events.UserMFAEnabled,
+	events.UserMFADisabled,
+	events.UserRecoveryEmailVerified,
+	events.UserRecoveryEmailRemoved,
+	events.UserCredentialsBreached,
+	events.UserSuspended,
+	events.UserActivated,
+	events.AccessPolicyViolated,
+}
+
+// Service manages the notification webhooks of users
+type Service struct {
+	repo       repositories.NotificationWebhookRepository
+	userRepo   repositories.UserRepository
+	maxPerUser int
+	logger     *zap.Logger
+}
+
+var _ services.NotificationWebhookService = (*Service)(nil)
+
+// NewService creates a new notification webhook service. A maxPerUser of 0
+// uses DefaultMaxPerUser.
+func NewService(
+	repo repositories.NotificationWebhookRepository,
+	userRepo repositories.UserRepository,
+	maxPerUser int,
+	logger *zap.Logger,
+) *Service {
+	if maxPerUser <= 0 {
+		maxPerUser = DefaultMaxPerUser
+	}
+	return &Service{
+		repo:       repo,
+		userRepo:   userRepo,
+		maxPerUser: maxPerUser,
+		logger:     logger,
+	}
+}
+
+// Register validates and stores a webhook with a new signing secret
+func (s *Service) Register(ctx context.Context, input services.RegisterWebhookInput) (*models.NotificationWebhook, error) {
+	webhook := &models.NotificationWebhook{
+		UserID:     input.UserID,
+		URL:        strings.TrimSpace(input.URL),
+		Format:     input.Format,
+		EventTypes: input.EventTypes,
+		CreatedBy:  input.CreatedBy,
+	}
+	if webhook.Format == "" {
+		webhook.Format = models.WebhookFormatJSON
+	}
+	if err := validateWebhook(webhook); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.GetByID(ctx, input.UserID); err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.ListByUser(ctx, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	if len(existing) >= s.maxPerUser {
+		return nil, services.ErrWebhookLimitReached
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	webhook.Secret = hex.EncodeToString(secret)
+
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	s.logger.Info("registered notification webhook",
+		zap.String("webhookID", webhook.ID.String()),
+		zap.String("userID", webhook.UserID.String()),
+		zap.String("createdBy", webhook.CreatedBy.String()))
+	return webhook, nil
+}
+
+// List returns the webhooks of a user
+func (s *Service) List(ctx context.Context, userID uuid.UUID) ([]*models.NotificationWebhook, error) {
+	webhooks, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// Delete removes a webhook of a user
+func (s *Service) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("deleted notification webhook",
+		zap.String("webhookID", id.String()),
+		zap.String("userID", userID.String()))
+	return nil
+}
+
+// validateWebhook checks that a webhook can be delivered to. URLs must use
+// HTTPS and may not name a local address; the sender additionally refuses
+// to connect to private networks.
+func validateWebhook(webhook *models.NotificationWebhook) error {
+	if !webhook.Format.IsValid() {
+		return fmt.Errorf("%w: webhook format must be json or slack", errors.ErrInvalidInput)
+	}
+
+	u, err := url.Parse(webhook.URL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return fmt.Errorf("%w: webhook URL must be an absolute https URL", errors.ErrInvalidInput)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: webhook URL must not name a local host", errors.ErrInvalidInput)
+	}
+	if ip := net.ParseIP(host); ip != nil && (!ip.IsGlobalUnicast() || ip.IsPrivate()) {
+		return fmt.Errorf("%w: webhook URL must not name a private address", errors.ErrInvalidInput)
+	}
+
+	for _, eventType := range webhook.EventTypes {
+		if !slices.Contains(EventTypes, events.EventType(eventType)) {
+			return fmt.Errorf("%w: unknown webhook event %q", errors.ErrInvalidInput, eventType)
+		}
+	}
+	return nil
+}
@@ -20,6 +20,9 @@ var (
 
 	// ErrInvalidInput indicates that the provided input is invalid
 	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrInvalidStatusTransition indicates that a user cannot move from its current status to the requested one
+	ErrInvalidStatusTransition = errors.New("invalid user status transition")
 )
 
 // DomainError represents a domain-specific error with operation context
@@ -11,11 +11,95 @@ type EventType string
 
 const (
 	// User-related events
-	UserRegistered     EventType = "user.registered"
-	UserVerified       EventType = "user.verified"
-	UserPasswordReset  EventType = "user.password.reset"
-	UserPasswordChange EventType = "user.password.changed"
-	UserDeleted        EventType = "user.deleted"
+	UserRegistered            EventType = "user.registered"
+	UserVerified              EventType = "user.verified"
+	UserPasswordReset         EventType = "user.password.reset"
+	UserPasswordChange        EventType = "user.password.changed"
+	UserDeleted               EventType = "user.deleted"
+	UserUpdated               EventType = "user.updated"
+	UserSecuritySummary       EventType = "user.security.summary"
+	UserVerificationRequested EventType = "user.verification.requested"
+	UserMFAEnabled            EventType = "user.mfa.enabled"
+	UserMFADisabled           EventType = "user.mfa.disabled"
+	UserCredentialsBreached   EventType = "user.credentials.breached"
+	UserLoggedIn              EventType = "user.logged_in"
+	UserProfileThreshold      EventType = "user.profile.threshold_crossed"
+	UserPurged                EventType = "user.purged"
+	UserPasskeyRegistered     EventType = "user.passkey.registered"
+	UserPasskeyRemoved        EventType = "user.passkey.removed"
+	UserMagicLinkRequested    EventType = "user.magic_link.requested"
+	UserLoginNewDevice        EventType = "user.login.new_device"
+
+	UserRecoveryEmailVerificationRequested EventType = "user.recovery_email.verification_requested"
+	UserRecoveryEmailVerified              EventType = "user.recovery_email.verified"
+	UserRecoveryEmailRemoved               EventType = "user.recovery_email.removed"
+
+	UserKnowledgeFactorsSet      EventType = "user.knowledge_factors.set"
+	UserKnowledgeFactorsRemoved  EventType = "user.knowledge_factors.removed"
+	UserKnowledgeFactorsRecovery EventType = "user.knowledge_factors.recovery"
+
+	UserIdentityLinked   EventType = "user.identity.linked"
+	UserIdentityUnlinked EventType = "user.identity.unlinked"
+
+	// Security audit events
+	SigningKeyRotated            EventType = "security.signing_key.rotated"
+	OAuthClientSecretRegenerated EventType = "security.oauth_client_secret.regenerated"
+	OAuthClientRegistered        EventType = "security.oauth_client.registered"
+	OAuthClientUpdated           EventType = "security.oauth_client.updated"
+	OAuthClientDisabled          EventType = "security.oauth_client.disabled"
+	OAuthClientEnabled           EventType = "security.oauth_client.enabled"
+	OAuthClientDeleted           EventType = "security.oauth_client.deleted"
+	AccessPolicyViolated         EventType = "security.access_policy.violated"
+	UserPurgeApproved            EventType = "security.user_purge.approved"
+	UserPurgeRejected            EventType = "security.user_purge.rejected"
+	ServiceAccountCreated        EventType = "security.service_account.created"
+	APIKeyCreated                EventType = "security.api_key.created"
+	APIKeyRevoked                EventType = "security.api_key.revoked"
+	CacheInvalidated             EventType = "security.cache.invalidated"
+	AdminPasswordResetRequested  EventType = "security.admin_password_reset.requested"
+	AdminPasswordResetRejected   EventType = "security.admin_password_reset.rejected"
+	RoleCreated                  EventType = "security.role.created"
+	RoleDeleted                  EventType = "security.role.deleted"
+	RolePermissionsChanged       EventType = "security.role.permissions_changed"
+	UserRoleChanged              EventType = "security.user_role.changed"
+	BootstrapTokenIssued         EventType = "security.bootstrap_token.issued"
+	BootstrapCompleted           EventType = "security.bootstrap.completed"
+	RegistrationRiskAssessed     EventType = "security.registration_risk.assessed"
+	AccountRiskChanged           EventType = "security.account_risk.changed"
+
+	// User status transition events
+	UserActivated           EventType = "user.activated"
+	UserSuspended           EventType = "user.suspended"
+	UserPendingVerification EventType = "user.pending_verification"
+
+	// Account deletion events: users who delete their account are
+	// deactivated, and purged unless they restore it within the grace period
+	UserDeactivated        EventType = "user.deactivated"
+	UserRestored           EventType = "user.restored"
+	UserDeactivationPurged EventType = "user.deactivation.purged"
+
+	// Moderation events
+	AccountFlagged      EventType = "moderation.account.flagged"
+	AccountFlagReviewed EventType = "moderation.flag.reviewed"
+	AccountRestricted   EventType = "moderation.account.restricted"
+	AccountUnrestricted EventType = "moderation.account.unrestricted"
+
+	// Organization events
+	OrganizationCreated       EventType = "organization.created"
+	OrganizationMemberInvited EventType = "organization.member.invited"
+	OrganizationMemberRemoved EventType = "organization.member.removed"
+	// OrganizationMemberJoined is published when a user joins an
+	// organization themselves: through its SSO connection, its domain join
+	// policy or by accepting an invitation
+	OrganizationMemberJoined      EventType = "organization.member.joined"
+	OrganizationInvitationCreated EventType = "organization.invitation.created"
+	OrganizationDomainVerified    EventType = "organization.domain.verified"
+
+	// SSO connection events
+	SSOConnectionCreated EventType = "organization.sso_connection.created"
+	SSOConnectionUpdated EventType = "organization.sso_connection.updated"
+	SSOConnectionDeleted EventType = "organization.sso_connection.deleted"
+	SSODomainVerified    EventType = "organization.sso_domain.verified"
 )
 
 // BaseEvent contains common fields for all events
@@ -24,6 +108,12 @@ type BaseEvent struct {
 	Type      EventType `json:"type"`
 	Timestamp time.Time `json:"timestamp"`
 	Version   string    `json:"version"`
+	Metadata  Metadata  `json:"metadata"`
+}
+
+// SetMetadata attaches request metadata to the event
+func (e *BaseEvent) SetMetadata(metadata Metadata) {
+	e.Metadata = metadata
 }
 
 // UserRegisteredEvent is published when a new user registers
@@ -66,6 +156,407 @@ type UserDeletedEvent struct {
 	Email  string    `json:"email"`
 }
 
+// UserDeactivationEvent is published when a user deletes their account,
+// restores it or is purged after the grace period; its type tells which.
+// RestoredBy is the admin who restored the account, if not the user.
+type UserDeactivationEvent struct {
+	BaseEvent
+	UserID        uuid.UUID `json:"userId"`
+	Email         string    `json:"email"`
+	Username      string    `json:"username"`
+	DeactivatedAt time.Time `json:"deactivatedAt"`
+	PurgeAfter    time.Time `json:"purgeAfter"`
+	RestoredBy    uuid.UUID `json:"restoredBy"`
+}
+
+// UserVerificationRequestedEvent is published when a verification email should
+// be sent; the notification service consumes it to deliver the email
+type UserVerificationRequestedEvent struct {
+	BaseEvent
+	UserID           uuid.UUID `json:"userId"`
+	Email            string    `json:"email"`
+	VerificationLink string    `json:"verificationLink"`
+	Attempt          int       `json:"attempt"` // number of verification emails sent in the last 24 hours, including this one
+	ExpiresAt        time.Time `json:"expiresAt"`
+}
+
+// UserMagicLinkRequestedEvent is published when a passwordless login link
+// should be sent; the notification service consumes it to deliver the email
+type UserMagicLinkRequestedEvent struct {
+	BaseEvent
+	UserID    uuid.UUID `json:"userId"`
+	Email     string    `json:"email"`
+	LoginLink string    `json:"loginLink"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// UserRecoveryEmailVerificationRequestedEvent is published when a new
+// recovery email should be verified; the email goes to the recovery address
+type UserRecoveryEmailVerificationRequestedEvent struct {
+	BaseEvent
+	UserID           uuid.UUID `json:"userId"`
+	Email            string    `json:"email"` // the recovery address
+	VerificationLink string    `json:"verificationLink"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+}
+
+// UserRecoveryEmailEvent is published when a user's recovery email is
+// verified or removed; its type tells which
+type UserRecoveryEmailEvent struct {
+	BaseEvent
+	UserID        uuid.UUID `json:"userId"`
+	Email         string    `json:"email"`
+	RecoveryEmail string    `json:"recoveryEmail"`
+}
+
+// UserKnowledgeFactorsEvent is published when a user sets or removes their
+// security question answers or recovers their account with them; its type
+// tells which
+type UserKnowledgeFactorsEvent struct {
+	BaseEvent
+	UserID uuid.UUID `json:"userId"`
+	Email  string    `json:"email"`
+}
+
+// UserIdentityEvent is published when a user links or unlinks the account of
+// an external identity provider; its type tells which
+type UserIdentityEvent struct {
+	BaseEvent
+	UserID     uuid.UUID `json:"userId"`
+	Email      string    `json:"email"`
+	IdentityID uuid.UUID `json:"identityId"`
+	Provider   string    `json:"provider"`
+}
+
+// UserStatusChangedEvent is published when a user moves between lifecycle
+// statuses; its type identifies the transition
+type UserStatusChangedEvent struct {
+	BaseEvent
+	UserID         uuid.UUID `json:"userId"`
+	Email          string    `json:"email"`
+	PreviousStatus string    `json:"previousStatus"`
+	Status         string    `json:"status"`
+	Reason         string    `json:"reason,omitempty"`
+}
+
+// UserUpdatedEvent is published when a user's email or username changes
+type UserUpdatedEvent struct {
+	BaseEvent
+	UserID        uuid.UUID `json:"userId"`
+	Email         string    `json:"email"`
+	Username      string    `json:"username"`
+	ChangedFields []string  `json:"changedFields"`
+}
+
+// UserSecuritySummaryEvent is published periodically with a user's security
+// activity so the notification service can email it
+type UserSecuritySummaryEvent struct {
+	BaseEvent
+	UserID          uuid.UUID `json:"userId"`
+	Email           string    `json:"email"`
+	Username        string    `json:"username"`
+	PeriodStart     time.Time `json:"periodStart"`
+	PeriodEnd       time.Time `json:"periodEnd"`
+	NewDevices      int       `json:"newDevices"`
+	PasswordChanges int       `json:"passwordChanges"`
+	ActiveSessions  int       `json:"activeSessions"`
+}
+
+// SigningKeyRotatedEvent is published when a token signing key is rotated.
+// The metadata actor is the admin who rotated it, or a system actor for
+// scheduled rotations.
+type SigningKeyRotatedEvent struct {
+	BaseEvent
+	TokenType     string `json:"tokenType"`
+	PreviousKeyID string `json:"previousKeyId,omitempty"`
+	KeyID         string `json:"keyId"`
+	Algorithm     string `json:"algorithm"`
+}
+
+// UserMFAChangedEvent is published when a user enables or disables a second
+// factor. Reason is reset when an admin removed it.
+type UserMFAChangedEvent struct {
+	BaseEvent
+	UserID uuid.UUID `json:"userId"`
+	Email  string    `json:"email"`
+	Method string    `json:"method"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// UserPasskeyEvent is published when a user registers or removes a passkey
+type UserPasskeyEvent struct {
+	BaseEvent
+	UserID    uuid.UUID `json:"userId"`
+	Email     string    `json:"email"`
+	PasskeyID uuid.UUID `json:"passkeyId"`
+	Name      string    `json:"name"`
+}
+
+// UserCredentialsBreachedEvent is published when a user's credentials were
+// found in a breach; the notification service consumes it to tell the user
+// to choose a new password through the reset link
+type UserCredentialsBreachedEvent struct {
+	BaseEvent
+	UserID    uuid.UUID `json:"userId"`
+	Email     string    `json:"email"`
+	Source    string    `json:"source,omitempty"`
+	ResetLink string    `json:"resetLink"`
+}
+
+// UserLoggedInEvent is published when a user starts a new session. The
+// metadata carries the client IP and user agent of the login.
+type UserLoggedInEvent struct {
+	BaseEvent
+	UserID    uuid.UUID `json:"userId"`
+	Email     string    `json:"email"`
+	SessionID string    `json:"sessionId"`
+}
+
+// UserLoginNewDeviceEvent is published when a user signs in from a device
+// or country they have not signed in from before, so that they are told by
+// email. ConfirmationLink is set when the login is held back until the user
+// confirms it.
+type UserLoginNewDeviceEvent struct {
+	BaseEvent
+	UserID           uuid.UUID  `json:"userId"`
+	Email            string     `json:"email"`
+	NewDevice        bool       `json:"newDevice"`
+	NewCountry       bool       `json:"newCountry"`
+	UserAgent        string     `json:"userAgent,omitempty"`
+	IPAddress        string     `json:"ipAddress,omitempty"`
+	Country          string     `json:"country,omitempty"`
+	ConfirmationLink string     `json:"confirmationLink,omitempty"`
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty"`
+}
+
+// OAuthClientSecretRegeneratedEvent is published when the secret of an OAuth
+// client is replaced. The metadata actor is the admin who replaced it.
+// A previous secret that keeps working for an overlap window expires at
+// PreviousSecretExpiresAt.
+type OAuthClientSecretRegeneratedEvent struct {
+	BaseEvent
+	ClientID                string     `json:"clientId"`
+	Name                    string     `json:"name"`
+	PreviousSecretExpiresAt *time.Time `json:"previousSecretExpiresAt,omitempty"`
+}
+
+// OAuthClientEvent is published when an admin registers, updates, disables,
+// enables or deletes an OAuth client. The metadata actor is the admin.
+type OAuthClientEvent struct {
+	BaseEvent
+	ClientID     string   `json:"clientId"`
+	Name         string   `json:"name"`
+	Public       bool     `json:"public"`
+	RedirectURIs []string `json:"redirectUris,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// AccessPolicyViolatedEvent is published when an organization's access
+// policy denies a login. Code names the rule; the metadata carries the
+// client IP and country of the login.
+type AccessPolicyViolatedEvent struct {
+	BaseEvent
+	UserID         uuid.UUID `json:"userId"`
+	Email          string    `json:"email"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	Code           string    `json:"code"`
+}
+
+// UserProfileThresholdEvent is published when a profile change moves a
+// user's profile completeness across a configured threshold, in either
+// direction
+type UserProfileThresholdEvent struct {
+	BaseEvent
+	UserID    uuid.UUID `json:"userId"`
+	Threshold int       `json:"threshold"`
+	Previous  int       `json:"previous"` // completeness before the change, in percent
+	Percent   int       `json:"percent"`  // completeness after the change
+	Reached   bool      `json:"reached"`  // false when completeness dropped below the threshold
+}
+
+// UserPurgeApprovedEvent is published when an admin approves permanently
+// deleting a user
+type UserPurgeApprovedEvent struct {
+	BaseEvent
+	UserID     uuid.UUID `json:"userId"`
+	ApproverID uuid.UUID `json:"approverId"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// UserPurgeRejectedEvent is published when an admin's attempt to purge a
+// user is refused, e.g. for an invalid or self-issued approval
+type UserPurgeRejectedEvent struct {
+	BaseEvent
+	UserID  uuid.UUID `json:"userId"`
+	AdminID uuid.UUID `json:"adminId"`
+	Reason  string    `json:"reason"`
+}
+
+// UserPurgedEvent is published when a user was permanently deleted. It
+// keeps the email and username for the audit trail only.
+type UserPurgedEvent struct {
+	BaseEvent
+	UserID     uuid.UUID `json:"userId"`
+	Email      string    `json:"email"`
+	Username   string    `json:"username"`
+	PurgedBy   uuid.UUID `json:"purgedBy"`
+	ApprovedBy uuid.UUID `json:"approvedBy"`
+	ApprovedAt time.Time `json:"approvedAt"`
+}
+
+// AdminPasswordResetEvent is published when an admin sends a user the
+// password reset email, or is refused; its type tells which
+type AdminPasswordResetEvent struct {
+	BaseEvent
+	UserID  uuid.UUID `json:"userId"`
+	AdminID uuid.UUID `json:"adminId"`
+	Reason  string    `json:"reason,omitempty"` // why a request was refused
+}
+
+// ServiceAccountCreatedEvent is published when an admin creates a service
+// account
+type ServiceAccountCreatedEvent struct {
+	BaseEvent
+	UserID    uuid.UUID `json:"userId"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedBy uuid.UUID `json:"createdBy"`
+}
+
+// APIKeyEvent is published when an API key of a service account is created
+// or revoked
+type APIKeyEvent struct {
+	BaseEvent
+	KeyID  uuid.UUID `json:"keyId"`
+	UserID uuid.UUID `json:"userId"`
+	Prefix string    `json:"prefix,omitempty"` // set on creation
+	Actor  uuid.UUID `json:"actor"`            // admin who created or revoked the key
+}
+
+// RoleEvent is published when a role is created or deleted or its
+// permissions change. The metadata actor is the admin.
+type RoleEvent struct {
+	BaseEvent
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions,omitempty"` // the permissions after the change
+}
+
+// UserRoleChangedEvent is published when an admin assigns a user another role
+type UserRoleChangedEvent struct {
+	BaseEvent
+	UserID       uuid.UUID `json:"userId"`
+	PreviousRole string    `json:"previousRole"`
+	Role         string    `json:"role"`
+	Actor        uuid.UUID `json:"actor"`
+}
+
+// BootstrapEvent is published when a bootstrap token is issued at the first
+// start of a deployment and when it is used to create the first admin;
+// its type tells which. OrganizationID is set when setup also created an
+// organization.
+type BootstrapEvent struct {
+	BaseEvent
+	ExpiresAt      time.Time `json:"expiresAt"`
+	AdminID        uuid.UUID `json:"adminId"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+}
+
+// RegistrationRiskAssessedEvent is published when a registration scored
+// high enough to be challenged with email verification or queued for
+// manual review
+type RegistrationRiskAssessedEvent struct {
+	BaseEvent
+	UserID   uuid.UUID `json:"userId"`
+	Email    string    `json:"email"`
+	Score    int       `json:"score"`
+	Decision string    `json:"decision"`
+	Reasons  []string  `json:"reasons,omitempty"`
+}
+
+// AccountRiskChangedEvent is published when the risk state of an account
+// changes, raising or lowering what its logins require
+type AccountRiskChangedEvent struct {
+	BaseEvent
+	UserID        uuid.UUID `json:"userId"`
+	PreviousState string    `json:"previousState"`
+	State         string    `json:"state"`
+	Reason        string    `json:"reason,omitempty"`
+}
+
+// OrganizationCreatedEvent is published when a user creates an organization
+type OrganizationCreatedEvent struct {
+	BaseEvent
+	OrganizationID uuid.UUID `json:"organizationId"`
+	Name           string    `json:"name"`
+	CreatedBy      uuid.UUID `json:"createdBy"`
+}
+
+// OrganizationMemberEvent is published when a user is invited into, joins
+// or is removed from an organization
+type OrganizationMemberEvent struct {
+	BaseEvent
+	OrganizationID uuid.UUID `json:"organizationId"`
+	UserID         uuid.UUID `json:"userId"`
+	Role           string    `json:"role"`
+	Actor          uuid.UUID `json:"actor"`
+}
+
+// OrganizationDomainEvent is published when an organization admin verifies
+// one of the organization's email domains
+type OrganizationDomainEvent struct {
+	BaseEvent
+	OrganizationID uuid.UUID `json:"organizationId"`
+	Domain         string    `json:"domain"`
+	Actor          uuid.UUID `json:"actor"`
+}
+
+// SSOConnectionEvent is published when an organization admin creates,
+// updates or deletes an SSO connection, or verifies one of its domains
+type SSOConnectionEvent struct {
+	BaseEvent
+	OrganizationID uuid.UUID `json:"organizationId"`
+	ConnectionID   uuid.UUID `json:"connectionId"`
+	Domain         string    `json:"domain,omitempty"`
+	Actor          uuid.UUID `json:"actor"`
+}
+
+// CacheInvalidatedEvent is published when an admin deletes cache keys
+// matching a pattern. The metadata actor is the admin.
+type CacheInvalidatedEvent struct {
+	BaseEvent
+	Pattern string `json:"pattern"`
+	Deleted int64  `json:"deleted"`
+}
+
+// AccountFlaggedEvent is published when an account is reported by a user or
+// flagged by an admin
+type AccountFlaggedEvent struct {
+	BaseEvent
+	FlagID     uuid.UUID `json:"flagId"`
+	UserID     uuid.UUID `json:"userId"`
+	Category   string    `json:"category"`
+	ReportedBy uuid.UUID `json:"reportedBy"`
+	ByAdmin    bool      `json:"byAdmin"`
+}
+
+// AccountFlagReviewedEvent is published when an admin confirms or dismisses
+// a flag
+type AccountFlagReviewedEvent struct {
+	BaseEvent
+	FlagID     uuid.UUID `json:"flagId"`
+	UserID     uuid.UUID `json:"userId"`
+	Status     string    `json:"status"`
+	ReviewedBy uuid.UUID `json:"reviewedBy"`
+}
+
+// AccountRestrictionEvent is published when an account becomes restricted
+// because of its flags or when the restriction is lifted
+type AccountRestrictionEvent struct {
+	BaseEvent
+	UserID uuid.UUID `json:"userId"`
+	FlagID uuid.UUID `json:"flagId"` // flag whose report or review changed the restriction
+}
+
 // NewBaseEvent creates a new base event
 func NewBaseEvent(eventType EventType) BaseEvent {
 	return BaseEvent{
@@ -125,3 +616,439 @@ func NewUserDeletedEvent(userID uuid.UUID, email string) *UserDeletedEvent {
 		Email:     email,
 	}
 }
+
+// NewUserDeactivationEvent creates a new user deactivation event of the given type
+func NewUserDeactivationEvent(eventType EventType, userID uuid.UUID, email, username string, deactivatedAt, purgeAfter time.Time, restoredBy uuid.UUID) *UserDeactivationEvent {
+	return &UserDeactivationEvent{
+		BaseEvent:     NewBaseEvent(eventType),
+		UserID:        userID,
+		Email:         email,
+		Username:      username,
+		DeactivatedAt: deactivatedAt,
+		PurgeAfter:    purgeAfter,
+		RestoredBy:    restoredBy,
+	}
+}
+
+// NewBootstrapEvent creates a new bootstrap event of the given type
+func NewBootstrapEvent(eventType EventType, expiresAt time.Time, adminID, organizationID uuid.UUID) *BootstrapEvent {
+	return &BootstrapEvent{
+		BaseEvent:      NewBaseEvent(eventType),
+		ExpiresAt:      expiresAt,
+		AdminID:        adminID,
+		OrganizationID: organizationID,
+	}
+}
+
+// NewRegistrationRiskAssessedEvent creates a new registration risk assessed event
+func NewRegistrationRiskAssessedEvent(userID uuid.UUID, email string, score int, decision string, reasons []string) *RegistrationRiskAssessedEvent {
+	return &RegistrationRiskAssessedEvent{
+		BaseEvent: NewBaseEvent(RegistrationRiskAssessed),
+		UserID:    userID,
+		Email:     email,
+		Score:     score,
+		Decision:  decision,
+		Reasons:   reasons,
+	}
+}
+
+// NewAccountRiskChangedEvent creates a new account risk changed event
+func NewAccountRiskChangedEvent(userID uuid.UUID, previousState, state, reason string) *AccountRiskChangedEvent {
+	return &AccountRiskChangedEvent{
+		BaseEvent:     NewBaseEvent(AccountRiskChanged),
+		UserID:        userID,
+		PreviousState: previousState,
+		State:         state,
+		Reason:        reason,
+	}
+}
+
+// NewUserUpdatedEvent creates a new user updated event
+func NewUserUpdatedEvent(userID uuid.UUID, email, username string, changedFields []string) *UserUpdatedEvent {
+	return &UserUpdatedEvent{
+		BaseEvent:     NewBaseEvent(UserUpdated),
+		UserID:        userID,
+		Email:         email,
+		Username:      username,
+		ChangedFields: changedFields,
+	}
+}
+
+// NewUserMFAChangedEvent creates a new MFA changed event of the given type
+func NewUserMFAChangedEvent(eventType EventType, userID uuid.UUID, email, method, reason string) *UserMFAChangedEvent {
+	return &UserMFAChangedEvent{
+		BaseEvent: NewBaseEvent(eventType),
+		UserID:    userID,
+		Email:     email,
+		Method:    method,
+		Reason:    reason,
+	}
+}
+
+// NewUserPasskeyEvent creates a new passkey registered or removed event
+func NewUserPasskeyEvent(eventType EventType, userID uuid.UUID, email string, passkeyID uuid.UUID, name string) *UserPasskeyEvent {
+	return &UserPasskeyEvent{
+		BaseEvent: NewBaseEvent(eventType),
+		UserID:    userID,
+		Email:     email,
+		PasskeyID: passkeyID,
+		Name:      name,
+	}
+}
+
+// NewUserLoggedInEvent creates a new user logged in event
+func NewUserLoggedInEvent(userID uuid.UUID, email, sessionID string) *UserLoggedInEvent {
+	return &UserLoggedInEvent{
+		BaseEvent: NewBaseEvent(UserLoggedIn),
+		UserID:    userID,
+		Email:     email,
+		SessionID: sessionID,
+	}
+}
+
+// NewUserLoginNewDeviceEvent creates a new login from a new device event.
+// confirmationLink and expiresAt are empty unless the login awaits
+// confirmation.
+func NewUserLoginNewDeviceEvent(userID uuid.UUID, email string, newDevice, newCountry bool, userAgent, ipAddress, country, confirmationLink string, expiresAt *time.Time) *UserLoginNewDeviceEvent {
+	return &UserLoginNewDeviceEvent{
+		BaseEvent:        NewBaseEvent(UserLoginNewDevice),
+		UserID:           userID,
+		Email:            email,
+		NewDevice:        newDevice,
+		NewCountry:       newCountry,
+		UserAgent:        userAgent,
+		IPAddress:        ipAddress,
+		Country:          country,
+		ConfirmationLink: confirmationLink,
+		ExpiresAt:        expiresAt,
+	}
+}
+
+// NewAccessPolicyViolatedEvent creates a new access policy violated event
+func NewAccessPolicyViolatedEvent(userID uuid.UUID, email string, organizationID uuid.UUID, code string) *AccessPolicyViolatedEvent {
+	return &AccessPolicyViolatedEvent{
+		BaseEvent:      NewBaseEvent(AccessPolicyViolated),
+		UserID:         userID,
+		Email:          email,
+		OrganizationID: organizationID,
+		Code:           code,
+	}
+}
+
+// NewUserProfileThresholdEvent creates a new profile threshold crossed event
+func NewUserProfileThresholdEvent(userID uuid.UUID, threshold, previous, percent int) *UserProfileThresholdEvent {
+	return &UserProfileThresholdEvent{
+		BaseEvent: NewBaseEvent(UserProfileThreshold),
+		UserID:    userID,
+		Threshold: threshold,
+		Previous:  previous,
+		Percent:   percent,
+		Reached:   percent >= threshold,
+	}
+}
+
+// NewUserPurgeApprovedEvent creates a new user purge approved event
+func NewUserPurgeApprovedEvent(userID, approverID uuid.UUID, expiresAt time.Time) *UserPurgeApprovedEvent {
+	return &UserPurgeApprovedEvent{
+		BaseEvent:  NewBaseEvent(UserPurgeApproved),
+		UserID:     userID,
+		ApproverID: approverID,
+		ExpiresAt:  expiresAt,
+	}
+}
+
+// NewUserPurgeRejectedEvent creates a new user purge rejected event
+func NewUserPurgeRejectedEvent(userID, adminID uuid.UUID, reason string) *UserPurgeRejectedEvent {
+	return &UserPurgeRejectedEvent{
+		BaseEvent: NewBaseEvent(UserPurgeRejected),
+		UserID:    userID,
+		AdminID:   adminID,
+		Reason:    reason,
+	}
+}
+
+// NewAdminPasswordResetEvent creates a new admin password reset event of
+// the given type
+func NewAdminPasswordResetEvent(eventType EventType, userID, adminID uuid.UUID, reason string) *AdminPasswordResetEvent {
+	return &AdminPasswordResetEvent{
+		BaseEvent: NewBaseEvent(eventType),
+		UserID:    userID,
+		AdminID:   adminID,
+		Reason:    reason,
+	}
+}
+
+// NewUserPurgedEvent creates a new user purged event
+func NewUserPurgedEvent(userID uuid.UUID, email, username string, purgedBy, approvedBy uuid.UUID, approvedAt time.Time) *UserPurgedEvent {
+	return &UserPurgedEvent{
+		BaseEvent:  NewBaseEvent(UserPurged),
+		UserID:     userID,
+		Email:      email,
+		Username:   username,
+		PurgedBy:   purgedBy,
+		ApprovedBy: approvedBy,
+		ApprovedAt: approvedAt,
+	}
+}
+
+// NewServiceAccountCreatedEvent creates a new service account created event
+func NewServiceAccountCreatedEvent(userID uuid.UUID, username, role string, createdBy uuid.UUID) *ServiceAccountCreatedEvent {
+	return &ServiceAccountCreatedEvent{
+		BaseEvent: NewBaseEvent(ServiceAccountCreated),
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		CreatedBy: createdBy,
+	}
+}
+
+// NewRoleEvent creates a new role event of the given type
+func NewRoleEvent(eventType EventType, role string, permissions []string) *RoleEvent {
+	return &RoleEvent{
+		BaseEvent:   NewBaseEvent(eventType),
+		Role:        role,
+		Permissions: permissions,
+	}
+}
+
+// NewUserRoleChangedEvent creates a new user role changed event
+func NewUserRoleChangedEvent(userID uuid.UUID, previousRole, role string, actor uuid.UUID) *UserRoleChangedEvent {
+	return &UserRoleChangedEvent{
+		BaseEvent:    NewBaseEvent(UserRoleChanged),
+		UserID:       userID,
+		PreviousRole: previousRole,
+		Role:         role,
+		Actor:        actor,
+	}
+}
+
+// NewOrganizationCreatedEvent creates a new organization created event
+func NewOrganizationCreatedEvent(organizationID uuid.UUID, name string, createdBy uuid.UUID) *OrganizationCreatedEvent {
+	return &OrganizationCreatedEvent{
+		BaseEvent:      NewBaseEvent(OrganizationCreated),
+		OrganizationID: organizationID,
+		Name:           name,
+		CreatedBy:      createdBy,
+	}
+}
+
+// NewOrganizationMemberEvent creates a new organization member event of the given type
+func NewOrganizationMemberEvent(eventType EventType, organizationID, userID uuid.UUID, role string, actor uuid.UUID) *OrganizationMemberEvent {
+	return &OrganizationMemberEvent{
+		BaseEvent:      NewBaseEvent(eventType),
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Role:           role,
+		Actor:          actor,
+	}
+}
+
+// NewOrganizationDomainEvent creates a new organization domain event of the given type
+func NewOrganizationDomainEvent(eventType EventType, organizationID uuid.UUID, domain string, actor uuid.UUID) *OrganizationDomainEvent {
+	return &OrganizationDomainEvent{
+		BaseEvent:      NewBaseEvent(eventType),
+		OrganizationID: organizationID,
+		Domain:         domain,
+		Actor:          actor,
+	}
+}
+
+// NewSSOConnectionEvent creates a new SSO connection event of the given type
+func NewSSOConnectionEvent(eventType EventType, organizationID, connectionID uuid.UUID, domain string, actor uuid.UUID) *SSOConnectionEvent {
+	return &SSOConnectionEvent{
+		BaseEvent:      NewBaseEvent(eventType),
+		OrganizationID: organizationID,
+		ConnectionID:   connectionID,
+		Domain:         domain,
+		Actor:          actor,
+	}
+}
+
+// NewAPIKeyEvent creates a new API key created or revoked event
+func NewAPIKeyEvent(eventType EventType, keyID, userID uuid.UUID, prefix string, actor uuid.UUID) *APIKeyEvent {
+	return &APIKeyEvent{
+		BaseEvent: NewBaseEvent(eventType),
+		KeyID:     keyID,
+		UserID:    userID,
+		Prefix:    prefix,
+		Actor:     actor,
+	}
+}
+
+// NewCacheInvalidatedEvent creates a new cache invalidated event
+func NewCacheInvalidatedEvent(pattern string, deleted int64) *CacheInvalidatedEvent {
+	return &CacheInvalidatedEvent{
+		BaseEvent: NewBaseEvent(CacheInvalidated),
+		Pattern:   pattern,
+		Deleted:   deleted,
+	}
+}
+
+// NewAccountFlaggedEvent creates a new account flagged event
+func NewAccountFlaggedEvent(flagID, userID uuid.UUID, category string, reportedBy uuid.UUID, byAdmin bool) *AccountFlaggedEvent {
+	return &AccountFlaggedEvent{
+		BaseEvent:  NewBaseEvent(AccountFlagged),
+		FlagID:     flagID,
+		UserID:     userID,
+		Category:   category,
+		ReportedBy: reportedBy,
+		ByAdmin:    byAdmin,
+	}
+}
+
+// NewAccountFlagReviewedEvent creates a new account flag reviewed event
+func NewAccountFlagReviewedEvent(flagID, userID uuid.UUID, status string, reviewedBy uuid.UUID) *AccountFlagReviewedEvent {
+	return &AccountFlagReviewedEvent{
+		BaseEvent:  NewBaseEvent(AccountFlagReviewed),
+		FlagID:     flagID,
+		UserID:     userID,
+		Status:     status,
+		ReviewedBy: reviewedBy,
+	}
+}
+
+// NewAccountRestrictionEvent creates a new account restricted or
+// unrestricted event
+func NewAccountRestrictionEvent(eventType EventType, userID, flagID uuid.UUID) *AccountRestrictionEvent {
+	return &AccountRestrictionEvent{
+		BaseEvent: NewBaseEvent(eventType),
+		UserID:    userID,
+		FlagID:    flagID,
+	}
+}
+
+// NewUserCredentialsBreachedEvent creates a new credentials breached event
+func NewUserCredentialsBreachedEvent(userID uuid.UUID, email, source, resetLink string) *UserCredentialsBreachedEvent {
+	return &UserCredentialsBreachedEvent{
+		BaseEvent: NewBaseEvent(UserCredentialsBreached),
+		UserID:    userID,
+		Email:     email,
+		Source:    source,
+		ResetLink: resetLink,
+	}
+}
+
+// NewSigningKeyRotatedEvent creates a new signing key rotated event
+func NewSigningKeyRotatedEvent(tokenType, previousKeyID, keyID, algorithm string) *SigningKeyRotatedEvent {
+	return &SigningKeyRotatedEvent{
+		BaseEvent:     NewBaseEvent(SigningKeyRotated),
+		TokenType:     tokenType,
+		PreviousKeyID: previousKeyID,
+		KeyID:         keyID,
+		Algorithm:     algorithm,
+	}
+}
+
+// NewOAuthClientSecretRegeneratedEvent creates a new OAuth client secret regenerated event
+func NewOAuthClientSecretRegeneratedEvent(clientID, name string, previousSecretExpiresAt *time.Time) *OAuthClientSecretRegeneratedEvent {
+	return &OAuthClientSecretRegeneratedEvent{
+		BaseEvent:               NewBaseEvent(OAuthClientSecretRegenerated),
+		ClientID:                clientID,
+		Name:                    name,
+		PreviousSecretExpiresAt: previousSecretExpiresAt,
+	}
+}
+
+// NewOAuthClientEvent creates a new OAuth client event of the given type
+func NewOAuthClientEvent(eventType EventType, clientID, name string, public bool, redirectURIs, scopes []string) *OAuthClientEvent {
+	return &OAuthClientEvent{
+		BaseEvent:    NewBaseEvent(eventType),
+		ClientID:     clientID,
+		Name:         name,
+		Public:       public,
+		RedirectURIs: redirectURIs,
+		Scopes:       scopes,
+	}
+}
+
+// NewUserSecuritySummaryEvent creates a new security summary event
+func NewUserSecuritySummaryEvent(userID uuid.UUID, email, username string, periodStart, periodEnd time.Time, newDevices, passwordChanges, activeSessions int) *UserSecuritySummaryEvent {
+	return &UserSecuritySummaryEvent{
+		BaseEvent:       NewBaseEvent(UserSecuritySummary),
+		UserID:          userID,
+		Email:           email,
+		Username:        username,
+		PeriodStart:     periodStart,
+		PeriodEnd:       periodEnd,
+		NewDevices:      newDevices,
+		PasswordChanges: passwordChanges,
+		ActiveSessions:  activeSessions,
+	}
+}
+
+// NewUserVerificationRequestedEvent creates a new verification requested event
+func NewUserVerificationRequestedEvent(userID uuid.UUID, email, verificationLink string, attempt int, expiresAt time.Time) *UserVerificationRequestedEvent {
+	return &UserVerificationRequestedEvent{
+		BaseEvent:        NewBaseEvent(UserVerificationRequested),
+		UserID:           userID,
+		Email:            email,
+		VerificationLink: verificationLink,
+		Attempt:          attempt,
+		ExpiresAt:        expiresAt,
+	}
+}
+
+// NewUserMagicLinkRequestedEvent creates a new magic link requested event
+func NewUserMagicLinkRequestedEvent(userID uuid.UUID, email, loginLink string, expiresAt time.Time) *UserMagicLinkRequestedEvent {
+	return &UserMagicLinkRequestedEvent{
+		BaseEvent: NewBaseEvent(UserMagicLinkRequested),
+		UserID:    userID,
+		Email:     email,
+		LoginLink: loginLink,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// NewUserRecoveryEmailVerificationRequestedEvent creates a new recovery
+// email verification requested event
+func NewUserRecoveryEmailVerificationRequestedEvent(userID uuid.UUID, recoveryEmail, verificationLink string, expiresAt time.Time) *UserRecoveryEmailVerificationRequestedEvent {
+	return &UserRecoveryEmailVerificationRequestedEvent{
+		BaseEvent:        NewBaseEvent(UserRecoveryEmailVerificationRequested),
+		UserID:           userID,
+		Email:            recoveryEmail,
+		VerificationLink: verificationLink,
+		ExpiresAt:        expiresAt,
+	}
+}
+
+// NewUserRecoveryEmailEvent creates a new recovery email event of the given type
+func NewUserRecoveryEmailEvent(eventType EventType, userID uuid.UUID, email, recoveryEmail string) *UserRecoveryEmailEvent {
+	return &UserRecoveryEmailEvent{
+		BaseEvent:     NewBaseEvent(eventType),
+		UserID:        userID,
+		Email:         email,
+		RecoveryEmail: recoveryEmail,
+	}
+}
+
+// NewUserKnowledgeFactorsEvent creates a new knowledge factors event of the given type
+func NewUserKnowledgeFactorsEvent(eventType EventType, userID uuid.UUID, email string) *UserKnowledgeFactorsEvent {
+	return &UserKnowledgeFactorsEvent{
+		BaseEvent: NewBaseEvent(eventType),
+		UserID:    userID,
+		Email:     email,
+	}
+}
+
+// NewUserIdentityEvent creates a new identity linked or unlinked event
+func NewUserIdentityEvent(eventType EventType, userID uuid.UUID, email string, identityID uuid.UUID, provider string) *UserIdentityEvent {
+	return &UserIdentityEvent{
+		BaseEvent:  NewBaseEvent(eventType),
+		UserID:     userID,
+		Email:      email,
+		IdentityID: identityID,
+		Provider:   provider,
+	}
+}
+
+// NewUserStatusChangedEvent creates a new status changed event of the given type
+func NewUserStatusChangedEvent(eventType EventType, userID uuid.UUID, email, previousStatus, status, reason string) *UserStatusChangedEvent {
+	return &UserStatusChangedEvent{
+		BaseEvent:      NewBaseEvent(eventType),
+		UserID:         userID,
+		Email:          email,
+		PreviousStatus: previousStatus,
+		Status:         status,
+		Reason:         reason,
+	}
+}
@@ -0,0 +1,39 @@
+package events
+
+import "context"
+
+// Metadata describes who triggered an event and from where
+type Metadata struct {
+	ActorID       string `json:"actorId,omitempty"`
+	ClientIP      string `json:"clientIp,omitempty"`
+	Country       string `json:"country,omitempty"` // ISO 3166-1 alpha-2 code of the client IP, when known
+	UserAgent     string `json:"userAgent,omitempty"`
+	DeviceID      string `json:"deviceId,omitempty"`
+	TraceID       string `json:"traceId,omitempty"`
+	CorrelationID string `json:"correlationId,omitempty"`
+	// TraceSampled is set when the caller records the trace, so that
+	// metrics can link to it
+	TraceSampled bool `json:"-"`
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a copy of ctx carrying the given event metadata
+func WithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, metadata)
+}
+
+// MetadataFromContext returns the event metadata stored in ctx, if any
+func MetadataFromContext(ctx context.Context) Metadata {
+	if metadata, ok := ctx.Value(metadataKey{}).(Metadata); ok {
+		return metadata
+	}
+	return Metadata{}
+}
+
+// WithActor returns a copy of ctx whose event metadata records the given actor
+func WithActor(ctx context.Context, actorID string) context.Context {
+	metadata := MetadataFromContext(ctx)
+	metadata.ActorID = actorID
+	return WithMetadata(ctx, metadata)
+}
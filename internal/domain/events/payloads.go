@@ -0,0 +1,88 @@
+package events
+
+import "reflect"
+
+// payloads maps registered event types to their payload types. Serializers
+// with schemas, such as Avro, derive the schema of each topic from them
+// before the first event is published.
+var payloads = map[EventType]interface{}{
+	UserRegistered:                         UserRegisteredEvent{},
+	UserVerified:                           UserVerifiedEvent{},
+	UserPasswordReset:                      UserPasswordResetEvent{},
+	UserPasswordChange:                     UserPasswordChangedEvent{},
+	UserDeleted:                            UserDeletedEvent{},
+	UserUpdated:                            UserUpdatedEvent{},
+	UserSecuritySummary:                    UserSecuritySummaryEvent{},
+	UserVerificationRequested:              UserVerificationRequestedEvent{},
+	UserMFAEnabled:                         UserMFAChangedEvent{},
+	UserMFADisabled:                        UserMFAChangedEvent{},
+	UserCredentialsBreached:                UserCredentialsBreachedEvent{},
+	UserLoggedIn:                           UserLoggedInEvent{},
+	UserProfileThreshold:                   UserProfileThresholdEvent{},
+	UserPurged:                             UserPurgedEvent{},
+	UserPasskeyRegistered:                  UserPasskeyEvent{},
+	UserPasskeyRemoved:                     UserPasskeyEvent{},
+	UserMagicLinkRequested:                 UserMagicLinkRequestedEvent{},
+	UserLoginNewDevice:                     UserLoginNewDeviceEvent{},
+	UserRecoveryEmailVerificationRequested: UserRecoveryEmailVerificationRequestedEvent{},
+	UserRecoveryEmailVerified:              UserRecoveryEmailEvent{},
+	UserRecoveryEmailRemoved:               UserRecoveryEmailEvent{},
+	UserKnowledgeFactorsSet:                UserKnowledgeFactorsEvent{},
+	UserKnowledgeFactorsRemoved:            UserKnowledgeFactorsEvent{},
+	UserKnowledgeFactorsRecovery:           UserKnowledgeFactorsEvent{},
+	UserIdentityLinked:                     UserIdentityEvent{},
+	UserIdentityUnlinked:                   UserIdentityEvent{},
+	SigningKeyRotated:                      SigningKeyRotatedEvent{},
+	OAuthClientSecretRegenerated:           OAuthClientSecretRegeneratedEvent{},
+	OAuthClientRegistered:                  OAuthClientEvent{},
+	OAuthClientUpdated:                     OAuthClientEvent{},
+	OAuthClientDisabled:                    OAuthClientEvent{},
+	OAuthClientEnabled:                     OAuthClientEvent{},
+	OAuthClientDeleted:                     OAuthClientEvent{},
+	AccessPolicyViolated:                   AccessPolicyViolatedEvent{},
+	UserPurgeApproved:                      UserPurgeApprovedEvent{},
+	UserPurgeRejected:                      UserPurgeRejectedEvent{},
+	ServiceAccountCreated:                  ServiceAccountCreatedEvent{},
+	APIKeyCreated:                          APIKeyEvent{},
+	APIKeyRevoked:                          APIKeyEvent{},
+	CacheInvalidated:                       CacheInvalidatedEvent{},
+	AdminPasswordResetRequested:            AdminPasswordResetEvent{},
+	AdminPasswordResetRejected:             AdminPasswordResetEvent{},
+	RoleCreated:                            RoleEvent{},
+	RoleDeleted:                            RoleEvent{},
+	RolePermissionsChanged:                 RoleEvent{},
+	UserRoleChanged:                        UserRoleChangedEvent{},
+	BootstrapTokenIssued:                   BootstrapEvent{},
+	BootstrapCompleted:                     BootstrapEvent{},
+	RegistrationRiskAssessed:               RegistrationRiskAssessedEvent{},
+	AccountRiskChanged:                     AccountRiskChangedEvent{},
+	UserActivated:                          UserStatusChangedEvent{},
+	UserSuspended:                          UserStatusChangedEvent{},
+	UserPendingVerification:                UserStatusChangedEvent{},
+	UserDeactivated:                        UserDeactivationEvent{},
+	UserRestored:                           UserDeactivationEvent{},
+	UserDeactivationPurged:                 UserDeactivationEvent{},
+	AccountFlagged:                         AccountFlaggedEvent{},
+	AccountFlagReviewed:                    AccountFlagReviewedEvent{},
+	AccountRestricted:                      AccountRestrictionEvent{},
+	AccountUnrestricted:                    AccountRestrictionEvent{},
+	OrganizationCreated:                    OrganizationCreatedEvent{},
+	OrganizationMemberInvited:              OrganizationMemberEvent{},
+	OrganizationMemberRemoved:              OrganizationMemberEvent{},
+	OrganizationMemberJoined:               OrganizationMemberEvent{},
+	OrganizationInvitationCreated:          OrganizationMemberEvent{},
+	OrganizationDomainVerified:             OrganizationDomainEvent{},
+	SSOConnectionCreated:                   SSOConnectionEvent{},
+	SSOConnectionUpdated:                   SSOConnectionEvent{},
+	SSOConnectionDeleted:                   SSOConnectionEvent{},
+	SSODomainVerified:                      SSOConnectionEvent{},
+}
+
+// PayloadType returns the payload type of a registered event type
+func (t EventType) PayloadType() (reflect.Type, bool) {
+	payload, ok := payloads[t]
+	if !ok {
+		return nil, false
+	}
+	return reflect.TypeOf(payload), true
+}
@@ -0,0 +1,145 @@
+package events
+
+import "sort"
+
+// TopicConfig describes the Kafka topic events of a type are published to
+type TopicConfig struct {
+	Topic string
+}
+
+// registry maps every event type to its topic. The publisher and the
+// consumers both resolve topics here, so that an event type cannot be
+// published to one topic and consumed from another. Each type is published
+// to the topic named after it.
+var registry = newRegistry(
+	UserRegistered,
+	UserVerified,
+	UserPasswordReset,
+	UserPasswordChange,
+	UserDeleted,
+	UserUpdated,
+	UserSecuritySummary,
+	UserVerificationRequested,
+	UserMFAEnabled,
+	UserMFADisabled,
+	UserCredentialsBreached,
+	UserLoggedIn,
+	UserProfileThreshold,
+	UserPurged,
+	UserPasskeyRegistered,
+	UserPasskeyRemoved,
+	UserMagicLinkRequested,
+	UserLoginNewDevice,
+	UserRecoveryEmailVerificationRequested,
+	UserRecoveryEmailVerified,
+	UserRecoveryEmailRemoved,
+	UserKnowledgeFactorsSet,
+	UserKnowledgeFactorsRemoved,
+	UserKnowledgeFactorsRecovery,
+	UserIdentityLinked,
+	UserIdentityUnlinked,
+	SigningKeyRotated,
+	OAuthClientSecretRegenerated,
+	OAuthClientRegistered,
+	OAuthClientUpdated,
+	OAuthClientDisabled,
+	OAuthClientEnabled,
+	OAuthClientDeleted,
+	AccessPolicyViolated,
+	UserPurgeApproved,
+	UserPurgeRejected,
+	ServiceAccountCreated,
+	APIKeyCreated,
+	APIKeyRevoked,
+	CacheInvalidated,
+	AdminPasswordResetRequested,
+	AdminPasswordResetRejected,
+	RoleCreated,
+	RoleDeleted,
+	RolePermissionsChanged,
+	UserRoleChanged,
+	BootstrapTokenIssued,
+	BootstrapCompleted,
+	RegistrationRiskAssessed,
+	AccountRiskChanged,
+	UserActivated,
+	UserSuspended,
+	UserPendingVerification,
+	UserDeactivated,
+	UserRestored,
+	UserDeactivationPurged,
+	AccountFlagged,
+	AccountFlagReviewed,
+	AccountRestricted,
+	AccountUnrestricted,
+	OrganizationCreated,
+	OrganizationMemberInvited,
+	OrganizationMemberRemoved,
+	OrganizationMemberJoined,
+	OrganizationInvitationCreated,
+	OrganizationDomainVerified,
+	SSOConnectionCreated,
+	SSOConnectionUpdated,
+	SSOConnectionDeleted,
+	SSODomainVerified,
+)
+
+// topicTypes maps topics back to the event types published to them
+var topicTypes = func() map[string]EventType {
+	types := make(map[string]EventType, len(registry))
+	for eventType, config := range registry {
+		types[config.Topic] = eventType
+	}
+	return types
+}()
+
+func newRegistry(types ...EventType) map[EventType]TopicConfig {
+	registry := make(map[EventType]TopicConfig, len(types))
+	for _, eventType := range types {
+		registry[eventType] = TopicConfig{Topic: string(eventType)}
+	}
+	return registry
+}
+
+// Registered reports whether the event type is in the registry
+func (t EventType) Registered() bool {
+	_, ok := registry[t]
+	return ok
+}
+
+// Topic returns the topic events of the type are published to. Types
+// missing from the registry are published to the topic named after them.
+func (t EventType) Topic() string {
+	if config, ok := registry[t]; ok {
+		return config.Topic
+	}
+	return string(t)
+}
+
+// TypeOfTopic returns the event type published to a topic. Topics missing
+// from the registry are taken to be named after their event type.
+func TypeOfTopic(topic string) EventType {
+	if eventType, ok := topicTypes[topic]; ok {
+		return eventType
+	}
+	return EventType(topic)
+}
+
+// Topics returns the topics to consume to receive events of the given types
+func Topics(types []EventType) []string {
+	topics := make([]string, 0, len(types))
+	for _, eventType := range types {
+		topics = append(topics, eventType.Topic())
+	}
+	return topics
+}
+
+// RegisteredTopics returns the topics of all registered event types, sorted
+func RegisteredTopics() []string {
+	topics := make([]string, 0, len(registry))
+	for _, config := range registry {
+		topics = append(topics, config.Topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
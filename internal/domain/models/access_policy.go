@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Codes of the access policy rules that deny a login
+const (
+	AccessDeniedCountry        = "country_not_allowed"
+	AccessDeniedUnknownCountry = "country_unknown"
+	AccessDeniedOutsideHours   = "outside_business_hours"
+)
+
+// weekdays are the day names business hours accept, indexed by time.Weekday
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// AccessPolicy restricts where and when the users of an organization may
+// log in. Empty rules allow every login.
+type AccessPolicy struct {
+	// AllowedCountries are ISO 3166-1 alpha-2 codes; logins from other
+	// countries, or from clients whose country is unknown, are denied
+	AllowedCountries []string       `json:"allowedCountries,omitempty"`
+	BusinessHours    *BusinessHours `json:"businessHours,omitempty"`
+}
+
+// BusinessHours is the weekly window logins are allowed in. A window ending
+// before it starts runs past midnight into the next day.
+type BusinessHours struct {
+	Timezone string   `json:"timezone"`       // IANA name, e.g. Europe/Berlin
+	Days     []string `json:"days,omitempty"` // mon to sun; empty allows every day
+	Start    string   `json:"start"`          // HH:MM, inclusive
+	End      string   `json:"end"`            // HH:MM, exclusive
+}
+
+// Validate checks that the policy can be evaluated
+func (p *AccessPolicy) Validate() error {
+	for _, country := range p.AllowedCountries {
+		if len(country) != 2 || strings.ToUpper(country) != country {
+			return fmt.Errorf("country %q is not an upper case ISO 3166-1 alpha-2 code", country)
+		}
+	}
+	if hours := p.BusinessHours; hours != nil {
+		if _, err := time.LoadLocation(hours.Timezone); err != nil || hours.Timezone == "" {
+			return fmt.Errorf("business hours time zone %q is unknown", hours.Timezone)
+		}
+		for _, day := range hours.Days {
+			if !slices.Contains(weekdays, day) {
+				return fmt.Errorf("business hours day %q must be one of mon, tue, wed, thu, fri, sat or sun", day)
+			}
+		}
+		start, err := parseClock(hours.Start)
+		if err != nil {
+			return fmt.Errorf("business hours start: %w", err)
+		}
+		end, err := parseClock(hours.End)
+		if err != nil {
+			return fmt.Errorf("business hours end: %w", err)
+		}
+		if start == end {
+			return fmt.Errorf("business hours must not start and end at the same time")
+		}
+	}
+	return nil
+}
+
+// Evaluate returns the code of the rule denying a login from the given
+// country at the given time, or an empty string when the login is allowed.
+// An empty country is unknown. Rules that cannot be evaluated deny.
+func (p *AccessPolicy) Evaluate(country string, at time.Time) string {
+	if len(p.AllowedCountries) > 0 {
+		if country == "" {
+			return AccessDeniedUnknownCountry
+		}
+		if !slices.Contains(p.AllowedCountries, strings.ToUpper(country)) {
+			return AccessDeniedCountry
+		}
+	}
+	if hours := p.BusinessHours; hours != nil && !hours.contains(at) {
+		return AccessDeniedOutsideHours
+	}
+	return ""
+}
+
+// contains reports whether a time lies within the business hours
+func (h *BusinessHours) contains(at time.Time) bool {
+	location, err := time.LoadLocation(h.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err := parseClock(h.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(h.End)
+	if err != nil {
+		return false
+	}
+
+	local := at.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	day := local.Weekday()
+	if start < end {
+		return minute >= start && minute < end && h.allowsDay(day)
+	}
+	// Past midnight the window belongs to the day it started on
+	if minute >= start {
+		return h.allowsDay(day)
+	}
+	return minute < end && h.allowsDay((day+6)%7)
+}
+
+// allowsDay reports whether the window opens on a weekday
+func (h *BusinessHours) allowsDay(day time.Weekday) bool {
+	return len(h.Days) == 0 || slices.Contains(h.Days, weekdays[day])
+}
+
+// parseClock parses HH:MM into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a HH:MM time", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
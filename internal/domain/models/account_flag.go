@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FlagCategory is why an account was flagged
+type FlagCategory string
+
+const (
+	FlagCategorySpam  FlagCategory = "spam"
+	FlagCategoryFraud FlagCategory = "fraud"
+	FlagCategoryAbuse FlagCategory = "abuse"
+	FlagCategoryOther FlagCategory = "other"
+)
+
+// IsValid reports whether the category is known
+func (c FlagCategory) IsValid() bool {
+	switch c {
+	case FlagCategorySpam, FlagCategoryFraud, FlagCategoryAbuse, FlagCategoryOther:
+		return true
+	default:
+		return false
+	}
+}
+
+// FlagStatus is where a flag stands in the review queue
+type FlagStatus string
+
+const (
+	// FlagStatusOpen flags wait for an admin's review
+	FlagStatusOpen FlagStatus = "open"
+	// FlagStatusConfirmed flags were upheld by an admin
+	FlagStatusConfirmed FlagStatus = "confirmed"
+	// FlagStatusDismissed flags were rejected or lifted by an admin
+	FlagStatusDismissed FlagStatus = "dismissed"
+)
+
+// IsValid reports whether the status is known
+func (s FlagStatus) IsValid() bool {
+	return s == FlagStatusOpen || s == FlagStatusConfirmed || s == FlagStatusDismissed
+}
+
+// AccountFlag is a report of an account for spam, fraud or other abuse,
+// raised by another user or an admin
+type AccountFlag struct {
+	ID         uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`
+	UserID     uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Category   FlagCategory `gorm:"type:varchar(20);not null" json:"category"`
+	Reason     string       `gorm:"type:text;not null" json:"reason"`
+	ReportedBy uuid.UUID    `gorm:"type:uuid;not null" json:"reported_by"`
+	ByAdmin    bool         `gorm:"not null;default:false" json:"by_admin"` // raised through the admin API
+	Status     FlagStatus   `gorm:"type:varchar(20);not null;index" json:"status"`
+	ReviewedBy *uuid.UUID   `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewNote string       `gorm:"type:text" json:"review_note,omitempty"`
+	ReviewedAt *time.Time   `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time    `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time    `gorm:"not null" json:"updated_at"`
+}
+
+// TableName specifies the table name for the AccountFlag model
+func (AccountFlag) TableName() string {
+	return "account_flags"
+}
+
+// IsActive reports whether the flag still counts against the account
+func (f *AccountFlag) IsActive() bool {
+	return f.Status == FlagStatusOpen || f.Status == FlagStatusConfirmed
+}
+
+// Review records an admin's decision on the flag. Open flags can be
+// confirmed or dismissed; confirmed flags can be lifted by dismissing them.
+func (f *AccountFlag) Review(status FlagStatus, reviewerID uuid.UUID, note string) bool {
+	switch {
+	case f.Status == FlagStatusOpen && (status == FlagStatusConfirmed || status == FlagStatusDismissed):
+	case f.Status == FlagStatusConfirmed && status == FlagStatusDismissed:
+	default:
+		return false
+	}
+	now := time.Now()
+	f.Status = status
+	f.ReviewedBy = &reviewerID
+	f.ReviewNote = note
+	f.ReviewedAt = &now
+	return true
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// AccountRiskState is how likely an account is under attack. Failed logins
+// and logins from new devices or countries raise it; it falls back to
+// normal once the raised state expires.
+type AccountRiskState string
+
+const (
+	// AccountRiskNormal logins need nothing beyond the usual factors
+	AccountRiskNormal AccountRiskState = "normal"
+	// AccountRiskWatch logins have to solve a CAPTCHA
+	AccountRiskWatch AccountRiskState = "watch"
+	// AccountRiskRestricted logins have to solve a CAPTCHA and pass a
+	// second factor; users without one cannot sign in
+	AccountRiskRestricted AccountRiskState = "restricted"
+)
+
+func (s AccountRiskState) level() int {
+	switch s {
+	case AccountRiskWatch:
+		return 1
+	case AccountRiskRestricted:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Exceeds reports whether the state is a higher risk than other
+func (s AccountRiskState) Exceeds(other AccountRiskState) bool {
+	return s.level() > other.level()
+}
+
+// AccountRisk is the risk state of an account, why it was raised and until
+// when it holds
+type AccountRisk struct {
+	State  AccountRiskState `json:"state"`
+	Reason string           `json:"reason,omitempty"`
+	Until  *time.Time       `json:"until,omitempty"`
+}
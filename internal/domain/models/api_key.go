@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a credential a service account authenticates machine-to-machine
+// requests with. Only the hash of the key is stored; the key itself is
+// shown once when it is created.
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"` // the service account
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"` // start of the key, to tell keys apart
+	KeyHash    string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	CreatedBy  uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	NotBefore  *time.Time `json:"not_before,omitempty"` // nil is active at once
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // nil never expires
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"not null" json:"created_at"`
+}
+
+// TableName specifies the table name for the APIKey model
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsValid reports whether the key can authenticate requests at the given time
+func (k *APIKey) IsValid(at time.Time) bool {
+	return k.RevokedAt == nil &&
+		(k.NotBefore == nil || !at.Before(*k.NotBefore)) &&
+		(k.ExpiresAt == nil || at.Before(*k.ExpiresAt))
+}
@@ -0,0 +1,47 @@
+package models
+
+import "strings"
+
+// Ownership of an email domain is proven with a DNS TXT record named by the
+// record prefix and the domain, whose value is the value prefix and a
+// verification token
+const (
+	DomainVerificationRecordPrefix = "_identity-verification."
+	DomainVerificationValuePrefix  = "identity-verification="
+)
+
+// DomainVerificationRecord returns the name and value of the DNS TXT record
+// that proves ownership of domain with token
+func DomainVerificationRecord(domain, token string) (name, value string) {
+	return DomainVerificationRecordPrefix + domain, DomainVerificationValuePrefix + token
+}
+
+// NormalizeDomain lowercases a domain and reports whether it is a valid
+// host name with at least two labels
+func NormalizeDomain(domain string) (string, bool) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if len(domain) > 253 || !strings.Contains(domain, ".") {
+		return domain, false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return domain, false
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return domain, false
+			}
+		}
+	}
+	return domain, true
+}
+
+// EmailDomain returns the lowercased domain of an email address, or an
+// empty string when it has none
+func EmailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(email[at+1:]), "."))
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailVerificationStatus is the state of a verification email
+type EmailVerificationStatus string
+
+const (
+	// EmailVerificationSent is a verification email whose link has not been used yet
+	EmailVerificationSent EmailVerificationStatus = "sent"
+	// EmailVerificationClicked is a verification email whose link was used
+	EmailVerificationClicked EmailVerificationStatus = "clicked"
+	// EmailVerificationExpired is a verification email whose link expired or was superseded
+	EmailVerificationExpired EmailVerificationStatus = "expired"
+)
+
+// EmailVerification records a verification email sent to a user
+type EmailVerification struct {
+	ID        uuid.UUID               `gorm:"type:uuid;primary_key" json:"id"`
+	UserID    uuid.UUID               `gorm:"type:uuid;not null;index" json:"user_id"`
+	Email     string                  `gorm:"type:varchar(255);not null" json:"email"`
+	TokenHash string                  `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	Status    EmailVerificationStatus `gorm:"type:varchar(20);not null" json:"status"`
+	SentAt    time.Time               `gorm:"not null" json:"sent_at"`
+	ExpiresAt time.Time               `gorm:"not null" json:"expires_at"`
+	ClickedAt *time.Time              `json:"clicked_at,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (v *EmailVerification) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = NewID()
+	}
+	if v.SentAt.IsZero() {
+		v.SentAt = time.Now()
+	}
+	return nil
+}
+
+// TableName specifies the table name for the EmailVerification model
+func (EmailVerification) TableName() string {
+	return "email_verifications"
+}
+
+// NewEmailVerification records a verification email that was just sent
+func NewEmailVerification(userID uuid.UUID, email, tokenHash string, ttl time.Duration) *EmailVerification {
+	now := time.Now()
+	return &EmailVerification{
+		UserID:    userID,
+		Email:     email,
+		TokenHash: tokenHash,
+		Status:    EmailVerificationSent,
+		SentAt:    now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+// CurrentStatus returns the status of the verification, treating sent links
+// past their expiry as expired
+func (v *EmailVerification) CurrentStatus() EmailVerificationStatus {
+	if v.Status == EmailVerificationSent && time.Now().After(v.ExpiresAt) {
+		return EmailVerificationExpired
+	}
+	return v.Status
+}
+
+// MarkClicked records that the verification link was used
+func (v *EmailVerification) MarkClicked() {
+	now := time.Now()
+	v.Status = EmailVerificationClicked
+	v.ClickedAt = &now
+}
+
+// EmailVerificationState summarizes a user's verification emails for support staff
+type EmailVerificationState struct {
+	UserID        uuid.UUID
+	EmailVerified bool
+	SentToday     int // verification emails sent in the last 24 hours
+	DailyLimit    int
+	Attempts      []*EmailVerification // most recent first
+}
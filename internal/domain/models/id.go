@@ -0,0 +1,35 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// newID generates the IDs of new entities; UUIDv4 by default
+var newID = uuid.New
+
+// SetIDVersion selects the UUID version of the IDs of new entities: 4 for
+// random IDs or 7 for time-ordered IDs, which keep primary key indexes
+// compact under heavy inserts. Both versions share the uuid column type, so
+// existing IDs stay valid when the version changes. It must be called
+// before entities are created.
+func SetIDVersion(version int) error {
+	switch version {
+	case 0, 4:
+		newID = uuid.New
+	case 7:
+		newID = func() uuid.UUID {
+			// NewV7 only fails when the random source does
+			return uuid.Must(uuid.NewV7())
+		}
+	default:
+		return fmt.Errorf("unsupported ID version %d", version)
+	}
+	return nil
+}
+
+// NewID returns the ID of a new entity in the selected UUID version
+func NewID() uuid.UUID {
+	return newID()
+}
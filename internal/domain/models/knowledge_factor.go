@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KnowledgeFactor is a user's answer to a security question, which can
+// recover their account. The question is kept as shown to the user so that
+// answers stay valid when the configured questions change.
+type KnowledgeFactor struct {
+	UserID     uuid.UUID `gorm:"type:uuid;primary_key" json:"user_id"`
+	Question   string    `gorm:"type:varchar(255);primary_key" json:"question"`
+	AnswerHash string    `gorm:"type:varchar(255);not null" json:"-"`
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+}
+
+// TableName specifies the table name for the KnowledgeFactor model
+func (KnowledgeFactor) TableName() string {
+	return "knowledge_factors"
+}
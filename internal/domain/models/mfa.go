@@ -0,0 +1,61 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTPCredential is a user's authenticator app secret. The secret is needed
+// in plain text to compute codes, so it is stored as is.
+type TOTPCredential struct {
+	UserID uuid.UUID `gorm:"type:uuid;primary_key" json:"user_id"`
+	Secret string    `gorm:"not null" json:"-"` // base32 encoded
+	// ConfirmedAt is set once the user proved they set up the authenticator;
+	// unconfirmed credentials are not required at login
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	// LastUsedStep is the time step of the last accepted code, so a code
+	// cannot be replayed within its validity window
+	LastUsedStep int64     `gorm:"not null;default:0" json:"-"`
+	CreatedAt    time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// TableName specifies the table name for the TOTPCredential model
+func (TOTPCredential) TableName() string {
+	return "totp_credentials"
+}
+
+// Confirmed reports whether the credential was confirmed with a valid code
+func (c *TOTPCredential) Confirmed() bool {
+	return c.ConfirmedAt != nil
+}
+
+// MFAPolicy requires the users it covers to sign in with a second factor
+// once EnforceAfter has passed. Until then they are reminded at login.
+type MFAPolicy struct {
+	ID   uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name string    `gorm:"not null" json:"name"`
+	// OrganizationID limits the policy to the members of an organization;
+	// nil covers every user
+	OrganizationID *uuid.UUID `gorm:"type:uuid;index" json:"organization_id,omitempty"`
+	// Roles limits the policy to users with one of the roles; empty covers every role
+	Roles        []Role    `gorm:"serializer:json" json:"roles,omitempty"`
+	EnforceAfter time.Time `gorm:"not null" json:"enforce_after"`
+	CreatedAt    time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// TableName specifies the table name for the MFAPolicy model
+func (MFAPolicy) TableName() string {
+	return "mfa_policies"
+}
+
+// AppliesTo reports whether the policy covers the user
+func (p *MFAPolicy) AppliesTo(user *User) bool {
+	if p.OrganizationID != nil && (user.OrganizationID == nil || *user.OrganizationID != *p.OrganizationID) {
+		return false
+	}
+	return len(p.Roles) == 0 || slices.Contains(p.Roles, user.Role)
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookFormat is the payload format a notification webhook expects
+type WebhookFormat string
+
+const (
+	// WebhookFormatJSON posts the notification as JSON, signed with the
+	// webhook's secret
+	WebhookFormatJSON WebhookFormat = "json"
+	// WebhookFormatSlack posts a message to a Slack incoming webhook
+	WebhookFormatSlack WebhookFormat = "slack"
+)
+
+// IsValid reports whether the format is known
+func (f WebhookFormat) IsValid() bool {
+	return f == WebhookFormatJSON || f == WebhookFormatSlack
+}
+
+// NotificationWebhook receives real-time notifications of logins and
+// security changes on a user's account
+type NotificationWebhook struct {
+	ID     uuid.UUID     `gorm:"type:uuid;primary_key" json:"id"`
+	UserID uuid.UUID     `gorm:"type:uuid;not null;index" json:"user_id"`
+	URL    string        `gorm:"type:text;not null" json:"url"`
+	Format WebhookFormat `gorm:"type:varchar(20);not null" json:"format"`
+	// Secret signs JSON payloads so receivers can verify their origin
+	Secret string `gorm:"not null" json:"-"`
+	// EventTypes limits the notifications to these events; empty receives all
+	EventTypes []string  `gorm:"serializer:json" json:"event_types,omitempty"`
+	CreatedBy  uuid.UUID `gorm:"type:uuid;not null" json:"created_by"` // the user or an admin
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// TableName specifies the table name for the NotificationWebhook model
+func (NotificationWebhook) TableName() string {
+	return "notification_webhooks"
+}
+
+// Wants reports whether the webhook is notified of the given event type
+func (w *NotificationWebhook) Wants(eventType string) bool {
+	return len(w.EventTypes) == 0 || slices.Contains(w.EventTypes, eventType)
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthGrantType is an OAuth 2.0 grant a client may use to obtain tokens
+type OAuthGrantType string
+
+const (
+	// GrantAuthorizationCode signs users in to the client through the authorization endpoint
+	GrantAuthorizationCode OAuthGrantType = "authorization_code"
+	// GrantClientCredentials issues tokens to the client itself, without a user
+	GrantClientCredentials OAuthGrantType = "client_credentials"
+)
+
+// OAuthClient is an application registered with the OAuth 2.0 / OpenID
+// Connect provider. Public clients, such as single-page and mobile apps, have
+// no secret and must use PKCE.
+type OAuthClient struct {
+	ID           uuid.UUID        `gorm:"type:uuid;primary_key" json:"id"`
+	ClientID     string           `gorm:"type:varchar(64);not null;uniqueIndex" json:"client_id"`
+	SecretHash   string           `gorm:"type:varchar(64)" json:"-"` // empty for public clients
+	Name         string           `gorm:"type:varchar(255);not null" json:"name"`
+	RedirectURIs []string         `gorm:"type:jsonb;serializer:json;not null" json:"redirect_uris"`
+	GrantTypes   []OAuthGrantType `gorm:"type:jsonb;serializer:json;not null" json:"grant_types"`
+	Scopes       []string         `gorm:"type:jsonb;serializer:json;not null" json:"scopes"`
+	// PreviousSecretHash is the secret replaced by the last rotation, which
+	// keeps working until PreviousSecretExpiresAt so that deployments of the
+	// client can switch over
+	PreviousSecretHash      string     `gorm:"type:varchar(64)" json:"-"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	// DisabledAt is set while the client may not authenticate or sign users in
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"not null" json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (c *OAuthClient) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = NewID()
+	}
+	return nil
+}
+
+// TableName specifies the table name for the OAuthClient model
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// Confidential reports whether the client authenticates with a secret
+func (c *OAuthClient) Confidential() bool {
+	return c.SecretHash != ""
+}
+
+// Disabled reports whether the client was disabled
+func (c *OAuthClient) Disabled() bool {
+	return c.DisabledAt != nil
+}
+
+// SetSecret replaces the client secret. Only its hash is stored; secrets are
+// random and long, so a fast hash is sufficient.
+func (c *OAuthClient) SetSecret(secret string) {
+	c.SecretHash = hashClientSecret(secret)
+}
+
+// RotateSecret replaces the client secret, keeping the old one valid for
+// overlap. A rotation without overlap revokes the old secret immediately.
+func (c *OAuthClient) RotateSecret(secret string, overlap time.Duration) {
+	c.PreviousSecretHash = ""
+	c.PreviousSecretExpiresAt = nil
+	if overlap > 0 {
+		expiresAt := time.Now().Add(overlap)
+		c.PreviousSecretHash = c.SecretHash
+		c.PreviousSecretExpiresAt = &expiresAt
+	}
+	c.SetSecret(secret)
+}
+
+// VerifySecret reports whether secret is the client secret, or the previous
+// secret while its overlap window lasts
+func (c *OAuthClient) VerifySecret(secret string) bool {
+	if !c.Confidential() || secret == "" {
+		return false
+	}
+	hash := []byte(hashClientSecret(secret))
+	if subtle.ConstantTimeCompare([]byte(c.SecretHash), hash) == 1 {
+		return true
+	}
+	return c.PreviousSecretHash != "" && c.PreviousSecretExpiresAt != nil && time.Now().Before(*c.PreviousSecretExpiresAt) &&
+		subtle.ConstantTimeCompare([]byte(c.PreviousSecretHash), hash) == 1
+}
+
+// AllowsGrant reports whether the client may use the given grant type
+func (c *OAuthClient) AllowsGrant(grantType OAuthGrantType) bool {
+	for _, allowed := range c.GrantTypes {
+		if allowed == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsRedirectURI reports whether uri exactly matches a registered redirect URI
+func (c *OAuthClient) AllowsRedirectURI(uri string) bool {
+	for _, allowed := range c.RedirectURIs {
+		if allowed == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsScopes reports whether every scope was registered for the client
+func (c *OAuthClient) AllowsScopes(scopes []string) bool {
+	for _, scope := range scopes {
+		allowed := false
+		for _, registered := range c.Scopes {
+			if scope == registered {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+func hashClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
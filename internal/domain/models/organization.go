@@ -0,0 +1,131 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrganizationRole is the role of a member within an organization. It is
+// separate from the user's role, which applies across the service.
+type OrganizationRole string
+
+const (
+	// OrganizationRoleOwner manages the organization, its members and its owners
+	OrganizationRoleOwner OrganizationRole = "owner"
+	// OrganizationRoleAdmin manages the members of the organization
+	OrganizationRoleAdmin OrganizationRole = "admin"
+	// OrganizationRoleMember belongs to the organization
+	OrganizationRoleMember OrganizationRole = "member"
+)
+
+// IsValid reports whether the role is a known organization role
+func (r OrganizationRole) IsValid() bool {
+	switch r {
+	case OrganizationRoleOwner, OrganizationRoleAdmin, OrganizationRoleMember:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanManageMembers reports whether members with the role may invite and
+// remove members
+func (r OrganizationRole) CanManageMembers() bool {
+	return r == OrganizationRoleOwner || r == OrganizationRoleAdmin
+}
+
+// DomainJoinPolicy decides what happens to users whose verified email is in
+// one of an organization's verified domains
+type DomainJoinPolicy string
+
+const (
+	// DomainJoinPolicyOff leaves such users alone
+	DomainJoinPolicyOff DomainJoinPolicy = "off"
+	// DomainJoinPolicyInvite invites them, and they become members when
+	// they accept
+	DomainJoinPolicyInvite DomainJoinPolicy = "invite"
+	// DomainJoinPolicyJoin makes them members
+	DomainJoinPolicyJoin DomainJoinPolicy = "join"
+)
+
+// IsValid reports whether the policy is a known domain join policy
+func (p DomainJoinPolicy) IsValid() bool {
+	switch p {
+	case DomainJoinPolicyOff, DomainJoinPolicyInvite, DomainJoinPolicyJoin:
+		return true
+	default:
+		return false
+	}
+}
+
+// Organization is a tenant whose members share its settings
+type Organization struct {
+	ID               uuid.UUID        `gorm:"type:uuid;primary_key" json:"id"`
+	Name             string           `gorm:"type:varchar(100);not null" json:"name"`
+	DomainJoinPolicy DomainJoinPolicy `gorm:"type:varchar(20);not null;default:off" json:"domain_join_policy"`
+	CreatedBy        *uuid.UUID       `gorm:"type:uuid" json:"created_by,omitempty"` // nil for organizations that predate their records
+	CreatedAt        time.Time        `gorm:"not null" json:"created_at"`
+	UpdatedAt        time.Time        `gorm:"not null" json:"updated_at"`
+}
+
+// TableName specifies the table name for the Organization model
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// OrganizationMembership makes a user a member of an organization
+type OrganizationMembership struct {
+	OrganizationID uuid.UUID        `gorm:"type:uuid;primary_key" json:"organization_id"`
+	UserID         uuid.UUID        `gorm:"type:uuid;primary_key" json:"user_id"`
+	Role           OrganizationRole `gorm:"type:varchar(20);not null" json:"role"`
+	InvitedBy      *uuid.UUID       `gorm:"type:uuid" json:"invited_by,omitempty"`
+	CreatedAt      time.Time        `gorm:"not null" json:"created_at"`
+}
+
+// TableName specifies the table name for the OrganizationMembership model
+func (OrganizationMembership) TableName() string {
+	return "organization_memberships"
+}
+
+// OrganizationDomain is an email domain claimed by an organization. Once the
+// organization proves it owns the domain by publishing the verification
+// token in DNS, users with verified emails in it are handled by the
+// organization's domain join policy.
+type OrganizationDomain struct {
+	OrganizationID    uuid.UUID  `gorm:"type:uuid;primary_key" json:"organization_id"`
+	Domain            string     `gorm:"type:varchar(253);primary_key" json:"domain"`
+	VerificationToken string     `gorm:"type:varchar(64);not null" json:"verification_token"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+	CreatedAt         time.Time  `gorm:"not null" json:"created_at"`
+}
+
+// TableName specifies the table name for the OrganizationDomain model
+func (OrganizationDomain) TableName() string {
+	return "organization_domains"
+}
+
+// IsVerified reports whether ownership of the domain was proven
+func (d *OrganizationDomain) IsVerified() bool {
+	return d.VerifiedAt != nil
+}
+
+// VerificationRecord returns the name and value of the DNS TXT record that
+// proves ownership of the domain
+func (d *OrganizationDomain) VerificationRecord() (name, value string) {
+	return DomainVerificationRecord(d.Domain, d.VerificationToken)
+}
+
+// OrganizationInvitation invites a user into an organization. The user
+// becomes a member with the role when they accept.
+type OrganizationInvitation struct {
+	OrganizationID uuid.UUID        `gorm:"type:uuid;primary_key" json:"organization_id"`
+	UserID         uuid.UUID        `gorm:"type:uuid;primary_key" json:"user_id"`
+	Role           OrganizationRole `gorm:"type:varchar(20);not null" json:"role"`
+	CreatedAt      time.Time        `gorm:"not null" json:"created_at"`
+}
+
+// TableName specifies the table name for the OrganizationInvitation model
+func (OrganizationInvitation) TableName() string {
+	return "organization_invitations"
+}
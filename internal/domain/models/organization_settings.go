@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Email templates an organization can override
+const (
+	EmailTemplateVerification  = "verification"
+	EmailTemplatePasswordReset = "password_reset"
+	EmailTemplateWelcome       = "welcome"
+	EmailTemplateMagicLink     = "magic_link"
+	EmailTemplateRecoveryEmail = "recovery_email"
+	EmailTemplateNewDevice     = "new_device"
+)
+
+// EmailTemplates are the names of the email templates an organization can override
+var EmailTemplates = []string{EmailTemplateVerification, EmailTemplatePasswordReset, EmailTemplateWelcome, EmailTemplateMagicLink, EmailTemplateRecoveryEmail, EmailTemplateNewDevice}
+
+// PasswordPolicy is the password strength policy of an organization
+type PasswordPolicy struct {
+	MinLength           int  `json:"minLength"`
+	MaxLength           int  `json:"maxLength"`
+	RequireUppercase    bool `json:"requireUppercase"`
+	RequireLowercase    bool `json:"requireLowercase"`
+	RequireNumbers      bool `json:"requireNumbers"`
+	RequireSpecialChars bool `json:"requireSpecialChars"`
+}
+
+// EmailTemplate is an organization's version of an email. Subject and
+// bodies are Go templates rendered by the email sender.
+type EmailTemplate struct {
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"htmlBody,omitempty"`
+	TextBody string `json:"textBody,omitempty"`
+}
+
+// OrganizationSettings holds the settings an organization overrides for its
+// users. Nil fields and missing templates inherit the service-wide
+// configuration.
+type OrganizationSettings struct {
+	OrganizationID         uuid.UUID                `gorm:"type:uuid;primary_key" json:"organization_id"`
+	PasswordPolicy         *PasswordPolicy          `gorm:"type:jsonb;serializer:json" json:"password_policy,omitempty"`
+	AccessTokenTTLSeconds  *int                     `json:"access_token_ttl_seconds,omitempty"`  // may only shorten the configured lifetime
+	RefreshTokenTTLSeconds *int                     `json:"refresh_token_ttl_seconds,omitempty"` // may only shorten the configured lifetime
+	RequireMFA             *bool                    `gorm:"column:require_mfa" json:"require_mfa,omitempty"`
+	MaxSessions            *int                     `json:"max_sessions,omitempty"` // concurrent sessions per user
+	SessionLimitAction     *SessionLimitAction      `gorm:"type:varchar(20)" json:"session_limit_action,omitempty"`
+	EmailTemplates         map[string]EmailTemplate `gorm:"type:jsonb;serializer:json" json:"email_templates,omitempty"`
+	AccessPolicy           *AccessPolicy            `gorm:"type:jsonb;serializer:json" json:"access_policy,omitempty"`
+	SecuritySummaries      *bool                    `json:"security_summaries,omitempty"` // monthly security summary events
+	CreatedAt              time.Time                `gorm:"not null" json:"created_at"`
+	UpdatedAt              time.Time                `gorm:"not null" json:"updated_at"`
+}
+
+// TableName specifies the table name for the OrganizationSettings model
+func (OrganizationSettings) TableName() string {
+	return "organization_settings"
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Permission is an action a user may perform
+type Permission string
+
+const (
+	// PermissionProfileRead allows reading the own profile
+	PermissionProfileRead Permission = "profile:read"
+	// PermissionProfileWrite allows changing the own profile and password
+	PermissionProfileWrite Permission = "profile:write"
+	// PermissionUsersRead allows reading any user and their history
+	PermissionUsersRead Permission = "users:read"
+	// PermissionUsersWrite allows changing any user, including their status
+	PermissionUsersWrite Permission = "users:write"
+	// PermissionUsersPurge allows approving and carrying out user purges
+	PermissionUsersPurge Permission = "users:purge"
+	// PermissionRolesRead allows listing roles and their permissions
+	PermissionRolesRead Permission = "roles:read"
+	// PermissionRolesWrite allows managing roles and assigning them to users
+	PermissionRolesWrite Permission = "roles:write"
+	// PermissionServiceAccountsWrite allows managing service accounts and their API keys
+	PermissionServiceAccountsWrite Permission = "service_accounts:write"
+	// PermissionOAuthClientsWrite allows managing OAuth clients
+	PermissionOAuthClientsWrite Permission = "oauth_clients:write"
+	// PermissionOrganizationsWrite allows managing organization settings
+	PermissionOrganizationsWrite Permission = "organizations:write"
+	// PermissionMFAPoliciesWrite allows managing MFA enforcement policies
+	PermissionMFAPoliciesWrite Permission = "mfa_policies:write"
+	// PermissionCacheAdmin allows inspecting and invalidating the cache
+	PermissionCacheAdmin Permission = "cache:admin"
+	// PermissionAuditLogRead allows verifying the audit log
+	PermissionAuditLogRead Permission = "audit_log:read"
+	// PermissionCredentialBreachesWrite allows importing breached credentials
+	PermissionCredentialBreachesWrite Permission = "credential_breaches:write"
+	// PermissionSigningKeysRead allows listing token signing keys
+	PermissionSigningKeysRead Permission = "signing_keys:read"
+	// PermissionSigningKeysRotate allows rotating token signing keys
+	PermissionSigningKeysRotate Permission = "signing_keys:rotate"
+	// PermissionServiceModeRead allows reading the service mode
+	PermissionServiceModeRead Permission = "service_mode:read"
+	// PermissionServiceModeWrite allows changing the service mode
+	PermissionServiceModeWrite Permission = "service_mode:write"
+	// PermissionConfigRead allows reading the effective configuration
+	PermissionConfigRead Permission = "config:read"
+)
+
+// allPermissions lists every permission roles can grant
+var allPermissions = []Permission{
+	PermissionProfileRead,
+	PermissionProfileWrite,
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionUsersPurge,
+	PermissionRolesRead,
+	PermissionRolesWrite,
+	PermissionServiceAccountsWrite,
+	PermissionOAuthClientsWrite,
+	PermissionOrganizationsWrite,
+	PermissionMFAPoliciesWrite,
+	PermissionCacheAdmin,
+	PermissionAuditLogRead,
+	PermissionCredentialBreachesWrite,
+	PermissionSigningKeysRead,
+	PermissionSigningKeysRotate,
+	PermissionServiceModeRead,
+	PermissionServiceModeWrite,
+	PermissionConfigRead,
+}
+
+// AllPermissions returns every permission roles can grant
+func AllPermissions() []Permission {
+	return append([]Permission(nil), allPermissions...)
+}
+
+// IsValid reports whether the permission is one roles can grant
+func (p Permission) IsValid() bool {
+	return slices.Contains(allPermissions, p)
+}
+
+// defaultRolePermissions lists the permissions the built-in roles are
+// created with. The roles table is authoritative once they exist.
+var defaultRolePermissions = map[Role][]Permission{
+	RoleUser: {
+		PermissionProfileRead,
+		PermissionProfileWrite,
+	},
+	RoleAdmin: allPermissions,
+}
+
+// DefaultPermissions returns the permissions a built-in role is created
+// with, and nothing for other roles. It is the fallback for tokens issued
+// without a permission set.
+func (r Role) DefaultPermissions() []Permission {
+	return append([]Permission(nil), defaultRolePermissions[r]...)
+}
+
+// PermissionGrant is a permission together with what granted it
+type PermissionGrant struct {
+	Permission Permission
+	Sources    []string // e.g. "role:admin"
+}
+
+// EffectivePermissions is the permission set a user currently holds
+type EffectivePermissions struct {
+	UserID uuid.UUID
+	Status UserStatus
+	Roles  []Role
+	// Active is false when the user cannot authenticate, in which case the
+	// user holds no permissions
+	Active bool
+	Grants []PermissionGrant
+}
+
+// EffectivePermissions resolves the user's roles into the concrete
+// permissions they grant, given the permissions of each role, sorted by
+// permission
+func (u *User) EffectivePermissions(rolePermissions map[Role][]Permission) *EffectivePermissions {
+	effective := &EffectivePermissions{
+		UserID: u.ID,
+		Status: u.Status,
+		Roles:  []Role{u.Role},
+		Active: u.Status.CanAuthenticate(),
+		Grants: []PermissionGrant{},
+	}
+	if !effective.Active {
+		return effective
+	}
+
+	grants := make(map[Permission]*PermissionGrant)
+	for _, role := range effective.Roles {
+		for _, permission := range rolePermissions[role] {
+			grant, ok := grants[permission]
+			if !ok {
+				grant = &PermissionGrant{Permission: permission}
+				grants[permission] = grant
+			}
+			grant.Sources = append(grant.Sources, "role:"+string(role))
+		}
+	}
+
+	for _, grant := range grants {
+		effective.Grants = append(effective.Grants, *grant)
+	}
+	sort.Slice(effective.Grants, func(i, j int) bool {
+		return effective.Grants[i].Permission < effective.Grants[j].Permission
+	})
+	return effective
+}
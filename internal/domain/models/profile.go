@@ -0,0 +1,129 @@
+package models
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"time"
+	_ "time/tzdata" // time zones are validated even where the system has no database
+)
+
+// Profile fields that count towards profile completeness
+const (
+	ProfileFieldFirstName     = "first_name"
+	ProfileFieldLastName      = "last_name"
+	ProfileFieldPhoneNumber   = "phone_number"
+	ProfileFieldLocale        = "locale"
+	ProfileFieldEmailVerified = "email_verified"
+)
+
+// Profile fields users can set that do not count towards completeness
+const (
+	ProfileFieldTimeZone  = "time_zone"
+	ProfileFieldAvatarURL = "avatar_url"
+
+	ProfileFieldMarketingOptOut = "marketing_opt_out"
+)
+
+// maxAvatarURLLength bounds the avatar URL users can set
+const maxAvatarURLLength = 2048
+
+// ProfileFields are the fields profile completeness can be measured over
+var ProfileFields = []string{
+	ProfileFieldFirstName,
+	ProfileFieldLastName,
+	ProfileFieldPhoneNumber,
+	ProfileFieldLocale,
+	ProfileFieldEmailVerified,
+}
+
+var (
+	phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+	localePattern      = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+)
+
+// ProfileCompleteness reports how much of a user's profile is filled in
+type ProfileCompleteness struct {
+	Percent  int      `json:"percent"`
+	Fields   []string `json:"fields"`   // fields completeness is measured over
+	Missing  []string `json:"missing"`  // fields not filled in yet
+	Required []string `json:"required"` // missing fields the user must still provide
+}
+
+// IsProfileField reports whether a name is one of ProfileFields
+func IsProfileField(field string) bool {
+	return slices.Contains(ProfileFields, field)
+}
+
+// IsValidPhoneNumber reports whether a phone number is in E.164 format
+func IsValidPhoneNumber(number string) bool {
+	return phoneNumberPattern.MatchString(number)
+}
+
+// IsValidLocale reports whether a locale looks like a BCP 47 language tag
+func IsValidLocale(locale string) bool {
+	return len(locale) <= 35 && localePattern.MatchString(locale)
+}
+
+// IsValidTimeZone reports whether a time zone is an IANA time zone name,
+// e.g. Europe/Berlin
+func IsValidTimeZone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// IsValidAvatarURL reports whether an avatar URL is an absolute HTTPS URL
+func IsValidAvatarURL(avatarURL string) bool {
+	if len(avatarURL) > maxAvatarURLLength {
+		return false
+	}
+	u, err := url.Parse(avatarURL)
+	return err == nil && u.Scheme == "https" && u.Host != "" && u.User == nil
+}
+
+// HasProfileField reports whether a profile field of the user is filled in
+func (u *User) HasProfileField(field string) bool {
+	switch field {
+	case ProfileFieldFirstName:
+		return u.FirstName != ""
+	case ProfileFieldLastName:
+		return u.LastName != ""
+	case ProfileFieldPhoneNumber:
+		return u.PhoneNumber != ""
+	case ProfileFieldLocale:
+		return u.Locale != ""
+	case ProfileFieldEmailVerified:
+		return u.EmailVerified
+	default:
+		return false
+	}
+}
+
+// ProfileCompleteness measures the user's profile over the given fields,
+// of which required ones must be provided. Without fields the profile is
+// complete.
+func (u *User) ProfileCompleteness(fields, required []string) *ProfileCompleteness {
+	completeness := &ProfileCompleteness{
+		Percent:  100,
+		Fields:   fields,
+		Missing:  []string{},
+		Required: []string{},
+	}
+	if len(fields) == 0 {
+		return completeness
+	}
+	for _, field := range fields {
+		if u.HasProfileField(field) {
+			continue
+		}
+		completeness.Missing = append(completeness.Missing, field)
+		if slices.Contains(required, field) {
+			completeness.Required = append(completeness.Required, field)
+		}
+	}
+	completeness.Percent = (len(fields) - len(completeness.Missing)) * 100 / len(fields)
+	return completeness
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PublicProfile is the part of a user that may be shown to other users
+type PublicProfile struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	EmailHash string    `json:"emailHash"`           // identifies the Gravatar without revealing the email
+	AvatarURL string    `json:"avatarUrl,omitempty"` // chosen by the user; takes precedence over the Gravatar
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// EmailHash returns the SHA-256 hex digest of the normalized email, as used
+// by Gravatar
+func EmailHash(email string) string {
+	sum := sha256.Sum256([]byte(NormalizeIdentifier(email)))
+	return hex.EncodeToString(sum[:])
+}
+
+// PublicProfile returns the user's public profile
+func (u *User) PublicProfile() *PublicProfile {
+	return &PublicProfile{
+		ID:        u.ID,
+		Username:  u.Username,
+		EmailHash: EmailHash(u.Email),
+		AvatarURL: u.AvatarURL,
+		CreatedAt: u.CreatedAt,
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+// roleNamePattern restricts role names to what fits in tokens and URLs
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+
+// IsValid reports whether the role is a well-formed role name
+func (r Role) IsValid() bool {
+	return roleNamePattern.MatchString(string(r))
+}
+
+// IsBuiltIn reports whether the role is one of the built-in roles, which
+// cannot be deleted
+func (r Role) IsBuiltIn() bool {
+	return r == RoleAdmin || r == RoleUser
+}
+
+// RoleDefinition is a role users can be assigned and the permissions it grants
+type RoleDefinition struct {
+	Name        Role         `gorm:"type:varchar(50);primary_key" json:"name"`
+	Description string       `gorm:"type:varchar(255)" json:"description,omitempty"`
+	Permissions []Permission `gorm:"-" json:"permissions"`
+	CreatedAt   time.Time    `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time    `gorm:"not null" json:"updated_at"`
+}
+
+// TableName specifies the table name for the RoleDefinition model
+func (RoleDefinition) TableName() string {
+	return "roles"
+}
+
+// RolePermission grants a permission to a role
+type RolePermission struct {
+	Role       Role       `gorm:"type:varchar(50);primary_key"`
+	Permission Permission `gorm:"type:varchar(100);primary_key"`
+}
+
+// TableName specifies the table name for the RolePermission model
+func (RolePermission) TableName() string {
+	return "role_permissions"
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SecurityActivityType identifies a security-relevant account action
+type SecurityActivityType string
+
+const (
+	SecurityActivityLogin          SecurityActivityType = "login"
+	SecurityActivityPasswordChange SecurityActivityType = "password_change"
+	// SecurityActivityLoginConfirmed records a device the user confirmed by
+	// email after a login from it was held back
+	SecurityActivityLoginConfirmed SecurityActivityType = "login_confirmed"
+	// SecurityActivityRegistration records where an account was registered
+	// from, so that registrations from the same IP address or device can be
+	// counted
+	SecurityActivityRegistration SecurityActivityType = "registration"
+)
+
+// SecurityActivity records a security-relevant action on a user account
+type SecurityActivity struct {
+	ID        uuid.UUID            `gorm:"type:uuid;primary_key" json:"id"`
+	UserID    uuid.UUID            `gorm:"type:uuid;not null;index" json:"user_id"`
+	Type      SecurityActivityType `gorm:"type:varchar(50);not null" json:"type"`
+	DeviceID  string               `gorm:"type:varchar(255)" json:"device_id,omitempty"`
+	UserAgent string               `gorm:"type:text" json:"user_agent,omitempty"`
+	IPAddress string               `gorm:"type:varchar(64)" json:"ip_address,omitempty"`
+	Country   string               `gorm:"type:varchar(2)" json:"country,omitempty"`
+	SessionID string               `gorm:"type:varchar(64)" json:"session_id,omitempty"`
+	CreatedAt time.Time            `gorm:"not null" json:"created_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (a *SecurityActivity) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = NewID()
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// TableName specifies the table name for the SecurityActivity model
+func (SecurityActivity) TableName() string {
+	return "security_activities"
+}
+
+// SecuritySummary aggregates a user's security activity over a period
+type SecuritySummary struct {
+	UserID          uuid.UUID
+	PeriodStart     time.Time
+	PeriodEnd       time.Time
+	NewDevices      int
+	PasswordChanges int
+	ActiveSessions  int
+}
+
+// LoginHistory tells whether a login comes from a device and country the
+// user signed in from before
+type LoginHistory struct {
+	HasLogins    bool // false for the user's first login
+	KnownDevice  bool
+	KnownCountry bool
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionLimitAction decides what happens when a user with the maximum
+// number of concurrent sessions logs in again
+type SessionLimitAction string
+
+const (
+	// SessionLimitDeny rejects the new login
+	SessionLimitDeny SessionLimitAction = "deny"
+	// SessionLimitEvictOldest ends the oldest sessions to make room
+	SessionLimitEvictOldest SessionLimitAction = "evict_oldest"
+)
+
+// IsValid reports whether the action is known
+func (a SessionLimitAction) IsValid() bool {
+	return a == SessionLimitDeny || a == SessionLimitEvictOldest
+}
+
+// Session is a login of a user, shared by the tokens issued for it until
+// its last refresh token expires
+type Session struct {
+	ID        string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
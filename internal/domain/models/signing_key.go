@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SigningKey is a version of the key signing tokens of a type. Versions
+// start at 1 and each rotation stores the next one.
+type SigningKey struct {
+	TokenType string    `gorm:"type:varchar(20);primary_key"`
+	Version   int       `gorm:"primary_key;autoIncrement:false"`
+	Key       []byte    `gorm:"column:key_material;not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for the SigningKey model
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
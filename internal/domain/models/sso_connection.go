@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SSOProtocol is the protocol an SSO connection signs users in with
+type SSOProtocol string
+
+const (
+	// SSOProtocolOIDC signs users in with OpenID Connect
+	SSOProtocolOIDC SSOProtocol = "oidc"
+)
+
+// SSOProviderPrefix prefixes the provider name external identities signed in
+// through an SSO connection are linked under, so that subjects of different
+// connections never collide
+const SSOProviderPrefix = "sso:"
+
+// SSOConnection is an identity provider an organization signs its members in
+// with. Users are sent to it when the domain of their email is a verified
+// domain of the connection.
+type SSOConnection struct {
+	ID             uuid.UUID   `gorm:"type:uuid;primary_key" json:"id"`
+	OrganizationID uuid.UUID   `gorm:"type:uuid;not null" json:"organization_id"`
+	Name           string      `gorm:"type:varchar(100);not null" json:"name"`
+	Protocol       SSOProtocol `gorm:"type:varchar(20);not null" json:"protocol"`
+	Issuer         string      `gorm:"not null" json:"issuer"`
+	ClientID       string      `gorm:"type:varchar(255);not null" json:"client_id"`
+	ClientSecret   string      `gorm:"not null" json:"-"`
+	// AuthURL, TokenURL and UserInfoURL are discovered from the issuer
+	AuthURL     string     `gorm:"not null" json:"auth_url"`
+	TokenURL    string     `gorm:"not null" json:"token_url"`
+	UserInfoURL string     `gorm:"not null" json:"user_info_url"`
+	Enabled     bool       `gorm:"not null" json:"enabled"`
+	CreatedBy   *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt   time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"not null" json:"updated_at"`
+}
+
+// TableName specifies the table name for the SSOConnection model
+func (SSOConnection) TableName() string {
+	return "sso_connections"
+}
+
+// ProviderName is the provider external identities signed in through the
+// connection are linked under
+func (c *SSOConnection) ProviderName() string {
+	return SSOProviderPrefix + c.ID.String()
+}
+
+// SSODomain is an email domain claimed by an SSO connection. The claim takes
+// effect once the organization proves it owns the domain by publishing the
+// verification token in DNS.
+type SSODomain struct {
+	ConnectionID      uuid.UUID  `gorm:"type:uuid;primary_key" json:"connection_id"`
+	Domain            string     `gorm:"type:varchar(253);primary_key" json:"domain"`
+	VerificationToken string     `gorm:"type:varchar(64);not null" json:"verification_token"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+	CreatedAt         time.Time  `gorm:"not null" json:"created_at"`
+}
+
+// TableName specifies the table name for the SSODomain model
+func (SSODomain) TableName() string {
+	return "sso_domains"
+}
+
+// IsVerified reports whether ownership of the domain was proven
+func (d *SSODomain) IsVerified() bool {
+	return d.VerifiedAt != nil
+}
+
+// VerificationRecord returns the name and value of the DNS TXT record that
+// proves ownership of the domain
+func (d *SSODomain) VerificationRecord() (name, value string) {
+	return DomainVerificationRecord(d.Domain, d.VerificationToken)
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,9 +11,14 @@ import (
 type UserStatus string
 
 const (
-	UserStatusActive   UserStatus = "active"
-	UserStatusInactive UserStatus = "inactive"
-	UserStatusPending  UserStatus = "pending"
+	UserStatusActive    UserStatus = "active"
+	UserStatusInactive  UserStatus = "inactive"
+	UserStatusPending   UserStatus = "pending"
+	UserStatusSuspended UserStatus = "suspended"
+	// UserStatusDeactivated marks users who deleted their account but may
+	// still restore it until it is purged
+	UserStatusDeactivated UserStatus = "deactivated"
+	UserStatusDeleted     UserStatus = "deleted"
 )
 
 type Role string
@@ -24,25 +30,37 @@ const (
 
 // User represents the user entity in our domain
 type User struct {
-	ID             uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
-	Email          string         `gorm:"type:varchar(255);uniqueIndex" json:"email"`
-	Username       string         `gorm:"type:varchar(255);uniqueIndex" json:"username"`
-	PasswordHash   string         `gorm:"type:varchar(255)" json:"-"`
-	Status         UserStatus     `gorm:"type:user_status;default:'pending'" json:"status"`
-	FirstName      string         `gorm:"type:varchar(255)" json:"first_name"`
-	LastName       string         `gorm:"type:varchar(255)" json:"last_name"`
-	Role           Role          `gorm:"type:user_role;default:'user'" json:"role"`
-	EmailVerified  bool          `gorm:"default:false" json:"email_verified"`
-	CreatedAt      time.Time     `gorm:"not null" json:"created_at"`
-	UpdatedAt      time.Time     `gorm:"not null" json:"updated_at"`
-	LastLoginAt    *time.Time    `json:"last_login_at,omitempty"`
-	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                      uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	Email                   string         `gorm:"type:varchar(255);uniqueIndex:idx_users_email_active,where:deleted_at IS NULL" json:"email"`
+	Username                string         `gorm:"type:varchar(255);uniqueIndex:idx_users_username_active,where:deleted_at IS NULL" json:"username"`
+	PasswordHash            string         `gorm:"type:varchar(255)" json:"-"`
+	Status                  UserStatus     `gorm:"type:user_status;default:'pending'" json:"status"`
+	FirstName               string         `gorm:"type:varchar(255)" json:"first_name"`
+	LastName                string         `gorm:"type:varchar(255)" json:"last_name"`
+	PhoneNumber             string         `gorm:"type:varchar(32)" json:"phone_number,omitempty"` // E.164, e.g. +4930123456
+	Locale                  string         `gorm:"type:varchar(35)" json:"locale,omitempty"`       // BCP 47 language tag, e.g. de-DE
+	TimeZone                string         `gorm:"type:varchar(64)" json:"time_zone,omitempty"`    // IANA time zone, e.g. Europe/Berlin
+	AvatarURL               string         `gorm:"type:varchar(2048)" json:"avatar_url,omitempty"` // chosen by the user; takes precedence over the Gravatar
+	Role                    Role           `gorm:"type:user_role;default:'user'" json:"role"`
+	EmailVerified           bool           `gorm:"default:false" json:"email_verified"`
+	VerificationRequired    bool           `gorm:"not null;default:false" json:"verification_required"` // blocks login until the email is verified, e.g. for risky registrations
+	CreatedAt               time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt               time.Time      `gorm:"not null" json:"updated_at"`
+	LastLoginAt             *time.Time     `json:"last_login_at,omitempty"`
+	OrganizationID          *uuid.UUID     `gorm:"type:uuid;index" json:"organization_id,omitempty"`      // nil outside any organization
+	PasswordResetRequired   bool           `gorm:"not null;default:false" json:"password_reset_required"` // blocks password login, e.g. after a credential breach
+	ServiceAccount          bool           `gorm:"not null;default:false" json:"service_account"`         // machine user that only authenticates with API keys
+	RecoveryEmail           string         `gorm:"type:varchar(255)" json:"recovery_email,omitempty"`     // verified second address for password resets
+	RecoveryEmailVerifiedAt *time.Time     `json:"recovery_email_verified_at,omitempty"`
+	MarketingOptOut         bool           `gorm:"not null;default:false" json:"marketing_opt_out"` // suppresses marketing-relevant events about the user
+	DeactivatedAt           *time.Time     `gorm:"index" json:"deactivated_at,omitempty"`           // set while the user can restore their deleted account
+	DeletedAt               gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // BeforeCreate will set a UUID rather than numeric ID
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
-		u.ID = uuid.New()
+		u.ID = NewID()
 	}
 	if u.CreatedAt.IsZero() {
 		u.CreatedAt = time.Now()
@@ -64,6 +82,12 @@ func (User) TableName() string {
 	return "users"
 }
 
+// NormalizeIdentifier normalizes an email or username for lookups, which
+// match identifiers case-insensitively
+func NormalizeIdentifier(identifier string) string {
+	return strings.ToLower(strings.TrimSpace(identifier))
+}
+
 // NewUser creates a new user with default values
 func NewUser(email, username string, role Role) *User {
 	return &User{
@@ -75,15 +99,38 @@ func NewUser(email, username string, role Role) *User {
 	}
 }
 
-// UpdatePassword updates the user's password hash
+// UpdatePassword updates the user's password hash, lifting a required reset
 func (u *User) UpdatePassword(passwordHash string) {
 	u.PasswordHash = passwordHash
+	u.PasswordResetRequired = false
 }
 
-// VerifyEmail marks the user's email as verified
-func (u *User) VerifyEmail() {
+// VerifyEmail marks the user's email as verified, activating the account if
+// it was waiting for verification and lifting a required verification.
+// Suspended accounts stay suspended.
+func (u *User) VerifyEmail() error {
+	if u.Status == UserStatusDeleted {
+		return &StatusTransitionError{From: u.Status, To: UserStatusActive}
+	}
 	u.EmailVerified = true
-	u.Status = UserStatusActive
+	u.VerificationRequired = false
+	if u.Status == UserStatusPending {
+		return u.TransitionTo(UserStatusActive)
+	}
+	return nil
+}
+
+// SetRecoveryEmail sets the recovery email once the user verified it
+func (u *User) SetRecoveryEmail(email string) {
+	now := time.Now()
+	u.RecoveryEmail = email
+	u.RecoveryEmailVerifiedAt = &now
+}
+
+// ClearRecoveryEmail removes the recovery email
+func (u *User) ClearRecoveryEmail() {
+	u.RecoveryEmail = ""
+	u.RecoveryEmailVerifiedAt = nil
 }
 
 // UpdateLastLogin updates the user's last login timestamp
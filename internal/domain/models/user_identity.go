@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentity links a user to their account at an external identity provider
+type UserIdentity struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider    string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+	Subject     string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject" json:"subject"`
+	Email       string     `gorm:"type:varchar(255)" json:"email"` // as reported when linked
+	CreatedAt   time.Time  `gorm:"not null" json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// TableName specifies the table name for the UserIdentity model
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = NewID()
+	}
+	if i.CreatedAt.IsZero() {
+		i.CreatedAt = time.Now()
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/errors"
+)
+
+// userStatusTransitions lists the statuses each status may move to. Deleted
+// is terminal. Suspended users cannot deactivate their account, so that
+// restoring it cannot lift the suspension.
+var userStatusTransitions = map[UserStatus][]UserStatus{
+	UserStatusPending:     {UserStatusActive, UserStatusSuspended, UserStatusDeactivated, UserStatusDeleted},
+	UserStatusActive:      {UserStatusPending, UserStatusSuspended, UserStatusDeactivated, UserStatusDeleted},
+	UserStatusSuspended:   {UserStatusActive, UserStatusDeleted},
+	UserStatusInactive:    {UserStatusActive, UserStatusSuspended, UserStatusDeactivated, UserStatusDeleted},
+	UserStatusDeactivated: {UserStatusActive, UserStatusPending, UserStatusDeleted},
+	UserStatusDeleted:     {},
+}
+
+// StatusTransitionError is returned for a status change the lifecycle does not allow
+type StatusTransitionError struct {
+	From UserStatus
+	To   UserStatus
+}
+
+// Error implements the error interface
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("cannot change user status from %s to %s", e.From, e.To)
+}
+
+// Unwrap allows matching with errors.ErrInvalidStatusTransition
+func (e *StatusTransitionError) Unwrap() error {
+	return errors.ErrInvalidStatusTransition
+}
+
+// IsValid reports whether the status is part of the user lifecycle
+func (s UserStatus) IsValid() bool {
+	_, ok := userStatusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a user may move from this status to the given one
+func (s UserStatus) CanTransitionTo(to UserStatus) bool {
+	for _, allowed := range userStatusTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAuthenticate reports whether users with this status may sign in
+func (s UserStatus) CanAuthenticate() bool {
+	return s == UserStatusActive || s == UserStatusPending
+}
+
+// TransitionTo moves the user to the given status. Moving to the current
+// status is a no-op; any other change must be allowed by the lifecycle.
+func (u *User) TransitionTo(to UserStatus) error {
+	if u.Status == to {
+		return nil
+	}
+	if !u.Status.CanTransitionTo(to) {
+		return &StatusTransitionError{From: u.Status, To: to}
+	}
+	u.Status = to
+	return nil
+}
+
+// Deactivate moves the user to the deactivated status, from which the
+// account can be restored until it is purged
+func (u *User) Deactivate(at time.Time) error {
+	if u.Status == UserStatusDeactivated {
+		return nil
+	}
+	if err := u.TransitionTo(UserStatusDeactivated); err != nil {
+		return err
+	}
+	u.DeactivatedAt = &at
+	return nil
+}
+
+// Restore reactivates a deactivated user. Users who had not verified their
+// email are pending again.
+func (u *User) Restore() error {
+	if u.Status != UserStatusDeactivated {
+		return &StatusTransitionError{From: u.Status, To: UserStatusActive}
+	}
+	to := UserStatusActive
+	if !u.EmailVerified {
+		to = UserStatusPending
+	}
+	if err := u.TransitionTo(to); err != nil {
+		return err
+	}
+	u.DeactivatedAt = nil
+	return nil
+}
+
+// RequireReverification marks the email as unverified and moves an active
+// user back to pending until the new address is verified
+func (u *User) RequireReverification() error {
+	if u.Status == UserStatusActive {
+		if err := u.TransitionTo(UserStatusPending); err != nil {
+			return err
+		}
+	}
+	u.EmailVerified = false
+	return nil
+}
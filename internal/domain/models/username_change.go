@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UsernameChange records a change of a user's username
+type UsernameChange struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	OldUsername   string     `gorm:"type:varchar(255);not null" json:"old_username"`
+	NewUsername   string     `gorm:"type:varchar(255);not null" json:"new_username"`
+	ChangedAt     time.Time  `gorm:"not null" json:"changed_at"`
+	ReservedUntil *time.Time `json:"reserved_until,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (c *UsernameChange) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = NewID()
+	}
+	if c.ChangedAt.IsZero() {
+		c.ChangedAt = time.Now()
+	}
+	return nil
+}
+
+// TableName specifies the table name for the UsernameChange model
+func (UsernameChange) TableName() string {
+	return "username_changes"
+}
+
+// NewUsernameChange creates a username change record, reserving the old
+// username for the given period so that other users cannot claim it
+func NewUsernameChange(userID uuid.UUID, oldUsername, newUsername string, reservation time.Duration) *UsernameChange {
+	now := time.Now()
+	change := &UsernameChange{
+		UserID:      userID,
+		OldUsername: oldUsername,
+		NewUsername: newUsername,
+		ChangedAt:   now,
+	}
+	if reservation > 0 {
+		reservedUntil := now.Add(reservation)
+		change.ReservedUntil = &reservedUntil
+	}
+	return change
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential is a passkey a user registered with an authenticator.
+// The authenticator keeps the private key; only the public key is stored.
+type WebAuthnCredential struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	// CredentialID is the authenticator's ID of the credential, which
+	// assertions name the credential they were made with by
+	CredentialID []byte `gorm:"type:bytea;not null;uniqueIndex" json:"-"`
+	PublicKey    []byte `gorm:"type:bytea;not null" json:"-"` // COSE_Key
+	// SignCount is the signature counter of the last assertion; a counter
+	// that does not increase hints at a cloned authenticator
+	SignCount  int64      `gorm:"not null;default:0" json:"-"`
+	AAGUID     []byte     `gorm:"type:bytea" json:"-"` // authenticator model, all zero when not attested
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"not null" json:"created_at"`
+}
+
+// TableName specifies the table name for the WebAuthnCredential model
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// AccountFlagFilter selects flags of the review queue. Zero fields match
+// every flag.
+type AccountFlagFilter struct {
+	Status models.FlagStatus
+	UserID uuid.UUID
+}
+
+// AccountFlagRepository defines the interface for account flag persistence
+type AccountFlagRepository interface {
+	// Create stores a new flag
+	Create(ctx context.Context, flag *models.AccountFlag) error
+
+	// GetByID retrieves a flag. It returns services.ErrNotFound when there
+	// is no such flag.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AccountFlag, error)
+
+	// List retrieves the flags matching filter, oldest first
+	List(ctx context.Context, filter AccountFlagFilter, offset, limit int) ([]*models.AccountFlag, error)
+
+	// ListActiveByUser retrieves the open and confirmed flags of a user
+	ListActiveByUser(ctx context.Context, userID uuid.UUID) ([]*models.AccountFlag, error)
+
+	// Update saves a reviewed flag
+	Update(ctx context.Context, flag *models.AccountFlag) error
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// APIKeyRepository defines the interface for API key persistence
+type APIKeyRepository interface {
+	// Create stores a new key
+	Create(ctx context.Context, key *models.APIKey) error
+
+	// GetByHash retrieves the key with the given hash, including revoked and
+	// expired keys. It returns services.ErrNotFound when there is none.
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+
+	// ListByUser returns the keys of a service account, oldest first
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.APIKey, error)
+
+	// Revoke marks a key of a service account revoked. It returns
+	// services.ErrNotFound when the account has no such active key.
+	Revoke(ctx context.Context, userID, id uuid.UUID, at time.Time) error
+
+	// RecordUse sets when a key was last used
+	RecordUse(ctx context.Context, id uuid.UUID, at time.Time) error
+}
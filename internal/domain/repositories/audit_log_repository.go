@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// AuditLogRepository defines the interface for the append-only audit log
+type AuditLogRepository interface {
+	// Append chains the entries, in order, to the latest entry and stores
+	// them. Concurrent appends are serialized so that the chain never forks.
+	Append(ctx context.Context, entries ...*models.AuditLogEntry) error
+
+	// Latest retrieves the latest entry, or nil when the log is empty
+	Latest(ctx context.Context) (*models.AuditLogEntry, error)
+
+	// ListAfter retrieves up to limit entries following the given sequence
+	// number, in order
+	ListAfter(ctx context.Context, sequence int64, limit int) ([]*models.AuditLogEntry, error)
+
+	// ListBefore retrieves up to limit entries preceding the given sequence
+	// number, newest first; 0 starts from the latest entry
+	ListBefore(ctx context.Context, sequence int64, limit int) ([]*models.AuditLogEntry, error)
+}
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// EmailVerificationRepository defines the interface for verification email persistence
+type EmailVerificationRepository interface {
+	// Create records a sent verification email
+	Create(ctx context.Context, verification *models.EmailVerification) error
+
+	// GetByTokenHash retrieves a verification email by the hash of its token
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error)
+
+	// Update updates a verification email
+	Update(ctx context.Context, verification *models.EmailVerification) error
+
+	// ListByUser retrieves a user's verification emails, most recent first
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.EmailVerification, error)
+
+	// CountSince counts the verification emails sent to a user since the given time
+	CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+
+	// ExpirePending marks a user's unused verification emails as expired
+	ExpirePending(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// KnowledgeFactorRepository defines the interface for security question
+// answer persistence
+type KnowledgeFactorRepository interface {
+	// Replace stores the answers of a user in place of their previous ones
+	Replace(ctx context.Context, userID uuid.UUID, factors []*models.KnowledgeFactor) error
+
+	// ListByUser returns the answers of a user, ordered by question
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.KnowledgeFactor, error)
+
+	// DeleteByUser removes the answers of a user. It returns
+	// services.ErrNotFound when the user has none.
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
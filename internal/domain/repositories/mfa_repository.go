@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// TOTPCredentialRepository defines the interface for authenticator app credential persistence
+type TOTPCredentialRepository interface {
+	// Save creates or replaces the credential of a user
+	Save(ctx context.Context, credential *models.TOTPCredential) error
+
+	// GetByUserID retrieves the credential of a user
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.TOTPCredential, error)
+
+	// Delete removes the credential of a user
+	Delete(ctx context.Context, userID uuid.UUID) error
+
+	// UseStep records that the code of a time step was accepted. It returns
+	// false when a code of this or a later step was accepted before.
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+}
+
+// MFAPolicyRepository defines the interface for MFA enforcement policy persistence
+type MFAPolicyRepository interface {
+	// Create stores a new policy
+	Create(ctx context.Context, policy *models.MFAPolicy) error
+
+	// Update replaces a policy
+	Update(ctx context.Context, policy *models.MFAPolicy) error
+
+	// Delete removes a policy
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// GetByID retrieves a policy by ID
+	GetByID(ctx context.Context, id uuid.UUID) (*models.MFAPolicy, error)
+
+	// List returns all policies, earliest enforcement first
+	List(ctx context.Context) ([]*models.MFAPolicy, error)
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// NotificationWebhookRepository defines the interface for notification webhook persistence
+type NotificationWebhookRepository interface {
+	// Create stores a new webhook
+	Create(ctx context.Context, webhook *models.NotificationWebhook) error
+
+	// ListByUser returns the webhooks of a user, oldest first
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.NotificationWebhook, error)
+
+	// Delete removes a webhook of a user. It returns services.ErrNotFound
+	// when the user has no such webhook.
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+}
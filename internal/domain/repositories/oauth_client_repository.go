@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// OAuthClientRepository defines the interface for OAuth client persistence
+type OAuthClientRepository interface {
+	// Create registers a client
+	Create(ctx context.Context, client *models.OAuthClient) error
+
+	// GetByClientID retrieves a client by its public client ID
+	GetByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error)
+
+	// List retrieves every registered client, oldest first
+	List(ctx context.Context) ([]*models.OAuthClient, error)
+
+	// Update updates a client
+	Update(ctx context.Context, client *models.OAuthClient) error
+
+	// Delete removes a client by its public client ID
+	Delete(ctx context.Context, clientID string) error
+}
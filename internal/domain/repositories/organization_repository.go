@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// OrganizationRepository defines the interface for organization and
+// membership persistence
+type OrganizationRepository interface {
+	// Create stores a new organization with its first member, typically its
+	// owner. The member's organization becomes the new one when they had none.
+	Create(ctx context.Context, organization *models.Organization, owner *models.OrganizationMembership) error
+
+	// GetByID retrieves an organization. It returns services.ErrNotFound
+	// when there is no such organization.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+
+	// Update saves the settings of an organization
+	Update(ctx context.Context, organization *models.Organization) error
+
+	// ListByUser returns the organizations a user is a member of, ordered by name
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error)
+
+	// AddMember adds a user to an organization. The user's organization
+	// becomes this one when they had none. It returns services.ErrConflict
+	// when the user is already a member.
+	AddMember(ctx context.Context, membership *models.OrganizationMembership) error
+
+	// GetMember retrieves the membership of a user in an organization. It
+	// returns services.ErrNotFound when the user is not a member.
+	GetMember(ctx context.Context, organizationID, userID uuid.UUID) (*models.OrganizationMembership, error)
+
+	// ListMembers returns the memberships of an organization, oldest first
+	ListMembers(ctx context.Context, organizationID uuid.UUID) ([]*models.OrganizationMembership, error)
+
+	// RemoveMember removes a user from an organization and clears the
+	// user's organization when it was this one. It returns
+	// services.ErrNotFound when the user is not a member.
+	RemoveMember(ctx context.Context, organizationID, userID uuid.UUID) error
+
+	// AddDomain claims an email domain for an organization. It returns
+	// services.ErrConflict when the organization already claimed it.
+	AddDomain(ctx context.Context, domain *models.OrganizationDomain) error
+
+	// ListDomains returns the domains claimed by an organization, ordered
+	// by name
+	ListDomains(ctx context.Context, organizationID uuid.UUID) ([]*models.OrganizationDomain, error)
+
+	// VerifyDomain marks a claimed domain verified. It returns
+	// services.ErrNotFound when the organization did not claim it and
+	// services.ErrConflict when another organization verified it.
+	VerifyDomain(ctx context.Context, organizationID uuid.UUID, domain string, at time.Time) error
+
+	// RemoveDomain removes a domain claim. It returns services.ErrNotFound
+	// when the organization did not claim the domain.
+	RemoveDomain(ctx context.Context, organizationID uuid.UUID, domain string) error
+
+	// GetByVerifiedDomain retrieves the organization that verified a
+	// domain. It returns services.ErrNotFound when none did.
+	GetByVerifiedDomain(ctx context.Context, domain string) (*models.Organization, error)
+
+	// AddInvitation invites a user into an organization. It returns
+	// services.ErrConflict when the user is already invited.
+	AddInvitation(ctx context.Context, invitation *models.OrganizationInvitation) error
+
+	// GetInvitation retrieves the invitation of a user into an
+	// organization. It returns services.ErrNotFound when there is none.
+	GetInvitation(ctx context.Context, organizationID, userID uuid.UUID) (*models.OrganizationInvitation, error)
+
+	// ListInvitationsByUser returns the invitations of a user, oldest first
+	ListInvitationsByUser(ctx context.Context, userID uuid.UUID) ([]*models.OrganizationInvitation, error)
+
+	// AcceptInvitation replaces an invitation with a membership of its role,
+	// like AddMember. It returns services.ErrNotFound when there is no such
+	// invitation.
+	AcceptInvitation(ctx context.Context, invitation *models.OrganizationInvitation) (*models.OrganizationMembership, error)
+
+	// RemoveInvitation removes the invitation of a user into an
+	// organization. It returns services.ErrNotFound when there is none.
+	RemoveInvitation(ctx context.Context, organizationID, userID uuid.UUID) error
+}
+
+type organizationScopeKey struct{}
+
+// WithOrganizationScope returns a copy of ctx that restricts the user
+// queries of a UserRepository to the members of an organization
+func WithOrganizationScope(ctx context.Context, organizationID uuid.UUID) context.Context {
+	return context.WithValue(ctx, organizationScopeKey{}, organizationID)
+}
+
+// OrganizationScope returns the organization user queries in ctx are
+// restricted to, if any
+func OrganizationScope(ctx context.Context) (uuid.UUID, bool) {
+	organizationID, ok := ctx.Value(organizationScopeKey{}).(uuid.UUID)
+	return organizationID, ok
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// OrganizationSettingsRepository defines the interface for organization settings persistence
+type OrganizationSettingsRepository interface {
+	// Get retrieves the settings of an organization
+	Get(ctx context.Context, organizationID uuid.UUID) (*models.OrganizationSettings, error)
+
+	// Save creates or replaces the settings of an organization
+	Save(ctx context.Context, settings *models.OrganizationSettings) error
+
+	// Delete removes the settings of an organization
+	Delete(ctx context.Context, organizationID uuid.UUID) error
+}
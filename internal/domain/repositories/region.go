@@ -0,0 +1,19 @@
+package repositories
+
+import "context"
+
+type regionKey struct{}
+
+// WithRegion returns a copy of ctx whose queries go to the database of the
+// given region, the home region of the data they read. Writes for another
+// region than the deployment's own are refused.
+func WithRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
+// Region returns the region the queries in ctx go to; empty for the
+// deployment's own region
+func Region(ctx context.Context) string {
+	region, _ := ctx.Value(regionKey{}).(string)
+	return region
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// RoleRepository defines the interface for role and permission persistence
+type RoleRepository interface {
+	// Create stores a new role together with its permissions. It returns
+	// services.ErrConflict when a role with the name exists.
+	Create(ctx context.Context, role *models.RoleDefinition) error
+
+	// Delete removes a role and its permissions. It returns
+	// services.ErrNotFound when there is no such role.
+	Delete(ctx context.Context, name models.Role) error
+
+	// GetByName retrieves a role with its permissions. It returns
+	// services.ErrNotFound when there is no such role.
+	GetByName(ctx context.Context, name models.Role) (*models.RoleDefinition, error)
+
+	// List returns all roles with their permissions, ordered by name
+	List(ctx context.Context) ([]*models.RoleDefinition, error)
+
+	// SetPermissions replaces the permissions of a role. It returns
+	// services.ErrNotFound when there is no such role.
+	SetPermissions(ctx context.Context, name models.Role, permissions []models.Permission) error
+
+	// CountUsers returns how many users, including deleted ones, hold a role
+	CountUsers(ctx context.Context, name models.Role) (int64, error)
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// SecurityActivityRepository defines the interface for security activity persistence
+type SecurityActivityRepository interface {
+	// Create records a security activity
+	Create(ctx context.Context, activity *models.SecurityActivity) error
+
+	// Summarize aggregates a user's activity between from and to. Sessions
+	// started since sessionsSince are counted as active.
+	Summarize(ctx context.Context, userID uuid.UUID, from, to, sessionsSince time.Time) (*models.SecuritySummary, error)
+
+	// LoginHistory reports whether the user signed in, or confirmed a
+	// login, from the device and country before. A device is identified by
+	// its ID, or its user agent without one; an unidentifiable device or an
+	// empty country counts as known.
+	LoginHistory(ctx context.Context, userID uuid.UUID, deviceID, userAgent, country string) (*models.LoginHistory, error)
+
+	// RecentRegistrations counts the registrations since the given time from
+	// the IP address and the device ID. Empty values count nothing.
+	RecentRegistrations(ctx context.Context, ipAddress, deviceID string, since time.Time) (fromIP, fromDevice int, err error)
+}
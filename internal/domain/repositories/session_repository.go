@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// SessionRepository tracks the active sessions of users. Sessions past
+// their expiry are no longer active.
+type SessionRepository interface {
+	// Start records a session unless its user already has limit active
+	// sessions, in which case it reports false. With evictOldest the oldest
+	// sessions are ended to make room instead and their IDs returned. A
+	// limit of 0 admits every session.
+	Start(ctx context.Context, session *models.Session, limit int, evictOldest bool) (started bool, evicted []string, err error)
+
+	// Extend moves the expiry of an active session, reporting false when
+	// the session is not active
+	Extend(ctx context.Context, userID uuid.UUID, sessionID string, expiresAt time.Time) (bool, error)
+
+	// End ends a session
+	End(ctx context.Context, userID uuid.UUID, sessionID string) error
+
+	// EndAll ends every session of a user
+	EndAll(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// SigningKeyRepository defines the interface for signing key persistence
+type SigningKeyRepository interface {
+	// Create stores a new version of a signing key. It returns
+	// services.ErrConflict when the version exists, e.g. because another
+	// replica rotated the key at the same time.
+	Create(ctx context.Context, key *models.SigningKey) error
+
+	// ListLatest returns up to limit versions of the signing key of a token
+	// type, newest first
+	ListLatest(ctx context.Context, tokenType string, limit int) ([]*models.SigningKey, error)
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// SSOConnectionRepository defines the interface for persistence of SSO
+// connections and the domains they claim
+type SSOConnectionRepository interface {
+	// Create stores a new connection
+	Create(ctx context.Context, connection *models.SSOConnection) error
+
+	// GetByID retrieves a connection. It returns services.ErrNotFound when
+	// there is no such connection.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.SSOConnection, error)
+
+	// ListByOrganization returns the connections of an organization, ordered by name
+	ListByOrganization(ctx context.Context, organizationID uuid.UUID) ([]*models.SSOConnection, error)
+
+	// Update saves changes to a connection
+	Update(ctx context.Context, connection *models.SSOConnection) error
+
+	// Delete removes a connection with its domains. It returns
+	// services.ErrNotFound when there is no such connection.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// AddDomain stores a domain claimed by a connection. It returns
+	// services.ErrConflict when the connection already claims it.
+	AddDomain(ctx context.Context, domain *models.SSODomain) error
+
+	// ListDomains returns the domains claimed by a connection, ordered by domain
+	ListDomains(ctx context.Context, connectionID uuid.UUID) ([]*models.SSODomain, error)
+
+	// VerifyDomain marks a domain of a connection as verified. It returns
+	// services.ErrConflict when another connection verified it first.
+	VerifyDomain(ctx context.Context, connectionID uuid.UUID, domain string, verifiedAt time.Time) error
+
+	// RemoveDomain removes a domain from a connection. It returns
+	// services.ErrNotFound when the connection does not claim it.
+	RemoveDomain(ctx context.Context, connectionID uuid.UUID, domain string) error
+
+	// GetByVerifiedDomain retrieves the connection that verified a domain.
+	// It returns services.ErrNotFound when no connection did.
+	GetByVerifiedDomain(ctx context.Context, domain string) (*models.SSOConnection, error)
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// UserIdentityRepository defines the interface for external identity links
+type UserIdentityRepository interface {
+	// Create links an external identity to a user
+	Create(ctx context.Context, identity *models.UserIdentity) error
+
+	// GetByProviderSubject retrieves the link of a provider's user
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error)
+
+	// ListByUser retrieves the external identities linked to a user
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UserIdentity, error)
+
+	// Delete removes an identity of a user, returning services.ErrNotFound
+	// when the user has no identity with the ID
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+
+	// RecordLogin sets the last login time of an identity to now
+	RecordLogin(ctx context.Context, id uuid.UUID) error
+}
@@ -2,12 +2,15 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mibrahim2344/identity-service/internal/domain/models"
 )
 
-// UserRepository defines the interface for user persistence operations
+// UserRepository defines the interface for user persistence operations.
+// Queries of a context passed through WithOrganizationScope only see the
+// members of that organization.
 type UserRepository interface {
 	// Create creates a new user
 	Create(ctx context.Context, user *models.User) error
@@ -24,12 +27,33 @@ type UserRepository interface {
 	// GetByIdentifier retrieves a user by email or username
 	GetByIdentifier(ctx context.Context, identifier string) (*models.User, error)
 
+	// IdentifiersTaken reports in a single query whether a user other than
+	// a soft-deleted one has the email or the username, matched
+	// case-insensitively like logins. It ignores the organization scope.
+	IdentifiersTaken(ctx context.Context, email, username string) (emailTaken, usernameTaken bool, err error)
+
+	// ListByRecoveryEmail retrieves the users with the given recovery email,
+	// matched case-insensitively
+	ListByRecoveryEmail(ctx context.Context, email string) ([]*models.User, error)
+
 	// Update updates an existing user
 	Update(ctx context.Context, user *models.User) error
 
 	// Delete deletes a user by their ID
 	Delete(ctx context.Context, id uuid.UUID) error
 
+	// GetByIDIncludingDeleted retrieves a user by their ID, including a
+	// soft-deleted one
+	GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*models.User, error)
+
+	// Purge permanently deletes a user, including a soft-deleted one, and
+	// every record referring to the user
+	Purge(ctx context.Context, id uuid.UUID) error
+
 	// List retrieves users with pagination
 	List(ctx context.Context, offset, limit int) ([]*models.User, error)
+
+	// ListDeactivatedBefore retrieves up to limit users deactivated before
+	// the given time, longest deactivated first
+	ListDeactivatedBefore(ctx context.Context, before time.Time, limit int) ([]*models.User, error)
 }
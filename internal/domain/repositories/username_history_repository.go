@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// UsernameHistoryRepository defines the interface for username change history persistence
+type UsernameHistoryRepository interface {
+	// Create records a username change
+	Create(ctx context.Context, change *models.UsernameChange) error
+
+	// ListByUser retrieves a user's username changes, most recent first
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UsernameChange, error)
+
+	// IsReserved checks whether a username is still reserved by a user other than the given one
+	IsReserved(ctx context.Context, username string, excludeUserID uuid.UUID) (bool, error)
+
+	// ReleaseReservations ends every username reservation held by a user
+	ReleaseReservations(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// WebAuthnCredentialRepository defines the interface for passkey persistence
+type WebAuthnCredentialRepository interface {
+	// Create stores a new credential
+	Create(ctx context.Context, credential *models.WebAuthnCredential) error
+
+	// GetByCredentialID retrieves a credential by the authenticator's ID of it
+	GetByCredentialID(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error)
+
+	// ListByUser returns the credentials of a user, oldest first
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.WebAuthnCredential, error)
+
+	// RecordUse stores the signature counter of an assertion made with a
+	// credential and when it was made
+	RecordUse(ctx context.Context, id uuid.UUID, signCount int64, at time.Time) error
+
+	// Delete removes a credential of a user
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+}
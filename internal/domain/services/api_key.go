@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// CreateServiceAccountInput represents the input for creating a service account
+type CreateServiceAccountInput struct {
+	Username  string
+	Email     string      // contact of the team owning the account
+	Role      models.Role // empty uses models.RoleUser
+	CreatedBy uuid.UUID
+}
+
+// CreateAPIKeyInput represents the input for creating an API key
+type CreateAPIKeyInput struct {
+	UserID    uuid.UUID // the service account
+	Name      string
+	NotBefore time.Time     // a future activation of keys provisioned ahead of time
+	ExpiresIn time.Duration // 0 never expires, counted from activation
+	CreatedBy uuid.UUID
+}
+
+// CreatedAPIKey is a new API key together with the key itself, which is
+// not shown again
+type CreatedAPIKey struct {
+	*models.APIKey
+	Key string
+}
+
+// APIKeyService defines the interface for service accounts and the API
+// keys they authenticate machine-to-machine requests with
+type APIKeyService interface {
+	// CreateServiceAccount creates an active user that has no password and
+	// only authenticates with API keys
+	CreateServiceAccount(ctx context.Context, input CreateServiceAccountInput) (*models.User, error)
+
+	// CreateKey creates an API key for a service account
+	CreateKey(ctx context.Context, input CreateAPIKeyInput) (*CreatedAPIKey, error)
+
+	// ListKeys returns the API keys of a service account, including revoked
+	// and expired ones
+	ListKeys(ctx context.Context, userID uuid.UUID) ([]*models.APIKey, error)
+
+	// RevokeKey revokes an API key of a service account
+	RevokeKey(ctx context.Context, userID, id, revokedBy uuid.UUID) error
+
+	// Authenticate returns the service account an API key belongs to. It
+	// returns ErrAPIKeyInvalid for unknown, revoked and expired keys and
+	// for accounts that cannot authenticate.
+	Authenticate(ctx context.Context, key string) (*models.User, error)
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// AuditLogService defines the interface for the tamper-evident audit log
+type AuditLogService interface {
+	// Verify recomputes the hash chain of the whole log and reports the
+	// first entry that was modified, removed or reordered
+	Verify(ctx context.Context) (*models.AuditLogVerification, error)
+
+	// List returns up to limit entries preceding the given sequence number,
+	// newest first; 0 starts from the latest entry
+	List(ctx context.Context, before int64, limit int) ([]*models.AuditLogEntry, error)
+}
+
+// AuditNotary defines the interface for storing audit log checkpoints
+// outside the service's control, e.g. in write-once object storage. A
+// notarized checkpoint cannot be rewritten together with the log.
+type AuditNotary interface {
+	// Notarize stores a checkpoint. Storing the same checkpoint again must
+	// be harmless.
+	Notarize(ctx context.Context, checkpoint *models.AuditLogCheckpoint) error
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// BootstrapInput is the initial setup of a new deployment
+type BootstrapInput struct {
+	Admin RegisterUserInput
+	// Organization names an organization to create with the admin as its
+	// owner; empty creates none
+	Organization string
+	// Settings override the service-wide configuration for the
+	// organization; nil keeps it
+	Settings *models.OrganizationSettings
+}
+
+// BootstrapResult is what the initial setup created
+type BootstrapResult struct {
+	Admin        *models.User
+	Organization *models.Organization // nil when none was created
+}
+
+// BootstrapService lets first-run automation set up a new deployment
+// without any credentials but the one-time bootstrap token printed at its
+// first start
+type BootstrapService interface {
+	// Setup creates the first admin, whose email counts as verified, and
+	// optionally an organization with tenant settings. The token is
+	// destroyed once the admin exists; invalid, expired and used tokens
+	// fail with ErrInvalidToken.
+	Setup(ctx context.Context, token string, input BootstrapInput) (*BootstrapResult, error)
+}
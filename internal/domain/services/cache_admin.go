@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+)
+
+// CacheStats describes the contents and health of the cache
+type CacheStats struct {
+	Keys            int64            // keys in the cache database
+	Namespaces      map[string]int64 // scanned keys by the part of the key before the first ':'
+	Scanned         int64            // keys counted into Namespaces
+	Truncated       bool             // the scan stopped at its limit before covering every key
+	RevokedTokens   int64            // revoked access tokens still within their lifetime
+	RevokedSessions int64            // revoked refresh token sessions
+	Hits            int64            // key lookups that found a key since the cache server started
+	Misses          int64            // key lookups that found no key since the cache server started
+}
+
+// HitRatio returns the share of key lookups that found a key, or 0 before
+// any lookup
+func (s *CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// CacheInspector inspects and invalidates cache keys for operational
+// debugging. Implementations walk the keyspace incrementally and never
+// block the cache for long.
+type CacheInspector interface {
+	// Stats counts the keys of each namespace, scanning up to limit keys
+	Stats(ctx context.Context, limit int) (*CacheStats, error)
+
+	// DeleteMatching deletes the keys matching a glob pattern and returns
+	// how many were deleted
+	DeleteMatching(ctx context.Context, pattern string) (int64, error)
+}
+
+// CacheAdminService defines the interface for the cache admin API
+type CacheAdminService interface {
+	// Stats reports key counts by namespace, revoked tokens and the hit
+	// ratio of the cache
+	Stats(ctx context.Context) (*CacheStats, error)
+
+	// Invalidate deletes the keys matching a "<namespace>:<glob>" pattern.
+	// It returns ErrCacheNamespaceProtected for namespaces whose keys
+	// enforce security, such as revocations and single-use markers.
+	Invalidate(ctx context.Context, pattern string) (int64, error)
+}
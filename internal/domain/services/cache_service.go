@@ -21,6 +21,10 @@ type CacheService interface {
 
 	// SetNX sets a value in the cache only if the key doesn't exist
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+
+	// Incr atomically increments a counter and returns its new value. A new
+	// counter starts at 1 and expires after the given expiration.
+	Incr(ctx context.Context, key string, expiration time.Duration) (int64, error)
 }
 
 // CacheSettings represents the configuration settings for cache operations
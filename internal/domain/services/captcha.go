@@ -0,0 +1,11 @@
+package services
+
+import "context"
+
+// CaptchaVerifier checks the CAPTCHA responses clients send with logins to
+// accounts at raised risk
+type CaptchaVerifier interface {
+	// Verify reports whether a CAPTCHA response is valid, given the IP
+	// address of the client that solved it
+	Verify(ctx context.Context, response, remoteIP string) (bool, error)
+}
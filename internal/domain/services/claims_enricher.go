@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"maps"
+)
+
+// TokenTypeID identifies OpenID Connect ID tokens to claims enrichers. ID
+// tokens are signed with the access token key and are not validated by the
+// token service.
+const TokenTypeID TokenType = "id"
+
+// ClaimsEnricher adds custom claims to the access and ID tokens the token
+// service signs, e.g. a deployment's own shape of the organization or
+// permissions of the subject. The subject is in the user_id claim of access
+// tokens and the sub claim of ID tokens.
+type ClaimsEnricher interface {
+	// EnrichClaims adds claims to a token of the given type before it is
+	// signed. Claims the token service set cannot be replaced or removed.
+	EnrichClaims(ctx context.Context, tokenType TokenType, claims map[string]interface{}) error
+}
+
+// ClaimsEnricherFunc adapts a function to the ClaimsEnricher interface
+type ClaimsEnricherFunc func(ctx context.Context, tokenType TokenType, claims map[string]interface{}) error
+
+// EnrichClaims implements ClaimsEnricher
+func (f ClaimsEnricherFunc) EnrichClaims(ctx context.Context, tokenType TokenType, claims map[string]interface{}) error {
+	return f(ctx, tokenType, claims)
+}
+
+// EnrichClaims runs the enrichers over the claims in order and restores the
+// claims they were given, so that enrichers cannot forge the subject,
+// expiry or other registered claims
+func EnrichClaims(ctx context.Context, enrichers []ClaimsEnricher, tokenType TokenType, claims map[string]interface{}) error {
+	if len(enrichers) == 0 {
+		return nil
+	}
+	registered := maps.Clone(claims)
+	for _, enricher := range enrichers {
+		if err := enricher.EnrichClaims(ctx, tokenType, claims); err != nil {
+			return fmt.Errorf("failed to enrich %s token claims: %w", tokenType, err)
+		}
+	}
+	maps.Copy(claims, registered)
+	return nil
+}
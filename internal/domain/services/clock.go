@@ -0,0 +1,20 @@
+package services
+
+import "time"
+
+// Clock tells the current time. Expiry, rotation and rate limiting take it
+// instead of calling time.Now so that tests can control time.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface
+type ClockFunc func() time.Time
+
+// Now implements Clock
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the Clock of the system time
+var SystemClock Clock = ClockFunc(time.Now)
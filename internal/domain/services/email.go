@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// ErrEmailRejected is returned when the mail server permanently rejects an
+// email, so sending it again cannot succeed
+var ErrEmailRejected = errors.New("email rejected")
+
+// EmailData is what email templates can refer to
+type EmailData struct {
+	Email     string
+	FirstName string
+	Username  string
+	Link      string    // verification or password reset link; empty for welcome emails
+	ExpiresAt time.Time // of the link; zero when unknown
+	// Device, IPAddress and Country describe the client of a login from a
+	// new device; empty for other emails
+	Device    string
+	IPAddress string
+	Country   string
+}
+
+// EmailMessage is an email rendered from a template
+type EmailMessage struct {
+	To       string
+	Template string                // one of models.EmailTemplates
+	Override *models.EmailTemplate // the organization's version; nil uses the default
+	Data     EmailData
+}
+
+// EmailSender renders and delivers emails
+type EmailSender interface {
+	// Send renders and delivers an email. Transient failures are retried;
+	// ErrEmailRejected is returned when the email cannot be delivered.
+	Send(ctx context.Context, message EmailMessage) error
+}
@@ -24,6 +24,9 @@ var (
 	// ErrCacheConnectionFailed is returned when the connection to the cache fails
 	ErrCacheConnectionFailed = errors.New("cache connection failed")
 
+	// ErrCacheNamespaceProtected is returned when invalidating cache keys that enforce security, such as revocations
+	ErrCacheNamespaceProtected = errors.New("cache namespace cannot be invalidated")
+
 	// ErrAuthentication is returned when authentication fails
 	ErrAuthentication = errors.New("authentication failed")
 
@@ -33,6 +36,9 @@ var (
 	// ErrUsernameAlreadyExists is returned when attempting to use a username that is already taken
 	ErrUsernameAlreadyExists = errors.New("username already exists")
 
+	// ErrUsernameChangeCooldown is returned when a username is changed again before the cooldown has elapsed
+	ErrUsernameChangeCooldown = errors.New("username was changed too recently")
+
 	// ErrUserAlreadyExists is returned when a user with the same email or username already exists
 	ErrUserAlreadyExists = errors.New("user already exists")
 
@@ -41,8 +47,212 @@ var (
 
 	// ErrTokenRevoked is returned when attempting to use a revoked token
 	ErrTokenRevoked = errors.New("token has been revoked")
+
+	// ErrDeviceMismatch is returned when a device-bound token is used from another device
+	ErrDeviceMismatch = errors.New("token is bound to a different device")
+
+	// ErrDeviceIDRequired is returned when a user whose tokens are bound to
+	// a device signs in or refreshes without a device ID
+	ErrDeviceIDRequired = errors.New("device ID required")
+
+	// ErrAccountDisabled is returned when a suspended or deactivated user attempts to sign in
+	ErrAccountDisabled = errors.New("account is disabled")
+
+	// ErrAccountDeactivated is returned when a user who deleted their account attempts to sign in while it can still be restored
+	ErrAccountDeactivated = errors.New("account is deactivated")
+	// ErrLoginConfirmationRequired is returned when a login from a new device or country waits for the user to confirm it by email
+	ErrLoginConfirmationRequired = errors.New("login confirmation required")
+
+	// ErrPasswordResetRequired is returned when a user whose password must be reset, e.g. after a breach, attempts to sign in with it
+	ErrPasswordResetRequired = errors.New("password reset required")
+
+	// ErrCrossRegionWrite is returned when a request with a token of another region attempts to change data, which only its home region may
+	ErrCrossRegionWrite = errors.New("data can only be changed in its home region")
+
+	// ErrRegionUnavailable is returned when a token's region has no database cluster configured
+	ErrRegionUnavailable = errors.New("region is not available")
+
+	// ErrEmailVerificationRequired is returned when a user who must verify their email first, e.g. after a risky registration, attempts to sign in
+	ErrEmailVerificationRequired = errors.New("email verification required")
+	// ErrEmailNotVerified is returned when a user who has not verified their email attempts to sign in while verified emails are required
+	ErrEmailNotVerified = errors.New("email not verified")
+
+	// ErrCaptchaRequired is returned when a login to an account at raised risk comes without a CAPTCHA response
+	ErrCaptchaRequired = errors.New("captcha required")
+
+	// ErrCaptchaInvalid is returned when the CAPTCHA response of a login is not valid
+	ErrCaptchaInvalid = errors.New("captcha invalid")
+
+	// ErrLoginBlocked is returned when a login to a restricted account cannot be escalated to a second factor because the user has none
+	ErrLoginBlocked = errors.New("login blocked")
+
+	// ErrSessionLimitReached is returned when a user with the maximum number of concurrent sessions logs in again and the policy denies the login
+	ErrSessionLimitReached = errors.New("concurrent session limit reached")
+
+	// ErrAccessPolicyViolation is returned when an organization's access policy denies a login; see AccessPolicyViolation
+	ErrAccessPolicyViolation = errors.New("login denied by access policy")
+
+	// ErrAccountAlreadyReported is returned when a user reports an account they already have an active report for
+	ErrAccountAlreadyReported = errors.New("account already reported")
+
+	// ErrFlagAlreadyReviewed is returned when a flag's review does not follow from its current status
+	ErrFlagAlreadyReviewed = errors.New("flag cannot be reviewed this way")
+
+	// ErrAPIKeyInvalid is returned when an API key is unknown, revoked or expired
+	ErrAPIKeyInvalid = errors.New("invalid API key")
+
+	// ErrNotServiceAccount is returned when API keys are managed for a user that is not a service account
+	ErrNotServiceAccount = errors.New("user is not a service account")
+
+	// ErrWebhookLimitReached is returned when a user already has the maximum number of notification webhooks
+	ErrWebhookLimitReached = errors.New("webhook limit reached")
+
+	// ErrVerificationLimitReached is returned when a user has been sent the maximum number of verification emails for the day
+	ErrVerificationLimitReached = errors.New("verification email limit reached")
+
+	// ErrKeyRotationUnsupported is returned when the token service cannot rotate its signing keys
+	ErrKeyRotationUnsupported = errors.New("signing key rotation is not supported")
+
+	// ErrInvalidToken is returned when a token is missing, malformed or expired
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrRevocationUnavailable is returned when a token cannot be accepted
+	// because its revocation status cannot be checked
+	ErrRevocationUnavailable = errors.New("token revocation status unavailable")
+
+	// ErrSearchUnavailable is returned when user search is not enabled
+	ErrSearchUnavailable = errors.New("user search is not enabled")
+
+	// ErrUnknownIdentityProvider is returned for a login with a provider that is not configured
+	ErrUnknownIdentityProvider = errors.New("unknown identity provider")
+
+	// ErrFederationStateInvalid is returned when a provider callback does not match a started login
+	ErrFederationStateInvalid = errors.New("invalid or expired login state")
+
+	// ErrExternalEmailUnverified is returned when an unknown external identity has no verified email
+	ErrExternalEmailUnverified = errors.New("identity provider did not verify the email address")
+
+	// ErrAccountLinkRequiresVerification is returned when an external identity
+	// matches a user whose own email is not verified yet
+	ErrAccountLinkRequiresVerification = errors.New("verify the existing account's email before linking")
+
+	// ErrIdentityAlreadyLinked is returned when linking an external identity
+	// that is linked to another user
+	ErrIdentityAlreadyLinked = errors.New("external identity is linked to another user")
+
+	// ErrLastLoginMethod is returned when removing the only way a user can sign in
+	ErrLastLoginMethod = errors.New("cannot remove the last sign-in method")
+
+	// ErrMFACodeInvalid is returned when a one-time code is wrong, expired or replayed
+	ErrMFACodeInvalid = errors.New("invalid MFA code")
+
+	// ErrMFATokenInvalid is returned when an MFA challenge token is unknown,
+	// expired or was used for too many wrong codes
+	ErrMFATokenInvalid = errors.New("invalid or expired MFA token")
+
+	// ErrMFATooManyAttempts is returned when a user entered too many wrong
+	// codes and has to wait before trying again
+	ErrMFATooManyAttempts = errors.New("too many MFA attempts")
+
+	// ErrMFAAlreadyEnabled is returned when setting up an authenticator for a user who has one
+	ErrMFAAlreadyEnabled = errors.New("MFA is already enabled")
+
+	// ErrMFANotEnabled is returned when a user has no authenticator set up
+	ErrMFANotEnabled = errors.New("MFA is not enabled")
+
+	// ErrMFARequiredByPolicy is returned when disabling MFA that an enforced policy requires
+	ErrMFARequiredByPolicy = errors.New("MFA is required by policy")
+
+	// ErrPasskeysNotEnabled is returned when passkeys are used while they are not enabled
+	ErrPasskeysNotEnabled = errors.New("passkeys are not enabled")
+
+	// ErrPasskeyInvalid is returned when an authenticator's response does not verify
+	ErrPasskeyInvalid = errors.New("invalid passkey response")
+
+	// ErrPasskeyChallengeInvalid is returned when a passkey registration or
+	// sign-in token is unknown, expired or was already used
+	ErrPasskeyChallengeInvalid = errors.New("invalid or expired passkey challenge")
+
+	// ErrMagicLinksNotEnabled is returned when magic links are used while
+	// they are not enabled
+	ErrMagicLinksNotEnabled = errors.New("magic links are not enabled")
+
+	// ErrStepUpFailed is returned when an admin's re-authentication before a
+	// sensitive action fails
+	ErrStepUpFailed = errors.New("step-up authentication failed")
+
+	// ErrAdminPasswordResetLimitReached is returned when an admin requested
+	// the maximum number of password resets for other users in the last hour
+	ErrAdminPasswordResetLimitReached = errors.New("admin password reset limit reached")
+
+	// ErrPurgeApprovalInvalid is returned when a purge approval token is
+	// unknown, expired, already used or approves the purge of another user
+	ErrPurgeApprovalInvalid = errors.New("invalid or expired purge approval")
+
+	// ErrPurgeApprovalSelf is returned when an admin purges a user with an
+	// approval they issued themselves
+	ErrPurgeApprovalSelf = errors.New("purge must be approved by a second admin")
+
+	// ErrRoleBuiltIn is returned when a change would delete a built-in role
+	// or take role management away from the admin role
+	ErrRoleBuiltIn = errors.New("built-in roles cannot be changed this way")
+
+	// ErrRoleInUse is returned when deleting a role users still hold
+	ErrRoleInUse = errors.New("role is assigned to users")
+
+	// ErrKnowledgeFactorsNotEnabled is returned when security questions are
+	// used while they are not enabled
+	ErrKnowledgeFactorsNotEnabled = errors.New("security questions are not enabled")
+
+	// ErrKnowledgeFactorsInvalid is returned when recovering an account with
+	// wrong answers, or an account that has no security questions
+	ErrKnowledgeFactorsInvalid = errors.New("invalid security question answers")
+
+	// ErrKnowledgeFactorAttemptsExceeded is returned when an account had the
+	// maximum number of failed security question recoveries for the day
+	ErrKnowledgeFactorAttemptsExceeded = errors.New("too many security question attempts")
+
+	// ErrAudienceTokensNotEnabled is returned when minting audience-scoped
+	// tokens while no audiences are configured
+	ErrAudienceTokensNotEnabled = errors.New("audience tokens are not enabled")
+
+	// ErrAudienceForbidden is returned when a user may not mint tokens for
+	// an audience
+	ErrAudienceForbidden = errors.New("not allowed to mint tokens for this audience")
+
+	// ErrOrganizationLastOwner is returned when removing the only owner of an organization
+	ErrOrganizationLastOwner = errors.New("organization must keep an owner")
+
+	// ErrDomainUnverified is returned when the DNS record proving
+	// ownership of a domain is missing
+	ErrDomainUnverified = errors.New("domain verification record not found")
+
+	// ErrSSOConnectionNotFound is returned when no enabled SSO connection
+	// exists for a login, including when no connection claims an email's domain
+	ErrSSOConnectionNotFound = errors.New("no SSO connection found")
+
+	// ErrSSODomainMismatch is returned when an SSO connection signs in a user
+	// whose email is not in one of the connection's verified domains
+	ErrSSODomainMismatch = errors.New("email domain is not verified for the SSO connection")
 )
 
+// AccessPolicyViolation is returned when an organization's access policy
+// denies a login. It matches ErrAccessPolicyViolation; Code is one of the
+// models.AccessDenied codes.
+type AccessPolicyViolation struct {
+	Code string
+}
+
+// Error implements the error interface
+func (e *AccessPolicyViolation) Error() string {
+	return ErrAccessPolicyViolation.Error() + ": " + e.Code
+}
+
+// Unwrap returns ErrAccessPolicyViolation
+func (e *AccessPolicyViolation) Unwrap() error {
+	return ErrAccessPolicyViolation
+}
+
 // IsNotFoundError checks if the given error is a not found error
 func IsNotFoundError(err error) bool {
 	return errors.Is(err, ErrNotFound)
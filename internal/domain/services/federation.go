@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// ExternalIdentity is a user as described by an external identity provider
+type ExternalIdentity struct {
+	Provider string // configured provider name, e.g. google
+	Subject  string // the provider's stable user ID
+	Email    string
+	// EmailVerified reports whether the provider vouches for the email;
+	// only verified emails link to existing users or create new ones
+	EmailVerified bool
+	Username      string // suggested username, may be empty
+	FirstName     string
+	LastName      string
+	// OrganizationID is the organization whose SSO connection vouched for
+	// the identity; the user becomes a member of it
+	OrganizationID *uuid.UUID
+	// LinkingUserID is the signed-in user who started the login to link the
+	// identity to their account, nil for sign-ins
+	LinkingUserID *uuid.UUID
+}
+
+// LoginMethods are the ways a user can sign in
+type LoginMethods struct {
+	HasPassword bool
+	Passkeys    []*models.WebAuthnCredential
+	Identities  []*models.UserIdentity
+}
+
+// Count returns how many ways the user can sign in
+func (m *LoginMethods) Count() int {
+	count := len(m.Passkeys) + len(m.Identities)
+	if m.HasPassword {
+		count++
+	}
+	return count
+}
+
+// FederatedLogin is a started login with an external identity provider
+type FederatedLogin struct {
+	// AuthorizationURL is where the browser is sent to sign in
+	AuthorizationURL string
+	// State identifies the login on the callback. It must be bound to the
+	// browser, e.g. in a cookie, to prevent login CSRF.
+	State string
+}
+
+// FederationService defines the interface for signing in with external
+// identity providers through the OAuth 2.0 authorization code flow
+type FederationService interface {
+	// Providers returns the names of the configured providers
+	Providers() []string
+
+	// BeginLogin starts a login with the given provider
+	BeginLogin(ctx context.Context, provider string) (*FederatedLogin, error)
+
+	// BeginLink starts a login with the given provider that links the
+	// identity to the signed-in user instead of signing in
+	BeginLink(ctx context.Context, provider string, userID uuid.UUID) (*FederatedLogin, error)
+
+	// CompleteLogin exchanges the authorization code returned to the
+	// callback for the user's identity. Each state can be completed once.
+	CompleteLogin(ctx context.Context, provider, state, code string) (*ExternalIdentity, error)
+}
@@ -1,13 +1,19 @@
 package services
 
+import "context"
+
 // MetricsService defines the interface for collecting and managing application metrics
 type MetricsService interface {
-	// RecordRequest records an incoming request with its duration and status
-	RecordRequest(path string, method string, statusCode int, duration float64)
+	// RecordRequest records an incoming request with its duration and status.
+	// The trace of the request in ctx may be attached as an exemplar.
+	RecordRequest(ctx context.Context, path string, method string, statusCode int, duration float64)
 	
 	// IncrementCounter increments a named counter
 	IncrementCounter(name string, labels map[string]string)
 	
 	// ObserveValue records a value observation for a metric
 	ObserveValue(name string, value float64, labels map[string]string)
+
+	// ObserveHistogram records a sample, such as a latency in seconds, in a named histogram
+	ObserveHistogram(name string, value float64, labels map[string]string)
 }
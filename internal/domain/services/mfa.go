@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// MFAAction is what a login waiting for a second factor needs next
+type MFAAction string
+
+const (
+	// MFAActionVerify asks for a code of the user's authenticator
+	MFAActionVerify MFAAction = "verify"
+	// MFAActionEnroll asks the user to set up an authenticator, which a
+	// policy requires before they may sign in
+	MFAActionEnroll MFAAction = "enroll"
+)
+
+// MFAChallenge is a login waiting for a second factor. Its token stands in
+// for the password on the MFA endpoints until it expires.
+type MFAChallenge struct {
+	Token     string
+	Action    MFAAction
+	ExpiresAt time.Time
+}
+
+// MFARequirement is what the MFA policies covering a user demand
+type MFARequirement struct {
+	Required bool
+	// EnforceAfter is when the earliest covering policy takes effect; users
+	// without a second factor are reminded at login until then
+	EnforceAfter time.Time
+}
+
+// Enforced reports whether the requirement is in effect at the given time
+func (r MFARequirement) Enforced(at time.Time) bool {
+	return r.Required && !at.Before(r.EnforceAfter)
+}
+
+// MFAStatus describes a user's second factor and what policies demand of them
+type MFAStatus struct {
+	TOTPEnabled bool
+	EnabledAt   *time.Time
+	Requirement MFARequirement
+}
+
+// TOTPEnrollment is a started authenticator app setup
+type TOTPEnrollment struct {
+	Secret string // base32 encoded, for manual entry
+	URI    string // otpauth:// URI, usually shown as QR code
+}
+
+// TOTPService defines the interface for time-based one-time passwords (RFC 6238)
+type TOTPService interface {
+	// GenerateSecret returns a new random base32 encoded secret
+	GenerateSecret() (string, error)
+
+	// KeyURI returns the otpauth:// URI authenticator apps import the secret from
+	KeyURI(secret, accountName string) string
+
+	// Validate checks a code at the given time. It returns the time step the
+	// code belongs to, which callers use to reject replayed codes.
+	Validate(secret, code string, at time.Time) (int64, bool)
+}
+
+// MFAPolicyService defines the interface for managing and evaluating MFA
+// enforcement policies
+type MFAPolicyService interface {
+	// ListPolicies returns all policies, earliest enforcement first
+	ListPolicies(ctx context.Context) ([]*models.MFAPolicy, error)
+
+	// GetPolicy retrieves a policy by ID
+	GetPolicy(ctx context.Context, id uuid.UUID) (*models.MFAPolicy, error)
+
+	// CreatePolicy validates and stores a new policy
+	CreatePolicy(ctx context.Context, policy *models.MFAPolicy) error
+
+	// UpdatePolicy validates and replaces a policy
+	UpdatePolicy(ctx context.Context, policy *models.MFAPolicy) error
+
+	// DeletePolicy removes a policy
+	DeletePolicy(ctx context.Context, id uuid.UUID) error
+
+	// Requirement evaluates the policies covering a user, including the
+	// RequireMFA setting of their organization
+	Requirement(ctx context.Context, user *models.User) (MFARequirement, error)
+}
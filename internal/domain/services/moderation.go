@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+)
+
+// ScopeRestricted is the scope of first-party access tokens issued to a
+// flagged account, which only allow reading
+const ScopeRestricted = "restricted"
+
+// FlagAccountInput represents the input for flagging an account
+type FlagAccountInput struct {
+	UserID     uuid.UUID
+	Category   models.FlagCategory
+	Reason     string
+	ReportedBy uuid.UUID
+	ByAdmin    bool // flagged through the admin API rather than reported by a user
+}
+
+// ReviewFlagInput represents an admin's decision on a flag
+type ReviewFlagInput struct {
+	Status     models.FlagStatus // confirmed or dismissed
+	ReviewerID uuid.UUID
+	Note       string
+}
+
+// ModerationService defines the interface for abuse reports, the review
+// queue and the restrictions of flagged accounts
+type ModerationService interface {
+	// FlagAccount reports an account. A user can have one active report
+	// per account.
+	FlagAccount(ctx context.Context, input FlagAccountInput) (*models.AccountFlag, error)
+
+	// ListFlags returns the review queue, oldest first
+	ListFlags(ctx context.Context, filter repositories.AccountFlagFilter, offset, limit int) ([]*models.AccountFlag, error)
+
+	// ReviewFlag confirms or dismisses a flag
+	ReviewFlag(ctx context.Context, id uuid.UUID, input ReviewFlagInput) (*models.AccountFlag, error)
+
+	// IsRestricted reports whether an account is restricted because of its
+	// flags
+	IsRestricted(ctx context.Context, userID uuid.UUID) (bool, error)
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// OpenID Connect scopes understood by the provider
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// OAuth 2.0 error codes (RFC 6749 sections 4.1.2.1 and 5.2, OpenID Connect
+// Core section 3.1.2.6)
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthUnauthorizedClient      = "unauthorized_client"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthAccessDenied            = "access_denied"
+	OAuthLoginRequired           = "login_required"
+	OAuthInsufficientScope       = "insufficient_scope"
+)
+
+// Provider endpoint paths, relative to the issuer URL
+const (
+	OAuthAuthorizePath       = "/oauth2/authorize"
+	OAuthTokenPath           = "/oauth2/token"
+	OAuthUserInfoPath        = "/oauth2/userinfo"
+	OAuthIntrospectPath      = "/oauth2/introspect"
+	OAuthIntrospectBatchPath = "/oauth2/introspect/batch"
+	OAuthRevokePath          = "/oauth2/revoke"
+	OIDCDiscoveryPath        = "/.well-known/openid-configuration"
+	JWKSPath                 = "/.well-known/jwks.json"
+)
+
+// OAuthError is an OAuth 2.0 error returned to the client
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+// Error implements the error interface
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// NewOAuthError returns an OAuth 2.0 error with the given code
+func NewOAuthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// OAuthClientRegistration represents the input for registering an OAuth client
+type OAuthClientRegistration struct {
+	Name         string
+	RedirectURIs []string
+	GrantTypes   []models.OAuthGrantType
+	Scopes       []string
+	Public       bool // public clients get no secret and must use PKCE
+}
+
+// OAuthClientUpdate represents the input for reconfiguring an OAuth client.
+// Empty names and nil lists are left unchanged.
+type OAuthClientUpdate struct {
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+}
+
+// AuthorizationRequest is an authorization code request (RFC 6749 section
+// 4.1.1) with the PKCE parameters of RFC 7636
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// OAuthTokenRequest is a request to the token endpoint. The client
+// credentials come from HTTP basic auth or the request body.
+type OAuthTokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	Scope        string
+}
+
+// OAuthTokens is a successful token endpoint response
+type OAuthTokens struct {
+	AccessToken string
+	IDToken     string // only for authorization codes granted the openid scope
+	ExpiresIn   time.Duration
+	Scope       string
+}
+
+// TokenRequest is a request about a token to the introspection (RFC 7662
+// section 2.1) or revocation (RFC 7009 section 2.1) endpoint. The client
+// credentials come from HTTP basic auth or the request body.
+type TokenRequest struct {
+	ClientID      string
+	ClientSecret  string
+	Token         string
+	TokenTypeHint string
+}
+
+// BatchTokenRequest is a request to introspect several tokens at once. The
+// client credentials come from HTTP basic auth or the request body.
+type BatchTokenRequest struct {
+	ClientID     string
+	ClientSecret string
+	Tokens       []string
+}
+
+// TokenIntrospection describes a token (RFC 7662 section 2.2). Only Active
+// is set for tokens that are invalid, expired or revoked.
+type TokenIntrospection struct {
+	Active    bool
+	Scope     string
+	ClientID  string
+	Username  string
+	Subject   string // the user, or the client of client credentials tokens
+	Issuer    string
+	IssuedAt  time.Time
+	NotBefore time.Time // zero for tokens active when issued
+	ExpiresAt time.Time
+	Audience  string // the internal API of audience-scoped tokens
+}
+
+// ProviderMetadata describes the provider for OpenID Connect discovery
+type ProviderMetadata struct {
+	Issuer                           string
+	AuthorizationEndpoint            string
+	TokenEndpoint                    string
+	UserInfoEndpoint                 string
+	IntrospectionEndpoint            string
+	RevocationEndpoint               string
+	JWKSURI                          string
+	ScopesSupported                  []string
+	ResponseTypesSupported           []string
+	GrantTypesSupported              []string
+	SubjectTypesSupported            []string
+	IDTokenSigningAlgValuesSupported []string
+	TokenEndpointAuthMethods         []string
+	CodeChallengeMethodsSupported    []string
+}
+
+// OAuthService defines the OAuth 2.0 / OpenID Connect provider operations
+type OAuthService interface {
+	// RegisterClient registers a client and returns it with its secret,
+	// which is not stored and cannot be retrieved again. Public clients get
+	// an empty secret.
+	RegisterClient(ctx context.Context, registration OAuthClientRegistration) (*models.OAuthClient, string, error)
+
+	// ListClients retrieves every registered client
+	ListClients(ctx context.Context) ([]*models.OAuthClient, error)
+
+	// UpdateClient changes the name, redirect URIs or scopes of a client
+	UpdateClient(ctx context.Context, clientID string, update OAuthClientUpdate) (*models.OAuthClient, error)
+
+	// DisableClient stops a client from authenticating and signing users in
+	// until it is enabled again. Tokens already issued to it stay valid until
+	// they expire.
+	DisableClient(ctx context.Context, clientID string) (*models.OAuthClient, error)
+
+	// EnableClient lifts DisableClient
+	EnableClient(ctx context.Context, clientID string) (*models.OAuthClient, error)
+
+	// DeleteClient removes a client. Tokens already issued to it stay valid
+	// until they expire.
+	DeleteClient(ctx context.Context, clientID string) error
+
+	// RegenerateClientSecret replaces the secret of a confidential client.
+	// The old secret keeps working for overlap, so that deployments of the
+	// client can switch over; 0 revokes it immediately.
+	RegenerateClientSecret(ctx context.Context, clientID string, overlap time.Duration) (*models.OAuthClient, string, error)
+
+	// ValidateRedirect checks the client and redirect URI of an authorization
+	// request. Only once they are valid may errors be redirected to the client.
+	ValidateRedirect(ctx context.Context, request AuthorizationRequest) error
+
+	// Authorize grants an authorization code to the client for the signed-in user
+	Authorize(ctx context.Context, userID uuid.UUID, request AuthorizationRequest) (string, error)
+
+	// Token exchanges an authorization code or client credentials for tokens
+	Token(ctx context.Context, request OAuthTokenRequest) (*OAuthTokens, error)
+
+	// Introspect describes an access token to an authenticated confidential
+	// client, consulting the revocation store
+	Introspect(ctx context.Context, request TokenRequest) (*TokenIntrospection, error)
+
+	// IntrospectBatch describes several access tokens to an authenticated
+	// confidential client in one call, in the order given
+	IntrospectBatch(ctx context.Context, request BatchTokenRequest) ([]TokenIntrospection, error)
+
+	// Revoke revokes an access token issued to the authenticated client.
+	// Invalid and expired tokens need no revoking and are ignored.
+	Revoke(ctx context.Context, request TokenRequest) error
+
+	// UserInfo returns the claims about a user released for the granted scope
+	UserInfo(ctx context.Context, userID uuid.UUID, scope string) (*UserInfo, error)
+
+	// Metadata describes the provider for discovery
+	Metadata() ProviderMetadata
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// OrganizationMember is a user together with their membership of an organization
+type OrganizationMember struct {
+	User       *models.User
+	Membership *models.OrganizationMembership
+}
+
+// OrganizationService defines the interface for managing organizations and
+// their members. Members' organization roles decide what they may do:
+// owners and admins manage members, and only owners manage owners.
+type OrganizationService interface {
+	// CreateOrganization creates an organization owned by the given user
+	CreateOrganization(ctx context.Context, name string, ownerID uuid.UUID) (*models.Organization, error)
+
+	// ListOrganizations returns the organizations a user is a member of
+	ListOrganizations(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error)
+
+	// GetOrganization retrieves an organization for one of its members. It
+	// returns ErrNotFound for users who are not members.
+	GetOrganization(ctx context.Context, id, actorID uuid.UUID) (*models.Organization, error)
+
+	// ListMembers returns a page of the members of an organization for one
+	// of its members
+	ListMembers(ctx context.Context, id, actorID uuid.UUID, offset, limit int) ([]*OrganizationMember, error)
+
+	// InviteMember adds the user with the given email to an organization
+	// with the given role
+	InviteMember(ctx context.Context, id uuid.UUID, email string, role models.OrganizationRole, actorID uuid.UUID) (*OrganizationMember, error)
+
+	// RemoveMember removes a user from an organization. Members may remove
+	// themselves; the last owner cannot be removed.
+	RemoveMember(ctx context.Context, id, userID, actorID uuid.UUID) error
+
+	// SetDomainJoinPolicy sets what happens to users whose verified email is
+	// in one of the organization's verified domains
+	SetDomainJoinPolicy(ctx context.Context, id uuid.UUID, policy models.DomainJoinPolicy, actorID uuid.UUID) (*models.Organization, error)
+
+	// ListDomains returns the email domains an organization claimed
+	ListDomains(ctx context.Context, id, actorID uuid.UUID) ([]*models.OrganizationDomain, error)
+
+	// AddDomain claims an email domain for an organization with a new
+	// verification token
+	AddDomain(ctx context.Context, id uuid.UUID, domain string, actorID uuid.UUID) (*models.OrganizationDomain, error)
+
+	// VerifyDomain checks DNS for the TXT record holding the verification
+	// token of a claimed domain. It returns ErrDomainUnverified when the
+	// record is missing.
+	VerifyDomain(ctx context.Context, id uuid.UUID, domain string, actorID uuid.UUID) (*models.OrganizationDomain, error)
+
+	// RemoveDomain removes a domain claim from an organization
+	RemoveDomain(ctx context.Context, id uuid.UUID, domain string, actorID uuid.UUID) error
+
+	// ListInvitations returns the organizations a user is invited into
+	ListInvitations(ctx context.Context, userID uuid.UUID) ([]*OrganizationInvitation, error)
+
+	// AcceptInvitation makes a user a member of an organization they are
+	// invited into
+	AcceptInvitation(ctx context.Context, id, userID uuid.UUID) (*models.OrganizationMembership, error)
+
+	// DeclineInvitation removes the invitation of a user into an organization
+	DeclineInvitation(ctx context.Context, id, userID uuid.UUID) error
+}
+
+// OrganizationInvitation is an invitation together with the organization it
+// invites into
+type OrganizationInvitation struct {
+	Organization *models.Organization
+	Invitation   *models.OrganizationInvitation
+}
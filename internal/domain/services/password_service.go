@@ -10,11 +10,28 @@ type PasswordService interface {
 	// VerifyPassword verifies if a password matches its hash
 	VerifyPassword(ctx context.Context, password, hash string) error
 
+	// NeedsRehash reports whether a hash was made with another algorithm or
+	// other parameters than the configured ones, so that it should be
+	// replaced once the password is verified
+	NeedsRehash(hash string) bool
+
 	// GenerateRandomPassword generates a random password
 	GenerateRandomPassword(ctx context.Context) (string, error)
 
 	// ValidatePassword validates password strength
 	ValidatePassword(ctx context.Context, password string) error
+
+	// ValidatePasswordPolicy validates password strength against the given
+	// policy instead of the configured one
+	ValidatePasswordPolicy(ctx context.Context, password string, policy PasswordConfig) error
+}
+
+// SecretHasher hashes low-entropy secrets other than passwords, such as the
+// answers to security questions, with a slow hash. Unlike the password
+// service it applies no strength policy.
+type SecretHasher interface {
+	Hash(secret string) (string, error)
+	Verify(secret, hash string) error
 }
 
 // PasswordConfig represents the configuration for password operations
@@ -0,0 +1,30 @@
+package services
+
+import "context"
+
+// RegistrationRiskInput describes a registration attempt for risk scoring
+type RegistrationRiskInput struct {
+	Email     string
+	IPAddress string
+	DeviceID  string
+	UserAgent string
+	// Registrations from the same IP address and device during the last
+	// hour, not counting this one
+	RecentFromIP     int
+	RecentFromDevice int
+}
+
+// RegistrationRisk is the score of a registration attempt, from 0 for no
+// risk to 100, with the reasons that contributed to it
+type RegistrationRisk struct {
+	Score   int
+	Reasons []string
+}
+
+// RegistrationRiskScorer scores registration attempts. Depending on the
+// score, a registration is approved, has to verify its email before
+// signing in, or is queued for manual review.
+type RegistrationRiskScorer interface {
+	// Score scores a registration attempt
+	Score(ctx context.Context, input RegistrationRiskInput) (*RegistrationRisk, error)
+}
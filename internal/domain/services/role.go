@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// RoleService defines the interface for managing roles, the permissions
+// they grant and the roles of users
+type RoleService interface {
+	// ListRoles returns all roles with their permissions, ordered by name
+	ListRoles(ctx context.Context) ([]*models.RoleDefinition, error)
+
+	// GetRole retrieves a role with its permissions
+	GetRole(ctx context.Context, name models.Role) (*models.RoleDefinition, error)
+
+	// CreateRole validates and stores a new role
+	CreateRole(ctx context.Context, role *models.RoleDefinition) error
+
+	// DeleteRole removes a role no user holds. Built-in roles cannot be deleted.
+	DeleteRole(ctx context.Context, name models.Role) error
+
+	// SetPermissions replaces the permissions a role grants
+	SetPermissions(ctx context.Context, name models.Role, permissions []models.Permission) (*models.RoleDefinition, error)
+
+	// GrantPermission adds a permission to a role
+	GrantPermission(ctx context.Context, name models.Role, permission models.Permission) (*models.RoleDefinition, error)
+
+	// RevokePermission removes a permission from a role
+	RevokePermission(ctx context.Context, name models.Role, permission models.Permission) (*models.RoleDefinition, error)
+
+	// AssignRole gives a user another role on behalf of an admin. The user's
+	// sessions are revoked so that no token keeps the old permissions.
+	AssignRole(ctx context.Context, userID uuid.UUID, name models.Role, assignedBy uuid.UUID) (*models.User, error)
+
+	// Permissions returns the permissions a role grants, cached briefly as
+	// tokens are issued and checked with them. Unknown roles grant nothing.
+	Permissions(ctx context.Context, name models.Role) ([]models.Permission, error)
+}
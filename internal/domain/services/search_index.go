@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// UserDocument is the representation of a user kept in the search index
+type UserDocument struct {
+	ID            uuid.UUID         `json:"id"`
+	Email         string            `json:"email"`
+	Username      string            `json:"username"`
+	FirstName     string            `json:"firstName"`
+	LastName      string            `json:"lastName"`
+	Status        models.UserStatus `json:"status"`
+	Role          models.Role       `json:"role"`
+	EmailVerified bool              `json:"emailVerified"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+}
+
+// NewUserDocument maps a user to its search document
+func NewUserDocument(user *models.User) *UserDocument {
+	return &UserDocument{
+		ID:            user.ID,
+		Email:         user.Email,
+		Username:      user.Username,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Status:        user.Status,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+// UserSearchQuery is a full-text search over users
+type UserSearchQuery struct {
+	Query  string            // matched against email, username and name; empty matches all
+	Status models.UserStatus // optional status filter
+	Offset int
+	Limit  int
+}
+
+// UserSearchResult is a page of users matching a search
+type UserSearchResult struct {
+	Total int64 // number of matching users, of which Users is one page
+	Users []*UserDocument
+}
+
+// UserSearchIndex is a secondary index of users for full-text search. It is
+// kept in sync from the event stream and may lag behind the database.
+type UserSearchIndex interface {
+	// IndexUser creates or replaces the document of a user
+	IndexUser(ctx context.Context, document *UserDocument) error
+
+	// DeleteUser removes the document of a user; removing a missing document is not an error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+
+	// SearchUsers returns the users matching the query, best matches first
+	SearchUsers(ctx context.Context, query UserSearchQuery) (*UserSearchResult, error)
+}
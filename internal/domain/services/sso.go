@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// OIDCEndpoints are the endpoints of an OpenID Connect provider
+type OIDCEndpoints struct {
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+}
+
+// SSOConnector signs users in with the identity providers of SSO connections
+// through the OAuth 2.0 authorization code flow
+type SSOConnector interface {
+	// Discover reads the endpoints of an OpenID Connect issuer from its
+	// discovery document
+	Discover(ctx context.Context, issuer string) (*OIDCEndpoints, error)
+
+	// RedirectURL returns the callback URL to register with the identity
+	// provider of a connection
+	RedirectURL(connection *models.SSOConnection) string
+
+	// BeginLogin starts a login with the identity provider of a connection
+	BeginLogin(ctx context.Context, connection *models.SSOConnection) (*FederatedLogin, error)
+
+	// CompleteLogin exchanges the authorization code returned to the
+	// callback for the user's identity. Each state can be completed once.
+	CompleteLogin(ctx context.Context, connection *models.SSOConnection, state, code string) (*ExternalIdentity, error)
+}
+
+// TXTResolver looks up DNS TXT records; *net.Resolver implements it
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// SSOConnectionInput holds the settings of an SSO connection
+type SSOConnectionInput struct {
+	Name     string
+	Issuer   string // OpenID Connect issuer URL
+	ClientID string
+	// ClientSecret may be left empty on updates to keep the current one
+	ClientSecret string
+	Enabled      bool
+}
+
+// SSOConnectionDetails is an SSO connection with the domains it claims
+type SSOConnectionDetails struct {
+	Connection *models.SSOConnection
+	Domains    []*models.SSODomain
+	// RedirectURL is the callback URL to register with the identity provider
+	RedirectURL string
+}
+
+// SSOService defines the interface for the SSO connections organizations
+// sign their members in with. Owners and admins of an organization manage
+// its connections; users are sent to a connection when the domain of their
+// email is one the connection verified.
+type SSOService interface {
+	// ListConnections returns the connections of an organization
+	ListConnections(ctx context.Context, organizationID, actorID uuid.UUID) ([]*SSOConnectionDetails, error)
+
+	// GetConnection retrieves a connection of an organization
+	GetConnection(ctx context.Context, organizationID, connectionID, actorID uuid.UUID) (*SSOConnectionDetails, error)
+
+	// CreateConnection validates and stores a new connection, discovering
+	// the endpoints of its issuer
+	CreateConnection(ctx context.Context, organizationID uuid.UUID, input SSOConnectionInput, actorID uuid.UUID) (*SSOConnectionDetails, error)
+
+	// UpdateConnection replaces the settings of a connection
+	UpdateConnection(ctx context.Context, organizationID, connectionID uuid.UUID, input SSOConnectionInput, actorID uuid.UUID) (*SSOConnectionDetails, error)
+
+	// DeleteConnection removes a connection with its domains
+	DeleteConnection(ctx context.Context, organizationID, connectionID, actorID uuid.UUID) error
+
+	// AddDomain claims an email domain for a connection. The claim takes
+	// effect once verified.
+	AddDomain(ctx context.Context, organizationID, connectionID uuid.UUID, domain string, actorID uuid.UUID) (*models.SSODomain, error)
+
+	// VerifyDomain checks DNS for the verification token of a claimed domain
+	VerifyDomain(ctx context.Context, organizationID, connectionID uuid.UUID, domain string, actorID uuid.UUID) (*models.SSODomain, error)
+
+	// RemoveDomain removes a domain from a connection
+	RemoveDomain(ctx context.Context, organizationID, connectionID uuid.UUID, domain string, actorID uuid.UUID) error
+
+	// Discover returns the enabled connection users with the given email
+	// sign in with, for home realm discovery
+	Discover(ctx context.Context, email string) (*models.SSOConnection, error)
+
+	// BeginLogin starts a login with an enabled connection
+	BeginLogin(ctx context.Context, connectionID uuid.UUID) (*FederatedLogin, error)
+
+	// CompleteLogin completes a login with a connection. The identity names
+	// the connection's organization, and its email is in one of the
+	// connection's verified domains.
+	CompleteLogin(ctx context.Context, connectionID uuid.UUID, state, code string) (*ExternalIdentity, error)
+}
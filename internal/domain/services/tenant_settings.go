@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// TenantSettings are the overrides in effect for the users of an
+// organization. Zero values leave the service-wide configuration in place.
+type TenantSettings struct {
+	PasswordPolicy       *PasswordConfig // nil uses the password service's policy
+	AccessTokenLifetime  time.Duration   // shortens the configured lifetime when positive
+	RefreshTokenLifetime time.Duration   // shortens the configured lifetime when positive
+	RequireMFA           bool
+	MaxSessions          int                       // overrides the concurrent session limit when positive
+	SessionLimitAction   models.SessionLimitAction // empty uses the service-wide action
+	// EmailTemplates holds the organization's templates by name; emails
+	// without one use the default template
+	EmailTemplates map[string]models.EmailTemplate
+	AccessPolicy   *models.AccessPolicy // nil allows logins from anywhere at any time
+	// SecuritySummaries turns monthly security summaries on or off for the
+	// organization's users; nil uses the service-wide setting
+	SecuritySummaries *bool
+}
+
+// TenantSettingsService defines the interface for resolving and managing
+// per-organization configuration overrides
+type TenantSettingsService interface {
+	// Resolve returns the settings in effect for an organization. A nil
+	// organizationID resolves to no overrides.
+	Resolve(ctx context.Context, organizationID *uuid.UUID) (*TenantSettings, error)
+
+	// GetOverrides retrieves the overrides of an organization
+	GetOverrides(ctx context.Context, organizationID uuid.UUID) (*models.OrganizationSettings, error)
+
+	// SaveOverrides validates and replaces the overrides of an organization
+	SaveOverrides(ctx context.Context, settings *models.OrganizationSettings) error
+
+	// DeleteOverrides removes the overrides of an organization, which then
+	// uses the service-wide configuration
+	DeleteOverrides(ctx context.Context, organizationID uuid.UUID) error
+}
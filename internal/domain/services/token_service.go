@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,8 +21,13 @@ const (
 	TokenTypeReset TokenType = "reset"
 	// TokenTypeVerification represents an email verification token
 	TokenTypeVerification TokenType = "verification"
+	// TokenTypeMagicLink represents a single-use passwordless login token
+	TokenTypeMagicLink TokenType = "magic_link"
 )
 
+// BearerTokenType is the OAuth2 token type reported to clients
+const BearerTokenType = "Bearer"
+
 // TokenClaims represents the claims in a JWT token
 type TokenClaims struct {
 	UserID    uuid.UUID `json:"user_id"`
@@ -28,6 +35,81 @@ type TokenClaims struct {
 	Username  string    `json:"username"`
 	Role      string    `json:"role"`
 	TokenType TokenType `json:"token_type"`
+	SessionID string    `json:"sid,omitempty"` // shared by an access and refresh token pair
+	// DeviceFingerprint is a hash of the device ID captured at login that
+	// refresh requests must present when device binding is enforced
+	DeviceFingerprint string `json:"dfp,omitempty"`
+	// ClientID and Scope are set on tokens issued to OAuth clients. Client
+	// credentials tokens have no user and a nil UserID.
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// Permissions are the permissions the user's role granted when an
+	// access token was issued
+	Permissions []string `json:"permissions,omitempty"`
+	// TenantID is the ID of the organization of the user, if any
+	TenantID string `json:"tenant_id,omitempty"`
+	// Region is the home region of the user's data. Tokens are stamped with
+	// the region of the deployment that issued them.
+	Region string `json:"region,omitempty"`
+	// GivenName, FamilyName and EmailVerified come from the user's profile
+	// when profile claims are enabled. EmailVerified is nil otherwise.
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	// Audience is the internal API a token was minted for. Such tokens are
+	// refused by this service's own API.
+	Audience string `json:"aud,omitempty"`
+	// Lifetime shortens the configured lifetime of the token type when
+	// positive, e.g. for an organization's override. It is not a claim.
+	Lifetime time.Duration `json:"-"`
+	// NotBefore delays the activation of a token when in the future, e.g.
+	// for pre-provisioned machine credentials. Its lifetime counts from
+	// activation and it is refused until then.
+	NotBefore time.Time `json:"-"`
+	// IssuedAt and ExpiresAt are set on the claims of validated tokens
+	IssuedAt  time.Time `json:"-"`
+	ExpiresAt time.Time `json:"-"`
+}
+
+// UserInfo holds the OpenID Connect standard claims about a user. Claims
+// outside the granted scopes are left empty.
+type UserInfo struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+	GivenName         string
+	FamilyName        string
+}
+
+// Claims returns the non-empty claims under their OpenID Connect names
+func (u UserInfo) Claims() map[string]interface{} {
+	claims := map[string]interface{}{"sub": u.Subject}
+	if u.Email != "" {
+		claims["email"] = u.Email
+		claims["email_verified"] = u.EmailVerified
+	}
+	for name, value := range map[string]string{
+		"preferred_username": u.PreferredUsername,
+		"name":               u.Name,
+		"given_name":         u.GivenName,
+		"family_name":        u.FamilyName,
+	} {
+		if value != "" {
+			claims[name] = value
+		}
+	}
+	return claims
+}
+
+// IDTokenClaims represents the claims of an OpenID Connect ID token
+type IDTokenClaims struct {
+	Issuer   string
+	Audience string // client ID
+	Nonce    string
+	AuthTime time.Time // when the user signed in; zero omits auth_time
+	UserInfo UserInfo
 }
 
 // TokenService defines the interface for token-related operations
@@ -44,6 +126,13 @@ type TokenService interface {
 	// GenerateVerificationToken generates an email verification token
 	GenerateVerificationToken(ctx context.Context, claims TokenClaims) (string, error)
 
+	// GenerateMagicLinkToken generates a passwordless login token
+	GenerateMagicLinkToken(ctx context.Context, claims TokenClaims) (string, error)
+
+	// GenerateIDToken generates an OpenID Connect ID token, signed with the
+	// access token key and valid as long as an access token
+	GenerateIDToken(ctx context.Context, claims IDTokenClaims) (string, error)
+
 	// ValidateToken validates a token and returns its claims
 	ValidateToken(ctx context.Context, token string, tokenType TokenType) (*TokenClaims, error)
 
@@ -52,13 +141,185 @@ type TokenService interface {
 
 	// IsTokenRevoked checks if a token has been revoked
 	IsTokenRevoked(ctx context.Context, token string) (bool, error)
+
+	// RevokeSession revokes every token issued for the given session
+	RevokeSession(ctx context.Context, sessionID string) error
+
+	// RevokeUserSessions revokes every access and refresh token issued to
+	// the given user so far
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) error
+
+	// TokenDuration returns the lifetime of tokens of the given type
+	TokenDuration(tokenType TokenType) time.Duration
+
+	// ListSigningKeys describes the current signing keys without exposing their material
+	ListSigningKeys(ctx context.Context) ([]SigningKeyInfo, error)
+
+	// RotateSigningKey replaces the signing key of the given token type. Tokens
+	// signed with the previous key stay valid until the next rotation.
+	RotateSigningKey(ctx context.Context, tokenType TokenType) (*SigningKeyInfo, error)
+
+	// JWKS returns the public keys of the token types signed with an
+	// asymmetric algorithm, including keys replaced by the last rotation
+	JWKS(ctx context.Context) ([]JSONWebKey, error)
+}
+
+// JSONWebKey is the public part of an asymmetric signing key, as published
+// in a JSON Web Key Set (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string // RSA or EC
+	KeyID     string
+	Algorithm string
+	// N and E are the RSA modulus and exponent; Curve, X and Y the EC curve
+	// and point. Values are base64url encoded without padding.
+	N     string
+	E     string
+	Curve string
+	X     string
+	Y     string
+}
+
+// SigningKeyStatus describes where a signing key is in its rotation lifecycle
+type SigningKeyStatus string
+
+const (
+	// SigningKeyActive is a key within its rotation interval
+	SigningKeyActive SigningKeyStatus = "active"
+	// SigningKeyRotationDue is a key older than its rotation interval
+	SigningKeyRotationDue SigningKeyStatus = "rotation_due"
+)
+
+// SigningKeyInfo describes a signing key for auditing
+type SigningKeyInfo struct {
+	KeyID     string
+	TokenType TokenType
+	Algorithm string
+	CreatedAt *time.Time // nil when the creation time is unknown
+	NotAfter  *time.Time // nil when no rotation interval is configured
+	Status    SigningKeyStatus
+}
+
+// NewSigningKeyInfo describes a signing key as of now. The key ID is derived
+// from a hash of the key so that keys can be told apart without revealing
+// them.
+func NewSigningKeyInfo(tokenType TokenType, algorithm string, key []byte, createdAt time.Time, rotationInterval time.Duration, now time.Time) SigningKeyInfo {
+	info := SigningKeyInfo{
+		KeyID:     SigningKeyID(key),
+		TokenType: tokenType,
+		Algorithm: algorithm,
+		Status:    SigningKeyActive,
+	}
+
+	if !createdAt.IsZero() {
+		info.CreatedAt = &createdAt
+		if rotationInterval > 0 {
+			notAfter := createdAt.Add(rotationInterval)
+			info.NotAfter = &notAfter
+			if now.After(notAfter) {
+				info.Status = SigningKeyRotationDue
+			}
+		}
+	}
+
+	return info
+}
+
+// SigningKeyID derives the public identifier of a signing key, used as the
+// kid header of the tokens it signs
+func SigningKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SigningKeyTokenTypes lists the token types that are signed with their own key
+var SigningKeyTokenTypes = []TokenType{
+	TokenTypeAccess,
+	TokenTypeRefresh,
+	TokenTypeReset,
+	TokenTypeVerification,
+	TokenTypeMagicLink,
+}
+
+// DefaultSigningAlgorithm is used for token types without a configured algorithm
+const DefaultSigningAlgorithm = "HS256"
+
+// SigningAlgorithms lists the supported token signing algorithms
+var SigningAlgorithms = []string{"HS256", "HS384", "HS512", "RS256", "ES256"}
+
+// AsymmetricSigningAlgorithms lists the supported algorithms signing with a
+// private key, whose public key downstream services can fetch
+var AsymmetricSigningAlgorithms = []string{"RS256", "ES256"}
+
+// IsSigningAlgorithm reports whether alg is a supported signing algorithm
+func IsSigningAlgorithm(alg string) bool {
+	for _, supported := range SigningAlgorithms {
+		if alg == supported {
+			return true
+		}
+	}
+	return false
 }
 
+// IsAsymmetricAlgorithm reports whether alg signs with a private key
+func IsAsymmetricAlgorithm(alg string) bool {
+	for _, asymmetric := range AsymmetricSigningAlgorithms {
+		if alg == asymmetric {
+			return true
+		}
+	}
+	return false
+}
+
+// SigningKeyPolicy configures the signing key of a single token type
+type SigningKeyPolicy struct {
+	Algorithm        string        // empty uses DefaultSigningAlgorithm
+	RotationInterval time.Duration // 0 falls back to TokenConfig.KeyRotationInterval
+}
+
+// RevocationFailurePolicy decides how tokens are validated while the
+// revocation store is unavailable
+type RevocationFailurePolicy string
+
+const (
+	// RevocationFailClosed rejects every token whose revocation status cannot be checked
+	RevocationFailClosed RevocationFailurePolicy = "fail_closed"
+	// RevocationSkipCheck skips the revocation check but only accepts tokens
+	// issued within TokenConfig.DegradedMaxTokenAge
+	RevocationSkipCheck RevocationFailurePolicy = "skip_revocation_check"
+)
+
+// DefaultMagicLinkTokenDuration is used when no magic link token lifetime
+// is configured
+const DefaultMagicLinkTokenDuration = 15 * time.Minute
+
+// DefaultDegradedMaxTokenAge is used when RevocationSkipCheck is configured
+// without a maximum token age
+const DefaultDegradedMaxTokenAge = 5 * time.Minute
+
 // TokenConfig represents the configuration for token generation
 type TokenConfig struct {
 	AccessTokenDuration       time.Duration
 	RefreshTokenDuration      time.Duration
 	ResetTokenDuration        time.Duration
 	VerificationTokenDuration time.Duration
+	MagicLinkTokenDuration    time.Duration // 0 uses DefaultMagicLinkTokenDuration
 	SigningKey                []byte
+	KeyRotationInterval       time.Duration // signing keys older than this are reported as due for rotation
+	KeyPolicies               map[TokenType]SigningKeyPolicy
+	RevocationFailurePolicy   RevocationFailurePolicy // empty uses RevocationFailClosed
+	DegradedMaxTokenAge       time.Duration           // 0 uses DefaultDegradedMaxTokenAge
+	Region                    string                  // stamped on tokens without a region; empty stamps none
+}
+
+// KeyPolicy returns the signing key policy of the given token type with
+// defaults applied
+func (c TokenConfig) KeyPolicy(tokenType TokenType) SigningKeyPolicy {
+	policy := c.KeyPolicies[tokenType]
+	if policy.Algorithm == "" {
+		policy.Algorithm = DefaultSigningAlgorithm
+	}
+	if policy.RotationInterval == 0 {
+		policy.RotationInterval = c.KeyRotationInterval
+	}
+	return policy
 }
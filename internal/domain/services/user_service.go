@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mibrahim2344/identity-service/internal/domain/models"
@@ -25,18 +26,88 @@ type UpdateUserInput struct {
 	Role     models.Role
 }
 
+// UpdateProfileInput represents the profile fields a user fills in after
+// registration. Nil fields are left unchanged; empty strings clear them.
+type UpdateProfileInput struct {
+	FirstName   *string
+	LastName    *string
+	PhoneNumber *string // E.164, e.g. +4930123456
+	Locale      *string // BCP 47 language tag, e.g. de-DE
+	TimeZone    *string // IANA time zone, e.g. Europe/Berlin
+	AvatarURL   *string // absolute HTTPS URL
+}
+
 // LoginUserInput represents the input for user login
 type LoginUserInput struct {
-	Email    string
-	Username string
+	Identifier string // email or username
+	Password   string
+	// CaptchaToken is the CAPTCHA response, required while the account's
+	// risk is raised
+	CaptchaToken string
+}
+
+// StepUpInput re-authenticates an admin before a sensitive action: their
+// password, and a current authenticator code when they set one up
+type StepUpInput struct {
 	Password string
+	Code     string
+}
+
+// KnowledgeFactorAnswer is a user's answer to one security question
+type KnowledgeFactorAnswer struct {
+	Question string
+	Answer   string
+}
+
+// KnowledgeFactorStatus describes the security questions of a user
+type KnowledgeFactorStatus struct {
+	Questions       []string // the configured questions users choose from
+	RequiredAnswers int      // how many questions each user answers
+	Answered        []string // the questions the user answered, empty when not set up
+}
+
+// KnowledgeFactorRecovery is a password reset token issued for answering
+// security questions correctly
+type KnowledgeFactorRecovery struct {
+	ResetToken string
+	ExpiresAt  time.Time
+}
+
+// AudienceTokenInput represents a request for an access token of another
+// internal API
+type AudienceTokenInput struct {
+	Audience string // the configured name of the API
+	Scope    string // space-separated; empty requests every scope of the audience
+}
+
+// AudienceToken is an access token only the API of its audience accepts
+type AudienceToken struct {
+	AccessToken string
+	Audience    string
+	Scope       string
+	ExpiresAt   time.Time
 }
 
 // LoginResponse represents the response for a successful login
 type LoginResponse struct {
+	AccessToken           string
+	RefreshToken          string
+	TokenType             string
+	AccessTokenExpiresAt  time.Time
+	RefreshTokenExpiresAt time.Time
+	User                  *models.User
+	// MFAChallenge is set instead of the tokens when the login needs a
+	// second factor
+	MFAChallenge *MFAChallenge
+	// MFAEnrollmentDeadline is set when a policy will require the user to
+	// set up MFA, which they have not done yet
+	MFAEnrollmentDeadline *time.Time
+}
+
+// LogoutInput represents the tokens to revoke on logout. Either may be empty.
+type LogoutInput struct {
 	AccessToken  string
 	RefreshToken string
-	User         *models.User
 }
 
 // ResetPasswordInput represents the input for password reset
@@ -45,10 +116,39 @@ type ResetPasswordInput struct {
 	NewPassword string
 }
 
+// CredentialBreach reports a user whose credentials were found in a breach.
+// The user is identified by ID, or by email when no ID is given.
+type CredentialBreach struct {
+	UserID uuid.UUID
+	Email  string
+	Source string // where the breach was reported, e.g. a breach feed or an admin import
+}
+
+// PurgeApproval is an admin's approval to permanently delete a user. A
+// different admin redeems its token to carry out the purge before it
+// expires.
+type PurgeApproval struct {
+	Token      string
+	UserID     uuid.UUID
+	ApproverID uuid.UUID
+	ExpiresAt  time.Time
+}
+
+// AccountDeactivation reports when a deactivated account is purged unless
+// it is restored first
+type AccountDeactivation struct {
+	UserID        uuid.UUID
+	DeactivatedAt time.Time
+	PurgeAfter    time.Time
+}
+
 // TokenResponse represents a token response
 type TokenResponse struct {
-	AccessToken  string
-	RefreshToken string
+	AccessToken           string
+	RefreshToken          string
+	TokenType             string
+	AccessTokenExpiresAt  time.Time
+	RefreshTokenExpiresAt time.Time
 }
 
 // UserService defines the interface for user-related business operations
@@ -56,27 +156,246 @@ type UserService interface {
 	// RegisterUser registers a new user
 	RegisterUser(ctx context.Context, input RegisterUserInput) (*models.User, error)
 
+	// Login authenticates a user and issues an access and refresh token pair
+	Login(ctx context.Context, input LoginUserInput) (*LoginResponse, error)
+
 	// AuthenticateUser authenticates a user with email/username and password
 	AuthenticateUser(ctx context.Context, emailOrUsername, password string) (*models.User, error)
 
 	// GetUser retrieves a user by their ID
 	GetUser(ctx context.Context, id uuid.UUID) (*models.User, error)
 
+	// GetPublicProfile retrieves the part of a user that may be shown to other users
+	GetPublicProfile(ctx context.Context, id uuid.UUID) (*models.PublicProfile, error)
+
 	// UpdateUser updates user details
 	UpdateUser(ctx context.Context, id uuid.UUID, input UpdateUserInput) (*models.User, error)
 
+	// UpdateProfile fills in or changes a user's profile fields
+	UpdateProfile(ctx context.Context, id uuid.UUID, input UpdateProfileInput) (*models.User, error)
+
+	// SetMarketingConsent records whether marketing-relevant events may be
+	// published about a user
+	SetMarketingConsent(ctx context.Context, id uuid.UUID, consent bool) (*models.User, error)
+
+	// GetProfileCompleteness reports how much of a user's profile is filled
+	// in and which fields the user must still provide
+	GetProfileCompleteness(ctx context.Context, id uuid.UUID) (*models.ProfileCompleteness, error)
+
 	// ChangePassword changes a user's password
 	ChangePassword(ctx context.Context, id uuid.UUID, currentPassword, newPassword string) error
 
-	// RequestPasswordReset initiates a password reset process
+	// RequestPasswordReset queues a password reset for the account with the
+	// email. It succeeds whether or not the account exists, so that callers
+	// cannot learn which emails are registered.
 	RequestPasswordReset(ctx context.Context, email string) error
 
+	// RequestRecoveryPasswordReset sends password reset links to the verified
+	// recovery address of the accounts using it. Unknown addresses are ignored.
+	RequestRecoveryPasswordReset(ctx context.Context, recoveryEmail string) error
+
 	// ResetPassword resets a user's password using a reset token
 	ResetPassword(ctx context.Context, token, newPassword string) error
 
+	// SetPassword sets a user's password without a reset token, e.g. for an
+	// operator recovering a locked-out admin. The user's sessions are revoked.
+	SetPassword(ctx context.Context, id uuid.UUID, newPassword string) error
+
+	// RevokeSessions revokes every session of a user
+	RevokeSessions(ctx context.Context, id uuid.UUID) error
+
+	// RequestRecoveryEmail sends a verification link to the address a user
+	// wants as their recovery email
+	RequestRecoveryEmail(ctx context.Context, userID uuid.UUID, email string) error
+
+	// VerifyRecoveryEmail redeems a recovery email verification link. It
+	// returns ErrInvalidToken for unknown, expired and used tokens.
+	VerifyRecoveryEmail(ctx context.Context, token string) error
+
+	// RemoveRecoveryEmail removes the recovery email of a user
+	RemoveRecoveryEmail(ctx context.Context, userID uuid.UUID) error
+
 	// VerifyEmail verifies a user's email address
 	VerifyEmail(ctx context.Context, token string) error
 
+	// ResendVerificationEmail sends a new verification email to an unverified user
+	ResendVerificationEmail(ctx context.Context, email string) error
+
+	// GetEmailVerificationState retrieves the verification emails sent to a user
+	GetEmailVerificationState(ctx context.Context, id uuid.UUID) (*models.EmailVerificationState, error)
+
+	// GetEffectivePermissions resolves the permissions a user currently holds
+	GetEffectivePermissions(ctx context.Context, id uuid.UUID) (*models.EffectivePermissions, error)
+
 	// RefreshToken refreshes an access token using a refresh token
 	RefreshToken(ctx context.Context, refreshToken string) (*TokenResponse, error)
+
+	// Logout revokes the given tokens and the session they belong to
+	Logout(ctx context.Context, input LogoutInput) error
+
+	// ChangeUserStatus moves a user to another lifecycle status, recording the reason
+	ChangeUserStatus(ctx context.Context, id uuid.UUID, status models.UserStatus, reason string) (*models.User, error)
+
+	// GetUsernameHistory retrieves a user's previous usernames, most recent first
+	GetUsernameHistory(ctx context.Context, id uuid.UUID) ([]*models.UsernameChange, error)
+
+	// SearchUsers searches users in the search index
+	SearchUsers(ctx context.Context, query UserSearchQuery) (*UserSearchResult, error)
+
+	// SetOrganization moves a user into an organization, or out of any
+	// organization when organizationID is nil
+	SetOrganization(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (*models.User, error)
+
+	// LoginWithExternalIdentity signs in the user linked to an external
+	// identity. Unknown identities are linked to the user with the same
+	// verified email, or to a new user when there is none.
+	LoginWithExternalIdentity(ctx context.Context, identity *ExternalIdentity) (*LoginResponse, error)
+
+	// GetLoginMethods returns the password, passkeys and external
+	// identities a user can sign in with
+	GetLoginMethods(ctx context.Context, userID uuid.UUID) (*LoginMethods, error)
+
+	// LinkExternalIdentity links an external identity to a signed-in user.
+	// Identities linked to another user are rejected with
+	// ErrIdentityAlreadyLinked.
+	LinkExternalIdentity(ctx context.Context, userID uuid.UUID, identity *ExternalIdentity) (*models.UserIdentity, error)
+
+	// UnlinkExternalIdentity removes an external identity of a user. It
+	// returns ErrLastLoginMethod when the user could not sign in without it.
+	UnlinkExternalIdentity(ctx context.Context, userID, id uuid.UUID) error
+
+	// CompleteMFALogin finishes a login waiting for a second factor with a
+	// code of the user's authenticator. For an enroll challenge the code
+	// confirms the authenticator set up with BeginChallengeEnrollment.
+	CompleteMFALogin(ctx context.Context, mfaToken, code string) (*LoginResponse, error)
+
+	// BeginChallengeEnrollment starts setting up an authenticator for the
+	// user of an enroll challenge
+	BeginChallengeEnrollment(ctx context.Context, mfaToken string) (*TOTPEnrollment, error)
+
+	// GetMFAStatus returns a user's second factor and policy requirement
+	GetMFAStatus(ctx context.Context, userID uuid.UUID) (*MFAStatus, error)
+
+	// BeginTOTPEnrollment starts setting up an authenticator app, replacing
+	// an unconfirmed earlier attempt
+	BeginTOTPEnrollment(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error)
+
+	// ConfirmTOTPEnrollment enables the authenticator app once the user
+	// entered a valid code
+	ConfirmTOTPEnrollment(ctx context.Context, userID uuid.UUID, code string) error
+
+	// DisableTOTP removes the authenticator app after checking a current code
+	DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error
+
+	// ResetMFA removes a user's authenticator without a code, e.g. after
+	// they lost their device
+	ResetMFA(ctx context.Context, userID uuid.UUID) error
+
+	// BeginPasskeyRegistration starts registering a passkey for a user
+	BeginPasskeyRegistration(ctx context.Context, userID uuid.UUID) (*PasskeyRegistration, error)
+
+	// FinishPasskeyRegistration verifies the authenticator's response to a
+	// registration the user started and stores the new passkey
+	FinishPasskeyRegistration(ctx context.Context, userID uuid.UUID, token, name string, attestation PasskeyAttestation) (*models.WebAuthnCredential, error)
+
+	// ListPasskeys returns the passkeys of a user
+	ListPasskeys(ctx context.Context, userID uuid.UUID) ([]*models.WebAuthnCredential, error)
+
+	// DeletePasskey removes a passkey of a user. It returns
+	// ErrLastLoginMethod when the user could not sign in without it.
+	DeletePasskey(ctx context.Context, userID, id uuid.UUID) error
+
+	// BeginPasskeyLogin starts a sign-in with a discoverable passkey
+	BeginPasskeyLogin(ctx context.Context) (*PasskeyLogin, error)
+
+	// FinishPasskeyLogin verifies the authenticator's response to a sign-in
+	// and signs in the user of the passkey instead of with a password. It
+	// returns ErrInvalidCredentials for unknown passkeys and assertions that
+	// do not verify.
+	FinishPasskeyLogin(ctx context.Context, token string, assertion PasskeyAssertion) (*LoginResponse, error)
+
+	// RequestMagicLink emails a single-use login link to the user with the
+	// given address. Unknown addresses are ignored.
+	RequestMagicLink(ctx context.Context, email string) error
+
+	// LoginWithMagicLink redeems the token of a login link and signs in its
+	// user. It returns ErrInvalidToken for unknown, expired and used tokens.
+	LoginWithMagicLink(ctx context.Context, token string) (*LoginResponse, error)
+
+	// ConfirmLogin redeems the token emailed for a login held back as coming
+	// from a new device or country. The device and country become known, so
+	// signing in from them again succeeds. It returns ErrInvalidToken for
+	// unknown, expired and used tokens.
+	ConfirmLogin(ctx context.Context, token string) error
+
+	// GetAccountRisk returns the risk state of a user's account, which
+	// escalates what their logins require
+	GetAccountRisk(ctx context.Context, userID uuid.UUID) (*models.AccountRisk, error)
+
+	// ResetAccountRisk returns a user's account to the normal risk state and
+	// forgets their failed logins, e.g. after support verified the user
+	ResetAccountRisk(ctx context.Context, userID uuid.UUID) error
+
+	// AdminRequestPasswordReset sends a user the password reset email on an
+	// admin's behalf after re-authenticating the admin. The reset token is
+	// never returned. Attempts are limited per admin and published for the
+	// audit trail.
+	AdminRequestPasswordReset(ctx context.Context, userID, adminID uuid.UUID, stepUp StepUpInput) error
+
+	// ApproveUserPurge records an admin's approval to permanently delete a
+	// user, including a soft-deleted one
+	ApproveUserPurge(ctx context.Context, userID, approverID uuid.UUID) (*PurgeApproval, error)
+
+	// PurgeUser permanently deletes a user and every record referring to
+	// them. The approval token must have been issued for the user by an
+	// admin other than adminID and not have expired; it can be used once.
+	PurgeUser(ctx context.Context, userID, adminID uuid.UUID, approvalToken string) error
+
+	// DeleteUser deactivates a user's account and revokes their sessions.
+	// The account can be restored until the grace period ends.
+	DeleteUser(ctx context.Context, id uuid.UUID) (*AccountDeactivation, error)
+
+	// RestoreAccount restores the deactivated account of the user with the
+	// given credentials
+	RestoreAccount(ctx context.Context, identifier, password string) (*models.User, error)
+
+	// RestoreUser restores a deactivated account on behalf of its user
+	RestoreUser(ctx context.Context, userID, adminID uuid.UUID) (*models.User, error)
+
+	// PurgeDeactivatedUsers permanently deletes the users whose deactivation
+	// grace period has ended and returns how many were purged
+	PurgeDeactivatedUsers(ctx context.Context) (int, error)
+
+	// RespondToCredentialBreach revokes the sessions of a user whose
+	// credentials were breached, requires a password reset and sends them a
+	// reset link. It reports false when a reset was already required, in
+	// which case only the sessions are revoked again.
+	RespondToCredentialBreach(ctx context.Context, breach CredentialBreach) (bool, error)
+
+	// GetKnowledgeFactors returns the configured security questions and the
+	// ones a user answered
+	GetKnowledgeFactors(ctx context.Context, userID uuid.UUID) (*KnowledgeFactorStatus, error)
+
+	// SetKnowledgeFactors replaces a user's security question answers after
+	// re-authenticating them
+	SetKnowledgeFactors(ctx context.Context, userID uuid.UUID, answers []KnowledgeFactorAnswer, stepUp StepUpInput) error
+
+	// RemoveKnowledgeFactors removes a user's security question answers
+	RemoveKnowledgeFactors(ctx context.Context, userID uuid.UUID) error
+
+	// GetRecoveryQuestions returns the security questions to answer to
+	// recover the account with the given email or username. Unknown
+	// accounts get plausible questions so that callers cannot probe them.
+	GetRecoveryQuestions(ctx context.Context, identifier string) ([]string, error)
+
+	// RecoverWithKnowledgeFactors issues a password reset token to whoever
+	// answers an account's security questions. It returns
+	// ErrKnowledgeFactorsInvalid for wrong answers and unknown accounts, and
+	// limits failed attempts per account.
+	RecoverWithKnowledgeFactors(ctx context.Context, identifier string, answers []KnowledgeFactorAnswer) (*KnowledgeFactorRecovery, error)
+
+	// MintAudienceToken issues a user an access token for another internal
+	// API, carrying a subset of the scopes configured for it. It returns
+	// ErrAudienceForbidden when the user may not call the API.
+	MintAudienceToken(ctx context.Context, userID uuid.UUID, input AudienceTokenInput) (*AudienceToken, error)
 }
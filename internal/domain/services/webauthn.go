@@ -0,0 +1,96 @@
+package services
+
+import (
+	"time"
+)
+
+// PasskeyAlgorithms are the COSE algorithms of the passkeys the service
+// accepts, most preferred first: ES256, EdDSA and RS256
+var PasskeyAlgorithms = []int{-7, -8, -257}
+
+// RelyingParty identifies the service to authenticators. Passkeys are bound
+// to the relying party ID, the domain they were registered on.
+type RelyingParty struct {
+	ID   string
+	Name string
+}
+
+// PasskeyCreationOptions are the options the browser creates a passkey with
+type PasskeyCreationOptions struct {
+	Challenge       []byte
+	RelyingParty    RelyingParty
+	UserHandle      []byte // the user's ID, returned by discoverable passkeys at sign-in
+	UserName        string
+	UserDisplayName string
+	// ExcludeCredentials are the credential IDs of passkeys the user already
+	// registered, so an authenticator does not register twice
+	ExcludeCredentials [][]byte
+	Timeout            time.Duration
+}
+
+// PasskeyRequestOptions are the options the browser signs in with a passkey with
+type PasskeyRequestOptions struct {
+	Challenge []byte
+	RPID      string
+	// AllowCredentials are the credential IDs of the passkeys of the user
+	// signing in; empty lets the user pick any discoverable passkey
+	AllowCredentials [][]byte
+	Timeout          time.Duration
+}
+
+// PasskeyRegistration is a started passkey registration. Its token
+// identifies the registration when finishing it.
+type PasskeyRegistration struct {
+	Token     string
+	ExpiresAt time.Time
+	Options   PasskeyCreationOptions
+}
+
+// PasskeyLogin is a started passkey sign-in. Its token identifies the
+// sign-in when finishing it.
+type PasskeyLogin struct {
+	Token     string
+	ExpiresAt time.Time
+	Options   PasskeyRequestOptions
+}
+
+// PasskeyAttestation is the authenticator's response to creating a passkey
+type PasskeyAttestation struct {
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// PasskeyAssertion is the authenticator's response to signing in with a passkey
+type PasskeyAssertion struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte // set by discoverable passkeys
+}
+
+// AttestedPasskey is a new passkey verified from its attestation
+type AttestedPasskey struct {
+	CredentialID []byte
+	PublicKey    []byte // COSE_Key
+	AAGUID       []byte
+	SignCount    uint32
+}
+
+// WebAuthnVerifier defines the interface for verifying the responses of
+// authenticators (Web Authentication, Level 2). Both ceremonies require
+// user verification, so a passkey is a second factor on its own.
+type WebAuthnVerifier interface {
+	// RelyingParty returns the relying party passkeys are registered for
+	RelyingParty() RelyingParty
+
+	// VerifyRegistration checks an attestation answers the challenge and
+	// returns the new passkey. It returns ErrPasskeyInvalid for responses
+	// that do not verify.
+	VerifyRegistration(challenge []byte, attestation PasskeyAttestation) (*AttestedPasskey, error)
+
+	// VerifyAssertion checks an assertion answers the challenge and is
+	// signed by the passkey with the given COSE_Key. It returns the
+	// signature counter of the assertion, or ErrPasskeyInvalid.
+	VerifyAssertion(challenge []byte, publicKey []byte, assertion PasskeyAssertion) (uint32, error)
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// WebhookNotification is what a notification webhook receives about an
+// event on an account. It never carries secrets such as reset links.
+type WebhookNotification struct {
+	ID         string    `json:"id"` // of the event, for deduplication
+	Event      string    `json:"event"`
+	UserID     uuid.UUID `json:"userId"`
+	Email      string    `json:"email,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+	ClientIP   string    `json:"clientIp,omitempty"`
+	UserAgent  string    `json:"userAgent,omitempty"`
+}
+
+// WebhookSender delivers notifications to webhooks
+type WebhookSender interface {
+	// Send delivers a notification in the format of the webhook
+	Send(ctx context.Context, webhook *models.NotificationWebhook, notification WebhookNotification) error
+}
+
+// RegisterWebhookInput represents the input for registering a notification webhook
+type RegisterWebhookInput struct {
+	UserID     uuid.UUID
+	URL        string
+	Format     models.WebhookFormat // empty uses JSON
+	EventTypes []string             // empty receives every notification event
+	CreatedBy  uuid.UUID
+}
+
+// NotificationWebhookService defines the interface for managing the
+// notification webhooks of users
+type NotificationWebhookService interface {
+	// Register validates and stores a webhook. The returned webhook carries
+	// its signing secret, which is not shown again.
+	Register(ctx context.Context, input RegisterWebhookInput) (*models.NotificationWebhook, error)
+
+	// List returns the webhooks of a user
+	List(ctx context.Context, userID uuid.UUID) ([]*models.NotificationWebhook, error)
+
+	// Delete removes a webhook of a user
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+}
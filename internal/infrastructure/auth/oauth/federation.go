@@ -0,0 +1,273 @@
+// Package oauth signs users in with external identity providers such as
+// Google, GitHub and Microsoft through the OAuth 2.0 authorization code flow
+// with PKCE.
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/egress"
+	"go.uber.org/zap"
+)
+
+// stateTTL bounds how long a user may take to sign in at the provider
+const stateTTL = 10 * time.Minute
+
+// provider is a configured identity provider
+type provider struct {
+	config     ProviderConfig
+	httpClient *http.Client
+}
+
+// loginState is what is remembered about a started login
+type loginState struct {
+	Provider     string     `json:"provider"`
+	CodeVerifier string     `json:"codeVerifier"`
+	LinkUserID   *uuid.UUID `json:"linkUserId,omitempty"` // set for account linking
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Federation is a services.FederationService over the configured providers,
+// keeping the state of started logins in the cache
+type Federation struct {
+	providers    map[string]*provider
+	cacheService services.CacheService
+	logger       *zap.Logger
+}
+
+var _ services.FederationService = (*Federation)(nil)
+
+// NewFederation creates a federation over the given providers, keyed by the
+// name used in the login URLs. Providers are reached through the shared
+// egress settings.
+func NewFederation(configs map[string]ProviderConfig, cacheService services.CacheService, egressConfig egress.Config, logger *zap.Logger) (*Federation, error) {
+	httpClient, err := egress.NewClient(egressConfig, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure identity provider client: %w", err)
+	}
+
+	providers := make(map[string]*provider, len(configs))
+	for name, cfg := range configs {
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid identity provider %s: %w", name, err)
+		}
+		providers[name] = &provider{config: cfg.withDefaults(), httpClient: httpClient}
+	}
+
+	return &Federation{
+		providers:    providers,
+		cacheService: cacheService,
+		logger:       logger,
+	}, nil
+}
+
+// Providers returns the names of the configured providers
+func (f *Federation) Providers() []string {
+	names := make([]string, 0, len(f.providers))
+	for name := range f.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginLogin starts a login with the given provider
+func (f *Federation) BeginLogin(ctx context.Context, name string) (*services.FederatedLogin, error) {
+	p, ok := f.providers[name]
+	if !ok {
+		return nil, services.ErrUnknownIdentityProvider
+	}
+	return beginLogin(ctx, f.cacheService, name, p, nil)
+}
+
+// BeginLink starts a login with the given provider whose identity is linked
+// to the user; the callback completes it like any other login
+func (f *Federation) BeginLink(ctx context.Context, name string, userID uuid.UUID) (*services.FederatedLogin, error) {
+	p, ok := f.providers[name]
+	if !ok {
+		return nil, services.ErrUnknownIdentityProvider
+	}
+	return beginLogin(ctx, f.cacheService, name, p, &userID)
+}
+
+// CompleteLogin exchanges the authorization code returned to the callback
+// for the user's identity. Each state can be completed once.
+func (f *Federation) CompleteLogin(ctx context.Context, name, state, code string) (*services.ExternalIdentity, error) {
+	p, ok := f.providers[name]
+	if !ok {
+		return nil, services.ErrUnknownIdentityProvider
+	}
+	return completeLogin(ctx, f.cacheService, f.logger, name, p, state, code)
+}
+
+// beginLogin remembers a login with provider p, known by name, and returns
+// the provider's authorization URL for it. A login started by linkUserID
+// links the identity to that user.
+func beginLogin(ctx context.Context, cacheService services.CacheService, name string, p *provider, linkUserID *uuid.UUID) (*services.FederatedLogin, error) {
+	state, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := cacheService.Set(ctx, stateKey(hashToken(state)), loginState{
+		Provider:     name,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+	}, stateTTL); err != nil {
+		return nil, fmt.Errorf("failed to store login state: %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(p.config.AuthURL, "?") {
+		separator = "&"
+	}
+
+	return &services.FederatedLogin{
+		AuthorizationURL: p.config.AuthURL + separator + query.Encode(),
+		State:            state,
+	}, nil
+}
+
+// completeLogin completes a login started with beginLogin for the same
+// provider name
+func completeLogin(ctx context.Context, cacheService services.CacheService, logger *zap.Logger, name string, p *provider, state, code string) (*services.ExternalIdentity, error) {
+	if state == "" || code == "" {
+		return nil, services.ErrFederationStateInvalid
+	}
+	stateHash := hashToken(state)
+
+	var login loginState
+	if err := cacheService.Get(ctx, stateKey(stateHash), &login); err != nil {
+		if stderrors.Is(err, services.ErrCacheKeyNotFound) {
+			return nil, services.ErrFederationStateInvalid
+		}
+		return nil, fmt.Errorf("failed to get login state: %w", err)
+	}
+	completed, err := cacheService.SetNX(ctx, stateUsedKey(stateHash), true, stateTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete login state: %w", err)
+	}
+	if !completed {
+		return nil, services.ErrFederationStateInvalid
+	}
+	if err := cacheService.Delete(ctx, stateKey(stateHash)); err != nil {
+		logger.Warn("failed to delete completed login state", zap.Error(err))
+	}
+	if login.Provider != name {
+		return nil, services.ErrFederationStateInvalid
+	}
+
+	accessToken, err := p.exchangeCode(ctx, code, login.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign in with %s: %w", name, err)
+	}
+	info, err := p.fetchUserInfo(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign in with %s: %w", name, err)
+	}
+
+	return &services.ExternalIdentity{
+		Provider:      name,
+		Subject:       info.Subject,
+		Email:         strings.ToLower(strings.TrimSpace(info.Email)),
+		EmailVerified: info.EmailVerified && info.Email != "",
+		Username:      info.Username,
+		FirstName:     info.FirstName,
+		LastName:      info.LastName,
+		LinkingUserID: login.LinkUserID,
+	}, nil
+}
+
+// exchangeCode redeems an authorization code for an access token
+func (p *provider) exchangeCode(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"client_secret": {p.config.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// GitHub answers with a form-encoded body unless JSON is asked for
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "exchange authorization code"); err != nil {
+		return "", err
+	}
+
+	var token tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	// GitHub reports errors with a successful status
+	if token.Error != "" {
+		return "", fmt.Errorf("failed to exchange authorization code: %s: %s", token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("token response has no access token")
+	}
+	return token.AccessToken, nil
+}
+
+func stateKey(stateHash string) string {
+	return fmt.Sprintf("federation_state:%s", stateHash)
+}
+
+func stateUsedKey(stateHash string) string {
+	return fmt.Sprintf("federation_state_used:%s", stateHash)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,237 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxErrorBodyBytes bounds how much of an error response is kept for the error message
+const maxErrorBodyBytes = 1024
+
+// Provider types with built-in endpoints and user info mappings
+const (
+	TypeGoogle    = "google"
+	TypeGitHub    = "github"
+	TypeMicrosoft = "microsoft"
+	// TypeOIDC is any OpenID Connect provider; its endpoints must be configured
+	TypeOIDC = "oidc"
+)
+
+// ProviderConfig holds the settings of an external identity provider
+type ProviderConfig struct {
+	Type         string // google, github, microsoft or oidc
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is this service's callback URL as registered with the provider
+	RedirectURL string
+	Scopes      []string // empty uses the type's defaults
+	// AuthURL, TokenURL and UserInfoURL override the endpoints of the type;
+	// they are required for oidc
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	// Tenant selects the Microsoft Entra ID tenant: a tenant ID, common or
+	// organizations. Empty uses common.
+	Tenant string
+	// TrustEmail treats the provider's email as verified when it does not
+	// say, e.g. for a single-tenant Microsoft directory
+	TrustEmail bool
+}
+
+// withDefaults fills in the endpoints and scopes of the provider type
+func (c ProviderConfig) withDefaults() ProviderConfig {
+	var authURL, tokenURL, userInfoURL string
+	var scopes []string
+	switch c.Type {
+	case TypeGoogle:
+		authURL = "https://accounts.google.com/o/oauth2/v2/auth"
+		tokenURL = "https://oauth2.googleapis.com/token"
+		userInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+		scopes = []string{"openid", "email", "profile"}
+	case TypeGitHub:
+		authURL = "https://github.com/login/oauth/authorize"
+		tokenURL = "https://github.com/login/oauth/access_token"
+		userInfoURL = "https://api.github.com/user"
+		scopes = []string{"read:user", "user:email"}
+	case TypeMicrosoft:
+		tenant := c.Tenant
+		if tenant == "" {
+			tenant = "common"
+		}
+		base := "https://login.microsoftonline.com/" + url.PathEscape(tenant) + "/oauth2/v2.0"
+		authURL = base + "/authorize"
+		tokenURL = base + "/token"
+		userInfoURL = "https://graph.microsoft.com/oidc/userinfo"
+		scopes = []string{"openid", "email", "profile"}
+	case TypeOIDC:
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	if c.AuthURL == "" {
+		c.AuthURL = authURL
+	}
+	if c.TokenURL == "" {
+		c.TokenURL = tokenURL
+	}
+	if c.UserInfoURL == "" {
+		c.UserInfoURL = userInfoURL
+	}
+	if len(c.Scopes) == 0 {
+		c.Scopes = scopes
+	}
+	return c
+}
+
+// Validate checks that the provider can be used
+func (c ProviderConfig) Validate() error {
+	switch c.Type {
+	case TypeGoogle, TypeGitHub, TypeMicrosoft, TypeOIDC:
+	default:
+		return fmt.Errorf("unknown provider type %q", c.Type)
+	}
+	if c.ClientID == "" || c.ClientSecret == "" {
+		return fmt.Errorf("client ID and secret are required")
+	}
+	if c.RedirectURL == "" {
+		return fmt.Errorf("redirect URL is required")
+	}
+	c = c.withDefaults()
+	if c.AuthURL == "" || c.TokenURL == "" || c.UserInfoURL == "" {
+		return fmt.Errorf("authorization, token and user info URLs are required")
+	}
+	return nil
+}
+
+// userInfo is what a provider reports about the signed-in user
+type userInfo struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	FirstName     string
+	LastName      string
+}
+
+// oidcUserInfo is the OpenID Connect userinfo response. Some providers
+// encode email_verified as a string.
+type oidcUserInfo struct {
+	Subject           string      `json:"sub"`
+	Email             string      `json:"email"`
+	EmailVerified     interface{} `json:"email_verified"`
+	PreferredUsername string      `json:"preferred_username"`
+	GivenName         string      `json:"given_name"`
+	FamilyName        string      `json:"family_name"`
+}
+
+type githubUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// fetchUserInfo retrieves the user an access token was issued for
+func (p *provider) fetchUserInfo(ctx context.Context, accessToken string) (*userInfo, error) {
+	if p.config.Type == TypeGitHub {
+		return p.fetchGitHubUser(ctx, accessToken)
+	}
+
+	var info oidcUserInfo
+	if err := p.getJSON(ctx, p.config.UserInfoURL, accessToken, &info); err != nil {
+		return nil, err
+	}
+	if info.Subject == "" {
+		return nil, fmt.Errorf("user info has no subject")
+	}
+
+	verified := false
+	switch v := info.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified, _ = strconv.ParseBool(v)
+	}
+	return &userInfo{
+		Subject:       info.Subject,
+		Email:         info.Email,
+		EmailVerified: verified || p.config.TrustEmail,
+		Username:      info.PreferredUsername,
+		FirstName:     info.GivenName,
+		LastName:      info.FamilyName,
+	}, nil
+}
+
+// fetchGitHubUser retrieves a GitHub user with their primary email, which
+// the user endpoint omits when it is private
+func (p *provider) fetchGitHubUser(ctx context.Context, accessToken string) (*userInfo, error) {
+	var user githubUser
+	if err := p.getJSON(ctx, p.config.UserInfoURL, accessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, fmt.Errorf("user info has no ID")
+	}
+
+	var emails []githubEmail
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.config.UserInfoURL, "/user")+"/user/emails", accessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	info := &userInfo{
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Username: user.Login,
+	}
+	for _, email := range emails {
+		if email.Primary {
+			info.Email = email.Email
+			info.EmailVerified = email.Verified || p.config.TrustEmail
+		}
+	}
+	if first, last, ok := strings.Cut(strings.TrimSpace(user.Name), " "); ok {
+		info.FirstName, info.LastName = first, strings.TrimSpace(last)
+	} else {
+		info.FirstName = first
+	}
+	return info, nil
+}
+
+func (p *provider) getJSON(ctx context.Context, endpoint, accessToken string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create user info request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("user info request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "fetch user info"); err != nil {
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode user info: %w", err)
+	}
+	return nil
+}
+
+func checkResponse(resp *http.Response, operation string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	return fmt.Errorf("failed to %s: status %d: %s", operation, resp.StatusCode, strings.TrimSpace(string(body)))
+}
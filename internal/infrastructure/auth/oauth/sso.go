@@ -0,0 +1,173 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/egress"
+	"go.uber.org/zap"
+)
+
+// discoveryPath is where OpenID Connect providers publish their configuration
+const discoveryPath = "/.well-known/openid-configuration"
+
+// SSOConfig holds the settings of logins through SSO connections
+type SSOConfig struct {
+	// CallbackURL is the base of the connections' callback URLs, e.g.
+	// https://id.example.com/api/v1/auth/sso. A connection's callback is
+	// CallbackURL/{connection ID}/callback.
+	CallbackURL string
+	// AllowPrivateNetworks permits connections to loopback, private and
+	// link-local addresses. Issuers are chosen by organization admins, so
+	// this should only be set when requests go through a proxy.
+	AllowPrivateNetworks bool
+}
+
+// discoveryDocument is the part of an OpenID Connect discovery document
+// logins need
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// SSO is a services.SSOConnector signing users in with the OpenID Connect
+// providers of SSO connections, keeping the state of started logins in the
+// cache like Federation
+type SSO struct {
+	config       SSOConfig
+	httpClient   *http.Client
+	cacheService services.CacheService
+	logger       *zap.Logger
+}
+
+var _ services.SSOConnector = (*SSO)(nil)
+
+// NewSSO creates a connector reaching identity providers through the shared
+// egress settings
+func NewSSO(cfg SSOConfig, cacheService services.CacheService, egressConfig egress.Config, logger *zap.Logger) (*SSO, error) {
+	if cfg.CallbackURL == "" {
+		return nil, fmt.Errorf("SSO callback URL is required")
+	}
+	transport, err := egress.NewTransport(egressConfig, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure SSO client: %w", err)
+	}
+	if !cfg.AllowPrivateNetworks {
+		transport.DialContext = egress.PublicOnly(transport.DialContext)
+	}
+
+	return &SSO{
+		config: cfg,
+		httpClient: &http.Client{
+			Timeout:   egressConfig.Timeout,
+			Transport: transport,
+			// A redirect could lead to a private address the URL was
+			// validated against
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cacheService: cacheService,
+		logger:       logger,
+	}, nil
+}
+
+// Discover reads the endpoints of an OpenID Connect issuer from its
+// discovery document. The document must name the issuer itself, and every
+// endpoint must use HTTPS.
+func (s *SSO) Discover(ctx context.Context, issuer string) (*services.OIDCEndpoints, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	if err := checkHTTPS(issuer); err != nil {
+		return nil, fmt.Errorf("invalid issuer: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+discoveryPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("discovery request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "discover issuer"); err != nil {
+		return nil, err
+	}
+
+	var document discoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode discovery document: %w", err)
+	}
+	if strings.TrimSuffix(document.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery document names issuer %q", document.Issuer)
+	}
+	for name, endpoint := range map[string]string{
+		"authorization": document.AuthorizationEndpoint,
+		"token":         document.TokenEndpoint,
+		"user info":     document.UserInfoEndpoint,
+	} {
+		if err := checkHTTPS(endpoint); err != nil {
+			return nil, fmt.Errorf("invalid %s endpoint: %w", name, err)
+		}
+	}
+
+	return &services.OIDCEndpoints{
+		AuthURL:     document.AuthorizationEndpoint,
+		TokenURL:    document.TokenEndpoint,
+		UserInfoURL: document.UserInfoEndpoint,
+	}, nil
+}
+
+// RedirectURL returns the callback URL of a connection
+func (s *SSO) RedirectURL(connection *models.SSOConnection) string {
+	return strings.TrimSuffix(s.config.CallbackURL, "/") + "/" + connection.ID.String() + "/callback"
+}
+
+// BeginLogin starts a login with the identity provider of a connection
+func (s *SSO) BeginLogin(ctx context.Context, connection *models.SSOConnection) (*services.FederatedLogin, error) {
+	return beginLogin(ctx, s.cacheService, connection.ProviderName(), s.provider(connection), nil)
+}
+
+// CompleteLogin exchanges the authorization code returned to the callback
+// for the user's identity. Each state can be completed once.
+func (s *SSO) CompleteLogin(ctx context.Context, connection *models.SSOConnection, state, code string) (*services.ExternalIdentity, error) {
+	return completeLogin(ctx, s.cacheService, s.logger, connection.ProviderName(), s.provider(connection), state, code)
+}
+
+// provider configures the identity provider of a connection. Organizations
+// prove they own the domains their connections sign users in for, so their
+// providers are trusted with those emails.
+func (s *SSO) provider(connection *models.SSOConnection) *provider {
+	return &provider{
+		config: ProviderConfig{
+			Type:         TypeOIDC,
+			ClientID:     connection.ClientID,
+			ClientSecret: connection.ClientSecret,
+			RedirectURL:  s.RedirectURL(connection),
+			AuthURL:      connection.AuthURL,
+			TokenURL:     connection.TokenURL,
+			UserInfoURL:  connection.UserInfoURL,
+			TrustEmail:   true,
+		}.withDefaults(),
+		httpClient: s.httpClient,
+	}
+}
+
+// checkHTTPS checks that rawURL is an absolute HTTPS URL without credentials
+func checkHTTPS(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return fmt.Errorf("%q is not an absolute https URL", rawURL)
+	}
+	return nil
+}
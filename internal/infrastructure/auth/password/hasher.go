@@ -1,8 +1,13 @@
 package password
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -10,6 +15,10 @@ import (
 type PasswordHasher interface {
 	Hash(password string) (string, error)
 	Verify(password, hash string) error
+	// NeedsRehash reports whether a hash was made with another algorithm
+	// or other parameters than the hasher's, so that it should be replaced
+	// once the password is known
+	NeedsRehash(hash string) bool
 }
 
 // HashingAlgorithm represents the type of hashing algorithm
@@ -18,10 +27,28 @@ type HashingAlgorithm string
 const (
 	// BCrypt represents the bcrypt hashing algorithm
 	BCrypt HashingAlgorithm = "bcrypt"
-	// Add more algorithms as needed
+	// Argon2id represents the Argon2id hashing algorithm (RFC 9106)
+	Argon2id HashingAlgorithm = "argon2id"
 )
 
-// NewPasswordHasher creates a new password hasher based on the algorithm
+// ParseHashingAlgorithm parses a configured algorithm name. An empty name
+// is bcrypt.
+func ParseHashingAlgorithm(name string) (HashingAlgorithm, error) {
+	switch algorithm := HashingAlgorithm(strings.ToLower(name)); algorithm {
+	case "":
+		return BCrypt, nil
+	case BCrypt, Argon2id:
+		return algorithm, nil
+	default:
+		return "", fmt.Errorf("unsupported hashing algorithm: %s", name)
+	}
+}
+
+// NewPasswordHasher creates a new password hasher based on the algorithm.
+// bcrypt takes a "cost" option; Argon2id takes "memory" in KiB,
+// "iterations" and "parallelism". Hashers verify hashes of every supported
+// algorithm, so that the algorithm can be changed without resetting
+// passwords.
 func NewPasswordHasher(algorithm HashingAlgorithm, options map[string]interface{}) (PasswordHasher, error) {
 	switch algorithm {
 	case BCrypt:
@@ -32,11 +59,37 @@ func NewPasswordHasher(algorithm HashingAlgorithm, options map[string]interface{
 			}
 		}
 		return NewBCryptHasher(cost), nil
+	case Argon2id:
+		params := DefaultArgon2idParams
+		if memory, ok := options["memory"].(int); ok && memory > 0 {
+			params.Memory = uint32(memory)
+		}
+		if iterations, ok := options["iterations"].(int); ok && iterations > 0 {
+			params.Iterations = uint32(iterations)
+		}
+		if parallelism, ok := options["parallelism"].(int); ok && parallelism > 0 {
+			params.Parallelism = uint8(min(parallelism, 255))
+		}
+		return NewArgon2idHasher(params), nil
 	default:
 		return nil, fmt.Errorf("unsupported hashing algorithm: %s", algorithm)
 	}
 }
 
+// verify checks a password against a hash of any supported algorithm
+func verify(password, hash string) error {
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		return verifyArgon2id(password, hash)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return fmt.Errorf("invalid password")
+		}
+		return fmt.Errorf("failed to verify password: %w", err)
+	}
+	return nil
+}
+
 // BCryptHasher implements PasswordHasher using bcrypt
 type BCryptHasher struct {
 	cost int
@@ -63,11 +116,115 @@ func (h *BCryptHasher) Hash(password string) (string, error) {
 
 // Verify checks if the password matches the hash
 func (h *BCryptHasher) Verify(password, hash string) error {
-	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
-		if err == bcrypt.ErrMismatchedHashAndPassword {
-			return fmt.Errorf("invalid password")
-		}
+	return verify(password, hash)
+}
+
+// NeedsRehash reports whether the hash is not a bcrypt hash of the
+// hasher's cost
+func (h *BCryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost
+}
+
+// argon2idPrefix starts Argon2id hashes in the PHC string format
+const argon2idPrefix = "$argon2id$"
+
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+// Argon2idParams are the tunable costs of Argon2id
+type Argon2idParams struct {
+	Memory      uint32 // in KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultArgon2idParams are the second recommended parameters of RFC 9106
+// section 4 for memory-constrained environments
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+}
+
+// Argon2idHasher implements PasswordHasher using Argon2id. Hashes are
+// encoded in the PHC string format with their parameters, e.g.
+// $argon2id$v=19$m=65536,t=3,p=4$salt$key.
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher creates a new Argon2idHasher
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+// Hash generates an Argon2id hash of the password with a random salt
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, argon2idKeyLength)
+	return encodeArgon2id(h.params, salt, key), nil
+}
+
+// Verify checks if the password matches the hash
+func (h *Argon2idHasher) Verify(password, hash string) error {
+	return verify(password, hash)
+}
+
+// NeedsRehash reports whether the hash is not an Argon2id hash of the
+// hasher's parameters
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2id(hash)
+	return err != nil || params != h.params
+}
+
+// verifyArgon2id checks a password against an Argon2id hash with the
+// parameters encoded in it
+func verifyArgon2id(password, hash string) error {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
 		return fmt.Errorf("failed to verify password: %w", err)
 	}
+	computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return fmt.Errorf("invalid password")
+	}
 	return nil
 }
+
+func encodeArgon2id(params Argon2idParams, salt, key []byte) string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(hash string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, fmt.Errorf("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id key")
+	}
+	return params, salt, key, nil
+}
@@ -51,6 +51,12 @@ func (s *Service) VerifyPassword(ctx context.Context, password, hash string) err
 	return nil
 }
 
+// NeedsRehash reports whether a hash should be replaced with one of the
+// configured hasher
+func (s *Service) NeedsRehash(hash string) bool {
+	return s.hasher.NeedsRehash(hash)
+}
+
 // GenerateRandomPassword generates a random password
 func (s *Service) GenerateRandomPassword(ctx context.Context) (string, error) {
 	const (
@@ -138,12 +144,17 @@ func (s *Service) GenerateRandomPassword(ctx context.Context) (string, error) {
 
 // ValidatePassword validates password strength
 func (s *Service) ValidatePassword(ctx context.Context, password string) error {
-	if len(password) < s.config.MinLength {
-		return fmt.Errorf("password must be at least %d characters long", s.config.MinLength)
+	return s.ValidatePasswordPolicy(ctx, password, s.config)
+}
+
+// ValidatePasswordPolicy validates password strength against the given policy
+func (s *Service) ValidatePasswordPolicy(ctx context.Context, password string, policy services.PasswordConfig) error {
+	if len(password) < policy.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", policy.MinLength)
 	}
 
-	if len(password) > s.config.MaxLength {
-		return fmt.Errorf("password must not exceed %d characters", s.config.MaxLength)
+	if len(password) > policy.MaxLength {
+		return fmt.Errorf("password must not exceed %d characters", policy.MaxLength)
 	}
 
 	var (
@@ -166,16 +177,16 @@ func (s *Service) ValidatePassword(ctx context.Context, password string) error {
 		}
 	}
 
-	if s.config.RequireUppercase && !hasUpper {
+	if policy.RequireUppercase && !hasUpper {
 		return fmt.Errorf("password must contain at least one uppercase letter")
 	}
-	if s.config.RequireLowercase && !hasLower {
+	if policy.RequireLowercase && !hasLower {
 		return fmt.Errorf("password must contain at least one lowercase letter")
 	}
-	if s.config.RequireNumbers && !hasNumber {
+	if policy.RequireNumbers && !hasNumber {
 		return fmt.Errorf("password must contain at least one number")
 	}
-	if s.config.RequireSpecialChars && !hasSpecial {
+	if policy.RequireSpecialChars && !hasSpecial {
 		return fmt.Errorf("password must contain at least one special character")
 	}
 
@@ -0,0 +1,104 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// privateKey parses a PKCS #8 encoded signing key of an asymmetric algorithm
+func privateKey(algorithm string, key []byte) (crypto.Signer, error) {
+	parsed, err := x509.ParsePKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if algorithm == "RS256" {
+			return k, nil
+		}
+	case *ecdsa.PrivateKey:
+		if algorithm == "ES256" && k.Curve == elliptic.P256() {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("signing key does not match algorithm %s", algorithm)
+}
+
+// keyMatchesAlgorithm reports whether a stored key can sign with algorithm.
+// A key generated for another algorithm is left behind after the algorithm
+// of a token type is reconfigured.
+func keyMatchesAlgorithm(algorithm string, key []byte) bool {
+	if services.IsAsymmetricAlgorithm(algorithm) {
+		_, err := privateKey(algorithm, key)
+		return err == nil
+	}
+	_, err := x509.ParsePKCS8PrivateKey(key)
+	return err != nil
+}
+
+// signingKeyMaterial returns what jwt signs with: the private key of
+// asymmetric algorithms, the secret itself otherwise
+func signingKeyMaterial(algorithm string, key []byte) (interface{}, error) {
+	if !services.IsAsymmetricAlgorithm(algorithm) {
+		return key, nil
+	}
+	return privateKey(algorithm, key)
+}
+
+// verificationKeyMaterial returns what jwt verifies with: the public key of
+// asymmetric algorithms, the secret itself otherwise
+func verificationKeyMaterial(algorithm string, key []byte) (interface{}, error) {
+	if !services.IsAsymmetricAlgorithm(algorithm) {
+		return key, nil
+	}
+	signer, err := privateKey(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Public(), nil
+}
+
+// newJSONWebKey describes the public part of an asymmetric signing key
+func newJSONWebKey(algorithm string, key []byte) (services.JSONWebKey, error) {
+	signer, err := privateKey(algorithm, key)
+	if err != nil {
+		return services.JSONWebKey{}, err
+	}
+
+	jwk := services.JSONWebKey{
+		KeyID:     services.SigningKeyID(key),
+		Algorithm: algorithm,
+	}
+	switch publicKey := signer.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64URL(publicKey.N.Bytes())
+		jwk.E = base64URL(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdhKey, err := publicKey.ECDH()
+		if err != nil {
+			return services.JSONWebKey{}, fmt.Errorf("failed to encode public key: %w", err)
+		}
+		// Uncompressed point: 0x04 followed by the X and Y coordinates
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+		jwk.KeyType = "EC"
+		jwk.Curve = "P-256"
+		jwk.X = base64URL(point[1 : 1+size])
+		jwk.Y = base64URL(point[1+size:])
+	}
+	return jwk, nil
+}
+
+func base64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
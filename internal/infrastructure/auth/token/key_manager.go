@@ -2,45 +2,108 @@ package token
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
 	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
 )
 
-// KeyManager defines the interface for managing signing keys
+// KeyManager defines the interface for managing signing keys. Keys of
+// asymmetric algorithms are PKCS #8 encoded private keys; any other key is
+// an HMAC secret.
 type KeyManager interface {
-	// GetSigningKey returns the signing key for the given token type
-	GetSigningKey(ctx context.Context, tokenType services.TokenType) ([]byte, error)
-	
-	// RotateKey rotates the signing key for the given token type
-	RotateKey(ctx context.Context, tokenType services.TokenType) error
+	// GetSigningKey returns the signing key for the given token type,
+	// generating one for algorithm if there is none
+	GetSigningKey(ctx context.Context, tokenType services.TokenType, algorithm string) ([]byte, error)
+
+	// RotateKey replaces the signing key for the given token type with a new
+	// key for algorithm
+	RotateKey(ctx context.Context, tokenType services.TokenType, algorithm string) error
+
+	// GetKeyCreatedAt returns when the signing key for the given token type was
+	// created, or the zero time when there is no key or its age is unknown
+	GetKeyCreatedAt(ctx context.Context, tokenType services.TokenType) (time.Time, error)
+
+	// GetPreviousSigningKey returns the key replaced by the last rotation of the
+	// given token type, or nil if the key has never been rotated
+	GetPreviousSigningKey(ctx context.Context, tokenType services.TokenType) ([]byte, error)
+}
+
+const (
+	// signingKeySize is the size of generated HMAC secrets, long enough for
+	// every supported HMAC algorithm
+	signingKeySize = 64 // 512 bits
+	// rsaKeyBits is the modulus size of generated RSA keys
+	rsaKeyBits = 2048
+)
+
+// generateSigningKey creates a new signing key for the given algorithm
+func generateSigningKey(algorithm string) ([]byte, error) {
+	var privateKey interface{}
+	var err error
+	switch algorithm {
+	case "RS256":
+		privateKey, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case "ES256":
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		key := make([]byte, signingKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	key, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+	return key, nil
 }
 
 // LocalKeyManager implements KeyManager using local storage
 type LocalKeyManager struct {
-	keys  map[services.TokenType][]byte
-	mutex sync.RWMutex
+	keys      map[services.TokenType][]byte
+	previous  map[services.TokenType][]byte
+	createdAt map[services.TokenType]time.Time
+	clock     services.Clock
+	mutex     sync.RWMutex
 }
 
-// NewLocalKeyManager creates a new LocalKeyManager
-func NewLocalKeyManager() *LocalKeyManager {
+// NewLocalKeyManager creates a new LocalKeyManager. Keys are dated with
+// clock, which the age of a key and its rotation are measured against.
+func NewLocalKeyManager(clock services.Clock) *LocalKeyManager {
 	return &LocalKeyManager{
-		keys: make(map[services.TokenType][]byte),
+		keys:      make(map[services.TokenType][]byte),
+		previous:  make(map[services.TokenType][]byte),
+		createdAt: make(map[services.TokenType]time.Time),
+		clock:     clock,
 	}
 }
 
 // GetSigningKey returns the signing key for the given token type
-func (m *LocalKeyManager) GetSigningKey(ctx context.Context, tokenType services.TokenType) ([]byte, error) {
+func (m *LocalKeyManager) GetSigningKey(ctx context.Context, tokenType services.TokenType, algorithm string) ([]byte, error) {
 	m.mutex.RLock()
 	key, exists := m.keys[tokenType]
 	m.mutex.RUnlock()
 
 	if !exists {
 		// Generate a new key if one doesn't exist
-		if err := m.RotateKey(ctx, tokenType); err != nil {
+		if err := m.RotateKey(ctx, tokenType, algorithm); err != nil {
 			return nil, err
 		}
 		m.mutex.RLock()
@@ -52,65 +115,321 @@ func (m *LocalKeyManager) GetSigningKey(ctx context.Context, tokenType services.
 }
 
 // RotateKey rotates the signing key for the given token type
-func (m *LocalKeyManager) RotateKey(ctx context.Context, tokenType services.TokenType) error {
-	key := make([]byte, 32) // 256 bits
-	if _, err := rand.Read(key); err != nil {
-		return fmt.Errorf("failed to generate key: %w", err)
+func (m *LocalKeyManager) RotateKey(ctx context.Context, tokenType services.TokenType, algorithm string) error {
+	key, err := generateSigningKey(algorithm)
+	if err != nil {
+		return err
 	}
 
+	m.setKey(tokenType, key, m.clock.Now())
+	return nil
+}
+
+// GetKeyCreatedAt returns when the signing key for the given token type was created
+func (m *LocalKeyManager) GetKeyCreatedAt(ctx context.Context, tokenType services.TokenType) (time.Time, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.createdAt[tokenType], nil
+}
+
+// GetPreviousSigningKey returns the key replaced by the last rotation
+func (m *LocalKeyManager) GetPreviousSigningKey(ctx context.Context, tokenType services.TokenType) ([]byte, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.previous[tokenType], nil
+}
+
+func (m *LocalKeyManager) setKey(tokenType services.TokenType, key []byte, createdAt time.Time) {
 	m.mutex.Lock()
+	if current, exists := m.keys[tokenType]; exists {
+		m.previous[tokenType] = current
+	}
 	m.keys[tokenType] = key
+	m.createdAt[tokenType] = createdAt
 	m.mutex.Unlock()
+}
+
+// keysPerTokenType is how many versions of a token type's key are loaded:
+// the current key and the one replaced by the last rotation
+const keysPerTokenType = 2
+
+// defaultKeySyncInterval is how often keys are reloaded from storage when no
+// interval is configured
+const defaultKeySyncInterval = time.Minute
+
+// KeyChangeNotifier tells the replicas of the service that the signing key
+// of a token type changed
+type KeyChangeNotifier interface {
+	// NotifyKeyChanged announces a new signing key of the token type
+	NotifyKeyChanged(ctx context.Context, tokenType services.TokenType) error
+
+	// SubscribeKeyChanges calls handle for every announced key change until
+	// ctx is done or the subscription fails
+	SubscribeKeyChanges(ctx context.Context, handle func(tokenType services.TokenType)) error
+}
+
+// keyVersions are the loaded versions of a token type's signing key
+type keyVersions struct {
+	current  *models.SigningKey
+	previous *models.SigningKey
+}
+
+// DistributedKeyManager implements KeyManager for replicated deployments.
+// Keys are versioned and stored in the database, which is their only source
+// of truth: when it cannot be reached no key is made up locally, so every
+// replica signs and validates tokens with the same keys. Each replica keeps
+// the loaded keys in memory and reloads them when a rotation is announced
+// through the notifier, as well as periodically in case an announcement
+// was missed.
+type DistributedKeyManager struct {
+	keys     repositories.SigningKeyRepository
+	notifier KeyChangeNotifier
+	legacy   services.CacheService
+	clock    services.Clock
+	versions map[services.TokenType]keyVersions
+	mutex    sync.RWMutex
+}
+
+// NewDistributedKeyManager creates a new DistributedKeyManager. notifier may
+// be nil, leaving replicas to pick up rotations when they next reload their
+// keys. Keys that earlier versions shared through the legacy cache are
+// imported by Sync; legacy may be nil. New keys are dated with clock.
+func NewDistributedKeyManager(keys repositories.SigningKeyRepository, notifier KeyChangeNotifier, legacy services.CacheService, clock services.Clock) *DistributedKeyManager {
+	return &DistributedKeyManager{
+		keys:     keys,
+		notifier: notifier,
+		legacy:   legacy,
+		clock:    clock,
+		versions: make(map[services.TokenType]keyVersions),
+	}
+}
 
+// Sync loads the keys of every token type from storage, importing keys from
+// the legacy cache for token types that have none stored yet. It is called
+// on startup so that a replica does not sign a token before it knows the
+// keys of the others.
+func (m *DistributedKeyManager) Sync(ctx context.Context) error {
+	for _, tokenType := range services.SigningKeyTokenTypes {
+		versions, err := m.load(ctx, tokenType)
+		if err != nil {
+			return err
+		}
+		if versions.current == nil && m.legacy != nil {
+			if err := m.importLegacyKeys(ctx, tokenType); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-// RedisKeyManager implements KeyManager using Redis for distributed key management
-type RedisKeyManager struct {
-	cache services.CacheService
-	local *LocalKeyManager
+// Watch reloads the keys of a token type whenever a rotation is announced,
+// and of every token type at the given interval, until ctx is cancelled. An
+// interval of 0 uses one minute.
+func (m *DistributedKeyManager) Watch(ctx context.Context, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 {
+		interval = defaultKeySyncInterval
+	}
+	if m.notifier != nil {
+		go m.subscribe(ctx, interval, logger)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Sync(ctx); err != nil {
+				logger.Error("failed to sync signing keys", zap.Error(err))
+			}
+		}
+	}
 }
 
-// NewRedisKeyManager creates a new RedisKeyManager
-func NewRedisKeyManager(cache services.CacheService) *RedisKeyManager {
-	return &RedisKeyManager{
-		cache: cache,
-		local: NewLocalKeyManager(),
+// subscribe listens for key changes, subscribing again after the given
+// delay when the subscription fails
+func (m *DistributedKeyManager) subscribe(ctx context.Context, retryDelay time.Duration, logger *zap.Logger) {
+	for {
+		err := m.notifier.SubscribeKeyChanges(ctx, func(tokenType services.TokenType) {
+			if _, err := m.load(ctx, tokenType); err != nil {
+				logger.Error("failed to reload signing key",
+					zap.String("tokenType", string(tokenType)),
+					zap.Error(err))
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("signing key change subscription failed", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryDelay):
+		}
 	}
 }
 
-// GetSigningKey returns the signing key for the given token type
-func (m *RedisKeyManager) GetSigningKey(ctx context.Context, tokenType services.TokenType) ([]byte, error) {
-	var encodedKey string
-	err := m.cache.Get(ctx, fmt.Sprintf("signing_key:%s", tokenType), &encodedKey)
+// GetSigningKey returns the signing key for the given token type, storing
+// the first version when there is none
+func (m *DistributedKeyManager) GetSigningKey(ctx context.Context, tokenType services.TokenType, algorithm string) ([]byte, error) {
+	versions, err := m.versionsOf(ctx, tokenType)
 	if err != nil {
-		// Fallback to local key if Redis is unavailable
-		return m.local.GetSigningKey(ctx, tokenType)
+		return nil, err
 	}
+	if versions.current == nil {
+		if versions, err = m.store(ctx, tokenType, algorithm, 1); err != nil {
+			return nil, err
+		}
+	}
+	return versions.current.Key, nil
+}
 
-	key, err := base64.StdEncoding.DecodeString(encodedKey)
+// RotateKey stores the next version of the signing key for the given token
+// type and announces it to the other replicas
+func (m *DistributedKeyManager) RotateKey(ctx context.Context, tokenType services.TokenType, algorithm string) error {
+	// The stored versions rather than the loaded ones are rotated, so that
+	// a rotation by another replica is not overwritten
+	versions, err := m.load(ctx, tokenType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode key: %w", err)
+		return err
+	}
+	version := 1
+	if versions.current != nil {
+		version = versions.current.Version + 1
 	}
+	_, err = m.store(ctx, tokenType, algorithm, version)
+	return err
+}
 
-	return key, nil
+// GetKeyCreatedAt returns when the signing key for the given token type was
+// created, or the zero time when there is no key
+func (m *DistributedKeyManager) GetKeyCreatedAt(ctx context.Context, tokenType services.TokenType) (time.Time, error) {
+	versions, err := m.versionsOf(ctx, tokenType)
+	if err != nil || versions.current == nil {
+		return time.Time{}, err
+	}
+	return versions.current.CreatedAt, nil
 }
 
-// RotateKey rotates the signing key for the given token type
-func (m *RedisKeyManager) RotateKey(ctx context.Context, tokenType services.TokenType) error {
-	key := make([]byte, 32) // 256 bits
-	if _, err := rand.Read(key); err != nil {
-		return fmt.Errorf("failed to generate key: %w", err)
+// GetPreviousSigningKey returns the key replaced by the last rotation
+func (m *DistributedKeyManager) GetPreviousSigningKey(ctx context.Context, tokenType services.TokenType) ([]byte, error) {
+	versions, err := m.versionsOf(ctx, tokenType)
+	if err != nil || versions.previous == nil {
+		return nil, err
 	}
+	return versions.previous.Key, nil
+}
+
+// versionsOf returns the loaded keys of a token type, loading them on first use
+func (m *DistributedKeyManager) versionsOf(ctx context.Context, tokenType services.TokenType) (keyVersions, error) {
+	m.mutex.RLock()
+	versions, loaded := m.versions[tokenType]
+	m.mutex.RUnlock()
+	if loaded && versions.current != nil {
+		return versions, nil
+	}
+	return m.load(ctx, tokenType)
+}
+
+// load reads the current and previous keys of a token type from storage
+func (m *DistributedKeyManager) load(ctx context.Context, tokenType services.TokenType) (keyVersions, error) {
+	keys, err := m.keys.ListLatest(ctx, string(tokenType), keysPerTokenType)
+	if err != nil {
+		return keyVersions{}, fmt.Errorf("failed to load %s signing keys: %w", tokenType, err)
+	}
+	var versions keyVersions
+	if len(keys) > 0 {
+		versions.current = keys[0]
+	}
+	if len(keys) > 1 {
+		versions.previous = keys[1]
+	}
+
+	m.mutex.Lock()
+	m.versions[tokenType] = versions
+	m.mutex.Unlock()
+	return versions, nil
+}
 
-	encodedKey := base64.StdEncoding.EncodeToString(key)
-	err := m.cache.Set(ctx, fmt.Sprintf("signing_key:%s", tokenType), encodedKey, 0)
+// store generates and stores the given version of a token type's key. When
+// another replica stored that version first, its key is used instead.
+func (m *DistributedKeyManager) store(ctx context.Context, tokenType services.TokenType, algorithm string, version int) (keyVersions, error) {
+	key, err := generateSigningKey(algorithm)
 	if err != nil {
-		// Fallback to local key management if Redis is unavailable
-		m.local.mutex.Lock()
-		m.local.keys[tokenType] = key
-		m.local.mutex.Unlock()
+		return keyVersions{}, err
 	}
+	if err := m.create(ctx, tokenType, version, key, m.clock.Now().UTC()); err != nil {
+		return keyVersions{}, err
+	}
+	return m.load(ctx, tokenType)
+}
 
+// create stores a version of a token type's key and announces it. A version
+// stored first by another replica is not an error; that replica announces it.
+func (m *DistributedKeyManager) create(ctx context.Context, tokenType services.TokenType, version int, key []byte, createdAt time.Time) error {
+	err := m.keys.Create(ctx, &models.SigningKey{
+		TokenType: string(tokenType),
+		Version:   version,
+		Key:       key,
+		CreatedAt: createdAt,
+	})
+	if errors.Is(err, services.ErrConflict) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store %s signing key: %w", tokenType, err)
+	}
+	if m.notifier != nil {
+		// Replicas that miss the announcement pick the key up at their next sync
+		_ = m.notifier.NotifyKeyChanged(ctx, tokenType)
+	}
 	return nil
 }
+
+// importLegacyKeys stores the current and previous keys an earlier version
+// of the service kept in the cache, so that tokens they signed stay valid
+func (m *DistributedKeyManager) importLegacyKeys(ctx context.Context, tokenType services.TokenType) error {
+	current, err := m.legacyKey(ctx, "signing_key:"+string(tokenType))
+	if err != nil || current == nil {
+		return err
+	}
+	var createdAt time.Time
+	if err := m.legacy.Get(ctx, "signing_key_created_at:"+string(tokenType), &createdAt); err != nil || createdAt.IsZero() {
+		createdAt = m.clock.Now().UTC()
+	}
+
+	version := 1
+	previous, err := m.legacyKey(ctx, "signing_key_previous:"+string(tokenType))
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		if err := m.create(ctx, tokenType, version, previous, createdAt); err != nil {
+			return err
+		}
+		version++
+	}
+	if err := m.create(ctx, tokenType, version, current, createdAt); err != nil {
+		return err
+	}
+	_, err = m.load(ctx, tokenType)
+	return err
+}
+
+// legacyKey reads a key from the legacy cache, or nil if it is not there
+func (m *DistributedKeyManager) legacyKey(ctx context.Context, cacheKey string) ([]byte, error) {
+	var encodedKey string
+	if err := m.legacy.Get(ctx, cacheKey, &encodedKey); err != nil {
+		if errors.Is(err, services.ErrCacheKeyNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read legacy signing key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode legacy signing key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,93 @@
+package token
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedClock is a clock that only moves when a test advances it
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+type memorySigningKeyRepository struct {
+	mu   sync.Mutex
+	keys []*models.SigningKey
+}
+
+func (r *memorySigningKeyRepository) Create(ctx context.Context, key *models.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.keys {
+		if existing.TokenType == key.TokenType && existing.Version == key.Version {
+			return services.ErrConflict
+		}
+	}
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *memorySigningKeyRepository) ListLatest(ctx context.Context, tokenType string, limit int) ([]*models.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []*models.SigningKey
+	for _, key := range r.keys {
+		if key.TokenType == tokenType {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Version > keys[j].Version })
+	return keys[:min(len(keys), limit)], nil
+}
+
+func TestSigningKeyRotationFollowsClock(t *testing.T) {
+	for name, newKeyManager := range map[string]func(services.Clock) KeyManager{
+		"local": func(clock services.Clock) KeyManager { return NewLocalKeyManager(clock) },
+		"distributed": func(clock services.Clock) KeyManager {
+			return NewDistributedKeyManager(&memorySigningKeyRepository{}, nil, nil, clock)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			clock := &fixedClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+			s := NewService(services.TokenConfig{KeyRotationInterval: 24 * time.Hour},
+				memory.NewCacheService(), newKeyManager(clock), WithClock(clock))
+
+			info, err := s.signingKeyInfo(ctx, services.TokenTypeAccess)
+			require.NoError(t, err)
+			require.NotNil(t, info.CreatedAt)
+			assert.True(t, info.CreatedAt.Equal(clock.now))
+			assert.Equal(t, services.SigningKeyActive, info.Status)
+
+			createdAt := clock.now
+			clock.now = createdAt.Add(24 * time.Hour)
+			info, err = s.signingKeyInfo(ctx, services.TokenTypeAccess)
+			require.NoError(t, err)
+			assert.Equal(t, services.SigningKeyActive, info.Status)
+
+			clock.now = createdAt.Add(24*time.Hour + time.Second)
+			info, err = s.signingKeyInfo(ctx, services.TokenTypeAccess)
+			require.NoError(t, err)
+			assert.Equal(t, services.SigningKeyRotationDue, info.Status)
+
+			rotated, err := s.RotateSigningKey(ctx, services.TokenTypeAccess)
+			require.NoError(t, err)
+			assert.NotEqual(t, info.KeyID, rotated.KeyID)
+			assert.True(t, rotated.CreatedAt.Equal(clock.now))
+			assert.Equal(t, services.SigningKeyActive, rotated.Status)
+		})
+	}
+}
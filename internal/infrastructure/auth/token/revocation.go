@@ -0,0 +1,164 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// Revocation keeps revoked tokens and sessions in the cache, shared by all
+// replicas, until the tokens they cover have expired. Both token services
+// check it, whatever algorithm they sign with.
+type Revocation struct {
+	config services.TokenConfig
+	cache  services.CacheService
+	clock  services.Clock
+}
+
+// NewRevocation creates a revocation store over the cache
+func NewRevocation(config services.TokenConfig, cache services.CacheService, clock services.Clock) *Revocation {
+	return &Revocation{
+		config: config,
+		cache:  cache,
+		clock:  clock,
+	}
+}
+
+// RevokeToken revokes a token
+func (r *Revocation) RevokeToken(ctx context.Context, token string) error {
+	// Store the token in the blacklist with an expiration
+	err := r.cache.Set(ctx, fmt.Sprintf("revoked_token:%s", token), true, r.config.AccessTokenDuration)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// IsTokenRevoked checks if a token has been revoked
+func (r *Revocation) IsTokenRevoked(ctx context.Context, token string) (bool, error) {
+	var isRevoked bool
+	err := r.cache.Get(ctx, fmt.Sprintf("revoked_token:%s", token), &isRevoked)
+	if err != nil {
+		if errors.Is(err, services.ErrCacheKeyNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return isRevoked, nil
+}
+
+// RevokeSession revokes every token issued for the given session. The entry
+// outlives the refresh tokens of the session.
+func (r *Revocation) RevokeSession(ctx context.Context, sessionID string) error {
+	err := r.cache.Set(ctx, fmt.Sprintf("revoked_session:%s", sessionID), true, r.config.RefreshTokenDuration)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes every session token issued to a user up to
+// now. Token issue times have a resolution of seconds, so tokens issued in
+// the same second are revoked as well.
+func (r *Revocation) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	err := r.cache.Set(ctx, fmt.Sprintf("revoked_user_sessions:%s", userID), r.clock.Now().Unix(), r.config.RefreshTokenDuration)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+	return nil
+}
+
+// Check rejects a verified token that was revoked, alone, with its session
+// or with all sessions of its user. While the cache is unavailable, the
+// failure policy decides whether the token is rejected or only accepted when
+// recently issued.
+func (r *Revocation) Check(ctx context.Context, tokenString string, tokenType services.TokenType, userID uuid.UUID, claims jwt.MapClaims) error {
+	degraded := false
+	isRevoked, err := r.IsTokenRevoked(ctx, tokenString)
+	if err != nil {
+		if !r.skipOnFailure() {
+			return err
+		}
+		degraded = true
+	}
+	if isRevoked {
+		return fmt.Errorf("token is revoked")
+	}
+
+	if sessionID, _ := claims["sid"].(string); sessionID != "" {
+		var sessionRevoked bool
+		err := r.cache.Get(ctx, fmt.Sprintf("revoked_session:%s", sessionID), &sessionRevoked)
+		if err != nil && !errors.Is(err, services.ErrCacheKeyNotFound) {
+			if !r.skipOnFailure() {
+				return fmt.Errorf("failed to check session revocation: %w", err)
+			}
+			degraded = true
+		}
+		if sessionRevoked {
+			return fmt.Errorf("session is revoked")
+		}
+	}
+
+	// Session tokens issued before all sessions of their user were revoked
+	if tokenType == services.TokenTypeAccess || tokenType == services.TokenTypeRefresh {
+		revoked, err := r.userSessionsRevoked(ctx, userID, claims)
+		if err != nil {
+			if !r.skipOnFailure() {
+				return err
+			}
+			degraded = true
+		}
+		if revoked {
+			return fmt.Errorf("session is revoked")
+		}
+	}
+
+	// Without a revocation check only recently issued tokens are accepted,
+	// bounding how long a revoked token stays usable
+	if degraded {
+		issuedAt, err := claims.GetIssuedAt()
+		if err != nil || issuedAt == nil || r.clock.Now().Sub(issuedAt.Time) > r.degradedMaxTokenAge() {
+			return services.ErrRevocationUnavailable
+		}
+	}
+	return nil
+}
+
+// userSessionsRevoked reports whether a token was issued before the
+// sessions of its user were last revoked
+func (r *Revocation) userSessionsRevoked(ctx context.Context, userID uuid.UUID, claims jwt.MapClaims) (bool, error) {
+	var revokedAt int64
+	err := r.cache.Get(ctx, fmt.Sprintf("revoked_user_sessions:%s", userID), &revokedAt)
+	if err != nil {
+		if errors.Is(err, services.ErrCacheKeyNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check user session revocation: %w", err)
+	}
+
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return true, nil
+	}
+	return issuedAt.Unix() <= revokedAt, nil
+}
+
+// skipOnFailure reports whether tokens may be validated without a
+// revocation check while the revocation store is unavailable
+func (r *Revocation) skipOnFailure() bool {
+	return r.config.RevocationFailurePolicy == services.RevocationSkipCheck
+}
+
+// degradedMaxTokenAge returns the maximum age of tokens accepted without a
+// revocation check
+func (r *Revocation) degradedMaxTokenAge() time.Duration {
+	if r.config.DegradedMaxTokenAge > 0 {
+		return r.config.DegradedMaxTokenAge
+	}
+	return services.DefaultDegradedMaxTokenAge
+}
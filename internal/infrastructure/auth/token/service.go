@@ -13,39 +13,139 @@ import (
 // Service implements the domain.TokenService interface
 type Service struct {
 	config     services.TokenConfig
-	cache      services.CacheService
+	revocation *Revocation
 	keyManager KeyManager
+	clock      services.Clock
+	enrichers  []services.ClaimsEnricher
 }
 
-// NewService creates a new token service
-func NewService(config services.TokenConfig, cache services.CacheService, keyManager KeyManager) *Service {
-	return &Service{
+// Option configures optional settings of the token service
+type Option func(*Service)
+
+// WithClock sets the clock tokens are issued and validated with; the
+// default is the system clock
+func WithClock(clock services.Clock) Option {
+	return func(s *Service) {
+		s.clock = clock
+	}
+}
+
+// WithClaimsEnrichers adds custom claims to access and ID tokens with the
+// enrichers, which run in order
+func WithClaimsEnrichers(enrichers ...services.ClaimsEnricher) Option {
+	return func(s *Service) {
+		s.enrichers = append(s.enrichers, enrichers...)
+	}
+}
+
+// NewService creates a new token service. Magic link token lifetimes
+// default to DefaultMagicLinkTokenDuration.
+func NewService(config services.TokenConfig, cache services.CacheService, keyManager KeyManager, opts ...Option) *Service {
+	if config.MagicLinkTokenDuration == 0 {
+		config.MagicLinkTokenDuration = services.DefaultMagicLinkTokenDuration
+	}
+	s := &Service{
 		config:     config,
-		cache:      cache,
 		keyManager: keyManager,
+		clock:      services.SystemClock,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.revocation = NewRevocation(config, cache, s.clock)
+	return s
 }
 
 // generateToken creates a new JWT token
 func (s *Service) generateToken(ctx context.Context, claims services.TokenClaims, duration time.Duration) (string, error) {
-	now := time.Now()
+	if claims.Lifetime > 0 && claims.Lifetime < duration {
+		duration = claims.Lifetime
+	}
+	now := s.clock.Now()
+	activatesAt := now
+	if claims.NotBefore.After(now) {
+		activatesAt = claims.NotBefore
+	}
 	jwtClaims := jwt.MapClaims{
 		"user_id":    claims.UserID.String(),
 		"email":      claims.Email,
 		"username":   claims.Username,
+		"role":       claims.Role,
 		"token_type": string(claims.TokenType),
+		"jti":        uuid.New().String(),
 		"iat":        now.Unix(),
-		"exp":        now.Add(duration).Unix(),
+		"exp":        activatesAt.Add(duration).Unix(),
+	}
+	if activatesAt.After(now) {
+		jwtClaims["nbf"] = activatesAt.Unix()
+	}
+	if claims.SessionID != "" {
+		jwtClaims["sid"] = claims.SessionID
+	}
+	if claims.DeviceFingerprint != "" {
+		jwtClaims["dfp"] = claims.DeviceFingerprint
+	}
+	if claims.ClientID != "" {
+		jwtClaims["client_id"] = claims.ClientID
+	}
+	if claims.Scope != "" {
+		jwtClaims["scope"] = claims.Scope
+	}
+	if len(claims.Permissions) > 0 {
+		jwtClaims["permissions"] = claims.Permissions
+	}
+	if claims.TenantID != "" {
+		jwtClaims["tenant_id"] = claims.TenantID
+	}
+	// Tokens refreshed elsewhere keep the region they were first issued in
+	region := claims.Region
+	if region == "" {
+		region = s.config.Region
+	}
+	if region != "" {
+		jwtClaims["region"] = region
+	}
+	if claims.GivenName != "" {
+		jwtClaims["given_name"] = claims.GivenName
+	}
+	if claims.FamilyName != "" {
+		jwtClaims["family_name"] = claims.FamilyName
+	}
+	if claims.EmailVerified != nil {
+		jwtClaims["email_verified"] = *claims.EmailVerified
+	}
+	if claims.Audience != "" {
+		jwtClaims["aud"] = claims.Audience
+	}
+	if claims.TokenType == services.TokenTypeAccess {
+		if err := services.EnrichClaims(ctx, s.enrichers, services.TokenTypeAccess, jwtClaims); err != nil {
+			return "", err
+		}
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims)
+	return s.sign(ctx, claims.TokenType, jwtClaims)
+}
+
+// sign signs claims with the current key of the given token type
+func (s *Service) sign(ctx context.Context, tokenType services.TokenType, jwtClaims jwt.MapClaims) (string, error) {
+	method, err := s.signingMethod(tokenType)
+	if err != nil {
+		return "", err
+	}
 
-	key, err := s.keyManager.GetSigningKey(ctx, claims.TokenType)
+	key, err := s.currentKey(ctx, tokenType)
+	if err != nil {
+		return "", err
+	}
+	signingKey, err := signingKeyMaterial(method.Alg(), key)
 	if err != nil {
-		return "", fmt.Errorf("failed to get signing key: %w", err)
+		return "", err
 	}
 
-	signedToken, err := token.SignedString(key)
+	token := jwt.NewWithClaims(method, jwtClaims)
+	token.Header["kid"] = services.SigningKeyID(key)
+
+	signedToken, err := token.SignedString(signingKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -73,28 +173,192 @@ func (s *Service) GenerateVerificationToken(ctx context.Context, claims services
 	return s.generateToken(ctx, claims, s.config.VerificationTokenDuration)
 }
 
-// ValidateToken validates a token and returns its claims
-func (s *Service) ValidateToken(ctx context.Context, tokenString string, tokenType services.TokenType) (*services.TokenClaims, error) {
-	// Check if token is revoked
-	isRevoked, err := s.IsTokenRevoked(ctx, tokenString)
+// GenerateMagicLinkToken generates a passwordless login token
+func (s *Service) GenerateMagicLinkToken(ctx context.Context, claims services.TokenClaims) (string, error) {
+	return s.generateToken(ctx, claims, s.config.MagicLinkTokenDuration)
+}
+
+// GenerateIDToken generates an OpenID Connect ID token
+func (s *Service) GenerateIDToken(ctx context.Context, claims services.IDTokenClaims) (string, error) {
+	now := s.clock.Now()
+	jwtClaims := jwt.MapClaims(claims.UserInfo.Claims())
+	jwtClaims["iss"] = claims.Issuer
+	jwtClaims["aud"] = claims.Audience
+	jwtClaims["iat"] = now.Unix()
+	jwtClaims["exp"] = now.Add(s.config.AccessTokenDuration).Unix()
+	if claims.Nonce != "" {
+		jwtClaims["nonce"] = claims.Nonce
+	}
+	if !claims.AuthTime.IsZero() {
+		jwtClaims["auth_time"] = claims.AuthTime.Unix()
+	}
+	if err := services.EnrichClaims(ctx, s.enrichers, services.TokenTypeID, jwtClaims); err != nil {
+		return "", err
+	}
+	return s.sign(ctx, services.TokenTypeAccess, jwtClaims)
+}
+
+// TokenDuration returns the lifetime of tokens of the given type
+func (s *Service) TokenDuration(tokenType services.TokenType) time.Duration {
+	switch tokenType {
+	case services.TokenTypeAccess:
+		return s.config.AccessTokenDuration
+	case services.TokenTypeRefresh:
+		return s.config.RefreshTokenDuration
+	case services.TokenTypeReset:
+		return s.config.ResetTokenDuration
+	case services.TokenTypeVerification:
+		return s.config.VerificationTokenDuration
+	case services.TokenTypeMagicLink:
+		return s.config.MagicLinkTokenDuration
+	default:
+		return 0
+	}
+}
+
+// signingMethod returns the configured signing method of the given token type
+func (s *Service) signingMethod(tokenType services.TokenType) (jwt.SigningMethod, error) {
+	alg := s.config.KeyPolicy(tokenType).Algorithm
+	if !services.IsSigningAlgorithm(alg) {
+		return nil, fmt.Errorf("unsupported signing algorithm %q for %s tokens", alg, tokenType)
+	}
+	return jwt.GetSigningMethod(alg), nil
+}
+
+// currentKey returns the current signing key of the given token type. A key
+// generated for another algorithm is replaced first, so that reconfiguring
+// the algorithm of a token type takes effect without a manual rotation.
+func (s *Service) currentKey(ctx context.Context, tokenType services.TokenType) ([]byte, error) {
+	alg := s.config.KeyPolicy(tokenType).Algorithm
+	key, err := s.keyManager.GetSigningKey(ctx, tokenType, alg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+		return nil, fmt.Errorf("failed to get signing key: %w", err)
 	}
-	if isRevoked {
-		return nil, fmt.Errorf("token is revoked")
+	if keyMatchesAlgorithm(alg, key) {
+		return key, nil
 	}
 
-	key, err := s.keyManager.GetSigningKey(ctx, tokenType)
+	if err := s.keyManager.RotateKey(ctx, tokenType, alg); err != nil {
+		return nil, fmt.Errorf("failed to replace signing key: %w", err)
+	}
+	key, err = s.keyManager.GetSigningKey(ctx, tokenType, alg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get signing key: %w", err)
 	}
+	if !keyMatchesAlgorithm(alg, key) {
+		return nil, fmt.Errorf("signing key of %s tokens does not match algorithm %s", tokenType, alg)
+	}
+	return key, nil
+}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// signingKeyInfo describes the current signing key of the given token type
+func (s *Service) signingKeyInfo(ctx context.Context, tokenType services.TokenType) (*services.SigningKeyInfo, error) {
+	key, err := s.currentKey(ctx, tokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt, err := s.keyManager.GetKeyCreatedAt(ctx, tokenType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signing key age: %w", err)
+	}
+
+	policy := s.config.KeyPolicy(tokenType)
+	info := services.NewSigningKeyInfo(tokenType, policy.Algorithm, key, createdAt, policy.RotationInterval, s.clock.Now())
+	return &info, nil
+}
+
+// ListSigningKeys describes the current signing key of every token type
+func (s *Service) ListSigningKeys(ctx context.Context) ([]services.SigningKeyInfo, error) {
+	keys := make([]services.SigningKeyInfo, 0, len(services.SigningKeyTokenTypes))
+	for _, tokenType := range services.SigningKeyTokenTypes {
+		info, err := s.signingKeyInfo(ctx, tokenType)
+		if err != nil {
+			return nil, err
 		}
-		return key, nil
-	})
+		keys = append(keys, *info)
+	}
+	return keys, nil
+}
+
+// RotateSigningKey replaces the signing key of the given token type
+func (s *Service) RotateSigningKey(ctx context.Context, tokenType services.TokenType) (*services.SigningKeyInfo, error) {
+	if err := s.keyManager.RotateKey(ctx, tokenType, s.config.KeyPolicy(tokenType).Algorithm); err != nil {
+		return nil, fmt.Errorf("failed to rotate signing key: %w", err)
+	}
+	return s.signingKeyInfo(ctx, tokenType)
+}
+
+// JWKS returns the public keys of the token types signed with an asymmetric
+// algorithm. The key replaced by the last rotation is included while tokens
+// it signed may still be in use.
+func (s *Service) JWKS(ctx context.Context) ([]services.JSONWebKey, error) {
+	keys := []services.JSONWebKey{}
+	for _, tokenType := range services.SigningKeyTokenTypes {
+		alg := s.config.KeyPolicy(tokenType).Algorithm
+		if !services.IsAsymmetricAlgorithm(alg) {
+			continue
+		}
+
+		key, err := s.currentKey(ctx, tokenType)
+		if err != nil {
+			return nil, err
+		}
+		jwk, err := newJSONWebKey(alg, key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, jwk)
+
+		previous, err := s.keyManager.GetPreviousSigningKey(ctx, tokenType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get previous signing key: %w", err)
+		}
+		if previous != nil && keyMatchesAlgorithm(alg, previous) {
+			jwk, err := newJSONWebKey(alg, previous)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, jwk)
+		}
+	}
+	return keys, nil
+}
+
+// verificationKey returns the key material matching the kid header of a
+// token. Tokens without a kid are checked against the current key.
+func (s *Service) verificationKey(ctx context.Context, tokenType services.TokenType, alg, kid string) (interface{}, error) {
+	key, err := s.currentKey(ctx, tokenType)
+	if err != nil {
+		return nil, err
+	}
+	if kid == "" || kid == services.SigningKeyID(key) {
+		return verificationKeyMaterial(alg, key)
+	}
+
+	previous, err := s.keyManager.GetPreviousSigningKey(ctx, tokenType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous signing key: %w", err)
+	}
+	if previous != nil && kid == services.SigningKeyID(previous) {
+		return verificationKeyMaterial(alg, previous)
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// ValidateToken validates a token and returns its claims
+func (s *Service) ValidateToken(ctx context.Context, tokenString string, tokenType services.TokenType) (*services.TokenClaims, error) {
+	method, err := s.signingMethod(tokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parsing also refuses tokens used before their nbf claim
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.verificationKey(ctx, tokenType, method.Alg(), kid)
+	}, jwt.WithValidMethods([]string{method.Alg()}), jwt.WithTimeFunc(s.clock.Now))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -124,30 +388,84 @@ func (s *Service) ValidateToken(ctx context.Context, tokenString string, tokenTy
 		return nil, fmt.Errorf("invalid user_id format: %w", err)
 	}
 
-	return &services.TokenClaims{
-		UserID:    userID,
-		Email:     claims["email"].(string),
-		Username:  claims["username"].(string),
-		TokenType: tokenType,
-	}, nil
+	// Reject revoked tokens and tokens of revoked sessions
+	if err := s.revocation.Check(ctx, tokenString, tokenType, userID, claims); err != nil {
+		return nil, err
+	}
+
+	role, _ := claims["role"].(string)
+	clientID, _ := claims["client_id"].(string)
+	scope, _ := claims["scope"].(string)
+	permissions := stringsClaim(claims["permissions"])
+	tenantID, _ := claims["tenant_id"].(string)
+	region, _ := claims["region"].(string)
+	givenName, _ := claims["given_name"].(string)
+	familyName, _ := claims["family_name"].(string)
+	sessionID, _ := claims["sid"].(string)
+	deviceFingerprint, _ := claims["dfp"].(string)
+
+	result := &services.TokenClaims{
+		UserID:            userID,
+		Email:             claims["email"].(string),
+		Username:          claims["username"].(string),
+		Role:              role,
+		TokenType:         tokenType,
+		SessionID:         sessionID,
+		DeviceFingerprint: deviceFingerprint,
+		ClientID:          clientID,
+		Scope:             scope,
+		Permissions:       permissions,
+		TenantID:          tenantID,
+		Region:            region,
+		GivenName:         givenName,
+		FamilyName:        familyName,
+	}
+	if issuedAt, err := claims.GetIssuedAt(); err == nil && issuedAt != nil {
+		result.IssuedAt = issuedAt.Time
+	}
+	if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+		result.ExpiresAt = expiresAt.Time
+	}
+	if notBefore, err := claims.GetNotBefore(); err == nil && notBefore != nil {
+		result.NotBefore = notBefore.Time
+	}
+	if audience, err := claims.GetAudience(); err == nil && len(audience) > 0 {
+		result.Audience = audience[0]
+	}
+	if emailVerified, ok := claims["email_verified"].(bool); ok {
+		result.EmailVerified = &emailVerified
+	}
+	return result, nil
+}
+
+// stringsClaim returns the strings of a JSON array claim
+func stringsClaim(claim interface{}) []string {
+	items, _ := claim.([]interface{})
+	var values []string
+	for _, item := range items {
+		if value, ok := item.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values
 }
 
 // RevokeToken revokes a token
 func (s *Service) RevokeToken(ctx context.Context, token string) error {
-	// Store the token in the blacklist with an expiration
-	err := s.cache.Set(ctx, fmt.Sprintf("revoked_token:%s", token), true, s.config.AccessTokenDuration)
-	if err != nil {
-		return fmt.Errorf("failed to revoke token: %w", err)
-	}
-	return nil
+	return s.revocation.RevokeToken(ctx, token)
 }
 
 // IsTokenRevoked checks if a token has been revoked
 func (s *Service) IsTokenRevoked(ctx context.Context, token string) (bool, error) {
-	var isRevoked bool
-	err := s.cache.Get(ctx, fmt.Sprintf("revoked_token:%s", token), &isRevoked)
-	if err != nil {
-		return false, fmt.Errorf("failed to check token revocation: %w", err)
-	}
-	return isRevoked, nil
+	return s.revocation.IsTokenRevoked(ctx, token)
+}
+
+// RevokeSession revokes every token issued for the given session
+func (s *Service) RevokeSession(ctx context.Context, sessionID string) error {
+	return s.revocation.RevokeSession(ctx, sessionID)
+}
+
+// RevokeUserSessions revokes every session token issued to a user so far
+func (s *Service) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return s.revocation.RevokeUserSessions(ctx, userID)
 }
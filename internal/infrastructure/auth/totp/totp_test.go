@@ -0,0 +1,81 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 key of the RFC 6238 test vectors
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestValidateRFC6238Vectors(t *testing.T) {
+	// The RFC lists eight digit codes; six digit codes are their last six
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+
+	s := NewService("identity")
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			at := time.Unix(tt.unix, 0)
+			step, ok := s.Validate(rfcSecret, tt.code, at)
+			require.True(t, ok)
+			assert.Equal(t, tt.unix/30, step)
+		})
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	s := NewService("identity")
+	at := time.Unix(1111111111, 0)
+	current := at.Unix() / 30
+	key, err := encoding.DecodeString(rfcSecret)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		offset int64
+		ok     bool
+	}{
+		{name: "previous step", offset: -1, ok: true},
+		{name: "current step", offset: 0, ok: true},
+		{name: "next step", offset: 1, ok: true},
+		{name: "two steps behind", offset: -2, ok: false},
+		{name: "two steps ahead", offset: 2, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := s.Validate(rfcSecret, generate(key, current+tt.offset), at)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, current+tt.offset, step)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsMalformedInput(t *testing.T) {
+	s := NewService("identity")
+	at := time.Unix(59, 0)
+
+	_, ok := s.Validate(rfcSecret, " 287 082 ", at)
+	assert.True(t, ok, "spaces are ignored")
+	_, ok = s.Validate(rfcSecret, "28708", at)
+	assert.False(t, ok, "short code")
+	_, ok = s.Validate(rfcSecret, "94287082", at)
+	assert.False(t, ok, "eight digit code")
+	_, ok = s.Validate("not base32!", "287082", at)
+	assert.False(t, ok, "invalid secret")
+}
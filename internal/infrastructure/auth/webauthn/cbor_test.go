@@ -0,0 +1,75 @@
+package webauthn
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want interface{}
+	}{
+		{name: "small integer", data: []byte{0x17}, want: int64(23)},
+		{name: "one-byte integer", data: []byte{0x18, 0x18}, want: int64(24)},
+		{name: "eight-byte integer", data: []byte{0x1b, 0, 0, 0, 1, 0, 0, 0, 0}, want: int64(1 << 32)},
+		{name: "negative integer", data: []byte{0x39, 0x01, 0x00}, want: int64(-257)},
+		{name: "byte string", data: []byte{0x43, 1, 2, 3}, want: []byte{1, 2, 3}},
+		{name: "text string", data: []byte{0x63, 'f', 'm', 't'}, want: "fmt"},
+		{name: "array", data: []byte{0x82, 0x01, 0xf5}, want: []interface{}{int64(1), true}},
+		{name: "map", data: []byte{0xa2, 0x01, 0x02, 0x61, 'a', 0xf4}, want: map[interface{}]interface{}{int64(1): int64(2), "a": false}},
+		{name: "tag", data: []byte{0xd8, 0x18, 0x41, 0x00}, want: []byte{0}},
+		{name: "float is skipped", data: []byte{0xf9, 0x3c, 0x00}, want: nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := decodeCBOR(append(tt.data, 0xff))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, []byte{0xff}, rest)
+		})
+	}
+}
+
+func TestDecodeCBORRejects(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "missing argument", data: []byte{0x19, 0x01}},
+		{name: "oversized byte string", data: []byte{0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "oversized array", data: []byte{0x9a, 0xff, 0xff, 0xff, 0xff, 0x01}},
+		{name: "oversized map", data: []byte{0xba, 0xff, 0xff, 0xff, 0xff, 0x01, 0x02}},
+		{name: "integer overflows int64", data: []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "negative integer overflows int64", data: []byte{0x3b, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+		{name: "indefinite length", data: []byte{0x5f, 0x41, 0x00, 0xff}},
+		{name: "duplicate map key", data: []byte{0xa2, 0x01, 0x01, 0x01, 0x02}},
+		{name: "unsupported map key", data: []byte{0xa1, 0x41, 0x00, 0x01}},
+		{name: "unsupported simple value", data: []byte{0xf8, 0x20}},
+		{name: "truncated float", data: []byte{0xfb, 0x00}},
+		{name: "nested too deeply", data: append(bytes.Repeat([]byte{0x81}, maxCBORDepth+1), 0x00)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := decodeCBOR(tt.data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDecodeCBORTruncated(t *testing.T) {
+	a := newAuthenticator(t, coseAlgES256)
+	object := a.register("none", []byte("{}")).AttestationObject
+
+	// Every prefix of an attestation object is incomplete
+	for i := 0; i < len(object); i++ {
+		_, _, err := decodeCBOR(object[:i])
+		require.Error(t, err, "prefix of %d bytes", i)
+	}
+	_, rest, err := decodeCBOR(object)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+}
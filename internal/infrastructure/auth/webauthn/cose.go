@@ -0,0 +1,132 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE key parameters and values (RFC 9053) of the supported algorithms
+const (
+	coseKeyType      = 1
+	coseKeyAlgorithm = 3
+	coseKeyCurve     = -1 // EC2 and OKP
+	coseKeyX         = -2 // EC2 and OKP
+	coseKeyY         = -3 // EC2
+	coseKeyN         = -1 // RSA modulus
+	coseKeyE         = -2 // RSA exponent
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+
+	coseAlgES256 = -7
+	coseAlgEdDSA = -8
+	coseAlgRS256 = -257
+)
+
+// minRSABits is the smallest RSA modulus accepted
+const minRSABits = 2048
+
+// publicKey is a passkey's public key that checks assertion signatures
+type publicKey interface {
+	verify(data, signature []byte) bool
+}
+
+type ecdsaKey struct{ key *ecdsa.PublicKey }
+
+func (k ecdsaKey) verify(data, signature []byte) bool {
+	digest := sha256.Sum256(data)
+	return ecdsa.VerifyASN1(k.key, digest[:], signature)
+}
+
+type ed25519Key struct{ key ed25519.PublicKey }
+
+func (k ed25519Key) verify(data, signature []byte) bool {
+	return ed25519.Verify(k.key, data, signature)
+}
+
+type rsaKey struct{ key *rsa.PublicKey }
+
+func (k rsaKey) verify(data, signature []byte) bool {
+	digest := sha256.Sum256(data)
+	return rsa.VerifyPKCS1v15(k.key, crypto.SHA256, digest[:], signature) == nil
+}
+
+// parsePublicKey decodes a COSE_Key of one of the supported algorithms
+func parsePublicKey(data []byte) (publicKey, error) {
+	item, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after public key")
+	}
+	return publicKeyFromCOSE(item)
+}
+
+func publicKeyFromCOSE(item interface{}) (publicKey, error) {
+	params, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("public key is not a COSE_Key")
+	}
+	keyType, _ := params[int64(coseKeyType)].(int64)
+	algorithm, _ := params[int64(coseKeyAlgorithm)].(int64)
+
+	switch {
+	case keyType == coseKeyTypeEC2 && algorithm == coseAlgES256:
+		curve, _ := params[int64(coseKeyCurve)].(int64)
+		x, _ := params[int64(coseKeyX)].([]byte)
+		y, _ := params[int64(coseKeyY)].([]byte)
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid ES256 public key")
+		}
+		// ecdh rejects points that are not on the curve
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("invalid ES256 public key: %w", err)
+		}
+		return ecdsaKey{key: &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}}, nil
+
+	case keyType == coseKeyTypeOKP && algorithm == coseAlgEdDSA:
+		curve, _ := params[int64(coseKeyCurve)].(int64)
+		x, _ := params[int64(coseKeyX)].([]byte)
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid EdDSA public key")
+		}
+		return ed25519Key{key: ed25519.PublicKey(x)}, nil
+
+	case keyType == coseKeyTypeRSA && algorithm == coseAlgRS256:
+		n, _ := params[int64(coseKeyN)].([]byte)
+		e, _ := params[int64(coseKeyE)].([]byte)
+		if len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RS256 public key")
+		}
+		modulus := new(big.Int).SetBytes(n)
+		if modulus.BitLen() < minRSABits {
+			return nil, errors.New("RS256 public key is too short")
+		}
+		exponent := int(new(big.Int).SetBytes(e).Int64())
+		if exponent < 3 || exponent%2 == 0 {
+			return nil, errors.New("invalid RS256 public key exponent")
+		}
+		return rsaKey{key: &rsa.PublicKey{N: modulus, E: exponent}}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported public key type %d with algorithm %d", keyType, algorithm)
+	}
+}
@@ -0,0 +1,91 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePublicKey(t *testing.T) {
+	data := []byte("signed data")
+
+	t.Run("ES256", func(t *testing.T) {
+		a := newAuthenticator(t, coseAlgES256)
+		key, err := parsePublicKey(a.coseKey())
+		require.NoError(t, err)
+		assert.True(t, key.verify(data, a.sign(data)))
+		assert.False(t, key.verify([]byte("other data"), a.sign(data)))
+	})
+
+	t.Run("RS256", func(t *testing.T) {
+		a := newAuthenticator(t, coseAlgRS256)
+		key, err := parsePublicKey(a.coseKey())
+		require.NoError(t, err)
+		assert.True(t, key.verify(data, a.sign(data)))
+		assert.False(t, key.verify([]byte("other data"), a.sign(data)))
+	})
+
+	t.Run("EdDSA", func(t *testing.T) {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		key, err := parsePublicKey(encodeCBOR(cborMap{
+			{coseKeyType, coseKeyTypeOKP},
+			{coseKeyAlgorithm, coseAlgEdDSA},
+			{coseKeyCurve, coseCurveEd25519},
+			{coseKeyX, []byte(public)},
+		}))
+		require.NoError(t, err)
+		assert.True(t, key.verify(data, ed25519.Sign(private, data)))
+	})
+}
+
+func TestParsePublicKeyRejects(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	x := ecKey.X.FillBytes(make([]byte, 32))
+	y := ecKey.Y.FillBytes(make([]byte, 32))
+	offCurve := append([]byte(nil), y...)
+	offCurve[31] ^= 0x01
+
+	shortRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	es256 := newAuthenticator(t, coseAlgES256).coseKey()
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "point not on curve", data: encodeCBOR(cborMap{
+			{coseKeyType, coseKeyTypeEC2}, {coseKeyAlgorithm, coseAlgES256}, {coseKeyCurve, coseCurveP256}, {coseKeyX, x}, {coseKeyY, offCurve},
+		})},
+		{name: "other curve", data: encodeCBOR(cborMap{
+			{coseKeyType, coseKeyTypeEC2}, {coseKeyAlgorithm, coseAlgES256}, {coseKeyCurve, 2}, {coseKeyX, x}, {coseKeyY, y},
+		})},
+		{name: "short coordinate", data: encodeCBOR(cborMap{
+			{coseKeyType, coseKeyTypeEC2}, {coseKeyAlgorithm, coseAlgES256}, {coseKeyCurve, coseCurveP256}, {coseKeyX, x[1:]}, {coseKeyY, y},
+		})},
+		{name: "RSA modulus too short", data: encodeCBOR(cborMap{
+			{coseKeyType, coseKeyTypeRSA}, {coseKeyAlgorithm, coseAlgRS256}, {coseKeyN, shortRSA.N.Bytes()}, {coseKeyE, []byte{1, 0, 1}},
+		})},
+		{name: "even RSA exponent", data: encodeCBOR(cborMap{
+			{coseKeyType, coseKeyTypeRSA}, {coseKeyAlgorithm, coseAlgRS256}, {coseKeyN, newAuthenticator(t, coseAlgRS256).rsaKey.N.Bytes()}, {coseKeyE, []byte{4}},
+		})},
+		{name: "unsupported algorithm", data: encodeCBOR(cborMap{
+			{coseKeyType, coseKeyTypeEC2}, {coseKeyAlgorithm, -35}, {coseKeyCurve, coseCurveP256}, {coseKeyX, x}, {coseKeyY, y},
+		})},
+		{name: "not a map", data: encodeCBOR([]interface{}{x, y})},
+		{name: "trailing data", data: append(append([]byte(nil), es256...), 0x00)},
+		{name: "truncated", data: es256[:len(es256)-1]},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePublicKey(tt.data)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,216 @@
+// Package webauthn verifies the responses of passkey authenticators (Web
+// Authentication, Level 2) for registration and sign-in. Attestation
+// statements are not verified: the service requests none and trusts no
+// particular authenticator model.
+package webauthn
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// Client data types of the two ceremonies
+const (
+	clientDataCreate = "webauthn.create"
+	clientDataGet    = "webauthn.get"
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagAttestedCredData = 0x40
+)
+
+// authenticatorDataMinLength is the length of the RP ID hash, the flags and
+// the signature counter that start every authenticator data
+const authenticatorDataMinLength = 37
+
+// maxCredentialIDLength bounds credential IDs (Web Authentication, Level 3)
+const maxCredentialIDLength = 1023
+
+// Config holds the relying party passkeys are registered for
+type Config struct {
+	RPID   string // domain passkeys are bound to, e.g. example.com
+	RPName string // shown by authenticators
+	// Origins are the origins of the web apps allowed to use the passkeys,
+	// e.g. https://app.example.com
+	Origins []string
+}
+
+// Verifier is a services.WebAuthnVerifier
+type Verifier struct {
+	config   Config
+	rpIDHash [32]byte
+}
+
+var _ services.WebAuthnVerifier = (*Verifier)(nil)
+
+// NewVerifier creates a verifier for the relying party of config
+func NewVerifier(config Config) (*Verifier, error) {
+	if config.RPID == "" {
+		return nil, errors.New("relying party ID is required")
+	}
+	if len(config.Origins) == 0 {
+		return nil, errors.New("at least one origin is required")
+	}
+	if config.RPName == "" {
+		config.RPName = config.RPID
+	}
+	return &Verifier{
+		config:   config,
+		rpIDHash: sha256.Sum256([]byte(config.RPID)),
+	}, nil
+}
+
+// RelyingParty returns the relying party passkeys are registered for
+func (v *Verifier) RelyingParty() services.RelyingParty {
+	return services.RelyingParty{ID: v.config.RPID, Name: v.config.RPName}
+}
+
+// VerifyRegistration checks an attestation answers the challenge and
+// returns the new passkey
+func (v *Verifier) VerifyRegistration(challenge []byte, attestation services.PasskeyAttestation) (*services.AttestedPasskey, error) {
+	if err := v.verifyClientData(attestation.ClientDataJSON, clientDataCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	item, _, err := decodeCBOR(attestation.AttestationObject)
+	if err != nil {
+		return nil, invalid("malformed attestation object: %v", err)
+	}
+	object, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, invalid("malformed attestation object")
+	}
+	authData, ok := object["authData"].([]byte)
+	if !ok {
+		return nil, invalid("attestation object has no authenticator data")
+	}
+
+	flags, signCount, err := v.verifyAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if flags&flagAttestedCredData == 0 {
+		return nil, invalid("authenticator data has no credential")
+	}
+
+	// Attested credential data: AAGUID, credential ID length and ID, then
+	// the public key followed by optional extensions
+	data := authData[authenticatorDataMinLength:]
+	if len(data) < 18 {
+		return nil, invalid("truncated credential data")
+	}
+	aaguid := data[:16]
+	idLength := int(binary.BigEndian.Uint16(data[16:18]))
+	data = data[18:]
+	if idLength == 0 || idLength > maxCredentialIDLength || len(data) < idLength {
+		return nil, invalid("invalid credential ID")
+	}
+	credentialID := data[:idLength]
+	data = data[idLength:]
+
+	keyItem, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, invalid("malformed public key: %v", err)
+	}
+	if _, err := publicKeyFromCOSE(keyItem); err != nil {
+		return nil, invalid("%v", err)
+	}
+
+	return &services.AttestedPasskey{
+		CredentialID: append([]byte(nil), credentialID...),
+		PublicKey:    append([]byte(nil), data[:len(data)-len(rest)]...),
+		AAGUID:       append([]byte(nil), aaguid...),
+		SignCount:    signCount,
+	}, nil
+}
+
+// VerifyAssertion checks an assertion answers the challenge and is signed
+// by the passkey with the given public key
+func (v *Verifier) VerifyAssertion(challenge []byte, coseKey []byte, assertion services.PasskeyAssertion) (uint32, error) {
+	if err := v.verifyClientData(assertion.ClientDataJSON, clientDataGet, challenge); err != nil {
+		return 0, err
+	}
+	_, signCount, err := v.verifyAuthenticatorData(assertion.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err := parsePublicKey(coseKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse stored public key: %w", err)
+	}
+	clientDataHash := sha256.Sum256(assertion.ClientDataJSON)
+	signed := append(append([]byte(nil), assertion.AuthenticatorData...), clientDataHash[:]...)
+	if !key.verify(signed, assertion.Signature) {
+		return 0, invalid("signature does not verify")
+	}
+	return signCount, nil
+}
+
+// clientData is the part of the client data the service checks
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// verifyClientData checks the client data was collected by an allowed
+// origin for the given ceremony and challenge
+func (v *Verifier) verifyClientData(data []byte, ceremony string, challenge []byte) error {
+	var client clientData
+	if err := json.Unmarshal(data, &client); err != nil {
+		return invalid("malformed client data: %v", err)
+	}
+	if client.Type != ceremony {
+		return invalid("unexpected client data type %q", client.Type)
+	}
+	received, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(client.Challenge, "="))
+	if err != nil || subtle.ConstantTimeCompare(received, challenge) != 1 {
+		return invalid("challenge does not match")
+	}
+	if !slices.Contains(v.config.Origins, client.Origin) {
+		return invalid("origin %q is not allowed", client.Origin)
+	}
+	if client.CrossOrigin {
+		return invalid("cross-origin requests are not allowed")
+	}
+	return nil
+}
+
+// verifyAuthenticatorData checks the authenticator data is for the relying
+// party and the user was present and verified, and returns its flags and
+// signature counter
+func (v *Verifier) verifyAuthenticatorData(data []byte) (byte, uint32, error) {
+	if len(data) < authenticatorDataMinLength {
+		return 0, 0, invalid("truncated authenticator data")
+	}
+	if subtle.ConstantTimeCompare(data[:32], v.rpIDHash[:]) != 1 {
+		return 0, 0, invalid("authenticator data is for another relying party")
+	}
+	flags := data[32]
+	if flags&flagUserPresent == 0 {
+		return 0, 0, invalid("user was not present")
+	}
+	if flags&flagUserVerified == 0 {
+		return 0, 0, invalid("user was not verified")
+	}
+	return flags, binary.BigEndian.Uint32(data[33:37]), nil
+}
+
+// invalid returns an ErrPasskeyInvalid describing why a response was rejected
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", services.ErrPasskeyInvalid, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,364 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://app.example.com"
+)
+
+// cborMap is a CBOR map whose entries are encoded in order, as
+// authenticators encode them canonically
+type cborMap []cborEntry
+
+type cborEntry struct {
+	key, value interface{}
+}
+
+// encodeCBOR encodes the subset of CBOR the tests build authenticator
+// responses from
+func encodeCBOR(value interface{}) []byte {
+	head := func(major byte, arg uint64) []byte {
+		switch {
+		case arg < 24:
+			return []byte{major<<5 | byte(arg)}
+		case arg <= 0xff:
+			return []byte{major<<5 | 24, byte(arg)}
+		case arg <= 0xffff:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+		case arg <= 0xffffffff:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+		default:
+			return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, arg)
+		}
+	}
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []interface{}:
+		out := head(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case cborMap:
+		out := head(5, uint64(len(v)))
+		for _, entry := range v {
+			out = append(out, encodeCBOR(entry.key)...)
+			out = append(out, encodeCBOR(entry.value)...)
+		}
+		return out
+	default:
+		panic("unsupported CBOR value")
+	}
+}
+
+// authenticator is a software authenticator holding one passkey
+type authenticator struct {
+	t            *testing.T
+	algorithm    int
+	ecKey        *ecdsa.PrivateKey
+	rsaKey       *rsa.PrivateKey
+	credentialID []byte
+	aaguid       []byte
+	counter      uint32
+}
+
+func newAuthenticator(t *testing.T, algorithm int) *authenticator {
+	a := &authenticator{
+		t:            t,
+		algorithm:    algorithm,
+		credentialID: randomBytes(t, 32),
+		aaguid:       randomBytes(t, 16),
+	}
+	var err error
+	switch algorithm {
+	case coseAlgES256:
+		a.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case coseAlgRS256:
+		a.rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		t.Fatalf("unsupported algorithm %d", algorithm)
+	}
+	require.NoError(t, err)
+	return a
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+	return b
+}
+
+// coseKey encodes the public key of the passkey as a COSE_Key
+func (a *authenticator) coseKey() []byte {
+	if a.ecKey != nil {
+		return encodeCBOR(cborMap{
+			{coseKeyType, coseKeyTypeEC2},
+			{coseKeyAlgorithm, coseAlgES256},
+			{coseKeyCurve, coseCurveP256},
+			{coseKeyX, a.ecKey.X.FillBytes(make([]byte, 32))},
+			{coseKeyY, a.ecKey.Y.FillBytes(make([]byte, 32))},
+		})
+	}
+	return encodeCBOR(cborMap{
+		{coseKeyType, coseKeyTypeRSA},
+		{coseKeyAlgorithm, coseAlgRS256},
+		{coseKeyN, a.rsaKey.N.Bytes()},
+		{coseKeyE, big.NewInt(int64(a.rsaKey.E)).Bytes()},
+	})
+}
+
+func (a *authenticator) sign(data []byte) []byte {
+	digest := sha256.Sum256(data)
+	var signature []byte
+	var err error
+	if a.ecKey != nil {
+		signature, err = ecdsa.SignASN1(rand.Reader, a.ecKey, digest[:])
+	} else {
+		signature, err = rsa.SignPKCS1v15(rand.Reader, a.rsaKey, crypto.SHA256, digest[:])
+	}
+	require.NoError(a.t, err)
+	return signature
+}
+
+// authenticatorData builds authenticator data for rpID with the given
+// flags, attesting the credential when the flags say so
+func (a *authenticator) authenticatorData(rpID string, flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.counter)
+	if flags&flagAttestedCredData != 0 {
+		data = append(data, a.aaguid...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+// register answers a registration with an attestation in the given format,
+// none or packed self attestation
+func (a *authenticator) register(format string, clientData []byte) services.PasskeyAttestation {
+	authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified|flagAttestedCredData)
+	statement := cborMap{}
+	if format == "packed" {
+		clientDataHash := sha256.Sum256(clientData)
+		statement = cborMap{
+			{"alg", a.algorithm},
+			{"sig", a.sign(append(append([]byte(nil), authData...), clientDataHash[:]...))},
+		}
+	}
+	return services.PasskeyAttestation{
+		ClientDataJSON: clientData,
+		AttestationObject: encodeCBOR(cborMap{
+			{"fmt", format},
+			{"attStmt", statement},
+			{"authData", authData},
+		}),
+	}
+}
+
+// assert answers a sign-in, counting the signature
+func (a *authenticator) assert(rpID string, clientData []byte) services.PasskeyAssertion {
+	a.counter++
+	authData := a.authenticatorData(rpID, flagUserPresent|flagUserVerified)
+	clientDataHash := sha256.Sum256(clientData)
+	return services.PasskeyAssertion{
+		CredentialID:      a.credentialID,
+		ClientDataJSON:    clientData,
+		AuthenticatorData: authData,
+		Signature:         a.sign(append(append([]byte(nil), authData...), clientDataHash[:]...)),
+	}
+}
+
+func clientDataJSON(t *testing.T, ceremony string, challenge []byte, origin string) []byte {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":      origin,
+		"crossOrigin": false,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func newTestVerifier(t *testing.T) *Verifier {
+	verifier, err := NewVerifier(Config{RPID: testRPID, Origins: []string{testOrigin}})
+	require.NoError(t, err)
+	return verifier
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	verifier := newTestVerifier(t)
+	for _, tt := range []struct {
+		name      string
+		format    string
+		algorithm int
+	}{
+		{name: "none attestation with ES256", format: "none", algorithm: coseAlgES256},
+		{name: "packed attestation with ES256", format: "packed", algorithm: coseAlgES256},
+		{name: "packed attestation with RS256", format: "packed", algorithm: coseAlgRS256},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAuthenticator(t, tt.algorithm)
+			challenge := randomBytes(t, 32)
+			passkey, err := verifier.VerifyRegistration(challenge, a.register(tt.format, clientDataJSON(t, clientDataCreate, challenge, testOrigin)))
+			require.NoError(t, err)
+			assert.Equal(t, a.credentialID, passkey.CredentialID)
+			assert.Equal(t, a.aaguid, passkey.AAGUID)
+			assert.Equal(t, a.coseKey(), passkey.PublicKey)
+			assert.Zero(t, passkey.SignCount)
+
+			// The stored key verifies later sign-ins
+			signInChallenge := randomBytes(t, 32)
+			signCount, err := verifier.VerifyAssertion(signInChallenge, passkey.PublicKey,
+				a.assert(testRPID, clientDataJSON(t, clientDataGet, signInChallenge, testOrigin)))
+			require.NoError(t, err)
+			assert.Equal(t, uint32(1), signCount)
+		})
+	}
+}
+
+func TestVerifyRegistrationRejects(t *testing.T) {
+	verifier := newTestVerifier(t)
+	a := newAuthenticator(t, coseAlgES256)
+	challenge := randomBytes(t, 32)
+	valid := a.register("none", clientDataJSON(t, clientDataCreate, challenge, testOrigin))
+
+	withAuthData := func(authData []byte) services.PasskeyAttestation {
+		return services.PasskeyAttestation{
+			ClientDataJSON: valid.ClientDataJSON,
+			AttestationObject: encodeCBOR(cborMap{
+				{"fmt", "none"},
+				{"attStmt", cborMap{}},
+				{"authData", authData},
+			}),
+		}
+	}
+	authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified|flagAttestedCredData)
+
+	for _, tt := range []struct {
+		name        string
+		attestation services.PasskeyAttestation
+	}{
+		{
+			name:        "other challenge",
+			attestation: a.register("none", clientDataJSON(t, clientDataCreate, randomBytes(t, 32), testOrigin)),
+		},
+		{
+			name:        "other origin",
+			attestation: a.register("none", clientDataJSON(t, clientDataCreate, challenge, "https://evil.example.net")),
+		},
+		{
+			name:        "sign-in client data",
+			attestation: a.register("none", clientDataJSON(t, clientDataGet, challenge, testOrigin)),
+		},
+		{
+			name:        "rpIdHash mismatch",
+			attestation: withAuthData(a.authenticatorData("evil.example.net", flagUserPresent|flagUserVerified|flagAttestedCredData)),
+		},
+		{
+			name:        "user not verified",
+			attestation: withAuthData(a.authenticatorData(testRPID, flagUserPresent|flagAttestedCredData)),
+		},
+		{
+			name:        "no attested credential",
+			attestation: withAuthData(a.authenticatorData(testRPID, flagUserPresent|flagUserVerified)),
+		},
+		{
+			name:        "truncated public key",
+			attestation: withAuthData(authData[:len(authData)-1]),
+		},
+		{
+			name:        "truncated attestation object",
+			attestation: services.PasskeyAttestation{ClientDataJSON: valid.ClientDataJSON, AttestationObject: valid.AttestationObject[:len(valid.AttestationObject)-1]},
+		},
+		{
+			name:        "attestation object is not a map",
+			attestation: services.PasskeyAttestation{ClientDataJSON: valid.ClientDataJSON, AttestationObject: encodeCBOR([]interface{}{authData})},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.VerifyRegistration(challenge, tt.attestation)
+			assert.ErrorIs(t, err, services.ErrPasskeyInvalid)
+		})
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	verifier := newTestVerifier(t)
+	for _, algorithm := range []int{coseAlgES256, coseAlgRS256} {
+		a := newAuthenticator(t, algorithm)
+		challenge := randomBytes(t, 32)
+		clientData := clientDataJSON(t, clientDataGet, challenge, testOrigin)
+
+		t.Run("valid", func(t *testing.T) {
+			a.counter = 41
+			signCount, err := verifier.VerifyAssertion(challenge, a.coseKey(), a.assert(testRPID, clientData))
+			require.NoError(t, err)
+			assert.Equal(t, uint32(42), signCount)
+		})
+
+		t.Run("bad signature", func(t *testing.T) {
+			assertion := a.assert(testRPID, clientData)
+			assertion.Signature[len(assertion.Signature)-1] ^= 0xff
+			_, err := verifier.VerifyAssertion(challenge, a.coseKey(), assertion)
+			assert.ErrorIs(t, err, services.ErrPasskeyInvalid)
+		})
+
+		t.Run("signed by another key", func(t *testing.T) {
+			other := newAuthenticator(t, algorithm)
+			_, err := verifier.VerifyAssertion(challenge, a.coseKey(), other.assert(testRPID, clientData))
+			assert.ErrorIs(t, err, services.ErrPasskeyInvalid)
+		})
+
+		t.Run("tampered authenticator data", func(t *testing.T) {
+			assertion := a.assert(testRPID, clientData)
+			assertion.AuthenticatorData[36]++
+			_, err := verifier.VerifyAssertion(challenge, a.coseKey(), assertion)
+			assert.ErrorIs(t, err, services.ErrPasskeyInvalid)
+		})
+
+		t.Run("rpIdHash mismatch", func(t *testing.T) {
+			_, err := verifier.VerifyAssertion(challenge, a.coseKey(), a.assert("evil.example.net", clientData))
+			assert.ErrorIs(t, err, services.ErrPasskeyInvalid)
+		})
+
+		t.Run("other challenge", func(t *testing.T) {
+			_, err := verifier.VerifyAssertion(randomBytes(t, 32), a.coseKey(), a.assert(testRPID, clientData))
+			assert.ErrorIs(t, err, services.ErrPasskeyInvalid)
+		})
+
+		t.Run("truncated authenticator data", func(t *testing.T) {
+			assertion := a.assert(testRPID, clientData)
+			assertion.AuthenticatorData = assertion.AuthenticatorData[:authenticatorDataMinLength-1]
+			_, err := verifier.VerifyAssertion(challenge, a.coseKey(), assertion)
+			assert.ErrorIs(t, err, services.ErrPasskeyInvalid)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package egress
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Config holds the settings shared by all outbound HTTP integrations
+type Config struct {
+	// HTTPProxy, HTTPSProxy and NoProxy follow the conventions of the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables, which
+	// are used instead when none of them is set
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+	// CAFile is a PEM bundle trusted in addition to the system roots, e.g.
+	// of a TLS-inspecting corporate proxy
+	CAFile              string
+	Timeout             time.Duration // whole request, including the body; 0 disables
+	DialTimeout         time.Duration // 0 uses 30 seconds
+	TLSHandshakeTimeout time.Duration // 0 uses 10 seconds
+}
+
+// NewClient creates an HTTP client for an outbound integration. tlsConfig
+// carries integration-specific TLS settings and may be nil; timeout
+// overrides the shared timeout when positive.
+func NewClient(cfg Config, tlsConfig *tls.Config, timeout time.Duration) (*http.Client, error) {
+	transport, err := NewTransport(cfg, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = cfg.Timeout
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}, nil
+}
+
+// NewTransport creates an HTTP transport routed through the configured
+// proxies and trusting the configured CA bundle
+func NewTransport(cfg Config, tlsConfig *tls.Config) (*http.Transport, error) {
+	dialTimeout := cfg.DialTimeout
+	if dialTimeout <= 0 {
+		dialTimeout = 30 * time.Second
+	}
+	handshakeTimeout := cfg.TLSHandshakeTimeout
+	if handshakeTimeout <= 0 {
+		handshakeTimeout = 10 * time.Second
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc(cfg)
+	transport.DialContext = (&net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = handshakeTimeout
+
+	if tlsConfig != nil {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if cfg.CAFile != "" {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		pool, err := withCABundle(tlsConfig.RootCAs, cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport, nil
+}
+
+// PublicOnly refuses connections whose peer is not a public address. The
+// peer is checked after connecting, so names resolving to private addresses
+// are caught as well.
+func PublicOnly(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+			if ip := tcpAddr.IP; !ip.IsGlobalUnicast() || ip.IsPrivate() {
+				conn.Close()
+				return nil, fmt.Errorf("refusing to connect to non-public address %s", ip)
+			}
+		}
+		return conn, nil
+	}
+}
+
+// proxyFunc selects the proxy of a request from the configuration, falling
+// back to the environment when no proxy is configured
+func proxyFunc(cfg Config) func(*http.Request) (*url.URL, error) {
+	if cfg.HTTPProxy == "" && cfg.HTTPSProxy == "" && cfg.NoProxy == "" {
+		return http.ProxyFromEnvironment
+	}
+
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  cfg.HTTPProxy,
+		HTTPSProxy: cfg.HTTPSProxy,
+		NoProxy:    cfg.NoProxy,
+	}).ProxyFunc()
+	return func(r *http.Request) (*url.URL, error) {
+		return proxy(r.URL)
+	}
+}
+
+// withCABundle returns pool extended by the certificates in caFile. A nil
+// pool starts from the system roots.
+func withCABundle(pool *x509.CertPool, caFile string) (*x509.CertPool, error) {
+	caCert, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read egress CA file: %w", err)
+	}
+
+	if pool == nil {
+		systemPool, err := x509.SystemCertPool()
+		if err != nil {
+			systemPool = x509.NewCertPool()
+		}
+		pool = systemPool
+	} else {
+		pool = pool.Clone()
+	}
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to parse egress CA file: %s", caFile)
+	}
+	return pool, nil
+}
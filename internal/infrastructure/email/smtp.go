@@ -0,0 +1,288 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/tlsutil"
+)
+
+// TLSMode is how connections to the SMTP server are secured
+type TLSMode string
+
+const (
+	// TLSModeStartTLS upgrades a plain connection with STARTTLS, usually on port 587
+	TLSModeStartTLS TLSMode = "starttls"
+	// TLSModeImplicit connects over TLS, usually on port 465
+	TLSModeImplicit TLSMode = "implicit"
+	// TLSModeNone sends in plain text; only for local relays
+	TLSModeNone TLSMode = "none"
+)
+
+const (
+	defaultTimeout        = 10 * time.Second
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = time.Second
+	maxBackoff            = 30 * time.Second
+)
+
+// Config holds the SMTP settings
+type Config struct {
+	Host     string
+	Port     int
+	Username string // empty sends without authentication
+	Password string
+	From     string // sender address
+	FromName string // sender display name
+	TLSMode  TLSMode
+	// TLS holds the CA bundle, client certificate and server name used for
+	// TLS; its Enabled flag is implied by TLSMode
+	TLS            tlsutil.Config
+	Timeout        time.Duration // per attempt; 0 uses 10 seconds
+	MaxAttempts    int           // 0 uses 3
+	InitialBackoff time.Duration // doubled after each failed attempt; 0 uses 1 second
+}
+
+// Sender is a services.EmailSender delivering over SMTP
+type Sender struct {
+	config    Config
+	tlsConfig *tls.Config
+	from      mail.Address
+}
+
+var _ services.EmailSender = (*Sender)(nil)
+
+// NewSender creates a new SMTP sender
+func NewSender(cfg Config) (*Sender, error) {
+	if cfg.TLSMode == "" {
+		cfg.TLSMode = TLSModeStartTLS
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = defaultInitialBackoff
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	sender := &Sender{
+		config: cfg,
+		from:   mail.Address{Name: cfg.FromName, Address: cfg.From},
+	}
+	if cfg.TLSMode != TLSModeNone {
+		tlsSettings := cfg.TLS
+		tlsSettings.Enabled = true
+		if tlsSettings.ServerName == "" {
+			tlsSettings.ServerName = cfg.Host
+		}
+		tlsConfig, err := tlsutil.NewClientConfig(tlsSettings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure SMTP TLS: %w", err)
+		}
+		sender.tlsConfig = tlsConfig
+	}
+	return sender, nil
+}
+
+// Send renders an email and delivers it, retrying transient failures with
+// exponential backoff. Permanent failures wrap services.ErrEmailRejected.
+func (s *Sender) Send(ctx context.Context, message services.EmailMessage) error {
+	if _, err := mail.ParseAddress(message.To); err != nil {
+		return fmt.Errorf("%w: invalid recipient: %v", services.ErrEmailRejected, err)
+	}
+	content, err := render(message)
+	if err != nil {
+		return err
+	}
+	body, err := s.compose(message.To, content)
+	if err != nil {
+		return fmt.Errorf("failed to compose email: %w", err)
+	}
+
+	backoff := s.config.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := s.deliver(ctx, message.To, body)
+		if err == nil {
+			return nil
+		}
+		if !transient(err) {
+			if errors.Is(err, services.ErrEmailRejected) {
+				return err
+			}
+			return fmt.Errorf("%w: %v", services.ErrEmailRejected, err)
+		}
+		if attempt >= s.config.MaxAttempts {
+			return fmt.Errorf("failed to send email after %d attempts: %w", attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// deliver makes a single delivery attempt
+func (s *Sender) deliver(ctx context.Context, to string, body []byte) error {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	var conn net.Conn
+	var err error
+	if s.config.TLSMode == TLSModeImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: s.tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	deadline := time.Now().Add(s.config.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to greet SMTP server: %w", err)
+	}
+	defer client.Close()
+
+	if s.config.TLSMode == TLSModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%w: SMTP server does not support STARTTLS", services.ErrEmailRejected)
+		}
+		if err := client.StartTLS(s.tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(s.config.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// transient reports whether a failed attempt may succeed when retried.
+// Servers reply with 5xx codes to errors that persist and certificates
+// stay invalid; anything else, such as a 4xx reply or a network error, is
+// retried.
+func transient(err error) bool {
+	if errors.Is(err, services.ErrEmailRejected) {
+		return false
+	}
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code < 500
+	}
+	var certErr *tls.CertificateVerificationError
+	return !errors.As(err, &certErr)
+}
+
+// compose builds a multipart/alternative message with the plain text and
+// HTML bodies
+func (s *Sender) compose(to string, content rendered) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	subject := strings.Join(strings.Fields(content.Subject), " ")
+	headers := []string{
+		"From: " + s.from.String(),
+		"To: " + (&mail.Address{Address: to}).String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + messageID(s.config.From),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + writer.Boundary(),
+	}
+	header := strings.Join(headers, "\r\n") + "\r\n\r\n"
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", content.TextBody},
+		{"text/html; charset=utf-8", content.HTMLBody},
+	}
+	for _, part := range parts {
+		if part.body == "" {
+			continue
+		}
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return append([]byte(header), buf.Bytes()...), nil
+}
+
+// messageID generates a unique Message-ID in the sender's domain
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("<%d@%s>", time.Now().UnixNano(), domain)
+	}
+	return "<" + hex.EncodeToString(id) + "@" + domain + ">"
+}
@@ -0,0 +1,215 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"text/template"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// defaultTemplates are used for emails without an organization template
+var defaultTemplates = map[string]models.EmailTemplate{
+	models.EmailTemplateWelcome: {
+		Subject: "Welcome{{if .FirstName}}, {{.FirstName}}{{end}}",
+		HTMLBody: `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; line-height: 1.5; color: #222;">
+  <p>Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},</p>
+  <p>Your account{{if .Username}} <strong>{{.Username}}</strong>{{end}} has been created for {{.Email}}.</p>
+  <p>If you did not create an account, please contact support.</p>
+</body>
+</html>`,
+		TextBody: `Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},
+
+Your account{{if .Username}} {{.Username}}{{end}} has been created for {{.Email}}.
+
+If you did not create an account, please contact support.
+`,
+	},
+	models.EmailTemplateVerification: {
+		Subject: "Verify your email address",
+		HTMLBody: `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; line-height: 1.5; color: #222;">
+  <p>Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},</p>
+  <p>Please confirm that {{.Email}} is your email address.</p>
+  <p><a href="{{.Link}}" style="display: inline-block; padding: 10px 20px; background: #2563eb; color: #fff; text-decoration: none; border-radius: 4px;">Verify email</a></p>
+  {{if not .ExpiresAt.IsZero}}<p>The link expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}.</p>{{end}}
+  <p>If you did not create an account, you can ignore this email.</p>
+</body>
+</html>`,
+		TextBody: `Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},
+
+Please confirm that {{.Email}} is your email address by opening this link:
+
+{{.Link}}
+{{if not .ExpiresAt.IsZero}}
+The link expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}.
+{{end}}
+If you did not create an account, you can ignore this email.
+`,
+	},
+	models.EmailTemplatePasswordReset: {
+		Subject: "Reset your password",
+		HTMLBody: `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; line-height: 1.5; color: #222;">
+  <p>Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},</p>
+  <p>We received a request to reset the password of your account.</p>
+  <p><a href="{{.Link}}" style="display: inline-block; padding: 10px 20px; background: #2563eb; color: #fff; text-decoration: none; border-radius: 4px;">Choose a new password</a></p>
+  {{if not .ExpiresAt.IsZero}}<p>The link expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}.</p>{{end}}
+  <p>If you did not request a password reset, you can ignore this email; your password stays the same.</p>
+</body>
+</html>`,
+		TextBody: `Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},
+
+We received a request to reset the password of your account. Choose a new password by opening this link:
+
+{{.Link}}
+{{if not .ExpiresAt.IsZero}}
+The link expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}.
+{{end}}
+If you did not request a password reset, you can ignore this email; your password stays the same.
+`,
+	},
+	models.EmailTemplateMagicLink: {
+		Subject: "Your sign-in link",
+		HTMLBody: `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; line-height: 1.5; color: #222;">
+  <p>Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},</p>
+  <p>Use the link below to sign in to your account. It works once.</p>
+  <p><a href="{{.Link}}" style="display: inline-block; padding: 10px 20px; background: #2563eb; color: #fff; text-decoration: none; border-radius: 4px;">Sign in</a></p>
+  {{if not .ExpiresAt.IsZero}}<p>The link expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}.</p>{{end}}
+  <p>If you did not request a sign-in link, you can ignore this email.</p>
+</body>
+</html>`,
+		TextBody: `Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},
+
+Sign in to your account by opening this link. It works once.
+
+{{.Link}}
+{{if not .ExpiresAt.IsZero}}
+The link expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}.
+{{end}}
+If you did not request a sign-in link, you can ignore this email.
+`,
+	},
+	models.EmailTemplateRecoveryEmail: {
+		Subject: "Confirm your recovery email address",
+		HTMLBody: `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; line-height: 1.5; color: #222;">
+  <p>Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},</p>
+  <p>Please confirm that {{.Email}} should receive password reset links for your account{{if .Username}} <strong>{{.Username}}</strong>{{end}} when you cannot access your primary email address.</p>
+  <p><a href="{{.Link}}" style="display: inline-block; padding: 10px 20px; background: #2563eb; color: #fff; text-decoration: none; border-radius: 4px;">Confirm recovery email</a></p>
+  {{if not .ExpiresAt.IsZero}}<p>The link expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}.</p>{{end}}
+  <p>If you did not add this address, you can ignore this email.</p>
+</body>
+</html>`,
+		TextBody: `Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},
+
+Please confirm that {{.Email}} should receive password reset links for your account{{if .Username}} {{.Username}}{{end}} when you cannot access your primary email address, by opening this link:
+
+{{.Link}}
+{{if not .ExpiresAt.IsZero}}
+The link expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}.
+{{end}}
+If you did not add this address, you can ignore this email.
+`,
+	},
+	models.EmailTemplateNewDevice: {
+		Subject: "{{if .Link}}Confirm the{{else}}New{{end}} sign-in to your account",
+		HTMLBody: `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; line-height: 1.5; color: #222;">
+  <p>Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},</p>
+  <p>Your account{{if .Username}} <strong>{{.Username}}</strong>{{end}} was signed in to from a device or location you have not used before.</p>
+  <ul>
+    {{if .Device}}<li>Device: {{.Device}}</li>{{end}}
+    {{if .IPAddress}}<li>IP address: {{.IPAddress}}</li>{{end}}
+    {{if .Country}}<li>Country: {{.Country}}</li>{{end}}
+  </ul>
+  {{if .Link}}<p>If this was you, confirm the sign-in and then sign in again:</p>
+  <p><a href="{{.Link}}" style="display: inline-block; padding: 10px 20px; background: #2563eb; color: #fff; text-decoration: none; border-radius: 4px;">Confirm sign-in</a></p>
+  {{if not .ExpiresAt.IsZero}}<p>The link expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}.</p>{{end}}{{end}}
+  <p>If this was not you, change your password right away.</p>
+</body>
+</html>`,
+		TextBody: `Hi {{if .FirstName}}{{.FirstName}}{{else}}there{{end}},
+
+Your account{{if .Username}} {{.Username}}{{end}} was signed in to from a device or location you have not used before.
+{{if .Device}}
+Device: {{.Device}}{{end}}{{if .IPAddress}}
+IP address: {{.IPAddress}}{{end}}{{if .Country}}
+Country: {{.Country}}{{end}}
+{{if .Link}}
+If this was you, confirm the sign-in by opening this link and then sign in again:
+
+{{.Link}}
+{{if not .ExpiresAt.IsZero}}
+The link expires on {{.ExpiresAt.UTC.Format "January 2, 2006 at 15:04 MST"}}.
+{{end}}{{end}}
+If this was not you, change your password right away.
+`,
+	},
+}
+
+// rendered is an email ready to be sent
+type rendered struct {
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// render renders an email from the organization's template, or the default
+// template when there is none. HTML bodies are escaped contextually.
+func render(message services.EmailMessage) (rendered, error) {
+	tmpl, ok := defaultTemplates[message.Template]
+	if message.Override != nil {
+		tmpl, ok = *message.Override, true
+	}
+	if !ok {
+		return rendered{}, fmt.Errorf("%w: no template named %q", services.ErrEmailRejected, message.Template)
+	}
+
+	var result rendered
+	var err error
+	if result.Subject, err = renderText(message.Template, tmpl.Subject, message.Data); err != nil {
+		return rendered{}, err
+	}
+	if result.TextBody, err = renderText(message.Template, tmpl.TextBody, message.Data); err != nil {
+		return rendered{}, err
+	}
+	if tmpl.HTMLBody != "" {
+		t, err := htmltemplate.New(message.Template).Parse(tmpl.HTMLBody)
+		if err != nil {
+			return rendered{}, fmt.Errorf("%w: template %q: %v", services.ErrEmailRejected, message.Template, err)
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, message.Data); err != nil {
+			return rendered{}, fmt.Errorf("%w: template %q: %v", services.ErrEmailRejected, message.Template, err)
+		}
+		result.HTMLBody = buf.String()
+	}
+	return result, nil
+}
+
+// renderText renders a subject or plain text body
+func renderText(name, text string, data services.EmailData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: template %q: %v", services.ErrEmailRejected, name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: template %q: %v", services.ErrEmailRejected, name, err)
+	}
+	return buf.String(), nil
+}
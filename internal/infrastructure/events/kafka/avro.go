@@ -0,0 +1,350 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/linkedin/goavro/v2"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"go.uber.org/zap"
+)
+
+const (
+	// avroNamespace is the namespace of the records of event schemas
+	avroNamespace = "identity.events"
+	// avroMagicByte starts every message of the Confluent wire format
+	avroMagicByte = 0
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+// AvroSerializer encodes events with Avro in the Confluent wire format: a
+// zero byte, the big-endian ID of the schema in the registry and the binary
+// encoding. The schema of each event type is derived from its payload type,
+// following the field names and optional fields of the JSON encoding, and
+// registered under the subject <topic>-value.
+type AvroSerializer struct {
+	registry *SchemaRegistry
+	logger   *zap.Logger
+
+	// mu guards codecs, which holds the registered schema of every topic
+	// and payload type published so far
+	mu     sync.RWMutex
+	codecs map[avroCodecKey]*avroCodec
+}
+
+type avroCodecKey struct {
+	topic       string
+	payloadType reflect.Type
+}
+
+type avroCodec struct {
+	id    int
+	codec *goavro.Codec
+}
+
+// NewAvroSerializer registers the schemas of every registered event type,
+// so that a schema change breaking the compatibility level of its subject
+// fails the startup instead of the first publish
+func NewAvroSerializer(ctx context.Context, registry *SchemaRegistry, logger *zap.Logger) (*AvroSerializer, error) {
+	s := &AvroSerializer{
+		registry: registry,
+		logger:   logger,
+		codecs:   make(map[avroCodecKey]*avroCodec),
+	}
+	for _, topic := range events.RegisteredTopics() {
+		payloadType, ok := events.TypeOfTopic(topic).PayloadType()
+		if !ok {
+			continue
+		}
+		if _, err := s.codec(ctx, topic, payloadType); err != nil {
+			return nil, err
+		}
+	}
+	logger.Info("registered avro event schemas", zap.Int("count", len(s.codecs)))
+	return s, nil
+}
+
+// Serialize encodes the payload of an event published to topic. Payload
+// types without a registered schema are registered on first use.
+func (s *AvroSerializer) Serialize(ctx context.Context, topic string, payload interface{}) ([]byte, error) {
+	value := reflect.ValueOf(payload)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil, fmt.Errorf("cannot encode nil %s payload", topic)
+		}
+		value = value.Elem()
+	}
+
+	codec, err := s.codec(ctx, topic, value.Type())
+	if err != nil {
+		return nil, err
+	}
+	native, err := avroNative(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", topic, err)
+	}
+
+	data := make([]byte, 5, 256)
+	data[0] = avroMagicByte
+	binary.BigEndian.PutUint32(data[1:5], uint32(codec.id))
+	data, err = codec.codec.BinaryFromNative(data, native)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", topic, err)
+	}
+	return data, nil
+}
+
+// codec returns the codec of a topic and payload type, registering its
+// schema when it has none yet
+func (s *AvroSerializer) codec(ctx context.Context, topic string, payloadType reflect.Type) (*avroCodec, error) {
+	key := avroCodecKey{topic: topic, payloadType: payloadType}
+	s.mu.RLock()
+	codec, ok := s.codecs[key]
+	s.mu.RUnlock()
+	if ok {
+		return codec, nil
+	}
+
+	schema, err := avroSchema(payloadType, map[string]bool{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive avro schema of %s: %w", topic, err)
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := goavro.NewCodec(string(schemaJSON))
+	if err != nil {
+		return nil, fmt.Errorf("invalid avro schema of %s: %w", topic, err)
+	}
+	id, err := s.registry.Register(ctx, topic+"-value", string(schemaJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	codec = &avroCodec{id: id, codec: parsed}
+	s.mu.Lock()
+	s.codecs[key] = codec
+	s.mu.Unlock()
+	s.logger.Debug("registered avro event schema", zap.String("topic", topic), zap.Int("schemaId", id))
+	return codec, nil
+}
+
+// avroSchema derives the Avro schema of a Go type. Records are named after
+// their Go types; records already defined are referenced by name.
+func avroSchema(t reflect.Type, defined map[string]bool) (interface{}, error) {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "long", "logicalType": "timestamp-micros"}, nil
+	case t == uuidType:
+		return map[string]interface{}{"type": "string", "logicalType": "uuid"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string", nil
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "long", nil
+	case reflect.Float32, reflect.Float64:
+		return "double", nil
+	case reflect.Pointer:
+		elem, err := avroSchema(t.Elem(), defined)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"null", elem}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes", nil
+		}
+		items, err := avroSchema(t.Elem(), defined)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map keys of %s must be strings", t)
+		}
+		values, err := avroSchema(t.Elem(), defined)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "map", "values": values}, nil
+	case reflect.Struct:
+		name := avroNamespace + "." + t.Name()
+		if defined[name] {
+			return name, nil
+		}
+		defined[name] = true
+
+		fields := []interface{}{}
+		for _, field := range jsonFields(t) {
+			schema, err := avroSchema(field.Type, defined)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.name, err)
+			}
+			avroField := map[string]interface{}{"name": field.name, "type": schema}
+			if field.Type.Kind() == reflect.Pointer {
+				avroField["default"] = nil
+			}
+			fields = append(fields, avroField)
+		}
+		return map[string]interface{}{
+			"type":      "record",
+			"name":      t.Name(),
+			"namespace": avroNamespace,
+			"fields":    fields,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// avroNative converts a value to the native form goavro encodes with the
+// schema avroSchema derived from its type
+func avroNative(v reflect.Value) (interface{}, error) {
+	switch {
+	case v.Type() == timeType:
+		return v.Interface().(time.Time), nil
+	case v.Type() == uuidType:
+		return v.Interface().(uuid.UUID).String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, nil
+		}
+		native, err := avroNative(v.Elem())
+		if err != nil {
+			return nil, err
+		}
+		// goavro selects the branch of a union by its type name
+		return map[string]interface{}{avroTypeName(v.Type().Elem()): native}, nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(data), v)
+			return data, nil
+		}
+		natives := make([]interface{}, v.Len())
+		for i := range natives {
+			native, err := avroNative(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			natives[i] = native
+		}
+		return natives, nil
+	case reflect.Map:
+		natives := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			native, err := avroNative(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			natives[iter.Key().String()] = native
+		}
+		return natives, nil
+	case reflect.Struct:
+		record := make(map[string]interface{})
+		for _, field := range jsonFields(v.Type()) {
+			native, err := avroNative(v.FieldByIndex(field.Index))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.name, err)
+			}
+			record[field.name] = native
+		}
+		return record, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+// avroTypeName returns the name of the Avro type avroSchema derives from a
+// Go type, as goavro names union branches
+func avroTypeName(t reflect.Type) string {
+	switch {
+	case t == timeType:
+		return "long.timestamp-micros"
+	case t == uuidType:
+		return "string.uuid"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Float32, reflect.Float64:
+		return "double"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes"
+		}
+		return "array"
+	case reflect.Map:
+		return "map"
+	case reflect.Struct:
+		return avroNamespace + "." + t.Name()
+	default:
+		return "long"
+	}
+}
+
+// jsonField is a struct field as encoding/json encodes it
+type jsonField struct {
+	reflect.StructField
+	name string
+}
+
+// jsonFields returns the fields of a struct under their JSON names, with the
+// fields of embedded structs promoted
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for _, embedded := range jsonFields(field.Type) {
+				embedded.Index = append([]int{i}, embedded.Index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{StructField: field, name: name})
+	}
+	return fields
+}
@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/tlsutil"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+const (
+	consumerInitialBackoff = time.Second
+	consumerMaxBackoff     = 30 * time.Second
+)
+
+// MessageHandler handles a single consumed message, given the event type
+// published to its topic. Returning an error retries the message; handlers
+// must therefore be idempotent.
+type MessageHandler func(ctx context.Context, eventType string, value []byte) error
+
+// Consumer consumes topics as part of a consumer group with at-least-once
+// semantics: offsets are committed only after the handler succeeded, and a
+// failing message is retried with backoff before any later message of its
+// partition is handled.
+type Consumer struct {
+	reader  *kafka.Reader
+	handler MessageHandler
+	logger  *zap.Logger
+}
+
+// NewConsumer creates a consumer for the given topics in the given consumer
+// group, using the connection settings of cfg
+func NewConsumer(cfg Config, groupID string, topics []string, handler MessageHandler, logger *zap.Logger) (*Consumer, error) {
+	tlsConfig, err := tlsutil.NewClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure kafka TLS: %w", err)
+	}
+	dialer := &kafka.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+		TLS:       tlsConfig,
+	}
+	if cfg.SASLMechanism != "" {
+		mechanism, err := newSASLMechanism(cfg)
+		if err != nil {
+			return nil, err
+		}
+		dialer.SASLMechanism = mechanism
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     cfg.Brokers,
+		GroupID:     groupID,
+		GroupTopics: topics,
+		Dialer:      dialer,
+		StartOffset: kafka.FirstOffset,
+	})
+
+	return &Consumer{
+		reader:  reader,
+		handler: handler,
+		logger:  logger,
+	}, nil
+}
+
+// Reader returns the underlying reader, e.g. for a ConsumerLagCollector
+func (c *Consumer) Reader() *kafka.Reader {
+	return c.reader
+}
+
+// Run consumes messages until ctx is cancelled
+func (c *Consumer) Run(ctx context.Context) error {
+	for {
+		message, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch message: %w", err)
+		}
+
+		if !c.handle(ctx, message) {
+			return nil
+		}
+
+		if err := c.reader.CommitMessages(ctx, message); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// The message is handled again after a rebalance or restart
+			c.logger.Warn("failed to commit message",
+				zap.String("topic", message.Topic),
+				zap.Int64("offset", message.Offset),
+				zap.Error(err))
+		}
+	}
+}
+
+// handle runs the handler until it succeeds. It returns false when ctx was
+// cancelled first.
+func (c *Consumer) handle(ctx context.Context, message kafka.Message) bool {
+	ctx, span := startProcessSpan(ctx, &message)
+	backoff := consumerInitialBackoff
+	for {
+		err := c.handler(ctx, string(events.TypeOfTopic(message.Topic)), message.Value)
+		if err == nil {
+			endSpan(span, nil)
+			return true
+		}
+		span.RecordError(err)
+
+		c.logger.Warn("failed to handle message, retrying",
+			zap.String("topic", message.Topic),
+			zap.Int("partition", message.Partition),
+			zap.Int64("offset", message.Offset),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			endSpan(span, ctx.Err())
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, consumerMaxBackoff)
+	}
+}
+
+// Close closes the underlying reader
+func (c *Consumer) Close() error {
+	return c.reader.Close()
+}
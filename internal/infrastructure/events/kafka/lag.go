@@ -0,0 +1,53 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/segmentio/kafka-go"
+)
+
+// ConsumerLagCollector periodically exports the lag of Kafka readers
+type ConsumerLagCollector struct {
+	readers        []*kafka.Reader
+	metricsService services.MetricsService
+}
+
+// NewConsumerLagCollector creates a new consumer lag collector for the given readers
+func NewConsumerLagCollector(metricsService services.MetricsService, readers ...*kafka.Reader) *ConsumerLagCollector {
+	return &ConsumerLagCollector{
+		readers:        readers,
+		metricsService: metricsService,
+	}
+}
+
+// Start exports the consumer lag every interval until ctx is cancelled
+func (c *ConsumerLagCollector) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.Collect()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect exports the current lag and committed offset of every reader
+func (c *ConsumerLagCollector) Collect() {
+	for _, reader := range c.readers {
+		stats := reader.Stats()
+		labels := map[string]string{
+			"topic":     stats.Topic,
+			"partition": stats.Partition,
+			"group":     reader.Config().GroupID,
+		}
+		c.metricsService.ObserveValue("kafka_consumer_lag", float64(stats.Lag), labels)
+		c.metricsService.ObserveValue("kafka_consumer_offset", float64(stats.Offset), labels)
+	}
+}
@@ -3,33 +3,188 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
 
-	"github.com/segmentio/kafka-go"
 	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/tlsutil"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"go.uber.org/zap"
 )
 
-const (
-	topicUserRegistered          = "user.registered"
-	topicUserEmailVerified      = "user.email.verified"
-	topicPasswordResetRequested = "user.password.reset.requested"
-	topicPasswordChanged        = "user.password.changed"
-)
+// Config holds the Kafka producer configuration
+type Config struct {
+	Brokers       []string
+	RequiredAcks  string
+	BatchSize     int
+	BatchBytes    int64
+	BatchTimeout  time.Duration
+	Compression   string
+	WriteTimeout  time.Duration
+	MaxAttempts   int
+	TLS           tlsutil.Config
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+}
 
 // Publisher implements the domain.EventPublisher interface using Kafka
 type Publisher struct {
-	writer *kafka.Writer
+	writer         *kafka.Writer
+	metricsService services.MetricsService
+	spool          *Spool
+	// spooling is set while the spool holds events; new events are spooled
+	// behind them so that they are published in order
+	spooling atomic.Bool
+	// tenantRouting copies events to the topics of organizations with
+	// routes; nil publishes to the shared topics only
+	tenantRouting *tenantRouting
+	// avro encodes events with the schemas of the schema registry; nil
+	// publishes JSON
+	avro *AvroSerializer
+}
+
+// PublisherOption configures optional Publisher behavior
+type PublisherOption func(*Publisher)
+
+// WithMetrics records publish outcomes, latency and batch sizes
+func WithMetrics(metricsService services.MetricsService) PublisherOption {
+	return func(p *Publisher) {
+		p.metricsService = metricsService
+	}
+}
+
+// WithSpool persists events that cannot be published to the spool instead
+// of failing the publish; StartSpoolReplay publishes them later
+func WithSpool(spool *Spool) PublisherOption {
+	return func(p *Publisher) {
+		p.spool = spool
+	}
+}
+
+// WithAvro publishes events encoded with Avro instead of JSON
+func WithAvro(serializer *AvroSerializer) PublisherOption {
+	return func(p *Publisher) {
+		p.avro = serializer
+	}
 }
 
 // NewPublisher creates a new Kafka event publisher
-func NewPublisher(brokers []string) *Publisher {
+func NewPublisher(cfg Config, opts ...PublisherOption) (*Publisher, error) {
+	requiredAcks, err := parseRequiredAcks(cfg.RequiredAcks)
+	if err != nil {
+		return nil, err
+	}
+
+	compression, err := parseCompression(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := tlsutil.NewClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure kafka TLS: %w", err)
+	}
+	transport := &kafka.Transport{TLS: tlsConfig}
+
+	if cfg.SASLMechanism != "" {
+		mechanism, err := newSASLMechanism(cfg)
+		if err != nil {
+			return nil, err
+		}
+		transport.SASL = mechanism
+	}
+
+	batchTimeout := cfg.BatchTimeout
+	if batchTimeout == 0 {
+		// The library default of one second adds that much latency to
+		// every synchronous publish on the request path
+		batchTimeout = 10 * time.Millisecond
+	}
+
 	writer := &kafka.Writer{
-		Addr:     kafka.TCP(brokers...),
-		Balancer: &kafka.LeastBytes{},
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Balancer:     &kafka.LeastBytes{},
+		RequiredAcks: requiredAcks,
+		BatchSize:    cfg.BatchSize,
+		BatchBytes:   cfg.BatchBytes,
+		BatchTimeout: batchTimeout,
+		Compression:  compression,
+		WriteTimeout: cfg.WriteTimeout,
+		MaxAttempts:  cfg.MaxAttempts,
+		Transport:    transport,
 	}
 
-	return &Publisher{
+	publisher := &Publisher{
 		writer: writer,
 	}
+	for _, opt := range opts {
+		opt(publisher)
+	}
+	if publisher.metricsService != nil {
+		writer.Completion = publisher.recordBatch
+	}
+	if publisher.spool != nil && publisher.spool.Pending() > 0 {
+		publisher.spooling.Store(true)
+	}
+
+	return publisher, nil
+}
+
+// parseRequiredAcks maps the configured acknowledgement level to kafka.RequiredAcks,
+// defaulting to all in-sync replicas
+func parseRequiredAcks(acks string) (kafka.RequiredAcks, error) {
+	switch strings.ToLower(acks) {
+	case "", "all":
+		return kafka.RequireAll, nil
+	case "leader", "one":
+		return kafka.RequireOne, nil
+	case "none":
+		return kafka.RequireNone, nil
+	default:
+		return 0, fmt.Errorf("unsupported kafka required acks: %s", acks)
+	}
+}
+
+// parseCompression maps the configured codec name to kafka.Compression
+func parseCompression(codec string) (kafka.Compression, error) {
+	switch strings.ToLower(codec) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unsupported kafka compression codec: %s", codec)
+	}
+}
+
+// newSASLMechanism builds the SASL mechanism used to authenticate with the brokers
+func newSASLMechanism(cfg Config) (sasl.Mechanism, error) {
+	switch strings.ToLower(cfg.SASLMechanism) {
+	case "plain":
+		return plain.Mechanism{
+			Username: cfg.SASLUsername,
+			Password: cfg.SASLPassword,
+		}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, cfg.SASLUsername, cfg.SASLPassword)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, cfg.SASLUsername, cfg.SASLPassword)
+	default:
+		return nil, fmt.Errorf("unsupported kafka SASL mechanism: %s", cfg.SASLMechanism)
+	}
 }
 
 // Close closes the Kafka writer
@@ -37,42 +192,190 @@ func (p *Publisher) Close() error {
 	return p.writer.Close()
 }
 
+// Ping checks that the brokers are reachable by requesting cluster metadata
+// with the writer's connection settings
+func (p *Publisher) Ping(ctx context.Context) error {
+	client := &kafka.Client{Addr: p.writer.Addr, Transport: p.writer.Transport}
+	if _, err := client.Metadata(ctx, &kafka.MetadataRequest{}); err != nil {
+		return fmt.Errorf("failed to reach kafka: %w", err)
+	}
+	return nil
+}
+
 // PublishUserRegistered publishes a UserRegisteredEvent
 func (p *Publisher) PublishUserRegistered(ctx context.Context, event events.UserRegisteredEvent) error {
-	return p.publishEvent(ctx, topicUserRegistered, event)
+	return p.publishEvent(ctx, events.UserRegistered, event)
 }
 
 // PublishUserEmailVerified publishes a UserEmailVerifiedEvent
 func (p *Publisher) PublishUserEmailVerified(ctx context.Context, event events.UserEmailVerifiedEvent) error {
-	return p.publishEvent(ctx, topicUserEmailVerified, event)
+	return p.publishEvent(ctx, events.UserVerified, event)
 }
 
 // PublishPasswordResetRequested publishes a UserPasswordResetRequestedEvent
 func (p *Publisher) PublishPasswordResetRequested(ctx context.Context, event events.UserPasswordResetRequestedEvent) error {
-	return p.publishEvent(ctx, topicPasswordResetRequested, event)
+	return p.publishEvent(ctx, events.UserPasswordReset, event)
 }
 
 // PublishPasswordChanged publishes a UserPasswordChangedEvent
 func (p *Publisher) PublishPasswordChanged(ctx context.Context, event events.UserPasswordChangedEvent) error {
-	return p.publishEvent(ctx, topicPasswordChanged, event)
+	return p.publishEvent(ctx, events.UserPasswordChange, event)
 }
 
 // PublishUserEvent implements the services.EventPublisher interface
 func (p *Publisher) PublishUserEvent(ctx context.Context, eventType string, payload interface{}) error {
-	return p.publishEvent(ctx, eventType, payload)
+	return p.publishEvent(ctx, events.EventType(eventType), payload)
 }
 
-// publishEvent is a helper function to publish events to Kafka
-func (p *Publisher) publishEvent(ctx context.Context, topic string, event interface{}) error {
-	data, err := json.Marshal(event)
+// publishEvent is a helper function to publish events to Kafka, on the
+// topic the registry holds for the event type
+func (p *Publisher) publishEvent(ctx context.Context, eventType events.EventType, event interface{}) error {
+	topic := eventType.Topic()
+	data, err := p.encode(ctx, topic, event)
 	if err != nil {
 		return err
 	}
 
-	message := kafka.Message{
-		Topic: topic,
-		Value: data,
+	if !eventType.Registered() {
+		p.incrementCounter("kafka_unregistered_events_total", topic)
+	}
+
+	// Spooled events are routed to tenant topics when they are replayed
+	if p.spooling.Load() {
+		return p.spoolEvent(topic, data, nil)
+	}
+
+	messages := p.messages(ctx, topic, data)
+	ctx, span := startPublishSpan(ctx, &messages[0])
+	for i := range messages[1:] {
+		injectTraceContext(ctx, &messages[i+1])
+	}
+	// The writer has already retried up to its configured attempts when
+	// WriteMessages fails
+	start := time.Now()
+	err = p.writer.WriteMessages(ctx, messages...)
+	p.recordPublish(topic, time.Since(start), err)
+	endSpan(span, err)
+	if err != nil && p.spool != nil {
+		p.spooling.Store(true)
+		return p.spoolEvent(topic, data, err)
+	}
+	return err
+}
+
+// encode serializes an event with Avro when configured, and JSON otherwise
+func (p *Publisher) encode(ctx context.Context, topic string, event interface{}) ([]byte, error) {
+	if p.avro != nil {
+		return p.avro.Serialize(ctx, topic, event)
+	}
+	return json.Marshal(event)
+}
+
+// messages returns the messages publishing an event: to the shared topic
+// and, when the event's organization has a route, to its own topic
+func (p *Publisher) messages(ctx context.Context, topic string, data []byte) []kafka.Message {
+	messages := []kafka.Message{{Topic: topic, Value: data}}
+	if p.tenantRouting == nil {
+		return messages
+	}
+	message, ok, err := p.tenantRouting.route(ctx, string(events.TypeOfTopic(topic)), data)
+	if err != nil {
+		// The event still reaches the shared topic
+		p.incrementCounter("kafka_tenant_routing_failures_total", topic)
+	}
+	if ok {
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// spoolEvent appends an event to the spool. publishErr is the error that
+// caused the event to be spooled, if it was published at all.
+func (p *Publisher) spoolEvent(topic string, data []byte, publishErr error) error {
+	if err := p.spool.Append(topic, data); err != nil {
+		if publishErr != nil {
+			return fmt.Errorf("failed to publish event: %w, and failed to spool it: %v", publishErr, err)
+		}
+		return fmt.Errorf("failed to spool event: %w", err)
+	}
+	p.incrementCounter("kafka_events_spooled_total", topic)
+	p.recordSpoolDepth()
+	return nil
+}
+
+func (p *Publisher) recordSpoolDepth() {
+	if p.metricsService != nil {
+		p.metricsService.ObserveValue("kafka_spool_pending_events", float64(p.spool.Pending()), map[string]string{})
+	}
+}
+
+// StartSpoolReplay publishes spooled events every interval until ctx is
+// cancelled. It returns immediately when the publisher has no spool.
+func (p *Publisher) StartSpoolReplay(ctx context.Context, interval time.Duration, logger *zap.Logger) {
+	if p.spool == nil {
+		return
 	}
 
-	return p.writer.WriteMessages(ctx, message)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		replayed, err := p.spool.Replay(func(topic string, value []byte) error {
+			if err := p.writer.WriteMessages(ctx, p.messages(ctx, topic, value)...); err != nil {
+				return err
+			}
+			p.incrementCounter("kafka_events_replayed_total", topic)
+			return nil
+		}, func() {
+			p.spooling.Store(false)
+		})
+		p.recordSpoolDepth()
+		if replayed > 0 {
+			logger.Info("replayed spooled events", zap.Int("count", replayed))
+		}
+		if err != nil {
+			logger.Warn("failed to replay spooled events", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Publisher) incrementCounter(name, topic string) {
+	if p.metricsService != nil {
+		p.metricsService.IncrementCounter(name, map[string]string{"topic": topic})
+	}
+}
+
+// recordPublish records the outcome and latency of a single publish call
+func (p *Publisher) recordPublish(topic string, duration time.Duration, err error) {
+	if p.metricsService == nil {
+		return
+	}
+
+	labels := map[string]string{"topic": topic}
+	p.metricsService.ObserveHistogram("kafka_publish_duration_seconds", duration.Seconds(), labels)
+	if err != nil {
+		p.metricsService.IncrementCounter("kafka_publish_failure_total", labels)
+		return
+	}
+	p.metricsService.IncrementCounter("kafka_publish_success_total", labels)
+}
+
+// recordBatch records the size of every batch the writer delivers to a
+// partition. All messages of a batch share the same topic.
+func (p *Publisher) recordBatch(messages []kafka.Message, err error) {
+	if len(messages) == 0 {
+		return
+	}
+
+	labels := map[string]string{"topic": messages[0].Topic}
+	p.metricsService.ObserveHistogram("kafka_publish_batch_size", float64(len(messages)), labels)
+	if err != nil {
+		p.metricsService.IncrementCounter("kafka_publish_batch_failure_total", labels)
+	}
 }
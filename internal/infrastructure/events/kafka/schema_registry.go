@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/tlsutil"
+)
+
+// defaultSchemaRegistryTimeout bounds each request to the schema registry
+const defaultSchemaRegistryTimeout = 10 * time.Second
+
+// ErrIncompatibleSchema is returned when the schema registry rejects a
+// schema because it breaks the compatibility level of its subject
+var ErrIncompatibleSchema = errors.New("schema is incompatible with the registered versions")
+
+// SchemaRegistryConfig holds the Confluent Schema Registry connection settings
+type SchemaRegistryConfig struct {
+	URL      string
+	Username string // basic auth, e.g. a Confluent Cloud API key
+	Password string
+	TLS      tlsutil.Config
+	Timeout  time.Duration
+}
+
+// SchemaRegistry registers schemas with a Confluent Schema Registry
+type SchemaRegistry struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewSchemaRegistry creates a new schema registry client
+func NewSchemaRegistry(cfg SchemaRegistryConfig) (*SchemaRegistry, error) {
+	tlsConfig, err := tlsutil.NewClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure schema registry TLS: %w", err)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSchemaRegistryTimeout
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &SchemaRegistry{
+		url:      strings.TrimRight(cfg.URL, "/"),
+		username: cfg.Username,
+		password: cfg.Password,
+		client:   &http.Client{Timeout: timeout, Transport: transport},
+	}, nil
+}
+
+// schemaRegistryError is the error body of the schema registry API
+type schemaRegistryError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+// Register registers an Avro schema under a subject and returns its ID.
+// Registering a schema the subject already has returns the existing ID; a
+// schema breaking the compatibility level of the subject is rejected with
+// ErrIncompatibleSchema.
+func (r *SchemaRegistry) Register(ctx context.Context, subject, schema string) (int, error) {
+	body, err := json.Marshal(map[string]string{"schema": schema, "schemaType": "AVRO"})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/subjects/%s/versions", r.url, url.PathEscape(subject)), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach schema registry: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema registry response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr schemaRegistryError
+		_ = json.Unmarshal(data, &apiErr)
+		if resp.StatusCode == http.StatusConflict {
+			return 0, fmt.Errorf("%w: subject %s: %s", ErrIncompatibleSchema, subject, apiErr.Message)
+		}
+		return 0, fmt.Errorf("schema registry rejected subject %s with status %d: %s", subject, resp.StatusCode, apiErr.Message)
+	}
+
+	var registered struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(data, &registered); err != nil {
+		return 0, fmt.Errorf("failed to decode schema registry response: %w", err)
+	}
+	return registered.ID, nil
+}
@@ -0,0 +1,215 @@
+package kafka
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	spoolFileName   = "events.jsonl"
+	replayFileName  = "events.replaying.jsonl"
+	spoolBufferSize = 1024 * 1024
+)
+
+// spooledEvent is a single event persisted while the brokers were unavailable
+type spooledEvent struct {
+	Topic     string          `json:"topic"`
+	Value     json.RawMessage `json:"value"`
+	SpooledAt time.Time       `json:"spooledAt"`
+}
+
+// Spool is a write-ahead log on disk for events that could not be
+// published, so they can be replayed once the brokers are reachable again.
+// Events spooled before a restart are replayed after it.
+type Spool struct {
+	dir         string
+	pending     int
+	mutex       sync.Mutex // guards the spool files and pending
+	replayMutex sync.Mutex // serializes replays
+}
+
+// NewSpool creates a new event spool in the given directory
+func NewSpool(dir string) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create event spool directory: %w", err)
+	}
+
+	spool := &Spool{dir: dir}
+	for _, name := range []string{replayFileName, spoolFileName} {
+		lines, err := readLines(filepath.Join(dir, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		spool.pending += len(lines)
+	}
+	return spool, nil
+}
+
+// Pending returns the number of events waiting to be replayed
+func (s *Spool) Pending() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.pending
+}
+
+// Append persists an event for later replay
+func (s *Spool) Append(topic string, value []byte) error {
+	line, err := json.Marshal(spooledEvent{
+		Topic:     topic,
+		Value:     value,
+		SpooledAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal spooled event: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.append(filepath.Join(s.dir, spoolFileName), [][]byte{line}); err != nil {
+		return err
+	}
+	s.pending++
+	return nil
+}
+
+// Replay hands every spooled event to publish in the order it was spooled
+// and returns how many were published. Replay stops at the first failure;
+// that event and every later one stay spooled. drained is called, before
+// any further event can be spooled, once the spool is empty. Events can be
+// appended while a replay is publishing.
+func (s *Spool) Replay(publish func(topic string, value []byte) error, drained func()) (int, error) {
+	s.replayMutex.Lock()
+	defer s.replayMutex.Unlock()
+
+	lines, err := s.startReplay(drained)
+	if err != nil || len(lines) == 0 {
+		return 0, err
+	}
+
+	published := 0
+	for i, line := range lines {
+		var event spooledEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			// A torn write cannot be replayed; drop it rather than block the spool
+			s.done()
+			continue
+		}
+		if err := publish(event.Topic, event.Value); err != nil {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			if requeueErr := s.requeue(lines[i:]); requeueErr != nil {
+				return published, requeueErr
+			}
+			return published, fmt.Errorf("failed to replay spooled event: %w", err)
+		}
+		published++
+		s.done()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := os.Remove(filepath.Join(s.dir, replayFileName)); err != nil {
+		return published, fmt.Errorf("failed to remove replayed events: %w", err)
+	}
+
+	// Events spooled while replaying are replayed on the next run
+	if _, err := os.Stat(filepath.Join(s.dir, spoolFileName)); errors.Is(err, os.ErrNotExist) {
+		s.pending = 0
+		drained()
+	}
+	return published, nil
+}
+
+// done marks a spooled event as no longer pending
+func (s *Spool) done() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pending--
+}
+
+// startReplay moves the spooled events aside and returns them. Events left
+// over from an interrupted replay are returned first.
+func (s *Spool) startReplay(drained func()) ([][]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	replayPath := filepath.Join(s.dir, replayFileName)
+	if _, err := os.Stat(replayPath); errors.Is(err, os.ErrNotExist) {
+		if err := os.Rename(filepath.Join(s.dir, spoolFileName), replayPath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				s.pending = 0
+				drained()
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to prepare event spool replay: %w", err)
+		}
+	}
+	return readLines(replayPath)
+}
+
+// requeue moves the unpublished events of a replay back in front of the
+// events spooled since the replay started. The caller must hold s.mutex.
+func (s *Spool) requeue(remaining [][]byte) error {
+	replayPath := filepath.Join(s.dir, replayFileName)
+	spoolPath := filepath.Join(s.dir, spoolFileName)
+	newer, err := readLines(spoolPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	tmpPath := spoolPath + ".tmp"
+	if err := os.Remove(tmpPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to requeue spooled events: %w", err)
+	}
+	if err := s.append(tmpPath, append(remaining, newer...)); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, spoolPath); err != nil {
+		return fmt.Errorf("failed to requeue spooled events: %w", err)
+	}
+	return os.Remove(replayPath)
+}
+
+func (s *Spool) append(path string, lines [][]byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open event spool: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, line := range lines {
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write event spool: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write event spool: %w", err)
+	}
+	return file.Sync()
+}
+
+func readLines(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), spoolBufferSize)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event spool: %w", err)
+	}
+	return lines, nil
+}
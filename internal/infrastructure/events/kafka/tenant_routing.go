@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// EventTypeHeader names the header carrying the event type of messages on
+// dedicated tenant topics, which hold events of every type
+const EventTypeHeader = "event-type"
+
+// TenantRoute is where the events of an organization are published in
+// addition to the shared topics. Exactly one of Topic and Prefix is set.
+type TenantRoute struct {
+	Topic  string // dedicated topic receiving every event of the organization
+	Prefix string // prepended to the event type, giving a topic per event type
+}
+
+// OrganizationLookup returns the organization of a user, if the user
+// belongs to one
+type OrganizationLookup func(ctx context.Context, userID uuid.UUID) (uuid.UUID, bool, error)
+
+// tenantRouting copies the events of organizations with routes to their
+// own topics
+type tenantRouting struct {
+	routes map[uuid.UUID]TenantRoute
+	lookup OrganizationLookup
+}
+
+// WithTenantRouting also publishes the events of the given organizations to
+// their own topics, so that they can consume them without filtering the
+// shared topics. Events name their organization or their user, whose
+// organization is looked up; events of users who no longer exist are only
+// published to the shared topics.
+func WithTenantRouting(routes map[uuid.UUID]TenantRoute, lookup OrganizationLookup) PublisherOption {
+	return func(p *Publisher) {
+		if len(routes) > 0 {
+			p.tenantRouting = &tenantRouting{routes: routes, lookup: lookup}
+		}
+	}
+}
+
+// eventSubject holds the fields of an event payload identifying its organization
+type eventSubject struct {
+	OrganizationID uuid.UUID `json:"organizationId"`
+	UserID         uuid.UUID `json:"userId"`
+}
+
+// route returns the copy of an event for its organization's topic, if the
+// organization has a route
+func (t *tenantRouting) route(ctx context.Context, eventType string, data []byte) (kafka.Message, bool, error) {
+	var subject eventSubject
+	if err := json.Unmarshal(data, &subject); err != nil {
+		return kafka.Message{}, false, nil
+	}
+
+	organizationID := subject.OrganizationID
+	if organizationID == uuid.Nil && subject.UserID != uuid.Nil && t.lookup != nil {
+		id, ok, err := t.lookup(ctx, subject.UserID)
+		if err != nil || !ok {
+			return kafka.Message{}, false, err
+		}
+		organizationID = id
+	}
+
+	route, ok := t.routes[organizationID]
+	if !ok {
+		return kafka.Message{}, false, nil
+	}
+	if route.Topic != "" {
+		return kafka.Message{
+			Topic:   route.Topic,
+			Value:   data,
+			Headers: []kafka.Header{{Key: EventTypeHeader, Value: []byte(eventType)}},
+		}, true, nil
+	}
+	return kafka.Message{Topic: route.Prefix + eventType, Value: data}, true, nil
+}
@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/mibrahim2344/identity-service/internal/infrastructure/events/kafka"
+
+// headerCarrier propagates trace context in the headers of a message
+type headerCarrier struct {
+	message *kafka.Message
+}
+
+var _ propagation.TextMapCarrier = headerCarrier{}
+
+// Get returns the value of the first header with the key
+func (c headerCarrier) Get(key string) string {
+	for _, header := range c.message.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Set replaces the headers with the key
+func (c headerCarrier) Set(key, value string) {
+	headers := c.message.Headers[:0]
+	for _, header := range c.message.Headers {
+		if header.Key != key {
+			headers = append(headers, header)
+		}
+	}
+	c.message.Headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Keys lists the header keys
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, len(c.message.Headers))
+	for i, header := range c.message.Headers {
+		keys[i] = header.Key
+	}
+	return keys
+}
+
+// startPublishSpan starts the producer span of a message and injects its
+// context into the message headers, so that consumers continue the trace
+func startPublishSpan(ctx context.Context, message *kafka.Message) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, message.Topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", message.Topic),
+			attribute.String("messaging.operation", "publish"),
+		))
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{message})
+	return ctx, span
+}
+
+// injectTraceContext puts the span context of ctx into the headers of an
+// additional message of a publish
+func injectTraceContext(ctx context.Context, message *kafka.Message) {
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{message})
+}
+
+// startProcessSpan starts the consumer span of a message, continuing the
+// trace whose context the producer put in the message headers
+func startProcessSpan(ctx context.Context, message *kafka.Message) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier{message})
+	return otel.Tracer(tracerName).Start(ctx, message.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", message.Topic),
+			attribute.String("messaging.operation", "process"),
+			attribute.Int("messaging.kafka.destination.partition", message.Partition),
+			attribute.Int64("messaging.kafka.message.offset", message.Offset),
+		))
+}
+
+// endSpan records the outcome of a span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
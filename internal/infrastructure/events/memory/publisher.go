@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// defaultRetainedEvents is how many events a publisher keeps when no limit
+// is given
+const defaultRetainedEvents = 1000
+
+// Event is an event the publisher received
+type Event struct {
+	Type        string
+	Payload     interface{}
+	PublishedAt time.Time
+}
+
+// Publisher implements services.EventPublisher without Kafka for
+// development and demo deployments and tests. Events are logged and the
+// most recent ones kept in memory; nothing consumes them, so features fed
+// by the event stream, such as email delivery, do not run.
+type Publisher struct {
+	mu     sync.Mutex
+	events []Event
+	limit  int
+	logger *zap.Logger
+}
+
+// NewPublisher creates a new in-memory event publisher keeping the last
+// limit events; 0 keeps 1000
+func NewPublisher(limit int, logger *zap.Logger) *Publisher {
+	if limit <= 0 {
+		limit = defaultRetainedEvents
+	}
+	return &Publisher{
+		limit:  limit,
+		logger: logger,
+	}
+}
+
+// Ensure Publisher implements services.EventPublisher
+var _ services.EventPublisher = (*Publisher)(nil)
+
+// PublishUserEvent records and logs an event
+func (p *Publisher) PublishUserEvent(ctx context.Context, eventType string, payload interface{}) error {
+	p.mu.Lock()
+	if len(p.events) == p.limit {
+		p.events = append(p.events[:0], p.events[1:]...)
+	}
+	p.events = append(p.events, Event{Type: eventType, Payload: payload, PublishedAt: time.Now()})
+	p.mu.Unlock()
+
+	p.logger.Debug("event published", zap.String("eventType", eventType))
+	return nil
+}
+
+// Events returns the retained events, oldest first
+func (p *Publisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
@@ -0,0 +1,187 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/tlsutil"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// defaultPublishTimeout bounds waiting for the stream to acknowledge an event
+const defaultPublishTimeout = 5 * time.Second
+
+// Config holds the NATS connection and JetStream settings
+type Config struct {
+	URL             string // comma-separated server URLs
+	Stream          string
+	CredentialsFile string
+	Token           string
+	TLS             tlsutil.Config
+	PublishTimeout  time.Duration
+}
+
+// Publisher implements services.EventPublisher using NATS JetStream. Events
+// are published to subjects named like the Kafka topics, with the same JSON
+// payloads and trace context headers, and stored in one stream.
+type Publisher struct {
+	conn           *nats.Conn
+	js             jetstream.JetStream
+	publishTimeout time.Duration
+	metricsService services.MetricsService
+}
+
+// PublisherOption configures optional Publisher behavior
+type PublisherOption func(*Publisher)
+
+// WithMetrics records publish outcomes and latency
+func WithMetrics(metricsService services.MetricsService) PublisherOption {
+	return func(p *Publisher) {
+		p.metricsService = metricsService
+	}
+}
+
+// Ensure Publisher implements services.EventPublisher
+var _ services.EventPublisher = (*Publisher)(nil)
+
+// NewPublisher connects to NATS and creates the stream capturing the
+// subjects of the registered event types when it does not exist. An
+// existing stream is left as its operators configured it.
+func NewPublisher(ctx context.Context, cfg Config, opts ...PublisherOption) (*Publisher, error) {
+	connOptions := []nats.Option{
+		nats.Name("identity-service"),
+		// Keep reconnecting; publishes fail until the connection is back
+		nats.MaxReconnects(-1),
+	}
+	if cfg.CredentialsFile != "" {
+		connOptions = append(connOptions, nats.UserCredentials(cfg.CredentialsFile))
+	}
+	if cfg.Token != "" {
+		connOptions = append(connOptions, nats.Token(cfg.Token))
+	}
+	tlsConfig, err := tlsutil.NewClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure nats TLS: %w", err)
+	}
+	if tlsConfig != nil {
+		connOptions = append(connOptions, nats.Secure(tlsConfig))
+	}
+
+	conn, err := nats.Connect(cfg.URL, connOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+	if err := ensureStream(ctx, js, cfg.Stream); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	publishTimeout := cfg.PublishTimeout
+	if publishTimeout <= 0 {
+		publishTimeout = defaultPublishTimeout
+	}
+	publisher := &Publisher{
+		conn:           conn,
+		js:             js,
+		publishTimeout: publishTimeout,
+	}
+	for _, opt := range opts {
+		opt(publisher)
+	}
+	return publisher, nil
+}
+
+// ensureStream creates the stream of the events unless it exists
+func ensureStream(ctx context.Context, js jetstream.JetStream, name string) error {
+	_, err := js.Stream(ctx, name)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, jetstream.ErrStreamNotFound) {
+		return fmt.Errorf("failed to look up nats stream %s: %w", name, err)
+	}
+	_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     name,
+		Subjects: events.RegisteredTopics(),
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create nats stream %s: %w", name, err)
+	}
+	return nil
+}
+
+// Close delivers buffered messages and closes the connection
+func (p *Publisher) Close() error {
+	return p.conn.Drain()
+}
+
+// Ping checks that the server is reachable with a round trip
+func (p *Publisher) Ping(ctx context.Context) error {
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to reach nats: %w", err)
+	}
+	return nil
+}
+
+// PublishUserEvent implements the services.EventPublisher interface
+func (p *Publisher) PublishUserEvent(ctx context.Context, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	subject := events.EventType(eventType).Topic()
+	msg := &nats.Msg{Subject: subject, Data: data, Header: nats.Header{}}
+	ctx, span := startPublishSpan(ctx, msg)
+
+	// The stream only captures the subjects of registered event types, so
+	// other events are published without waiting for an acknowledgement
+	start := time.Now()
+	if !events.EventType(eventType).Registered() {
+		p.incrementCounter("nats_unregistered_events_total", subject)
+		err = p.conn.PublishMsg(msg)
+	} else {
+		publishCtx, cancel := context.WithTimeout(ctx, p.publishTimeout)
+		_, err = p.js.PublishMsg(publishCtx, msg)
+		cancel()
+	}
+	p.recordPublish(subject, time.Since(start), err)
+	endSpan(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+func (p *Publisher) incrementCounter(name, subject string) {
+	if p.metricsService != nil {
+		p.metricsService.IncrementCounter(name, map[string]string{"topic": subject})
+	}
+}
+
+// recordPublish records the outcome and latency of a single publish call
+func (p *Publisher) recordPublish(subject string, duration time.Duration, err error) {
+	if p.metricsService == nil {
+		return
+	}
+
+	labels := map[string]string{"topic": subject}
+	p.metricsService.ObserveHistogram("nats_publish_duration_seconds", duration.Seconds(), labels)
+	if err != nil {
+		p.metricsService.IncrementCounter("nats_publish_failure_total", labels)
+		return
+	}
+	p.metricsService.IncrementCounter("nats_publish_success_total", labels)
+}
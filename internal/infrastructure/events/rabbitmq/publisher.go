@@ -0,0 +1,221 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/tlsutil"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// defaultPublishTimeout bounds waiting for the broker to confirm an event
+const defaultPublishTimeout = 5 * time.Second
+
+// Config holds the RabbitMQ connection and exchange settings
+type Config struct {
+	URL            string
+	Exchange       string
+	TLS            tlsutil.Config
+	PublishTimeout time.Duration
+}
+
+// Publisher implements services.EventPublisher using RabbitMQ. Events are
+// published to a durable topic exchange with the Kafka topic of their type
+// as routing key, with the same JSON payloads and trace context headers,
+// and confirmed by the broker before a publish returns.
+type Publisher struct {
+	cfg            Config
+	dial           func() (*amqp.Connection, error)
+	metricsService services.MetricsService
+
+	// mu guards the connection and channel, which are reopened on the next
+	// publish after the broker closed them
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+}
+
+// PublisherOption configures optional Publisher behavior
+type PublisherOption func(*Publisher)
+
+// WithMetrics records publish outcomes and latency
+func WithMetrics(metricsService services.MetricsService) PublisherOption {
+	return func(p *Publisher) {
+		p.metricsService = metricsService
+	}
+}
+
+// Ensure Publisher implements services.EventPublisher
+var _ services.EventPublisher = (*Publisher)(nil)
+
+// NewPublisher connects to RabbitMQ and declares the exchange
+func NewPublisher(cfg Config, opts ...PublisherOption) (*Publisher, error) {
+	tlsConfig, err := tlsutil.NewClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure rabbitmq TLS: %w", err)
+	}
+	if cfg.PublishTimeout <= 0 {
+		cfg.PublishTimeout = defaultPublishTimeout
+	}
+
+	publisher := &Publisher{
+		cfg: cfg,
+		dial: func() (*amqp.Connection, error) {
+			return amqp.DialConfig(cfg.URL, amqp.Config{
+				TLSClientConfig: tlsConfig,
+				Properties:      amqp.Table{"connection_name": "identity-service"},
+			})
+		},
+	}
+	for _, opt := range opts {
+		opt(publisher)
+	}
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	if _, err := publisher.openChannel(); err != nil {
+		return nil, err
+	}
+	return publisher, nil
+}
+
+// openChannel returns the channel, reconnecting and declaring the exchange
+// when the broker closed the connection or the channel. The caller holds mu.
+func (p *Publisher) openChannel() (*amqp.Channel, error) {
+	if p.channel != nil && !p.channel.IsClosed() {
+		return p.channel, nil
+	}
+	if p.conn == nil || p.conn.IsClosed() {
+		conn, err := p.dial()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to rabbitmq: %w", err)
+		}
+		p.conn = conn
+	}
+
+	channel, err := p.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rabbitmq channel: %w", err)
+	}
+	if err := channel.ExchangeDeclare(p.cfg.Exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		channel.Close()
+		return nil, fmt.Errorf("failed to declare rabbitmq exchange %s: %w", p.cfg.Exchange, err)
+	}
+	if err := channel.Confirm(false); err != nil {
+		channel.Close()
+		return nil, fmt.Errorf("failed to enable rabbitmq publisher confirms: %w", err)
+	}
+	p.channel = channel
+	return channel, nil
+}
+
+// Close closes the channel and the connection
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	if p.channel != nil && !p.channel.IsClosed() {
+		errs = append(errs, p.channel.Close())
+	}
+	if p.conn != nil && !p.conn.IsClosed() {
+		errs = append(errs, p.conn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Ping checks that the connection to the broker is open, reconnecting when
+// it was lost
+func (p *Publisher) Ping(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.openChannel(); err != nil {
+		return fmt.Errorf("failed to reach rabbitmq: %w", err)
+	}
+	return nil
+}
+
+// PublishUserEvent implements the services.EventPublisher interface
+func (p *Publisher) PublishUserEvent(ctx context.Context, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	routingKey := events.EventType(eventType).Topic()
+	if !events.EventType(eventType).Registered() {
+		p.incrementCounter("rabbitmq_unregistered_events_total", routingKey)
+	}
+	msg := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Headers:      amqp.Table{},
+		Body:         data,
+	}
+	ctx, span := startPublishSpan(ctx, p.cfg.Exchange, routingKey, &msg)
+
+	start := time.Now()
+	err = p.publish(ctx, routingKey, msg)
+	p.recordPublish(routingKey, time.Since(start), err)
+	endSpan(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+// publish sends a message and waits for the broker to confirm it
+func (p *Publisher) publish(ctx context.Context, routingKey string, msg amqp.Publishing) error {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.PublishTimeout)
+	defer cancel()
+
+	p.mu.Lock()
+	channel, err := p.openChannel()
+	if err != nil {
+		p.mu.Unlock()
+		return err
+	}
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, p.cfg.Exchange, routingKey, false, false, msg)
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errors.New("rabbitmq rejected the event")
+	}
+	return nil
+}
+
+func (p *Publisher) incrementCounter(name, routingKey string) {
+	if p.metricsService != nil {
+		p.metricsService.IncrementCounter(name, map[string]string{"topic": routingKey})
+	}
+}
+
+// recordPublish records the outcome and latency of a single publish call
+func (p *Publisher) recordPublish(routingKey string, duration time.Duration, err error) {
+	if p.metricsService == nil {
+		return
+	}
+
+	labels := map[string]string{"topic": routingKey}
+	p.metricsService.ObserveHistogram("rabbitmq_publish_duration_seconds", duration.Seconds(), labels)
+	if err != nil {
+		p.metricsService.IncrementCounter("rabbitmq_publish_failure_total", labels)
+		return
+	}
+	p.metricsService.IncrementCounter("rabbitmq_publish_success_total", labels)
+}
@@ -0,0 +1,63 @@
+package rabbitmq
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/mibrahim2344/identity-service/internal/infrastructure/events/rabbitmq"
+
+// headerCarrier propagates trace context in the headers of a message
+type headerCarrier amqp.Table
+
+var _ propagation.TextMapCarrier = headerCarrier{}
+
+// Get returns the value of the header with the key
+func (c headerCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+// Set replaces the header with the key
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys lists the header keys
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// startPublishSpan starts the producer span of a message and injects its
+// context into the message headers, so that consumers continue the trace
+func startPublishSpan(ctx context.Context, exchange, routingKey string, msg *amqp.Publishing) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, routingKey+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", exchange),
+			attribute.String("messaging.rabbitmq.destination.routing_key", routingKey),
+			attribute.String("messaging.operation", "publish"),
+		))
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(msg.Headers))
+	return ctx, span
+}
+
+// endSpan records the outcome of a span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
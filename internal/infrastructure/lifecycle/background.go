@@ -0,0 +1,43 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Background tracks the goroutines of background jobs and consumers, so
+// that shutdown can wait for them to return before it closes the stores
+// they use. The zero value is ready to use.
+type Background struct {
+	wg sync.WaitGroup
+}
+
+// Go runs fn in a tracked goroutine
+func (b *Background) Go(fn func()) {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		fn()
+	}()
+}
+
+// Stop returns a StopFunc that cancels the context of the goroutines with
+// cancel and waits for them to return, giving up once its ctx is done
+func (b *Background) Stop(cancel context.CancelFunc) StopFunc {
+	return func(ctx context.Context) error {
+		cancel()
+
+		done := make(chan struct{})
+		go func() {
+			b.wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("background jobs still running: %w", ctx.Err())
+		}
+	}
+}
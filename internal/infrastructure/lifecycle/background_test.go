@@ -0,0 +1,38 @@
+package lifecycle
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackgroundStop(t *testing.T) {
+	t.Run("waits for goroutines to return", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var background Background
+		var stopped atomic.Bool
+		background.Go(func() {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			stopped.Store(true)
+		})
+
+		assert.NoError(t, background.Stop(cancel)(context.Background()))
+		assert.True(t, stopped.Load())
+	})
+
+	t.Run("gives up once the shutdown times out", func(t *testing.T) {
+		_, cancel := context.WithCancel(context.Background())
+		var background Background
+		release := make(chan struct{})
+		defer close(release)
+		background.Go(func() { <-release })
+
+		timeout, cancelTimeout := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancelTimeout()
+		assert.ErrorIs(t, background.Stop(cancel)(timeout), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,169 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultCheckTimeout bounds each dependency check when none is configured
+	DefaultCheckTimeout = 2 * time.Second
+	// DefaultCheckInterval is how long results are reused when none is configured
+	DefaultCheckInterval = 10 * time.Second
+)
+
+// DependencyState is the result of a dependency check
+type DependencyState string
+
+const (
+	DependencyUp   DependencyState = "up"
+	DependencyDown DependencyState = "down"
+)
+
+// CheckFunc checks that a dependency is reachable, giving up once ctx is done
+type CheckFunc func(ctx context.Context) error
+
+// Dependency describes the last check of a dependency. Probes are served
+// to unauthenticated callers, so only the name and status are encoded; the
+// timing and error are for logs.
+type Dependency struct {
+	Name       string          `json:"name"`
+	Status     DependencyState `json:"status"`
+	Optional   bool            `json:"optional,omitempty"` // does not affect readiness
+	DurationMs int64           `json:"-"`
+	CheckedAt  time.Time       `json:"-"`
+	Error      string          `json:"-"`
+}
+
+// HealthConfig holds the settings of dependency checks
+type HealthConfig struct {
+	// Timeout bounds each check; 0 uses DefaultCheckTimeout
+	Timeout time.Duration
+	// Interval is how long results are reused before dependencies are
+	// checked again, so that frequent probes do not load them; 0 uses
+	// DefaultCheckInterval
+	Interval time.Duration
+}
+
+// check is a named dependency check
+type check struct {
+	name     string
+	check    CheckFunc
+	optional bool
+}
+
+// Health checks the dependencies of the service for readiness probes.
+// Checks run concurrently, and their results are cached for the configured
+// interval. Changes of a dependency's status are logged.
+type Health struct {
+	config    HealthConfig
+	mutex     sync.Mutex
+	checks    []check
+	results   []Dependency
+	checkedAt time.Time
+	logger    *zap.Logger
+}
+
+// NewHealth creates a health checker with no dependencies
+func NewHealth(config HealthConfig, logger *zap.Logger) *Health {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultCheckTimeout
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultCheckInterval
+	}
+	return &Health{config: config, logger: logger}
+}
+
+// Register adds a dependency to check. It is checked from the next probe on.
+func (h *Health) Register(name string, checkFunc CheckFunc) {
+	h.register(check{name: name, check: checkFunc})
+}
+
+// RegisterOptional adds a dependency whose outages the service tolerates.
+// Its status is reported but does not make the service unready.
+func (h *Health) RegisterOptional(name string, checkFunc CheckFunc) {
+	h.register(check{name: name, check: checkFunc, optional: true})
+}
+
+func (h *Health) register(c check) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.checks = append(h.checks, c)
+	h.checkedAt = time.Time{}
+}
+
+// Check returns the status of every registered dependency in registration
+// order, checking them again when the cached results are older than the
+// interval. Concurrent callers wait for a single round of checks.
+func (h *Health) Check(ctx context.Context) []Dependency {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if !h.checkedAt.IsZero() && time.Since(h.checkedAt) < h.config.Interval {
+		return append([]Dependency(nil), h.results...)
+	}
+
+	// The results are shared, so a caller going away must not fail them
+	ctx = context.WithoutCancel(ctx)
+	results := make([]Dependency, len(h.checks))
+	var wg sync.WaitGroup
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = h.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if i < len(h.results) && h.results[i].Name == result.Name && h.results[i].Status == result.Status {
+			continue
+		}
+		if result.Status == DependencyUp {
+			h.logger.Info("dependency is up", zap.String("dependency", result.Name))
+		} else {
+			h.logger.Warn("dependency is down",
+				zap.String("dependency", result.Name),
+				zap.String("error", result.Error))
+		}
+	}
+	h.results = results
+	h.checkedAt = time.Now()
+	return append([]Dependency(nil), results...)
+}
+
+// run checks a dependency within the timeout
+func (h *Health) run(ctx context.Context, c check) Dependency {
+	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.check(ctx)
+	result := Dependency{
+		Name:       c.name,
+		Status:     DependencyUp,
+		Optional:   c.optional,
+		DurationMs: time.Since(start).Milliseconds(),
+		CheckedAt:  start.UTC(),
+	}
+	if err != nil {
+		result.Status = DependencyDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Healthy reports whether every required dependency is up
+func Healthy(dependencies []Dependency) bool {
+	for _, d := range dependencies {
+		if d.Status != DependencyUp && !d.Optional {
+			return false
+		}
+	}
+	return true
+}
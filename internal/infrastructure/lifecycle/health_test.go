@@ -0,0 +1,31 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHealthCheck(t *testing.T) {
+	health := NewHealth(HealthConfig{}, zap.NewNop())
+	health.Register("postgres", func(ctx context.Context) error {
+		return errors.New("dial tcp 10.0.0.5:5432: connect: connection refused")
+	})
+	health.RegisterOptional("kafka", func(ctx context.Context) error { return nil })
+
+	dependencies := health.Check(context.Background())
+	require.Len(t, dependencies, 2)
+	assert.Equal(t, DependencyDown, dependencies[0].Status)
+	assert.NotEmpty(t, dependencies[0].Error)
+	assert.False(t, Healthy(dependencies))
+
+	// Error details stay out of probe responses
+	data, err := json.Marshal(dependencies)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"name":"postgres","status":"down"},{"name":"kafka","status":"up","optional":true}]`, string(data))
+}
@@ -0,0 +1,81 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// StopFunc stops a component, giving up once ctx is done
+type StopFunc func(ctx context.Context) error
+
+// component is a named component registered for shutdown
+type component struct {
+	name string
+	stop StopFunc
+}
+
+// Shutdown stops the components of the service in the reverse order of
+// their registration, like deferred calls: components registered after
+// their dependencies are stopped before them, so servers stop taking
+// requests before the stores and publishers they use are closed.
+type Shutdown struct {
+	mutex      sync.Mutex
+	components []component
+	logger     *zap.Logger
+}
+
+// NewShutdown creates a shutdown sequence with no components
+func NewShutdown(logger *zap.Logger) *Shutdown {
+	return &Shutdown{logger: logger}
+}
+
+// Register adds a component to stop
+func (s *Shutdown) Register(name string, stop StopFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.components = append(s.components, component{name: name, stop: stop})
+}
+
+// RegisterCloser adds a component stopped by a close function that takes no
+// context, such as database and client connections
+func (s *Shutdown) RegisterCloser(name string, close func() error) {
+	s.Register(name, func(context.Context) error {
+		return close()
+	})
+}
+
+// Run stops every registered component within the timeout, logging each
+// as a structured lifecycle event. Components are stopped even when
+// earlier ones fail or the timeout passes, so that connections are still
+// closed; the errors are joined.
+func (s *Shutdown) Run(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	s.mutex.Lock()
+	components := s.components
+	s.components = nil
+	s.mutex.Unlock()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		start := time.Now()
+		if err := c.stop(ctx); err != nil {
+			s.logger.Error("lifecycle component failed to stop",
+				zap.String("component", c.name),
+				zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			continue
+		}
+		s.logger.Info("lifecycle component stopped",
+			zap.String("component", c.name),
+			zap.Duration("duration", time.Since(start)))
+	}
+	return errors.Join(errs...)
+}
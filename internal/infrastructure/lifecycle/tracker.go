@@ -0,0 +1,140 @@
+package lifecycle
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PhaseStatus is the state of a startup phase
+type PhaseStatus string
+
+const (
+	PhasePending PhaseStatus = "pending"
+	PhaseRunning PhaseStatus = "running"
+	PhaseDone    PhaseStatus = "done"
+	PhaseFailed  PhaseStatus = "failed"
+)
+
+// Phase describes a startup phase, typically the initialization of one
+// dependency. The error of a failed phase is logged rather than encoded,
+// since it can name internal hosts.
+type Phase struct {
+	Name        string      `json:"name"`
+	Status      PhaseStatus `json:"status"`
+	StartedAt   *time.Time  `json:"startedAt,omitempty"`
+	CompletedAt *time.Time  `json:"completedAt,omitempty"`
+	Error       string      `json:"-"`
+}
+
+// Tracker records the progress of startup phases and logs each transition as
+// a structured lifecycle event. The service is ready once every phase is done.
+type Tracker struct {
+	mutex  sync.RWMutex
+	phases []*Phase
+	index  map[string]*Phase
+	logger *zap.Logger
+}
+
+// NewTracker creates a tracker with the given phases registered as pending,
+// so that readiness reports list dependencies that have not started yet
+func NewTracker(logger *zap.Logger, phases ...string) *Tracker {
+	t := &Tracker{
+		index:  make(map[string]*Phase),
+		logger: logger,
+	}
+	for _, name := range phases {
+		t.phase(name)
+	}
+	return t
+}
+
+// phase returns the named phase, registering it if needed. Callers must hold the lock
+// or be the constructor.
+func (t *Tracker) phase(name string) *Phase {
+	p, ok := t.index[name]
+	if !ok {
+		p = &Phase{Name: name, Status: PhasePending}
+		t.phases = append(t.phases, p)
+		t.index[name] = p
+	}
+	return p
+}
+
+// Begin marks a phase as running
+func (t *Tracker) Begin(name string) {
+	now := time.Now().UTC()
+
+	t.mutex.Lock()
+	p := t.phase(name)
+	p.Status = PhaseRunning
+	p.StartedAt = &now
+	p.CompletedAt = nil
+	p.Error = ""
+	t.mutex.Unlock()
+
+	t.logger.Info("lifecycle phase started", zap.String("phase", name))
+}
+
+// Complete marks a phase as done
+func (t *Tracker) Complete(name string) {
+	now := time.Now().UTC()
+
+	t.mutex.Lock()
+	p := t.phase(name)
+	p.Status = PhaseDone
+	p.CompletedAt = &now
+	var duration time.Duration
+	if p.StartedAt != nil {
+		duration = now.Sub(*p.StartedAt)
+	}
+	t.mutex.Unlock()
+
+	t.logger.Info("lifecycle phase completed",
+		zap.String("phase", name),
+		zap.Duration("duration", duration))
+}
+
+// Fail marks a phase as failed
+func (t *Tracker) Fail(name string, err error) {
+	now := time.Now().UTC()
+
+	t.mutex.Lock()
+	p := t.phase(name)
+	p.Status = PhaseFailed
+	p.CompletedAt = &now
+	if err != nil {
+		p.Error = err.Error()
+	}
+	t.mutex.Unlock()
+
+	t.logger.Error("lifecycle phase failed",
+		zap.String("phase", name),
+		zap.Error(err))
+}
+
+// Ready reports whether every phase has completed
+func (t *Tracker) Ready() bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	for _, p := range t.phases {
+		if p.Status != PhaseDone {
+			return false
+		}
+	}
+	return true
+}
+
+// Phases returns a snapshot of all phases in registration order
+func (t *Tracker) Phases() []Phase {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	phases := make([]Phase, 0, len(t.phases))
+	for _, p := range t.phases {
+		phases = append(phases, *p)
+	}
+	return phases
+}
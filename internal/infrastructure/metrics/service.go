@@ -1,9 +1,13 @@
 package metrics
 
 import (
+	"context"
+	"sync"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
-	"github.com/mibrahim2344/identity-service/internal/domain/services"
 )
 
 // Ensure metricsService implements services.MetricsService
@@ -11,12 +15,27 @@ var _ services.MetricsService = (*metricsService)(nil)
 
 type metricsService struct {
 	requestDuration *prometheus.HistogramVec
-	counters       map[string]*prometheus.CounterVec
-	observations   map[string]*prometheus.GaugeVec
+	counters        map[string]*prometheus.CounterVec
+	observations    map[string]*prometheus.GaugeVec
+	histograms      map[string]*prometheus.HistogramVec
+	mutex           sync.Mutex
+	traceExemplars  bool
+}
+
+// Option configures optional metrics behaviour
+type Option func(*metricsService)
+
+// WithTraceExemplars attaches the trace IDs of sampled requests to request
+// latency observations as exemplars. They are only exposed in the
+// OpenMetrics format.
+func WithTraceExemplars() Option {
+	return func(m *metricsService) {
+		m.traceExemplars = true
+	}
 }
 
 // NewMetricsService creates a new metrics service using Prometheus
-func NewMetricsService() *metricsService {
+func NewMetricsService(opts ...Option) *metricsService {
 	requestDuration := promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name: "http_request_duration_seconds",
@@ -25,24 +44,41 @@ func NewMetricsService() *metricsService {
 		[]string{"path", "method", "status"},
 	)
 
-	return &metricsService{
+	m := &metricsService{
 		requestDuration: requestDuration,
-		counters:       make(map[string]*prometheus.CounterVec),
-		observations:   make(map[string]*prometheus.GaugeVec),
+		counters:        make(map[string]*prometheus.CounterVec),
+		observations:    make(map[string]*prometheus.GaugeVec),
+		histograms:      make(map[string]*prometheus.HistogramVec),
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
+	return m
 }
 
 // RecordRequest records an incoming request with its duration and status
-func (m *metricsService) RecordRequest(path string, method string, statusCode int, duration float64) {
-	m.requestDuration.WithLabelValues(
+func (m *metricsService) RecordRequest(ctx context.Context, path string, method string, statusCode int, duration float64) {
+	observer := m.requestDuration.WithLabelValues(
 		path,
 		method,
 		string(rune(statusCode)),
-	).Observe(duration)
+	)
+
+	// Link latency spikes to example traces of the requests behind them
+	if metadata := events.MetadataFromContext(ctx); m.traceExemplars && metadata.TraceSampled {
+		if exemplars, ok := observer.(prometheus.ExemplarObserver); ok {
+			exemplars.ObserveWithExemplar(duration, prometheus.Labels{"trace_id": metadata.TraceID})
+			return
+		}
+	}
+	observer.Observe(duration)
 }
 
 // IncrementCounter increments a named counter
 func (m *metricsService) IncrementCounter(name string, labels map[string]string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	counter, exists := m.counters[name]
 	if !exists {
 		counter = promauto.NewCounterVec(
@@ -59,6 +95,9 @@ func (m *metricsService) IncrementCounter(name string, labels map[string]string)
 
 // ObserveValue records a value observation for a metric
 func (m *metricsService) ObserveValue(name string, value float64, labels map[string]string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	gauge, exists := m.observations[name]
 	if !exists {
 		gauge = promauto.NewGaugeVec(
@@ -73,6 +112,25 @@ func (m *metricsService) ObserveValue(name string, value float64, labels map[str
 	gauge.With(labels).Set(value)
 }
 
+// ObserveHistogram records a sample in a named histogram using the default buckets
+func (m *metricsService) ObserveHistogram(name string, value float64, labels map[string]string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	histogram, exists := m.histograms[name]
+	if !exists {
+		histogram = promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: name,
+				Help: "Custom histogram " + name,
+			},
+			getLabelsKeys(labels),
+		)
+		m.histograms[name] = histogram
+	}
+	histogram.With(labels).Observe(value)
+}
+
 func getLabelsKeys(labels map[string]string) []string {
 	keys := make([]string, 0, len(labels))
 	for k := range labels {
@@ -0,0 +1,94 @@
+package notary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/egress"
+)
+
+// maxErrorBodyBytes bounds how much of an error response is kept for the error message
+const maxErrorBodyBytes = 1024
+
+// Config holds the settings of an object storage notary
+type Config struct {
+	// URL is the prefix of the checkpoint objects, e.g. a bucket URL with a
+	// key prefix. Its query, such as a pre-signed or SAS token, is kept.
+	URL         string
+	BearerToken string // empty to disable
+}
+
+// HTTPNotary is a services.AuditNotary storing each checkpoint as a JSON
+// object with a plain HTTP PUT, as supported by S3-compatible, GCS and Azure
+// Blob storage. The bucket should enforce object lock or retention so that
+// stored checkpoints cannot be overwritten.
+type HTTPNotary struct {
+	baseURL     *url.URL
+	bearerToken string
+	httpClient  *http.Client
+}
+
+var _ services.AuditNotary = (*HTTPNotary)(nil)
+
+// NewHTTPNotary creates a new object storage notary, connecting through the
+// shared egress settings
+func NewHTTPNotary(cfg Config, egressConfig egress.Config) (*HTTPNotary, error) {
+	baseURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notary URL: %w", err)
+	}
+
+	httpClient, err := egress.NewClient(egressConfig, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure notary client: %w", err)
+	}
+
+	return &HTTPNotary{
+		baseURL:     baseURL,
+		bearerToken: cfg.BearerToken,
+		httpClient:  httpClient,
+	}, nil
+}
+
+// Notarize stores the checkpoint as audit-checkpoint-<sequence>.json below
+// the base URL. The sequence is zero-padded so that objects sort in order.
+func (n *HTTPNotary) Notarize(ctx context.Context, checkpoint *models.AuditLogCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	target := *n.baseURL
+	target.Path = strings.TrimRight(target.Path, "/") + fmt.Sprintf("/audit-checkpoint-%020d.json", checkpoint.Sequence)
+	target.RawPath = ""
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create notary request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Required by Azure Blob storage, ignored elsewhere
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	if n.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+n.bearerToken)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("notary request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	return fmt.Errorf("failed to store checkpoint: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// cacheEntry is a cached value, encoded like in Redis, and when it expires
+type cacheEntry struct {
+	data      []byte
+	expiresAt time.Time // zero for entries that do not expire
+}
+
+func (e cacheEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// cacheSweepInterval is how often expired entries that are never read
+// again are dropped
+const cacheSweepInterval = time.Minute
+
+// CacheService implements services.CacheService in process memory. Values
+// are stored JSON encoded like the Redis cache service stores them, so that
+// callers see the same round trip. Expired entries are dropped when read and
+// swept periodically when the cache is written to.
+type CacheService struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	swept   time.Time
+}
+
+// NewCacheService creates a new in-memory cache service
+func NewCacheService() services.CacheService {
+	return &CacheService{
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Set stores a value in the cache with the given key and expiration
+func (s *CacheService) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache value: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(key, data, expiration)
+	return nil
+}
+
+// Get retrieves a value from the cache by key
+func (s *CacheService) Get(ctx context.Context, key string, dest interface{}) error {
+	s.mu.Lock()
+	entry, ok := s.entries[key]
+	if ok && entry.expired(time.Now()) {
+		delete(s.entries, key)
+		ok = false
+	}
+	s.mu.Unlock()
+	if !ok {
+		return services.ErrCacheKeyNotFound
+	}
+
+	if err := json.Unmarshal(entry.data, dest); err != nil {
+		return fmt.Errorf("failed to unmarshal cache value: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a value from the cache by key
+func (s *CacheService) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// Clear removes all values from the cache
+func (s *CacheService) Clear(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = make(map[string]cacheEntry)
+	return nil
+}
+
+// SetNX sets a value in the cache only if the key doesn't exist
+func (s *CacheService) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal cache value: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[key]; ok && !entry.expired(time.Now()) {
+		return false, nil
+	}
+	s.put(key, data, expiration)
+	return true, nil
+}
+
+// Incr atomically increments a counter and returns its new value
+func (s *CacheService) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || entry.expired(time.Now()) {
+		s.put(key, []byte("1"), expiration)
+		return 1, nil
+	}
+
+	var count int64
+	if err := json.Unmarshal(entry.data, &count); err != nil {
+		return 0, fmt.Errorf("failed to increment cache value: %w", err)
+	}
+	count++
+	// Keep the expiration of the existing counter
+	entry.data = []byte(strconv.FormatInt(count, 10))
+	s.entries[key] = entry
+	return count, nil
+}
+
+// put stores an encoded value, first sweeping expired entries when the
+// last sweep is long enough ago
+func (s *CacheService) put(key string, data []byte, expiration time.Duration) {
+	now := time.Now()
+	if now.Sub(s.swept) >= cacheSweepInterval {
+		for existing, entry := range s.entries {
+			if entry.expired(now) {
+				delete(s.entries, existing)
+			}
+		}
+		s.swept = now
+	}
+	entry := cacheEntry{data: data}
+	if expiration > 0 {
+		entry.expiresAt = now.Add(expiration)
+	}
+	s.entries[key] = entry
+}
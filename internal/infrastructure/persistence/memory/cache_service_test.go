@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheServiceIncr(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("concurrent increments are all counted", func(t *testing.T) {
+		s := NewCacheService()
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := s.Incr(ctx, "counter", time.Minute)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		count, err := s.Incr(ctx, "counter", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(51), count)
+	})
+
+	t.Run("expired counter starts over", func(t *testing.T) {
+		s := NewCacheService()
+		_, err := s.Incr(ctx, "counter", time.Millisecond)
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+
+		count, err := s.Incr(ctx, "counter", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// TOTPCredentialRepository implements repositories.TOTPCredentialRepository in memory
+type TOTPCredentialRepository struct {
+	store *Store
+}
+
+// NewTOTPCredentialRepository creates a new in-memory TOTP credential repository
+func NewTOTPCredentialRepository(store *Store) repositories.TOTPCredentialRepository {
+	return &TOTPCredentialRepository{
+		store: store,
+	}
+}
+
+// Save creates or replaces the credential of a user
+func (r *TOTPCredentialRepository) Save(ctx context.Context, credential *models.TOTPCredential) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	if credential.CreatedAt.IsZero() {
+		credential.CreatedAt = now
+	}
+	credential.UpdatedAt = now
+	copied := *credential
+	r.store.totpCredentials[credential.UserID] = &copied
+	return nil
+}
+
+// GetByUserID retrieves the credential of a user
+func (r *TOTPCredentialRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.TOTPCredential, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	credential, ok := r.store.totpCredentials[userID]
+	if !ok {
+		return nil, services.ErrNotFound
+	}
+	copied := *credential
+	return &copied, nil
+}
+
+// Delete removes the credential of a user
+func (r *TOTPCredentialRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.totpCredentials[userID]; !ok {
+		return services.ErrNotFound
+	}
+	delete(r.store.totpCredentials, userID)
+	return nil
+}
+
+// UseStep records an accepted time step unless a later or equal one was
+// accepted before
+func (r *TOTPCredentialRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	credential, ok := r.store.totpCredentials[userID]
+	if !ok || credential.LastUsedStep >= step {
+		return false, nil
+	}
+	credential.LastUsedStep = step
+	credential.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// MFAPolicyRepository implements repositories.MFAPolicyRepository in memory
+type MFAPolicyRepository struct {
+	store *Store
+}
+
+// NewMFAPolicyRepository creates a new in-memory MFA policy repository
+func NewMFAPolicyRepository(store *Store) repositories.MFAPolicyRepository {
+	return &MFAPolicyRepository{
+		store: store,
+	}
+}
+
+// Create stores a new policy
+func (r *MFAPolicyRepository) Create(ctx context.Context, policy *models.MFAPolicy) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if policy.ID == uuid.Nil {
+		policy.ID = models.NewID()
+	}
+	now := time.Now()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+	r.store.mfaPolicies[policy.ID] = copyMFAPolicy(policy)
+	return nil
+}
+
+// Update replaces a policy
+func (r *MFAPolicyRepository) Update(ctx context.Context, policy *models.MFAPolicy) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.mfaPolicies[policy.ID]
+	if !ok {
+		return services.ErrNotFound
+	}
+	policy.CreatedAt = existing.CreatedAt
+	policy.UpdatedAt = time.Now()
+	r.store.mfaPolicies[policy.ID] = copyMFAPolicy(policy)
+	return nil
+}
+
+// Delete removes a policy
+func (r *MFAPolicyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.mfaPolicies[id]; !ok {
+		return services.ErrNotFound
+	}
+	delete(r.store.mfaPolicies, id)
+	return nil
+}
+
+// GetByID retrieves a policy by ID
+func (r *MFAPolicyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.MFAPolicy, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	policy, ok := r.store.mfaPolicies[id]
+	if !ok {
+		return nil, services.ErrNotFound
+	}
+	return copyMFAPolicy(policy), nil
+}
+
+// List returns all policies, earliest enforcement first
+func (r *MFAPolicyRepository) List(ctx context.Context) ([]*models.MFAPolicy, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	policies := make([]*models.MFAPolicy, 0, len(r.store.mfaPolicies))
+	for _, policy := range r.store.mfaPolicies {
+		policies = append(policies, copyMFAPolicy(policy))
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if !policies[i].EnforceAfter.Equal(policies[j].EnforceAfter) {
+			return policies[i].EnforceAfter.Before(policies[j].EnforceAfter)
+		}
+		return policies[i].CreatedAt.Before(policies[j].CreatedAt)
+	})
+	return policies, nil
+}
+
+// copyMFAPolicy copies a policy with its roles
+func copyMFAPolicy(policy *models.MFAPolicy) *models.MFAPolicy {
+	copied := *policy
+	copied.Roles = append([]models.Role(nil), policy.Roles...)
+	return &copied
+}
@@ -0,0 +1,342 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// OrganizationRepository implements repositories.OrganizationRepository in memory
+type OrganizationRepository struct {
+	store *Store
+}
+
+// NewOrganizationRepository creates a new in-memory organization repository
+func NewOrganizationRepository(store *Store) repositories.OrganizationRepository {
+	return &OrganizationRepository{
+		store: store,
+	}
+}
+
+// Create stores a new organization with its first member
+func (r *OrganizationRepository) Create(ctx context.Context, organization *models.Organization, owner *models.OrganizationMembership) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if organization.ID == uuid.Nil {
+		organization.ID = models.NewID()
+	}
+	now := time.Now()
+	organization.CreatedAt = now
+	organization.UpdatedAt = now
+	owner.OrganizationID = organization.ID
+
+	copied := *organization
+	r.store.organizations[organization.ID] = &copied
+	return r.store.addMember(owner)
+}
+
+// GetByID retrieves an organization
+func (r *OrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	organization, ok := r.store.organizations[id]
+	if !ok {
+		return nil, services.ErrNotFound
+	}
+	copied := *organization
+	return &copied, nil
+}
+
+// Update saves the settings of an organization
+func (r *OrganizationRepository) Update(ctx context.Context, organization *models.Organization) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	organization.UpdatedAt = time.Now()
+	copied := *organization
+	r.store.organizations[organization.ID] = &copied
+	return nil
+}
+
+// ListByUser returns the organizations a user is a member of, ordered by name
+func (r *OrganizationRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var organizations []*models.Organization
+	for organizationID, members := range r.store.memberships {
+		if _, member := members[userID]; !member {
+			continue
+		}
+		if organization, ok := r.store.organizations[organizationID]; ok {
+			copied := *organization
+			organizations = append(organizations, &copied)
+		}
+	}
+	sort.Slice(organizations, func(i, j int) bool {
+		if organizations[i].Name != organizations[j].Name {
+			return organizations[i].Name < organizations[j].Name
+		}
+		return organizations[i].ID.String() < organizations[j].ID.String()
+	})
+	return organizations, nil
+}
+
+// AddMember adds a user to an organization
+func (r *OrganizationRepository) AddMember(ctx context.Context, membership *models.OrganizationMembership) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.addMember(membership)
+}
+
+// GetMember retrieves the membership of a user in an organization
+func (r *OrganizationRepository) GetMember(ctx context.Context, organizationID, userID uuid.UUID) (*models.OrganizationMembership, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	membership, ok := r.store.memberships[organizationID][userID]
+	if !ok {
+		return nil, services.ErrNotFound
+	}
+	copied := *membership
+	return &copied, nil
+}
+
+// ListMembers returns the memberships of an organization, oldest first
+func (r *OrganizationRepository) ListMembers(ctx context.Context, organizationID uuid.UUID) ([]*models.OrganizationMembership, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	memberships := make([]*models.OrganizationMembership, 0, len(r.store.memberships[organizationID]))
+	for _, membership := range r.store.memberships[organizationID] {
+		copied := *membership
+		memberships = append(memberships, &copied)
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		if !memberships[i].CreatedAt.Equal(memberships[j].CreatedAt) {
+			return memberships[i].CreatedAt.Before(memberships[j].CreatedAt)
+		}
+		return memberships[i].UserID.String() < memberships[j].UserID.String()
+	})
+	return memberships, nil
+}
+
+// RemoveMember removes a user from an organization
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, organizationID, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, member := r.store.memberships[organizationID][userID]; !member {
+		return services.ErrNotFound
+	}
+	delete(r.store.memberships[organizationID], userID)
+	if user, ok := r.store.users[userID]; ok && user.OrganizationID != nil && *user.OrganizationID == organizationID {
+		user.OrganizationID = nil
+	}
+	return nil
+}
+
+// AddDomain stores a domain claimed by an organization
+func (r *OrganizationRepository) AddDomain(ctx context.Context, domain *models.OrganizationDomain) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, claimed := r.store.domains[domain.OrganizationID][domain.Domain]; claimed {
+		return services.NewConflictError("organization already claims the domain")
+	}
+	if domain.CreatedAt.IsZero() {
+		domain.CreatedAt = time.Now()
+	}
+	if r.store.domains[domain.OrganizationID] == nil {
+		r.store.domains[domain.OrganizationID] = make(map[string]*models.OrganizationDomain)
+	}
+	copied := *domain
+	r.store.domains[domain.OrganizationID][domain.Domain] = &copied
+	return nil
+}
+
+// ListDomains returns the domains claimed by an organization, ordered by domain
+func (r *OrganizationRepository) ListDomains(ctx context.Context, organizationID uuid.UUID) ([]*models.OrganizationDomain, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	domains := make([]*models.OrganizationDomain, 0, len(r.store.domains[organizationID]))
+	for _, domain := range r.store.domains[organizationID] {
+		copied := *domain
+		domains = append(domains, &copied)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].Domain < domains[j].Domain
+	})
+	return domains, nil
+}
+
+// VerifyDomain marks a domain of an organization as verified
+func (r *OrganizationRepository) VerifyDomain(ctx context.Context, organizationID uuid.UUID, domain string, verifiedAt time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	claim, ok := r.store.domains[organizationID][domain]
+	if !ok {
+		return services.ErrNotFound
+	}
+	if owner, verified := r.store.verifiedDomainOwner(domain); verified && owner != organizationID {
+		return services.NewConflictError("domain is verified by another organization")
+	}
+	claim.VerifiedAt = &verifiedAt
+	return nil
+}
+
+// RemoveDomain removes a domain from an organization
+func (r *OrganizationRepository) RemoveDomain(ctx context.Context, organizationID uuid.UUID, domain string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, claimed := r.store.domains[organizationID][domain]; !claimed {
+		return services.ErrNotFound
+	}
+	delete(r.store.domains[organizationID], domain)
+	return nil
+}
+
+// GetByVerifiedDomain retrieves the organization that verified a domain
+func (r *OrganizationRepository) GetByVerifiedDomain(ctx context.Context, domain string) (*models.Organization, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	owner, verified := r.store.verifiedDomainOwner(domain)
+	if !verified {
+		return nil, services.ErrNotFound
+	}
+	organization, ok := r.store.organizations[owner]
+	if !ok {
+		return nil, services.ErrNotFound
+	}
+	copied := *organization
+	return &copied, nil
+}
+
+// AddInvitation stores an invitation into an organization
+func (r *OrganizationRepository) AddInvitation(ctx context.Context, invitation *models.OrganizationInvitation) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, invited := r.store.invitations[invitation.OrganizationID][invitation.UserID]; invited {
+		return services.NewConflictError("user is already invited to the organization")
+	}
+	if invitation.CreatedAt.IsZero() {
+		invitation.CreatedAt = time.Now()
+	}
+	if r.store.invitations[invitation.OrganizationID] == nil {
+		r.store.invitations[invitation.OrganizationID] = make(map[uuid.UUID]*models.OrganizationInvitation)
+	}
+	copied := *invitation
+	r.store.invitations[invitation.OrganizationID][invitation.UserID] = &copied
+	return nil
+}
+
+// GetInvitation retrieves the invitation of a user into an organization
+func (r *OrganizationRepository) GetInvitation(ctx context.Context, organizationID, userID uuid.UUID) (*models.OrganizationInvitation, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	invitation, ok := r.store.invitations[organizationID][userID]
+	if !ok {
+		return nil, services.ErrNotFound
+	}
+	copied := *invitation
+	return &copied, nil
+}
+
+// ListInvitationsByUser returns the invitations of a user, oldest first
+func (r *OrganizationRepository) ListInvitationsByUser(ctx context.Context, userID uuid.UUID) ([]*models.OrganizationInvitation, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var invitations []*models.OrganizationInvitation
+	for _, invited := range r.store.invitations {
+		if invitation, ok := invited[userID]; ok {
+			copied := *invitation
+			invitations = append(invitations, &copied)
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		if !invitations[i].CreatedAt.Equal(invitations[j].CreatedAt) {
+			return invitations[i].CreatedAt.Before(invitations[j].CreatedAt)
+		}
+		return invitations[i].OrganizationID.String() < invitations[j].OrganizationID.String()
+	})
+	return invitations, nil
+}
+
+// AcceptInvitation replaces an invitation with a membership
+func (r *OrganizationRepository) AcceptInvitation(ctx context.Context, invitation *models.OrganizationInvitation) (*models.OrganizationMembership, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, invited := r.store.invitations[invitation.OrganizationID][invitation.UserID]; !invited {
+		return nil, services.ErrNotFound
+	}
+	membership := &models.OrganizationMembership{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         invitation.UserID,
+		Role:           invitation.Role,
+	}
+	if err := r.store.addMember(membership); err != nil {
+		return nil, err
+	}
+	delete(r.store.invitations[invitation.OrganizationID], invitation.UserID)
+	return membership, nil
+}
+
+// RemoveInvitation removes the invitation of a user into an organization
+func (r *OrganizationRepository) RemoveInvitation(ctx context.Context, organizationID, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, invited := r.store.invitations[organizationID][userID]; !invited {
+		return services.ErrNotFound
+	}
+	delete(r.store.invitations[organizationID], userID)
+	return nil
+}
+
+// addMember stores a membership and makes its organization the user's
+// organization when they have none
+func (s *Store) addMember(membership *models.OrganizationMembership) error {
+	if _, member := s.memberships[membership.OrganizationID][membership.UserID]; member {
+		return services.NewConflictError("user is already a member of the organization")
+	}
+	if membership.CreatedAt.IsZero() {
+		membership.CreatedAt = time.Now()
+	}
+	if s.memberships[membership.OrganizationID] == nil {
+		s.memberships[membership.OrganizationID] = make(map[uuid.UUID]*models.OrganizationMembership)
+	}
+	copied := *membership
+	s.memberships[membership.OrganizationID][membership.UserID] = &copied
+
+	if user, ok := s.users[membership.UserID]; ok && user.OrganizationID == nil {
+		organizationID := membership.OrganizationID
+		user.OrganizationID = &organizationID
+	}
+	return nil
+}
+
+// verifiedDomainOwner returns the organization that verified a domain
+func (s *Store) verifiedDomainOwner(domain string) (uuid.UUID, bool) {
+	for organizationID, domains := range s.domains {
+		if claim, ok := domains[domain]; ok && claim.VerifiedAt != nil {
+			return organizationID, true
+		}
+	}
+	return uuid.Nil, false
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// OrganizationSettingsRepository implements repositories.OrganizationSettingsRepository in memory
+type OrganizationSettingsRepository struct {
+	store *Store
+}
+
+// NewOrganizationSettingsRepository creates a new in-memory organization settings repository
+func NewOrganizationSettingsRepository(store *Store) repositories.OrganizationSettingsRepository {
+	return &OrganizationSettingsRepository{
+		store: store,
+	}
+}
+
+// Get retrieves the settings of an organization
+func (r *OrganizationSettingsRepository) Get(ctx context.Context, organizationID uuid.UUID) (*models.OrganizationSettings, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	settings, ok := r.store.settings[organizationID]
+	if !ok {
+		return nil, services.ErrNotFound
+	}
+	copied := *settings
+	return &copied, nil
+}
+
+// Save creates or replaces the settings of an organization
+func (r *OrganizationSettingsRepository) Save(ctx context.Context, settings *models.OrganizationSettings) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := r.store.settings[settings.OrganizationID]; ok {
+		settings.CreatedAt = existing.CreatedAt
+	} else if settings.CreatedAt.IsZero() {
+		settings.CreatedAt = now
+	}
+	settings.UpdatedAt = now
+	copied := *settings
+	r.store.settings[settings.OrganizationID] = &copied
+	return nil
+}
+
+// Delete removes the settings of an organization
+func (r *OrganizationSettingsRepository) Delete(ctx context.Context, organizationID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.settings[organizationID]; !ok {
+		return services.ErrNotFound
+	}
+	delete(r.store.settings, organizationID)
+	return nil
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// RoleRepository implements repositories.RoleRepository in memory
+type RoleRepository struct {
+	store *Store
+}
+
+// NewRoleRepository creates a new in-memory role repository
+func NewRoleRepository(store *Store) repositories.RoleRepository {
+	return &RoleRepository{
+		store: store,
+	}
+}
+
+// Create stores a new role together with its permissions
+func (r *RoleRepository) Create(ctx context.Context, role *models.RoleDefinition) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.roles[role.Name]; exists {
+		return services.NewConflictError("role already exists")
+	}
+	now := time.Now()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+	r.store.roles[role.Name] = copyRole(role)
+	return nil
+}
+
+// Delete removes a role and its permissions
+func (r *RoleRepository) Delete(ctx context.Context, name models.Role) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.roles[name]; !exists {
+		return services.ErrNotFound
+	}
+	delete(r.store.roles, name)
+	return nil
+}
+
+// GetByName retrieves a role with its permissions
+func (r *RoleRepository) GetByName(ctx context.Context, name models.Role) (*models.RoleDefinition, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	role, ok := r.store.roles[name]
+	if !ok {
+		return nil, services.ErrNotFound
+	}
+	return copyRole(role), nil
+}
+
+// List returns all roles with their permissions, ordered by name
+func (r *RoleRepository) List(ctx context.Context) ([]*models.RoleDefinition, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	roles := make([]*models.RoleDefinition, 0, len(r.store.roles))
+	for _, role := range r.store.roles {
+		roles = append(roles, copyRole(role))
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	return roles, nil
+}
+
+// SetPermissions replaces the permissions of a role
+func (r *RoleRepository) SetPermissions(ctx context.Context, name models.Role, permissions []models.Permission) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	role, ok := r.store.roles[name]
+	if !ok {
+		return services.ErrNotFound
+	}
+	role.Permissions = append([]models.Permission(nil), permissions...)
+	role.UpdatedAt = time.Now()
+	return nil
+}
+
+// CountUsers returns how many users, including deleted ones, hold a role
+func (r *RoleRepository) CountUsers(ctx context.Context, name models.Role) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var count int64
+	for _, user := range r.store.users {
+		if user.Role == name {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// copyRole copies a role with its permissions in a stable order
+func copyRole(role *models.RoleDefinition) *models.RoleDefinition {
+	copied := *role
+	copied.Permissions = append([]models.Permission{}, role.Permissions...)
+	sort.Slice(copied.Permissions, func(i, j int) bool {
+		return copied.Permissions[i] < copied.Permissions[j]
+	})
+	return &copied
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+// Store holds the records of the in-memory repositories, which stand in for
+// Postgres in development and demo deployments and in tests. Like the
+// database, one store is shared by the repositories created over it, so
+// that e.g. organization memberships update the users they refer to.
+// Nothing is persisted: the records are gone when the process exits.
+type Store struct {
+	mu sync.RWMutex
+
+	users map[uuid.UUID]*models.User
+	// Indexes of the identifiers of users that are not soft-deleted,
+	// normalized like logins
+	emails    map[string]uuid.UUID
+	usernames map[string]uuid.UUID
+
+	roles map[models.Role]*models.RoleDefinition
+
+	organizations map[uuid.UUID]*models.Organization
+	memberships   map[uuid.UUID]map[uuid.UUID]*models.OrganizationMembership // by organization and user
+	domains       map[uuid.UUID]map[string]*models.OrganizationDomain        // by organization and domain
+	invitations   map[uuid.UUID]map[uuid.UUID]*models.OrganizationInvitation // by organization and user
+	settings      map[uuid.UUID]*models.OrganizationSettings
+
+	totpCredentials map[uuid.UUID]*models.TOTPCredential
+	mfaPolicies     map[uuid.UUID]*models.MFAPolicy
+}
+
+// NewStore creates an empty store with the built-in roles, as the
+// migrations create them in the database
+func NewStore() *Store {
+	store := &Store{
+		users:           make(map[uuid.UUID]*models.User),
+		emails:          make(map[string]uuid.UUID),
+		usernames:       make(map[string]uuid.UUID),
+		roles:           make(map[models.Role]*models.RoleDefinition),
+		organizations:   make(map[uuid.UUID]*models.Organization),
+		memberships:     make(map[uuid.UUID]map[uuid.UUID]*models.OrganizationMembership),
+		domains:         make(map[uuid.UUID]map[string]*models.OrganizationDomain),
+		invitations:     make(map[uuid.UUID]map[uuid.UUID]*models.OrganizationInvitation),
+		settings:        make(map[uuid.UUID]*models.OrganizationSettings),
+		totpCredentials: make(map[uuid.UUID]*models.TOTPCredential),
+		mfaPolicies:     make(map[uuid.UUID]*models.MFAPolicy),
+	}
+
+	now := time.Now()
+	builtIn := map[models.Role]string{
+		models.RoleAdmin: "Administers users and the service",
+		models.RoleUser:  "Manages their own account",
+	}
+	for name, description := range builtIn {
+		store.roles[name] = &models.RoleDefinition{
+			Name:        name,
+			Description: description,
+			Permissions: name.DefaultPermissions(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+	}
+	return store
+}
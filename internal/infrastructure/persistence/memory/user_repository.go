@@ -0,0 +1,330 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// UserRepository implements repositories.UserRepository in memory. Users
+// are looked up by email and username through indexes, which also enforce
+// the uniqueness the database's unique indexes enforce.
+type UserRepository struct {
+	store *Store
+}
+
+// NewUserRepository creates a new in-memory user repository
+func NewUserRepository(store *Store) repositories.UserRepository {
+	return &UserRepository{
+		store: store,
+	}
+}
+
+// Create creates a new user
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if user.ID == uuid.Nil {
+		user.ID = models.NewID()
+	}
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+	if _, exists := r.store.users[user.ID]; exists {
+		return services.ErrUserAlreadyExists
+	}
+	if err := r.store.checkIdentifiers(user); err != nil {
+		return err
+	}
+	r.store.putUser(user)
+	return nil
+}
+
+// GetByID retrieves a user by their ID
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.visibleUser(ctx, id)
+	if !ok {
+		return nil, domainerrors.WrapError("GetByID", domainerrors.ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// GetByEmail retrieves a user by their email, which matches
+// case-insensitively
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	id, ok := r.store.emails[models.NormalizeIdentifier(email)]
+	if !ok {
+		return nil, domainerrors.WrapError("GetByEmail", domainerrors.ErrUserNotFound)
+	}
+	user, ok := r.store.visibleUser(ctx, id)
+	if !ok {
+		return nil, domainerrors.WrapError("GetByEmail", domainerrors.ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// GetByUsername retrieves a user by their username, which matches
+// case-insensitively
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	id, ok := r.store.usernames[models.NormalizeIdentifier(username)]
+	if !ok {
+		return nil, domainerrors.WrapError("GetByUsername", domainerrors.ErrUserNotFound)
+	}
+	user, ok := r.store.visibleUser(ctx, id)
+	if !ok {
+		return nil, domainerrors.WrapError("GetByUsername", domainerrors.ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// GetByIdentifier retrieves a user by their email or username.
+// Identifiers match case-insensitively; should the identifier be one
+// user's email and another user's username, the email match wins.
+func (r *UserRepository) GetByIdentifier(ctx context.Context, identifier string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	identifier = models.NormalizeIdentifier(identifier)
+	for _, index := range []map[string]uuid.UUID{r.store.emails, r.store.usernames} {
+		if id, ok := index[identifier]; ok {
+			if user, ok := r.store.visibleUser(ctx, id); ok {
+				return user, nil
+			}
+		}
+	}
+	return nil, domainerrors.WrapError("GetByIdentifier", domainerrors.ErrUserNotFound)
+}
+
+// IdentifiersTaken reports whether a user other than a soft-deleted one has
+// the email or the username
+func (r *UserRepository) IdentifiersTaken(ctx context.Context, email, username string) (bool, bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	email, username = models.NormalizeIdentifier(email), models.NormalizeIdentifier(username)
+	_, emailTaken := r.store.emails[email]
+	_, usernameTaken := r.store.usernames[username]
+	return emailTaken, usernameTaken, nil
+}
+
+// ListByRecoveryEmail retrieves the users with the given recovery email,
+// matched case-insensitively
+func (r *UserRepository) ListByRecoveryEmail(ctx context.Context, email string) ([]*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	email = models.NormalizeIdentifier(email)
+	return r.store.listUsers(ctx, func(user *models.User) bool {
+		return models.NormalizeIdentifier(user.RecoveryEmail) == email
+	}), nil
+}
+
+// Update updates a stored user
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[user.ID]; !ok || !r.store.inScope(ctx, user.ID) {
+		return domainerrors.WrapError("Update", domainerrors.ErrUserNotFound)
+	}
+	user.UpdatedAt = time.Now()
+	if err := r.store.checkIdentifiers(user); err != nil {
+		return err
+	}
+	r.store.putUser(user)
+	return nil
+}
+
+// Delete soft-deletes a user
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.visibleUser(ctx, id)
+	if !ok {
+		return nil
+	}
+	user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.store.putUser(user)
+	return nil
+}
+
+// GetByIDIncludingDeleted retrieves a user by their ID, including a soft-deleted one
+func (r *UserRepository) GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[id]
+	if !ok || !r.store.inScope(ctx, id) {
+		return nil, domainerrors.WrapError("GetByIDIncludingDeleted", domainerrors.ErrUserNotFound)
+	}
+	return copyUser(user), nil
+}
+
+// Purge permanently deletes a user and the records referring to the user
+func (r *UserRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok || !r.store.inScope(ctx, id) {
+		return domainerrors.WrapError("Purge", domainerrors.ErrUserNotFound)
+	}
+	r.store.unindexUser(user)
+	delete(r.store.users, id)
+	for _, members := range r.store.memberships {
+		delete(members, id)
+	}
+	for _, invited := range r.store.invitations {
+		delete(invited, id)
+	}
+	delete(r.store.totpCredentials, id)
+	return nil
+}
+
+// List lists all users with pagination, oldest first
+func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := r.store.listUsers(ctx, func(*models.User) bool { return true })
+	return page(users, offset, limit), nil
+}
+
+// ListDeactivatedBefore retrieves up to limit users deactivated before the
+// given time, longest deactivated first
+func (r *UserRepository) ListDeactivatedBefore(ctx context.Context, before time.Time, limit int) ([]*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := r.store.listUsers(ctx, func(user *models.User) bool {
+		return user.Status == models.UserStatusDeactivated && user.DeactivatedAt != nil && user.DeactivatedAt.Before(before)
+	})
+	sort.SliceStable(users, func(i, j int) bool {
+		return users[i].DeactivatedAt.Before(*users[j].DeactivatedAt)
+	})
+	return page(users, 0, limit), nil
+}
+
+// visibleUser returns a copy of a user that is not soft-deleted and is in
+// the organization ctx is scoped to, if any
+func (s *Store) visibleUser(ctx context.Context, id uuid.UUID) (*models.User, bool) {
+	user, ok := s.users[id]
+	if !ok || user.DeletedAt.Valid || !s.inScope(ctx, id) {
+		return nil, false
+	}
+	return copyUser(user), true
+}
+
+// inScope reports whether a user is a member of the organization ctx is
+// scoped to, or ctx is not scoped
+func (s *Store) inScope(ctx context.Context, userID uuid.UUID) bool {
+	organizationID, ok := repositories.OrganizationScope(ctx)
+	if !ok {
+		return true
+	}
+	_, member := s.memberships[organizationID][userID]
+	return member
+}
+
+// listUsers returns copies of the visible users matching a filter, oldest first
+func (s *Store) listUsers(ctx context.Context, match func(*models.User) bool) []*models.User {
+	var users []*models.User
+	for id, user := range s.users {
+		if user.DeletedAt.Valid || !s.inScope(ctx, id) || !match(user) {
+			continue
+		}
+		users = append(users, copyUser(user))
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.Before(users[j].CreatedAt)
+		}
+		return users[i].ID.String() < users[j].ID.String()
+	})
+	return users
+}
+
+// checkIdentifiers returns an error when another user that is not
+// soft-deleted has the email or username of a user about to be stored
+func (s *Store) checkIdentifiers(user *models.User) error {
+	if user.DeletedAt.Valid {
+		return nil
+	}
+	if id, ok := s.emails[models.NormalizeIdentifier(user.Email)]; ok && id != user.ID {
+		return services.ErrEmailAlreadyExists
+	}
+	if id, ok := s.usernames[models.NormalizeIdentifier(user.Username)]; ok && id != user.ID {
+		return services.ErrUsernameAlreadyExists
+	}
+	return nil
+}
+
+// putUser stores a copy of a user and reindexes its identifiers
+func (s *Store) putUser(user *models.User) {
+	if previous, ok := s.users[user.ID]; ok {
+		s.unindexUser(previous)
+	}
+	stored := copyUser(user)
+	s.users[user.ID] = stored
+	if stored.DeletedAt.Valid {
+		return
+	}
+	if stored.Email != "" {
+		s.emails[models.NormalizeIdentifier(stored.Email)] = stored.ID
+	}
+	if stored.Username != "" {
+		s.usernames[models.NormalizeIdentifier(stored.Username)] = stored.ID
+	}
+}
+
+// unindexUser removes the identifiers of a stored user from the indexes
+func (s *Store) unindexUser(user *models.User) {
+	if id, ok := s.emails[models.NormalizeIdentifier(user.Email)]; ok && id == user.ID {
+		delete(s.emails, models.NormalizeIdentifier(user.Email))
+	}
+	if id, ok := s.usernames[models.NormalizeIdentifier(user.Username)]; ok && id == user.ID {
+		delete(s.usernames, models.NormalizeIdentifier(user.Username))
+	}
+}
+
+// copyUser copies a user so that callers cannot change stored records
+// without saving them
+func copyUser(user *models.User) *models.User {
+	copied := *user
+	return &copied
+}
+
+// page returns the items from offset, at most limit of them; a limit of 0
+// or less returns all of them
+func page[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
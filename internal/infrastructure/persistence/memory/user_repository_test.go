@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestUser(email, username string) *models.User {
+	return &models.User{Email: email, Username: username, Status: models.UserStatusActive}
+}
+
+func TestUserRepositoryLookups(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository(NewStore())
+	user := newTestUser("Alice@Example.com", "Alice")
+	require.NoError(t, repo.Create(ctx, user))
+
+	for _, email := range []string{"Alice@Example.com", "alice@example.com", " ALICE@example.com "} {
+		found, err := repo.GetByEmail(ctx, email)
+		require.NoError(t, err, email)
+		assert.Equal(t, user.ID, found.ID)
+	}
+	for _, username := range []string{"Alice", "alice"} {
+		found, err := repo.GetByUsername(ctx, username)
+		require.NoError(t, err, username)
+		assert.Equal(t, user.ID, found.ID)
+	}
+	found, err := repo.GetByIdentifier(ctx, "ALICE")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	_, err = repo.GetByEmail(ctx, "alice")
+	assert.True(t, errors.Is(err, domainerrors.ErrUserNotFound))
+
+	// Stored users are copies
+	found.Email = "changed@example.com"
+	found, err = repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice@Example.com", found.Email)
+}
+
+func TestUserRepositoryUniqueness(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository(NewStore())
+	alice := newTestUser("alice@example.com", "alice")
+	require.NoError(t, repo.Create(ctx, alice))
+	bob := newTestUser("bob@example.com", "bob")
+	require.NoError(t, repo.Create(ctx, bob))
+
+	assert.ErrorIs(t, repo.Create(ctx, newTestUser("ALICE@example.com", "other")), services.ErrEmailAlreadyExists)
+	assert.ErrorIs(t, repo.Create(ctx, newTestUser("other@example.com", "Alice")), services.ErrUsernameAlreadyExists)
+	assert.ErrorIs(t, repo.Create(ctx, &models.User{ID: alice.ID, Email: "x@example.com", Username: "x"}), services.ErrUserAlreadyExists)
+
+	bob.Email = "Alice@example.com"
+	assert.ErrorIs(t, repo.Update(ctx, bob), services.ErrEmailAlreadyExists)
+
+	// A changed email frees the old one
+	alice.Email = "alice@example.org"
+	require.NoError(t, repo.Update(ctx, alice))
+	bob.Email = "alice@example.com"
+	require.NoError(t, repo.Update(ctx, bob))
+	_, err := repo.GetByEmail(ctx, "alice@example.org")
+	assert.NoError(t, err)
+
+	emailTaken, usernameTaken, err := repo.IdentifiersTaken(ctx, "ALICE@example.com", "nobody")
+	require.NoError(t, err)
+	assert.True(t, emailTaken)
+	assert.False(t, usernameTaken)
+}
+
+func TestUserRepositoryUpdateUnknownUser(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository(NewStore())
+	err := repo.Update(ctx, &models.User{ID: uuid.New(), Email: "ghost@example.com", Username: "ghost"})
+	assert.True(t, errors.Is(err, domainerrors.ErrUserNotFound))
+
+	_, err = repo.GetByEmail(ctx, "ghost@example.com")
+	assert.True(t, errors.Is(err, domainerrors.ErrUserNotFound))
+}
+
+func TestUserRepositorySoftDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository(NewStore())
+	user := newTestUser("alice@example.com", "alice")
+	require.NoError(t, repo.Create(ctx, user))
+	require.NoError(t, repo.Delete(ctx, user.ID))
+
+	_, err := repo.GetByID(ctx, user.ID)
+	assert.True(t, errors.Is(err, domainerrors.ErrUserNotFound))
+	_, err = repo.GetByEmail(ctx, "alice@example.com")
+	assert.True(t, errors.Is(err, domainerrors.ErrUserNotFound))
+	_, err = repo.GetByIdentifier(ctx, "alice")
+	assert.True(t, errors.Is(err, domainerrors.ErrUserNotFound))
+	users, err := repo.List(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	deleted, err := repo.GetByIDIncludingDeleted(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted.DeletedAt.Valid)
+
+	// The identifiers of a deleted user can be registered again
+	require.NoError(t, repo.Create(ctx, newTestUser("alice@example.com", "alice")))
+
+	require.NoError(t, repo.Purge(ctx, user.ID))
+	_, err = repo.GetByIDIncludingDeleted(ctx, user.ID)
+	assert.True(t, errors.Is(err, domainerrors.ErrUserNotFound))
+}
+
+func TestUserRepositoryOrganizationScope(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	repo := NewUserRepository(store)
+	organizations := NewOrganizationRepository(store)
+
+	member := newTestUser("member@example.com", "member")
+	require.NoError(t, repo.Create(ctx, member))
+	outsider := newTestUser("outsider@example.com", "outsider")
+	require.NoError(t, repo.Create(ctx, outsider))
+	organization := &models.Organization{Name: "Acme"}
+	require.NoError(t, organizations.Create(ctx, organization, &models.OrganizationMembership{
+		UserID: member.ID,
+		Role:   models.OrganizationRoleOwner,
+	}))
+
+	scoped := repositories.WithOrganizationScope(ctx, organization.ID)
+	found, err := repo.GetByEmail(scoped, "member@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, member.ID, found.ID)
+
+	_, err = repo.GetByID(scoped, outsider.ID)
+	assert.True(t, errors.Is(err, domainerrors.ErrUserNotFound))
+	_, err = repo.GetByEmail(scoped, "outsider@example.com")
+	assert.True(t, errors.Is(err, domainerrors.ErrUserNotFound))
+	assert.True(t, errors.Is(repo.Update(scoped, outsider), domainerrors.ErrUserNotFound))
+
+	users, err := repo.List(scoped, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, member.ID, users[0].ID)
+
+	users, err = repo.List(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+}
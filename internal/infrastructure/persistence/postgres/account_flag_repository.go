@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// AccountFlagRepository implements repositories.AccountFlagRepository using GORM
+type AccountFlagRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountFlagRepository creates a new postgres account flag repository
+func NewAccountFlagRepository(db *gorm.DB) repositories.AccountFlagRepository {
+	return &AccountFlagRepository{
+		db: db,
+	}
+}
+
+// Create stores a new flag
+func (r *AccountFlagRepository) Create(ctx context.Context, flag *models.AccountFlag) error {
+	if flag.ID == uuid.Nil {
+		flag.ID = models.NewID()
+	}
+	now := time.Now()
+	flag.CreatedAt = now
+	flag.UpdatedAt = now
+	return r.db.WithContext(ctx).Create(flag).Error
+}
+
+// GetByID retrieves a flag
+func (r *AccountFlagRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AccountFlag, error) {
+	var flag models.AccountFlag
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&flag).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// List retrieves the flags matching filter, oldest first
+func (r *AccountFlagRepository) List(ctx context.Context, filter repositories.AccountFlagFilter, offset, limit int) ([]*models.AccountFlag, error) {
+	query := r.db.WithContext(ctx)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	var flags []*models.AccountFlag
+	err := query.Order("created_at ASC, id").Offset(offset).Limit(limit).Find(&flags).Error
+	if err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// ListActiveByUser retrieves the open and confirmed flags of a user
+func (r *AccountFlagRepository) ListActiveByUser(ctx context.Context, userID uuid.UUID) ([]*models.AccountFlag, error) {
+	var flags []*models.AccountFlag
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status IN ?", userID, []models.FlagStatus{models.FlagStatusOpen, models.FlagStatusConfirmed}).
+		Order("created_at ASC").
+		Find(&flags).Error
+	if err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// Update saves a reviewed flag
+func (r *AccountFlagRepository) Update(ctx context.Context, flag *models.AccountFlag) error {
+	flag.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Save(flag).Error
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository implements repositories.APIKeyRepository using GORM
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new postgres API key repository
+func NewAPIKeyRepository(db *gorm.DB) repositories.APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+// Create stores a new key
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	if key.ID == uuid.Nil {
+		key.ID = models.NewID()
+	}
+	key.CreatedAt = time.Now()
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetByHash retrieves the key with the given hash
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUser returns the keys of a service account, oldest first
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke marks a key of a service account revoked
+func (r *APIKeyRepository) Revoke(ctx context.Context, userID, id uuid.UUID, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
+
+// RecordUse sets when a key was last used
+func (r *APIKeyRepository) RecordUse(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// EmailVerificationRepository implements repositories.EmailVerificationRepository using GORM
+type EmailVerificationRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailVerificationRepository creates a new postgres email verification repository
+func NewEmailVerificationRepository(db *gorm.DB) repositories.EmailVerificationRepository {
+	return &EmailVerificationRepository{
+		db: db,
+	}
+}
+
+// Create records a sent verification email
+func (r *EmailVerificationRepository) Create(ctx context.Context, verification *models.EmailVerification) error {
+	return r.db.WithContext(ctx).Create(verification).Error
+}
+
+// GetByTokenHash retrieves a verification email by the hash of its token
+func (r *EmailVerificationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error) {
+	var verification models.EmailVerification
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&verification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// Update updates a verification email
+func (r *EmailVerificationRepository) Update(ctx context.Context, verification *models.EmailVerification) error {
+	return r.db.WithContext(ctx).Save(verification).Error
+}
+
+// ListByUser retrieves a user's verification emails, most recent first
+func (r *EmailVerificationRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.EmailVerification, error) {
+	var verifications []*models.EmailVerification
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("sent_at DESC").
+		Find(&verifications).Error
+	if err != nil {
+		return nil, err
+	}
+	return verifications, nil
+}
+
+// CountSince counts the verification emails sent to a user since the given time
+func (r *EmailVerificationRepository) CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.EmailVerification{}).
+		Where("user_id = ? AND sent_at >= ?", userID, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// ExpirePending marks a user's unused verification emails as expired
+func (r *EmailVerificationRepository) ExpirePending(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Model(&models.EmailVerification{}).
+		Where("user_id = ? AND status = ?", userID, models.EmailVerificationSent).
+		Update("status", models.EmailVerificationExpired).Error
+}
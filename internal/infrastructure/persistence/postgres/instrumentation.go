@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"errors"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	queryStartKey = "instrumentation:start"
+
+	// defaultSlowQueryThreshold is used when no slow query threshold is configured
+	defaultSlowQueryThreshold = 200 * time.Millisecond
+)
+
+// closureSuffix matches the suffix the runtime gives anonymous functions
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+$`)
+
+// InstrumentationConfig configures query metrics and the slow query log
+type InstrumentationConfig struct {
+	// SlowQueryThreshold is the duration above which queries are logged as warnings
+	SlowQueryThreshold time.Duration
+	// ErrorQueryThreshold is the duration above which queries are logged as
+	// errors; 0 disables the error level
+	ErrorQueryThreshold time.Duration
+	// MethodThresholds overrides SlowQueryThreshold per repository method,
+	// keyed like "Repository.List"
+	MethodThresholds map[string]time.Duration
+}
+
+// QueryInstrumentation is a GORM plugin recording latency and errors per
+// repository method and logging slow queries
+type QueryInstrumentation struct {
+	config         InstrumentationConfig
+	metricsService services.MetricsService
+	logger         *zap.Logger
+}
+
+// NewQueryInstrumentation creates a new query instrumentation plugin
+func NewQueryInstrumentation(config InstrumentationConfig, metricsService services.MetricsService, logger *zap.Logger) *QueryInstrumentation {
+	if config.SlowQueryThreshold <= 0 {
+		config.SlowQueryThreshold = defaultSlowQueryThreshold
+	}
+	return &QueryInstrumentation{
+		config:         config,
+		metricsService: metricsService,
+		logger:         logger,
+	}
+}
+
+// Name returns the plugin name
+func (p *QueryInstrumentation) Name() string {
+	return "query_instrumentation"
+}
+
+// Initialize registers the instrumentation callbacks around every GORM operation
+func (p *QueryInstrumentation) Initialize(db *gorm.DB) error {
+	type register func(name string, fn func(*gorm.DB)) error
+
+	callback := db.Callback()
+	operations := []struct {
+		name   string
+		before register
+		after  register
+	}{
+		{"create", callback.Create().Before("gorm:create").Register, callback.Create().After("gorm:create").Register},
+		{"query", callback.Query().Before("gorm:query").Register, callback.Query().After("gorm:query").Register},
+		{"update", callback.Update().Before("gorm:update").Register, callback.Update().After("gorm:update").Register},
+		{"delete", callback.Delete().Before("gorm:delete").Register, callback.Delete().After("gorm:delete").Register},
+		{"row", callback.Row().Before("gorm:row").Register, callback.Row().After("gorm:row").Register},
+		{"raw", callback.Raw().Before("gorm:raw").Register, callback.Raw().After("gorm:raw").Register},
+	}
+
+	for _, op := range operations {
+		if err := op.before("instrumentation:before_"+op.name, p.before); err != nil {
+			return err
+		}
+		if err := op.after("instrumentation:after_"+op.name, p.after(op.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *QueryInstrumentation) before(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func (p *QueryInstrumentation) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		duration := time.Since(start)
+
+		method := repositoryMethod()
+		labels := map[string]string{
+			"method":    method,
+			"operation": operation,
+			"table":     db.Statement.Table,
+		}
+		p.metricsService.ObserveHistogram("db_query_duration_seconds", duration.Seconds(), labels)
+
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			p.metricsService.IncrementCounter("db_query_errors_total", labels)
+		}
+
+		p.logSlowQuery(db, method, operation, duration)
+	}
+}
+
+// logSlowQuery logs queries slower than the applicable threshold. Only the
+// statement is logged, never its bound values.
+func (p *QueryInstrumentation) logSlowQuery(db *gorm.DB, method, operation string, duration time.Duration) {
+	threshold := p.config.SlowQueryThreshold
+	if override, ok := p.config.MethodThresholds[method]; ok && override > 0 {
+		threshold = override
+	}
+	if duration < threshold {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("operation", operation),
+		zap.String("table", db.Statement.Table),
+		zap.Duration("duration", duration),
+		zap.Duration("threshold", threshold),
+		zap.Int64("rowsAffected", db.Statement.RowsAffected),
+		zap.String("sql", db.Statement.SQL.String()),
+	}
+
+	if p.config.ErrorQueryThreshold > 0 && duration >= p.config.ErrorQueryThreshold {
+		p.logger.Error("slow query", fields...)
+		return
+	}
+	p.logger.Warn("slow query", fields...)
+}
+
+// repositoryMethod returns the persistence method that issued the current
+// query, such as "Repository.GetByID", by walking the call stack
+func repositoryMethod() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if strings.Contains(frame.Function, "/infrastructure/persistence/") &&
+			!strings.Contains(frame.Function, "QueryInstrumentation") {
+			name := frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+			// Drop the package name, pointer receiver syntax and closure suffixes
+			if i := strings.Index(name, "."); i >= 0 {
+				name = name[i+1:]
+			}
+			name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+			return closureSuffix.ReplaceAllString(name, "")
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
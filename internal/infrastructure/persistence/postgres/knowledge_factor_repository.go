@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// KnowledgeFactorRepository implements repositories.KnowledgeFactorRepository using GORM
+type KnowledgeFactorRepository struct {
+	db *gorm.DB
+}
+
+// NewKnowledgeFactorRepository creates a new postgres knowledge factor repository
+func NewKnowledgeFactorRepository(db *gorm.DB) repositories.KnowledgeFactorRepository {
+	return &KnowledgeFactorRepository{
+		db: db,
+	}
+}
+
+// Replace stores the answers of a user in place of their previous ones
+func (r *KnowledgeFactorRepository) Replace(ctx context.Context, userID uuid.UUID, factors []*models.KnowledgeFactor) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.KnowledgeFactor{}, "user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		if len(factors) == 0 {
+			return nil
+		}
+		return tx.Create(&factors).Error
+	})
+}
+
+// ListByUser returns the answers of a user, ordered by question
+func (r *KnowledgeFactorRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.KnowledgeFactor, error) {
+	var factors []*models.KnowledgeFactor
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("question ASC").Find(&factors).Error
+	if err != nil {
+		return nil, err
+	}
+	return factors, nil
+}
+
+// DeleteByUser removes the answers of a user
+func (r *KnowledgeFactorRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.KnowledgeFactor{}, "user_id = ?", userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
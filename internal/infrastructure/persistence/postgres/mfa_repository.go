@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TOTPCredentialRepository implements repositories.TOTPCredentialRepository using GORM
+type TOTPCredentialRepository struct {
+	db *gorm.DB
+}
+
+// NewTOTPCredentialRepository creates a new postgres TOTP credential repository
+func NewTOTPCredentialRepository(db *gorm.DB) repositories.TOTPCredentialRepository {
+	return &TOTPCredentialRepository{
+		db: db,
+	}
+}
+
+// Save creates or replaces the credential of a user
+func (r *TOTPCredentialRepository) Save(ctx context.Context, credential *models.TOTPCredential) error {
+	now := time.Now()
+	if credential.CreatedAt.IsZero() {
+		credential.CreatedAt = now
+	}
+	credential.UpdatedAt = now
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"secret", "confirmed_at", "last_used_step", "created_at", "updated_at",
+		}),
+	}).Create(credential).Error
+}
+
+// GetByUserID retrieves the credential of a user
+func (r *TOTPCredentialRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.TOTPCredential, error) {
+	var credential models.TOTPCredential
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&credential).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// Delete removes the credential of a user
+func (r *TOTPCredentialRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.TOTPCredential{}, "user_id = ?", userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
+
+// UseStep records an accepted time step unless a later or equal one was
+// accepted before, atomically so concurrent logins cannot share a code
+func (r *TOTPCredentialRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.TOTPCredential{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Updates(map[string]interface{}{"last_used_step": step, "updated_at": time.Now()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// MFAPolicyRepository implements repositories.MFAPolicyRepository using GORM
+type MFAPolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewMFAPolicyRepository creates a new postgres MFA policy repository
+func NewMFAPolicyRepository(db *gorm.DB) repositories.MFAPolicyRepository {
+	return &MFAPolicyRepository{
+		db: db,
+	}
+}
+
+// Create stores a new policy
+func (r *MFAPolicyRepository) Create(ctx context.Context, policy *models.MFAPolicy) error {
+	if policy.ID == uuid.Nil {
+		policy.ID = models.NewID()
+	}
+	now := time.Now()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+	return r.db.WithContext(ctx).Create(policy).Error
+}
+
+// Update replaces a policy
+func (r *MFAPolicyRepository) Update(ctx context.Context, policy *models.MFAPolicy) error {
+	policy.UpdatedAt = time.Now()
+	result := r.db.WithContext(ctx).Model(&models.MFAPolicy{}).Where("id = ?", policy.ID).
+		Select("name", "organization_id", "roles", "enforce_after", "updated_at").
+		Updates(policy)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a policy
+func (r *MFAPolicyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.MFAPolicy{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
+
+// GetByID retrieves a policy by ID
+func (r *MFAPolicyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.MFAPolicy, error) {
+	var policy models.MFAPolicy
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&policy).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// List returns all policies, earliest enforcement first
+func (r *MFAPolicyRepository) List(ctx context.Context) ([]*models.MFAPolicy, error) {
+	var policies []*models.MFAPolicy
+	if err := r.db.WithContext(ctx).Order("enforce_after ASC, created_at ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
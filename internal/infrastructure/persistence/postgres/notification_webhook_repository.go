@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// NotificationWebhookRepository implements repositories.NotificationWebhookRepository using GORM
+type NotificationWebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationWebhookRepository creates a new postgres notification webhook repository
+func NewNotificationWebhookRepository(db *gorm.DB) repositories.NotificationWebhookRepository {
+	return &NotificationWebhookRepository{
+		db: db,
+	}
+}
+
+// Create stores a new webhook
+func (r *NotificationWebhookRepository) Create(ctx context.Context, webhook *models.NotificationWebhook) error {
+	if webhook.ID == uuid.Nil {
+		webhook.ID = models.NewID()
+	}
+	now := time.Now()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+// ListByUser returns the webhooks of a user, oldest first
+func (r *NotificationWebhookRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.NotificationWebhook, error) {
+	var webhooks []*models.NotificationWebhook
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&webhooks).Error
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// Delete removes a webhook of a user
+func (r *NotificationWebhookRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.NotificationWebhook{}, "id = ? AND user_id = ?", id, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
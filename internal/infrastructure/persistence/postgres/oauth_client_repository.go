@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// OAuthClientRepository implements repositories.OAuthClientRepository using GORM
+type OAuthClientRepository struct {
+	db *gorm.DB
+}
+
+// NewOAuthClientRepository creates a new postgres OAuth client repository
+func NewOAuthClientRepository(db *gorm.DB) repositories.OAuthClientRepository {
+	return &OAuthClientRepository{
+		db: db,
+	}
+}
+
+// Create registers a client
+func (r *OAuthClientRepository) Create(ctx context.Context, client *models.OAuthClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+// GetByClientID retrieves a client by its public client ID
+func (r *OAuthClientRepository) GetByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+// List retrieves every registered client, oldest first
+func (r *OAuthClientRepository) List(ctx context.Context) ([]*models.OAuthClient, error) {
+	var clients []*models.OAuthClient
+	if err := r.db.WithContext(ctx).Order("created_at ASC").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// Update updates a client
+func (r *OAuthClientRepository) Update(ctx context.Context, client *models.OAuthClient) error {
+	return r.db.WithContext(ctx).Save(client).Error
+}
+
+// Delete removes a client by its public client ID
+func (r *OAuthClientRepository) Delete(ctx context.Context, clientID string) error {
+	result := r.db.WithContext(ctx).Where("client_id = ?", clientID).Delete(&models.OAuthClient{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
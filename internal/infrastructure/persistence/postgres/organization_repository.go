@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// OrganizationRepository implements repositories.OrganizationRepository using GORM
+type OrganizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates a new postgres organization repository
+func NewOrganizationRepository(db *gorm.DB) repositories.OrganizationRepository {
+	return &OrganizationRepository{
+		db: db,
+	}
+}
+
+// Create stores a new organization with its first member
+func (r *OrganizationRepository) Create(ctx context.Context, organization *models.Organization, owner *models.OrganizationMembership) error {
+	if organization.ID == uuid.Nil {
+		organization.ID = models.NewID()
+	}
+	now := time.Now()
+	organization.CreatedAt = now
+	organization.UpdatedAt = now
+	owner.OrganizationID = organization.ID
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		return addMember(tx, owner)
+	})
+}
+
+// GetByID retrieves an organization
+func (r *OrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&organization).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// Update saves the settings of an organization
+func (r *OrganizationRepository) Update(ctx context.Context, organization *models.Organization) error {
+	organization.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Save(organization).Error
+}
+
+// ListByUser returns the organizations a user is a member of, ordered by name
+func (r *OrganizationRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.Organization, error) {
+	var organizations []*models.Organization
+	err := r.db.WithContext(ctx).
+		Where("id IN (SELECT organization_id FROM organization_memberships WHERE user_id = ?)", userID).
+		Order("name ASC, id ASC").
+		Find(&organizations).Error
+	if err != nil {
+		return nil, err
+	}
+	return organizations, nil
+}
+
+// AddMember adds a user to an organization
+func (r *OrganizationRepository) AddMember(ctx context.Context, membership *models.OrganizationMembership) error {
+	return translateUniqueViolation(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return addMember(tx, membership)
+	}))
+}
+
+// GetMember retrieves the membership of a user in an organization
+func (r *OrganizationRepository) GetMember(ctx context.Context, organizationID, userID uuid.UUID) (*models.OrganizationMembership, error) {
+	var membership models.OrganizationMembership
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		First(&membership).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// ListMembers returns the memberships of an organization, oldest first
+func (r *OrganizationRepository) ListMembers(ctx context.Context, organizationID uuid.UUID) ([]*models.OrganizationMembership, error) {
+	var memberships []*models.OrganizationMembership
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ?", organizationID).
+		Order("created_at ASC, user_id ASC").
+		Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// RemoveMember removes a user from an organization
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, organizationID, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.OrganizationMembership{}, "organization_id = ? AND user_id = ?", organizationID, userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return services.ErrNotFound
+		}
+		return tx.Model(&models.User{}).
+			Where("id = ? AND organization_id = ?", userID, organizationID).
+			Update("organization_id", nil).Error
+	})
+}
+
+// AddDomain stores a domain claimed by an organization
+func (r *OrganizationRepository) AddDomain(ctx context.Context, domain *models.OrganizationDomain) error {
+	if domain.CreatedAt.IsZero() {
+		domain.CreatedAt = time.Now()
+	}
+	return translateUniqueViolation(r.db.WithContext(ctx).Create(domain).Error)
+}
+
+// ListDomains returns the domains claimed by an organization, ordered by domain
+func (r *OrganizationRepository) ListDomains(ctx context.Context, organizationID uuid.UUID) ([]*models.OrganizationDomain, error) {
+	var domains []*models.OrganizationDomain
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ?", organizationID).
+		Order("domain ASC").
+		Find(&domains).Error
+	if err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+// VerifyDomain marks a domain of an organization as verified
+func (r *OrganizationRepository) VerifyDomain(ctx context.Context, organizationID uuid.UUID, domain string, verifiedAt time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.OrganizationDomain{}).
+		Where("organization_id = ? AND domain = ?", organizationID, domain).
+		Update("verified_at", verifiedAt)
+	if result.Error != nil {
+		return translateUniqueViolation(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
+
+// RemoveDomain removes a domain from an organization
+func (r *OrganizationRepository) RemoveDomain(ctx context.Context, organizationID uuid.UUID, domain string) error {
+	result := r.db.WithContext(ctx).Delete(&models.OrganizationDomain{}, "organization_id = ? AND domain = ?", organizationID, domain)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
+
+// GetByVerifiedDomain retrieves the organization that verified a domain
+func (r *OrganizationRepository) GetByVerifiedDomain(ctx context.Context, domain string) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.db.WithContext(ctx).
+		Where("id = (SELECT organization_id FROM organization_domains WHERE domain = ? AND verified_at IS NOT NULL)", domain).
+		First(&organization).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// AddInvitation stores an invitation into an organization
+func (r *OrganizationRepository) AddInvitation(ctx context.Context, invitation *models.OrganizationInvitation) error {
+	if invitation.CreatedAt.IsZero() {
+		invitation.CreatedAt = time.Now()
+	}
+	return translateUniqueViolation(r.db.WithContext(ctx).Create(invitation).Error)
+}
+
+// GetInvitation retrieves the invitation of a user into an organization
+func (r *OrganizationRepository) GetInvitation(ctx context.Context, organizationID, userID uuid.UUID) (*models.OrganizationInvitation, error) {
+	var invitation models.OrganizationInvitation
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		First(&invitation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// ListInvitationsByUser returns the invitations of a user, oldest first
+func (r *OrganizationRepository) ListInvitationsByUser(ctx context.Context, userID uuid.UUID) ([]*models.OrganizationInvitation, error) {
+	var invitations []*models.OrganizationInvitation
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC, organization_id ASC").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation replaces an invitation with a membership in one transaction
+func (r *OrganizationRepository) AcceptInvitation(ctx context.Context, invitation *models.OrganizationInvitation) (*models.OrganizationMembership, error) {
+	membership := &models.OrganizationMembership{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         invitation.UserID,
+		Role:           invitation.Role,
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.OrganizationInvitation{}, "organization_id = ? AND user_id = ?", invitation.OrganizationID, invitation.UserID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return services.ErrNotFound
+		}
+		return addMember(tx, membership)
+	})
+	if err != nil {
+		return nil, translateUniqueViolation(err)
+	}
+	return membership, nil
+}
+
+// RemoveInvitation removes the invitation of a user into an organization
+func (r *OrganizationRepository) RemoveInvitation(ctx context.Context, organizationID, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.OrganizationInvitation{}, "organization_id = ? AND user_id = ?", organizationID, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
+
+// addMember stores a membership and makes its organization the user's
+// organization when they have none
+func addMember(tx *gorm.DB, membership *models.OrganizationMembership) error {
+	if membership.CreatedAt.IsZero() {
+		membership.CreatedAt = time.Now()
+	}
+	if err := tx.Create(membership).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.User{}).
+		Where("id = ? AND organization_id IS NULL", membership.UserID).
+		Update("organization_id", membership.OrganizationID).Error
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrganizationSettingsRepository implements repositories.OrganizationSettingsRepository using GORM
+type OrganizationSettingsRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationSettingsRepository creates a new postgres organization settings repository
+func NewOrganizationSettingsRepository(db *gorm.DB) repositories.OrganizationSettingsRepository {
+	return &OrganizationSettingsRepository{
+		db: db,
+	}
+}
+
+// Get retrieves the settings of an organization
+func (r *OrganizationSettingsRepository) Get(ctx context.Context, organizationID uuid.UUID) (*models.OrganizationSettings, error) {
+	var settings models.OrganizationSettings
+	err := r.db.WithContext(ctx).Where("organization_id = ?", organizationID).First(&settings).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Save creates or replaces the settings of an organization
+func (r *OrganizationSettingsRepository) Save(ctx context.Context, settings *models.OrganizationSettings) error {
+	now := time.Now()
+	if settings.CreatedAt.IsZero() {
+		settings.CreatedAt = now
+	}
+	settings.UpdatedAt = now
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "organization_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"password_policy", "access_token_ttl_seconds", "refresh_token_ttl_seconds",
+			"require_mfa", "max_sessions", "session_limit_action", "email_templates",
+			"access_policy", "security_summaries", "updated_at",
+		}),
+	}).Create(settings).Error
+}
+
+// Delete removes the settings of an organization
+func (r *OrganizationSettingsRepository) Delete(ctx context.Context, organizationID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.OrganizationSettings{}, "organization_id = ?", organizationID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// RegionRouting is a GORM plugin sending the queries of requests for data
+// homed in another region to that region's cluster. Writes for another
+// region are refused so that data is only ever changed in its home region.
+type RegionRouting struct {
+	region   string
+	clusters map[string]gorm.ConnPool
+}
+
+// NewRegionRouting creates a new region routing plugin for a deployment in
+// the given region, with the connection pools of the other regions' clusters
+func NewRegionRouting(region string, clusters map[string]gorm.ConnPool) *RegionRouting {
+	return &RegionRouting{region: region, clusters: clusters}
+}
+
+// Name returns the plugin name
+func (p *RegionRouting) Name() string {
+	return "region_routing"
+}
+
+// Initialize registers the routing callbacks. Writes are checked before
+// their default transaction begins on the local connection pool.
+func (p *RegionRouting) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:begin_transaction").Register("region_routing:create", p.refuseWrite); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:begin_transaction").Register("region_routing:update", p.refuseWrite); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:begin_transaction").Register("region_routing:delete", p.refuseWrite); err != nil {
+		return err
+	}
+	// Raw statements may change anything
+	if err := callback.Raw().Before("gorm:raw").Register("region_routing:raw", p.refuseWrite); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("region_routing:query", p.route); err != nil {
+		return err
+	}
+	return callback.Row().Before("gorm:row").Register("region_routing:row", p.route)
+}
+
+// remoteRegion returns the region of the statement's data when it is not
+// the deployment's own
+func (p *RegionRouting) remoteRegion(db *gorm.DB) (string, bool) {
+	if db.Statement.Context == nil {
+		return "", false
+	}
+	region := repositories.Region(db.Statement.Context)
+	if region == "" || region == p.region {
+		return "", false
+	}
+	return region, true
+}
+
+func (p *RegionRouting) refuseWrite(db *gorm.DB) {
+	if _, remote := p.remoteRegion(db); remote {
+		db.AddError(services.ErrCrossRegionWrite)
+	}
+}
+
+func (p *RegionRouting) route(db *gorm.DB) {
+	region, remote := p.remoteRegion(db)
+	if !remote {
+		return
+	}
+	// Transactions hold a local connection and are only opened for writes
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		db.AddError(services.ErrCrossRegionWrite)
+		return
+	}
+	pool, ok := p.clusters[region]
+	if !ok {
+		db.AddError(services.ErrRegionUnavailable)
+		return
+	}
+	db.Statement.ConnPool = pool
+}
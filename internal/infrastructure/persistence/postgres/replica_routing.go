@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultReplicaStaleness is how long reads stay on the primary after a
+// write when no staleness window is configured
+const defaultReplicaStaleness = time.Second
+
+// replicaReadKey marks the contexts of reads that replicas may serve
+type replicaReadKey struct{}
+
+// withReplicaRead marks a read that tolerates replication lag, so that it
+// may be served by a read replica
+func withReplicaRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadKey{}, true)
+}
+
+// ReplicaRouting is a GORM plugin sending reads that tolerate replication
+// lag to read replicas in turn. Writes and all other reads use the primary.
+// For a staleness window after every write, reads stay on the primary so
+// that callers see their own changes despite replication lag.
+type ReplicaRouting struct {
+	replicas  []gorm.ConnPool
+	staleness time.Duration
+	primary   gorm.ConnPool
+	next      atomic.Uint64
+	lastWrite atomic.Int64 // unix nanoseconds
+}
+
+// NewReplicaRouting creates a new replica routing plugin over the
+// connection pools of the replicas. A staleness of 0 uses one second.
+func NewReplicaRouting(replicas []gorm.ConnPool, staleness time.Duration) *ReplicaRouting {
+	if staleness <= 0 {
+		staleness = defaultReplicaStaleness
+	}
+	return &ReplicaRouting{replicas: replicas, staleness: staleness}
+}
+
+// Name returns the plugin name
+func (p *ReplicaRouting) Name() string {
+	return "replica_routing"
+}
+
+// Initialize registers the routing callbacks. Writes are recorded once they
+// have been committed, including failed ones, which may still have changed
+// rows before failing.
+func (p *ReplicaRouting) Initialize(db *gorm.DB) error {
+	p.primary = db.ConnPool
+	callback := db.Callback()
+	if err := callback.Create().After("gorm:commit_or_rollback_transaction").Register("replica_routing:create", p.recordWrite); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:commit_or_rollback_transaction").Register("replica_routing:update", p.recordWrite); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:commit_or_rollback_transaction").Register("replica_routing:delete", p.recordWrite); err != nil {
+		return err
+	}
+	if err := callback.Raw().After("gorm:raw").Register("replica_routing:raw", p.recordWrite); err != nil {
+		return err
+	}
+	return callback.Query().Before("gorm:query").Register("replica_routing:query", p.route)
+}
+
+func (p *ReplicaRouting) recordWrite(db *gorm.DB) {
+	p.lastWrite.Store(time.Now().UnixNano())
+}
+
+func (p *ReplicaRouting) route(db *gorm.DB) {
+	if len(p.replicas) == 0 || db.Statement.Context == nil {
+		return
+	}
+	if replicaRead, _ := db.Statement.Context.Value(replicaReadKey{}).(bool); !replicaRead {
+		return
+	}
+	// Transactions and reads routed to another region's cluster keep their
+	// connection pool
+	if db.Statement.ConnPool != p.primary {
+		return
+	}
+	if time.Since(time.Unix(0, p.lastWrite.Load())) < p.staleness {
+		return
+	}
+	db.Statement.ConnPool = p.replicas[p.next.Add(1)%uint64(len(p.replicas))]
+}
@@ -3,6 +3,8 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/mibrahim2344/identity-service/internal/domain/errors"
 	"github.com/mibrahim2344/identity-service/internal/domain/models"
@@ -50,10 +52,12 @@ func (r *UserRepository) GetByIdentifier(ctx context.Context, identifier string)
 		SELECT id, email, username, password_hash, first_name, last_name, role, 
 		       email_verified, created_at, updated_at, last_login_at, deleted_at
 		FROM users 
-		WHERE (email = $1 OR username = $1) AND deleted_at IS NULL
+		WHERE (LOWER(email) = $1 OR LOWER(username) = $1) AND deleted_at IS NULL
+		ORDER BY LOWER(email) = $1 DESC
 		LIMIT 1
 	`
-	
+	identifier = models.NormalizeIdentifier(identifier)
+
 	user := &models.User{}
 	err := r.db.QueryRowContext(ctx, query, identifier).Scan(
 		&user.ID,
@@ -92,8 +96,46 @@ func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// IdentifiersTaken reports whether a user has the email or the username
+func (r *UserRepository) IdentifiersTaken(ctx context.Context, email, username string) (bool, bool, error) {
+	query := `
+		SELECT COALESCE(BOOL_OR(LOWER(email) = $1), FALSE),
+		       COALESCE(BOOL_OR(LOWER(username) = $2), FALSE)
+		FROM users
+		WHERE (LOWER(email) = $1 OR LOWER(username) = $2) AND deleted_at IS NULL
+	`
+	var emailTaken, usernameTaken bool
+	err := r.db.QueryRowContext(ctx, query, models.NormalizeIdentifier(email), models.NormalizeIdentifier(username)).
+		Scan(&emailTaken, &usernameTaken)
+	return emailTaken, usernameTaken, err
+}
+
+// ListByRecoveryEmail retrieves the users with the given recovery email
+func (r *UserRepository) ListByRecoveryEmail(ctx context.Context, email string) ([]*models.User, error) {
+	// Implementation here
+	return nil, nil
+}
+
+// GetByIDIncludingDeleted retrieves a user by ID, including a soft-deleted one
+func (r *UserRepository) GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	// Implementation here
+	return nil, nil
+}
+
+// Purge permanently deletes a user by ID
+func (r *UserRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	// Implementation here
+	return nil
+}
+
 // List retrieves users with pagination
 func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]*models.User, error) {
 	// Implementation here
 	return nil, nil
 }
+
+// ListDeactivatedBefore retrieves users deactivated before the given time
+func (r *UserRepository) ListDeactivatedBefore(ctx context.Context, before time.Time, limit int) ([]*models.User, error) {
+	// Implementation here
+	return nil, nil
+}
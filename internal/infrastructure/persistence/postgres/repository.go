@@ -2,11 +2,46 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
 	"github.com/mibrahim2344/identity-service/internal/domain/models"
 	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Unique indexes on the identifiers of users that are not soft-deleted
+	usersEmailIndex    = "idx_users_email_active"
+	usersUsernameIndex = "idx_users_username_active"
+	// Unique index on the provider and subject of external identities
+	userIdentitiesIndex = "idx_user_identities_provider_subject"
+	// Unique index on the authenticator's IDs of passkeys
+	webAuthnCredentialsIndex = "idx_webauthn_credentials_credential_id"
+	// Primary key of roles, which are identified by name
+	rolesPrimaryKey = "roles_pkey"
+	// Primary key of organization memberships, one per organization and user
+	organizationMembershipsPrimaryKey = "organization_memberships_pkey"
+	// Primary key of SSO domains, one per connection and domain
+	ssoDomainsPrimaryKey = "sso_domains_pkey"
+	// Unique index on verified SSO domains, which one connection may own
+	ssoVerifiedDomainIndex = "idx_sso_domains_verified_domain"
+	// Primary key of organization domains, one per organization and domain
+	organizationDomainsPrimaryKey = "organization_domains_pkey"
+	// Unique index on verified organization domains, which one organization
+	// may own
+	organizationVerifiedDomainIndex = "idx_organization_domains_verified_domain"
+	// Primary key of organization invitations, one per organization and user
+	organizationInvitationsPrimaryKey = "organization_invitations_pkey"
+	// Primary key of signing keys, one per token type and version
+	signingKeysPrimaryKey = "signing_keys_pkey"
+
+	uniqueViolationCode = "23505"
 )
 
 type Repository struct {
@@ -22,23 +57,28 @@ func NewRepository(db *gorm.DB) repositories.UserRepository {
 
 // Create creates a new user
 func (r *Repository) Create(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	return translateUniqueViolation(r.db.WithContext(ctx).Create(user).Error)
 }
 
-// GetByID retrieves a user by their ID
+// GetByID retrieves a user by their ID. It may be served by a read
+// replica, like GetByEmail and List.
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
+	err := r.query(withReplicaRead(ctx)).Where("id = ?", id).First(&user).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.WrapError("GetByID", domainerrors.ErrUserNotFound)
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-// GetByEmail retrieves a user by their email
+// GetByEmail retrieves a user by their email, which matches
+// case-insensitively
 func (r *Repository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := r.query(withReplicaRead(ctx)).Where("LOWER(email) = ?", models.NormalizeIdentifier(email)).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -48,39 +88,174 @@ func (r *Repository) GetByEmail(ctx context.Context, email string) (*models.User
 // GetByUsername retrieves a user by their username
 func (r *Repository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
+	err := r.query(ctx).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-// GetByIdentifier retrieves a user by their email or username
+// GetByIdentifier retrieves a user by their email or username in a single
+// query. Identifiers match case-insensitively; should the identifier be one
+// user's email and another user's username, the email match wins.
 func (r *Repository) GetByIdentifier(ctx context.Context, identifier string) (*models.User, error) {
+	identifier = models.NormalizeIdentifier(identifier)
+
 	var user models.User
-	err := r.db.WithContext(ctx).Where("email = ? OR username = ?", identifier, identifier).First(&user).Error
+	err := r.query(ctx).
+		Where("LOWER(email) = ? OR LOWER(username) = ?", identifier, identifier).
+		Order(clause.Expr{SQL: "LOWER(email) = ? DESC", Vars: []interface{}{identifier}}).
+		First(&user).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.WrapError("GetByIdentifier", domainerrors.ErrUserNotFound)
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
+// IdentifiersTaken reports whether a user other than a soft-deleted one has
+// the email or the username. Both are checked in one query, so a concurrent
+// registration is either seen for both or for neither; the unique indexes
+// catch registrations committed after it.
+func (r *Repository) IdentifiersTaken(ctx context.Context, email, username string) (bool, bool, error) {
+	email, username = models.NormalizeIdentifier(email), models.NormalizeIdentifier(username)
+
+	var taken struct {
+		EmailTaken    bool
+		UsernameTaken bool
+	}
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Select("COALESCE(BOOL_OR(LOWER(email) = ?), FALSE) AS email_taken, "+
+			"COALESCE(BOOL_OR(LOWER(username) = ?), FALSE) AS username_taken", email, username).
+		Where("LOWER(email) = ? OR LOWER(username) = ?", email, username).
+		Scan(&taken).Error
+	if err != nil {
+		return false, false, err
+	}
+	return taken.EmailTaken, taken.UsernameTaken, nil
+}
+
+// ListByRecoveryEmail retrieves the users with the given recovery email,
+// matched case-insensitively
+func (r *Repository) ListByRecoveryEmail(ctx context.Context, email string) ([]*models.User, error) {
+	var users []*models.User
+	err := r.query(ctx).
+		Where("LOWER(recovery_email) = ?", models.NormalizeIdentifier(email)).
+		Order("created_at").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // Update updates a user
 func (r *Repository) Update(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+	return translateUniqueViolation(r.db.WithContext(ctx).Save(user).Error)
 }
 
 // Delete deletes a user
 func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.User{}, "id = ?", id).Error
+	return r.query(ctx).Delete(&models.User{}, "id = ?", id).Error
+}
+
+// GetByIDIncludingDeleted retrieves a user by their ID, including a soft-deleted one
+func (r *Repository) GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := r.query(ctx).Unscoped().Where("id = ?", id).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.WrapError("GetByIDIncludingDeleted", domainerrors.ErrUserNotFound)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Purge permanently deletes a user. The records referring to the user are
+// removed by their foreign keys' ON DELETE CASCADE.
+func (r *Repository) Purge(ctx context.Context, id uuid.UUID) error {
+	result := r.query(ctx).Unscoped().Delete(&models.User{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domainerrors.WrapError("Purge", domainerrors.ErrUserNotFound)
+	}
+	return nil
 }
 
 // List lists all users with pagination
 func (r *Repository) List(ctx context.Context, offset, limit int) ([]*models.User, error) {
 	var users []*models.User
-	err := r.db.WithContext(ctx).Offset(offset).Limit(limit).Find(&users).Error
+	err := r.query(withReplicaRead(ctx)).Order("created_at, id").Offset(offset).Limit(limit).Find(&users).Error
 	if err != nil {
 		return nil, err
 	}
 	return users, nil
 }
+
+// ListDeactivatedBefore retrieves up to limit users deactivated before the
+// given time, longest deactivated first
+func (r *Repository) ListDeactivatedBefore(ctx context.Context, before time.Time, limit int) ([]*models.User, error) {
+	var users []*models.User
+	err := r.query(ctx).
+		Where("status = ? AND deactivated_at < ?", models.UserStatusDeactivated, before).
+		Order("deactivated_at, id").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// query starts a query of users, restricted to the members of the
+// organization ctx is scoped to, if any
+func (r *Repository) query(ctx context.Context) *gorm.DB {
+	db := r.db.WithContext(ctx)
+	if organizationID, ok := repositories.OrganizationScope(ctx); ok {
+		db = db.Where("users.id IN (SELECT user_id FROM organization_memberships WHERE organization_id = ?)", organizationID)
+	}
+	return db
+}
+
+// translateUniqueViolation maps violations of the identifier unique indexes,
+// such as from concurrent registrations, to domain errors
+func translateUniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolationCode {
+		return err
+	}
+
+	switch pgErr.ConstraintName {
+	case usersEmailIndex:
+		return services.ErrEmailAlreadyExists
+	case usersUsernameIndex:
+		return services.ErrUsernameAlreadyExists
+	case userIdentitiesIndex:
+		return services.NewConflictError("external identity is already linked")
+	case webAuthnCredentialsIndex:
+		return services.NewConflictError("passkey is already registered")
+	case rolesPrimaryKey:
+		return services.NewConflictError("role already exists")
+	case organizationMembershipsPrimaryKey:
+		return services.NewConflictError("user is already a member of the organization")
+	case ssoDomainsPrimaryKey:
+		return services.NewConflictError("connection already claims the domain")
+	case ssoVerifiedDomainIndex:
+		return services.NewConflictError("domain is verified by another connection")
+	case organizationDomainsPrimaryKey:
+		return services.NewConflictError("organization already claims the domain")
+	case organizationVerifiedDomainIndex:
+		return services.NewConflictError("domain is verified by another organization")
+	case organizationInvitationsPrimaryKey:
+		return services.NewConflictError("user is already invited to the organization")
+	case signingKeysPrimaryKey:
+		return services.NewConflictError("signing key version already exists")
+	default:
+		return err
+	}
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// RoleRepository implements repositories.RoleRepository using GORM
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository creates a new postgres role repository
+func NewRoleRepository(db *gorm.DB) repositories.RoleRepository {
+	return &RoleRepository{
+		db: db,
+	}
+}
+
+// Create stores a new role together with its permissions
+func (r *RoleRepository) Create(ctx context.Context, role *models.RoleDefinition) error {
+	now := time.Now()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+	return translateUniqueViolation(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+		return insertRolePermissions(tx, role.Name, role.Permissions)
+	}))
+}
+
+// Delete removes a role and its permissions
+func (r *RoleRepository) Delete(ctx context.Context, name models.Role) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.RolePermission{}, "role = ?", name).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.RoleDefinition{}, "name = ?", name)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return services.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// GetByName retrieves a role with its permissions
+func (r *RoleRepository) GetByName(ctx context.Context, name models.Role) (*models.RoleDefinition, error) {
+	var role models.RoleDefinition
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+
+	var grants []models.RolePermission
+	if err := r.db.WithContext(ctx).Where("role = ?", name).Order("permission ASC").Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	role.Permissions = make([]models.Permission, 0, len(grants))
+	for _, grant := range grants {
+		role.Permissions = append(role.Permissions, grant.Permission)
+	}
+	return &role, nil
+}
+
+// List returns all roles with their permissions, ordered by name
+func (r *RoleRepository) List(ctx context.Context) ([]*models.RoleDefinition, error) {
+	var roles []*models.RoleDefinition
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	var grants []models.RolePermission
+	if err := r.db.WithContext(ctx).Order("permission ASC").Find(&grants).Error; err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[models.Role][]models.Permission)
+	for _, grant := range grants {
+		permissions[grant.Role] = append(permissions[grant.Role], grant.Permission)
+	}
+	for _, role := range roles {
+		role.Permissions = permissions[role.Name]
+		if role.Permissions == nil {
+			role.Permissions = []models.Permission{}
+		}
+	}
+	return roles, nil
+}
+
+// SetPermissions replaces the permissions of a role
+func (r *RoleRepository) SetPermissions(ctx context.Context, name models.Role, permissions []models.Permission) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RoleDefinition{}).Where("name = ?", name).Update("updated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return services.ErrNotFound
+		}
+		if err := tx.Delete(&models.RolePermission{}, "role = ?", name).Error; err != nil {
+			return err
+		}
+		return insertRolePermissions(tx, name, permissions)
+	})
+}
+
+// CountUsers returns how many users, including deleted ones, hold a role
+func (r *RoleRepository) CountUsers(ctx context.Context, name models.Role) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
+
+// insertRolePermissions grants a role the given permissions, in a stable order
+func insertRolePermissions(tx *gorm.DB, name models.Role, permissions []models.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	grants := make([]models.RolePermission, 0, len(permissions))
+	for _, permission := range permissions {
+		grants = append(grants, models.RolePermission{Role: name, Permission: permission})
+	}
+	sort.Slice(grants, func(i, j int) bool {
+		return grants[i].Permission < grants[j].Permission
+	})
+	return tx.Create(&grants).Error
+}
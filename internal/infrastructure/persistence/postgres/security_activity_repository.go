@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+// deviceKey identifies a device by its explicit ID, falling back to the user agent
+const deviceKey = "COALESCE(NULLIF(device_id, ''), user_agent)"
+
+// SecurityActivityRepository implements repositories.SecurityActivityRepository using GORM
+type SecurityActivityRepository struct {
+	db *gorm.DB
+}
+
+// NewSecurityActivityRepository creates a new postgres security activity repository
+func NewSecurityActivityRepository(db *gorm.DB) repositories.SecurityActivityRepository {
+	return &SecurityActivityRepository{
+		db: db,
+	}
+}
+
+// Create records a security activity
+func (r *SecurityActivityRepository) Create(ctx context.Context, activity *models.SecurityActivity) error {
+	return r.db.WithContext(ctx).Create(activity).Error
+}
+
+// Summarize aggregates a user's activity between from and to. Sessions
+// started since sessionsSince are counted as active.
+func (r *SecurityActivityRepository) Summarize(ctx context.Context, userID uuid.UUID, from, to, sessionsSince time.Time) (*models.SecuritySummary, error) {
+	db := r.db.WithContext(ctx).Model(&models.SecurityActivity{})
+	summary := &models.SecuritySummary{
+		UserID:      userID,
+		PeriodStart: from,
+		PeriodEnd:   to,
+	}
+
+	var passwordChanges int64
+	err := db.Session(&gorm.Session{}).
+		Where("user_id = ? AND type = ? AND created_at >= ? AND created_at < ?",
+			userID, models.SecurityActivityPasswordChange, from, to).
+		Count(&passwordChanges).Error
+	if err != nil {
+		return nil, err
+	}
+	summary.PasswordChanges = int(passwordChanges)
+
+	// Devices first seen during the period
+	seenBefore := r.db.Model(&models.SecurityActivity{}).
+		Select(deviceKey).
+		Where("user_id = ? AND type = ? AND created_at < ? AND "+deviceKey+" IS NOT NULL",
+			userID, models.SecurityActivityLogin, from)
+	var newDevices int64
+	err = db.Session(&gorm.Session{}).
+		Distinct(deviceKey).
+		Where("user_id = ? AND type = ? AND created_at >= ? AND created_at < ?",
+			userID, models.SecurityActivityLogin, from, to).
+		Where(deviceKey+" IS NOT NULL AND "+deviceKey+" NOT IN (?)", seenBefore).
+		Count(&newDevices).Error
+	if err != nil {
+		return nil, err
+	}
+	summary.NewDevices = int(newDevices)
+
+	var activeSessions int64
+	err = db.Session(&gorm.Session{}).
+		Distinct("session_id").
+		Where("user_id = ? AND type = ? AND created_at >= ? AND session_id <> ''",
+			userID, models.SecurityActivityLogin, sessionsSince).
+		Count(&activeSessions).Error
+	if err != nil {
+		return nil, err
+	}
+	summary.ActiveSessions = int(activeSessions)
+
+	return summary, nil
+}
+
+// LoginHistory reports whether the user signed in, or confirmed a login,
+// from the device and country before
+func (r *SecurityActivityRepository) LoginHistory(ctx context.Context, userID uuid.UUID, deviceID, userAgent, country string) (*models.LoginHistory, error) {
+	logins := func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&models.SecurityActivity{}).
+			Where("user_id = ? AND type IN ?", userID,
+				[]models.SecurityActivityType{models.SecurityActivityLogin, models.SecurityActivityLoginConfirmed})
+	}
+	history := &models.LoginHistory{KnownDevice: true, KnownCountry: true}
+
+	var count int64
+	if err := logins().Limit(1).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	history.HasLogins = count > 0
+	if !history.HasLogins {
+		return history, nil
+	}
+
+	if device := deviceID; device != "" || userAgent != "" {
+		if device == "" {
+			device = userAgent
+		}
+		if err := logins().Where(deviceKey+" = ?", device).Limit(1).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		history.KnownDevice = count > 0
+	}
+	if country != "" {
+		// Until a login with a country was recorded, e.g. right after
+		// countries started being tracked, no country is new
+		if err := logins().Where("country <> ''").Limit(1).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			if err := logins().Where("country = ?", country).Limit(1).Count(&count).Error; err != nil {
+				return nil, err
+			}
+			history.KnownCountry = count > 0
+		}
+	}
+
+	return history, nil
+}
+
+// RecentRegistrations counts the registrations since the given time from
+// the IP address and the device ID
+func (r *SecurityActivityRepository) RecentRegistrations(ctx context.Context, ipAddress, deviceID string, since time.Time) (int, int, error) {
+	count := func(column, value string) (int, error) {
+		if value == "" {
+			return 0, nil
+		}
+		var count int64
+		err := r.db.WithContext(ctx).Model(&models.SecurityActivity{}).
+			Where("type = ? AND created_at >= ? AND "+column+" = ?", models.SecurityActivityRegistration, since, value).
+			Count(&count).Error
+		return int(count), err
+	}
+
+	fromIP, err := count("ip_address", ipAddress)
+	if err != nil {
+		return 0, 0, err
+	}
+	fromDevice, err := count("device_id", deviceID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return fromIP, fromDevice, nil
+}
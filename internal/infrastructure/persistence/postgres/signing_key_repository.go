@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+// SigningKeyRepository implements repositories.SigningKeyRepository using GORM
+type SigningKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewSigningKeyRepository creates a new postgres signing key repository
+func NewSigningKeyRepository(db *gorm.DB) repositories.SigningKeyRepository {
+	return &SigningKeyRepository{
+		db: db,
+	}
+}
+
+// Create stores a new version of a signing key
+func (r *SigningKeyRepository) Create(ctx context.Context, key *models.SigningKey) error {
+	return translateUniqueViolation(r.db.WithContext(ctx).Create(key).Error)
+}
+
+// ListLatest returns up to limit versions of the signing key of a token
+// type, newest first
+func (r *SigningKeyRepository) ListLatest(ctx context.Context, tokenType string, limit int) ([]*models.SigningKey, error) {
+	var keys []*models.SigningKey
+	err := r.db.WithContext(ctx).
+		Where("token_type = ?", tokenType).
+		Order("version DESC").
+		Limit(limit).
+		Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
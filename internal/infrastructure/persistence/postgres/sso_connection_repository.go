@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// SSOConnectionRepository implements repositories.SSOConnectionRepository using GORM
+type SSOConnectionRepository struct {
+	db *gorm.DB
+}
+
+// NewSSOConnectionRepository creates a new postgres SSO connection repository
+func NewSSOConnectionRepository(db *gorm.DB) repositories.SSOConnectionRepository {
+	return &SSOConnectionRepository{
+		db: db,
+	}
+}
+
+// Create stores a new connection
+func (r *SSOConnectionRepository) Create(ctx context.Context, connection *models.SSOConnection) error {
+	if connection.ID == uuid.Nil {
+		connection.ID = models.NewID()
+	}
+	now := time.Now()
+	connection.CreatedAt = now
+	connection.UpdatedAt = now
+	return r.db.WithContext(ctx).Create(connection).Error
+}
+
+// GetByID retrieves a connection
+func (r *SSOConnectionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.SSOConnection, error) {
+	var connection models.SSOConnection
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&connection).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &connection, nil
+}
+
+// ListByOrganization returns the connections of an organization, ordered by name
+func (r *SSOConnectionRepository) ListByOrganization(ctx context.Context, organizationID uuid.UUID) ([]*models.SSOConnection, error) {
+	var connections []*models.SSOConnection
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ?", organizationID).
+		Order("name ASC, id ASC").
+		Find(&connections).Error
+	if err != nil {
+		return nil, err
+	}
+	return connections, nil
+}
+
+// Update saves changes to a connection
+func (r *SSOConnectionRepository) Update(ctx context.Context, connection *models.SSOConnection) error {
+	connection.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Save(connection).Error
+}
+
+// Delete removes a connection; its domains are removed by the foreign key
+func (r *SSOConnectionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.SSOConnection{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
+
+// AddDomain stores a domain claimed by a connection
+func (r *SSOConnectionRepository) AddDomain(ctx context.Context, domain *models.SSODomain) error {
+	if domain.CreatedAt.IsZero() {
+		domain.CreatedAt = time.Now()
+	}
+	return translateUniqueViolation(r.db.WithContext(ctx).Create(domain).Error)
+}
+
+// ListDomains returns the domains claimed by a connection, ordered by domain
+func (r *SSOConnectionRepository) ListDomains(ctx context.Context, connectionID uuid.UUID) ([]*models.SSODomain, error) {
+	var domains []*models.SSODomain
+	err := r.db.WithContext(ctx).
+		Where("connection_id = ?", connectionID).
+		Order("domain ASC").
+		Find(&domains).Error
+	if err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+// VerifyDomain marks a domain of a connection as verified
+func (r *SSOConnectionRepository) VerifyDomain(ctx context.Context, connectionID uuid.UUID, domain string, verifiedAt time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.SSODomain{}).
+		Where("connection_id = ? AND domain = ?", connectionID, domain).
+		Update("verified_at", verifiedAt)
+	if result.Error != nil {
+		return translateUniqueViolation(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
+
+// RemoveDomain removes a domain from a connection
+func (r *SSOConnectionRepository) RemoveDomain(ctx context.Context, connectionID uuid.UUID, domain string) error {
+	result := r.db.WithContext(ctx).Delete(&models.SSODomain{}, "connection_id = ? AND domain = ?", connectionID, domain)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
+
+// GetByVerifiedDomain retrieves the connection that verified a domain
+func (r *SSOConnectionRepository) GetByVerifiedDomain(ctx context.Context, domain string) (*models.SSOConnection, error) {
+	var connection models.SSOConnection
+	err := r.db.WithContext(ctx).
+		Where("id = (SELECT connection_id FROM sso_domains WHERE domain = ? AND verified_at IS NOT NULL)", domain).
+		First(&connection).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &connection, nil
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const queryCancelKey = "timeouts:cancel"
+
+// TimeoutConfig bounds the duration of queries per operation class. The
+// deadline of the caller's context still applies when it is earlier.
+type TimeoutConfig struct {
+	Read  time.Duration // queries; 0 disables
+	Write time.Duration // creates, updates, deletes and raw statements; 0 disables
+}
+
+// QueryTimeouts is a GORM plugin deriving a bounded context for every query
+type QueryTimeouts struct {
+	config TimeoutConfig
+}
+
+// NewQueryTimeouts creates a new query timeouts plugin
+func NewQueryTimeouts(config TimeoutConfig) *QueryTimeouts {
+	return &QueryTimeouts{config: config}
+}
+
+// Name returns the plugin name
+func (p *QueryTimeouts) Name() string {
+	return "query_timeouts"
+}
+
+// Initialize registers the timeout callbacks around GORM operations. The
+// context is cancelled only after the default transaction has been committed,
+// since cancelling it rolls the transaction back. Row operations are left
+// alone because their result is scanned after the callbacks have run.
+func (p *QueryTimeouts) Initialize(db *gorm.DB) error {
+	type register func(name string, fn func(*gorm.DB)) error
+
+	callback := db.Callback()
+	operations := []struct {
+		name    string
+		timeout time.Duration
+		before  register
+		after   register
+	}{
+		{"create", p.config.Write, callback.Create().Before("gorm:begin_transaction").Register, callback.Create().After("gorm:commit_or_rollback_transaction").Register},
+		{"query", p.config.Read, callback.Query().Before("gorm:query").Register, callback.Query().After("gorm:query").Register},
+		{"update", p.config.Write, callback.Update().Before("gorm:begin_transaction").Register, callback.Update().After("gorm:commit_or_rollback_transaction").Register},
+		{"delete", p.config.Write, callback.Delete().Before("gorm:begin_transaction").Register, callback.Delete().After("gorm:commit_or_rollback_transaction").Register},
+		{"raw", p.config.Write, callback.Raw().Before("gorm:raw").Register, callback.Raw().After("gorm:raw").Register},
+	}
+
+	for _, op := range operations {
+		if op.timeout <= 0 {
+			continue
+		}
+		if err := op.before("timeouts:before_"+op.name, p.before(op.timeout)); err != nil {
+			return err
+		}
+		if err := op.after("timeouts:after_"+op.name, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *QueryTimeouts) before(timeout time.Duration) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		db.Statement.Context = ctx
+		db.InstanceSet(queryCancelKey, cancel)
+	}
+}
+
+func (p *QueryTimeouts) after(db *gorm.DB) {
+	if value, ok := db.InstanceGet(queryCancelKey); ok {
+		if cancel, ok := value.(context.CancelFunc); ok {
+			cancel()
+		}
+	}
+}
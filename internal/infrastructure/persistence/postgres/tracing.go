@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	querySpanKey = "tracing:span"
+
+	tracerName = "github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/postgres"
+)
+
+// QueryTracing is a GORM plugin recording every query as a client span of
+// the trace in the statement's context
+type QueryTracing struct {
+	tracer trace.Tracer
+}
+
+// NewQueryTracing creates a new query tracing plugin using the global
+// tracer provider
+func NewQueryTracing() *QueryTracing {
+	return &QueryTracing{tracer: otel.Tracer(tracerName)}
+}
+
+// Name returns the plugin name
+func (p *QueryTracing) Name() string {
+	return "query_tracing"
+}
+
+// Initialize registers the tracing callbacks around every GORM operation
+func (p *QueryTracing) Initialize(db *gorm.DB) error {
+	type register func(name string, fn func(*gorm.DB)) error
+
+	callback := db.Callback()
+	operations := []struct {
+		name   string
+		before register
+		after  register
+	}{
+		{"create", callback.Create().Before("gorm:create").Register, callback.Create().After("gorm:create").Register},
+		{"query", callback.Query().Before("gorm:query").Register, callback.Query().After("gorm:query").Register},
+		{"update", callback.Update().Before("gorm:update").Register, callback.Update().After("gorm:update").Register},
+		{"delete", callback.Delete().Before("gorm:delete").Register, callback.Delete().After("gorm:delete").Register},
+		{"row", callback.Row().Before("gorm:row").Register, callback.Row().After("gorm:row").Register},
+		{"raw", callback.Raw().Before("gorm:raw").Register, callback.Raw().After("gorm:raw").Register},
+	}
+
+	for _, op := range operations {
+		if err := op.before("tracing:before_"+op.name, p.before(op.name)); err != nil {
+			return err
+		}
+		if err := op.after("tracing:after_"+op.name, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *QueryTracing) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		ctx, span := p.tracer.Start(db.Statement.Context, "db."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "postgresql"),
+				attribute.String("db.operation", operation),
+			))
+		db.Statement.Context = ctx
+		db.InstanceSet(querySpanKey, span)
+	}
+}
+
+// after ends the span. Only the statement is recorded, never its bound values.
+func (p *QueryTracing) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(querySpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
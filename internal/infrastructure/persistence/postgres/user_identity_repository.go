@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// UserIdentityRepository implements repositories.UserIdentityRepository using GORM
+type UserIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewUserIdentityRepository creates a new postgres user identity repository
+func NewUserIdentityRepository(db *gorm.DB) repositories.UserIdentityRepository {
+	return &UserIdentityRepository{
+		db: db,
+	}
+}
+
+// Create links an external identity to a user
+func (r *UserIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	return translateUniqueViolation(r.db.WithContext(ctx).Create(identity).Error)
+}
+
+// GetByProviderSubject retrieves the link of a provider's user
+func (r *UserIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// ListByUser retrieves the external identities linked to a user
+func (r *UserIdentityRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UserIdentity, error) {
+	var identities []*models.UserIdentity
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&identities).Error
+	if err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// Delete removes an identity of a user
+func (r *UserIdentityRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&models.UserIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
+
+// RecordLogin sets the last login time of an identity to now
+func (r *UserIdentityRepository) RecordLogin(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Model(&models.UserIdentity{}).
+		Where("id = ?", id).
+		Update("last_login_at", time.Now()).Error
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+// UsernameHistoryRepository implements repositories.UsernameHistoryRepository using GORM
+type UsernameHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewUsernameHistoryRepository creates a new postgres username history repository
+func NewUsernameHistoryRepository(db *gorm.DB) repositories.UsernameHistoryRepository {
+	return &UsernameHistoryRepository{
+		db: db,
+	}
+}
+
+// Create records a username change
+func (r *UsernameHistoryRepository) Create(ctx context.Context, change *models.UsernameChange) error {
+	return r.db.WithContext(ctx).Create(change).Error
+}
+
+// ListByUser retrieves a user's username changes, most recent first
+func (r *UsernameHistoryRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UsernameChange, error) {
+	var changes []*models.UsernameChange
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("changed_at DESC").
+		Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// IsReserved checks whether a username is still reserved by a user other than the given one
+func (r *UsernameHistoryRepository) IsReserved(ctx context.Context, username string, excludeUserID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.UsernameChange{}).
+		Where("LOWER(old_username) = LOWER(?) AND user_id <> ? AND reserved_until > ?", username, excludeUserID, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ReleaseReservations ends every username reservation held by a user
+func (r *UsernameHistoryRepository) ReleaseReservations(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).
+		Model(&models.UsernameChange{}).
+		Where("user_id = ? AND reserved_until > ?", userID, now).
+		Update("reserved_until", now).Error
+}
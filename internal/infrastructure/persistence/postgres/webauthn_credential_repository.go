@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"gorm.io/gorm"
+)
+
+// WebAuthnCredentialRepository implements repositories.WebAuthnCredentialRepository using GORM
+type WebAuthnCredentialRepository struct {
+	db *gorm.DB
+}
+
+// NewWebAuthnCredentialRepository creates a new postgres passkey repository
+func NewWebAuthnCredentialRepository(db *gorm.DB) repositories.WebAuthnCredentialRepository {
+	return &WebAuthnCredentialRepository{
+		db: db,
+	}
+}
+
+// Create stores a new credential
+func (r *WebAuthnCredentialRepository) Create(ctx context.Context, credential *models.WebAuthnCredential) error {
+	if credential.ID == uuid.Nil {
+		credential.ID = models.NewID()
+	}
+	credential.CreatedAt = time.Now()
+	return translateUniqueViolation(r.db.WithContext(ctx).Create(credential).Error)
+}
+
+// GetByCredentialID retrieves a credential by the authenticator's ID of it
+func (r *WebAuthnCredentialRepository) GetByCredentialID(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	err := r.db.WithContext(ctx).Where("credential_id = ?", credentialID).First(&credential).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, services.ErrNotFound
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// ListByUser returns the credentials of a user, oldest first
+func (r *WebAuthnCredentialRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.WebAuthnCredential, error) {
+	var credentials []*models.WebAuthnCredential
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&credentials).Error
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// RecordUse stores the signature counter and time of an assertion
+func (r *WebAuthnCredentialRepository) RecordUse(ctx context.Context, id uuid.UUID, signCount int64, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.WebAuthnCredential{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"sign_count": signCount, "last_used_at": at}).Error
+}
+
+// Delete removes a credential of a user
+func (r *WebAuthnCredentialRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.WebAuthnCredential{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return services.ErrNotFound
+	}
+	return nil
+}
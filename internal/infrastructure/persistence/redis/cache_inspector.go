@@ -0,0 +1,121 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/redis/go-redis/v9"
+)
+
+// scanBatchSize is how many keys a single SCAN call is asked to visit
+const scanBatchSize = 1000
+
+// Namespaces of revocation keys written by the token service
+const (
+	revokedTokenNamespace   = "revoked_token"
+	revokedSessionNamespace = "revoked_session"
+)
+
+// CacheInspector implements the domain.CacheInspector interface using Redis.
+// It walks the keyspace with SCAN so that inspecting a large cache does not
+// block other clients.
+type CacheInspector struct {
+	client *redis.Client
+}
+
+// NewCacheInspector creates a new Redis cache inspector
+func NewCacheInspector(client *redis.Client) services.CacheInspector {
+	return &CacheInspector{client: client}
+}
+
+// Stats counts the keys of each namespace, scanning up to limit keys; a
+// limit of 0 or less scans every key
+func (i *CacheInspector) Stats(ctx context.Context, limit int) (*services.CacheStats, error) {
+	size, err := i.client.DBSize(ctx).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache size: %w", err)
+	}
+
+	stats := &services.CacheStats{
+		Keys:       size,
+		Namespaces: make(map[string]int64),
+	}
+
+	var cursor uint64
+	for {
+		var keys []string
+		keys, cursor, err = i.client.Scan(ctx, cursor, "*", scanBatchSize).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cache keys: %w", err)
+		}
+		for _, key := range keys {
+			namespace, _, _ := strings.Cut(key, ":")
+			stats.Namespaces[namespace]++
+		}
+		stats.Scanned += int64(len(keys))
+		if cursor == 0 {
+			break
+		}
+		if limit > 0 && stats.Scanned >= int64(limit) {
+			stats.Truncated = true
+			break
+		}
+	}
+	stats.RevokedTokens = stats.Namespaces[revokedTokenNamespace]
+	stats.RevokedSessions = stats.Namespaces[revokedSessionNamespace]
+
+	info, err := i.client.Info(ctx, "stats").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache stats: %w", err)
+	}
+	stats.Hits, stats.Misses = keyspaceHits(info)
+
+	return stats, nil
+}
+
+// DeleteMatching deletes the keys matching a glob pattern in batches, so
+// only matching keys are ever removed
+func (i *CacheInspector) DeleteMatching(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := i.client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to scan cache keys: %w", err)
+		}
+		if len(keys) > 0 {
+			n, err := i.client.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return deleted, fmt.Errorf("failed to delete cache keys: %w", err)
+			}
+			deleted += n
+		}
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
+
+// keyspaceHits reads the keyspace hits and misses from the stats section
+// of INFO
+func keyspaceHits(info string) (hits, misses int64) {
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		switch name {
+		case "keyspace_hits":
+			hits, _ = strconv.ParseInt(value, 10, 64)
+		case "keyspace_misses":
+			misses, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return hits, misses
+}
@@ -86,6 +86,25 @@ func (s *CacheService) SetNX(ctx context.Context, key string, value interface{},
 	return success, nil
 }
 
+// incrScript increments a counter and sets its expiration when it is new,
+// in one step so that a counter never outlives its window
+var incrScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 and tonumber(ARGV[1]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// Incr atomically increments a counter and returns its new value
+func (s *CacheService) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	count, err := incrScript.Run(ctx, s.client, []string{key}, expiration.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment cache value: %w", err)
+	}
+	return count, nil
+}
+
 // GetWithTTL retrieves a value and its remaining TTL from the cache
 func (s *CacheService) GetWithTTL(ctx context.Context, key string, dest interface{}) (time.Duration, error) {
 	pipe := s.client.Pipeline()
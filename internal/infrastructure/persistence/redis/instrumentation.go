@@ -0,0 +1,113 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/redis/go-redis/v9"
+)
+
+// CommandInstrumentation is a go-redis hook recording command latency and
+// errors
+type CommandInstrumentation struct {
+	metricsService services.MetricsService
+}
+
+var _ redis.Hook = (*CommandInstrumentation)(nil)
+
+// NewCommandInstrumentation creates a new command instrumentation hook
+func NewCommandInstrumentation(metricsService services.MetricsService) *CommandInstrumentation {
+	return &CommandInstrumentation{
+		metricsService: metricsService,
+	}
+}
+
+// DialHook records failed connection attempts
+func (h *CommandInstrumentation) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			h.metricsService.IncrementCounter("redis_dial_errors_total", map[string]string{})
+		}
+		return conn, err
+	}
+}
+
+// ProcessHook records the latency and outcome of a single command
+func (h *CommandInstrumentation) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.record(strings.ToLower(cmd.Name()), time.Since(start), err)
+		return err
+	}
+}
+
+// ProcessPipelineHook records the latency and outcome of a pipeline as a whole
+func (h *CommandInstrumentation) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.record("pipeline", time.Since(start), err)
+		return err
+	}
+}
+
+func (h *CommandInstrumentation) record(command string, duration time.Duration, err error) {
+	labels := map[string]string{"command": command}
+	h.metricsService.ObserveHistogram("redis_command_duration_seconds", duration.Seconds(), labels)
+
+	// A missing key is a normal cache miss, not a Redis failure
+	if err != nil && !errors.Is(err, redis.Nil) {
+		h.metricsService.IncrementCounter("redis_command_errors_total", labels)
+	}
+}
+
+// PoolStatsCollector periodically exports the connection pool statistics of
+// a Redis client
+type PoolStatsCollector struct {
+	client         *redis.Client
+	metricsService services.MetricsService
+}
+
+// NewPoolStatsCollector creates a new connection pool statistics collector
+func NewPoolStatsCollector(client *redis.Client, metricsService services.MetricsService) *PoolStatsCollector {
+	return &PoolStatsCollector{
+		client:         client,
+		metricsService: metricsService,
+	}
+}
+
+// Start exports the pool statistics every interval until ctx is cancelled
+func (c *PoolStatsCollector) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.Collect()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect exports the current pool statistics. Hits, misses and timeouts are
+// cumulative since the client was created.
+func (c *PoolStatsCollector) Collect() {
+	stats := c.client.PoolStats()
+	labels := map[string]string{}
+
+	c.metricsService.ObserveValue("redis_pool_hits", float64(stats.Hits), labels)
+	c.metricsService.ObserveValue("redis_pool_misses", float64(stats.Misses), labels)
+	c.metricsService.ObserveValue("redis_pool_timeouts", float64(stats.Timeouts), labels)
+	c.metricsService.ObserveValue("redis_pool_total_connections", float64(stats.TotalConns), labels)
+	c.metricsService.ObserveValue("redis_pool_idle_connections", float64(stats.IdleConns), labels)
+	c.metricsService.ObserveValue("redis_pool_stale_connections", float64(stats.StaleConns), labels)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/tlsutil"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -11,16 +12,37 @@ import (
 type Config struct {
 	Host     string
 	Port     int
+	Username string // ACL user, empty for the default user
 	Password string
 	DB       int
+	TLS      tlsutil.Config
+}
+
+// NewOptions builds the go-redis client options for the given configuration
+func NewOptions(cfg Config) (*redis.Options, error) {
+	tlsConfig, err := tlsutil.NewClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure redis TLS: %w", err)
+	}
+
+	return &redis.Options{
+		Addr:      fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Username:  cfg.Username,
+		Password:  cfg.Password,
+		DB:        cfg.DB,
+		TLSConfig: tlsConfig,
+		// Honor the deadlines of request contexts instead of only the
+		// socket read and write timeouts
+		ContextTimeoutEnabled: true,
+	}, nil
 }
 
 // NewClient creates a new Redis client
 func NewClient(cfg Config) (*redis.Client, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
+	options, err := NewOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(options)
 	return client, client.Ping(context.Background()).Err()
 }
@@ -0,0 +1,161 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/redis/go-redis/v9"
+)
+
+// pruneExpiredSessions removes the sessions of KEYS[1] and KEYS[2] whose
+// expiry lies before ARGV[1]. It is shared by the session scripts.
+const pruneExpiredSessions = `
+local now = tonumber(ARGV[1])
+local expiries = redis.call('HGETALL', KEYS[2])
+for i = 1, #expiries, 2 do
+	if tonumber(expiries[i + 1]) <= now then
+		redis.call('ZREM', KEYS[1], expiries[i])
+		redis.call('HDEL', KEYS[2], expiries[i])
+	end
+end
+`
+
+// keepSessions keeps KEYS[1] and KEYS[2] until the session expiring at
+// ARGV[3] has expired
+const keepSessions = `
+local remaining = tonumber(ARGV[3]) - now
+if redis.call('PTTL', KEYS[1]) < remaining then
+	redis.call('PEXPIRE', KEYS[1], remaining)
+	redis.call('PEXPIRE', KEYS[2], remaining)
+end
+`
+
+// startSessionScript records session ARGV[2] expiring at ARGV[3], created at
+// ARGV[4], within the limit ARGV[5], evicting the oldest sessions when
+// ARGV[6] is 1. It returns 0 when the limit denies the session and 1
+// followed by the evicted session IDs otherwise.
+var startSessionScript = redis.NewScript(pruneExpiredSessions + `
+local limit = tonumber(ARGV[5])
+local evicted = {}
+if limit > 0 then
+	local excess = redis.call('ZCARD', KEYS[1]) - limit + 1
+	if excess > 0 then
+		if ARGV[6] ~= '1' then
+			return {0}
+		end
+		evicted = redis.call('ZRANGE', KEYS[1], 0, excess - 1)
+		for _, id in ipairs(evicted) do
+			redis.call('ZREM', KEYS[1], id)
+			redis.call('HDEL', KEYS[2], id)
+		end
+	end
+end
+redis.call('ZADD', KEYS[1], ARGV[4], ARGV[2])
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[3])
+` + keepSessions + `
+return {1, unpack(evicted)}
+`)
+
+// extendSessionScript moves the expiry of active session ARGV[2] to ARGV[3].
+// It returns 0 when the session is not active.
+var extendSessionScript = redis.NewScript(pruneExpiredSessions + `
+if not redis.call('ZSCORE', KEYS[1], ARGV[2]) then
+	return 0
+end
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[3])
+` + keepSessions + `
+return 1
+`)
+
+// SessionRepository implements repositories.SessionRepository with a sorted
+// set of the session IDs of each user, ordered by creation, and a hash of
+// their expiries. Both keys share a hash slot so the scripts run on Redis
+// Cluster.
+type SessionRepository struct {
+	client *redis.Client
+	clock  services.Clock // decides which sessions have expired
+}
+
+var _ repositories.SessionRepository = (*SessionRepository)(nil)
+
+// NewSessionRepository creates a new Redis session repository
+func NewSessionRepository(client *redis.Client, clock services.Clock) *SessionRepository {
+	return &SessionRepository{client: client, clock: clock}
+}
+
+func sessionKeys(userID uuid.UUID) []string {
+	return []string{
+		fmt.Sprintf("user_sessions:{%s}", userID),
+		fmt.Sprintf("user_session_expiries:{%s}", userID),
+	}
+}
+
+// Start records a session within the limit of its user
+func (r *SessionRepository) Start(ctx context.Context, session *models.Session, limit int, evictOldest bool) (bool, []string, error) {
+	evict := "0"
+	if evictOldest {
+		evict = "1"
+	}
+	result, err := startSessionScript.Run(ctx, r.client, sessionKeys(session.UserID),
+		r.clock.Now().UnixMilli(),
+		session.ID,
+		session.ExpiresAt.UnixMilli(),
+		session.CreatedAt.UnixMilli(),
+		limit,
+		evict,
+	).Slice()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to start session: %w", err)
+	}
+
+	if started, _ := result[0].(int64); started == 0 {
+		return false, nil, nil
+	}
+	evicted := make([]string, 0, len(result)-1)
+	for _, id := range result[1:] {
+		if sessionID, ok := id.(string); ok {
+			evicted = append(evicted, sessionID)
+		}
+	}
+	return true, evicted, nil
+}
+
+// Extend moves the expiry of an active session
+func (r *SessionRepository) Extend(ctx context.Context, userID uuid.UUID, sessionID string, expiresAt time.Time) (bool, error) {
+	extended, err := extendSessionScript.Run(ctx, r.client, sessionKeys(userID),
+		r.clock.Now().UnixMilli(),
+		sessionID,
+		expiresAt.UnixMilli(),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to extend session: %w", err)
+	}
+	return extended == 1, nil
+}
+
+// End ends a session
+func (r *SessionRepository) End(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	keys := sessionKeys(userID)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, keys[0], sessionID)
+		pipe.HDel(ctx, keys[1], sessionID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+	return nil
+}
+
+// EndAll ends every session of a user
+func (r *SessionRepository) EndAll(ctx context.Context, userID uuid.UUID) error {
+	if err := r.client.Del(ctx, sessionKeys(userID)...).Err(); err != nil {
+		return fmt.Errorf("failed to end sessions: %w", err)
+	}
+	return nil
+}
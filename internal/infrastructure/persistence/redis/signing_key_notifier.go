@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/redis/go-redis/v9"
+)
+
+// signingKeyChannel is the pub/sub channel signing key changes are announced on
+const signingKeyChannel = "signing_keys"
+
+// SigningKeyNotifier announces signing key changes to the replicas of the
+// service over Redis pub/sub. Announcements are not stored, so replicas that
+// are not subscribed when a key changes miss it.
+type SigningKeyNotifier struct {
+	client *redis.Client
+}
+
+// NewSigningKeyNotifier creates a new Redis signing key notifier
+func NewSigningKeyNotifier(client *redis.Client) *SigningKeyNotifier {
+	return &SigningKeyNotifier{client: client}
+}
+
+// NotifyKeyChanged announces a new signing key of the token type
+func (n *SigningKeyNotifier) NotifyKeyChanged(ctx context.Context, tokenType services.TokenType) error {
+	if err := n.client.Publish(ctx, signingKeyChannel, string(tokenType)).Err(); err != nil {
+		return fmt.Errorf("failed to announce signing key change: %w", err)
+	}
+	return nil
+}
+
+// SubscribeKeyChanges calls handle for every announced key change until ctx
+// is done or the subscription fails
+func (n *SigningKeyNotifier) SubscribeKeyChanges(ctx context.Context, handle func(tokenType services.TokenType)) error {
+	subscription := n.client.Subscribe(ctx, signingKeyChannel)
+	defer subscription.Close()
+	if _, err := subscription.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to signing key changes: %w", err)
+	}
+
+	messages := subscription.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case message, ok := <-messages:
+			if !ok {
+				return errors.New("signing key change subscription closed")
+			}
+			handle(services.TokenType(message.Payload))
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// CommandTimeout is a go-redis hook bounding the duration of every command
+// and pipeline. The deadline of the caller's context still applies when it
+// is earlier.
+type CommandTimeout struct {
+	timeout time.Duration
+}
+
+var _ redis.Hook = (*CommandTimeout)(nil)
+
+// NewCommandTimeout creates a new command timeout hook
+func NewCommandTimeout(timeout time.Duration) *CommandTimeout {
+	return &CommandTimeout{timeout: timeout}
+}
+
+// DialHook leaves dialing to the client's dial timeout
+func (h *CommandTimeout) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook bounds the duration of a single command
+func (h *CommandTimeout) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, cancel := context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+		return next(ctx, cmd)
+	}
+}
+
+// ProcessPipelineHook bounds the duration of a pipeline as a whole
+func (h *CommandTimeout) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, cancel := context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+		return next(ctx, cmds)
+	}
+}
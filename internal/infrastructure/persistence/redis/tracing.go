@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/redis"
+
+// CommandTracing is a go-redis hook recording commands and pipelines as
+// client spans of the trace in the caller's context
+type CommandTracing struct {
+	tracer trace.Tracer
+}
+
+var _ redis.Hook = (*CommandTracing)(nil)
+
+// NewCommandTracing creates a new command tracing hook using the global
+// tracer provider
+func NewCommandTracing() *CommandTracing {
+	return &CommandTracing{tracer: otel.Tracer(tracerName)}
+}
+
+// DialHook leaves dialing untraced; connections outlive the commands that
+// open them
+func (h *CommandTracing) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook records a single command. Only the command name is recorded,
+// never its keys or values.
+func (h *CommandTracing) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		command := strings.ToLower(cmd.Name())
+		ctx, span := h.start(ctx, "redis."+command, attribute.String("db.operation", command))
+		defer span.End()
+
+		err := next(ctx, cmd)
+		h.record(span, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook records a pipeline as a whole
+func (h *CommandTracing) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := h.start(ctx, "redis.pipeline",
+			attribute.String("db.operation", "pipeline"),
+			attribute.Int("db.redis.pipeline_length", len(cmds)))
+		defer span.End()
+
+		err := next(ctx, cmds)
+		h.record(span, err)
+		return err
+	}
+}
+
+func (h *CommandTracing) start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return h.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attributes, attribute.String("db.system", "redis"))...))
+}
+
+func (h *CommandTracing) record(span trace.Span, err error) {
+	// A missing key is a normal cache miss, not a Redis failure
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,272 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/egress"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/tlsutil"
+)
+
+// maxErrorBodyBytes bounds how much of an error response is kept for the error message
+const maxErrorBodyBytes = 1024
+
+// indexMappings is the mapping of the users index. Text fields keep an
+// exact keyword variant for sorting and filtering.
+var indexMappings = map[string]interface{}{
+	"mappings": map[string]interface{}{
+		"properties": map[string]interface{}{
+			"id":            map[string]string{"type": "keyword"},
+			"email":         textWithKeyword(),
+			"username":      textWithKeyword(),
+			"firstName":     textWithKeyword(),
+			"lastName":      textWithKeyword(),
+			"status":        map[string]string{"type": "keyword"},
+			"role":          map[string]string{"type": "keyword"},
+			"emailVerified": map[string]string{"type": "boolean"},
+			"createdAt":     map[string]string{"type": "date"},
+			"updatedAt":     map[string]string{"type": "date"},
+		},
+	},
+}
+
+func textWithKeyword() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "text",
+		"fields": map[string]interface{}{
+			"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256},
+		},
+	}
+}
+
+// Config holds the connection settings of the search cluster
+type Config struct {
+	URL      string // base URL of an Elasticsearch or OpenSearch cluster
+	Index    string
+	Username string // basic auth, empty to disable
+	Password string
+	Timeout  time.Duration // 0 uses the egress timeout
+	TLS      tlsutil.Config
+}
+
+// Client is a services.UserSearchIndex backed by Elasticsearch or
+// OpenSearch through their shared document and search REST API
+type Client struct {
+	baseURL    string
+	index      string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+var _ services.UserSearchIndex = (*Client)(nil)
+
+// NewClient creates a new search cluster client, connecting through the
+// shared egress settings
+func NewClient(cfg Config, egressConfig egress.Config) (*Client, error) {
+	tlsConfig, err := tlsutil.NewClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure search TLS: %w", err)
+	}
+
+	httpClient, err := egress.NewClient(egressConfig, tlsConfig, cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure search client: %w", err)
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(cfg.URL, "/"),
+		index:      cfg.Index,
+		username:   cfg.Username,
+		password:   cfg.Password,
+		httpClient: httpClient,
+	}, nil
+}
+
+// EnsureIndex creates the users index with its mappings unless it exists.
+// It reports whether this call created the index.
+func (c *Client) EnsureIndex(ctx context.Context) (bool, error) {
+	resp, err := c.do(ctx, http.MethodHead, c.index, nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		return false, fmt.Errorf("failed to check search index: status %d", resp.StatusCode)
+	}
+
+	resp, err = c.do(ctx, http.MethodPut, c.index, indexMappings)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	// Another instance may have created the index in the meantime
+	if resp.StatusCode == http.StatusBadRequest {
+		body := readErrorBody(resp)
+		if strings.Contains(body, "resource_already_exists_exception") {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create search index: status %d: %s", resp.StatusCode, body)
+	}
+	if err := checkResponse(resp, "create search index"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IndexUser creates or replaces the document of a user. The document's
+// UpdatedAt is used as external version, so a stale document never replaces
+// a newer one.
+func (c *Client) IndexUser(ctx context.Context, document *services.UserDocument) error {
+	path := fmt.Sprintf("%s/_doc/%s?version_type=external_gte&version=%s",
+		c.index, url.PathEscape(document.ID.String()),
+		strconv.FormatInt(document.UpdatedAt.UnixNano(), 10))
+	resp, err := c.do(ctx, http.MethodPut, path, document)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		return nil
+	}
+	return checkResponse(resp, "index user")
+}
+
+// DeleteUser removes the document of a user
+func (c *Client) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	resp, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("%s/_doc/%s", c.index, url.PathEscape(id.String())), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return checkResponse(resp, "delete user from search index")
+}
+
+type searchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Source services.UserDocument `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// SearchUsers returns the users matching the query. Without a query text the
+// most recently created users come first.
+func (c *Client) SearchUsers(ctx context.Context, query services.UserSearchQuery) (*services.UserSearchResult, error) {
+	resp, err := c.do(ctx, http.MethodPost, c.index+"/_search", searchRequest(query))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "search users"); err != nil {
+		return nil, err
+	}
+
+	var body searchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode search response: %w", err)
+	}
+
+	result := &services.UserSearchResult{
+		Total: body.Hits.Total.Value,
+		Users: make([]*services.UserDocument, 0, len(body.Hits.Hits)),
+	}
+	for i := range body.Hits.Hits {
+		result.Users = append(result.Users, &body.Hits.Hits[i].Source)
+	}
+	return result, nil
+}
+
+// searchRequest builds the search body for a query. Query text matches
+// prefixes of the email, username and name, favouring the username.
+func searchRequest(query services.UserSearchQuery) map[string]interface{} {
+	boolQuery := map[string]interface{}{}
+	if text := strings.TrimSpace(query.Query); text != "" {
+		boolQuery["must"] = []interface{}{
+			map[string]interface{}{
+				"multi_match": map[string]interface{}{
+					"query":  text,
+					"type":   "bool_prefix",
+					"fields": []string{"username^2", "email", "firstName", "lastName"},
+				},
+			},
+		}
+	}
+	if query.Status != "" {
+		boolQuery["filter"] = []interface{}{
+			map[string]interface{}{"term": map[string]interface{}{"status": query.Status}},
+		}
+	}
+
+	request := map[string]interface{}{
+		"from":             query.Offset,
+		"size":             query.Limit,
+		"track_total_hits": true,
+		"query":            map[string]interface{}{"bool": boolQuery},
+	}
+	if strings.TrimSpace(query.Query) == "" {
+		request["sort"] = []interface{}{
+			map[string]interface{}{"createdAt": map[string]string{"order": "desc"}},
+		}
+	}
+	return request
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal search request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/"+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create search request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("search request failed: %w", err)
+	}
+	return resp, nil
+}
+
+func checkResponse(resp *http.Response, operation string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return fmt.Errorf("failed to %s: status %d: %s", operation, resp.StatusCode, readErrorBody(resp))
+}
+
+func readErrorBody(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	return strings.TrimSpace(string(body))
+}
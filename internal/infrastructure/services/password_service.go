@@ -4,33 +4,58 @@ import (
 	"context"
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"math/big"
 	"unicode"
 
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/auth/password"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrPasswordTooShort    = errors.New("password must be at least 8 characters long")
-	ErrPasswordTooLong     = errors.New("password must be at most 72 characters long")
-	ErrPasswordNoUpper     = errors.New("password must contain at least one uppercase letter")
-	ErrPasswordNoLower     = errors.New("password must contain at least one lowercase letter")
-	ErrPasswordNoNumber    = errors.New("password must contain at least one number")
-	ErrPasswordNoSpecial   = errors.New("password must contain at least one special character")
-	ErrPasswordHashFailed  = errors.New("failed to hash password")
-	ErrPasswordInvalid     = errors.New("invalid password")
+	ErrPasswordTooShort   = errors.New("password is too short")
+	ErrPasswordTooLong    = errors.New("password is too long")
+	ErrPasswordNoUpper    = errors.New("password must contain at least one uppercase letter")
+	ErrPasswordNoLower    = errors.New("password must contain at least one lowercase letter")
+	ErrPasswordNoNumber   = errors.New("password must contain at least one number")
+	ErrPasswordNoSpecial  = errors.New("password must contain at least one special character")
+	ErrPasswordHashFailed = errors.New("failed to hash password")
+	ErrPasswordInvalid    = errors.New("invalid password")
 )
 
+// DefaultPasswordPolicy is the policy ValidatePassword enforces. bcrypt
+// ignores everything after 72 bytes.
+var DefaultPasswordPolicy = services.PasswordConfig{
+	MinLength:           8,
+	MaxLength:           72,
+	RequireUppercase:    true,
+	RequireLowercase:    true,
+	RequireNumbers:      true,
+	RequireSpecialChars: true,
+}
+
 // PasswordService handles password-related operations
-type PasswordService struct{}
+type PasswordService struct {
+	hasher password.PasswordHasher
+}
 
-// NewPasswordService creates a new password service
-func NewPasswordService() *PasswordService {
-	return &PasswordService{}
+// NewPasswordService creates a new password service hashing with the given
+// hasher. A nil hasher uses bcrypt with the default cost.
+func NewPasswordService(hasher password.PasswordHasher) *PasswordService {
+	if hasher == nil {
+		hasher = password.NewBCryptHasher(bcrypt.DefaultCost)
+	}
+	return &PasswordService{hasher: hasher}
 }
 
-// ValidatePassword validates a password
+// ValidatePassword validates a password against the default policy
 func (s *PasswordService) ValidatePassword(ctx context.Context, password string) error {
+	return s.ValidatePasswordPolicy(ctx, password, DefaultPasswordPolicy)
+}
+
+// ValidatePasswordPolicy validates a password against the given policy
+func (s *PasswordService) ValidatePasswordPolicy(ctx context.Context, password string, policy services.PasswordConfig) error {
 	var (
 		hasUpper   bool
 		hasLower   bool
@@ -38,11 +63,11 @@ func (s *PasswordService) ValidatePassword(ctx context.Context, password string)
 		hasSpecial bool
 	)
 
-	if len(password) < 8 {
-		return ErrPasswordTooShort
+	if len(password) < policy.MinLength {
+		return fmt.Errorf("%w: at least %d characters are required", ErrPasswordTooShort, policy.MinLength)
 	}
-	if len(password) > 72 {
-		return ErrPasswordTooLong
+	if len(password) > policy.MaxLength {
+		return fmt.Errorf("%w: at most %d characters are allowed", ErrPasswordTooLong, policy.MaxLength)
 	}
 
 	for _, char := range password {
@@ -58,16 +83,16 @@ func (s *PasswordService) ValidatePassword(ctx context.Context, password string)
 		}
 	}
 
-	if !hasUpper {
+	if policy.RequireUppercase && !hasUpper {
 		return ErrPasswordNoUpper
 	}
-	if !hasLower {
+	if policy.RequireLowercase && !hasLower {
 		return ErrPasswordNoLower
 	}
-	if !hasNumber {
+	if policy.RequireNumbers && !hasNumber {
 		return ErrPasswordNoNumber
 	}
-	if !hasSpecial {
+	if policy.RequireSpecialChars && !hasSpecial {
 		return ErrPasswordNoSpecial
 	}
 
@@ -76,22 +101,27 @@ func (s *PasswordService) ValidatePassword(ctx context.Context, password string)
 
 // HashPassword hashes a password
 func (s *PasswordService) HashPassword(ctx context.Context, password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := s.hasher.Hash(password)
 	if err != nil {
 		return "", ErrPasswordHashFailed
 	}
-	return string(hash), nil
+	return hash, nil
 }
 
 // VerifyPassword verifies if a password matches its hash
 func (s *PasswordService) VerifyPassword(ctx context.Context, password, hash string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	if err != nil {
+	if err := s.hasher.Verify(password, hash); err != nil {
 		return ErrPasswordInvalid
 	}
 	return nil
 }
 
+// NeedsRehash reports whether a hash should be replaced with one of the
+// configured hasher
+func (s *PasswordService) NeedsRehash(hash string) bool {
+	return s.hasher.NeedsRehash(hash)
+}
+
 // GenerateRandomPassword generates a random password
 func (s *PasswordService) GenerateRandomPassword(ctx context.Context) (string, error) {
 	const (
@@ -1,8 +1,6 @@
 package services
 
 import (
-	"time"
-
 	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
 	"github.com/mibrahim2344/identity-service/internal/domain/services"
 	"gorm.io/gorm"
@@ -29,17 +27,15 @@ func NewServices(
 	eventPublisher services.EventPublisher,
 	metricsCollector services.MetricsService,
 	userRepo repositories.UserRepository,
-	tokenSecret string,
-	accessTokenExpiry,
-	refreshTokenExpiry time.Duration,
+	tokenConfig services.TokenConfig,
 ) *Services {
 	return &Services{
 		DB:               db,
 		Cache:            cache,
 		EventPublisher:   eventPublisher,
 		MetricsCollector: metricsCollector,
-		Password:         NewPasswordService(),
-		Token:            NewTokenService(tokenSecret, accessTokenExpiry, refreshTokenExpiry),
+		Password:         NewPasswordService(nil),
+		Token:            NewTokenService(tokenConfig, cache),
 		UserRepository:   userRepo,
 	}
 }
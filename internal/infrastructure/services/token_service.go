@@ -3,11 +3,13 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/auth/token"
 )
 
 var (
@@ -17,22 +19,56 @@ var (
 
 // TokenService handles JWT token operations
 type TokenService struct {
-	config services.TokenConfig
+	config     services.TokenConfig
+	revocation *token.Revocation
+	clock      services.Clock
+	enrichers  []services.ClaimsEnricher
 }
 
-// NewTokenService creates a new token service
-func NewTokenService(secret string, accessTokenExpiry, refreshTokenExpiry time.Duration) *TokenService {
-	return &TokenService{
-		config: services.TokenConfig{
-			AccessTokenDuration:        accessTokenExpiry,
-			RefreshTokenDuration:      refreshTokenExpiry,
-			ResetTokenDuration:        24 * time.Hour,    // 24 hours
-			VerificationTokenDuration: 72 * time.Hour,    // 72 hours
-			SigningKey:               []byte(secret),
-		},
+// TokenServiceOption configures optional settings of the token service
+type TokenServiceOption func(*TokenService)
+
+// WithTokenClock sets the clock tokens are issued and validated with; the
+// default is the system clock
+func WithTokenClock(clock services.Clock) TokenServiceOption {
+	return func(s *TokenService) {
+		s.clock = clock
 	}
 }
 
+// WithTokenClaimsEnrichers adds custom claims to access tokens with the
+// enrichers, which run in order
+func WithTokenClaimsEnrichers(enrichers ...services.ClaimsEnricher) TokenServiceOption {
+	return func(s *TokenService) {
+		s.enrichers = append(s.enrichers, enrichers...)
+	}
+}
+
+// NewTokenService creates a new token service signing every token type with
+// config.SigningKey and keeping revoked tokens and sessions in cache. Reset
+// and verification token lifetimes default to 24 and 72 hours, magic link
+// token lifetimes to DefaultMagicLinkTokenDuration.
+func NewTokenService(config services.TokenConfig, cache services.CacheService, opts ...TokenServiceOption) *TokenService {
+	if config.ResetTokenDuration == 0 {
+		config.ResetTokenDuration = 24 * time.Hour
+	}
+	if config.VerificationTokenDuration == 0 {
+		config.VerificationTokenDuration = 72 * time.Hour
+	}
+	if config.MagicLinkTokenDuration == 0 {
+		config.MagicLinkTokenDuration = services.DefaultMagicLinkTokenDuration
+	}
+	s := &TokenService{
+		config: config,
+		clock:  services.SystemClock,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.revocation = token.NewRevocation(config, cache, s.clock)
+	return s
+}
+
 // GenerateAccessToken generates a new access token
 func (s *TokenService) GenerateAccessToken(ctx context.Context, claims services.TokenClaims) (string, error) {
 	return s.generateToken(ctx, claims, s.config.AccessTokenDuration)
@@ -53,11 +89,83 @@ func (s *TokenService) GenerateVerificationToken(ctx context.Context, claims ser
 	return s.generateToken(ctx, claims, s.config.VerificationTokenDuration)
 }
 
+// GenerateMagicLinkToken generates a passwordless login token
+func (s *TokenService) GenerateMagicLinkToken(ctx context.Context, claims services.TokenClaims) (string, error) {
+	return s.generateToken(ctx, claims, s.config.MagicLinkTokenDuration)
+}
+
+// GenerateIDToken is not supported: clients verify ID tokens against the
+// published JWKS, which a shared secret cannot appear in
+func (s *TokenService) GenerateIDToken(ctx context.Context, claims services.IDTokenClaims) (string, error) {
+	return "", fmt.Errorf("ID tokens require an asymmetric signing algorithm")
+}
+
+// TokenDuration returns the lifetime of tokens of the given type
+func (s *TokenService) TokenDuration(tokenType services.TokenType) time.Duration {
+	switch tokenType {
+	case services.TokenTypeAccess:
+		return s.config.AccessTokenDuration
+	case services.TokenTypeRefresh:
+		return s.config.RefreshTokenDuration
+	case services.TokenTypeReset:
+		return s.config.ResetTokenDuration
+	case services.TokenTypeVerification:
+		return s.config.VerificationTokenDuration
+	case services.TokenTypeMagicLink:
+		return s.config.MagicLinkTokenDuration
+	default:
+		return 0
+	}
+}
+
+// ListSigningKeys describes the configured signing key, which is shared by
+// all token types. Its creation time is unknown.
+func (s *TokenService) ListSigningKeys(ctx context.Context) ([]services.SigningKeyInfo, error) {
+	keys := make([]services.SigningKeyInfo, 0, len(services.SigningKeyTokenTypes))
+	for _, tokenType := range services.SigningKeyTokenTypes {
+		policy := s.config.KeyPolicy(tokenType)
+		keys = append(keys, services.NewSigningKeyInfo(
+			tokenType,
+			policy.Algorithm,
+			s.config.SigningKey,
+			time.Time{},
+			policy.RotationInterval,
+			s.clock.Now(),
+		))
+	}
+	return keys, nil
+}
+
+// RotateSigningKey is not supported because the signing key is static
+func (s *TokenService) RotateSigningKey(ctx context.Context, tokenType services.TokenType) (*services.SigningKeyInfo, error) {
+	return nil, services.ErrKeyRotationUnsupported
+}
+
+// JWKS returns no keys because the shared signing key is symmetric
+func (s *TokenService) JWKS(ctx context.Context) ([]services.JSONWebKey, error) {
+	return []services.JSONWebKey{}, nil
+}
+
+// signingMethod returns the configured signing method of the given token
+// type. Asymmetric algorithms need generated key pairs and are rejected.
+func (s *TokenService) signingMethod(tokenType services.TokenType) (jwt.SigningMethod, error) {
+	alg := s.config.KeyPolicy(tokenType).Algorithm
+	if !services.IsSigningAlgorithm(alg) || services.IsAsymmetricAlgorithm(alg) {
+		return nil, fmt.Errorf("unsupported signing algorithm %q for %s tokens", alg, tokenType)
+	}
+	return jwt.GetSigningMethod(alg), nil
+}
+
 // ValidateToken validates a token and returns its claims
 func (s *TokenService) ValidateToken(ctx context.Context, tokenString string, tokenType services.TokenType) (*services.TokenClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+	method, err := s.signingMethod(tokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return s.config.SigningKey, nil
-	})
+	}, jwt.WithValidMethods([]string{method.Alg()}), jwt.WithTimeFunc(s.clock.Now))
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -66,8 +174,8 @@ func (s *TokenService) ValidateToken(ctx context.Context, tokenString string, to
 		return nil, ErrInvalidToken
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || !parsed.Valid {
 		return nil, ErrInvalidToken
 	}
 
@@ -81,37 +189,154 @@ func (s *TokenService) ValidateToken(ctx context.Context, tokenString string, to
 		return nil, ErrInvalidToken
 	}
 
-	return &services.TokenClaims{
-		UserID:    userID,
-		Email:     claims["email"].(string),
-		Username:  claims["username"].(string),
-		Role:      claims["role"].(string),
-		TokenType: services.TokenType(claims["token_type"].(string)),
-	}, nil
+	// Reject revoked tokens and tokens of revoked sessions
+	if err := s.revocation.Check(ctx, tokenString, tokenType, userID, claims); err != nil {
+		return nil, err
+	}
+
+	sessionID, _ := claims["sid"].(string)
+	deviceFingerprint, _ := claims["dfp"].(string)
+	clientID, _ := claims["client_id"].(string)
+	scope, _ := claims["scope"].(string)
+	tenantID, _ := claims["tenant_id"].(string)
+	region, _ := claims["region"].(string)
+	givenName, _ := claims["given_name"].(string)
+	familyName, _ := claims["family_name"].(string)
+	var permissions []string
+	if items, ok := claims["permissions"].([]interface{}); ok {
+		for _, item := range items {
+			if permission, ok := item.(string); ok {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	result := &services.TokenClaims{
+		UserID:            userID,
+		Email:             claims["email"].(string),
+		Username:          claims["username"].(string),
+		Role:              claims["role"].(string),
+		TokenType:         services.TokenType(claims["token_type"].(string)),
+		SessionID:         sessionID,
+		DeviceFingerprint: deviceFingerprint,
+		ClientID:          clientID,
+		Scope:             scope,
+		Permissions:       permissions,
+		TenantID:          tenantID,
+		Region:            region,
+		GivenName:         givenName,
+		FamilyName:        familyName,
+	}
+	if issuedAt, err := claims.GetIssuedAt(); err == nil && issuedAt != nil {
+		result.IssuedAt = issuedAt.Time
+	}
+	if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+		result.ExpiresAt = expiresAt.Time
+	}
+	if notBefore, err := claims.GetNotBefore(); err == nil && notBefore != nil {
+		result.NotBefore = notBefore.Time
+	}
+	if audience, err := claims.GetAudience(); err == nil && len(audience) > 0 {
+		result.Audience = audience[0]
+	}
+	if emailVerified, ok := claims["email_verified"].(bool); ok {
+		result.EmailVerified = &emailVerified
+	}
+	return result, nil
 }
 
 // RevokeToken revokes a token
-func (s *TokenService) RevokeToken(ctx context.Context, token string) error {
-	// TODO: Implement token revocation using Redis
-	return nil
+func (s *TokenService) RevokeToken(ctx context.Context, tokenString string) error {
+	return s.revocation.RevokeToken(ctx, tokenString)
 }
 
 // IsTokenRevoked checks if a token has been revoked
-func (s *TokenService) IsTokenRevoked(ctx context.Context, token string) (bool, error) {
-	// TODO: Implement token revocation check using Redis
-	return false, nil
+func (s *TokenService) IsTokenRevoked(ctx context.Context, tokenString string) (bool, error) {
+	return s.revocation.IsTokenRevoked(ctx, tokenString)
+}
+
+// RevokeSession revokes every token issued for the given session
+func (s *TokenService) RevokeSession(ctx context.Context, sessionID string) error {
+	return s.revocation.RevokeSession(ctx, sessionID)
+}
+
+// RevokeUserSessions revokes every session token issued to a user so far
+func (s *TokenService) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return s.revocation.RevokeUserSessions(ctx, userID)
 }
 
 // generateToken generates a new JWT token
 func (s *TokenService) generateToken(ctx context.Context, claims services.TokenClaims, duration time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	if claims.Lifetime > 0 && claims.Lifetime < duration {
+		duration = claims.Lifetime
+	}
+	now := s.clock.Now()
+	activatesAt := now
+	if claims.NotBefore.After(now) {
+		activatesAt = claims.NotBefore
+	}
+	jwtClaims := jwt.MapClaims{
 		"user_id":    claims.UserID.String(),
 		"email":      claims.Email,
 		"username":   claims.Username,
 		"role":       claims.Role,
 		"token_type": string(claims.TokenType),
-		"exp":        time.Now().Add(duration).Unix(),
-	})
+		"jti":        uuid.New().String(),
+		"iat":        now.Unix(),
+		"exp":        activatesAt.Add(duration).Unix(),
+	}
+	if activatesAt.After(now) {
+		jwtClaims["nbf"] = activatesAt.Unix()
+	}
+	if claims.SessionID != "" {
+		jwtClaims["sid"] = claims.SessionID
+	}
+	if claims.DeviceFingerprint != "" {
+		jwtClaims["dfp"] = claims.DeviceFingerprint
+	}
+	if claims.ClientID != "" {
+		jwtClaims["client_id"] = claims.ClientID
+	}
+	if claims.Scope != "" {
+		jwtClaims["scope"] = claims.Scope
+	}
+	if len(claims.Permissions) > 0 {
+		jwtClaims["permissions"] = claims.Permissions
+	}
+	if claims.TenantID != "" {
+		jwtClaims["tenant_id"] = claims.TenantID
+	}
+	// Tokens refreshed elsewhere keep the region they were first issued in
+	region := claims.Region
+	if region == "" {
+		region = s.config.Region
+	}
+	if region != "" {
+		jwtClaims["region"] = region
+	}
+	if claims.GivenName != "" {
+		jwtClaims["given_name"] = claims.GivenName
+	}
+	if claims.FamilyName != "" {
+		jwtClaims["family_name"] = claims.FamilyName
+	}
+	if claims.EmailVerified != nil {
+		jwtClaims["email_verified"] = *claims.EmailVerified
+	}
+	if claims.Audience != "" {
+		jwtClaims["aud"] = claims.Audience
+	}
+	if claims.TokenType == services.TokenTypeAccess {
+		if err := services.EnrichClaims(ctx, s.enrichers, services.TokenTypeAccess, jwtClaims); err != nil {
+			return "", err
+		}
+	}
+	method, err := s.signingMethod(claims.TokenType)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(method, jwtClaims)
+	token.Header["kid"] = services.SigningKeyID(s.config.SigningKey)
 
 	return token.SignedString(s.config.SigningKey)
 }
@@ -0,0 +1,93 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/memory"
+	infraservices "github.com/mibrahim2344/identity-service/internal/infrastructure/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTokenService(now *time.Time) *infraservices.TokenService {
+	return infraservices.NewTokenService(services.TokenConfig{
+		AccessTokenDuration:  15 * time.Minute,
+		RefreshTokenDuration: 24 * time.Hour,
+		SigningKey:           []byte("0123456789abcdef0123456789abcdef"),
+	}, memory.NewCacheService(), infraservices.WithTokenClock(services.ClockFunc(func() time.Time {
+		return *now
+	})))
+}
+
+func TestTokenServiceRevocation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	userID := uuid.New()
+	issue := func(t *testing.T, s *infraservices.TokenService, tokenType services.TokenType, sessionID string) string {
+		claims := services.TokenClaims{
+			UserID:    userID,
+			Email:     "user@example.com",
+			Username:  "user",
+			Role:      "user",
+			TokenType: tokenType,
+			SessionID: sessionID,
+		}
+		var token string
+		var err error
+		if tokenType == services.TokenTypeRefresh {
+			token, err = s.GenerateRefreshToken(ctx, claims)
+		} else {
+			token, err = s.GenerateAccessToken(ctx, claims)
+		}
+		require.NoError(t, err)
+		return token
+	}
+
+	t.Run("revoked token", func(t *testing.T) {
+		s := newTestTokenService(&now)
+		token := issue(t, s, services.TokenTypeAccess, "")
+		_, err := s.ValidateToken(ctx, token, services.TokenTypeAccess)
+		require.NoError(t, err)
+
+		require.NoError(t, s.RevokeToken(ctx, token))
+		_, err = s.ValidateToken(ctx, token, services.TokenTypeAccess)
+		assert.Error(t, err)
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		s := newTestTokenService(&now)
+		revoked := issue(t, s, services.TokenTypeRefresh, "session-1")
+		other := issue(t, s, services.TokenTypeRefresh, "session-2")
+
+		require.NoError(t, s.RevokeSession(ctx, "session-1"))
+		_, err := s.ValidateToken(ctx, revoked, services.TokenTypeRefresh)
+		assert.Error(t, err)
+		_, err = s.ValidateToken(ctx, other, services.TokenTypeRefresh)
+		assert.NoError(t, err)
+	})
+
+	t.Run("revoked user sessions", func(t *testing.T) {
+		clock := now
+		s := newTestTokenService(&clock)
+		access := issue(t, s, services.TokenTypeAccess, "session-1")
+		refresh := issue(t, s, services.TokenTypeRefresh, "session-1")
+
+		clock = clock.Add(time.Minute)
+		require.NoError(t, s.RevokeUserSessions(ctx, userID))
+		_, err := s.ValidateToken(ctx, access, services.TokenTypeAccess)
+		assert.Error(t, err)
+		_, err = s.ValidateToken(ctx, refresh, services.TokenTypeRefresh)
+		assert.Error(t, err)
+
+		// Sessions started after the revocation are not affected
+		clock = clock.Add(time.Second)
+		fresh := issue(t, s, services.TokenTypeAccess, "session-2")
+		claims, err := s.ValidateToken(ctx, fresh, services.TokenTypeAccess)
+		require.NoError(t, err)
+		assert.Equal(t, userID, claims.UserID)
+	})
+}
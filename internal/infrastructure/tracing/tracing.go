@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// DefaultServiceName names the service in exported spans when none is configured
+const DefaultServiceName = "identity-service"
+
+// Config holds the settings of the OTLP trace exporter
+type Config struct {
+	// Endpoint is the host:port of the OTLP gRPC collector; empty uses the
+	// exporter's default, localhost:4317, or OTEL_EXPORTER_OTLP_ENDPOINT
+	Endpoint string
+	// Insecure sends spans without TLS, e.g. to a collector sidecar
+	Insecure    bool
+	ServiceName string
+	// SampleRatio is the fraction of new traces that are sampled. Requests
+	// continuing a trace follow the caller's decision.
+	SampleRatio float64
+}
+
+// Setup installs a tracer provider exporting spans over OTLP as the global
+// provider, and the W3C trace context and baggage propagators. The returned
+// function flushes and stops the exporter.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	options := []otlptracegrpc.Option{}
+	if cfg.Endpoint != "" {
+		options = append(options, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
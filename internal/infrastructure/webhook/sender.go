@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/egress"
+)
+
+// maxErrorBodyBytes bounds how much of an error response is kept for the error message
+const maxErrorBodyBytes = 1024
+
+// Headers of JSON deliveries. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook's secret.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+)
+
+// eventDescriptions phrase events for chat messages
+var eventDescriptions = map[events.EventType]string{
+	events.UserLoggedIn:            "New login",
+	events.UserPasswordChange:      "Password changed",
+	events.UserPasswordReset:       "Password reset requested",
+	events.UserMFAEnabled:          "Two-factor authentication enabled",
+	events.UserMFADisabled:         "Two-factor authentication disabled",
+	events.UserCredentialsBreached: "Credentials found in a breach, password reset required",
+	events.UserSuspended:           "Account suspended",
+	events.UserActivated:           "Account activated",
+	events.AccessPolicyViolated:    "Login denied by access policy",
+}
+
+// Config holds the delivery settings of notification webhooks
+type Config struct {
+	Timeout time.Duration // 0 uses the egress timeout
+	// AllowPrivateNetworks permits connections to loopback, private and
+	// link-local addresses. Webhook URLs are chosen by users, so this should
+	// only be set when deliveries go through a proxy on a private network.
+	AllowPrivateNetworks bool
+}
+
+// Sender is a services.WebhookSender posting over HTTPS
+type Sender struct {
+	httpClient *http.Client
+}
+
+var _ services.WebhookSender = (*Sender)(nil)
+
+// NewSender creates a new webhook sender, connecting through the shared
+// egress settings
+func NewSender(cfg Config, egressConfig egress.Config) (*Sender, error) {
+	transport, err := egress.NewTransport(egressConfig, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure webhook client: %w", err)
+	}
+	if !cfg.AllowPrivateNetworks {
+		transport.DialContext = egress.PublicOnly(transport.DialContext)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = egressConfig.Timeout
+	}
+	return &Sender{
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			// A redirect could lead to a private address the URL was
+			// validated against
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+// Send posts a notification in the format of the webhook
+func (s *Sender) Send(ctx context.Context, webhook *models.NotificationWebhook, notification services.WebhookNotification) error {
+	var payload interface{} = notification
+	if webhook.Format == models.WebhookFormatSlack {
+		payload = map[string]string{"text": slackText(notification)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if webhook.Format == models.WebhookFormatJSON {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	errBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	return fmt.Errorf("webhook responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(errBody)))
+}
+
+// Sign computes the signature of a JSON delivery
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// slackText phrases a notification as a chat message
+func slackText(notification services.WebhookNotification) string {
+	description, ok := eventDescriptions[events.EventType(notification.Event)]
+	if !ok {
+		description = notification.Event
+	}
+
+	account := notification.Email
+	if account == "" {
+		account = notification.UserID.String()
+	}
+	text := fmt.Sprintf("%s on account %s", description, account)
+	if notification.ClientIP != "" {
+		text += " from " + notification.ClientIP
+	}
+	if notification.UserAgent != "" {
+		text += " (" + notification.UserAgent + ")"
+	}
+	if !notification.OccurredAt.IsZero() {
+		text += " at " + notification.OccurredAt.UTC().Format(time.RFC1123)
+	}
+	return text
+}
@@ -0,0 +1,5 @@
+// Package identitypb holds the messages and service definition of the gRPC
+// API, generated from identity.proto
+package identitypb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative identity.proto
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/grpc/identitypb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// identityService implements the IdentityService gRPC API on top of the
+// application services
+type identityService struct {
+	identitypb.UnimplementedIdentityServiceServer
+	userService  services.UserService
+	tokenService services.TokenService
+	logger       *zap.Logger
+}
+
+func newIdentityService(userService services.UserService, tokenService services.TokenService, logger *zap.Logger) *identityService {
+	return &identityService{
+		userService:  userService,
+		tokenService: tokenService,
+		logger:       logger,
+	}
+}
+
+// ValidateToken validates an access token and returns its claims
+func (s *identityService) ValidateToken(ctx context.Context, req *identitypb.ValidateTokenRequest) (*identitypb.ValidateTokenResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	claims, err := s.tokenService.ValidateToken(ctx, req.GetToken(), services.TokenTypeAccess)
+	if err != nil {
+		if errors.Is(err, services.ErrRevocationUnavailable) {
+			return nil, status.Error(codes.Unavailable, "token revocation status unavailable")
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	return &identitypb.ValidateTokenResponse{Claims: newTokenClaims(claims)}, nil
+}
+
+// IntrospectToken reports whether a token is an active access or refresh
+// token, starting with the hinted type
+func (s *identityService) IntrospectToken(ctx context.Context, req *identitypb.IntrospectTokenRequest) (*identitypb.IntrospectTokenResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	tokenTypes := []services.TokenType{services.TokenTypeAccess, services.TokenTypeRefresh}
+	if req.GetTokenTypeHint() == identitypb.TokenType_TOKEN_TYPE_REFRESH {
+		tokenTypes[0], tokenTypes[1] = tokenTypes[1], tokenTypes[0]
+	}
+
+	for _, tokenType := range tokenTypes {
+		claims, err := s.tokenService.ValidateToken(ctx, req.GetToken(), tokenType)
+		if err == nil {
+			return &identitypb.IntrospectTokenResponse{Active: true, Claims: newTokenClaims(claims)}, nil
+		}
+		// Reporting a revoked token as active is worse than failing the call
+		if errors.Is(err, services.ErrRevocationUnavailable) {
+			return nil, status.Error(codes.Unavailable, "token revocation status unavailable")
+		}
+	}
+	return &identitypb.IntrospectTokenResponse{Active: false}, nil
+}
+
+// GetUser returns a user by ID
+func (s *identityService) GetUser(ctx context.Context, req *identitypb.GetUserRequest) (*identitypb.GetUserResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	user, err := s.userService.GetUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		s.logger.Error("failed to get user", zap.String("userID", id.String()), zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to get user")
+	}
+
+	return &identitypb.GetUserResponse{User: newUser(user)}, nil
+}
+
+// RegisterUser registers a new user
+func (s *identityService) RegisterUser(ctx context.Context, req *identitypb.RegisterUserRequest) (*identitypb.RegisterUserResponse, error) {
+	if req.GetEmail() == "" || req.GetUsername() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email, username and password are required")
+	}
+
+	user, err := s.userService.RegisterUser(ctx, services.RegisterUserInput{
+		Email:     req.GetEmail(),
+		Username:  req.GetUsername(),
+		Password:  req.GetPassword(),
+		FirstName: req.GetFirstName(),
+		LastName:  req.GetLastName(),
+		Role:      models.RoleUser,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserAlreadyExists), errors.Is(err, services.ErrEmailAlreadyExists):
+			return nil, status.Error(codes.AlreadyExists, "email already in use")
+		case errors.Is(err, services.ErrUsernameAlreadyExists):
+			return nil, status.Error(codes.AlreadyExists, "username already in use")
+		default:
+			s.logger.Error("failed to register user", zap.Error(err))
+			return nil, status.Error(codes.Internal, "failed to register user")
+		}
+	}
+
+	return &identitypb.RegisterUserResponse{User: newUser(user)}, nil
+}
+
+// newTokenClaims maps validated token claims to their message
+func newTokenClaims(claims *services.TokenClaims) *identitypb.TokenClaims {
+	message := &identitypb.TokenClaims{
+		Email:     claims.Email,
+		Username:  claims.Username,
+		Role:      claims.Role,
+		TokenType: identitypb.TokenType_TOKEN_TYPE_ACCESS,
+		SessionId: claims.SessionID,
+		ClientId:  claims.ClientID,
+		Scope:     claims.Scope,
+	}
+	if claims.UserID != uuid.Nil {
+		message.UserId = claims.UserID.String()
+	}
+	if claims.TokenType == services.TokenTypeRefresh {
+		message.TokenType = identitypb.TokenType_TOKEN_TYPE_REFRESH
+	}
+	if !claims.IssuedAt.IsZero() {
+		message.IssuedAt = timestamppb.New(claims.IssuedAt)
+	}
+	if !claims.ExpiresAt.IsZero() {
+		message.ExpiresAt = timestamppb.New(claims.ExpiresAt)
+	}
+	return message
+}
+
+// newUser maps a user to its message
+func newUser(user *models.User) *identitypb.User {
+	message := &identitypb.User{
+		Id:            user.ID.String(),
+		Email:         user.Email,
+		Username:      user.Username,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		EmailVerified: user.EmailVerified,
+		Status:        string(user.Status),
+		Role:          string(user.Role),
+		CreatedAt:     timestamppb.New(user.CreatedAt),
+		UpdatedAt:     timestamppb.New(user.UpdatedAt),
+	}
+	if user.OrganizationID != nil {
+		message.OrganizationId = user.OrganizationID.String()
+	}
+	return message
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/grpc/identitypb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Config represents gRPC server configuration
+type Config struct {
+	Host     string
+	Port     int
+	CertFile string // server certificate; the server speaks plaintext without one
+	KeyFile  string
+	// ClientCAFile is a PEM bundle that client certificates must chain to.
+	// Without one clients are not authenticated.
+	ClientCAFile string
+}
+
+// Server serves the gRPC API for other backend services alongside the HTTP
+// server
+type Server struct {
+	config     Config
+	logger     *zap.Logger
+	grpcServer *grpc.Server
+	health     *health.Server
+}
+
+// NewServer creates a new gRPC server serving the identity service
+func NewServer(
+	config Config,
+	userService services.UserService,
+	tokenService services.TokenService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) (*Server, error) {
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			recoveryInterceptor(logger),
+			metricsInterceptor(metricsService, logger),
+		),
+	}
+	if config.CertFile != "" {
+		tlsConfig, err := newTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	s := &Server{
+		config:     config,
+		logger:     logger,
+		grpcServer: grpc.NewServer(options...),
+		health:     health.NewServer(),
+	}
+	identitypb.RegisterIdentityServiceServer(s.grpcServer, newIdentityService(userService, tokenService, logger))
+	grpc_health_v1.RegisterHealthServer(s.grpcServer, s.health)
+	return s, nil
+}
+
+// newTLSConfig loads the server certificate and, when configured, requires
+// client certificates issued by the client CA
+func newTLSConfig(config Config) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gRPC server certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if config.ClientCAFile != "" {
+		caCert, err := os.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gRPC client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse gRPC client CA file: %s", config.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// Start starts the gRPC server and blocks until it stops
+func (s *Server) Start() error {
+	address := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	s.logger.Info("Starting gRPC server",
+		zap.String("address", address),
+		zap.Bool("tls", s.config.CertFile != ""),
+		zap.Bool("clientAuth", s.config.ClientCAFile != ""),
+	)
+	return s.grpcServer.Serve(listener)
+}
+
+// Stop gracefully stops the gRPC server, letting in-flight calls finish
+// until ctx is done
+func (s *Server) Stop(ctx context.Context) error {
+	s.logger.Info("stopping gRPC server")
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return fmt.Errorf("failed to stop gRPC server gracefully: %w", ctx.Err())
+	}
+}
+
+// metricsInterceptor records the duration and outcome of every call
+func metricsInterceptor(metricsService services.MetricsService, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		labels := map[string]string{
+			"method": info.FullMethod,
+			"code":   code.String(),
+		}
+		metricsService.ObserveHistogram("grpc_request_duration_seconds", time.Since(start).Seconds(), labels)
+		if code == codes.Internal || code == codes.Unknown {
+			logger.Error("gRPC call failed",
+				zap.String("method", info.FullMethod),
+				zap.Error(err))
+		}
+		return resp, err
+	}
+}
+
+// recoveryInterceptor turns a panicking call into an internal error instead
+// of crashing the process
+func recoveryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("panic in gRPC call",
+					zap.String("method", info.FullMethod),
+					zap.Any("panic", r),
+					zap.Stack("stack"))
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
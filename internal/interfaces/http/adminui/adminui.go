@@ -0,0 +1,40 @@
+// Package adminui serves a minimal single-page admin console built into the
+// binary, for deployments without a separate console. The page holds no
+// data: it signs admins in and calls the admin API with their access token,
+// which enforces the permissions of every action.
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// Path is where the console is served
+const Path = "/admin/"
+
+//go:embed assets
+var assets embed.FS
+
+// contentSecurityPolicy only lets the console load its own assets and call
+// the API of this service
+const contentSecurityPolicy = "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; img-src 'self'; " +
+	"base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+// Handler serves the console below Path
+func Handler() http.Handler {
+	root, err := fs.Sub(assets, "assets")
+	if err != nil {
+		// The assets are embedded at build time
+		panic(err)
+	}
+	files := http.StripPrefix(Path, http.FileServer(http.FS(root)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicy)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		// Pick up new assets after an upgrade
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,303 @@
+// Minimal admin console. Every request goes to the admin API with the
+// signed-in admin's access token, which is kept for the browser tab only.
+"use strict";
+
+const API = "/api/v1";
+const PAGE_SIZE = 20;
+
+const state = {
+  mfaToken: "",
+  query: "",
+  offset: 0,
+  auditPages: [], // the before cursor of each audit page visited
+};
+
+const $ = (id) => document.getElementById(id);
+
+function tokens() {
+  return {
+    access: sessionStorage.getItem("accessToken") || "",
+    refresh: sessionStorage.getItem("refreshToken") || "",
+  };
+}
+
+function storeTokens(response) {
+  sessionStorage.setItem("accessToken", response.accessToken);
+  sessionStorage.setItem("refreshToken", response.refreshToken || "");
+}
+
+function clearTokens() {
+  sessionStorage.removeItem("accessToken");
+  sessionStorage.removeItem("refreshToken");
+}
+
+function showMessage(text, isError) {
+  const message = $("message");
+  message.textContent = text;
+  message.classList.toggle("error", Boolean(isError));
+  message.hidden = !text;
+}
+
+// api calls the API and returns the decoded response body. Errors carry the
+// message of the error envelope.
+async function api(method, path, body) {
+  const headers = { Accept: "application/json" };
+  const { access } = tokens();
+  if (access) {
+    headers.Authorization = "Bearer " + access;
+  }
+  if (body !== undefined) {
+    headers["Content-Type"] = "application/json";
+  }
+  const response = await fetch(API + path, {
+    method,
+    headers,
+    body: body === undefined ? undefined : JSON.stringify(body),
+    credentials: "same-origin",
+  });
+  const data = await response.json().catch(() => ({}));
+  if (response.status === 401 && access) {
+    clearTokens();
+    show("login");
+  }
+  if (!response.ok) {
+    const error = new Error(data.error || response.statusText);
+    error.status = response.status;
+    throw error;
+  }
+  return { status: response.status, data };
+}
+
+function show(view) {
+  const signedIn = view !== "login";
+  $("nav").hidden = !signedIn;
+  for (const name of ["login", "users", "audit"]) {
+    $(name + "-view").hidden = name !== view;
+  }
+  for (const button of document.querySelectorAll("nav [data-view]")) {
+    button.classList.toggle("active", button.dataset.view === view);
+  }
+  if (view === "login") {
+    $("login-form").hidden = false;
+    $("mfa-form").hidden = true;
+  }
+}
+
+function cell(row, text) {
+  const td = document.createElement("td");
+  td.textContent = text;
+  row.appendChild(td);
+  return td;
+}
+
+function formatTime(value) {
+  return value ? new Date(value).toLocaleString() : "";
+}
+
+// Sign in
+
+async function signIn(event) {
+  event.preventDefault();
+  const form = event.target;
+  try {
+    const { status, data } = await api("POST", "/auth/login", {
+      emailOrUsername: form.emailOrUsername.value,
+      password: form.password.value,
+    });
+    form.password.value = "";
+    if (status === 202) {
+      if (data.action !== "verify") {
+        showMessage(data.message || "Finish setting up a second factor in the web app first.", true);
+        return;
+      }
+      state.mfaToken = data.mfaToken;
+      $("login-form").hidden = true;
+      $("mfa-form").hidden = false;
+      showMessage("Enter the code of your authenticator.");
+      return;
+    }
+    signedIn(data);
+  } catch (error) {
+    showMessage(error.message, true);
+  }
+}
+
+async function verifyMFA(event) {
+  event.preventDefault();
+  const form = event.target;
+  try {
+    const { data } = await api("POST", "/auth/mfa/verify", {
+      mfaToken: state.mfaToken,
+      code: form.code.value,
+    });
+    form.code.value = "";
+    state.mfaToken = "";
+    signedIn(data);
+  } catch (error) {
+    showMessage(error.message, true);
+  }
+}
+
+function signedIn(response) {
+  storeTokens(response);
+  showMessage("");
+  show("users");
+  searchUsers();
+}
+
+async function signOut() {
+  const { refresh } = tokens();
+  try {
+    await api("POST", "/auth/logout", { refreshToken: refresh });
+  } catch (error) {
+    // The tokens are forgotten either way
+  }
+  clearTokens();
+  showMessage("Signed out.");
+  show("login");
+}
+
+// Users
+
+async function searchUsers() {
+  const params = new URLSearchParams({ q: state.query, offset: state.offset, limit: PAGE_SIZE });
+  try {
+    const { data } = await api("GET", "/admin/users/search?" + params);
+    renderUsers(data);
+  } catch (error) {
+    showMessage(error.message, true);
+  }
+}
+
+function renderUsers(result) {
+  const body = $("users");
+  body.replaceChildren();
+  for (const user of result.users) {
+    const row = document.createElement("tr");
+    cell(row, user.email);
+    cell(row, user.username);
+    cell(row, [user.firstName, user.lastName].filter(Boolean).join(" "));
+    cell(row, user.status);
+    cell(row, formatTime(user.createdAt));
+    const actions = cell(row, "");
+    const suspended = user.status === "suspended";
+    const button = document.createElement("button");
+    button.type = "button";
+    button.textContent = suspended ? "Reactivate" : "Suspend";
+    button.addEventListener("click", () => changeStatus(user, suspended ? "active" : "suspended"));
+    actions.appendChild(button);
+    body.appendChild(row);
+  }
+  const last = Math.min(state.offset + result.users.length, result.total);
+  $("search-total").textContent = result.total
+    ? `${state.offset + 1}–${last} of ${result.total} users`
+    : "No users found";
+  $("users-previous").disabled = state.offset === 0;
+  $("users-next").disabled = last >= result.total;
+}
+
+async function changeStatus(user, status) {
+  const verb = status === "suspended" ? "Suspend" : "Reactivate";
+  const reason = window.prompt(`${verb} ${user.email}? Give a reason for the audit log.`);
+  if (reason === null) {
+    return;
+  }
+  try {
+    await api("PUT", `/admin/users/${encodeURIComponent(user.id)}/status`, { status, reason });
+    showMessage(`${user.email} is now ${status}.`);
+    // The search index catches up with the change shortly
+    setTimeout(searchUsers, 1000);
+  } catch (error) {
+    showMessage(error.message, true);
+  }
+}
+
+// Audit log
+
+async function loadAudit() {
+  const before = state.auditPages[state.auditPages.length - 1] || 0;
+  const params = new URLSearchParams({ limit: PAGE_SIZE });
+  if (before) {
+    params.set("before", before);
+  }
+  try {
+    const { data } = await api("GET", "/admin/audit-log?" + params);
+    renderAudit(data);
+  } catch (error) {
+    if (error.status === 404) {
+      showMessage("The audit log is not enabled.", true);
+      return;
+    }
+    showMessage(error.message, true);
+  }
+}
+
+function renderAudit(entries) {
+  const body = $("audit-entries");
+  body.replaceChildren();
+  for (const entry of entries) {
+    const row = document.createElement("tr");
+    cell(row, entry.sequence);
+    cell(row, formatTime(entry.createdAt));
+    cell(row, entry.eventType);
+    cell(row, entry.actorId || "");
+    const payload = document.createElement("pre");
+    payload.textContent = JSON.stringify(entry.payload, null, 2);
+    cell(row, "").appendChild(payload);
+    body.appendChild(row);
+  }
+  $("audit-newest").disabled = state.auditPages.length === 0;
+  const oldest = entries[entries.length - 1];
+  $("audit-older").disabled = entries.length < PAGE_SIZE || !oldest || oldest.sequence <= 1;
+  $("audit-older").dataset.before = oldest ? oldest.sequence : "";
+}
+
+// Wiring
+
+$("login-form").addEventListener("submit", signIn);
+$("mfa-form").addEventListener("submit", verifyMFA);
+$("sign-out").addEventListener("click", signOut);
+
+$("search-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  state.query = event.target.q.value;
+  state.offset = 0;
+  searchUsers();
+});
+$("users-previous").addEventListener("click", () => {
+  state.offset = Math.max(0, state.offset - PAGE_SIZE);
+  searchUsers();
+});
+$("users-next").addEventListener("click", () => {
+  state.offset += PAGE_SIZE;
+  searchUsers();
+});
+
+$("audit-newest").addEventListener("click", () => {
+  state.auditPages = [];
+  loadAudit();
+});
+$("audit-older").addEventListener("click", (event) => {
+  state.auditPages.push(Number(event.target.dataset.before));
+  loadAudit();
+});
+
+for (const button of document.querySelectorAll("nav [data-view]")) {
+  button.addEventListener("click", () => {
+    showMessage("");
+    show(button.dataset.view);
+    if (button.dataset.view === "audit") {
+      state.auditPages = [];
+      loadAudit();
+    } else {
+      searchUsers();
+    }
+  });
+}
+
+if (tokens().access) {
+  show("users");
+  searchUsers();
+} else {
+  show("login");
+}
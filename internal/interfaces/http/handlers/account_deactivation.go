@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+)
+
+// RestoreAccountRequest represents the request body for restoring a
+// deactivated account. Deactivated users cannot sign in, so they prove the
+// account is theirs with their credentials.
+type RestoreAccountRequest struct {
+	EmailOrUsername string `json:"emailOrUsername"`
+	Password        string `json:"password"`
+}
+
+// @Summary Delete account
+// @Description Deactivate the authenticated user's account and revoke their sessions. The account can
+// @Description be restored until purgeAfter, when it is deleted permanently.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} AccountDeactivation "Deactivated account"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 409 {object} ErrorResponse "Suspended accounts cannot be deleted"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me [delete]
+func (h *UserHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusAccepted, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	deactivation, err := h.userService.DeleteUser(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrInvalidStatusTransition) {
+			h.handleError(w, r, err, http.StatusConflict, "account cannot be deleted in its current status")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to delete account")
+		return
+	}
+
+	h.cookies.clearTokenCookies(w)
+	h.respondJSON(w, http.StatusAccepted, AccountDeactivation{
+		UserID:        deactivation.UserID.String(),
+		DeactivatedAt: deactivation.DeactivatedAt,
+		PurgeAfter:    deactivation.PurgeAfter,
+	})
+}
+
+// @Summary Restore account
+// @Description Restore a deactivated account before it is purged. The user signs in afterwards as usual.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body RestoreAccountRequest true "Credentials of the account"
+// @Success 200 {object} User "Restored user"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Invalid credentials"
+// @Failure 409 {object} ErrorResponse "Account is not deactivated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/restore [post]
+func (h *UserHandler) RestoreAccount(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req RestoreAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.userService.RestoreAccount(r.Context(), req.EmailOrUsername, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials), errors.Is(err, domainerrors.ErrUserNotFound):
+			h.handleError(w, r, err, http.StatusUnauthorized, "invalid credentials")
+		case errors.Is(err, services.ErrPasswordResetRequired):
+			h.handleError(w, r, err, http.StatusForbidden, "password reset required")
+		case errors.Is(err, domainerrors.ErrInvalidStatusTransition):
+			h.handleError(w, r, err, http.StatusConflict, "account is not deactivated")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to restore account")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, h.userResponse(user))
+}
+
+// @Summary Restore a user
+// @Description Restore a deactivated account on behalf of its user before it is purged
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} User "Restored user"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 409 {object} ErrorResponse "User is not deactivated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/restore [post]
+func (h *AdminHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, adminID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.RestoreUser(r.Context(), id, adminID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainerrors.ErrUserNotFound):
+			h.handleError(w, r, err, http.StatusNotFound, "user not found")
+		case errors.Is(err, domainerrors.ErrInvalidStatusTransition):
+			h.handleError(w, r, err, http.StatusConflict, "user is not deactivated")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to restore user")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newUserResponse(user))
+}
@@ -0,0 +1,582 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// AdminHandler handles HTTP requests for administrative operations
+type AdminHandler struct {
+	baseHandler
+	userService  services.UserService
+	tokenService services.TokenService
+}
+
+// maxBreachImportUsers bounds the users reported by a single breach import
+const maxBreachImportUsers = 1000
+
+// UpdateUserStatusRequest represents the request body for changing a user's status
+type UpdateUserStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(
+	userService services.UserService,
+	tokenService services.TokenService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *AdminHandler {
+	return &AdminHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		userService:  userService,
+		tokenService: tokenService,
+	}
+}
+
+// @Summary Get username history
+// @Description Get the previous usernames of a user, most recent first
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {array} UsernameChange "Username history"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/username-history [get]
+func (h *AdminHandler) GetUsernameHistory(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	changes, err := h.userService.GetUsernameHistory(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get username history")
+		return
+	}
+
+	response := make([]UsernameChange, 0, len(changes))
+	for _, change := range changes {
+		response = append(response, UsernameChange{
+			OldUsername:   change.OldUsername,
+			NewUsername:   change.NewUsername,
+			ChangedAt:     change.ChangedAt,
+			ReservedUntil: change.ReservedUntil,
+		})
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Change user status
+// @Description Move a user to another lifecycle status (pending, active, suspended or deleted).
+// @Description Only transitions allowed by the user lifecycle are accepted.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body UpdateUserStatusRequest true "New status and reason"
+// @Success 200 {object} User "Updated user"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 409 {object} ErrorResponse "Transition not allowed"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/status [put]
+func (h *AdminHandler) UpdateUserStatus(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	var req UpdateUserStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.userService.ChangeUserStatus(r.Context(), id, models.UserStatus(req.Status), req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, "unknown user status")
+		case errors.Is(err, domainerrors.ErrInvalidStatusTransition):
+			h.handleError(w, r, err, http.StatusConflict, err.Error())
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to change user status")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newUserResponse(user))
+}
+
+// @Summary Set user organization
+// @Description Move a user into an organization, whose settings then apply to them. An empty organization ID removes the user from their organization.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body SetUserOrganizationRequest true "Organization ID"
+// @Success 200 {object} User "Updated user"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/organization [put]
+func (h *AdminHandler) SetUserOrganization(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	var req SetUserOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	var organizationID *uuid.UUID
+	if req.OrganizationID != "" {
+		parsed, err := uuid.Parse(req.OrganizationID)
+		if err != nil {
+			h.handleError(w, r, err, http.StatusBadRequest, "invalid organization ID")
+			return
+		}
+		organizationID = &parsed
+	}
+
+	user, err := h.userService.SetOrganization(r.Context(), id, organizationID)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to set user organization")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newUserResponse(user))
+}
+
+// @Summary Reset user MFA
+// @Description Remove the authenticator app of a user, e.g. after they lost their device. Users covered by an
+// @Description enforced MFA policy set up a new one at their next login.
+// @Tags admin
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 204 "MFA reset"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 409 {object} ErrorResponse "MFA not enabled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/mfa [delete]
+func (h *AdminHandler) ResetUserMFA(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.userService.ResetMFA(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrMFANotEnabled) {
+			h.handleError(w, r, err, http.StatusConflict, "MFA is not enabled")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to reset MFA")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Import breached credentials
+// @Description Report users whose credentials were found in a breach, by ID or email. Their sessions are revoked,
+// @Description password login is blocked until they reset their password and they are sent a reset link. Users
+// @Description who already have to reset their password are not notified again.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CredentialBreachImportRequest true "Breached users"
+// @Success 200 {object} CredentialBreachImportResponse "Outcome per user"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /admin/credential-breaches [post]
+func (h *AdminHandler) ImportBreachedCredentials(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req CredentialBreachImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.UserIDs)+len(req.Emails) == 0 {
+		h.handleError(w, r, domainerrors.ErrInvalidInput, http.StatusBadRequest, "no users reported")
+		return
+	}
+	if len(req.UserIDs)+len(req.Emails) > maxBreachImportUsers {
+		h.handleError(w, r, domainerrors.ErrInvalidInput, http.StatusBadRequest,
+			"at most "+strconv.Itoa(maxBreachImportUsers)+" users can be reported at once")
+		return
+	}
+	source := req.Source
+	if source == "" {
+		source = "admin import"
+	}
+
+	response := CredentialBreachImportResponse{
+		Results: make([]CredentialBreachResult, 0, len(req.UserIDs)+len(req.Emails)),
+	}
+	respond := func(user string, breach services.CredentialBreach) {
+		notified, err := h.userService.RespondToCredentialBreach(r.Context(), breach)
+		status := "notified"
+		switch {
+		case errors.Is(err, domainerrors.ErrUserNotFound):
+			status = "not_found"
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			status = "invalid"
+		case err != nil:
+			h.logger.Error("failed to respond to credential breach", zap.String("user", user), zap.Error(err))
+			status = "failed"
+		case !notified:
+			status = "already_required"
+		default:
+			response.Notified++
+		}
+		response.Results = append(response.Results, CredentialBreachResult{User: user, Status: status})
+	}
+
+	for _, userID := range req.UserIDs {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			response.Results = append(response.Results, CredentialBreachResult{User: userID, Status: "invalid"})
+			continue
+		}
+		respond(userID, services.CredentialBreach{UserID: id, Source: source})
+	}
+	for _, email := range req.Emails {
+		respond(email, services.CredentialBreach{Email: email, Source: source})
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Get email verification state
+// @Description Get the verification emails sent to a user with their state (sent, clicked or expired), most recent first
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} EmailVerificationState "Email verification state"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/email-verification [get]
+func (h *AdminHandler) GetEmailVerification(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	state, err := h.userService.GetEmailVerificationState(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get email verification state")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newEmailVerificationState(state))
+}
+
+// @Summary Get effective permissions
+// @Description Resolve a user's roles into the concrete permissions they currently hold, with what granted each permission
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} EffectivePermissions "Effective permissions"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/permissions [get]
+func (h *AdminHandler) GetPermissions(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	permissions, err := h.userService.GetEffectivePermissions(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrUserNotFound) || services.IsNotFoundError(err) {
+			h.handleError(w, r, err, http.StatusNotFound, "user not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get effective permissions")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newEffectivePermissions(permissions))
+}
+
+// @Summary Get account risk
+// @Description Get the risk state of a user's account. Failed logins and logins from new devices or countries
+// @Description raise it: logins to watched accounts need a CAPTCHA, and logins to restricted accounts also need a
+// @Description second factor. Raised states expire.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} AccountRisk "Account risk state"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/risk [get]
+func (h *AdminHandler) GetAccountRisk(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	risk, err := h.userService.GetAccountRisk(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrUserNotFound) || services.IsNotFoundError(err) {
+			h.handleError(w, r, err, http.StatusNotFound, "user not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get account risk")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newAccountRisk(id, risk))
+}
+
+// @Summary Reset account risk
+// @Description Return a user's account to the normal risk state and forget their failed logins, e.g. after
+// @Description verifying the user through support
+// @Tags admin
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 204 "Account risk reset"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/risk [delete]
+func (h *AdminHandler) ResetAccountRisk(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.userService.ResetAccountRisk(r.Context(), id); err != nil {
+		if errors.Is(err, domainerrors.ErrUserNotFound) || services.IsNotFoundError(err) {
+			h.handleError(w, r, err, http.StatusNotFound, "user not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to reset account risk")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Search users
+// @Description Full-text search over email, username and name in the search index. The index is
+// @Description synced from the event stream and may briefly lag behind recent changes.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Search text; empty lists the most recently created users"
+// @Param status query string false "Only users with this status"
+// @Param offset query int false "Number of users to skip"
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Success 200 {object} UserSearchResponse "Matching users"
+// @Failure 400 {object} ErrorResponse "Invalid query"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 501 {object} ErrorResponse "Search is not enabled"
+// @Router /admin/users/search [get]
+func (h *AdminHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	params := r.URL.Query()
+	query := services.UserSearchQuery{
+		Query:  params.Get("q"),
+		Status: models.UserStatus(params.Get("status")),
+	}
+	pagination := []struct {
+		name   string
+		target *int
+	}{{"offset", &query.Offset}, {"limit", &query.Limit}}
+	for _, param := range pagination {
+		if value := params.Get(param.name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				h.handleError(w, r, err, http.StatusBadRequest, "invalid "+param.name)
+				return
+			}
+			*param.target = parsed
+		}
+	}
+
+	result, err := h.userService.SearchUsers(r.Context(), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSearchUnavailable):
+			h.handleError(w, r, err, http.StatusNotImplemented, "user search is not enabled")
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, "invalid search query")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to search users")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newUserSearchResponse(result))
+}
+
+// @Summary List signing keys
+// @Description List the current token signing keys with their age and rotation status. Key material is never returned.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} SigningKey "Signing keys"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/signing-keys [get]
+func (h *AdminHandler) ListSigningKeys(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	keys, err := h.tokenService.ListSigningKeys(r.Context())
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to list signing keys")
+		return
+	}
+
+	response := make([]SigningKey, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, newSigningKey(key))
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Rotate signing key
+// @Description Replace the signing key of a token type. Tokens signed with the previous key remain valid until the next rotation.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param type path string true "Token type (access, refresh, reset or verification)"
+// @Success 200 {object} SigningKey "New signing key"
+// @Failure 400 {object} ErrorResponse "Unknown token type"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 501 {object} ErrorResponse "Key rotation not supported"
+// @Router /admin/signing-keys/{type}/rotate [post]
+func (h *AdminHandler) RotateSigningKey(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	tokenType := services.TokenType(mux.Vars(r)["type"])
+	if !isSigningKeyTokenType(tokenType) {
+		h.handleError(w, r, errors.New("unknown token type"), http.StatusBadRequest, "unknown token type")
+		return
+	}
+
+	key, err := h.tokenService.RotateSigningKey(r.Context(), tokenType)
+	if err != nil {
+		if errors.Is(err, services.ErrKeyRotationUnsupported) {
+			h.handleError(w, r, err, http.StatusNotImplemented, "signing key rotation is not supported")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to rotate signing key")
+		return
+	}
+
+	h.logger.Info("signing key rotated",
+		zap.String("tokenType", string(tokenType)),
+		zap.String("keyId", key.KeyID))
+	h.respondJSON(w, http.StatusOK, newSigningKey(*key))
+}
+
+// isSigningKeyTokenType reports whether tokenType has its own signing key
+func isSigningKeyTokenType(tokenType services.TokenType) bool {
+	for _, t := range services.SigningKeyTokenTypes {
+		if t == tokenType {
+			return true
+		}
+	}
+	return false
+}
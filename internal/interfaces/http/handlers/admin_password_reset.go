@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// AdminPasswordResetRequest represents the request body for sending a user
+// the password reset email on their behalf. The admin re-enters their own
+// password, and a current authenticator code when they set one up.
+type AdminPasswordResetRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code,omitempty"`
+}
+
+// @Summary Send a user the password reset email
+// @Description Send a user the password reset email on their behalf. The reset link only goes to the user; the
+// @Description token is never returned. The admin re-authenticates with their password and authenticator code,
+// @Description attempts are limited per admin per hour, and sent and refused requests are audited.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body AdminPasswordResetRequest true "Admin's password and authenticator code"
+// @Success 202 {object} MessageResponse "Password reset email queued"
+// @Failure 400 {object} ErrorResponse "Invalid request or service account"
+// @Failure 403 {object} ErrorResponse "Forbidden or re-authentication failed"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 429 {object} ErrorResponse "Admin password reset limit reached"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/password-reset [post]
+func (h *AdminHandler) RequestUserPasswordReset(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusAccepted, time.Since(start).Seconds())
+	}()
+
+	id, adminID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req AdminPasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err := h.userService.AdminRequestPasswordReset(r.Context(), id, adminID, services.StepUpInput{
+		Password: req.Password,
+		Code:     req.Code,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAdminPasswordResetLimitReached):
+			w.Header().Set("Retry-After", "3600")
+			h.handleError(w, r, err, http.StatusTooManyRequests, "password reset limit reached, try again later")
+		case errors.Is(err, services.ErrStepUpFailed):
+			h.handleError(w, r, err, http.StatusForbidden, "re-authentication failed")
+		case errors.Is(err, domainerrors.ErrUserNotFound):
+			h.handleError(w, r, err, http.StatusNotFound, "user not found")
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to request password reset")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, MessageResponse{
+		Message: "password reset email queued",
+	})
+}
@@ -1,16 +1,47 @@
 package handlers
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
 
 // User represents the user model for API responses
 type User struct {
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	Username      string `json:"username"`
+	FirstName     string `json:"firstName"`
+	LastName      string `json:"lastName"`
+	PhoneNumber   string `json:"phoneNumber,omitempty"`
+	Locale        string `json:"locale,omitempty"`
+	TimeZone      string `json:"timeZone,omitempty"`
+	EmailVerified bool   `json:"emailVerified"`
+	Status        string `json:"status"`
+	Role          string `json:"role,omitempty"`
+	AvatarURL     string `json:"avatarUrl,omitempty"`
+	// RecoveryEmail is the verified address password resets can also be sent to
+	RecoveryEmail string `json:"recoveryEmail,omitempty"`
+	// OrganizationID is the organization whose settings apply to the user
+	OrganizationID string `json:"organizationId,omitempty"`
+	// PasswordResetRequired is set while password login is blocked, e.g.
+	// after the user's credentials were found in a breach
+	PasswordResetRequired bool `json:"passwordResetRequired,omitempty"`
+	// ServiceAccount is set on machine users that authenticate with API keys
+	ServiceAccount bool      `json:"serviceAccount,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// PublicProfile represents the part of a user shown to other users
+type PublicProfile struct {
 	ID        string    `json:"id"`
-	Email     string    `json:"email"`
 	Username  string    `json:"username"`
-	FirstName string    `json:"firstName"`
-	LastName  string    `json:"lastName"`
+	AvatarURL string    `json:"avatarUrl,omitempty"`
 	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 // TokenPair represents a pair of access and refresh tokens
@@ -19,3 +50,1380 @@ type TokenPair struct {
 	RefreshToken string `json:"refreshToken"`
 	ExpiresIn    int64  `json:"expiresIn"`
 }
+
+// LoginResponse represents a successful login response
+type LoginResponse struct {
+	AccessToken      string    `json:"accessToken"`
+	RefreshToken     string    `json:"refreshToken"`
+	TokenType        string    `json:"tokenType"`
+	ExpiresIn        int64     `json:"expiresIn"`        // access token lifetime in seconds
+	RefreshExpiresIn int64     `json:"refreshExpiresIn"` // refresh token lifetime in seconds
+	ExpiresAt        time.Time `json:"expiresAt"`
+	User             User      `json:"user"`
+	// MFAEnrollmentDeadline is when a policy starts requiring the user to
+	// sign in with a second factor they have not set up yet
+	MFAEnrollmentDeadline *time.Time `json:"mfaEnrollmentDeadline,omitempty"`
+	Notice                string     `json:"notice,omitempty"`
+}
+
+// MFAChallengeResponse represents a login that needs a second factor. With
+// action verify the client posts a code to /auth/mfa/verify; with action
+// enroll it sets up an authenticator through /auth/mfa/enroll first.
+type MFAChallengeResponse struct {
+	MFAToken  string    `json:"mfaToken"`
+	Action    string    `json:"action"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Message   string    `json:"message"`
+}
+
+// MFAVerifyRequest represents the request body for completing a login with a second factor
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfaToken"`
+	Code     string `json:"code"`
+}
+
+// MFAEnrollRequest represents the request body for setting up an
+// authenticator during a login that requires one
+type MFAEnrollRequest struct {
+	MFAToken string `json:"mfaToken"`
+}
+
+// MFACodeRequest represents a request body carrying an authenticator code
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+// TOTPEnrollment represents a started authenticator app setup
+type TOTPEnrollment struct {
+	Secret string `json:"secret"` // base32, for manual entry
+	URI    string `json:"uri"`    // otpauth:// URI, usually shown as QR code
+}
+
+// PasskeyRelyingParty identifies the service to authenticators
+type PasskeyRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// PasskeyUser describes the account a passkey is created for
+type PasskeyUser struct {
+	ID          string `json:"id"` // base64url user handle
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// PasskeyCredentialParameter names a public key algorithm authenticators may use
+type PasskeyCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"` // COSE algorithm
+}
+
+// PasskeyCredentialDescriptor names a passkey by its base64url credential ID
+type PasskeyCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// PasskeyAuthenticatorSelection states what authenticators must support
+type PasskeyAuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// PasskeyCreationOptions represents the options of navigator.credentials.create
+// in the JSON format PublicKeyCredential.parseCreationOptionsFromJSON reads
+type PasskeyCreationOptions struct {
+	Challenge              string                        `json:"challenge"` // base64url
+	RP                     PasskeyRelyingParty           `json:"rp"`
+	User                   PasskeyUser                   `json:"user"`
+	PubKeyCredParams       []PasskeyCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                         `json:"timeout"` // in milliseconds
+	ExcludeCredentials     []PasskeyCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection PasskeyAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                        `json:"attestation"`
+}
+
+// PasskeyRequestOptions represents the options of navigator.credentials.get
+// in the JSON format PublicKeyCredential.parseRequestOptionsFromJSON reads
+type PasskeyRequestOptions struct {
+	Challenge        string                        `json:"challenge"` // base64url
+	RPID             string                        `json:"rpId"`
+	Timeout          int64                         `json:"timeout"` // in milliseconds
+	AllowCredentials []PasskeyCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                        `json:"userVerification"`
+}
+
+// PasskeyRegistrationResponse represents a started passkey registration
+type PasskeyRegistrationResponse struct {
+	Token     string                 `json:"token"`
+	ExpiresAt time.Time              `json:"expiresAt"`
+	PublicKey PasskeyCreationOptions `json:"publicKey"`
+}
+
+// PasskeyLoginResponse represents a started passkey sign-in
+type PasskeyLoginResponse struct {
+	Token     string                `json:"token"`
+	ExpiresAt time.Time             `json:"expiresAt"`
+	PublicKey PasskeyRequestOptions `json:"publicKey"`
+}
+
+// PasskeyAttestationResponse is the authenticator's response to creating a
+// passkey, with base64url fields
+type PasskeyAttestationResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"`
+}
+
+// PasskeyAttestationCredential is the credential navigator.credentials.create
+// returned, serialized with PublicKeyCredential.toJSON
+type PasskeyAttestationCredential struct {
+	ID       string                     `json:"id"`
+	RawID    string                     `json:"rawId"`
+	Type     string                     `json:"type"`
+	Response PasskeyAttestationResponse `json:"response"`
+}
+
+// FinishPasskeyRegistrationRequest represents the request body for finishing a passkey registration
+type FinishPasskeyRegistrationRequest struct {
+	Token      string                       `json:"token"`
+	Name       string                       `json:"name"` // e.g. the device; defaults to Passkey
+	Credential PasskeyAttestationCredential `json:"credential"`
+}
+
+// PasskeyAssertionResponse is the authenticator's response to signing in
+// with a passkey, with base64url fields
+type PasskeyAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
+
+// PasskeyAssertionCredential is the credential navigator.credentials.get
+// returned, serialized with PublicKeyCredential.toJSON
+type PasskeyAssertionCredential struct {
+	ID       string                   `json:"id"`
+	RawID    string                   `json:"rawId"`
+	Type     string                   `json:"type"`
+	Response PasskeyAssertionResponse `json:"response"`
+}
+
+// FinishPasskeyLoginRequest represents the request body for signing in with a passkey
+type FinishPasskeyLoginRequest struct {
+	Token      string                     `json:"token"`
+	Credential PasskeyAssertionCredential `json:"credential"`
+}
+
+// RequestMagicLinkRequest represents the request body for requesting a login link
+type RequestMagicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+// ConfirmLoginRequest represents the request body for confirming a login
+// from a new device with the token of the emailed link
+type ConfirmLoginRequest struct {
+	Token string `json:"token"`
+}
+
+// RecoveryEmailRequest represents the request body for setting a recovery
+// email, or for requesting a password reset through one
+type RecoveryEmailRequest struct {
+	Email string `json:"email"`
+}
+
+// Passkey represents a registered passkey for API responses
+type Passkey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// UserIdentity represents an external identity linked to a user for API responses
+type UserIdentity struct {
+	ID          string     `json:"id"`
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email,omitempty"`
+	LastLoginAt *time.Time `json:"lastLoginAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// LoginMethodsResponse represents the ways a user can sign in
+type LoginMethodsResponse struct {
+	HasPassword bool           `json:"hasPassword"`
+	Passkeys    []Passkey      `json:"passkeys"`
+	Identities  []UserIdentity `json:"identities"`
+}
+
+// LinkIdentityResponse represents a started account link
+type LinkIdentityResponse struct {
+	// AuthorizationURL is where the browser is sent to sign in with the
+	// provider; the provider's callback then links the identity
+	AuthorizationURL string `json:"authorizationUrl"`
+}
+
+// MFAStatus represents a user's second factor and what policies require of them
+type MFAStatus struct {
+	TOTPEnabled  bool       `json:"totpEnabled"`
+	EnabledAt    *time.Time `json:"enabledAt,omitempty"`
+	Required     bool       `json:"required"`
+	EnforceAfter *time.Time `json:"enforceAfter,omitempty"`
+}
+
+// PurgeApproval represents an admin's approval to permanently delete a
+// user, which a second admin redeems with the token before it expires
+type PurgeApproval struct {
+	ApprovalToken string    `json:"approvalToken"`
+	UserID        string    `json:"userId"`
+	ApproverID    string    `json:"approverId"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+}
+
+// ProfileCompleteness represents how much of a user's profile is filled in.
+// Field names are first_name, last_name, phone_number, locale and
+// email_verified.
+type ProfileCompleteness struct {
+	Percent  int      `json:"percent"`
+	Fields   []string `json:"fields"`   // fields completeness is measured over
+	Missing  []string `json:"missing"`  // fields not filled in yet
+	Required []string `json:"required"` // missing fields the user must still provide
+}
+
+// AccountDeactivation represents a deleted account that can be restored
+// until it is purged
+type AccountDeactivation struct {
+	UserID        string    `json:"userId"`
+	DeactivatedAt time.Time `json:"deactivatedAt"`
+	PurgeAfter    time.Time `json:"purgeAfter"`
+}
+
+// Consent represents what a user consents to. Marketing-relevant events,
+// e.g. profile completeness reminders, are not published about users who
+// do not consent to marketing.
+type Consent struct {
+	Marketing bool `json:"marketing"`
+}
+
+// MFAPolicy represents an MFA enforcement policy. Without an organization
+// it covers every user, without roles every role.
+type MFAPolicy struct {
+	ID             string     `json:"id,omitempty"`
+	Name           string     `json:"name"`
+	OrganizationID string     `json:"organizationId,omitempty"`
+	Roles          []string   `json:"roles,omitempty"`
+	EnforceAfter   time.Time  `json:"enforceAfter"`
+	CreatedAt      *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+}
+
+// Role represents a role and the permissions it grants
+type Role struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Permissions []string   `json:"permissions"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+// RolePermissionsRequest represents the request body for replacing the
+// permissions of a role
+type RolePermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+// AssignRoleRequest represents the request body for assigning a user a role
+type AssignRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// Organization represents an organization for API responses
+type Organization struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// DomainJoinPolicy is off, invite or join
+	DomainJoinPolicy string    `json:"domainJoinPolicy"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// CreateOrganizationRequest represents the request body for creating an organization
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+// InviteMemberRequest represents the request body for inviting a user into
+// an organization. Role is owner, admin or member, and defaults to member.
+type InviteMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role,omitempty"`
+}
+
+// OrganizationDomain represents an email domain claimed by an organization
+// for API responses. The domain join policy applies to its users once a DNS
+// TXT record with the verification name and value is published and the
+// domain verified.
+type OrganizationDomain struct {
+	Domain             string     `json:"domain"`
+	Verified           bool       `json:"verified"`
+	VerificationRecord string     `json:"verificationRecord"`
+	VerificationValue  string     `json:"verificationValue"`
+	VerifiedAt         *time.Time `json:"verifiedAt,omitempty"`
+}
+
+// AddOrganizationDomainRequest represents the request body for claiming an
+// email domain for an organization
+type AddOrganizationDomainRequest struct {
+	Domain string `json:"domain"`
+}
+
+// DomainJoinPolicyRequest represents the request body for setting what
+// happens to users whose verified email is in one of the organization's
+// verified domains: off, invite or join
+type DomainJoinPolicyRequest struct {
+	Policy string `json:"policy"`
+}
+
+// OrganizationInvitation represents an invitation of the authenticated user
+// into an organization for API responses
+type OrganizationInvitation struct {
+	Organization Organization `json:"organization"`
+	Role         string       `json:"role"`
+	InvitedAt    time.Time    `json:"invitedAt"`
+}
+
+// OrganizationMember represents a member of an organization for API responses
+type OrganizationMember struct {
+	User      User      `json:"user"`
+	Role      string    `json:"role"`
+	InvitedBy string    `json:"invitedBy,omitempty"`
+	JoinedAt  time.Time `json:"joinedAt"`
+}
+
+// SSOConnection represents an SSO connection of an organization for API
+// responses. The client secret is never returned.
+type SSOConnection struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Protocol string `json:"protocol"`
+	Issuer   string `json:"issuer"`
+	ClientID string `json:"clientId"`
+	Enabled  bool   `json:"enabled"`
+	// RedirectURL is the callback URL to register with the identity provider
+	RedirectURL string      `json:"redirectUrl"`
+	Domains     []SSODomain `json:"domains"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+// SSODomain represents an email domain claimed by an SSO connection for API
+// responses. The claim takes effect once a DNS TXT record with the
+// verification name and value is published and the domain verified.
+type SSODomain struct {
+	Domain             string     `json:"domain"`
+	Verified           bool       `json:"verified"`
+	VerificationRecord string     `json:"verificationRecord"`
+	VerificationValue  string     `json:"verificationValue"`
+	VerifiedAt         *time.Time `json:"verifiedAt,omitempty"`
+}
+
+// SSOConnectionRequest represents the request body for creating or
+// updating an OpenID Connect SSO connection. The client secret may be left
+// out on updates to keep the current one. Enabled defaults to true.
+type SSOConnectionRequest struct {
+	Name         string `json:"name"`
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+	Enabled      *bool  `json:"enabled,omitempty"`
+}
+
+// AddSSODomainRequest represents the request body for claiming an email
+// domain for an SSO connection
+type AddSSODomainRequest struct {
+	Domain string `json:"domain"`
+}
+
+// UsernameChange represents a username history entry for API responses
+type UsernameChange struct {
+	OldUsername   string     `json:"oldUsername"`
+	NewUsername   string     `json:"newUsername"`
+	ChangedAt     time.Time  `json:"changedAt"`
+	ReservedUntil *time.Time `json:"reservedUntil,omitempty"`
+}
+
+// SigningKey represents signing key metadata for API responses
+type SigningKey struct {
+	KeyID     string     `json:"kid"`
+	TokenType string     `json:"tokenType"`
+	Algorithm string     `json:"algorithm"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	NotAfter  *time.Time `json:"notAfter,omitempty"`
+	Status    string     `json:"status"`
+}
+
+// JSONWebKey represents a public signing key in JWK format (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet represents the public signing keys for API responses
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// PasswordPolicy represents an organization's password policy
+type PasswordPolicy struct {
+	MinLength           int  `json:"minLength"`
+	MaxLength           int  `json:"maxLength"`
+	RequireUppercase    bool `json:"requireUppercase"`
+	RequireLowercase    bool `json:"requireLowercase"`
+	RequireNumbers      bool `json:"requireNumbers"`
+	RequireSpecialChars bool `json:"requireSpecialChars"`
+}
+
+// EmailTemplate represents an organization's version of an email
+type EmailTemplate struct {
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"htmlBody,omitempty"`
+	TextBody string `json:"textBody,omitempty"`
+}
+
+// AccessPolicy represents where and when an organization's users may log in
+type AccessPolicy struct {
+	AllowedCountries []string       `json:"allowedCountries,omitempty"` // ISO 3166-1 alpha-2 codes
+	BusinessHours    *BusinessHours `json:"businessHours,omitempty"`
+}
+
+// BusinessHours represents the weekly window an organization's users may log in
+type BusinessHours struct {
+	Timezone string   `json:"timezone"`       // IANA name, e.g. Europe/Berlin
+	Days     []string `json:"days,omitempty"` // mon to sun; empty allows every day
+	Start    string   `json:"start"`          // HH:MM
+	End      string   `json:"end"`            // HH:MM; before start runs past midnight
+}
+
+// OrganizationSettings represents the configuration overrides of an
+// organization. Omitted settings use the service-wide configuration.
+type OrganizationSettings struct {
+	OrganizationID         string                   `json:"organizationId,omitempty"`
+	PasswordPolicy         *PasswordPolicy          `json:"passwordPolicy,omitempty"`
+	AccessTokenTTLSeconds  *int                     `json:"accessTokenTtlSeconds,omitempty"`
+	RefreshTokenTTLSeconds *int                     `json:"refreshTokenTtlSeconds,omitempty"`
+	RequireMFA             *bool                    `json:"requireMfa,omitempty"`
+	MaxSessions            *int                     `json:"maxSessions,omitempty"`        // concurrent sessions per user
+	SessionLimitAction     *string                  `json:"sessionLimitAction,omitempty"` // deny or evict_oldest
+	EmailTemplates         map[string]EmailTemplate `json:"emailTemplates,omitempty"`     // verification, password_reset, welcome, magic_link or recovery_email
+	AccessPolicy           *AccessPolicy            `json:"accessPolicy,omitempty"`
+	SecuritySummaries      *bool                    `json:"securitySummaries,omitempty"` // monthly security summary events
+	UpdatedAt              *time.Time               `json:"updatedAt,omitempty"`
+}
+
+// SetUserOrganizationRequest represents the request body for moving a user
+// into an organization; an empty organization ID removes the user from it
+type SetUserOrganizationRequest struct {
+	OrganizationID string `json:"organizationId"`
+}
+
+// CredentialBreachImportRequest represents the request body for reporting
+// users whose credentials were found in a breach
+type CredentialBreachImportRequest struct {
+	Source  string   `json:"source"` // e.g. the breach the list comes from
+	UserIDs []string `json:"userIds,omitempty"`
+	Emails  []string `json:"emails,omitempty"`
+}
+
+// CredentialBreachResult represents the outcome of the breach response for
+// one reported user
+type CredentialBreachResult struct {
+	User   string `json:"user"`   // user ID or email as reported
+	Status string `json:"status"` // notified, already_required, not_found, invalid or failed
+}
+
+// CredentialBreachImportResponse represents the outcome of a breach import
+type CredentialBreachImportResponse struct {
+	Results  []CredentialBreachResult `json:"results"`
+	Notified int                      `json:"notified"`
+}
+
+// AuditLogVerification represents the outcome of an audit log verification for API responses
+type AuditLogVerification struct {
+	Valid        bool       `json:"valid"`
+	Entries      int64      `json:"entries"`
+	HeadSequence int64      `json:"headSequence,omitempty"`
+	HeadHash     string     `json:"headHash,omitempty"`
+	HeadTime     *time.Time `json:"headTime,omitempty"`
+	BrokenAt     int64      `json:"brokenAt,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+}
+
+// AuditLogEntry represents a recorded event of the audit log for API responses
+type AuditLogEntry struct {
+	Sequence  int64           `json:"sequence"`
+	EventID   string          `json:"eventId"`
+	EventType string          `json:"eventType"`
+	ActorID   string          `json:"actorId,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
+	Hash      string          `json:"hash"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// OAuthClient represents a registered OAuth client for API responses
+type OAuthClient struct {
+	ClientID     string   `json:"clientId"`
+	Name         string   `json:"name"`
+	Confidential bool     `json:"confidential"`
+	RedirectURIs []string `json:"redirectUris"`
+	GrantTypes   []string `json:"grantTypes"`
+	Scopes       []string `json:"scopes"`
+	// PreviousSecretExpiresAt is when the secret replaced by the last
+	// rotation stops working
+	PreviousSecretExpiresAt *time.Time `json:"previousSecretExpiresAt,omitempty"`
+	DisabledAt              *time.Time `json:"disabledAt,omitempty"`
+	CreatedAt               time.Time  `json:"createdAt"`
+}
+
+// RegisterOAuthClientRequest represents the request body for registering an OAuth client
+type RegisterOAuthClientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectUris"`
+	GrantTypes   []string `json:"grantTypes"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+}
+
+// UpdateOAuthClientRequest represents the request body for reconfiguring an
+// OAuth client. Omitted fields are left unchanged.
+type UpdateOAuthClientRequest struct {
+	Name         string   `json:"name,omitempty"`
+	RedirectURIs []string `json:"redirectUris,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// RegenerateOAuthClientSecretRequest represents the optional request body for
+// regenerating a client secret
+type RegenerateOAuthClientSecretRequest struct {
+	// OverlapMinutes is how long the old secret keeps working; 0 revokes it
+	// immediately
+	OverlapMinutes int `json:"overlapMinutes"`
+}
+
+// OAuthClientSecret represents a newly issued client secret, which is only
+// returned once
+type OAuthClientSecret struct {
+	ClientID                string     `json:"clientId"`
+	ClientSecret            string     `json:"clientSecret,omitempty"`
+	PreviousSecretExpiresAt *time.Time `json:"previousSecretExpiresAt,omitempty"`
+}
+
+// RegisteredOAuthClient represents a newly registered client with its secret
+type RegisteredOAuthClient struct {
+	OAuthClient
+	ClientSecret string `json:"clientSecret,omitempty"`
+}
+
+// NotificationWebhook represents a notification webhook for API responses
+type NotificationWebhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Format    string    `json:"format"`
+	Events    []string  `json:"events,omitempty"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RegisterNotificationWebhookRequest represents the request body for registering a notification webhook
+type RegisterNotificationWebhookRequest struct {
+	URL    string   `json:"url"`
+	Format string   `json:"format"` // json (default) or slack
+	Events []string `json:"events,omitempty"`
+}
+
+// RegisteredNotificationWebhook represents a newly registered webhook with
+// its signing secret, which is only returned once
+type RegisteredNotificationWebhook struct {
+	NotificationWebhook
+	Secret string `json:"secret"`
+}
+
+// CreateServiceAccountRequest represents the request body for creating a service account
+type CreateServiceAccountRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`          // contact of the owning team
+	Role     string `json:"role,omitempty"` // user (default) or admin
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+	// NotBefore provisions the key ahead of time: it authenticates requests
+	// from then on, and expiresInDays counts from then
+	NotBefore     *time.Time `json:"notBefore,omitempty"`
+	ExpiresInDays int        `json:"expiresInDays,omitempty"` // 0 never expires
+}
+
+// APIKey represents an API key of a service account for API responses
+type APIKey struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	CreatedBy  string     `json:"createdBy"`
+	NotBefore  *time.Time `json:"notBefore,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedAPIKey represents a newly created API key with the key itself,
+// which is only returned once
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// AccountFlag represents an abuse report of an account for API responses
+type AccountFlag struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	Category   string     `json:"category"`
+	Reason     string     `json:"reason"`
+	ReportedBy string     `json:"reportedBy"`
+	ByAdmin    bool       `json:"byAdmin"`
+	Status     string     `json:"status"`
+	ReviewedBy string     `json:"reviewedBy,omitempty"`
+	ReviewNote string     `json:"reviewNote,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// FlagAccountRequest represents the request body for reporting or flagging an account
+type FlagAccountRequest struct {
+	Category string `json:"category"` // spam, fraud, abuse or other
+	Reason   string `json:"reason"`
+}
+
+// ReviewFlagRequest represents the request body for reviewing a flag
+type ReviewFlagRequest struct {
+	Status string `json:"status"` // confirmed or dismissed
+	Note   string `json:"note,omitempty"`
+}
+
+// CacheStats represents the contents and health of the cache for API responses
+type CacheStats struct {
+	Keys            int64            `json:"keys"`
+	Namespaces      map[string]int64 `json:"namespaces"` // scanned keys by the part before the first ':'
+	Scanned         int64            `json:"scanned"`
+	Truncated       bool             `json:"truncated"` // counts are lower bounds when the scan stopped at its limit
+	RevokedTokens   int64            `json:"revokedTokens"`
+	RevokedSessions int64            `json:"revokedSessions"`
+	Hits            int64            `json:"hits"`
+	Misses          int64            `json:"misses"`
+	HitRatio        float64          `json:"hitRatio"`
+}
+
+// InvalidateCacheRequest represents the request body for invalidating cache keys
+type InvalidateCacheRequest struct {
+	Pattern string `json:"pattern"` // <namespace>:<glob>, e.g. public_profile:*
+}
+
+// InvalidateCacheResponse reports how many cache keys were invalidated
+type InvalidateCacheResponse struct {
+	Pattern string `json:"pattern"`
+	Deleted int64  `json:"deleted"`
+}
+
+// OAuthTokenResponse represents a successful token endpoint response (RFC 6749 section 5.1)
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// TokenIntrospectionResponse represents an introspection endpoint response
+// (RFC 7662 section 2.2). Inactive tokens only have active set.
+type TokenIntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Nbf       int64  `json:"nbf,omitempty"`
+	Aud       string `json:"aud,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+}
+
+// BatchIntrospectionResponse represents a batch introspection response,
+// describing the tokens in the order they were given
+type BatchIntrospectionResponse struct {
+	Tokens []TokenIntrospectionResponse `json:"tokens"`
+}
+
+// OAuthErrorResponse represents an OAuth 2.0 error response (RFC 6749 section 5.2)
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// ProviderMetadata represents the OpenID Connect discovery document
+type ProviderMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+// EmailVerificationAttempt represents a sent verification email for API responses
+type EmailVerificationAttempt struct {
+	Email     string     `json:"email"`
+	Status    string     `json:"status"`
+	SentAt    time.Time  `json:"sentAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	ClickedAt *time.Time `json:"clickedAt,omitempty"`
+}
+
+// AccountRisk represents the risk state of a user's account for API responses
+type AccountRisk struct {
+	UserID string `json:"userId"`
+	// State is normal, watch (logins need a CAPTCHA) or restricted (logins
+	// also need a second factor)
+	State  string     `json:"state"`
+	Reason string     `json:"reason,omitempty"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// newAccountRisk maps an account risk state to its API representation
+func newAccountRisk(userID uuid.UUID, risk *models.AccountRisk) AccountRisk {
+	return AccountRisk{
+		UserID: userID.String(),
+		State:  string(risk.State),
+		Reason: risk.Reason,
+		Until:  risk.Until,
+	}
+}
+
+// EmailVerificationState represents a user's email verification state for API responses
+type EmailVerificationState struct {
+	UserID        string `json:"userId"`
+	EmailVerified bool   `json:"emailVerified"`
+	// State is the status of the latest verification email, or not_sent
+	State      string                     `json:"state"`
+	SentToday  int                        `json:"sentToday"`
+	DailyLimit int                        `json:"dailyLimit"`
+	Attempts   []EmailVerificationAttempt `json:"attempts"`
+}
+
+// newEmailVerificationState maps a verification state to its API representation
+func newEmailVerificationState(state *models.EmailVerificationState) EmailVerificationState {
+	response := EmailVerificationState{
+		UserID:        state.UserID.String(),
+		EmailVerified: state.EmailVerified,
+		State:         "not_sent",
+		SentToday:     state.SentToday,
+		DailyLimit:    state.DailyLimit,
+		Attempts:      make([]EmailVerificationAttempt, 0, len(state.Attempts)),
+	}
+	for i, attempt := range state.Attempts {
+		if i == 0 {
+			response.State = string(attempt.Status)
+		}
+		response.Attempts = append(response.Attempts, EmailVerificationAttempt{
+			Email:     attempt.Email,
+			Status:    string(attempt.Status),
+			SentAt:    attempt.SentAt,
+			ExpiresAt: attempt.ExpiresAt,
+			ClickedAt: attempt.ClickedAt,
+		})
+	}
+	return response
+}
+
+// PermissionGrant represents a permission and what granted it for API responses
+type PermissionGrant struct {
+	Permission string   `json:"permission"`
+	Sources    []string `json:"sources"`
+}
+
+// EffectivePermissions represents a user's effective permissions for API responses
+type EffectivePermissions struct {
+	UserID string   `json:"userId"`
+	Status string   `json:"status"`
+	Roles  []string `json:"roles"`
+	// Active is false when the user cannot authenticate and holds no permissions
+	Active      bool              `json:"active"`
+	Permissions []PermissionGrant `json:"permissions"`
+}
+
+// newEffectivePermissions maps effective permissions to their API representation
+func newEffectivePermissions(effective *models.EffectivePermissions) EffectivePermissions {
+	response := EffectivePermissions{
+		UserID:      effective.UserID.String(),
+		Status:      string(effective.Status),
+		Roles:       make([]string, 0, len(effective.Roles)),
+		Active:      effective.Active,
+		Permissions: make([]PermissionGrant, 0, len(effective.Grants)),
+	}
+	for _, role := range effective.Roles {
+		response.Roles = append(response.Roles, string(role))
+	}
+	for _, grant := range effective.Grants {
+		response.Permissions = append(response.Permissions, PermissionGrant{
+			Permission: string(grant.Permission),
+			Sources:    grant.Sources,
+		})
+	}
+	return response
+}
+
+// newSigningKey maps signing key metadata to its API representation
+func newSigningKey(key services.SigningKeyInfo) SigningKey {
+	return SigningKey{
+		KeyID:     key.KeyID,
+		TokenType: string(key.TokenType),
+		Algorithm: key.Algorithm,
+		CreatedAt: key.CreatedAt,
+		NotAfter:  key.NotAfter,
+		Status:    string(key.Status),
+	}
+}
+
+// newJSONWebKey maps a public signing key to its JWK representation
+func newJSONWebKey(key services.JSONWebKey) JSONWebKey {
+	return JSONWebKey{
+		KeyType:   key.KeyType,
+		Use:       "sig",
+		KeyID:     key.KeyID,
+		Algorithm: key.Algorithm,
+		N:         key.N,
+		E:         key.E,
+		Curve:     key.Curve,
+		X:         key.X,
+		Y:         key.Y,
+	}
+}
+
+// newOAuthClient maps an OAuth client to its API representation
+func newOAuthClient(client *models.OAuthClient) OAuthClient {
+	grantTypes := make([]string, 0, len(client.GrantTypes))
+	for _, grantType := range client.GrantTypes {
+		grantTypes = append(grantTypes, string(grantType))
+	}
+	return OAuthClient{
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		Confidential: client.Confidential(),
+		RedirectURIs: client.RedirectURIs,
+		GrantTypes:   grantTypes,
+		Scopes:       client.Scopes,
+
+		PreviousSecretExpiresAt: client.PreviousSecretExpiresAt,
+		DisabledAt:              client.DisabledAt,
+		CreatedAt:               client.CreatedAt,
+	}
+}
+
+// newNotificationWebhook maps a notification webhook to its API representation
+func newNotificationWebhook(webhook *models.NotificationWebhook) NotificationWebhook {
+	return NotificationWebhook{
+		ID:        webhook.ID.String(),
+		URL:       webhook.URL,
+		Format:    string(webhook.Format),
+		Events:    webhook.EventTypes,
+		CreatedBy: webhook.CreatedBy.String(),
+		CreatedAt: webhook.CreatedAt,
+	}
+}
+
+// newAPIKey maps an API key to its API representation
+func newAPIKey(key *models.APIKey) APIKey {
+	return APIKey{
+		ID:         key.ID.String(),
+		UserID:     key.UserID.String(),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		CreatedBy:  key.CreatedBy.String(),
+		NotBefore:  key.NotBefore,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// newAccountFlag maps an account flag to its API representation
+func newPasskey(credential *models.WebAuthnCredential) Passkey {
+	return Passkey{
+		ID:         credential.ID.String(),
+		Name:       credential.Name,
+		LastUsedAt: credential.LastUsedAt,
+		CreatedAt:  credential.CreatedAt,
+	}
+}
+
+func newUserIdentity(identity *models.UserIdentity) UserIdentity {
+	return UserIdentity{
+		ID:          identity.ID.String(),
+		Provider:    identity.Provider,
+		Email:       identity.Email,
+		LastLoginAt: identity.LastLoginAt,
+		CreatedAt:   identity.CreatedAt,
+	}
+}
+
+func newLoginMethodsResponse(methods *services.LoginMethods) LoginMethodsResponse {
+	response := LoginMethodsResponse{
+		HasPassword: methods.HasPassword,
+		Passkeys:    make([]Passkey, 0, len(methods.Passkeys)),
+		Identities:  make([]UserIdentity, 0, len(methods.Identities)),
+	}
+	for _, credential := range methods.Passkeys {
+		response.Passkeys = append(response.Passkeys, newPasskey(credential))
+	}
+	for _, identity := range methods.Identities {
+		response.Identities = append(response.Identities, newUserIdentity(identity))
+	}
+	return response
+}
+
+func newCacheStats(stats *services.CacheStats) CacheStats {
+	return CacheStats{
+		Keys:            stats.Keys,
+		Namespaces:      stats.Namespaces,
+		Scanned:         stats.Scanned,
+		Truncated:       stats.Truncated,
+		RevokedTokens:   stats.RevokedTokens,
+		RevokedSessions: stats.RevokedSessions,
+		Hits:            stats.Hits,
+		Misses:          stats.Misses,
+		HitRatio:        stats.HitRatio(),
+	}
+}
+
+func newAccountFlag(flag *models.AccountFlag) AccountFlag {
+	response := AccountFlag{
+		ID:         flag.ID.String(),
+		UserID:     flag.UserID.String(),
+		Category:   string(flag.Category),
+		Reason:     flag.Reason,
+		ReportedBy: flag.ReportedBy.String(),
+		ByAdmin:    flag.ByAdmin,
+		Status:     string(flag.Status),
+		ReviewNote: flag.ReviewNote,
+		ReviewedAt: flag.ReviewedAt,
+		CreatedAt:  flag.CreatedAt,
+	}
+	if flag.ReviewedBy != nil {
+		response.ReviewedBy = flag.ReviewedBy.String()
+	}
+	return response
+}
+
+// newOrganizationSettings maps organization settings to their API representation
+func newOrganizationSettings(settings *models.OrganizationSettings) OrganizationSettings {
+	response := OrganizationSettings{
+		OrganizationID:         settings.OrganizationID.String(),
+		AccessTokenTTLSeconds:  settings.AccessTokenTTLSeconds,
+		RefreshTokenTTLSeconds: settings.RefreshTokenTTLSeconds,
+		RequireMFA:             settings.RequireMFA,
+		MaxSessions:            settings.MaxSessions,
+		SecuritySummaries:      settings.SecuritySummaries,
+		UpdatedAt:              &settings.UpdatedAt,
+	}
+	if action := settings.SessionLimitAction; action != nil {
+		response.SessionLimitAction = (*string)(action)
+	}
+	if policy := settings.PasswordPolicy; policy != nil {
+		response.PasswordPolicy = &PasswordPolicy{
+			MinLength:           policy.MinLength,
+			MaxLength:           policy.MaxLength,
+			RequireUppercase:    policy.RequireUppercase,
+			RequireLowercase:    policy.RequireLowercase,
+			RequireNumbers:      policy.RequireNumbers,
+			RequireSpecialChars: policy.RequireSpecialChars,
+		}
+	}
+	if len(settings.EmailTemplates) > 0 {
+		response.EmailTemplates = make(map[string]EmailTemplate, len(settings.EmailTemplates))
+		for name, tmpl := range settings.EmailTemplates {
+			response.EmailTemplates[name] = EmailTemplate(tmpl)
+		}
+	}
+	if policy := settings.AccessPolicy; policy != nil {
+		response.AccessPolicy = &AccessPolicy{AllowedCountries: policy.AllowedCountries}
+		if hours := policy.BusinessHours; hours != nil {
+			response.AccessPolicy.BusinessHours = (*BusinessHours)(hours)
+		}
+	}
+	return response
+}
+
+// toModel maps an organization settings request to the domain model
+func (s OrganizationSettings) toModel(organizationID uuid.UUID) *models.OrganizationSettings {
+	settings := &models.OrganizationSettings{
+		OrganizationID:         organizationID,
+		AccessTokenTTLSeconds:  s.AccessTokenTTLSeconds,
+		RefreshTokenTTLSeconds: s.RefreshTokenTTLSeconds,
+		RequireMFA:             s.RequireMFA,
+		MaxSessions:            s.MaxSessions,
+		SecuritySummaries:      s.SecuritySummaries,
+	}
+	if s.SessionLimitAction != nil {
+		action := models.SessionLimitAction(*s.SessionLimitAction)
+		settings.SessionLimitAction = &action
+	}
+	if policy := s.PasswordPolicy; policy != nil {
+		settings.PasswordPolicy = &models.PasswordPolicy{
+			MinLength:           policy.MinLength,
+			MaxLength:           policy.MaxLength,
+			RequireUppercase:    policy.RequireUppercase,
+			RequireLowercase:    policy.RequireLowercase,
+			RequireNumbers:      policy.RequireNumbers,
+			RequireSpecialChars: policy.RequireSpecialChars,
+		}
+	}
+	if len(s.EmailTemplates) > 0 {
+		settings.EmailTemplates = make(map[string]models.EmailTemplate, len(s.EmailTemplates))
+		for name, tmpl := range s.EmailTemplates {
+			settings.EmailTemplates[name] = models.EmailTemplate(tmpl)
+		}
+	}
+	if policy := s.AccessPolicy; policy != nil {
+		settings.AccessPolicy = &models.AccessPolicy{AllowedCountries: policy.AllowedCountries}
+		if hours := policy.BusinessHours; hours != nil {
+			settings.AccessPolicy.BusinessHours = (*models.BusinessHours)(hours)
+		}
+	}
+	return settings
+}
+
+// newAuditLogVerification maps an audit log verification to its API representation
+func newAuditLogVerification(verification *models.AuditLogVerification) AuditLogVerification {
+	response := AuditLogVerification{
+		Valid:    verification.Valid,
+		Entries:  verification.Entries,
+		BrokenAt: verification.BrokenAt,
+		Reason:   verification.Reason,
+	}
+	if head := verification.Head; head != nil {
+		response.HeadSequence = head.Sequence
+		response.HeadHash = head.Hash
+		response.HeadTime = &head.RecordedAt
+	}
+	return response
+}
+
+// newAuditLogEntry maps an audit log entry to its API representation
+func newAuditLogEntry(entry *models.AuditLogEntry) AuditLogEntry {
+	return AuditLogEntry{
+		Sequence:  entry.Sequence,
+		EventID:   entry.EventID,
+		EventType: entry.EventType,
+		ActorID:   entry.ActorID,
+		Payload:   json.RawMessage(entry.Payload),
+		Hash:      entry.Hash,
+		CreatedAt: entry.CreatedAt,
+	}
+}
+
+// newTokenIntrospectionResponse maps a token introspection to its API representation
+func newTokenIntrospectionResponse(introspection *services.TokenIntrospection) TokenIntrospectionResponse {
+	if !introspection.Active {
+		return TokenIntrospectionResponse{Active: false}
+	}
+	response := TokenIntrospectionResponse{
+		Active:    true,
+		Scope:     introspection.Scope,
+		ClientID:  introspection.ClientID,
+		Username:  introspection.Username,
+		TokenType: services.BearerTokenType,
+		Exp:       introspection.ExpiresAt.Unix(),
+		Iat:       introspection.IssuedAt.Unix(),
+		Sub:       introspection.Subject,
+		Iss:       introspection.Issuer,
+		Aud:       introspection.Audience,
+	}
+	if !introspection.NotBefore.IsZero() {
+		response.Nbf = introspection.NotBefore.Unix()
+	}
+	return response
+}
+
+// newProviderMetadata maps the provider metadata to the discovery document
+func newProviderMetadata(metadata services.ProviderMetadata) ProviderMetadata {
+	return ProviderMetadata{
+		Issuer:                            metadata.Issuer,
+		AuthorizationEndpoint:             metadata.AuthorizationEndpoint,
+		TokenEndpoint:                     metadata.TokenEndpoint,
+		UserInfoEndpoint:                  metadata.UserInfoEndpoint,
+		IntrospectionEndpoint:             metadata.IntrospectionEndpoint,
+		RevocationEndpoint:                metadata.RevocationEndpoint,
+		JWKSURI:                           metadata.JWKSURI,
+		ScopesSupported:                   metadata.ScopesSupported,
+		ResponseTypesSupported:            metadata.ResponseTypesSupported,
+		GrantTypesSupported:               metadata.GrantTypesSupported,
+		SubjectTypesSupported:             metadata.SubjectTypesSupported,
+		IDTokenSigningAlgValuesSupported:  metadata.IDTokenSigningAlgValuesSupported,
+		TokenEndpointAuthMethodsSupported: metadata.TokenEndpointAuthMethods,
+		CodeChallengeMethodsSupported:     metadata.CodeChallengeMethodsSupported,
+	}
+}
+
+// UserSearchResponse represents a page of users matching a search
+type UserSearchResponse struct {
+	Total int64  `json:"total"`
+	Users []User `json:"users"`
+}
+
+// newUserSearchResponse maps a search result to its API representation
+func newUserSearchResponse(result *services.UserSearchResult) UserSearchResponse {
+	response := UserSearchResponse{
+		Total: result.Total,
+		Users: make([]User, 0, len(result.Users)),
+	}
+	for _, document := range result.Users {
+		response.Users = append(response.Users, User{
+			ID:            document.ID.String(),
+			Email:         document.Email,
+			Username:      document.Username,
+			FirstName:     document.FirstName,
+			LastName:      document.LastName,
+			EmailVerified: document.EmailVerified,
+			Status:        string(document.Status),
+			CreatedAt:     document.CreatedAt,
+			UpdatedAt:     document.UpdatedAt,
+		})
+	}
+	return response
+}
+
+// newUserResponse maps a domain user to its API representation
+func newUserResponse(user *models.User) User {
+	response := User{
+		ID:            user.ID.String(),
+		Email:         user.Email,
+		Username:      user.Username,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		PhoneNumber:   user.PhoneNumber,
+		Locale:        user.Locale,
+		TimeZone:      user.TimeZone,
+		AvatarURL:     user.AvatarURL,
+		EmailVerified: user.EmailVerified,
+		RecoveryEmail: user.RecoveryEmail,
+		Status:        string(user.Status),
+		Role:          string(user.Role),
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+
+		PasswordResetRequired: user.PasswordResetRequired,
+		ServiceAccount:        user.ServiceAccount,
+	}
+	if user.OrganizationID != nil {
+		response.OrganizationID = user.OrganizationID.String()
+	}
+	return response
+}
+
+// newMFAStatus maps an MFA status to its API representation
+func newMFAStatus(status *services.MFAStatus) MFAStatus {
+	response := MFAStatus{
+		TOTPEnabled: status.TOTPEnabled,
+		EnabledAt:   status.EnabledAt,
+		Required:    status.Requirement.Required,
+	}
+	if status.Requirement.Required && !status.Requirement.EnforceAfter.IsZero() {
+		response.EnforceAfter = &status.Requirement.EnforceAfter
+	}
+	return response
+}
+
+// newMFAPolicy maps an MFA policy to its API representation
+func newMFAPolicy(policy *models.MFAPolicy) MFAPolicy {
+	response := MFAPolicy{
+		ID:           policy.ID.String(),
+		Name:         policy.Name,
+		EnforceAfter: policy.EnforceAfter,
+		CreatedAt:    &policy.CreatedAt,
+		UpdatedAt:    &policy.UpdatedAt,
+	}
+	if policy.OrganizationID != nil {
+		response.OrganizationID = policy.OrganizationID.String()
+	}
+	for _, role := range policy.Roles {
+		response.Roles = append(response.Roles, string(role))
+	}
+	return response
+}
+
+// toModel maps an MFA policy request to the domain model
+func (p MFAPolicy) toModel(id uuid.UUID) (*models.MFAPolicy, error) {
+	policy := &models.MFAPolicy{
+		ID:           id,
+		Name:         p.Name,
+		EnforceAfter: p.EnforceAfter,
+	}
+	if p.OrganizationID != "" {
+		organizationID, err := uuid.Parse(p.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		policy.OrganizationID = &organizationID
+	}
+	for _, role := range p.Roles {
+		policy.Roles = append(policy.Roles, models.Role(role))
+	}
+	return policy, nil
+}
+
+// newRole maps a role to its API representation
+func newRole(role *models.RoleDefinition) Role {
+	response := Role{
+		Name:        string(role.Name),
+		Description: role.Description,
+		Permissions: make([]string, 0, len(role.Permissions)),
+		CreatedAt:   &role.CreatedAt,
+		UpdatedAt:   &role.UpdatedAt,
+	}
+	for _, permission := range role.Permissions {
+		response.Permissions = append(response.Permissions, string(permission))
+	}
+	return response
+}
+
+// newOrganization maps an organization to its API representation
+func newOrganization(organization *models.Organization) Organization {
+	return Organization{
+		ID:               organization.ID.String(),
+		Name:             organization.Name,
+		DomainJoinPolicy: string(organization.DomainJoinPolicy),
+		CreatedAt:        organization.CreatedAt,
+	}
+}
+
+// newOrganizationDomain maps a domain claimed by an organization to its API representation
+func newOrganizationDomain(domain *models.OrganizationDomain) OrganizationDomain {
+	name, value := domain.VerificationRecord()
+	return OrganizationDomain{
+		Domain:             domain.Domain,
+		Verified:           domain.IsVerified(),
+		VerificationRecord: name,
+		VerificationValue:  value,
+		VerifiedAt:         domain.VerifiedAt,
+	}
+}
+
+// newOrganizationInvitation maps an organization invitation to its API representation
+func newOrganizationInvitation(invitation *services.OrganizationInvitation) OrganizationInvitation {
+	return OrganizationInvitation{
+		Organization: newOrganization(invitation.Organization),
+		Role:         string(invitation.Invitation.Role),
+		InvitedAt:    invitation.Invitation.CreatedAt,
+	}
+}
+
+// newOrganizationMember maps an organization member to its API representation
+func newOrganizationMember(member *services.OrganizationMember) OrganizationMember {
+	response := OrganizationMember{
+		User:     newUserResponse(member.User),
+		Role:     string(member.Membership.Role),
+		JoinedAt: member.Membership.CreatedAt,
+	}
+	if member.Membership.InvitedBy != nil {
+		response.InvitedBy = member.Membership.InvitedBy.String()
+	}
+	return response
+}
+
+// newSSOConnection maps an SSO connection to its API representation
+func newSSOConnection(details *services.SSOConnectionDetails) SSOConnection {
+	connection := details.Connection
+	response := SSOConnection{
+		ID:          connection.ID.String(),
+		Name:        connection.Name,
+		Protocol:    string(connection.Protocol),
+		Issuer:      connection.Issuer,
+		ClientID:    connection.ClientID,
+		Enabled:     connection.Enabled,
+		RedirectURL: details.RedirectURL,
+		Domains:     make([]SSODomain, 0, len(details.Domains)),
+		CreatedAt:   connection.CreatedAt,
+		UpdatedAt:   connection.UpdatedAt,
+	}
+	for _, domain := range details.Domains {
+		response.Domains = append(response.Domains, newSSODomain(domain))
+	}
+	return response
+}
+
+// newSSODomain maps a domain claimed by an SSO connection to its API representation
+func newSSODomain(domain *models.SSODomain) SSODomain {
+	name, value := domain.VerificationRecord()
+	return SSODomain{
+		Domain:             domain.Domain,
+		Verified:           domain.IsVerified(),
+		VerificationRecord: name,
+		VerificationValue:  value,
+		VerifiedAt:         domain.VerifiedAt,
+	}
+}
+
+// toInput maps an SSO connection request to the domain type
+func (r SSOConnectionRequest) toInput() services.SSOConnectionInput {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return services.SSOConnectionInput{
+		Name:         r.Name,
+		Issuer:       r.Issuer,
+		ClientID:     r.ClientID,
+		ClientSecret: r.ClientSecret,
+		Enabled:      enabled,
+	}
+}
+
+// toModel maps a role request to the domain model
+func (r Role) toModel() *models.RoleDefinition {
+	return &models.RoleDefinition{
+		Name:        models.Role(r.Name),
+		Description: r.Description,
+		Permissions: toPermissions(r.Permissions),
+	}
+}
+
+// toPermissions maps the permissions of a request to the domain model
+func toPermissions(values []string) []models.Permission {
+	permissions := make([]models.Permission, 0, len(values))
+	for _, value := range values {
+		permissions = append(permissions, models.Permission(value))
+	}
+	return permissions
+}
+
+// secondsUntil returns the number of whole seconds until t
+func secondsUntil(t time.Time) int64 {
+	return int64(time.Until(t).Round(time.Second).Seconds())
+}
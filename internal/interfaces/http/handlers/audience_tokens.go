@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+)
+
+// AudienceTokenRequest represents the request body for minting a token for
+// another internal API
+type AudienceTokenRequest struct {
+	Audience string `json:"audience"`
+	Scope    string `json:"scope,omitempty"` // space-separated; empty requests every scope of the audience
+}
+
+// AudienceTokenResponse represents an access token for another internal API
+type AudienceTokenResponse struct {
+	AccessToken string    `json:"accessToken"`
+	TokenType   string    `json:"tokenType"`
+	ExpiresIn   int64     `json:"expiresIn"` // in seconds
+	ExpiresAt   time.Time `json:"expiresAt"`
+	Audience    string    `json:"audience"`
+	Scope       string    `json:"scope"`
+}
+
+// @Summary Mint an audience token
+// @Description Issue the authenticated user or service account an access token for another internal API, carrying
+// @Description some of the scopes configured for that audience. This API refuses the token. Tokens issued to OAuth
+// @Description clients and restricted accounts cannot mint audience tokens.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body AudienceTokenRequest true "Audience and scopes"
+// @Success 200 {object} AudienceTokenResponse "Audience token"
+// @Failure 400 {object} ErrorResponse "Unknown audience or scope"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Not allowed to mint tokens for the audience"
+// @Failure 404 {object} ErrorResponse "Audience tokens are not enabled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/audience-tokens [post]
+func (h *UserHandler) MintAudienceToken(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	// Scoped tokens, such as those of OAuth clients, cannot be traded for
+	// scopes they were not granted
+	if middleware.GetScope(r.Context()) != "" {
+		h.handleError(w, r, services.ErrAudienceForbidden, http.StatusForbidden, "scoped tokens cannot mint audience tokens")
+		return
+	}
+
+	var req AudienceTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Audience == "" {
+		h.handleError(w, r, nil, http.StatusBadRequest, "audience is required")
+		return
+	}
+
+	token, err := h.userService.MintAudienceToken(r.Context(), id, services.AudienceTokenInput{
+		Audience: req.Audience,
+		Scope:    req.Scope,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAudienceTokensNotEnabled):
+			h.handleError(w, r, err, http.StatusNotFound, "audience tokens are not enabled")
+		case errors.Is(err, services.ErrAudienceForbidden):
+			h.handleError(w, r, err, http.StatusForbidden, "not allowed to mint tokens for this audience")
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to mint audience token")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, AudienceTokenResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   services.BearerTokenType,
+		ExpiresIn:   secondsUntil(token.ExpiresAt),
+		ExpiresAt:   token.ExpiresAt,
+		Audience:    token.Audience,
+		Scope:       token.Scope,
+	})
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// AuditLogHandler handles audit log administration requests
+type AuditLogHandler struct {
+	baseHandler
+	auditLogService services.AuditLogService
+}
+
+// NewAuditLogHandler creates a new audit log handler
+func NewAuditLogHandler(
+	auditLogService services.AuditLogService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *AuditLogHandler {
+	return &AuditLogHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		auditLogService: auditLogService,
+	}
+}
+
+// @Summary Verify audit log
+// @Description Recompute the hash chain of the audit log and report the first entry that was modified, removed or reordered. Compare the head with the notarized checkpoints to detect a rewritten chain.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} AuditLogVerification "Verification result"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/audit-log/verify [get]
+func (h *AuditLogHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	verification, err := h.auditLogService.Verify(r.Context())
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to verify audit log")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newAuditLogVerification(verification))
+}
+
+// @Summary List audit log entries
+// @Description List recorded events newest first. Pass the sequence of the last entry of a page as
+// @Description before to get the next page.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param before query int false "Only entries with a lower sequence number"
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Success 200 {array} AuditLogEntry "Entries"
+// @Failure 400 {object} ErrorResponse "Invalid query"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/audit-log [get]
+func (h *AuditLogHandler) List(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	params := r.URL.Query()
+	var before int64
+	if value := params.Get("before"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			h.handleError(w, r, err, http.StatusBadRequest, "invalid before")
+			return
+		}
+		before = parsed
+	}
+	var limit int
+	if value := params.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			h.handleError(w, r, err, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := h.auditLogService.List(r.Context(), before, limit)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrInvalidInput) {
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to list audit log entries")
+		return
+	}
+
+	response := make([]AuditLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, newAuditLogEntry(entry))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+)
+
+const gravatarBaseURL = "https://gravatar.com/avatar/"
+
+// AvatarConfig configures the avatar exposed in profile responses for users
+// who have not set an avatar URL, which is the optional Gravatar. It is off by default since the hash of a user's email can be
+// matched against known addresses.
+type AvatarConfig struct {
+	Gravatar     bool
+	DefaultImage string // Gravatar d parameter, e.g. identicon or mp; empty uses Gravatar's own
+	Size         int    // pixels; 0 uses Gravatar's default of 80
+}
+
+// avatarURL returns the avatar of the user with the given email, or an
+// empty string when avatars are disabled
+func (c AvatarConfig) avatarURL(email string) string {
+	if email == "" {
+		return ""
+	}
+	return c.gravatarURL(models.EmailHash(email))
+}
+
+// gravatarURL returns the Gravatar for the given email hash, or an empty
+// string when avatars are disabled
+func (c AvatarConfig) gravatarURL(emailHash string) string {
+	if !c.Gravatar || emailHash == "" {
+		return ""
+	}
+
+	query := url.Values{}
+	if c.DefaultImage != "" {
+		query.Set("d", c.DefaultImage)
+	}
+	if c.Size > 0 {
+		query.Set("s", strconv.Itoa(c.Size))
+	}
+
+	avatarURL := gravatarBaseURL + emailHash
+	if len(query) > 0 {
+		avatarURL += "?" + query.Encode()
+	}
+	return avatarURL
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+	"go.uber.org/zap"
+)
+
+// baseHandler provides the error and JSON response helpers shared by all handlers
+type baseHandler struct {
+	metricsService services.MetricsService
+	logger         *zap.Logger
+}
+
+func (h *baseHandler) handleError(w http.ResponseWriter, r *http.Request, err error, status int, message string) {
+	code := middleware.StatusCode(status)
+	if mapping, ok := mapDomainError(err); ok {
+		switch {
+		case status == http.StatusInternalServerError:
+			// The request failed, not the service
+			status, code, message = mapping.status, mapping.code, mapping.message
+		case status == mapping.status:
+			code = mapping.code
+		}
+	}
+	h.handleErrorCode(w, r, err, status, code, message)
+}
+
+// handleErrorCode responds with the error envelope carrying the given
+// machine-readable code clients can act on
+func (h *baseHandler) handleErrorCode(w http.ResponseWriter, r *http.Request, err error, status int, code, message string) {
+	h.recordError(r, err, message)
+	if err := middleware.WriteError(w, r, status, code, message); err != nil {
+		h.logger.Error("failed to encode response", zap.Error(err))
+	}
+}
+
+// handleAccessPolicyViolation responds to a login an organization's access
+// policy denied, naming the rule that denied it
+func (h *baseHandler) handleAccessPolicyViolation(w http.ResponseWriter, r *http.Request, err error) {
+	h.handleErrorCode(w, r, err, http.StatusForbidden, accessPolicyCode(err), "login not permitted by access policy")
+}
+
+// accessPolicyCode returns the code of the access policy rule that denied a login
+func accessPolicyCode(err error) string {
+	var violation *services.AccessPolicyViolation
+	if errors.As(err, &violation) {
+		return violation.Code
+	}
+	return "access_policy_violation"
+}
+
+func (h *baseHandler) recordError(r *http.Request, err error, message string) {
+	h.logger.Error(message,
+		zap.Error(err),
+		zap.String("path", r.URL.Path),
+		zap.String("method", r.Method),
+	)
+
+	h.metricsService.IncrementCounter("http_errors", map[string]string{
+		"path":    r.URL.Path,
+		"method":  r.Method,
+		"message": message,
+	})
+}
+
+func (h *baseHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if data != nil {
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			h.logger.Error("failed to encode response",
+				zap.Error(err),
+			)
+		}
+	}
+}
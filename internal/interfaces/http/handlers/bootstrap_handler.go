@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// BootstrapTokenHeader carries the one-time bootstrap token printed or
+// written at the first start of a deployment
+const BootstrapTokenHeader = "X-Bootstrap-Token"
+
+// BootstrapRequest represents the request body for the first-run setup of
+// a deployment. Organization and Settings are optional; Settings override
+// the configuration of the organization.
+type BootstrapRequest struct {
+	Admin        RegisterRequest       `json:"admin"`
+	Organization string                `json:"organization,omitempty"`
+	Settings     *OrganizationSettings `json:"settings,omitempty"`
+}
+
+// BootstrapResponse represents the result of the first-run setup
+type BootstrapResponse struct {
+	Admin        User          `json:"admin"`
+	Organization *Organization `json:"organization,omitempty"`
+}
+
+// BootstrapHandler handles the first-run setup of a deployment
+type BootstrapHandler struct {
+	baseHandler
+	bootstrap services.BootstrapService
+}
+
+// NewBootstrapHandler creates a new bootstrap handler
+func NewBootstrapHandler(
+	bootstrap services.BootstrapService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *BootstrapHandler {
+	return &BootstrapHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		bootstrap: bootstrap,
+	}
+}
+
+// @Summary Bootstrap deployment
+// @Description Create the first admin, and optionally an organization with its settings, with the one-time
+// @Description token issued at the first start. The token is destroyed once the admin is created or when
+// @Description it expires.
+// @Tags bootstrap
+// @Accept json
+// @Produce json
+// @Param X-Bootstrap-Token header string true "Bootstrap token"
+// @Param request body BootstrapRequest true "Admin and organization"
+// @Success 201 {object} BootstrapResponse "Created admin and organization"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Invalid or expired token"
+// @Failure 409 {object} ErrorResponse "Email or username already in use"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /bootstrap [post]
+func (h *BootstrapHandler) Setup(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	var req BootstrapRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Settings != nil && req.Organization == "" {
+		h.handleError(w, r, errors.New("settings without organization"), http.StatusBadRequest, "settings require an organization")
+		return
+	}
+
+	input := services.BootstrapInput{
+		Admin: services.RegisterUserInput{
+			Email:     req.Admin.Email,
+			Username:  req.Admin.Username,
+			Password:  req.Admin.Password,
+			FirstName: req.Admin.FirstName,
+			LastName:  req.Admin.LastName,
+		},
+		Organization: req.Organization,
+	}
+	if req.Settings != nil {
+		// The organization ID is set once the organization exists
+		input.Settings = req.Settings.toModel(uuid.Nil)
+	}
+
+	result, err := h.bootstrap.Setup(r.Context(), r.Header.Get(BootstrapTokenHeader), input)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to bootstrap deployment")
+		return
+	}
+
+	response := BootstrapResponse{Admin: newUserResponse(result.Admin)}
+	if result.Organization != nil {
+		organization := newOrganization(result.Organization)
+		response.Organization = &organization
+	}
+	h.respondJSON(w, http.StatusCreated, response)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// CacheAdminHandler handles admin requests inspecting the cache for
+// operational debugging
+type CacheAdminHandler struct {
+	baseHandler
+	cacheAdmin services.CacheAdminService
+}
+
+// NewCacheAdminHandler creates a new cache admin handler
+func NewCacheAdminHandler(
+	cacheAdmin services.CacheAdminService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *CacheAdminHandler {
+	return &CacheAdminHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		cacheAdmin: cacheAdmin,
+	}
+}
+
+// @Summary Get cache stats
+// @Description Report the cache's key counts by namespace, revoked tokens and sessions, and keyspace hits
+// @Description and misses since the cache server started. Large caches are counted up to the scan limit.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} CacheStats "Cache stats"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/cache/stats [get]
+func (h *CacheAdminHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	stats, err := h.cacheAdmin.Stats(r.Context())
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get cache stats")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newCacheStats(stats))
+}
+
+// @Summary Invalidate cache keys
+// @Description Delete the cache keys matching a "<namespace>:<glob>" pattern, e.g. public_profile:* or
+// @Description tenant_settings:<organization ID>. Only namespaces caching data kept elsewhere can be
+// @Description invalidated; revocations, sessions and single-use markers are protected.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body InvalidateCacheRequest true "Key pattern"
+// @Success 200 {object} InvalidateCacheResponse "Invalidated keys"
+// @Failure 400 {object} ErrorResponse "Invalid pattern"
+// @Failure 403 {object} ErrorResponse "Forbidden or protected namespace"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/cache/invalidate [post]
+func (h *CacheAdminHandler) Invalidate(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req InvalidateCacheRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	deleted, err := h.cacheAdmin.Invalidate(r.Context(), req.Pattern)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrCacheNamespaceProtected):
+			h.handleError(w, r, err, http.StatusForbidden, err.Error())
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to invalidate cache keys")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, InvalidateCacheResponse{
+		Pattern: req.Pattern,
+		Deleted: deleted,
+	})
+}
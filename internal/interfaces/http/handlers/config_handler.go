@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// ConfigHandler handles HTTP requests for inspecting the runtime configuration
+type ConfigHandler struct {
+	baseHandler
+	config map[string]any
+}
+
+// NewConfigHandler creates a new config handler serving the given
+// configuration, whose secrets must already be masked
+func NewConfigHandler(
+	config map[string]any,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *ConfigHandler {
+	return &ConfigHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		config: config,
+	}
+}
+
+// @Summary Get runtime configuration
+// @Description Get the configuration this instance runs with after the config file and environment
+// @Description overrides were applied, keyed by field name. Passwords, secrets and tokens are masked.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Effective configuration"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /admin/config [get]
+func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	h.respondJSON(w, http.StatusOK, h.config)
+}
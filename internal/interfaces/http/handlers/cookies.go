@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+)
+
+// CookieConfig configures the token cookies used in cookie mode
+type CookieConfig struct {
+	Enabled  bool
+	Domain   string
+	Path     string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// ParseSameSite converts a configured SameSite value (lax, strict or none)
+// into its http.SameSite mode, defaulting to lax
+func ParseSameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// newCookie builds a token cookie. Browsers only replace or clear a cookie
+// when name, domain and path match, so setting and clearing share this.
+func (c CookieConfig) newCookie(name, value string, expires time.Time) *http.Cookie {
+	path := c.Path
+	if path == "" {
+		path = "/"
+	}
+
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   c.Domain,
+		Path:     path,
+		Secure:   c.Secure || c.SameSite == http.SameSiteNoneMode,
+		HttpOnly: true,
+		SameSite: c.SameSite,
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+		cookie.Expires = time.Unix(0, 0)
+	} else {
+		cookie.Expires = expires
+	}
+	return cookie
+}
+
+// setTokenCookies writes the access and refresh token cookies in cookie mode
+func (c CookieConfig) setTokenCookies(w http.ResponseWriter, accessToken string, accessExpiresAt time.Time, refreshToken string, refreshExpiresAt time.Time) {
+	if !c.Enabled {
+		return
+	}
+	http.SetCookie(w, c.newCookie(middleware.AccessTokenCookie, accessToken, accessExpiresAt))
+	http.SetCookie(w, c.newCookie(middleware.RefreshTokenCookie, refreshToken, refreshExpiresAt))
+}
+
+// clearTokenCookies expires the access and refresh token cookies in cookie mode
+func (c CookieConfig) clearTokenCookies(w http.ResponseWriter) {
+	if !c.Enabled {
+		return
+	}
+	http.SetCookie(w, c.newCookie(middleware.AccessTokenCookie, "", time.Time{}))
+	http.SetCookie(w, c.newCookie(middleware.RefreshTokenCookie, "", time.Time{}))
+}
+
+// refreshTokenCookie returns the refresh token cookie value in cookie mode
+func (c CookieConfig) refreshTokenCookie(r *http.Request) string {
+	if !c.Enabled {
+		return ""
+	}
+	if cookie, err := r.Cookie(middleware.RefreshTokenCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
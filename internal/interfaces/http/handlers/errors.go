@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// emailNotVerifiedMessage tells users who must verify their email before
+// signing in how to get a new verification link
+const emailNotVerifiedMessage = "verify the email address before signing in; request a new link with POST /api/v1/auth/verify-email/resend"
+
+// errorMapping is the HTTP status, code and message of a domain error
+type errorMapping struct {
+	err     error
+	status  int
+	code    string
+	message string
+}
+
+// domainErrors maps domain errors to their responses, most specific first.
+// Handlers that fail with a mapped error where they would respond with 500
+// respond with the mapped status instead, so that clients can tell failures
+// of their request apart from failures of the service.
+var domainErrors = []errorMapping{
+	{domainerrors.ErrUserAlreadyExists, http.StatusConflict, "user_already_exists", "user already exists"},
+	{services.ErrUserAlreadyExists, http.StatusConflict, "user_already_exists", "user already exists"},
+	{services.ErrEmailAlreadyExists, http.StatusConflict, "email_already_exists", "email already exists"},
+	{services.ErrUsernameAlreadyExists, http.StatusConflict, "username_already_exists", "username already exists"},
+	{services.ErrUsernameChangeCooldown, http.StatusConflict, "username_change_cooldown", "username was changed too recently"},
+	{services.ErrConflict, http.StatusConflict, "conflict", "resource conflict"},
+	{domainerrors.ErrUserNotFound, http.StatusNotFound, "user_not_found", "user not found"},
+	{services.ErrNotFound, http.StatusNotFound, "not_found", "resource not found"},
+	{domainerrors.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials", "invalid credentials"},
+	{services.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials", "invalid credentials"},
+	{services.ErrTokenRevoked, http.StatusUnauthorized, "token_revoked", "token has been revoked"},
+	{domainerrors.ErrInvalidToken, http.StatusUnauthorized, "invalid_token", "invalid or expired token"},
+	{services.ErrInvalidToken, http.StatusUnauthorized, "invalid_token", "invalid or expired token"},
+	{services.ErrDeviceMismatch, http.StatusUnauthorized, "device_mismatch", "token is bound to a different device"},
+	{services.ErrDeviceIDRequired, http.StatusBadRequest, "device_id_required", "send the device ID in the X-Device-ID header"},
+	{services.ErrAuthentication, http.StatusUnauthorized, "authentication_failed", "authentication failed"},
+	{domainerrors.ErrUnauthorized, http.StatusForbidden, "forbidden", "not allowed"},
+	{services.ErrAccountDeactivated, http.StatusForbidden, "account_deactivated", "account is deactivated"},
+	{services.ErrLoginConfirmationRequired, http.StatusForbidden, "login_confirmation_required", "confirm the login with the link sent by email"},
+	{services.ErrAccountDisabled, http.StatusForbidden, "account_disabled", "account is disabled"},
+	{services.ErrPasswordResetRequired, http.StatusForbidden, "password_reset_required", "password reset required"},
+	{services.ErrEmailVerificationRequired, http.StatusForbidden, "email_verification_required", "verify the email address before signing in"},
+	{services.ErrEmailNotVerified, http.StatusForbidden, "email_not_verified", emailNotVerifiedMessage},
+	{services.ErrCaptchaRequired, http.StatusForbidden, "captcha_required", "solve the CAPTCHA and sign in again"},
+	{services.ErrCaptchaInvalid, http.StatusForbidden, "captcha_invalid", "invalid CAPTCHA response"},
+	{services.ErrLoginBlocked, http.StatusForbidden, "login_blocked", "login is blocked until the account's risk subsides"},
+	{domainerrors.ErrInvalidStatusTransition, http.StatusConflict, "invalid_status_transition", "invalid user status transition"},
+	{domainerrors.ErrInvalidInput, http.StatusBadRequest, "invalid_input", "invalid input"},
+	{services.ErrSessionLimitReached, http.StatusTooManyRequests, "session_limit_reached", "concurrent session limit reached"},
+	{services.ErrVerificationLimitReached, http.StatusTooManyRequests, "verification_limit_reached", "verification email limit reached"},
+	{services.ErrCrossRegionWrite, http.StatusMisdirectedRequest, "cross_region_write", "data can only be changed in its home region"},
+	{services.ErrRegionUnavailable, http.StatusMisdirectedRequest, "region_unavailable", "region is not available"},
+	{services.ErrRevocationUnavailable, http.StatusServiceUnavailable, "revocation_unavailable", "token revocation status unavailable"},
+}
+
+// mapDomainError returns the mapping of the first domain error err matches
+func mapDomainError(err error) (errorMapping, bool) {
+	if err == nil {
+		return errorMapping{}, false
+	}
+	for _, mapping := range domainErrors {
+		if errors.Is(err, mapping.err) {
+			return mapping, true
+		}
+	}
+	return errorMapping{}, false
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+)
+
+// @Summary List sign-in methods
+// @Description Get the password, passkeys and external identities the authenticated user can sign in with
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} LoginMethodsResponse "Sign-in methods"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/identities [get]
+func (h *UserHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	methods, err := h.userService.GetLoginMethods(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to list sign-in methods")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newLoginMethodsResponse(methods))
+}
+
+// @Summary Link an external identity
+// @Description Start linking the authenticated user's account at an identity provider. Send the browser to
+// @Description authorizationUrl; the provider's callback links the identity instead of signing in.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Provider name, e.g. google"
+// @Success 200 {object} LinkIdentityResponse "Authorization URL"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Unknown provider"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/identities/{provider} [post]
+func (h *UserHandler) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	login, err := h.federation.BeginLink(r.Context(), mux.Vars(r)["provider"], id)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownIdentityProvider) {
+			h.handleError(w, r, err, http.StatusNotFound, "unknown identity provider")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to start linking")
+		return
+	}
+
+	// The callback only completes the link in the browser that started it
+	http.SetCookie(w, h.federationStateCookie(federationStateCookiePath, login.State, federationStateMaxAge))
+	h.respondJSON(w, http.StatusOK, LinkIdentityResponse{AuthorizationURL: login.AuthorizationURL})
+}
+
+// @Summary Unlink an external identity
+// @Description Remove an external identity of the authenticated user. The last way the user can sign in cannot be removed.
+// @Tags users
+// @Security BearerAuth
+// @Param identityId path string true "Identity ID"
+// @Success 204 "Identity unlinked"
+// @Failure 400 {object} ErrorResponse "Invalid identity ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Identity not found"
+// @Failure 409 {object} ErrorResponse "Last sign-in method"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/identities/{identityId} [delete]
+func (h *UserHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	identityID, err := uuid.Parse(mux.Vars(r)["identityId"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid identity ID")
+		return
+	}
+
+	if err := h.userService.UnlinkExternalIdentity(r.Context(), id, identityID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound), errors.Is(err, services.ErrUnknownIdentityProvider):
+			h.handleError(w, r, err, http.StatusNotFound, "identity not found")
+		case errors.Is(err, services.ErrLastLoginMethod):
+			h.handleErrorCode(w, r, err, http.StatusConflict, "last_login_method", "cannot remove the last sign-in method")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to unlink identity")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// completeIdentityLink links an identity whose login was started with
+// LinkIdentity to the user who started it, responding as configured for
+// social logins
+func (h *UserHandler) completeIdentityLink(w http.ResponseWriter, r *http.Request, identity *services.ExternalIdentity) {
+	link, err := h.userService.LinkExternalIdentity(r.Context(), *identity.LinkingUserID, identity)
+	if err != nil {
+		if errors.Is(err, services.ErrIdentityAlreadyLinked) {
+			h.socialLoginFailed(w, r, err, http.StatusConflict, "identity_already_linked", "identity is linked to another account")
+			return
+		}
+		h.socialLoginFailed(w, r, err, http.StatusInternalServerError, "server_error", "failed to link identity")
+		return
+	}
+
+	if h.socialLogin.SuccessURL == "" {
+		h.respondJSON(w, http.StatusOK, newUserIdentity(link))
+		return
+	}
+	redirectWithResult(w, r, h.socialLogin.SuccessURL, url.Values{
+		"status":   {"linked"},
+		"provider": {link.Provider},
+	})
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// jwksMaxAge is how long clients may cache the key set. A rotated key signs
+// new tokens right away, so clients should refetch on an unknown kid.
+const jwksMaxAge = 5 * time.Minute
+
+// JWKSHandler publishes the public token signing keys so that downstream
+// services can validate tokens without sharing a secret
+type JWKSHandler struct {
+	baseHandler
+	tokenService services.TokenService
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(tokenService services.TokenService, metricsService services.MetricsService, logger *zap.Logger) *JWKSHandler {
+	return &JWKSHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		tokenService: tokenService,
+	}
+}
+
+// @Summary JSON Web Key Set
+// @Description Get the public keys of the token types signed with RS256 or ES256, including keys replaced by the last rotation. The set is empty when only HMAC algorithms are configured.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} JSONWebKeySet "Public signing keys"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	keys, err := h.tokenService.JWKS(r.Context())
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get signing keys")
+		return
+	}
+
+	response := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(keys))}
+	for _, key := range keys {
+		response.Keys = append(response.Keys, newJSONWebKey(key))
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(jwksMaxAge.Seconds())))
+	h.respondJSON(w, http.StatusOK, response)
+}
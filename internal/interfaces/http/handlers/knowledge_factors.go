@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+)
+
+// SecurityQuestionAnswer represents an answer to a security question
+type SecurityQuestionAnswer struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// SetSecurityQuestionsRequest represents the request body for setting the
+// security question answers of the authenticated user. The user re-enters
+// their password, and a current authenticator code when they set one up.
+type SetSecurityQuestionsRequest struct {
+	Answers  []SecurityQuestionAnswer `json:"answers"`
+	Password string                   `json:"password"`
+	Code     string                   `json:"code,omitempty"`
+}
+
+// SecurityQuestions represents the security questions of a user for API responses
+type SecurityQuestions struct {
+	Questions       []string `json:"questions"`       // the questions to choose from
+	RequiredAnswers int      `json:"requiredAnswers"` // how many questions to answer
+	Answered        []string `json:"answered"`        // the questions the user answered
+}
+
+// RecoveryQuestionsRequest represents the request body for looking up the
+// security questions of an account
+type RecoveryQuestionsRequest struct {
+	Identifier string `json:"identifier"` // email or username
+}
+
+// RecoveryQuestionsResponse represents the security questions to answer to
+// recover an account
+type RecoveryQuestionsResponse struct {
+	Questions []string `json:"questions"`
+}
+
+// SecurityQuestionRecoveryRequest represents the request body for
+// recovering an account by answering its security questions
+type SecurityQuestionRecoveryRequest struct {
+	Identifier string                   `json:"identifier"` // email or username
+	Answers    []SecurityQuestionAnswer `json:"answers"`
+}
+
+// SecurityQuestionRecoveryResponse represents a password reset token issued
+// for answering security questions, to be redeemed at /auth/reset-password
+type SecurityQuestionRecoveryResponse struct {
+	ResetToken string    `json:"resetToken"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// @Summary Get security questions
+// @Description Get the configured security questions and the ones the authenticated user answered
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} SecurityQuestions "Security questions"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Security questions are not enabled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/security-questions [get]
+func (h *UserHandler) GetSecurityQuestions(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	status, err := h.userService.GetKnowledgeFactors(r.Context(), id)
+	if err != nil {
+		h.handleKnowledgeFactorError(w, r, err, "failed to get security questions")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, SecurityQuestions{
+		Questions:       status.Questions,
+		RequiredAnswers: status.RequiredAnswers,
+		Answered:        status.Answered,
+	})
+}
+
+// @Summary Set security questions
+// @Description Replace the security question answers of the authenticated user, which can reset their password.
+// @Description The user re-authenticates with their password and authenticator code.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Param request body SetSecurityQuestionsRequest true "Answers and the user's password"
+// @Success 204 "Security questions set"
+// @Failure 400 {object} ErrorResponse "Invalid answers"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Re-authentication failed"
+// @Failure 404 {object} ErrorResponse "Security questions are not enabled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/security-questions [put]
+func (h *UserHandler) SetSecurityQuestions(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req SetSecurityQuestionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err := h.userService.SetKnowledgeFactors(r.Context(), id, toKnowledgeFactorAnswers(req.Answers), services.StepUpInput{
+		Password: req.Password,
+		Code:     req.Code,
+	})
+	if err != nil {
+		h.handleKnowledgeFactorError(w, r, err, "failed to set security questions")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Remove security questions
+// @Description Remove the security question answers of the authenticated user
+// @Tags users
+// @Security BearerAuth
+// @Success 204 "Security questions removed"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "No security questions set, or not enabled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/security-questions [delete]
+func (h *UserHandler) RemoveSecurityQuestions(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.userService.RemoveKnowledgeFactors(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "no security questions set")
+			return
+		}
+		h.handleKnowledgeFactorError(w, r, err, "failed to remove security questions")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get recovery security questions
+// @Description Get the security questions to answer to recover an account. The response looks the same whether
+// @Description or not the account exists or has security questions.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RecoveryQuestionsRequest true "Email or username"
+// @Success 200 {object} RecoveryQuestionsResponse "Security questions"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "Security questions are not enabled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/security-questions [post]
+func (h *UserHandler) GetRecoveryQuestions(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req RecoveryQuestionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Identifier == "" {
+		h.handleError(w, r, nil, http.StatusBadRequest, "identifier is required")
+		return
+	}
+
+	questions, err := h.userService.GetRecoveryQuestions(r.Context(), req.Identifier)
+	if err != nil {
+		h.handleKnowledgeFactorError(w, r, err, "failed to get security questions")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, RecoveryQuestionsResponse{Questions: questions})
+}
+
+// @Summary Recover an account with security questions
+// @Description Answer the security questions of an account for a password reset token to redeem at
+// @Description /auth/reset-password. Attempts are limited per account per day.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body SecurityQuestionRecoveryRequest true "Email or username and answers"
+// @Success 200 {object} SecurityQuestionRecoveryResponse "Password reset token"
+// @Failure 400 {object} ErrorResponse "Invalid request or wrong answers"
+// @Failure 404 {object} ErrorResponse "Security questions are not enabled"
+// @Failure 429 {object} ErrorResponse "Too many attempts"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/security-questions/recover [post]
+func (h *UserHandler) RecoverWithSecurityQuestions(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req SecurityQuestionRecoveryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Identifier == "" {
+		h.handleError(w, r, nil, http.StatusBadRequest, "identifier is required")
+		return
+	}
+
+	recovery, err := h.userService.RecoverWithKnowledgeFactors(r.Context(), req.Identifier, toKnowledgeFactorAnswers(req.Answers))
+	if err != nil {
+		h.handleKnowledgeFactorError(w, r, err, "failed to recover account")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, SecurityQuestionRecoveryResponse{
+		ResetToken: recovery.ResetToken,
+		ExpiresAt:  recovery.ExpiresAt,
+	})
+}
+
+// handleKnowledgeFactorError maps security question errors to responses
+func (h *UserHandler) handleKnowledgeFactorError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrKnowledgeFactorsNotEnabled):
+		h.handleError(w, r, err, http.StatusNotFound, "security questions are not enabled")
+	case errors.Is(err, services.ErrKnowledgeFactorsInvalid):
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid security question answers")
+	case errors.Is(err, services.ErrKnowledgeFactorAttemptsExceeded):
+		h.handleError(w, r, err, http.StatusTooManyRequests, "too many attempts, try again tomorrow")
+	case errors.Is(err, services.ErrStepUpFailed):
+		h.handleError(w, r, err, http.StatusForbidden, "re-authentication failed")
+	case errors.Is(err, domainerrors.ErrInvalidInput):
+		h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+	default:
+		h.handleError(w, r, err, http.StatusInternalServerError, message)
+	}
+}
+
+// toKnowledgeFactorAnswers maps the answers of a request to the domain type
+func toKnowledgeFactorAnswers(answers []SecurityQuestionAnswer) []services.KnowledgeFactorAnswer {
+	result := make([]services.KnowledgeFactorAnswer, 0, len(answers))
+	for _, answer := range answers {
+		result = append(result, services.KnowledgeFactorAnswer{
+			Question: answer.Question,
+			Answer:   answer.Answer,
+		})
+	}
+	return result
+}
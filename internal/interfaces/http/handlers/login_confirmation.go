@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// @Summary Confirm a login
+// @Description Confirm a login held back as coming from a new device or country with the token of the
+// @Description emailed link. The device and country become known, so signing in from them again succeeds.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ConfirmLoginRequest true "Confirmation token"
+// @Success 200 {object} MessageResponse "Login confirmed"
+// @Failure 400 {object} ErrorResponse "Invalid, expired or used token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/login/confirm [post]
+func (h *UserHandler) ConfirmLogin(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req ConfirmLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.userService.ConfirmLogin(r.Context(), req.Token); err != nil {
+		if errors.Is(err, services.ErrInvalidToken) {
+			h.handleError(w, r, err, http.StatusBadRequest, "invalid or expired confirmation link")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to confirm login")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, MessageResponse{
+		Message: "login confirmed; sign in again from the new device",
+	})
+}
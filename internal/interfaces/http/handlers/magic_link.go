@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// @Summary Request a magic link
+// @Description Email a single-use login link to the user with the given address. The response is the same
+// @Description whether or not the address is registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RequestMagicLinkRequest true "Email address"
+// @Success 200 {object} MessageResponse "Login link sent if the address is registered"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "Magic links not enabled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/magic-link [post]
+func (h *UserHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req RequestMagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Email == "" {
+		h.handleError(w, r, nil, http.StatusBadRequest, "email is required")
+		return
+	}
+
+	if err := h.userService.RequestMagicLink(r.Context(), req.Email); err != nil {
+		if errors.Is(err, services.ErrMagicLinksNotEnabled) {
+			h.handleError(w, r, err, http.StatusNotFound, "magic links are not enabled")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to request magic link")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, MessageResponse{
+		Message: "if the email exists, a login link has been sent",
+	})
+}
+
+// @Summary Sign in with a magic link
+// @Description Exchange the token of an emailed login link for an access and refresh token pair. Each link
+// @Description works once. Users who set up a second factor are asked for it as after a password login.
+// @Tags auth
+// @Produce json
+// @Param token query string true "Magic link token"
+// @Success 200 {object} LoginResponse "Login successful"
+// @Success 202 {object} MFAChallengeResponse "Second factor required"
+// @Failure 400 {object} ErrorResponse "Invalid, expired or used token"
+// @Failure 403 {object} ErrorResponse "Account disabled or denied by access policy (code names the rule)"
+// @Failure 404 {object} ErrorResponse "Magic links not enabled"
+// @Failure 409 {object} ErrorResponse "Concurrent session limit reached"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/magic-link/verify [get]
+func (h *UserHandler) VerifyMagicLink(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	response, err := h.userService.LoginWithMagicLink(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMagicLinksNotEnabled):
+			h.handleError(w, r, err, http.StatusNotFound, "magic links are not enabled")
+		case errors.Is(err, services.ErrInvalidToken):
+			h.handleError(w, r, err, http.StatusBadRequest, "invalid or expired magic link")
+		case errors.Is(err, services.ErrAccountDisabled):
+			h.handleError(w, r, err, http.StatusForbidden, "account is disabled")
+		case errors.Is(err, services.ErrSessionLimitReached):
+			h.handleError(w, r, err, http.StatusConflict, "concurrent session limit reached")
+		case errors.Is(err, services.ErrAccessPolicyViolation):
+			h.handleAccessPolicyViolation(w, r, err)
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to login")
+		}
+		return
+	}
+
+	h.respondLogin(w, r, response)
+}
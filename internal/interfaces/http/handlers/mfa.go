@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+)
+
+// newMFAChallengeResponse maps an MFA challenge to its API representation
+func newMFAChallengeResponse(challenge *services.MFAChallenge) MFAChallengeResponse {
+	message := "Enter the code of your authenticator app"
+	if challenge.Action == services.MFAActionEnroll {
+		message = "Multi-factor authentication is required. Set up an authenticator app to continue"
+	}
+	return MFAChallengeResponse{
+		MFAToken:  challenge.Token,
+		Action:    string(challenge.Action),
+		ExpiresAt: challenge.ExpiresAt,
+		Message:   message,
+	}
+}
+
+// handleMFAError responds to the errors shared by the MFA endpoints
+func (h *UserHandler) handleMFAError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrMFACodeInvalid):
+		h.handleError(w, r, err, http.StatusUnauthorized, "invalid code")
+	case errors.Is(err, services.ErrMFATokenInvalid):
+		h.handleError(w, r, err, http.StatusUnauthorized, "invalid or expired MFA token")
+	case errors.Is(err, services.ErrMFATooManyAttempts):
+		h.handleError(w, r, err, http.StatusTooManyRequests, "too many attempts, try again later")
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		h.handleError(w, r, err, http.StatusConflict, "MFA is already enabled")
+	case errors.Is(err, services.ErrMFANotEnabled):
+		h.handleError(w, r, err, http.StatusConflict, "MFA is not enabled")
+	case errors.Is(err, services.ErrMFARequiredByPolicy):
+		h.handleError(w, r, err, http.StatusForbidden, "MFA is required by policy")
+	case errors.Is(err, services.ErrAccountDisabled):
+		h.handleError(w, r, err, http.StatusForbidden, "account is disabled")
+	case errors.Is(err, services.ErrSessionLimitReached):
+		h.handleError(w, r, err, http.StatusConflict, "concurrent session limit reached")
+	default:
+		h.handleError(w, r, err, http.StatusInternalServerError, message)
+	}
+}
+
+// @Summary Complete a login with a second factor
+// @Description Exchange the MFA token of a login and a code of the user's authenticator app for tokens.
+// @Description For an enroll challenge the code confirms the authenticator set up through /auth/mfa/enroll.
+// @Description A token is dropped after five codes.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body MFAVerifyRequest true "MFA token and code"
+// @Success 200 {object} LoginResponse "Login successful"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Invalid code or MFA token"
+// @Failure 403 {object} ErrorResponse "Account disabled"
+// @Failure 409 {object} ErrorResponse "No authenticator set up or concurrent session limit reached"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/mfa/verify [post]
+func (h *UserHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req MFAVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	response, err := h.userService.CompleteMFALogin(r.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		h.handleMFAError(w, r, err, "failed to login")
+		return
+	}
+
+	h.respondLogin(w, r, response)
+}
+
+// @Summary Set up an authenticator during login
+// @Description Start setting up an authenticator app for a login whose MFA challenge has action enroll.
+// @Description Complete the login by posting a code of the new authenticator to /auth/mfa/verify.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body MFAEnrollRequest true "MFA token"
+// @Success 200 {object} TOTPEnrollment "Authenticator secret"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Invalid MFA token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/mfa/enroll [post]
+func (h *UserHandler) EnrollMFAChallenge(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req MFAEnrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	enrollment, err := h.userService.BeginChallengeEnrollment(r.Context(), req.MFAToken)
+	if err != nil {
+		h.handleMFAError(w, r, err, "failed to set up authenticator")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, TOTPEnrollment(*enrollment))
+}
+
+// @Summary Get MFA status
+// @Description Get whether the authenticated user has an authenticator app set up and whether a policy requires one
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} MFAStatus "MFA status"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/mfa [get]
+func (h *UserHandler) GetMFAStatus(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	status, err := h.userService.GetMFAStatus(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get MFA status")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newMFAStatus(status))
+}
+
+// @Summary Start authenticator app setup
+// @Description Generate a new authenticator app secret for the authenticated user. It takes effect once confirmed with a code;
+// @Description starting again replaces an unconfirmed secret.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} TOTPEnrollment "Authenticator secret"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 409 {object} ErrorResponse "MFA already enabled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/mfa/totp [post]
+func (h *UserHandler) BeginTOTPEnrollment(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	enrollment, err := h.userService.BeginTOTPEnrollment(r.Context(), id)
+	if err != nil {
+		h.handleMFAError(w, r, err, "failed to set up authenticator")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, TOTPEnrollment(*enrollment))
+}
+
+// @Summary Confirm authenticator app setup
+// @Description Enable the authenticator app of the authenticated user with a code it generated
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body MFACodeRequest true "Authenticator code"
+// @Success 200 {object} MessageResponse "MFA enabled"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Invalid code"
+// @Failure 409 {object} ErrorResponse "No setup started or MFA already enabled"
+// @Failure 429 {object} ErrorResponse "Too many attempts"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/mfa/totp/confirm [post]
+func (h *UserHandler) ConfirmTOTPEnrollment(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.userService.ConfirmTOTPEnrollment(r.Context(), id, req.Code); err != nil {
+		h.handleMFAError(w, r, err, "failed to enable MFA")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, MessageResponse{
+		Message: "MFA enabled",
+	})
+}
+
+// @Summary Disable authenticator app
+// @Description Remove the authenticator app of the authenticated user with a current code. Not allowed while a policy requires MFA.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body MFACodeRequest true "Authenticator code"
+// @Success 200 {object} MessageResponse "MFA disabled"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Invalid code"
+// @Failure 403 {object} ErrorResponse "MFA required by policy"
+// @Failure 409 {object} ErrorResponse "MFA not enabled"
+// @Failure 429 {object} ErrorResponse "Too many attempts"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/mfa/totp [delete]
+func (h *UserHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.userService.DisableTOTP(r.Context(), id, req.Code); err != nil {
+		h.handleMFAError(w, r, err, "failed to disable MFA")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, MessageResponse{
+		Message: "MFA disabled",
+	})
+}
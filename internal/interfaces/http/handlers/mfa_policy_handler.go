@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// MFAPolicyHandler handles requests managing MFA enforcement policies
+type MFAPolicyHandler struct {
+	baseHandler
+	policies services.MFAPolicyService
+}
+
+// NewMFAPolicyHandler creates a new MFA policy handler
+func NewMFAPolicyHandler(
+	policies services.MFAPolicyService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *MFAPolicyHandler {
+	return &MFAPolicyHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		policies: policies,
+	}
+}
+
+// @Summary List MFA policies
+// @Description List the MFA enforcement policies, earliest enforcement first
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} MFAPolicy "MFA policies"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/mfa-policies [get]
+func (h *MFAPolicyHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	policies, err := h.policies.ListPolicies(r.Context())
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to list MFA policies")
+		return
+	}
+
+	response := make([]MFAPolicy, 0, len(policies))
+	for _, policy := range policies {
+		response = append(response, newMFAPolicy(policy))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Create MFA policy
+// @Description Require the covered users to sign in with a second factor from enforceAfter on. Until then they are
+// @Description reminded at login; afterwards users without an authenticator must set one up to complete their login.
+// @Description Other instances apply the change within a minute.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body MFAPolicy true "MFA policy"
+// @Success 201 {object} MFAPolicy "Created policy"
+// @Failure 400 {object} ErrorResponse "Invalid policy"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/mfa-policies [post]
+func (h *MFAPolicyHandler) CreatePolicy(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	var req MFAPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	policy, err := req.toModel(uuid.Nil)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+
+	if err := h.policies.CreatePolicy(r.Context(), policy); err != nil {
+		if errors.Is(err, domainerrors.ErrInvalidInput) {
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to create MFA policy")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, newMFAPolicy(policy))
+}
+
+// @Summary Get MFA policy
+// @Description Get an MFA enforcement policy
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Policy ID"
+// @Success 200 {object} MFAPolicy "MFA policy"
+// @Failure 400 {object} ErrorResponse "Invalid policy ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Policy not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/mfa-policies/{id} [get]
+func (h *MFAPolicyHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid policy ID")
+		return
+	}
+
+	policy, err := h.policies.GetPolicy(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "MFA policy not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get MFA policy")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newMFAPolicy(policy))
+}
+
+// @Summary Replace MFA policy
+// @Description Replace an MFA enforcement policy. Other instances apply the change within a minute.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Policy ID"
+// @Param request body MFAPolicy true "MFA policy"
+// @Success 200 {object} MFAPolicy "Updated policy"
+// @Failure 400 {object} ErrorResponse "Invalid policy"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Policy not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/mfa-policies/{id} [put]
+func (h *MFAPolicyHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid policy ID")
+		return
+	}
+
+	var req MFAPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	policy, err := req.toModel(id)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+
+	if err := h.policies.UpdatePolicy(r.Context(), policy); err != nil {
+		switch {
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrNotFound):
+			h.handleError(w, r, err, http.StatusNotFound, "MFA policy not found")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to update MFA policy")
+		}
+		return
+	}
+
+	// Reload for the creation time, which the request does not carry
+	updated, err := h.policies.GetPolicy(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get MFA policy")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, newMFAPolicy(updated))
+}
+
+// @Summary Delete MFA policy
+// @Description Delete an MFA enforcement policy. Users keep the authenticators they set up.
+// @Tags admin
+// @Security BearerAuth
+// @Param id path string true "Policy ID"
+// @Success 204 "Policy deleted"
+// @Failure 400 {object} ErrorResponse "Invalid policy ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Policy not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/mfa-policies/{id} [delete]
+func (h *MFAPolicyHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid policy ID")
+		return
+	}
+
+	if err := h.policies.DeletePolicy(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "MFA policy not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to delete MFA policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+	"go.uber.org/zap"
+)
+
+// ModeHandler handles HTTP requests for switching the service mode
+type ModeHandler struct {
+	baseHandler
+	controller *middleware.ModeController
+}
+
+// NewModeHandler creates a new mode handler
+func NewModeHandler(
+	controller *middleware.ModeController,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *ModeHandler {
+	return &ModeHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		controller: controller,
+	}
+}
+
+// SetModeRequest represents the request body for changing the service mode
+type SetModeRequest struct {
+	Mode    string `json:"mode"`
+	Message string `json:"message"`
+}
+
+// @Summary Get service mode
+// @Description Get the current service mode (normal, read_only or maintenance)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} middleware.ModeStatus "Current service mode"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /admin/mode [get]
+func (h *ModeHandler) GetMode(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	h.respondJSON(w, http.StatusOK, h.controller.Status())
+}
+
+// @Summary Set service mode
+// @Description Switch the service into normal, read-only or maintenance mode. The mode is shared by
+// @Description all instances, which switch within seconds. In read-only mode authentication keeps
+// @Description working and other mutations return 503; in maintenance mode every API request returns
+// @Description 503 with the given message.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body SetModeRequest true "Service mode"
+// @Success 200 {object} middleware.ModeStatus "Updated service mode"
+// @Failure 400 {object} ErrorResponse "Invalid mode"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/mode [put]
+func (h *ModeHandler) SetMode(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req SetModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	mode, err := middleware.ParseServiceMode(req.Mode)
+	if err != nil || req.Mode == "" {
+		h.handleError(w, r, err, http.StatusBadRequest, "mode must be one of normal, read_only or maintenance")
+		return
+	}
+
+	if err := h.controller.SetMode(r.Context(), mode, req.Message); err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to set service mode")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.controller.Status())
+}
@@ -1,9 +1,13 @@
 package handlers
 
-// ErrorResponse represents an error response
+import "time"
+
+// ErrorResponse represents an error response, documenting
+// middleware.ErrorEnvelope
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	Error     string `json:"error"`
+	Code      string `json:"code"` // e.g. user_already_exists or the access policy rule that denied a login
+	RequestID string `json:"requestId,omitempty"`
 }
 
 // MessageResponse represents a simple message response
@@ -13,7 +17,10 @@ type MessageResponse struct {
 
 // TokenResponse represents an authentication token response
 type TokenResponse struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
-	ExpiresIn   int64  `json:"expiresIn"`
+	AccessToken      string    `json:"accessToken"`
+	RefreshToken     string    `json:"refreshToken"`
+	TokenType        string    `json:"tokenType"`
+	ExpiresIn        int64     `json:"expiresIn"`
+	RefreshExpiresIn int64     `json:"refreshExpiresIn"`
+	ExpiresAt        time.Time `json:"expiresAt"`
 }
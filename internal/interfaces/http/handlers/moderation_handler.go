@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+	"go.uber.org/zap"
+)
+
+// ModerationHandler handles abuse reports of accounts and the admin review
+// queue of flags
+type ModerationHandler struct {
+	baseHandler
+	moderation services.ModerationService
+}
+
+// NewModerationHandler creates a new moderation handler
+func NewModerationHandler(
+	moderation services.ModerationService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *ModerationHandler {
+	return &ModerationHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		moderation: moderation,
+	}
+}
+
+// @Summary Report an account
+// @Description Report another account for spam, fraud or other abuse. Reports wait for an admin's review;
+// @Description an account reported by enough users only gets read-only tokens until then.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body FlagAccountRequest true "Report"
+// @Success 201 {object} AccountFlag "Report"
+// @Failure 400 {object} ErrorResponse "Invalid report"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 409 {object} ErrorResponse "Account already reported"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/{id}/reports [post]
+func (h *ModerationHandler) ReportAccount(w http.ResponseWriter, r *http.Request) {
+	h.flagAccount(w, r, false)
+}
+
+// @Summary Flag an account
+// @Description Flag an account for spam, fraud or other abuse. The account only gets read-only tokens
+// @Description until the flag is dismissed.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body FlagAccountRequest true "Flag"
+// @Success 201 {object} AccountFlag "Flag"
+// @Failure 400 {object} ErrorResponse "Invalid flag"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 409 {object} ErrorResponse "Account already flagged"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/flags [post]
+func (h *ModerationHandler) FlagAccount(w http.ResponseWriter, r *http.Request) {
+	h.flagAccount(w, r, true)
+}
+
+// flagAccount reports the account in the path on behalf of the
+// authenticated user or admin
+func (h *ModerationHandler) flagAccount(w http.ResponseWriter, r *http.Request, byAdmin bool) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	reporterID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	var req FlagAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	flag, err := h.moderation.FlagAccount(r.Context(), services.FlagAccountInput{
+		UserID:     userID,
+		Category:   models.FlagCategory(req.Category),
+		Reason:     req.Reason,
+		ReportedBy: reporterID,
+		ByAdmin:    byAdmin,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		case errors.Is(err, domainerrors.ErrUserNotFound):
+			h.handleError(w, r, err, http.StatusNotFound, "user not found")
+		case errors.Is(err, services.ErrAccountAlreadyReported):
+			h.handleError(w, r, err, http.StatusConflict, "account already reported")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to flag account")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, newAccountFlag(flag))
+}
+
+// @Summary List account flags
+// @Description List the review queue of reports and flags, oldest first
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Only flags with this status: open, confirmed or dismissed"
+// @Param userId query string false "Only flags of this user"
+// @Param offset query int false "Number of flags to skip"
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Success 200 {array} AccountFlag "Flags"
+// @Failure 400 {object} ErrorResponse "Invalid query"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/flags [get]
+func (h *ModerationHandler) ListFlags(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	params := r.URL.Query()
+	filter := repositories.AccountFlagFilter{Status: models.FlagStatus(params.Get("status"))}
+	if value := params.Get("userId"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			h.handleError(w, r, err, http.StatusBadRequest, "invalid userId")
+			return
+		}
+		filter.UserID = userID
+	}
+	var offset, limit int
+	pagination := []struct {
+		name   string
+		target *int
+	}{{"offset", &offset}, {"limit", &limit}}
+	for _, param := range pagination {
+		if value := params.Get(param.name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				h.handleError(w, r, err, http.StatusBadRequest, "invalid "+param.name)
+				return
+			}
+			*param.target = parsed
+		}
+	}
+
+	flags, err := h.moderation.ListFlags(r.Context(), filter, offset, limit)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrInvalidInput) {
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to list flags")
+		return
+	}
+
+	response := make([]AccountFlag, 0, len(flags))
+	for _, flag := range flags {
+		response = append(response, newAccountFlag(flag))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Review an account flag
+// @Description Confirm or dismiss an open flag, or lift a confirmed one by dismissing it. The account's
+// @Description restriction follows from its remaining flags.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param flagId path string true "Flag ID"
+// @Param request body ReviewFlagRequest true "Review"
+// @Success 200 {object} AccountFlag "Reviewed flag"
+// @Failure 400 {object} ErrorResponse "Invalid review"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Flag not found"
+// @Failure 409 {object} ErrorResponse "Flag cannot be reviewed this way"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/flags/{flagId} [put]
+func (h *ModerationHandler) ReviewFlag(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	reviewerID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["flagId"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid flag ID")
+		return
+	}
+
+	var req ReviewFlagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	flag, err := h.moderation.ReviewFlag(r.Context(), id, services.ReviewFlagInput{
+		Status:     models.FlagStatus(req.Status),
+		ReviewerID: reviewerID,
+		Note:       req.Note,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrNotFound):
+			h.handleError(w, r, err, http.StatusNotFound, "flag not found")
+		case errors.Is(err, services.ErrFlagAlreadyReviewed):
+			h.handleError(w, r, err, http.StatusConflict, "flag cannot be reviewed this way")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to review flag")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newAccountFlag(flag))
+}
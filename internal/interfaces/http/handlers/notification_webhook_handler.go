@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+	"go.uber.org/zap"
+)
+
+// NotificationWebhookHandler handles requests managing notification
+// webhooks. Its routes serve the authenticated user under /users/me and
+// admins under /admin/users/{id}.
+type NotificationWebhookHandler struct {
+	baseHandler
+	webhooks services.NotificationWebhookService
+}
+
+// NewNotificationWebhookHandler creates a new notification webhook handler
+func NewNotificationWebhookHandler(
+	webhooks services.NotificationWebhookService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *NotificationWebhookHandler {
+	return &NotificationWebhookHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		webhooks: webhooks,
+	}
+}
+
+// @Summary List notification webhooks
+// @Description List the webhooks notified of logins and security changes on an account
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string false "User ID (admin route)"
+// @Success 200 {array} NotificationWebhook "Webhooks"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/webhooks [get]
+// @Router /admin/users/{id}/webhooks [get]
+func (h *NotificationWebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	userID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.webhooks.List(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to list webhooks")
+		return
+	}
+
+	response := make([]NotificationWebhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		response = append(response, newNotificationWebhook(webhook))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Register notification webhook
+// @Description Register an HTTPS webhook notified of logins and security changes on an account. JSON deliveries are
+// @Description signed with the secret, which is only returned in this response; slack deliveries post a message to a
+// @Description Slack incoming webhook. Without events the webhook receives every notification.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string false "User ID (admin route)"
+// @Param request body RegisterNotificationWebhookRequest true "Webhook"
+// @Success 201 {object} RegisteredNotificationWebhook "Registered webhook"
+// @Failure 400 {object} ErrorResponse "Invalid webhook"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 409 {object} ErrorResponse "Webhook limit reached"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/webhooks [post]
+// @Router /admin/users/{id}/webhooks [post]
+func (h *NotificationWebhookHandler) RegisterWebhook(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	userID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+	actorID, _ := middleware.GetUserID(r.Context())
+
+	var req RegisterNotificationWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	webhook, err := h.webhooks.Register(r.Context(), services.RegisterWebhookInput{
+		UserID:     userID,
+		URL:        req.URL,
+		Format:     models.WebhookFormat(req.Format),
+		EventTypes: req.Events,
+		CreatedBy:  actorID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		case errors.Is(err, domainerrors.ErrUserNotFound):
+			h.handleError(w, r, err, http.StatusNotFound, "user not found")
+		case errors.Is(err, services.ErrWebhookLimitReached):
+			h.handleError(w, r, err, http.StatusConflict, "webhook limit reached")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to register webhook")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, RegisteredNotificationWebhook{
+		NotificationWebhook: newNotificationWebhook(webhook),
+		Secret:              webhook.Secret,
+	})
+}
+
+// @Summary Delete notification webhook
+// @Description Stop notifying a webhook of an account
+// @Tags users
+// @Security BearerAuth
+// @Param id path string false "User ID (admin route)"
+// @Param webhookId path string true "Webhook ID"
+// @Success 204 "Webhook deleted"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/webhooks/{webhookId} [delete]
+// @Router /admin/users/{id}/webhooks/{webhookId} [delete]
+func (h *NotificationWebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	userID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["webhookId"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid webhook ID")
+		return
+	}
+
+	if err := h.webhooks.Delete(r.Context(), userID, id); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "webhook not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// targetUser resolves the account a request manages: the user in the path
+// on admin routes, the authenticated user otherwise. It responds with an
+// error when there is none.
+func (h *NotificationWebhookHandler) targetUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	if raw, ok := mux.Vars(r)["id"]; ok {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+			return uuid.Nil, false
+		}
+		return id, true
+	}
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return uuid.Nil, false
+	}
+	return id, true
+}
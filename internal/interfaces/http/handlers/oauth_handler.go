@@ -0,0 +1,681 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+	"go.uber.org/zap"
+)
+
+// OAuthHandler serves the OAuth 2.0 / OpenID Connect provider endpoints and
+// the admin endpoints managing OAuth clients
+type OAuthHandler struct {
+	baseHandler
+	oauthService services.OAuthService
+	// loginURL is where users without a session are sent by the
+	// authorization endpoint, with the authorization request as return_to
+	loginURL string
+}
+
+// NewOAuthHandler creates a new OAuth handler
+func NewOAuthHandler(
+	oauthService services.OAuthService,
+	loginURL string,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *OAuthHandler {
+	return &OAuthHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		oauthService: oauthService,
+		loginURL:     loginURL,
+	}
+}
+
+// @Summary OpenID Connect discovery
+// @Description Get the OpenID Connect provider metadata
+// @Tags oauth
+// @Produce json
+// @Success 200 {object} ProviderMetadata "Provider metadata"
+// @Router /.well-known/openid-configuration [get]
+func (h *OAuthHandler) Discovery(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	h.respondJSON(w, http.StatusOK, newProviderMetadata(h.oauthService.Metadata()))
+}
+
+// @Summary Authorization endpoint
+// @Description Grant an authorization code to a client for the signed-in user and redirect back to the client. Users without a session are sent to the login page first.
+// @Tags oauth
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param scope query string false "Space-separated scopes, e.g. openid profile email"
+// @Param state query string false "Opaque value returned to the client"
+// @Param nonce query string false "Value echoed in the ID token"
+// @Param code_challenge query string false "PKCE challenge, required for public clients"
+// @Param code_challenge_method query string false "Must be S256"
+// @Success 302 "Redirect to the client or the login page"
+// @Failure 400 {object} OAuthErrorResponse "Unknown client or redirect URI"
+// @Router /oauth2/authorize [get]
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusFound, time.Since(start).Seconds())
+	}()
+
+	query := r.URL.Query()
+	request := services.AuthorizationRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		Nonce:               query.Get("nonce"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	// Errors about the client or redirect URI must not be redirected
+	if err := h.oauthService.ValidateRedirect(r.Context(), request); err != nil {
+		var oauthErr *services.OAuthError
+		if errors.As(err, &oauthErr) {
+			h.respondJSON(w, http.StatusBadRequest, OAuthErrorResponse{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to validate authorization request")
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		if h.loginURL != "" {
+			h.redirectToLogin(w, r)
+			return
+		}
+		h.redirectToClient(w, r, request, url.Values{"error": {services.OAuthLoginRequired}})
+		return
+	}
+
+	code, err := h.oauthService.Authorize(r.Context(), userID, request)
+	if err != nil {
+		var oauthErr *services.OAuthError
+		if !errors.As(err, &oauthErr) {
+			h.logger.Error("failed to authorize client", zap.String("clientId", request.ClientID), zap.Error(err))
+			oauthErr = services.NewOAuthError("server_error", "")
+		}
+		params := url.Values{"error": {oauthErr.Code}}
+		if oauthErr.Description != "" {
+			params.Set("error_description", oauthErr.Description)
+		}
+		h.redirectToClient(w, r, request, params)
+		return
+	}
+
+	h.redirectToClient(w, r, request, url.Values{"code": {code}})
+}
+
+// redirectToLogin sends the user to the login page, which returns to the
+// authorization request once the user has signed in
+func (h *OAuthHandler) redirectToLogin(w http.ResponseWriter, r *http.Request) {
+	u, err := url.Parse(h.loginURL)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "invalid login URL")
+		return
+	}
+	query := u.Query()
+	query.Set("return_to", r.URL.RequestURI())
+	u.RawQuery = query.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// redirectToClient redirects to the validated redirect URI of a request,
+// adding params and the state of the request
+func (h *OAuthHandler) redirectToClient(w http.ResponseWriter, r *http.Request, request services.AuthorizationRequest, params url.Values) {
+	u, err := url.Parse(request.RedirectURI)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "invalid redirect URI")
+		return
+	}
+	query := u.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	if request.State != "" {
+		query.Set("state", request.State)
+	}
+	u.RawQuery = query.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// @Summary Token endpoint
+// @Description Exchange an authorization code or client credentials for tokens. Clients authenticate with HTTP basic auth or client_id and client_secret form fields.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code or client_credentials"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI of the authorization request"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param scope formData string false "Requested scopes for client credentials"
+// @Success 200 {object} OAuthTokenResponse "Tokens"
+// @Failure 400 {object} OAuthErrorResponse "Invalid request or grant"
+// @Failure 401 {object} OAuthErrorResponse "Client authentication failed"
+// @Router /oauth2/token [post]
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	// Token responses must never be cached (RFC 6749 section 5.1)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		h.respondJSON(w, http.StatusBadRequest, OAuthErrorResponse{Error: services.OAuthInvalidRequest, ErrorDescription: "malformed request body"})
+		return
+	}
+	request := services.OAuthTokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		Scope:        r.PostForm.Get("scope"),
+	}
+	basicAuth := clientCredentials(r, &request.ClientID, &request.ClientSecret)
+
+	tokens, err := h.oauthService.Token(r.Context(), request)
+	if err != nil {
+		h.handleClientError(w, r, err, basicAuth, "failed to issue tokens")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, OAuthTokenResponse{
+		AccessToken: tokens.AccessToken,
+		TokenType:   services.BearerTokenType,
+		ExpiresIn:   int(tokens.ExpiresIn.Seconds()),
+		IDToken:     tokens.IDToken,
+		Scope:       tokens.Scope,
+	})
+}
+
+// @Summary Token introspection endpoint
+// @Description Check whether an access token is active (RFC 7662). Confidential clients such as resource servers
+// @Description authenticate with HTTP basic auth or client_id and client_secret form fields. Revoked, expired and
+// @Description invalid tokens are reported as inactive.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Access token"
+// @Param token_type_hint formData string false "access_token"
+// @Success 200 {object} TokenIntrospectionResponse "Token state"
+// @Failure 400 {object} OAuthErrorResponse "Invalid request or client may not introspect"
+// @Failure 401 {object} OAuthErrorResponse "Client authentication failed"
+// @Router /oauth2/introspect [post]
+func (h *OAuthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	request, basicAuth, ok := h.tokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	introspection, err := h.oauthService.Introspect(r.Context(), request)
+	if err != nil {
+		h.handleClientError(w, r, err, basicAuth, "failed to introspect token")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newTokenIntrospectionResponse(introspection))
+}
+
+// @Summary Batch token introspection endpoint
+// @Description Check whether up to a configured number of access tokens are active in one request, for consumers
+// @Description such as log processors. Tokens are passed as repeated token form fields, and the response describes
+// @Description them in the same order like the introspection endpoint does. Confidential clients authenticate with
+// @Description HTTP basic auth or client_id and client_secret form fields.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData []string true "Access tokens" collectionFormat(multi)
+// @Success 200 {object} BatchIntrospectionResponse "Token states"
+// @Failure 400 {object} OAuthErrorResponse "Invalid request, too many tokens or client may not introspect"
+// @Failure 401 {object} OAuthErrorResponse "Client authentication failed"
+// @Router /oauth2/introspect/batch [post]
+func (h *OAuthHandler) IntrospectBatch(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		h.respondJSON(w, http.StatusBadRequest, OAuthErrorResponse{Error: services.OAuthInvalidRequest, ErrorDescription: "malformed request body"})
+		return
+	}
+	request := services.BatchTokenRequest{
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+		Tokens:       r.PostForm["token"],
+	}
+	basicAuth := clientCredentials(r, &request.ClientID, &request.ClientSecret)
+
+	introspections, err := h.oauthService.IntrospectBatch(r.Context(), request)
+	if err != nil {
+		h.handleClientError(w, r, err, basicAuth, "failed to introspect tokens")
+		return
+	}
+
+	response := BatchIntrospectionResponse{Tokens: make([]TokenIntrospectionResponse, len(introspections))}
+	for i := range introspections {
+		response.Tokens[i] = newTokenIntrospectionResponse(&introspections[i])
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Token revocation endpoint
+// @Description Revoke an access token issued to the client (RFC 7009). Clients authenticate with HTTP basic auth or
+// @Description client_id and client_secret form fields. Invalid and expired tokens are ignored.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param token formData string true "Access token"
+// @Param token_type_hint formData string false "access_token"
+// @Success 200 "Token revoked or already invalid"
+// @Failure 400 {object} OAuthErrorResponse "Invalid request or token issued to another client"
+// @Failure 401 {object} OAuthErrorResponse "Client authentication failed"
+// @Router /oauth2/revoke [post]
+func (h *OAuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	request, basicAuth, ok := h.tokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.oauthService.Revoke(r.Context(), request); err != nil {
+		h.handleClientError(w, r, err, basicAuth, "failed to revoke token")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// tokenRequest parses a request of the introspection or revocation
+// endpoint, or responds with an error. It reports whether the client
+// authenticated with HTTP basic auth.
+func (h *OAuthHandler) tokenRequest(w http.ResponseWriter, r *http.Request) (services.TokenRequest, bool, bool) {
+	if err := r.ParseForm(); err != nil {
+		h.respondJSON(w, http.StatusBadRequest, OAuthErrorResponse{Error: services.OAuthInvalidRequest, ErrorDescription: "malformed request body"})
+		return services.TokenRequest{}, false, false
+	}
+	request := services.TokenRequest{
+		ClientID:      r.PostForm.Get("client_id"),
+		ClientSecret:  r.PostForm.Get("client_secret"),
+		Token:         r.PostForm.Get("token"),
+		TokenTypeHint: r.PostForm.Get("token_type_hint"),
+	}
+	basicAuth := clientCredentials(r, &request.ClientID, &request.ClientSecret)
+	return request, basicAuth, true
+}
+
+// clientCredentials replaces the client ID and secret of a request body
+// with those of HTTP basic auth, if present, and reports whether they were
+func clientCredentials(r *http.Request, clientID, clientSecret *string) bool {
+	id, secret, basicAuth := r.BasicAuth()
+	if basicAuth {
+		*clientID = id
+		*clientSecret = secret
+	}
+	return basicAuth
+}
+
+// handleClientError responds with the OAuth error of a request of an
+// authenticating client. Failed authentication is reported with 401, with a
+// challenge when the client used HTTP basic auth.
+func (h *OAuthHandler) handleClientError(w http.ResponseWriter, r *http.Request, err error, basicAuth bool, message string) {
+	var oauthErr *services.OAuthError
+	if !errors.As(err, &oauthErr) {
+		h.handleError(w, r, err, http.StatusInternalServerError, message)
+		return
+	}
+	status := http.StatusBadRequest
+	if oauthErr.Code == services.OAuthInvalidClient {
+		status = http.StatusUnauthorized
+		if basicAuth {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+		}
+	}
+	h.respondJSON(w, status, OAuthErrorResponse{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+}
+
+// @Summary UserInfo endpoint
+// @Description Get the claims about the user of an access token granted the openid scope
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "OpenID Connect claims"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} OAuthErrorResponse "Token lacks the openid scope"
+// @Router /oauth2/userinfo [get]
+func (h *OAuthHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	info, err := h.oauthService.UserInfo(r.Context(), userID, middleware.GetScope(r.Context()))
+	if err != nil {
+		var oauthErr *services.OAuthError
+		switch {
+		case errors.As(err, &oauthErr):
+			w.Header().Set("WWW-Authenticate", `Bearer error="`+oauthErr.Code+`"`)
+			h.respondJSON(w, http.StatusForbidden, OAuthErrorResponse{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+		case errors.Is(err, domainerrors.ErrUserNotFound), errors.Is(err, services.ErrNotFound):
+			h.handleError(w, r, err, http.StatusUnauthorized, "unauthorized")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to get user info")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, info.Claims())
+}
+
+// @Summary List OAuth clients
+// @Description List the registered OAuth clients. Secrets are never returned.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} OAuthClient "OAuth clients"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/oauth/clients [get]
+func (h *OAuthHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	clients, err := h.oauthService.ListClients(r.Context())
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to list OAuth clients")
+		return
+	}
+
+	response := make([]OAuthClient, 0, len(clients))
+	for _, client := range clients {
+		response = append(response, newOAuthClient(client))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Register OAuth client
+// @Description Register an OAuth client. The client secret is only returned in this response.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body RegisterOAuthClientRequest true "Client registration"
+// @Success 201 {object} RegisteredOAuthClient "Registered client"
+// @Failure 400 {object} ErrorResponse "Invalid registration"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/oauth/clients [post]
+func (h *OAuthHandler) RegisterClient(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	var req RegisterOAuthClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	grantTypes := make([]models.OAuthGrantType, 0, len(req.GrantTypes))
+	for _, grantType := range req.GrantTypes {
+		grantTypes = append(grantTypes, models.OAuthGrantType(grantType))
+	}
+	client, secret, err := h.oauthService.RegisterClient(r.Context(), services.OAuthClientRegistration{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		GrantTypes:   grantTypes,
+		Scopes:       req.Scopes,
+		Public:       req.Public,
+	})
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrInvalidInput) {
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to register OAuth client")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, RegisteredOAuthClient{
+		OAuthClient:  newOAuthClient(client),
+		ClientSecret: secret,
+	})
+}
+
+// @Summary Delete OAuth client
+// @Description Delete an OAuth client. Tokens already issued to it stay valid until they expire.
+// @Tags admin
+// @Security BearerAuth
+// @Param clientId path string true "Client ID"
+// @Success 204 "Client deleted"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Client not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/oauth/clients/{clientId} [delete]
+func (h *OAuthHandler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	if err := h.oauthService.DeleteClient(r.Context(), mux.Vars(r)["clientId"]); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "OAuth client not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to delete OAuth client")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Update OAuth client
+// @Description Change the name, redirect URIs or scopes of an OAuth client. Omitted fields are left unchanged.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param clientId path string true "Client ID"
+// @Param request body UpdateOAuthClientRequest true "Client changes"
+// @Success 200 {object} OAuthClient "Updated client"
+// @Failure 400 {object} ErrorResponse "Invalid client configuration"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Client not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/oauth/clients/{clientId} [patch]
+func (h *OAuthHandler) UpdateClient(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req UpdateOAuthClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	client, err := h.oauthService.UpdateClient(r.Context(), mux.Vars(r)["clientId"], services.OAuthClientUpdate{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.handleError(w, r, err, http.StatusNotFound, "OAuth client not found")
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to update OAuth client")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newOAuthClient(client))
+}
+
+// @Summary Disable OAuth client
+// @Description Stop an OAuth client from authenticating and signing users in. Introspection reports tokens already issued
+// @Description to it as inactive while it is disabled; resource servers validating tokens themselves accept them until they expire.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param clientId path string true "Client ID"
+// @Success 200 {object} OAuthClient "Disabled client"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Client not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/oauth/clients/{clientId}/disable [post]
+func (h *OAuthHandler) DisableClient(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	client, err := h.oauthService.DisableClient(r.Context(), mux.Vars(r)["clientId"])
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "OAuth client not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to disable OAuth client")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newOAuthClient(client))
+}
+
+// @Summary Enable OAuth client
+// @Description Let a disabled OAuth client authenticate and sign users in again.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param clientId path string true "Client ID"
+// @Success 200 {object} OAuthClient "Enabled client"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Client not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/oauth/clients/{clientId}/enable [post]
+func (h *OAuthHandler) EnableClient(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	client, err := h.oauthService.EnableClient(r.Context(), mux.Vars(r)["clientId"])
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "OAuth client not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to enable OAuth client")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newOAuthClient(client))
+}
+
+// @Summary Regenerate OAuth client secret
+// @Description Replace the secret of a confidential OAuth client. The old secret keeps working for the requested overlap, so that deployments of the client can switch over; without overlap it stops working immediately.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param clientId path string true "Client ID"
+// @Param request body RegenerateOAuthClientSecretRequest false "Overlap of the old secret"
+// @Success 200 {object} OAuthClientSecret "New client secret"
+// @Failure 400 {object} ErrorResponse "Public clients have no secret or invalid overlap"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Client not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/oauth/clients/{clientId}/secret [post]
+func (h *OAuthHandler) RegenerateClientSecret(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	// The body is optional; without it the old secret is revoked immediately
+	var req RegenerateOAuthClientSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	clientID := mux.Vars(r)["clientId"]
+	overlap := time.Duration(req.OverlapMinutes) * time.Minute
+	client, secret, err := h.oauthService.RegenerateClientSecret(r.Context(), clientID, overlap)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			h.handleError(w, r, err, http.StatusNotFound, "OAuth client not found")
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to regenerate client secret")
+		}
+		return
+	}
+
+	h.logger.Info("OAuth client secret regenerated",
+		zap.String("clientId", clientID),
+		zap.Duration("overlap", overlap))
+	h.respondJSON(w, http.StatusOK, OAuthClientSecret{
+		ClientID:                clientID,
+		ClientSecret:            secret,
+		PreviousSecretExpiresAt: client.PreviousSecretExpiresAt,
+	})
+}
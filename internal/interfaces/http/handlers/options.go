@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// UserHandlerOption configures optional UserHandler behaviour
+type UserHandlerOption func(*UserHandler)
+
+// WithVerifyEmailRedirect enables redirects after email verification
+func WithVerifyEmailRedirect(cfg VerifyEmailRedirectConfig) UserHandlerOption {
+	return func(h *UserHandler) {
+		h.verifyEmailRedirect = cfg
+	}
+}
+
+// WithCookies enables cookie mode, in which tokens are also issued as
+// HttpOnly cookies and cleared again on logout
+func WithCookies(cfg CookieConfig) UserHandlerOption {
+	return func(h *UserHandler) {
+		h.cookies = cfg
+	}
+}
+
+// WithPublicProfileMaxAge lets clients and shared caches reuse public
+// profiles for maxAge; 0 disables caching
+func WithPublicProfileMaxAge(maxAge time.Duration) UserHandlerOption {
+	return func(h *UserHandler) {
+		h.publicProfileMaxAge = maxAge
+	}
+}
+
+// WithSocialLogin enables signing in with the identity providers of federation
+func WithSocialLogin(federation services.FederationService, cfg SocialLoginConfig) UserHandlerOption {
+	return func(h *UserHandler) {
+		h.federation = federation
+		h.socialLogin = cfg
+	}
+}
+
+// WithSSO enables signing in with the SSO connections of organizations.
+// Browsers are redirected as configured for social logins.
+func WithSSO(sso services.SSOService) UserHandlerOption {
+	return func(h *UserHandler) {
+		h.sso = sso
+	}
+}
+
+// WithAvatars enables avatars in profile responses
+func WithAvatars(cfg AvatarConfig) UserHandlerOption {
+	return func(h *UserHandler) {
+		h.avatars = cfg
+	}
+}
@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+)
+
+// @Summary Set domain join policy
+// @Description Set what happens to users whose verified email is in one of the organization's verified domains:
+// @Description off leaves them alone, invite invites them and join makes them members
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param request body DomainJoinPolicyRequest true "Policy"
+// @Success 200 {object} Organization "Updated organization"
+// @Failure 400 {object} ErrorResponse "Invalid policy"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/domain-join-policy [put]
+func (h *OrganizationHandler) SetDomainJoinPolicy(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req DomainJoinPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	organization, err := h.organizations.SetDomainJoinPolicy(r.Context(), id, models.DomainJoinPolicy(req.Policy), actorID)
+	if err != nil {
+		h.handleOrganizationDomainError(w, r, err, "failed to set domain join policy")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newOrganization(organization))
+}
+
+// @Summary List organization domains
+// @Description List the email domains an organization claimed, ordered by name
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {array} OrganizationDomain "Domains"
+// @Failure 400 {object} ErrorResponse "Invalid organization ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/domains [get]
+func (h *OrganizationHandler) ListDomains(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	domains, err := h.organizations.ListDomains(r.Context(), id, actorID)
+	if err != nil {
+		h.handleOrganizationDomainError(w, r, err, "failed to list organization domains")
+		return
+	}
+
+	response := make([]OrganizationDomain, 0, len(domains))
+	for _, domain := range domains {
+		response = append(response, newOrganizationDomain(domain))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Add organization domain
+// @Description Claim an email domain for an organization. Publish the returned TXT record and verify the domain
+// @Description before the domain join policy applies to its users.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param request body AddOrganizationDomainRequest true "Domain"
+// @Success 201 {object} OrganizationDomain "Claimed domain"
+// @Failure 400 {object} ErrorResponse "Invalid domain"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization not found"
+// @Failure 409 {object} ErrorResponse "Domain already claimed"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/domains [post]
+func (h *OrganizationHandler) AddDomain(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req AddOrganizationDomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	domain, err := h.organizations.AddDomain(r.Context(), id, req.Domain, actorID)
+	if err != nil {
+		h.handleOrganizationDomainError(w, r, err, "failed to add organization domain")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, newOrganizationDomain(domain))
+}
+
+// @Summary Verify organization domain
+// @Description Check DNS for the verification TXT record of a domain claimed by an organization
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param domain path string true "Domain"
+// @Success 200 {object} OrganizationDomain "Verified domain"
+// @Failure 400 {object} ErrorResponse "Invalid domain"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization or domain not found"
+// @Failure 409 {object} ErrorResponse "Domain verified by another organization"
+// @Failure 422 {object} ErrorResponse "Verification record not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/domains/{domain}/verify [post]
+func (h *OrganizationHandler) VerifyDomain(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	domain, err := h.organizations.VerifyDomain(r.Context(), id, mux.Vars(r)["domain"], actorID)
+	if err != nil {
+		h.handleOrganizationDomainError(w, r, err, "failed to verify organization domain")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newOrganizationDomain(domain))
+}
+
+// @Summary Remove organization domain
+// @Description Remove a domain from an organization; the domain join policy no longer applies to its users
+// @Tags organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param domain path string true "Domain"
+// @Success 204 "Domain removed"
+// @Failure 400 {object} ErrorResponse "Invalid domain"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization or domain not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/domains/{domain} [delete]
+func (h *OrganizationHandler) RemoveDomain(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.organizations.RemoveDomain(r.Context(), id, mux.Vars(r)["domain"], actorID); err != nil {
+		h.handleOrganizationDomainError(w, r, err, "failed to remove organization domain")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary List organization invitations
+// @Description List the organizations the authenticated user is invited into, oldest invitation first
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} OrganizationInvitation "Invitations"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/invitations [get]
+func (h *OrganizationHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	invitations, err := h.organizations.ListInvitations(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to list invitations")
+		return
+	}
+
+	response := make([]OrganizationInvitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		response = append(response, newOrganizationInvitation(invitation))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Accept organization invitation
+// @Description Accept the authenticated user's invitation into an organization and become a member
+// @Tags organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 204 "Invitation accepted"
+// @Failure 400 {object} ErrorResponse "Invalid organization ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Invitation not found"
+// @Failure 409 {object} ErrorResponse "Already a member"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/invitation/accept [post]
+func (h *OrganizationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, userID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.organizations.AcceptInvitation(r.Context(), id, userID); err != nil {
+		h.handleInvitationError(w, r, err, "failed to accept invitation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Decline organization invitation
+// @Description Decline the authenticated user's invitation into an organization
+// @Tags organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 204 "Invitation declined"
+// @Failure 400 {object} ErrorResponse "Invalid organization ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Invitation not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/invitation [delete]
+func (h *OrganizationHandler) DeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, userID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.organizations.DeclineInvitation(r.Context(), id, userID); err != nil {
+		h.handleInvitationError(w, r, err, "failed to decline invitation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleOrganizationDomainError maps organization domain and policy errors
+// to responses
+func (h *OrganizationHandler) handleOrganizationDomainError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, domainerrors.ErrInvalidInput):
+		h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domainerrors.ErrUnauthorized):
+		h.handleError(w, r, err, http.StatusForbidden, "insufficient organization role")
+	case errors.Is(err, services.ErrNotFound):
+		h.handleError(w, r, err, http.StatusNotFound, "organization or domain not found")
+	case errors.Is(err, services.ErrConflict):
+		h.handleError(w, r, err, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrDomainUnverified):
+		h.handleError(w, r, err, http.StatusUnprocessableEntity, err.Error())
+	default:
+		h.handleError(w, r, err, http.StatusInternalServerError, message)
+	}
+}
+
+// handleInvitationError maps invitation errors to responses
+func (h *OrganizationHandler) handleInvitationError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		h.handleError(w, r, err, http.StatusNotFound, "invitation not found")
+	case errors.Is(err, services.ErrConflict):
+		h.handleError(w, r, err, http.StatusConflict, "user is already a member")
+	default:
+		h.handleError(w, r, err, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+	"go.uber.org/zap"
+)
+
+// OrganizationHandler handles requests of users managing their organizations
+type OrganizationHandler struct {
+	baseHandler
+	organizations services.OrganizationService
+	sso           services.SSOService
+}
+
+// NewOrganizationHandler creates a new organization handler. sso may be nil
+// when SSO connections are disabled.
+func NewOrganizationHandler(
+	organizations services.OrganizationService,
+	sso services.SSOService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *OrganizationHandler {
+	return &OrganizationHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		organizations: organizations,
+		sso:           sso,
+	}
+}
+
+// @Summary Create organization
+// @Description Create an organization owned by the authenticated user. It becomes their organization when they have none.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateOrganizationRequest true "Organization"
+// @Success 201 {object} Organization "Created organization"
+// @Failure 400 {object} ErrorResponse "Invalid name"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations [post]
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	organization, err := h.organizations.CreateOrganization(r.Context(), req.Name, userID)
+	if err != nil {
+		h.handleOrganizationError(w, r, err, "failed to create organization")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, newOrganization(organization))
+}
+
+// @Summary List organizations
+// @Description List the organizations the authenticated user is a member of, ordered by name
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} Organization "Organizations"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations [get]
+func (h *OrganizationHandler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	organizations, err := h.organizations.ListOrganizations(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to list organizations")
+		return
+	}
+
+	response := make([]Organization, 0, len(organizations))
+	for _, organization := range organizations {
+		response = append(response, newOrganization(organization))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Get organization
+// @Description Get an organization the authenticated user is a member of
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} Organization "Organization"
+// @Failure 400 {object} ErrorResponse "Invalid organization ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Organization not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id} [get]
+func (h *OrganizationHandler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	organization, err := h.organizations.GetOrganization(r.Context(), id, actorID)
+	if err != nil {
+		h.handleOrganizationError(w, r, err, "failed to get organization")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newOrganization(organization))
+}
+
+// @Summary List organization members
+// @Description List the members of an organization the authenticated user is a member of, oldest account first
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param offset query int false "Number of members to skip"
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Success 200 {array} OrganizationMember "Members"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Organization not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/members [get]
+func (h *OrganizationHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	var offset, limit int
+	pagination := []struct {
+		name   string
+		target *int
+	}{{"offset", &offset}, {"limit", &limit}}
+	for _, param := range pagination {
+		if value := params.Get(param.name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				h.handleError(w, r, err, http.StatusBadRequest, "invalid "+param.name)
+				return
+			}
+			*param.target = parsed
+		}
+	}
+
+	members, err := h.organizations.ListMembers(r.Context(), id, actorID, offset, limit)
+	if err != nil {
+		h.handleOrganizationError(w, r, err, "failed to list members")
+		return
+	}
+
+	response := make([]OrganizationMember, 0, len(members))
+	for _, member := range members {
+		response = append(response, newOrganizationMember(member))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Invite organization member
+// @Description Add the user with the given email to an organization. Owners and admins may invite members and
+// @Description admins; only owners may invite owners.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param request body InviteMemberRequest true "Invitation"
+// @Success 201 {object} OrganizationMember "Added member"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Not allowed to invite with this role"
+// @Failure 404 {object} ErrorResponse "Organization or user not found"
+// @Failure 409 {object} ErrorResponse "User is already a member"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/members [post]
+func (h *OrganizationHandler) InviteMember(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req InviteMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Email == "" {
+		h.handleError(w, r, nil, http.StatusBadRequest, "email is required")
+		return
+	}
+
+	member, err := h.organizations.InviteMember(r.Context(), id, req.Email, models.OrganizationRole(req.Role), actorID)
+	if err != nil {
+		h.handleOrganizationError(w, r, err, "failed to invite member")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, newOrganizationMember(member))
+}
+
+// @Summary Remove organization member
+// @Description Remove a user from an organization. Owners and admins may remove members and admins, only owners may
+// @Description remove owners, and every member may leave. An organization always keeps an owner.
+// @Tags organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Success 204 "Member removed"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Not allowed to remove this member"
+// @Failure 404 {object} ErrorResponse "Organization or member not found"
+// @Failure 409 {object} ErrorResponse "Last owner"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/members/{userId} [delete]
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(mux.Vars(r)["userId"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.organizations.RemoveMember(r.Context(), id, userID, actorID); err != nil {
+		h.handleOrganizationError(w, r, err, "failed to remove member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// organizationRequest returns the organization of the request path and the
+// authenticated user, or responds with an error
+func (h *OrganizationHandler) organizationRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid organization ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	actorID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, actorID, true
+}
+
+// handleOrganizationError maps organization service errors to responses.
+// Organizations the user is not a member of are reported as not found.
+func (h *OrganizationHandler) handleOrganizationError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, domainerrors.ErrInvalidInput):
+		h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domainerrors.ErrUnauthorized):
+		h.handleError(w, r, err, http.StatusForbidden, "insufficient organization role")
+	case errors.Is(err, domainerrors.ErrUserNotFound):
+		h.handleError(w, r, err, http.StatusNotFound, "user not found")
+	case errors.Is(err, services.ErrNotFound):
+		h.handleError(w, r, err, http.StatusNotFound, "organization or member not found")
+	case errors.Is(err, services.ErrConflict):
+		h.handleError(w, r, err, http.StatusConflict, "user is already a member")
+	case errors.Is(err, services.ErrOrganizationLastOwner):
+		h.handleError(w, r, err, http.StatusConflict, err.Error())
+	default:
+		h.handleError(w, r, err, http.StatusInternalServerError, message)
+	}
+}
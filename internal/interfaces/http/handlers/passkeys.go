@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+)
+
+// publicKeyCredentialType is the only credential type of WebAuthn
+const publicKeyCredentialType = "public-key"
+
+// newPasskeyCreationOptions maps a started registration to the options of
+// navigator.credentials.create. Passkeys must be discoverable and verify
+// the user.
+func newPasskeyCreationOptions(options services.PasskeyCreationOptions) PasskeyCreationOptions {
+	response := PasskeyCreationOptions{
+		Challenge: encodeBase64URL(options.Challenge),
+		RP: PasskeyRelyingParty{
+			ID:   options.RelyingParty.ID,
+			Name: options.RelyingParty.Name,
+		},
+		User: PasskeyUser{
+			ID:          encodeBase64URL(options.UserHandle),
+			Name:        options.UserName,
+			DisplayName: options.UserDisplayName,
+		},
+		Timeout:            options.Timeout.Milliseconds(),
+		ExcludeCredentials: newPasskeyCredentialDescriptors(options.ExcludeCredentials),
+		AuthenticatorSelection: PasskeyAuthenticatorSelection{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   "required",
+		},
+		Attestation: "none",
+	}
+	for _, alg := range services.PasskeyAlgorithms {
+		response.PubKeyCredParams = append(response.PubKeyCredParams, PasskeyCredentialParameter{
+			Type: publicKeyCredentialType,
+			Alg:  alg,
+		})
+	}
+	return response
+}
+
+// newPasskeyRequestOptions maps a started sign-in to the options of
+// navigator.credentials.get
+func newPasskeyRequestOptions(options services.PasskeyRequestOptions) PasskeyRequestOptions {
+	return PasskeyRequestOptions{
+		Challenge:        encodeBase64URL(options.Challenge),
+		RPID:             options.RPID,
+		Timeout:          options.Timeout.Milliseconds(),
+		AllowCredentials: newPasskeyCredentialDescriptors(options.AllowCredentials),
+		UserVerification: "required",
+	}
+}
+
+func newPasskeyCredentialDescriptors(ids [][]byte) []PasskeyCredentialDescriptor {
+	descriptors := make([]PasskeyCredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		descriptors = append(descriptors, PasskeyCredentialDescriptor{
+			Type: publicKeyCredentialType,
+			ID:   encodeBase64URL(id),
+		})
+	}
+	return descriptors
+}
+
+// toAttestation decodes the base64url fields of a registration response
+func (c PasskeyAttestationCredential) toAttestation() (services.PasskeyAttestation, error) {
+	var attestation services.PasskeyAttestation
+	var err error
+	if c.Type != publicKeyCredentialType {
+		return attestation, errors.New("credential type must be public-key")
+	}
+	if attestation.ClientDataJSON, err = decodeBase64URL(c.Response.ClientDataJSON); err != nil {
+		return attestation, err
+	}
+	if attestation.AttestationObject, err = decodeBase64URL(c.Response.AttestationObject); err != nil {
+		return attestation, err
+	}
+	return attestation, nil
+}
+
+// toAssertion decodes the base64url fields of a sign-in response
+func (c PasskeyAssertionCredential) toAssertion() (services.PasskeyAssertion, error) {
+	var assertion services.PasskeyAssertion
+	var err error
+	if c.Type != publicKeyCredentialType {
+		return assertion, errors.New("credential type must be public-key")
+	}
+	rawID := c.RawID
+	if rawID == "" {
+		rawID = c.ID
+	}
+	if assertion.CredentialID, err = decodeBase64URL(rawID); err != nil {
+		return assertion, err
+	}
+	if assertion.ClientDataJSON, err = decodeBase64URL(c.Response.ClientDataJSON); err != nil {
+		return assertion, err
+	}
+	if assertion.AuthenticatorData, err = decodeBase64URL(c.Response.AuthenticatorData); err != nil {
+		return assertion, err
+	}
+	if assertion.Signature, err = decodeBase64URL(c.Response.Signature); err != nil {
+		return assertion, err
+	}
+	if assertion.UserHandle, err = decodeBase64URL(c.Response.UserHandle); err != nil {
+		return assertion, err
+	}
+	return assertion, nil
+}
+
+func encodeBase64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeBase64URL decodes base64url with or without padding
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// handlePasskeyError responds to the errors shared by the passkey endpoints
+func (h *UserHandler) handlePasskeyError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrPasskeysNotEnabled):
+		h.handleError(w, r, err, http.StatusNotFound, "passkeys are not enabled")
+	case errors.Is(err, services.ErrPasskeyChallengeInvalid):
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid or expired passkey token")
+	case errors.Is(err, services.ErrPasskeyInvalid), errors.Is(err, domainerrors.ErrInvalidInput):
+		h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrConflict):
+		h.handleError(w, r, err, http.StatusConflict, "passkey is already registered")
+	case errors.Is(err, services.ErrLastLoginMethod):
+		h.handleErrorCode(w, r, err, http.StatusConflict, "last_login_method", "cannot remove the last sign-in method")
+	case errors.Is(err, services.ErrNotFound):
+		h.handleError(w, r, err, http.StatusNotFound, "passkey not found")
+	case errors.Is(err, services.ErrInvalidCredentials):
+		h.handleError(w, r, err, http.StatusUnauthorized, "invalid credentials")
+	case errors.Is(err, services.ErrAccountDisabled):
+		h.handleError(w, r, err, http.StatusForbidden, "account is disabled")
+	case errors.Is(err, services.ErrSessionLimitReached):
+		h.handleError(w, r, err, http.StatusConflict, "concurrent session limit reached")
+	case errors.Is(err, services.ErrAccessPolicyViolation):
+		h.handleAccessPolicyViolation(w, r, err)
+	default:
+		h.handleError(w, r, err, http.StatusInternalServerError, message)
+	}
+}
+
+// @Summary Start passkey registration
+// @Description Start registering a passkey for the authenticated user. Pass publicKey to
+// @Description navigator.credentials.create and post the created credential with the token to /users/me/passkeys.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} PasskeyRegistrationResponse "Creation options"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Passkeys not enabled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/passkeys/registration [post]
+func (h *UserHandler) BeginPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	registration, err := h.userService.BeginPasskeyRegistration(r.Context(), id)
+	if err != nil {
+		h.handlePasskeyError(w, r, err, "failed to start passkey registration")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, PasskeyRegistrationResponse{
+		Token:     registration.Token,
+		ExpiresAt: registration.ExpiresAt,
+		PublicKey: newPasskeyCreationOptions(registration.Options),
+	})
+}
+
+// @Summary Finish passkey registration
+// @Description Store the passkey an authenticator created for a started registration
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body FinishPasskeyRegistrationRequest true "Registration token and created credential"
+// @Success 201 {object} Passkey "Registered passkey"
+// @Failure 400 {object} ErrorResponse "Invalid request, token or authenticator response"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Passkeys not enabled"
+// @Failure 409 {object} ErrorResponse "Passkey already registered"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/passkeys [post]
+func (h *UserHandler) FinishPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req FinishPasskeyRegistrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	attestation, err := req.Credential.toAttestation()
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid credential")
+		return
+	}
+
+	credential, err := h.userService.FinishPasskeyRegistration(r.Context(), id, req.Token, req.Name, attestation)
+	if err != nil {
+		h.handlePasskeyError(w, r, err, "failed to register passkey")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, newPasskey(credential))
+}
+
+// @Summary List passkeys
+// @Description List the passkeys of the authenticated user
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} Passkey "Passkeys"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Passkeys not enabled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/passkeys [get]
+func (h *UserHandler) ListPasskeys(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	credentials, err := h.userService.ListPasskeys(r.Context(), id)
+	if err != nil {
+		h.handlePasskeyError(w, r, err, "failed to list passkeys")
+		return
+	}
+
+	response := make([]Passkey, 0, len(credentials))
+	for _, credential := range credentials {
+		response = append(response, newPasskey(credential))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Delete a passkey
+// @Description Remove a passkey of the authenticated user. The authenticator keeps it until the user deletes it there.
+// @Tags users
+// @Security BearerAuth
+// @Param passkeyId path string true "Passkey ID"
+// @Success 204 "Passkey deleted"
+// @Failure 400 {object} ErrorResponse "Invalid passkey ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Passkey not found or passkeys not enabled"
+// @Failure 409 {object} ErrorResponse "Last sign-in method"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/passkeys/{passkeyId} [delete]
+func (h *UserHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	passkeyID, err := uuid.Parse(mux.Vars(r)["passkeyId"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid passkey ID")
+		return
+	}
+
+	if err := h.userService.DeletePasskey(r.Context(), id, passkeyID); err != nil {
+		h.handlePasskeyError(w, r, err, "failed to delete passkey")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Start passkey sign-in
+// @Description Start signing in with a passkey instead of a password. Pass publicKey to navigator.credentials.get
+// @Description and post the returned credential with the token to /auth/passkey/finish.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} PasskeyLoginResponse "Request options"
+// @Failure 404 {object} ErrorResponse "Passkeys not enabled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/passkey/begin [post]
+func (h *UserHandler) BeginPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	login, err := h.userService.BeginPasskeyLogin(r.Context())
+	if err != nil {
+		h.handlePasskeyError(w, r, err, "failed to start passkey sign-in")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, PasskeyLoginResponse{
+		Token:     login.Token,
+		ExpiresAt: login.ExpiresAt,
+		PublicKey: newPasskeyRequestOptions(login.Options),
+	})
+}
+
+// @Summary Sign in with a passkey
+// @Description Exchange the token of a started passkey sign-in and the authenticator's response for tokens.
+// @Description Passkeys verify the user, so no second factor is asked for.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body FinishPasskeyLoginRequest true "Sign-in token and returned credential"
+// @Success 200 {object} LoginResponse "Login successful"
+// @Failure 400 {object} ErrorResponse "Invalid request or token"
+// @Failure 401 {object} ErrorResponse "Invalid credentials"
+// @Failure 403 {object} ErrorResponse "Account disabled or denied by access policy (code names the rule)"
+// @Failure 404 {object} ErrorResponse "Passkeys not enabled"
+// @Failure 409 {object} ErrorResponse "Concurrent session limit reached"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/passkey/finish [post]
+func (h *UserHandler) FinishPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req FinishPasskeyLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	assertion, err := req.Credential.toAssertion()
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid credential")
+		return
+	}
+
+	response, err := h.userService.FinishPasskeyLogin(r.Context(), req.Token, assertion)
+	if err != nil {
+		h.handlePasskeyError(w, r, err, "failed to login")
+		return
+	}
+
+	h.respondLogin(w, r, response)
+}
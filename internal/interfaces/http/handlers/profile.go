@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+)
+
+// UpdateProfileRequest represents the request body for updating the profile.
+// Omitted fields are left unchanged; empty strings clear them.
+type UpdateProfileRequest struct {
+	FirstName   *string `json:"firstName"`
+	LastName    *string `json:"lastName"`
+	PhoneNumber *string `json:"phoneNumber"` // E.164, e.g. +4930123456
+	Locale      *string `json:"locale"`      // BCP 47 language tag, e.g. de-DE
+	TimeZone    *string `json:"timeZone"`    // IANA time zone, e.g. Europe/Berlin
+	AvatarURL   *string `json:"avatarUrl"`   // absolute HTTPS URL; replaces the Gravatar
+}
+
+// input converts the request to the profile fields to change
+func (req UpdateProfileRequest) input() services.UpdateProfileInput {
+	return services.UpdateProfileInput{
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		PhoneNumber: req.PhoneNumber,
+		Locale:      req.Locale,
+		TimeZone:    req.TimeZone,
+		AvatarURL:   req.AvatarURL,
+	}
+}
+
+// replacement converts the request to a replacement of the whole profile,
+// clearing omitted fields
+func (req UpdateProfileRequest) replacement() services.UpdateProfileInput {
+	input := req.input()
+	for _, field := range []**string{&input.FirstName, &input.LastName, &input.PhoneNumber, &input.Locale, &input.TimeZone, &input.AvatarURL} {
+		if *field == nil {
+			*field = new(string)
+		}
+	}
+	return input
+}
+
+// @Summary Update profile
+// @Description Fill in or change profile fields of the authenticated user after registration.
+// @Description Omitted fields are left unchanged.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UpdateProfileRequest true "Profile fields to change"
+// @Success 200 {object} User "Updated user"
+// @Failure 400 {object} ErrorResponse "Invalid request, phone number, locale, time zone or avatar URL"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me [patch]
+// @Router /users/me/profile [patch]
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	h.changeProfile(w, r, UpdateProfileRequest.input)
+}
+
+// @Summary Replace profile
+// @Description Replace the profile fields of the authenticated user. Omitted fields are cleared.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UpdateProfileRequest true "Profile"
+// @Success 200 {object} User "Updated user"
+// @Failure 400 {object} ErrorResponse "Invalid request, phone number, locale, time zone or avatar URL"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me [put]
+func (h *UserHandler) ReplaceProfile(w http.ResponseWriter, r *http.Request) {
+	h.changeProfile(w, r, UpdateProfileRequest.replacement)
+}
+
+// changeProfile applies the profile fields of the request body to the
+// authenticated user
+func (h *UserHandler) changeProfile(w http.ResponseWriter, r *http.Request, toInput func(UpdateProfileRequest) services.UpdateProfileInput) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(r.Context(), id, toInput(req))
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrInvalidInput) {
+			h.handleError(w, r, err, http.StatusBadRequest, "phone number must be in E.164 format, locale a BCP 47 language tag, time zone an IANA time zone and avatar URL an HTTPS URL")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to update profile")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, h.userResponse(user))
+}
+
+// @Summary Get profile completeness
+// @Description Report how much of the authenticated user's profile is filled in, which fields are missing
+// @Description and which of them the deployment requires the user to provide
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} ProfileCompleteness "Profile completeness"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/profile/completeness [get]
+func (h *UserHandler) GetProfileCompleteness(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	completeness, err := h.userService.GetProfileCompleteness(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get profile completeness")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, ProfileCompleteness{
+		Percent:  completeness.Percent,
+		Fields:   completeness.Fields,
+		Missing:  completeness.Missing,
+		Required: completeness.Required,
+	})
+}
+
+// UpdateConsentRequest represents the request body for giving or withdrawing
+// consent
+type UpdateConsentRequest struct {
+	Marketing *bool `json:"marketing"`
+}
+
+// @Summary Get consent
+// @Description Get whether the authenticated user consents to marketing
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Consent "Consent"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Router /users/me/consent [get]
+func (h *UserHandler) GetConsent(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, err := h.userService.GetUser(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusNotFound, "user not found")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, Consent{Marketing: !user.MarketingOptOut})
+}
+
+// @Summary Update consent
+// @Description Give or withdraw the authenticated user's consent to marketing. Without it,
+// @Description marketing-relevant events about the user are not published.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UpdateConsentRequest true "Consent"
+// @Success 200 {object} Consent "Updated consent"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/consent [put]
+func (h *UserHandler) UpdateConsent(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Marketing == nil {
+		h.handleError(w, r, domainerrors.ErrInvalidInput, http.StatusBadRequest, "marketing is required")
+		return
+	}
+
+	user, err := h.userService.SetMarketingConsent(r.Context(), id, *req.Marketing)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to update consent")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, Consent{Marketing: !user.MarketingOptOut})
+}
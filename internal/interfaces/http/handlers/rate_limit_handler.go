@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+	"go.uber.org/zap"
+)
+
+// RateLimitHandler handles HTTP requests for looking up rate limit quotas
+type RateLimitHandler struct {
+	baseHandler
+	limits *middleware.RateLimits
+}
+
+// NewRateLimitHandler creates a new rate limit handler
+func NewRateLimitHandler(
+	limits *middleware.RateLimits,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *RateLimitHandler {
+	return &RateLimitHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		limits: limits,
+	}
+}
+
+// RateLimitsResponse lists the quota the caller has left with each rate limit
+type RateLimitsResponse struct {
+	// ClientIP is the address the limits are counted for; limits apply per
+	// client IP and instance, not per user
+	ClientIP string                       `json:"clientIp"`
+	Limits   []middleware.RateLimitStatus `json:"limits"`
+}
+
+// @Summary Get rate limits
+// @Description Get the caller's remaining quota with every rate limit of this instance, so that
+// @Description clients can throttle themselves. Looking up the quotas does not count against them.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} RateLimitsResponse "Rate limit quotas"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Router /users/me/rate-limits [get]
+func (h *RateLimitHandler) GetRateLimits(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	h.respondJSON(w, http.StatusOK, RateLimitsResponse{
+		ClientIP: middleware.ClientIP(r),
+		Limits:   h.limits.Status(r),
+	})
+}
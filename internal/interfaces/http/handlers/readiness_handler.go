@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/lifecycle"
+	"go.uber.org/zap"
+)
+
+// ReadinessResponse represents the readiness probe response
+type ReadinessResponse struct {
+	Ready        bool                   `json:"ready"`
+	Phases       []lifecycle.Phase      `json:"phases"`
+	Dependencies []lifecycle.Dependency `json:"dependencies"`
+}
+
+// LivenessResponse represents the liveness probe response
+type LivenessResponse struct {
+	Status        string `json:"status"`
+	UptimeSeconds int64  `json:"uptimeSeconds"`
+}
+
+// ReadinessHandler reports startup progress and the reachability of
+// dependencies for orchestration readiness probes
+type ReadinessHandler struct {
+	tracker *lifecycle.Tracker
+	health  *lifecycle.Health
+	logger  *zap.Logger
+}
+
+// NewReadinessHandler creates a new readiness handler
+func NewReadinessHandler(tracker *lifecycle.Tracker, health *lifecycle.Health, logger *zap.Logger) *ReadinessHandler {
+	return &ReadinessHandler{
+		tracker: tracker,
+		health:  health,
+		logger:  logger,
+	}
+}
+
+// @Summary Readiness probe
+// @Description Report whether every startup phase has completed and every dependency (Postgres, Redis, Kafka)
+// @Description is reachable, listing the status of each phase and dependency. Errors are logged rather than returned.
+// @Description Dependency checks are cached for the configured interval.
+// @Tags health
+// @Produce json
+// @Success 200 {object} ReadinessResponse "Service is ready"
+// @Failure 503 {object} ReadinessResponse "Service is still starting or a dependency is down"
+// @Router /readyz [get]
+func (h *ReadinessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	response := ReadinessResponse{
+		Phases:       h.tracker.Phases(),
+		Dependencies: h.health.Check(r.Context()),
+	}
+	response.Ready = h.tracker.Ready() && lifecycle.Healthy(response.Dependencies)
+
+	status := http.StatusOK
+	if !response.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeProbeResponse(w, status, response, h.logger)
+}
+
+// LivenessHandler reports that the process is serving requests for
+// orchestration liveness probes. Dependencies are left to readiness, since
+// restarting the service does not bring them back.
+type LivenessHandler struct {
+	startedAt time.Time
+	logger    *zap.Logger
+}
+
+// NewLivenessHandler creates a new liveness handler
+func NewLivenessHandler(logger *zap.Logger) *LivenessHandler {
+	return &LivenessHandler{
+		startedAt: time.Now(),
+		logger:    logger,
+	}
+}
+
+// @Summary Liveness probe
+// @Description Report that the process is alive. Unlike /readyz it does not check dependencies.
+// @Tags health
+// @Produce json
+// @Success 200 {object} LivenessResponse "Service is alive"
+// @Router /healthz [get]
+func (h *LivenessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeProbeResponse(w, http.StatusOK, LivenessResponse{
+		Status:        "ok",
+		UptimeSeconds: int64(time.Since(h.startedAt).Seconds()),
+	}, h.logger)
+}
+
+// writeProbeResponse writes a probe response that caches must not reuse
+func writeProbeResponse(w http.ResponseWriter, status int, response interface{}, logger *zap.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("failed to encode response", zap.Error(err))
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+)
+
+// @Summary Set the recovery email
+// @Description Send a verification link to the address the authenticated user wants as their recovery email.
+// @Description The address replaces the current recovery email once the link is opened.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body RecoveryEmailRequest true "Recovery email address"
+// @Success 202 {object} MessageResponse "Verification link sent"
+// @Failure 400 {object} ErrorResponse "Invalid email address"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 429 {object} ErrorResponse "Verification link sent too recently"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/recovery-email [put]
+func (h *UserHandler) SetRecoveryEmail(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusAccepted, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req RecoveryEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Email == "" {
+		h.handleError(w, r, nil, http.StatusBadRequest, "email is required")
+		return
+	}
+
+	if err := h.userService.RequestRecoveryEmail(r.Context(), id, req.Email); err != nil {
+		switch {
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrVerificationLimitReached):
+			h.handleError(w, r, err, http.StatusTooManyRequests, "a verification link was sent recently, try again in a minute")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to set recovery email")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, MessageResponse{
+		Message: "a verification link has been sent to the recovery email",
+	})
+}
+
+// @Summary Remove the recovery email
+// @Description Remove the recovery email of the authenticated user
+// @Tags users
+// @Security BearerAuth
+// @Success 204 "Recovery email removed"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "No recovery email set"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/recovery-email [delete]
+func (h *UserHandler) RemoveRecoveryEmail(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.userService.RemoveRecoveryEmail(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "no recovery email set")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to remove recovery email")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Verify a recovery email
+// @Description Redeem the token of an emailed recovery email verification link. Each link works once.
+// @Tags auth
+// @Produce json
+// @Param token query string true "Recovery email verification token"
+// @Success 200 {object} MessageResponse "Recovery email verified"
+// @Failure 400 {object} ErrorResponse "Invalid, expired or used token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/recovery-email/verify [get]
+func (h *UserHandler) VerifyRecoveryEmail(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	if err := h.userService.VerifyRecoveryEmail(r.Context(), r.URL.Query().Get("token")); err != nil {
+		if errors.Is(err, services.ErrInvalidToken) {
+			h.handleError(w, r, err, http.StatusBadRequest, "invalid or expired verification link")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to verify recovery email")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, MessageResponse{
+		Message: "recovery email verified",
+	})
+}
+
+// @Summary Request password reset through a recovery email
+// @Description Send a password reset link to a verified recovery email, for users who cannot reach their
+// @Description primary address. The response is the same whether or not the address is registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RecoveryEmailRequest true "Recovery email address"
+// @Success 200 {object} MessageResponse "Reset link sent if the address is a verified recovery email"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/forgot-password/recovery-email [post]
+func (h *UserHandler) RequestRecoveryPasswordReset(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req RecoveryEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Email == "" {
+		h.handleError(w, r, nil, http.StatusBadRequest, "email is required")
+		return
+	}
+
+	if err := h.userService.RequestRecoveryPasswordReset(r.Context(), req.Email); err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to request password reset")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, MessageResponse{
+		Message: "if the recovery email is verified, a password reset link has been sent",
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// VerifyEmailRedirectConfig configures the optional redirect performed by
+// GET /auth/verify-email once the token has been processed
+type VerifyEmailRedirectConfig struct {
+	SuccessURL   string
+	FailureURL   string
+	AllowedHosts []string
+}
+
+// redirectTarget resolves the redirect URL for the given query parameter,
+// falling back to the configured default. It returns false when a
+// client-supplied URL is not on the allowlist.
+func (c VerifyEmailRedirectConfig) redirectTarget(r *http.Request, param, fallback string) (string, bool) {
+	requested := r.URL.Query().Get(param)
+	if requested == "" {
+		return fallback, true
+	}
+	if !c.isAllowed(requested) {
+		return "", false
+	}
+	return requested, true
+}
+
+// isAllowed reports whether rawURL is an absolute http(s) URL whose host is
+// allowlisted or matches one of the configured redirect URLs
+func (c VerifyEmailRedirectConfig) isAllowed(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+
+	allowed := append([]string{}, c.AllowedHosts...)
+	for _, configured := range []string{c.SuccessURL, c.FailureURL} {
+		if cu, err := url.Parse(configured); err == nil && cu.Host != "" {
+			allowed = append(allowed, cu.Host)
+		}
+	}
+
+	for _, host := range allowed {
+		if strings.EqualFold(strings.TrimSpace(host), u.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// redirectWithResult redirects to target with the given result params
+// appended to its query string
+func redirectWithResult(w http.ResponseWriter, r *http.Request, target string, result url.Values) {
+	u, err := url.Parse(target)
+	if err != nil {
+		http.Error(w, "invalid redirect URL", http.StatusInternalServerError)
+		return
+	}
+
+	query := u.Query()
+	for key, values := range result {
+		for _, value := range values {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
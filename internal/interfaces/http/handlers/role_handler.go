@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+	"go.uber.org/zap"
+)
+
+// RoleHandler handles requests managing roles, their permissions and the
+// roles of users
+type RoleHandler struct {
+	baseHandler
+	roles services.RoleService
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(
+	roles services.RoleService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *RoleHandler {
+	return &RoleHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		roles: roles,
+	}
+}
+
+// @Summary List permissions
+// @Description List every permission roles can grant
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} string "Permissions"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /admin/permissions [get]
+func (h *RoleHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	permissions := models.AllPermissions()
+	response := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		response = append(response, string(permission))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary List roles
+// @Description List the roles and the permissions they grant, ordered by name
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} Role "Roles"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/roles [get]
+func (h *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	roles, err := h.roles.ListRoles(r.Context())
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to list roles")
+		return
+	}
+
+	response := make([]Role, 0, len(roles))
+	for _, role := range roles {
+		response = append(response, newRole(role))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Create role
+// @Description Create a role granting the given permissions
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body Role true "Role"
+// @Success 201 {object} Role "Created role"
+// @Failure 400 {object} ErrorResponse "Invalid role"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 409 {object} ErrorResponse "Role already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/roles [post]
+func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	var req Role
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	role := req.toModel()
+	if err := h.roles.CreateRole(r.Context(), role); err != nil {
+		h.handleRoleError(w, r, err, "failed to create role")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, newRole(role))
+}
+
+// @Summary Get role
+// @Description Get a role and the permissions it grants
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Success 200 {object} Role "Role"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Role not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/roles/{name} [get]
+func (h *RoleHandler) GetRole(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	role, err := h.roles.GetRole(r.Context(), models.Role(mux.Vars(r)["name"]))
+	if err != nil {
+		h.handleRoleError(w, r, err, "failed to get role")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newRole(role))
+}
+
+// @Summary Delete role
+// @Description Delete a role no user holds. The built-in admin and user roles cannot be deleted.
+// @Tags admin
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Success 204 "Role deleted"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Role not found"
+// @Failure 409 {object} ErrorResponse "Role is built in or assigned to users"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/roles/{name} [delete]
+func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	if err := h.roles.DeleteRole(r.Context(), models.Role(mux.Vars(r)["name"])); err != nil {
+		h.handleRoleError(w, r, err, "failed to delete role")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Set role permissions
+// @Description Replace the permissions a role grants. Access tokens issued before keep the old permissions until
+// @Description they expire; other instances apply the change within a minute. The admin role always keeps
+// @Description roles:read and roles:write.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Param request body RolePermissionsRequest true "Permissions"
+// @Success 200 {object} Role "Updated role"
+// @Failure 400 {object} ErrorResponse "Unknown permission"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Role not found"
+// @Failure 409 {object} ErrorResponse "Change would take role management from the admin role"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/roles/{name}/permissions [put]
+func (h *RoleHandler) SetRolePermissions(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req RolePermissionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	role, err := h.roles.SetPermissions(r.Context(), models.Role(mux.Vars(r)["name"]), toPermissions(req.Permissions))
+	if err != nil {
+		h.handleRoleError(w, r, err, "failed to set role permissions")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newRole(role))
+}
+
+// @Summary Grant permission
+// @Description Add a permission to a role
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Param permission path string true "Permission"
+// @Success 200 {object} Role "Updated role"
+// @Failure 400 {object} ErrorResponse "Unknown permission"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Role not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/roles/{name}/permissions/{permission} [put]
+func (h *RoleHandler) GrantPermission(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	vars := mux.Vars(r)
+	role, err := h.roles.GrantPermission(r.Context(), models.Role(vars["name"]), models.Permission(vars["permission"]))
+	if err != nil {
+		h.handleRoleError(w, r, err, "failed to grant permission")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newRole(role))
+}
+
+// @Summary Revoke permission
+// @Description Remove a permission from a role
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Param permission path string true "Permission"
+// @Success 200 {object} Role "Updated role"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Role not found"
+// @Failure 409 {object} ErrorResponse "Change would take role management from the admin role"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/roles/{name}/permissions/{permission} [delete]
+func (h *RoleHandler) RevokePermission(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	vars := mux.Vars(r)
+	role, err := h.roles.RevokePermission(r.Context(), models.Role(vars["name"]), models.Permission(vars["permission"]))
+	if err != nil {
+		h.handleRoleError(w, r, err, "failed to revoke permission")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newRole(role))
+}
+
+// @Summary Assign user role
+// @Description Give a user another role. The user's sessions are revoked so that no token keeps the old
+// @Description permissions. Admins cannot change their own role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body AssignRoleRequest true "Role"
+// @Success 200 {object} User "Updated user"
+// @Failure 400 {object} ErrorResponse "Invalid user ID or unknown role"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/role [put]
+func (h *RoleHandler) AssignUserRole(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+	adminID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req AssignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.roles.AssignRole(r.Context(), userID, models.Role(req.Role), adminID)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrUserNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "user not found")
+			return
+		}
+		h.handleRoleError(w, r, err, "failed to assign role")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newUserResponse(user))
+}
+
+// handleRoleError maps role service errors to responses
+func (h *RoleHandler) handleRoleError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, domainerrors.ErrInvalidInput):
+		h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotFound):
+		h.handleError(w, r, err, http.StatusNotFound, "role not found")
+	case errors.Is(err, services.ErrConflict):
+		h.handleError(w, r, err, http.StatusConflict, "role already exists")
+	case errors.Is(err, services.ErrRoleBuiltIn), errors.Is(err, services.ErrRoleInUse):
+		h.handleError(w, r, err, http.StatusConflict, err.Error())
+	default:
+		h.handleError(w, r, err, http.StatusInternalServerError, message)
+	}
+}
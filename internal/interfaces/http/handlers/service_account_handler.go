@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+	"go.uber.org/zap"
+)
+
+// ServiceAccountHandler handles admin requests managing service accounts
+// and their API keys
+type ServiceAccountHandler struct {
+	baseHandler
+	apiKeys services.APIKeyService
+}
+
+// NewServiceAccountHandler creates a new service account handler
+func NewServiceAccountHandler(
+	apiKeys services.APIKeyService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		apiKeys: apiKeys,
+	}
+}
+
+// @Summary Create a service account
+// @Description Create an active machine user without a password. Service accounts cannot sign in and
+// @Description authenticate requests with API keys instead.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateServiceAccountRequest true "Service account"
+// @Success 201 {object} User "Service account"
+// @Failure 400 {object} ErrorResponse "Invalid service account"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 409 {object} ErrorResponse "Email or username already taken"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/service-accounts [post]
+func (h *ServiceAccountHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	adminID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.apiKeys.CreateServiceAccount(r.Context(), services.CreateServiceAccountInput{
+		Username:  req.Username,
+		Email:     req.Email,
+		Role:      models.Role(req.Role),
+		CreatedBy: adminID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrUserAlreadyExists):
+			h.handleError(w, r, err, http.StatusConflict, "email already registered")
+		case errors.Is(err, services.ErrUsernameAlreadyExists):
+			h.handleError(w, r, err, http.StatusConflict, "username already taken")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to create service account")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, newUserResponse(user))
+}
+
+// @Summary List API keys
+// @Description List the API keys of a service account, including revoked and expired ones
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service account ID"
+// @Success 200 {array} APIKey "API keys"
+// @Failure 400 {object} ErrorResponse "Invalid ID or not a service account"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/service-accounts/{id}/api-keys [get]
+func (h *ServiceAccountHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	keys, err := h.apiKeys.ListKeys(r.Context(), userID)
+	if err != nil {
+		h.handleServiceAccountError(w, r, err, "failed to list API keys")
+		return
+	}
+
+	response := make([]APIKey, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, newAPIKey(key))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Create an API key
+// @Description Create an API key for a service account. Requests send it in the X-API-Key header on the
+// @Description routes that accept API keys. The key is only returned in this response. Keys with notBefore set are
+// @Description provisioned ahead of time and refused until then.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service account ID"
+// @Param request body CreateAPIKeyRequest true "API key"
+// @Success 201 {object} CreatedAPIKey "Created API key"
+// @Failure 400 {object} ErrorResponse "Invalid API key or not a service account"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/service-accounts/{id}/api-keys [post]
+func (h *ServiceAccountHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	adminID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	input := services.CreateAPIKeyInput{
+		UserID:    userID,
+		Name:      req.Name,
+		ExpiresIn: time.Duration(req.ExpiresInDays) * 24 * time.Hour,
+		CreatedBy: adminID,
+	}
+	if req.NotBefore != nil {
+		input.NotBefore = *req.NotBefore
+	}
+	created, err := h.apiKeys.CreateKey(r.Context(), input)
+	if err != nil {
+		h.handleServiceAccountError(w, r, err, "failed to create API key")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, CreatedAPIKey{
+		APIKey: newAPIKey(created.APIKey),
+		Key:    created.Key,
+	})
+}
+
+// @Summary Revoke an API key
+// @Description Revoke an API key of a service account; requests with it are refused from then on
+// @Tags admin
+// @Security BearerAuth
+// @Param id path string true "Service account ID"
+// @Param keyId path string true "API key ID"
+// @Success 204 "API key revoked"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "API key not found or already revoked"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/service-accounts/{id}/api-keys/{keyId} [delete]
+func (h *ServiceAccountHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	adminID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+	keyID, err := uuid.Parse(mux.Vars(r)["keyId"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid API key ID")
+		return
+	}
+
+	if err := h.apiKeys.RevokeKey(r.Context(), userID, keyID, adminID); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "API key not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to revoke API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleServiceAccountError responds to errors of managing the API keys of
+// a service account
+func (h *ServiceAccountHandler) handleServiceAccountError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, domainerrors.ErrInvalidInput):
+		h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotServiceAccount):
+		h.handleError(w, r, err, http.StatusBadRequest, "user is not a service account")
+	case errors.Is(err, domainerrors.ErrUserNotFound):
+		h.handleError(w, r, err, http.StatusNotFound, "user not found")
+	default:
+		h.handleError(w, r, err, http.StatusInternalServerError, message)
+	}
+}
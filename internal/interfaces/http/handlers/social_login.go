@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// federationStateCookie binds a started social login to the browser, so a
+// callback can only complete a login started by the same browser
+const federationStateCookie = "federation_state"
+
+// federationStateCookiePath limits the state cookie to the social login routes
+const federationStateCookiePath = "/api/v1/auth/oauth/"
+
+// federationStateMaxAge is how long the browser keeps the state cookie
+const federationStateMaxAge = 10 * time.Minute
+
+// SocialLoginConfig configures where browsers land after signing in with an
+// external identity provider. Without redirect URLs the callback responds
+// with JSON.
+type SocialLoginConfig struct {
+	// SuccessURL receives the browser after a successful login; the tokens
+	// are issued as cookies, so it requires cookie mode
+	SuccessURL string
+	// FailureURL receives the browser after a failed login with an error
+	// code in the error query parameter
+	FailureURL string
+}
+
+// @Summary Sign in with an external identity provider
+// @Description Redirect the browser to the identity provider to sign in
+// @Tags auth
+// @Param provider path string true "Provider name, e.g. google"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} ErrorResponse "Unknown provider"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/oauth/{provider}/login [get]
+func (h *UserHandler) SocialLogin(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusFound, time.Since(start).Seconds())
+	}()
+
+	login, err := h.federation.BeginLogin(r.Context(), mux.Vars(r)["provider"])
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownIdentityProvider) {
+			h.handleError(w, r, err, http.StatusNotFound, "unknown identity provider")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to start login")
+		return
+	}
+
+	http.SetCookie(w, h.federationStateCookie(federationStateCookiePath, login.State, federationStateMaxAge))
+	http.Redirect(w, r, login.AuthorizationURL, http.StatusFound)
+}
+
+// @Summary Complete a sign-in with an external identity provider
+// @Description Exchange the authorization code for the user's identity and sign them in.
+// @Description The identity is linked to an existing user with the same verified email,
+// @Description otherwise a new user is created. A login started with /users/me/identities/{provider}
+// @Description links the identity to the user who started it instead.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name, e.g. google"
+// @Param state query string true "State of the login"
+// @Param code query string true "Authorization code"
+// @Success 200 {object} LoginResponse "Login successful, or UserIdentity when linking"
+// @Success 202 {object} MFAChallengeResponse "Second factor required"
+// @Success 302 "Redirect to the success or failure URL"
+// @Failure 400 {object} ErrorResponse "Invalid or expired login state"
+// @Failure 403 {object} ErrorResponse "Account disabled"
+// @Failure 404 {object} ErrorResponse "Unknown provider"
+// @Failure 409 {object} ErrorResponse "Email not verified or identity linked to another account"
+// @Failure 502 {object} ErrorResponse "Identity provider error"
+// @Router /auth/oauth/{provider}/callback [get]
+func (h *UserHandler) SocialLoginCallback(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	// The state is single-use, so the cookie is cleared whatever the outcome
+	http.SetCookie(w, h.federationStateCookie(federationStateCookiePath, "", 0))
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		h.socialLoginFailed(w, r, nil, http.StatusBadRequest, "access_denied", "sign-in was cancelled or denied")
+		return
+	}
+	state := query.Get("state")
+	cookie, err := r.Cookie(federationStateCookie)
+	if err != nil || state == "" || cookie.Value != state {
+		h.socialLoginFailed(w, r, err, http.StatusBadRequest, "invalid_state", "invalid or expired login state")
+		return
+	}
+
+	identity, err := h.federation.CompleteLogin(r.Context(), mux.Vars(r)["provider"], state, query.Get("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownIdentityProvider):
+			h.socialLoginFailed(w, r, err, http.StatusNotFound, "unknown_provider", "unknown identity provider")
+		case errors.Is(err, services.ErrFederationStateInvalid):
+			h.socialLoginFailed(w, r, err, http.StatusBadRequest, "invalid_state", "invalid or expired login state")
+		default:
+			h.socialLoginFailed(w, r, err, http.StatusBadGateway, "provider_error", "failed to sign in with identity provider")
+		}
+		return
+	}
+
+	if identity.LinkingUserID != nil {
+		h.completeIdentityLink(w, r, identity)
+		return
+	}
+	h.completeExternalLogin(w, r, identity)
+}
+
+// completeExternalLogin signs in the user of an external identity and
+// responds as configured for social logins
+func (h *UserHandler) completeExternalLogin(w http.ResponseWriter, r *http.Request, identity *services.ExternalIdentity) {
+	response, err := h.userService.LoginWithExternalIdentity(r.Context(), identity)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrExternalEmailUnverified):
+			h.socialLoginFailed(w, r, err, http.StatusConflict, "email_unverified", "identity provider did not verify the email")
+		case errors.Is(err, services.ErrAccountLinkRequiresVerification):
+			h.socialLoginFailed(w, r, err, http.StatusConflict, "link_requires_verification", "verify the email of the existing account before signing in with this provider")
+		case errors.Is(err, services.ErrAccountDisabled):
+			h.socialLoginFailed(w, r, err, http.StatusForbidden, "account_disabled", "account is disabled")
+		case errors.Is(err, services.ErrSessionLimitReached):
+			h.socialLoginFailed(w, r, err, http.StatusConflict, "session_limit_reached", "concurrent session limit reached")
+		case errors.Is(err, services.ErrAccessPolicyViolation):
+			h.socialLoginFailed(w, r, err, http.StatusForbidden, accessPolicyCode(err), "login not permitted by access policy")
+		case errors.Is(err, services.ErrLoginBlocked):
+			h.socialLoginFailed(w, r, err, http.StatusForbidden, "login_blocked", "login is blocked until the account's risk subsides")
+		case errors.Is(err, services.ErrLoginConfirmationRequired):
+			h.socialLoginFailed(w, r, err, http.StatusForbidden, "login_confirmation_required", "confirm the login with the link sent by email, then sign in again")
+		default:
+			h.socialLoginFailed(w, r, err, http.StatusInternalServerError, "server_error", "failed to login")
+		}
+		return
+	}
+
+	if h.socialLogin.SuccessURL == "" || !h.cookies.Enabled {
+		h.respondLogin(w, r, response)
+		return
+	}
+
+	// The web app continues a login needing a second factor with the token
+	if challenge := response.MFAChallenge; challenge != nil {
+		redirectWithResult(w, r, h.socialLogin.SuccessURL, url.Values{
+			"status":     {"mfa_required"},
+			"mfa_token":  {challenge.Token},
+			"mfa_action": {string(challenge.Action)},
+		})
+		return
+	}
+
+	h.cookies.setTokenCookies(w,
+		response.AccessToken, response.AccessTokenExpiresAt,
+		response.RefreshToken, response.RefreshTokenExpiresAt)
+	redirectWithResult(w, r, h.socialLogin.SuccessURL, url.Values{"status": {"success"}})
+}
+
+// socialLoginFailed redirects to the failure URL with code when one is
+// configured and responds with an error otherwise
+func (h *UserHandler) socialLoginFailed(w http.ResponseWriter, r *http.Request, err error, status int, code, message string) {
+	if h.socialLogin.FailureURL == "" {
+		h.handleErrorCode(w, r, err, status, code, message)
+		return
+	}
+	if err != nil {
+		h.logger.Info("social login failed", zap.String("error", code), zap.Error(err))
+	}
+	redirectWithResult(w, r, h.socialLogin.FailureURL, url.Values{"status": {"error"}, "error": {code}})
+}
+
+// federationStateCookie builds the state cookie for the login routes under
+// path; an empty value clears it. SameSite=Lax still sends it on the
+// provider's top-level redirect back.
+func (h *UserHandler) federationStateCookie(path, value string, maxAge time.Duration) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     federationStateCookie,
+		Value:    value,
+		Path:     path,
+		Domain:   h.cookies.Domain,
+		Secure:   h.cookies.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+	} else {
+		cookie.MaxAge = int(maxAge.Seconds())
+	}
+	return cookie
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// @Summary List SSO connections
+// @Description List the SSO connections of an organization. Only owners and admins may manage connections.
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {array} SSOConnection "SSO connections"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/sso-connections [get]
+func (h *OrganizationHandler) ListSSOConnections(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	connections, err := h.sso.ListConnections(r.Context(), id, actorID)
+	if err != nil {
+		h.handleSSOError(w, r, err, "failed to list SSO connections")
+		return
+	}
+
+	response := make([]SSOConnection, 0, len(connections))
+	for _, connection := range connections {
+		response = append(response, newSSOConnection(connection))
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// @Summary Create SSO connection
+// @Description Connect an organization to its OpenID Connect identity provider. The endpoints are discovered from
+// @Description the issuer. Register the returned redirect URL with the provider.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param request body SSOConnectionRequest true "Connection settings"
+// @Success 201 {object} SSOConnection "Created connection"
+// @Failure 400 {object} ErrorResponse "Invalid settings or issuer"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/sso-connections [post]
+func (h *OrganizationHandler) CreateSSOConnection(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req SSOConnectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	connection, err := h.sso.CreateConnection(r.Context(), id, req.toInput(), actorID)
+	if err != nil {
+		h.handleSSOError(w, r, err, "failed to create SSO connection")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, newSSOConnection(connection))
+}
+
+// @Summary Get SSO connection
+// @Description Get an SSO connection of an organization with its domains
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param connectionId path string true "SSO connection ID"
+// @Success 200 {object} SSOConnection "SSO connection"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization or connection not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/sso-connections/{connectionId} [get]
+func (h *OrganizationHandler) GetSSOConnection(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, connectionID, actorID, ok := h.ssoConnectionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	connection, err := h.sso.GetConnection(r.Context(), id, connectionID, actorID)
+	if err != nil {
+		h.handleSSOError(w, r, err, "failed to get SSO connection")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newSSOConnection(connection))
+}
+
+// @Summary Update SSO connection
+// @Description Replace the settings of an SSO connection. Leave out the client secret to keep the current one.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param connectionId path string true "SSO connection ID"
+// @Param request body SSOConnectionRequest true "Connection settings"
+// @Success 200 {object} SSOConnection "Updated connection"
+// @Failure 400 {object} ErrorResponse "Invalid settings or issuer"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization or connection not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/sso-connections/{connectionId} [put]
+func (h *OrganizationHandler) UpdateSSOConnection(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, connectionID, actorID, ok := h.ssoConnectionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req SSOConnectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	connection, err := h.sso.UpdateConnection(r.Context(), id, connectionID, req.toInput(), actorID)
+	if err != nil {
+		h.handleSSOError(w, r, err, "failed to update SSO connection")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newSSOConnection(connection))
+}
+
+// @Summary Delete SSO connection
+// @Description Delete an SSO connection with its domains
+// @Tags organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param connectionId path string true "SSO connection ID"
+// @Success 204 "Connection deleted"
+// @Failure 400 {object} ErrorResponse "Invalid ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization or connection not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/sso-connections/{connectionId} [delete]
+func (h *OrganizationHandler) DeleteSSOConnection(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, connectionID, actorID, ok := h.ssoConnectionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.sso.DeleteConnection(r.Context(), id, connectionID, actorID); err != nil {
+		h.handleSSOError(w, r, err, "failed to delete SSO connection")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Add SSO domain
+// @Description Claim an email domain for an SSO connection. Publish the returned TXT record and verify the domain
+// @Description before its users are sent to the connection.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param connectionId path string true "SSO connection ID"
+// @Param request body AddSSODomainRequest true "Domain"
+// @Success 201 {object} SSODomain "Claimed domain"
+// @Failure 400 {object} ErrorResponse "Invalid domain"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization or connection not found"
+// @Failure 409 {object} ErrorResponse "Domain already claimed"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/sso-connections/{connectionId}/domains [post]
+func (h *OrganizationHandler) AddSSODomain(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	id, connectionID, actorID, ok := h.ssoConnectionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req AddSSODomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	domain, err := h.sso.AddDomain(r.Context(), id, connectionID, req.Domain, actorID)
+	if err != nil {
+		h.handleSSOError(w, r, err, "failed to add SSO domain")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, newSSODomain(domain))
+}
+
+// @Summary Verify SSO domain
+// @Description Check DNS for the verification TXT record of a domain claimed by an SSO connection
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param connectionId path string true "SSO connection ID"
+// @Param domain path string true "Domain"
+// @Success 200 {object} SSODomain "Verified domain"
+// @Failure 400 {object} ErrorResponse "Invalid domain"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization, connection or domain not found"
+// @Failure 409 {object} ErrorResponse "Domain verified by another connection"
+// @Failure 422 {object} ErrorResponse "Verification record not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/sso-connections/{connectionId}/domains/{domain}/verify [post]
+func (h *OrganizationHandler) VerifySSODomain(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, connectionID, actorID, ok := h.ssoConnectionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	domain, err := h.sso.VerifyDomain(r.Context(), id, connectionID, mux.Vars(r)["domain"], actorID)
+	if err != nil {
+		h.handleSSOError(w, r, err, "failed to verify SSO domain")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newSSODomain(domain))
+}
+
+// @Summary Remove SSO domain
+// @Description Remove a domain from an SSO connection; its users are no longer sent to the connection
+// @Tags organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param connectionId path string true "SSO connection ID"
+// @Param domain path string true "Domain"
+// @Success 204 "Domain removed"
+// @Failure 400 {object} ErrorResponse "Invalid domain"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Insufficient organization role"
+// @Failure 404 {object} ErrorResponse "Organization, connection or domain not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /organizations/{id}/sso-connections/{connectionId}/domains/{domain} [delete]
+func (h *OrganizationHandler) RemoveSSODomain(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, connectionID, actorID, ok := h.ssoConnectionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.sso.RemoveDomain(r.Context(), id, connectionID, mux.Vars(r)["domain"], actorID); err != nil {
+		h.handleSSOError(w, r, err, "failed to remove SSO domain")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ssoConnectionRequest returns the organization and SSO connection of the
+// request path and the authenticated user, or responds with an error
+func (h *OrganizationHandler) ssoConnectionRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	id, actorID, ok := h.organizationRequest(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	connectionID, err := uuid.Parse(mux.Vars(r)["connectionId"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid connection ID")
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	return id, connectionID, actorID, true
+}
+
+// handleSSOError maps SSO service errors to responses. Organizations the
+// user is not a member of are reported as not found.
+func (h *OrganizationHandler) handleSSOError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, domainerrors.ErrInvalidInput):
+		h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domainerrors.ErrUnauthorized):
+		h.handleError(w, r, err, http.StatusForbidden, "insufficient organization role")
+	case errors.Is(err, services.ErrNotFound):
+		h.handleError(w, r, err, http.StatusNotFound, "organization, connection or domain not found")
+	case errors.Is(err, services.ErrConflict):
+		h.handleError(w, r, err, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrDomainUnverified):
+		h.handleError(w, r, err, http.StatusUnprocessableEntity, err.Error())
+	default:
+		h.handleError(w, r, err, http.StatusInternalServerError, message)
+	}
+}
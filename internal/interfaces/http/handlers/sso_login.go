@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+)
+
+// ssoPath prefixes the SSO login routes, to which the state cookie is limited
+const ssoPath = "/api/v1/auth/sso/"
+
+// SSODiscoveryRequest represents the request body for finding the SSO
+// connection of an email
+type SSODiscoveryRequest struct {
+	Email string `json:"email"`
+}
+
+// SSODiscoveryResponse represents the SSO connection users with an email
+// sign in with
+type SSODiscoveryResponse struct {
+	ConnectionID string `json:"connectionId"`
+	Name         string `json:"name"`
+	// LoginURL starts the login; browsers are sent there
+	LoginURL string `json:"loginUrl"`
+}
+
+// @Summary Discover SSO connection
+// @Description Find the SSO connection of the organization that verified the domain of an email, so that users
+// @Description can sign in with their company's identity provider
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body SSODiscoveryRequest true "Email"
+// @Success 200 {object} SSODiscoveryResponse "SSO connection"
+// @Failure 400 {object} ErrorResponse "Invalid email"
+// @Failure 404 {object} ErrorResponse "No SSO connection for the domain"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/sso/discover [post]
+func (h *UserHandler) DiscoverSSO(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req SSODiscoveryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	connection, err := h.sso.Discover(r.Context(), req.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainerrors.ErrInvalidInput):
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrSSOConnectionNotFound):
+			h.handleError(w, r, err, http.StatusNotFound, "no SSO connection for this email")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to discover SSO connection")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, SSODiscoveryResponse{
+		ConnectionID: connection.ID.String(),
+		Name:         connection.Name,
+		LoginURL:     ssoPath + connection.ID.String() + "/login",
+	})
+}
+
+// @Summary Sign in with an SSO connection
+// @Description Redirect the browser to the identity provider of an organization's SSO connection
+// @Tags auth
+// @Param connection path string true "SSO connection ID"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} ErrorResponse "Unknown or disabled connection"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/sso/{connection}/login [get]
+func (h *UserHandler) SSOLogin(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusFound, time.Since(start).Seconds())
+	}()
+
+	connectionID, err := uuid.Parse(mux.Vars(r)["connection"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusNotFound, "unknown SSO connection")
+		return
+	}
+
+	login, err := h.sso.BeginLogin(r.Context(), connectionID)
+	if err != nil {
+		if errors.Is(err, services.ErrSSOConnectionNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "unknown SSO connection")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to start login")
+		return
+	}
+
+	http.SetCookie(w, h.federationStateCookie(ssoPath, login.State, federationStateMaxAge))
+	http.Redirect(w, r, login.AuthorizationURL, http.StatusFound)
+}
+
+// @Summary Complete a sign-in with an SSO connection
+// @Description Exchange the authorization code for the user's identity and sign them in. The user's email must be
+// @Description in a verified domain of the connection. The user becomes a member of the connection's organization.
+// @Tags auth
+// @Produce json
+// @Param connection path string true "SSO connection ID"
+// @Param state query string true "State of the login"
+// @Param code query string true "Authorization code"
+// @Success 200 {object} LoginResponse "Login successful"
+// @Success 202 {object} MFAChallengeResponse "Second factor required"
+// @Success 302 "Redirect to the success or failure URL"
+// @Failure 400 {object} ErrorResponse "Invalid or expired login state"
+// @Failure 403 {object} ErrorResponse "Email domain not verified, or account disabled"
+// @Failure 404 {object} ErrorResponse "Unknown or disabled connection"
+// @Failure 502 {object} ErrorResponse "Identity provider error"
+// @Router /auth/sso/{connection}/callback [get]
+func (h *UserHandler) SSOLoginCallback(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	// The state is single-use, so the cookie is cleared whatever the outcome
+	http.SetCookie(w, h.federationStateCookie(ssoPath, "", 0))
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		h.socialLoginFailed(w, r, nil, http.StatusBadRequest, "access_denied", "sign-in was cancelled or denied")
+		return
+	}
+	state := query.Get("state")
+	cookie, err := r.Cookie(federationStateCookie)
+	if err != nil || state == "" || cookie.Value != state {
+		h.socialLoginFailed(w, r, err, http.StatusBadRequest, "invalid_state", "invalid or expired login state")
+		return
+	}
+	connectionID, err := uuid.Parse(mux.Vars(r)["connection"])
+	if err != nil {
+		h.socialLoginFailed(w, r, err, http.StatusNotFound, "unknown_connection", "unknown SSO connection")
+		return
+	}
+
+	identity, err := h.sso.CompleteLogin(r.Context(), connectionID, state, query.Get("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSSOConnectionNotFound):
+			h.socialLoginFailed(w, r, err, http.StatusNotFound, "unknown_connection", "unknown SSO connection")
+		case errors.Is(err, services.ErrFederationStateInvalid):
+			h.socialLoginFailed(w, r, err, http.StatusBadRequest, "invalid_state", "invalid or expired login state")
+		case errors.Is(err, services.ErrSSODomainMismatch):
+			h.socialLoginFailed(w, r, err, http.StatusForbidden, "domain_not_verified", "email domain is not verified for this SSO connection")
+		default:
+			h.socialLoginFailed(w, r, err, http.StatusBadGateway, "provider_error", "failed to sign in with identity provider")
+		}
+		return
+	}
+
+	h.completeExternalLogin(w, r, identity)
+}
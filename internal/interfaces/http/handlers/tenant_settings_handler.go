@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// TenantSettingsHandler handles requests managing per-organization configuration overrides
+type TenantSettingsHandler struct {
+	baseHandler
+	tenantSettings services.TenantSettingsService
+}
+
+// NewTenantSettingsHandler creates a new tenant settings handler
+func NewTenantSettingsHandler(
+	tenantSettings services.TenantSettingsService,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *TenantSettingsHandler {
+	return &TenantSettingsHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		tenantSettings: tenantSettings,
+	}
+}
+
+// @Summary Get organization settings
+// @Description Get the configuration overrides of an organization
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} OrganizationSettings "Organization settings"
+// @Failure 400 {object} ErrorResponse "Invalid organization ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Organization has no overrides"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/organizations/{id}/settings [get]
+func (h *TenantSettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+
+	settings, err := h.tenantSettings.GetOverrides(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "organization has no settings")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get organization settings")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newOrganizationSettings(settings))
+}
+
+// @Summary Replace organization settings
+// @Description Replace the configuration overrides of an organization. Omitted settings use the service-wide configuration;
+// @Description token lifetimes may only shorten the configured ones. Other instances apply the change within the settings cache TTL.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param request body OrganizationSettings true "Organization settings"
+// @Success 200 {object} OrganizationSettings "Saved organization settings"
+// @Failure 400 {object} ErrorResponse "Invalid settings"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/organizations/{id}/settings [put]
+func (h *TenantSettingsHandler) SaveSettings(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+
+	var req OrganizationSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	settings := req.toModel(id)
+	if err := h.tenantSettings.SaveOverrides(r.Context(), settings); err != nil {
+		if errors.Is(err, domainerrors.ErrInvalidInput) {
+			h.handleError(w, r, err, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to save organization settings")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, newOrganizationSettings(settings))
+}
+
+// @Summary Delete organization settings
+// @Description Remove the configuration overrides of an organization, which then uses the service-wide configuration
+// @Tags admin
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 204 "Settings deleted"
+// @Failure 400 {object} ErrorResponse "Invalid organization ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Organization has no overrides"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/organizations/{id}/settings [delete]
+func (h *TenantSettingsHandler) DeleteSettings(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+
+	if err := h.tenantSettings.DeleteOverrides(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "organization has no settings")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to delete organization settings")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,19 +2,33 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
 	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
 	"go.uber.org/zap"
 )
 
 // UserHandler handles HTTP requests for user operations
 type UserHandler struct {
-	userService    services.UserService
-	metricsService services.MetricsService
-	logger         *zap.Logger
+	baseHandler
+	userService         services.UserService
+	verifyEmailRedirect VerifyEmailRedirectConfig
+	cookies             CookieConfig
+	avatars             AvatarConfig
+	publicProfileMaxAge time.Duration
+	federation          services.FederationService
+	socialLogin         SocialLoginConfig
+	sso                 services.SSOService
 }
 
 // NewUserHandler creates a new user handler
@@ -22,12 +36,19 @@ func NewUserHandler(
 	userService services.UserService,
 	metricsService services.MetricsService,
 	logger *zap.Logger,
+	opts ...UserHandlerOption,
 ) *UserHandler {
-	return &UserHandler{
-		userService:    userService,
-		metricsService: metricsService,
-		logger:         logger,
+	h := &UserHandler{
+		baseHandler: baseHandler{
+			metricsService: metricsService,
+			logger:         logger,
+		},
+		userService: userService,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // RegisterRequest represents the request body for user registration
@@ -43,6 +64,9 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	EmailOrUsername string `json:"emailOrUsername"`
 	Password        string `json:"password"`
+	// CaptchaToken is the CAPTCHA response, required after a login failed
+	// with captcha_required
+	CaptchaToken string `json:"captchaToken,omitempty"`
 }
 
 // RequestPasswordResetRequest represents the request body for password reset request
@@ -50,6 +74,11 @@ type RequestPasswordResetRequest struct {
 	Email string `json:"email"`
 }
 
+// ResendVerificationRequest represents the request body for resending a verification email
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
 // ResetPasswordRequest represents the request body for password reset
 type ResetPasswordRequest struct {
 	Token       string `json:"token"`
@@ -61,6 +90,11 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refreshToken"`
 }
 
+// LogoutRequest represents the optional request body for logout
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
 // ChangePasswordRequest represents the request body for changing password
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"currentPassword"`
@@ -75,12 +109,13 @@ type ChangePasswordRequest struct {
 // @Param request body RegisterRequest true "User registration details"
 // @Success 201 {object} User "User created successfully"
 // @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 409 {object} ErrorResponse "Email or username already in use"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /auth/register [post]
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	defer func() {
-		h.metricsService.RecordRequest(r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
 	}()
 
 	var req RegisterRequest
@@ -98,7 +133,14 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.handleError(w, r, err, http.StatusInternalServerError, "failed to register user")
+		switch {
+		case errors.Is(err, services.ErrUserAlreadyExists), errors.Is(err, services.ErrEmailAlreadyExists):
+			h.handleError(w, r, err, http.StatusConflict, "email already in use")
+		case errors.Is(err, services.ErrUsernameAlreadyExists):
+			h.handleError(w, r, err, http.StatusConflict, "username already in use")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to register user")
+		}
 		return
 	}
 
@@ -111,15 +153,18 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "Login credentials"
-// @Success 200 {object} TokenPair "Login successful"
-// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Success 200 {object} LoginResponse "Login successful"
+// @Success 202 {object} MFAChallengeResponse "Second factor required"
+// @Failure 400 {object} ErrorResponse "Invalid request, or no device ID for a role whose tokens are bound to a device (device_id_required)"
 // @Failure 401 {object} ErrorResponse "Invalid credentials"
+// @Failure 403 {object} ErrorResponse "Account disabled, password reset required, email not verified (email_verification_required, or email_not_verified when all users must verify first), login from a new device awaiting confirmation by email, denied by access policy (code names the rule), CAPTCHA required or invalid (captcha_required, captcha_invalid), or blocked until the account's risk subsides (login_blocked)"
+// @Failure 409 {object} ErrorResponse "Concurrent session limit reached"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /auth/login [post]
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	defer func() {
-		h.metricsService.RecordRequest(r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
 	}()
 
 	var req LoginRequest
@@ -128,14 +173,99 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.userService.AuthenticateUser(r.Context(), req.EmailOrUsername, req.Password)
-
+	response, err := h.userService.Login(r.Context(), services.LoginUserInput{
+		Identifier:   req.EmailOrUsername,
+		Password:     req.Password,
+		CaptchaToken: req.CaptchaToken,
+	})
 	if err != nil {
-		h.handleError(w, r, err, http.StatusUnauthorized, "invalid credentials")
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			h.handleError(w, r, err, http.StatusUnauthorized, "invalid credentials")
+			return
+		}
+		if errors.Is(err, services.ErrAccountDeactivated) {
+			h.handleError(w, r, err, http.StatusForbidden, "account is deactivated and can be restored")
+			return
+		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			h.handleError(w, r, err, http.StatusForbidden, "account is disabled")
+			return
+		}
+		if errors.Is(err, services.ErrPasswordResetRequired) {
+			h.handleError(w, r, err, http.StatusForbidden, "password reset required")
+			return
+		}
+		if errors.Is(err, services.ErrEmailVerificationRequired) {
+			h.handleError(w, r, err, http.StatusForbidden, "verify the email address before signing in")
+			return
+		}
+		if errors.Is(err, services.ErrEmailNotVerified) {
+			h.handleError(w, r, err, http.StatusForbidden, emailNotVerifiedMessage)
+			return
+		}
+		if errors.Is(err, services.ErrLoginConfirmationRequired) {
+			h.handleError(w, r, err, http.StatusForbidden, "confirm the login with the link sent by email, then sign in again")
+			return
+		}
+		if errors.Is(err, services.ErrCaptchaRequired) {
+			h.handleError(w, r, err, http.StatusForbidden, "solve the CAPTCHA and sign in again")
+			return
+		}
+		if errors.Is(err, services.ErrCaptchaInvalid) {
+			h.handleError(w, r, err, http.StatusForbidden, "invalid CAPTCHA response")
+			return
+		}
+		if errors.Is(err, services.ErrLoginBlocked) {
+			h.handleError(w, r, err, http.StatusForbidden, "login is blocked until the account's risk subsides")
+			return
+		}
+		if errors.Is(err, services.ErrSessionLimitReached) {
+			h.handleError(w, r, err, http.StatusConflict, "concurrent session limit reached")
+			return
+		}
+		if errors.Is(err, services.ErrAccessPolicyViolation) {
+			h.handleAccessPolicyViolation(w, r, err)
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to login")
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, response)
+	h.respondLogin(w, r, response)
+}
+
+// respondLogin responds to a login with an MFA challenge when it needs a
+// second factor, and with the issued tokens otherwise
+func (h *UserHandler) respondLogin(w http.ResponseWriter, r *http.Request, response *services.LoginResponse) {
+	if challenge := response.MFAChallenge; challenge != nil {
+		h.respondJSON(w, http.StatusAccepted, newMFAChallengeResponse(challenge))
+		return
+	}
+
+	h.cookies.setTokenCookies(w,
+		response.AccessToken, response.AccessTokenExpiresAt,
+		response.RefreshToken, response.RefreshTokenExpiresAt)
+
+	h.respondJSON(w, http.StatusOK, h.loginResponse(response))
+}
+
+// loginResponse maps the tokens of a login to their API representation
+func (h *UserHandler) loginResponse(response *services.LoginResponse) LoginResponse {
+	login := LoginResponse{
+		AccessToken:           response.AccessToken,
+		RefreshToken:          response.RefreshToken,
+		TokenType:             response.TokenType,
+		ExpiresIn:             secondsUntil(response.AccessTokenExpiresAt),
+		RefreshExpiresIn:      secondsUntil(response.RefreshTokenExpiresAt),
+		ExpiresAt:             response.AccessTokenExpiresAt,
+		User:                  h.userResponse(response.User),
+		MFAEnrollmentDeadline: response.MFAEnrollmentDeadline,
+	}
+	if deadline := response.MFAEnrollmentDeadline; deadline != nil {
+		login.Notice = fmt.Sprintf("Multi-factor authentication is required from %s. Set up an authenticator app before then to keep signing in.",
+			deadline.UTC().Format(time.RFC1123))
+	}
+	return login
 }
 
 // @Summary Request password reset
@@ -144,15 +274,13 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param request body RequestPasswordResetRequest true "Email address"
-// @Success 200 {object} MessageResponse "Password reset email sent"
+// @Success 200 {object} MessageResponse "Password reset email sent if the account exists"
 // @Failure 400 {object} ErrorResponse "Invalid request"
-// @Failure 404 {object} ErrorResponse "User not found"
-// @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /auth/forgot-password [post]
 func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	defer func() {
-		h.metricsService.RecordRequest(r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
 	}()
 
 	var req RequestPasswordResetRequest
@@ -186,7 +314,7 @@ func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Reques
 func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	defer func() {
-		h.metricsService.RecordRequest(r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
 	}()
 
 	var req ResetPasswordRequest
@@ -212,29 +340,112 @@ func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param request body RefreshTokenRequest true "Refresh token"
 // @Success 200 {object} TokenResponse "Token refresh successful"
-// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 400 {object} ErrorResponse "Invalid request, or no device ID for a device-bound token (device_id_required)"
 // @Failure 401 {object} ErrorResponse "Invalid token"
+// @Failure 403 {object} ErrorResponse "Account disabled"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /auth/refresh [post]
 func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	defer func() {
-		h.metricsService.RecordRequest(r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
 	}()
 
+	// In cookie mode the refresh token may come from its cookie instead of the body
 	var req RefreshTokenRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !(errors.Is(err, io.EOF) && h.cookies.Enabled) {
 		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	if req.RefreshToken == "" {
+		req.RefreshToken = h.cookies.refreshTokenCookie(r)
+	}
 
 	tokens, err := h.userService.RefreshToken(r.Context(), req.RefreshToken)
 	if err != nil {
+		if errors.Is(err, services.ErrDeviceMismatch) {
+			h.handleError(w, r, err, http.StatusUnauthorized, "refresh token is bound to a different device")
+			return
+		}
+		if errors.Is(err, services.ErrDeviceIDRequired) {
+			h.handleError(w, r, err, http.StatusBadRequest, "send the device ID in the X-Device-ID header")
+			return
+		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			h.handleError(w, r, err, http.StatusForbidden, "account is disabled")
+			return
+		}
 		h.handleError(w, r, err, http.StatusUnauthorized, "invalid refresh token")
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, tokens)
+	h.cookies.setTokenCookies(w,
+		tokens.AccessToken, tokens.AccessTokenExpiresAt,
+		tokens.RefreshToken, tokens.RefreshTokenExpiresAt)
+
+	h.respondJSON(w, http.StatusOK, TokenResponse{
+		AccessToken:      tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		TokenType:        tokens.TokenType,
+		ExpiresIn:        secondsUntil(tokens.AccessTokenExpiresAt),
+		RefreshExpiresIn: secondsUntil(tokens.RefreshTokenExpiresAt),
+		ExpiresAt:        tokens.AccessTokenExpiresAt,
+	})
+}
+
+// @Summary Logout
+// @Description Revoke the access token from the Authorization header or access token cookie,
+// @Description the refresh token from the body or refresh token cookie, and their session.
+// @Description In cookie mode the token cookies are cleared.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LogoutRequest false "Refresh token to revoke"
+// @Success 200 {object} MessageResponse "Logged out successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Invalid or missing token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/logout [post]
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	var req LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.RefreshToken == "" {
+		req.RefreshToken = h.cookies.refreshTokenCookie(r)
+	}
+
+	accessToken, err := middleware.AccessToken(r)
+	if err != nil && !errors.Is(err, middleware.ErrMissingToken) {
+		h.handleError(w, r, err, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	// Cookies are cleared whatever the outcome so clients never keep stale tokens
+	h.cookies.clearTokenCookies(w)
+
+	err = h.userService.Logout(r.Context(), services.LogoutInput{
+		AccessToken:  accessToken,
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidToken) {
+			h.handleError(w, r, err, http.StatusUnauthorized, "invalid or missing token")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to logout")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, MessageResponse{
+		Message: "logged out successfully",
+	})
 }
 
 // @Summary Get user profile
@@ -250,13 +461,12 @@ func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	defer func() {
-		h.metricsService.RecordRequest(r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
 	}()
 
-	userID := r.Context().Value("user_id").(string)
-	id, err := uuid.Parse(userID)
-	if err != nil {
-		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+	id, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -266,42 +476,172 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, user)
+	h.respondJSON(w, http.StatusOK, h.userResponse(user))
+}
+
+// @Summary Get public profile
+// @Description Get the public profile of any user. Only the username, avatar and creation time are
+// @Description returned; users that cannot sign in are reported as not found. Rate limited per client IP.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} PublicProfile "Public profile"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 429 {object} ErrorResponse "Too many requests"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/{id}/public [get]
+func (h *UserHandler) GetPublicProfile(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+	}()
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	profile, err := h.userService.GetPublicProfile(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrUserNotFound) || services.IsNotFoundError(err) {
+			h.handleError(w, r, err, http.StatusNotFound, "user not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to get public profile")
+		return
+	}
+
+	if h.publicProfileMaxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.publicProfileMaxAge.Seconds())))
+	}
+	avatarURL := profile.AvatarURL
+	if avatarURL == "" {
+		avatarURL = h.avatars.gravatarURL(profile.EmailHash)
+	}
+	h.respondJSON(w, http.StatusOK, PublicProfile{
+		ID:        profile.ID.String(),
+		Username:  profile.Username,
+		AvatarURL: avatarURL,
+		CreatedAt: profile.CreatedAt,
+	})
+}
+
+// userResponse maps a domain user to its API representation, falling back
+// to the configured avatar when the user has not set one
+func (h *UserHandler) userResponse(user *models.User) User {
+	response := newUserResponse(user)
+	if response.AvatarURL == "" {
+		response.AvatarURL = h.avatars.avatarURL(user.Email)
+	}
+	return response
 }
 
 // @Summary Verify email address
-// @Description Verify user's email address using verification token
+// @Description Verify user's email address using verification token. When a success or failure
+// @Description URL is configured or supplied (allowlisted hosts only), responds with a 302 redirect
+// @Description carrying the result as status and error query parameters.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param token query string true "Verification token"
+// @Param success_url query string false "URL to redirect to on success"
+// @Param failure_url query string false "URL to redirect to on failure"
 // @Success 200 {object} MessageResponse "Email verified successfully"
-// @Failure 400 {object} ErrorResponse "Invalid token"
+// @Success 302 "Redirect to the success or failure URL"
+// @Failure 400 {object} ErrorResponse "Invalid token or redirect URL"
 // @Failure 500 {object} ErrorResponse "Internal server error"
-// @Router /users/verify-email [get]
+// @Router /auth/verify-email [get]
 func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	defer func() {
-		h.metricsService.RecordRequest(r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
 	}()
 
+	successURL, ok := h.verifyEmailRedirect.redirectTarget(r, "success_url", h.verifyEmailRedirect.SuccessURL)
+	if !ok {
+		h.handleError(w, r, nil, http.StatusBadRequest, "Redirect URL is not allowed")
+		return
+	}
+	failureURL, ok := h.verifyEmailRedirect.redirectTarget(r, "failure_url", h.verifyEmailRedirect.FailureURL)
+	if !ok {
+		h.handleError(w, r, nil, http.StatusBadRequest, "Redirect URL is not allowed")
+		return
+	}
+
 	token := r.URL.Query().Get("token")
 	if token == "" {
+		if failureURL != "" {
+			redirectWithResult(w, r, failureURL, url.Values{"status": {"error"}, "error": {"missing_token"}})
+			return
+		}
 		h.handleError(w, r, nil, http.StatusBadRequest, "Verification token is required")
 		return
 	}
 
 	err := h.userService.VerifyEmail(r.Context(), token)
 	if err != nil {
+		if failureURL != "" {
+			h.logger.Info("email verification failed", zap.Error(err))
+			redirectWithResult(w, r, failureURL, url.Values{"status": {"error"}, "error": {"invalid_token"}})
+			return
+		}
 		h.handleError(w, r, err, http.StatusBadRequest, "Invalid verification token")
 		return
 	}
 
+	if successURL != "" {
+		redirectWithResult(w, r, successURL, url.Values{"status": {"success"}})
+		return
+	}
+
 	h.respondJSON(w, http.StatusOK, map[string]string{
 		"message": "Email verified successfully",
 	})
 }
 
+// @Summary Resend verification email
+// @Description Send a new verification email to an unverified address. Earlier links stop working.
+// @Description The number of verification emails per user is capped per day; requests over the cap are accepted
+// @Description but send nothing, so the response does not reveal whether an address is registered. Each client IP
+// @Description may request a limited number of resends per hour.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResendVerificationRequest true "Email address"
+// @Success 202 {object} MessageResponse "Verification email queued"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 429 {object} ErrorResponse "Hourly resend limit reached"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/verify-email/resend [post]
+func (h *UserHandler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusAccepted, time.Since(start).Seconds())
+	}()
+
+	var req ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Email == "" {
+		h.handleError(w, r, nil, http.StatusBadRequest, "email is required")
+		return
+	}
+
+	if err := h.userService.ResendVerificationEmail(r.Context(), req.Email); err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to resend verification email")
+		return
+	}
+
+	// Respond the same way whether or not the address is registered
+	h.respondJSON(w, http.StatusAccepted, map[string]string{
+		"message": "if the email is registered and unverified, a verification link has been sent",
+	})
+}
+
 // @Summary Change user password
 // @Description Change the password of the authenticated user
 // @Tags users
@@ -317,7 +657,7 @@ func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	defer func() {
-		h.metricsService.RecordRequest(r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusOK, time.Since(start).Seconds())
 	}()
 
 	var req ChangePasswordRequest
@@ -336,30 +676,3 @@ func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		"message": "password has been changed successfully",
 	})
 }
-
-func (h *UserHandler) handleError(w http.ResponseWriter, r *http.Request, err error, status int, message string) {
-	h.logger.Error(message,
-		zap.Error(err),
-		zap.String("path", r.URL.Path),
-		zap.String("method", r.Method),
-	)
-
-	h.metricsService.IncrementCounter("http_errors", map[string]string{
-		"path":    r.URL.Path,
-		"method":  r.Method,
-		"message": message,
-	})
-	h.respondJSON(w, status, map[string]string{"error": message})
-}
-
-func (h *UserHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if data != nil {
-		if err := json.NewEncoder(w).Encode(data); err != nil {
-			h.logger.Error("failed to encode response",
-				zap.Error(err),
-			)
-		}
-	}
-}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domainerrors "github.com/mibrahim2344/identity-service/internal/domain/errors"
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+)
+
+// PurgeUserRequest represents the request body for permanently deleting a user
+type PurgeUserRequest struct {
+	ApprovalToken string `json:"approvalToken"`
+}
+
+// targetUser reads the user an admin acts on and the acting admin of a request
+func (h *AdminHandler) targetUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	adminID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		h.handleError(w, r, errors.New("missing user ID"), http.StatusUnauthorized, "unauthorized")
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid user ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, adminID, true
+}
+
+// @Summary Approve a user purge
+// @Description Approve permanently deleting a user, including a soft-deleted one. The returned token lets a
+// @Description second admin carry out the purge until it expires; the approving admin cannot use it.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 201 {object} PurgeApproval "Approval token"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/purge-approvals [post]
+func (h *AdminHandler) ApproveUserPurge(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusCreated, time.Since(start).Seconds())
+	}()
+
+	id, adminID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	approval, err := h.userService.ApproveUserPurge(r.Context(), id, adminID)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrUserNotFound) {
+			h.handleError(w, r, err, http.StatusNotFound, "user not found")
+			return
+		}
+		h.handleError(w, r, err, http.StatusInternalServerError, "failed to approve purge")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, PurgeApproval{
+		ApprovalToken: approval.Token,
+		UserID:        approval.UserID.String(),
+		ApproverID:    approval.ApproverID.String(),
+		ExpiresAt:     approval.ExpiresAt,
+	})
+}
+
+// @Summary Purge a user
+// @Description Permanently delete a user and every record referring to them with a purge approval issued by
+// @Description another admin. This cannot be undone; the audit log keeps the approval and the purge.
+// @Tags admin
+// @Accept json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body PurgeUserRequest true "Approval token of a second admin"
+// @Success 204 "User purged"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 403 {object} ErrorResponse "Forbidden, invalid, expired or self-issued approval"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/purge [post]
+func (h *AdminHandler) PurgeUser(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		h.metricsService.RecordRequest(r.Context(), r.Method, r.URL.Path, http.StatusNoContent, time.Since(start).Seconds())
+	}()
+
+	id, adminID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req PurgeUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.userService.PurgeUser(r.Context(), id, adminID, req.ApprovalToken); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPurgeApprovalSelf):
+			h.handleError(w, r, err, http.StatusForbidden, "purge must be approved by a second admin")
+		case errors.Is(err, services.ErrPurgeApprovalInvalid):
+			h.handleError(w, r, err, http.StatusForbidden, "invalid or expired purge approval")
+		case errors.Is(err, domainerrors.ErrUserNotFound):
+			h.handleError(w, r, err, http.StatusNotFound, "user not found")
+		default:
+			h.handleError(w, r, err, http.StatusInternalServerError, "failed to purge user")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,62 +2,300 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/google/uuid"
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
+	"github.com/mibrahim2344/identity-service/internal/domain/repositories"
 	"github.com/mibrahim2344/identity-service/internal/domain/services"
 	"go.uber.org/zap"
 )
 
+// APIKeyHeader carries the API key of a service account
+const APIKeyHeader = "X-API-Key"
+
 // AuthMiddleware handles authentication for protected routes
 type AuthMiddleware struct {
 	tokenService   services.TokenService
 	metricsService services.MetricsService
 	logger         *zap.Logger
+
+	apiKeys        services.APIKeyService
+	apiKeyPrefixes []string
+
+	roles services.RoleService // nil checks permissions against the built-in defaults
+}
+
+// AuthOption configures optional AuthMiddleware behaviour
+type AuthOption func(*AuthMiddleware)
+
+// WithAPIKeys lets Authenticate accept API keys in the X-API-Key header
+// instead of bearer tokens on routes under the given path prefixes
+func WithAPIKeys(apiKeys services.APIKeyService, pathPrefixes []string) AuthOption {
+	return func(m *AuthMiddleware) {
+		m.apiKeys = apiKeys
+		m.apiKeyPrefixes = pathPrefixes
+	}
+}
+
+// WithRoles lets RequirePermission resolve the permissions of requests whose
+// credentials carry none, such as API keys, from the roles table
+func WithRoles(roles services.RoleService) AuthOption {
+	return func(m *AuthMiddleware) {
+		m.roles = roles
+	}
 }
 
 // NewAuthMiddleware creates a new auth middleware
-func NewAuthMiddleware(tokenService services.TokenService, metricsService services.MetricsService, logger *zap.Logger) *AuthMiddleware {
-	return &AuthMiddleware{
+func NewAuthMiddleware(tokenService services.TokenService, metricsService services.MetricsService, logger *zap.Logger, opts ...AuthOption) *AuthMiddleware {
+	m := &AuthMiddleware{
 		tokenService:   tokenService,
 		metricsService: metricsService,
 		logger:         logger,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // Custom type for context keys
 type contextKey string
 
 const (
-	userIDKey contextKey = "user_id"
+	userIDKey      contextKey = "user_id"
+	roleKey        contextKey = "role"
+	scopeKey       contextKey = "scope"
+	permissionsKey contextKey = "permissions"
+	tenantKey      contextKey = "tenant_id"
 )
 
-// Authenticate verifies the JWT token and adds user information to the context
+// Authenticate verifies the JWT token and adds user information to the context.
+// Only first-party tokens are accepted: a token issued to an OAuth client
+// must not act as its user on the service's own API.
 func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
+	return m.authenticate(next, false)
+}
+
+// AuthenticateOAuthClient is Authenticate for the OAuth endpoints clients
+// call on behalf of their users, such as userinfo, which also accept user
+// tokens issued to OAuth clients
+func (m *AuthMiddleware) AuthenticateOAuthClient(next http.Handler) http.Handler {
+	return m.authenticate(next, true)
+}
+
+func (m *AuthMiddleware) authenticate(next http.Handler, allowClients bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "missing authorization header", http.StatusUnauthorized)
+		if key := r.Header.Get(APIKeyHeader); key != "" {
+			m.authenticateAPIKey(w, r, key, next)
 			return
 		}
 
-		// Extract bearer token
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "invalid authorization header", http.StatusUnauthorized)
+		// Extract bearer token from the header or the access token cookie
+		token, err := AccessToken(r)
+		if err != nil {
+			m.writeError(w, r, http.StatusUnauthorized, "missing_token", err.Error())
 			return
 		}
 
-		token := parts[1]
 		claims, err := m.tokenService.ValidateToken(r.Context(), token, services.TokenTypeAccess)
 		if err != nil {
 			m.logger.Error("invalid token", zap.Error(err))
-			http.Error(w, "invalid token", http.StatusUnauthorized)
+			m.writeError(w, r, http.StatusUnauthorized, "invalid_token", "invalid token")
+			return
+		}
+		// Client credentials tokens identify an OAuth client, not a user, and
+		// audience-scoped tokens are for other APIs
+		if claims.UserID == uuid.Nil || claims.Audience != "" || (claims.ClientID != "" && !allowClients) {
+			m.writeError(w, r, http.StatusUnauthorized, "invalid_token", "invalid token")
+			return
+		}
+		// Tokens of flagged accounts only allow reading
+		if claims.ClientID == "" && claims.Scope == services.ScopeRestricted && !isReadOnly(r.Method) {
+			m.metricsService.IncrementCounter("http_forbidden_total", map[string]string{
+				"path":   r.URL.Path,
+				"method": r.Method,
+			})
+			m.writeError(w, r, http.StatusForbidden, "account_restricted", "account is restricted")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
+	})
+}
+
+// Identify adds the user of a valid first-party access token to the context
+// like Authenticate, but serves requests without one anonymously. Tokens
+// issued to OAuth clients or for other APIs are ignored, so that a client
+// cannot act as the signed-in user of the OAuth provider itself.
+func (m *AuthMiddleware) Identify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := AccessToken(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		// Add user ID to context
-		ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		claims, err := m.tokenService.ValidateToken(r.Context(), token, services.TokenTypeAccess)
+		if err != nil || claims.UserID == uuid.Nil || claims.ClientID != "" || claims.Audience != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
 	})
 }
+
+// authenticateAPIKey serves a request authenticated with the API key of a
+// service account, if the route accepts API keys
+func (m *AuthMiddleware) authenticateAPIKey(w http.ResponseWriter, r *http.Request, key string, next http.Handler) {
+	if m.apiKeys == nil || !m.acceptsAPIKeys(r.URL.Path) {
+		m.writeError(w, r, http.StatusUnauthorized, "api_key_not_accepted", "API keys are not accepted for this route")
+		return
+	}
+
+	user, err := m.apiKeys.Authenticate(r.Context(), key)
+	if err != nil {
+		if !errors.Is(err, services.ErrAPIKeyInvalid) {
+			m.logger.Error("failed to authenticate API key", zap.Error(err))
+		}
+		m.metricsService.IncrementCounter("api_key_auth_failures_total", map[string]string{
+			"path": r.URL.Path,
+		})
+		m.writeError(w, r, http.StatusUnauthorized, "invalid_api_key", "invalid API key")
+		return
+	}
+
+	next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), &services.TokenClaims{
+		UserID:   user.ID,
+		Email:    user.Email,
+		Username: user.Username,
+		Role:     string(user.Role),
+		TenantID: tenantID(user),
+	})))
+}
+
+// acceptsAPIKeys reports whether a route accepts API keys
+func (m *AuthMiddleware) acceptsAPIKeys(path string) bool {
+	for _, prefix := range m.apiKeyPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tenantID returns the tenant claim of a user's tokens
+func tenantID(user *models.User) string {
+	if user.OrganizationID == nil {
+		return ""
+	}
+	return user.OrganizationID.String()
+}
+
+// isReadOnly reports whether a request method does not change anything
+func isReadOnly(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// withClaims adds the user of a token to the context and records it as the
+// actor of any events
+func withClaims(ctx context.Context, claims *services.TokenClaims) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, claims.UserID)
+	ctx = context.WithValue(ctx, roleKey, models.Role(claims.Role))
+	ctx = context.WithValue(ctx, scopeKey, claims.Scope)
+	if len(claims.Permissions) > 0 {
+		permissions := make([]models.Permission, 0, len(claims.Permissions))
+		for _, permission := range claims.Permissions {
+			permissions = append(permissions, models.Permission(permission))
+		}
+		ctx = context.WithValue(ctx, permissionsKey, permissions)
+	}
+	if tenantID, err := uuid.Parse(claims.TenantID); err == nil {
+		ctx = context.WithValue(ctx, tenantKey, tenantID)
+	}
+	// The user's data is read from the region that issued the token
+	if claims.Region != "" {
+		ctx = repositories.WithRegion(ctx, claims.Region)
+	}
+	return events.WithActor(ctx, claims.UserID.String())
+}
+
+// RequirePermission rejects authenticated requests that do not hold all of
+// the given permissions. Access tokens carry the permissions of their user's
+// role; for API keys and tokens issued without them, the permissions of the
+// role are resolved. It must be applied after Authenticate.
+func (m *AuthMiddleware) RequirePermission(permissions ...models.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			granted, err := m.permissions(r.Context())
+			if err != nil {
+				m.logger.Error("failed to resolve permissions", zap.Error(err))
+				m.writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to check permissions")
+				return
+			}
+			for _, permission := range permissions {
+				if !slices.Contains(granted, permission) {
+					m.metricsService.IncrementCounter("http_forbidden_total", map[string]string{
+						"path":   r.URL.Path,
+						"method": r.Method,
+					})
+					m.writeError(w, r, http.StatusForbidden, "insufficient_permissions", "insufficient permissions")
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// permissions returns the permissions of the authenticated request
+func (m *AuthMiddleware) permissions(ctx context.Context) ([]models.Permission, error) {
+	if permissions, ok := ctx.Value(permissionsKey).([]models.Permission); ok {
+		return permissions, nil
+	}
+	role := GetRole(ctx)
+	if role == "" {
+		return nil, nil
+	}
+	if m.roles == nil {
+		return role.DefaultPermissions(), nil
+	}
+	return m.roles.Permissions(ctx, role)
+}
+
+// writeError responds with the error envelope
+func (m *AuthMiddleware) writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if err := WriteError(w, r, status, code, message); err != nil {
+		m.logger.Error("failed to encode response", zap.Error(err))
+	}
+}
+
+// GetUserID returns the authenticated user's ID stored in the context
+func GetUserID(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	return userID, ok
+}
+
+// GetTenantID returns the organization of the authenticated user, if any
+func GetTenantID(ctx context.Context) (uuid.UUID, bool) {
+	tenantID, ok := ctx.Value(tenantKey).(uuid.UUID)
+	return tenantID, ok
+}
+
+// GetScope returns the OAuth scope of the authenticated request's token,
+// empty for first-party tokens unless they are restricted
+func GetScope(ctx context.Context) string {
+	scope, _ := ctx.Value(scopeKey).(string)
+	return scope
+}
+
+// GetRole returns the authenticated user's role stored in the context
+func GetRole(ctx context.Context) models.Role {
+	role, _ := ctx.Value(roleKey).(models.Role)
+	return role
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+)
+
+// ResolveClientCountry records the client's country in the event metadata,
+// as named by header. The header is set by a CDN or proxy geolocating the
+// client, e.g. CF-IPCountry; like forwarding headers it is only trusted
+// from trustedProxies. It must run after EventMetadata. An empty header
+// disables it.
+func ResolveClientCountry(header string, trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if header == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !containsIP(trustedProxies, peerIP(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			country := strings.ToUpper(strings.TrimSpace(r.Header.Get(header)))
+			if len(country) != 2 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			metadata := events.MetadataFromContext(r.Context())
+			metadata.Country = country
+			next.ServeHTTP(w, r.WithContext(events.WithMetadata(r.Context(), metadata)))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveClientIP(t *testing.T) {
+	proxies, err := ParseNetworks([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		realIP         string
+		want           string
+	}{
+		{
+			name:         "no trusted proxies ignores forwarding headers",
+			remoteAddr:   "203.0.113.7:4000",
+			forwardedFor: "198.51.100.1",
+			realIP:       "198.51.100.2",
+			want:         "203.0.113.7",
+		},
+		{
+			name:           "untrusted peer ignores forwarding headers",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "203.0.113.7:4000",
+			forwardedFor:   "198.51.100.1",
+			want:           "203.0.113.7",
+		},
+		{
+			name:           "trusted peer skips trusted hops from the right",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:4000",
+			forwardedFor:   "198.51.100.1, 203.0.113.9, 10.0.0.2",
+			want:           "203.0.113.9",
+		},
+		{
+			name:           "trusted peer falls back to X-Real-IP",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:4000",
+			realIP:         "203.0.113.9",
+			want:           "203.0.113.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trusted := proxies
+			if len(tt.trustedProxies) == 0 {
+				trusted = nil
+			}
+			var got string
+			handler := ResolveClientIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
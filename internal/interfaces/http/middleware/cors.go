@@ -2,28 +2,113 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// CORSMiddleware handles CORS headers
-func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
+// AnyOrigin allows requests from every origin when listed in
+// CORSConfig.AllowedOrigins
+const AnyOrigin = "*"
+
+// defaultCORSMaxAge is how long browsers cache preflight results when no
+// max age is configured
+const defaultCORSMaxAge = 5 * time.Minute
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	defaultCORSHeaders = []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token",
+		APIKeyHeader, DeviceIDHeader, RequestIDHeader, CorrelationIDHeader, ErrorFormatHeader}
+)
+
+// CORSConfig controls which cross-origin browser requests are allowed.
+// Origins are compared case-insensitively as scheme://host[:port].
+type CORSConfig struct {
+	// AllowedOrigins may call the API from a browser; AnyOrigin allows all.
+	// Empty denies every cross-origin request.
+	AllowedOrigins []string
+	AllowedMethods []string // empty allows GET, POST, PUT, PATCH and DELETE
+	AllowedHeaders []string // empty allows the headers the API reads
+	// CredentialOrigins may send cookies and see responses to credentialed
+	// requests. They must be allowed origins; AnyOrigin is not accepted
+	// here, so credentials are only ever shared with named origins.
+	CredentialOrigins []string
+	MaxAge            time.Duration // of preflight results; 0 uses 5 minutes
+}
+
+// CORSMiddleware answers preflight requests and sets the CORS headers of
+// responses to allowed origins. Preflight requests from other origins are
+// refused; their other requests are served without CORS headers, so
+// browsers do not expose the responses.
+func CORSMiddleware(config CORSConfig) func(http.Handler) http.Handler {
+	anyOrigin := false
+	origins := make(map[string]bool, len(config.AllowedOrigins))
+	for _, origin := range config.AllowedOrigins {
+		if origin == AnyOrigin {
+			anyOrigin = true
+			continue
+		}
+		origins[normalizeOrigin(origin)] = true
+	}
+	credentialOrigins := make(map[string]bool, len(config.CredentialOrigins))
+	for _, origin := range config.CredentialOrigins {
+		credentialOrigins[normalizeOrigin(origin)] = true
+	}
+
+	methods := config.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := config.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	maxAge := config.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultCORSMaxAge
+	}
+	allowMethods := strings.Join(append([]string{http.MethodOptions}, methods...), ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	exposeHeaders := strings.Join([]string{"Authorization", "Retry-After", RequestIDHeader,
+		RateLimitLimitHeader, RateLimitRemainingHeader, RateLimitResetHeader}, ", ")
+	maxAgeSeconds := strconv.Itoa(int(maxAge.Seconds()))
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
 			if origin == "" {
-				origin = "*"
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Responses differ by origin, so caches must not share them
+			w.Header().Add("Vary", "Origin")
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			normalized := normalizeOrigin(origin)
+			if !anyOrigin && !origins[normalized] {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if credentialOrigins[normalized] {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			} else if anyOrigin {
+				w.Header().Set("Access-Control-Allow-Origin", AnyOrigin)
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
 			}
+			w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
 
-			// Allow the origin that sent the request
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token")
-			w.Header().Set("Access-Control-Expose-Headers", "Authorization")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Max-Age", "300")
-
-			// Handle preflight requests
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusOK)
+			if preflight {
+				w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+				w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+				w.Header().Set("Access-Control-Max-Age", maxAgeSeconds)
+				w.WriteHeader(http.StatusNoContent)
 				return
 			}
 
@@ -31,3 +116,8 @@ func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// normalizeOrigin lowercases an origin and drops a trailing slash
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	// ErrorFormatHeader names the version of the error envelope on error
+	// responses, so clients can tell envelope changes apart
+	ErrorFormatHeader = "X-Error-Format"
+	// ErrorFormatVersion is the current version of the error envelope.
+	// Version 1 was the ad hoc {"error": message} body.
+	ErrorFormatVersion = "2"
+)
+
+// ErrorEnvelope is the body of every error response of the API. Code is a
+// stable, machine-readable identifier clients should match on; Error is a
+// human-readable message that may change.
+type ErrorEnvelope struct {
+	Error     string `json:"error"`
+	Code      string `json:"code"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// NewErrorEnvelope returns the error envelope of a request
+func NewErrorEnvelope(r *http.Request, code, message string) ErrorEnvelope {
+	return ErrorEnvelope{Error: message, Code: code, RequestID: GetRequestID(r.Context())}
+}
+
+// WriteError responds with the error envelope
+func WriteError(w http.ResponseWriter, r *http.Request, status int, code, message string) error {
+	return writeErrorBody(w, status, NewErrorEnvelope(r, code, message))
+}
+
+// writeErrorBody responds with an error body embedding the envelope
+func writeErrorBody(w http.ResponseWriter, status int, body interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(ErrorFormatHeader, ErrorFormatVersion)
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(body)
+}
+
+// StatusCode returns the generic error code of an HTTP status, for errors
+// without a more specific code
+func StatusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusMethodNotAllowed:
+		return "method_not_allowed"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusGone:
+		return "gone"
+	case http.StatusRequestEntityTooLarge:
+		return "payload_too_large"
+	case http.StatusUnsupportedMediaType:
+		return "unsupported_media_type"
+	case http.StatusUnprocessableEntity:
+		return "unprocessable_entity"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusNotImplemented:
+		return "not_implemented"
+	case http.StatusServiceUnavailable:
+		return "service_unavailable"
+	}
+	if status >= http.StatusInternalServerError {
+		return "internal_error"
+	}
+	return "error"
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/events"
+)
+
+const (
+	// CorrelationIDHeader is the header used to propagate correlation IDs across services
+	CorrelationIDHeader = "X-Correlation-ID"
+	// DeviceIDHeader carries a client-generated identifier of the calling device
+	DeviceIDHeader = "X-Device-ID"
+
+	traceParentHeader = "traceparent"
+	traceIDHeader     = "X-Trace-ID"
+
+	// traceFlagSampled is the traceparent flag of traces the caller records
+	traceFlagSampled = 0x01
+)
+
+// EventMetadata captures the client IP, user agent, device ID, trace ID and correlation ID
+// of the request so that domain events published while handling it can be attributed
+func EventMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		correlationID := r.Header.Get(CorrelationIDHeader)
+		if correlationID == "" {
+			correlationID = GetRequestID(r.Context())
+		}
+
+		traceID, sampled := traceContext(r)
+		ctx := events.WithMetadata(r.Context(), events.Metadata{
+			ClientIP:      ClientIP(r),
+			UserAgent:     r.UserAgent(),
+			DeviceID:      r.Header.Get(DeviceIDHeader),
+			TraceID:       traceID,
+			CorrelationID: correlationID,
+			TraceSampled:  sampled,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// traceContext extracts the trace ID from a W3C traceparent header, falling
+// back to X-Trace-ID, and whether the caller samples the trace
+func traceContext(r *http.Request) (string, bool) {
+	// traceparent: {version}-{trace-id}-{parent-id}-{flags}
+	if parts := strings.Split(r.Header.Get(traceParentHeader), "-"); len(parts) == 4 && len(parts[1]) == 32 {
+		flags, err := strconv.ParseUint(parts[3], 16, 8)
+		return parts[1], err == nil && flags&traceFlagSampled != 0
+	}
+	return r.Header.Get(traceIDHeader), false
+}
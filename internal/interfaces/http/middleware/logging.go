@@ -44,7 +44,7 @@ func (m *LoggingMiddleware) LogRequest(next http.Handler) http.Handler {
 		)
 
 		// Record metrics
-		m.metricsService.RecordRequest(r.URL.Path, r.Method, rw.status, duration.Seconds())
+		m.metricsService.RecordRequest(r.Context(), r.URL.Path, r.Method, rw.status, duration.Seconds())
 	})
 }
 
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+const (
+	// RateLimitLimitHeader, RateLimitRemainingHeader and RateLimitResetHeader
+	// advertise the quota of the limiter applying to a response, following
+	// the IETF RateLimit header fields draft
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset" // seconds until the window resets
+)
+
+// RateLimitStatus is the quota a client has left with a rate limiter
+type RateLimitStatus struct {
+	Name          string    `json:"name"`
+	Limit         int       `json:"limit"`
+	Remaining     int       `json:"remaining"`
+	WindowSeconds int       `json:"windowSeconds"`
+	ResetAt       time.Time `json:"resetAt"`
+	// Enforced is false for soft limits, which are advertised but do not
+	// reject requests
+	Enforced bool `json:"enforced"`
+}
+
+// RateLimiter limits how many requests each client IP may make per fixed
+// window. Counts are kept in memory, so the limit applies per instance.
+type RateLimiter struct {
+	name           string
+	limit          int
+	window         time.Duration
+	soft           bool
+	clock          services.Clock
+	metricsService services.MetricsService
+	logger         *zap.Logger
+
+	mutex       sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+// NewRateLimiter creates a rate limiter allowing limit requests per client
+// IP in every window, which is measured with clock. name labels the
+// rejection metric.
+func NewRateLimiter(name string, limit int, window time.Duration, clock services.Clock, metricsService services.MetricsService, logger *zap.Logger) *RateLimiter {
+	return &RateLimiter{
+		name:           name,
+		limit:          limit,
+		window:         window,
+		clock:          clock,
+		metricsService: metricsService,
+		logger:         logger,
+		counts:         make(map[string]int),
+	}
+}
+
+// NewSoftRateLimiter creates a rate limiter that advertises its quota but
+// serves requests over the limit, counting them in a metric, so that clients
+// can throttle themselves before hard limits are introduced
+func NewSoftRateLimiter(name string, limit int, window time.Duration, clock services.Clock, metricsService services.MetricsService, logger *zap.Logger) *RateLimiter {
+	limiter := NewRateLimiter(name, limit, window, clock, metricsService, logger)
+	limiter.soft = true
+	return limiter
+}
+
+// Limit rejects requests over the limit with a 429. Every response carries
+// the client's quota in the RateLimit headers; a limiter further down the
+// chain replaces them, since its routes are the more specific.
+func (l *RateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := l.clock.Now()
+		status, allowed := l.allow(ClientIP(r), now)
+		setRateLimitHeaders(w, status, now)
+		if allowed {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		labels := map[string]string{"limiter": l.name}
+		if l.soft {
+			l.metricsService.IncrementCounter("http_rate_limit_exceeded_total", labels)
+			next.ServeHTTP(w, r)
+			return
+		}
+		l.metricsService.IncrementCounter("http_rate_limited_total", labels)
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(status.ResetAt.Sub(now).Seconds())+1))
+		if err := WriteError(w, r, http.StatusTooManyRequests, "rate_limited", "too many requests"); err != nil {
+			l.logger.Error("failed to encode response", zap.Error(err))
+		}
+	})
+}
+
+// Status returns the quota client has left without counting a request
+func (l *RateLimiter) Status(client string) RateLimitStatus {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.roll(l.clock.Now())
+	return l.status(client)
+}
+
+// allow counts a request from client and reports whether it is within the
+// limit, along with the quota left afterwards
+func (l *RateLimiter) allow(client string, now time.Time) (RateLimitStatus, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.roll(now)
+	if l.counts[client] >= l.limit {
+		return l.status(client), false
+	}
+	l.counts[client]++
+	return l.status(client), true
+}
+
+// roll starts a new window once the current one has passed. All counts are
+// dropped then, which keeps memory bounded by the clients seen in a single
+// window.
+func (l *RateLimiter) roll(now time.Time) {
+	if now.Sub(l.windowStart) >= l.window {
+		l.windowStart = now
+		l.counts = make(map[string]int)
+	}
+}
+
+func (l *RateLimiter) status(client string) RateLimitStatus {
+	return RateLimitStatus{
+		Name:          l.name,
+		Limit:         l.limit,
+		Remaining:     max(l.limit-l.counts[client], 0),
+		WindowSeconds: int(l.window.Seconds()),
+		ResetAt:       l.windowStart.Add(l.window).UTC(),
+		Enforced:      !l.soft,
+	}
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, status RateLimitStatus, now time.Time) {
+	w.Header().Set(RateLimitLimitHeader, strconv.Itoa(status.Limit))
+	w.Header().Set(RateLimitRemainingHeader, strconv.Itoa(status.Remaining))
+	w.Header().Set(RateLimitResetHeader, strconv.Itoa(int(status.ResetAt.Sub(now).Seconds())+1))
+}
+
+// RateLimits collects the rate limiters of the routes so that clients can
+// look up the quota they have left with each of them. Limiters are added
+// while routes are set up, before requests are served.
+type RateLimits struct {
+	limiters []*RateLimiter
+}
+
+// Add registers a limiter and returns it
+func (l *RateLimits) Add(limiter *RateLimiter) *RateLimiter {
+	l.limiters = append(l.limiters, limiter)
+	return limiter
+}
+
+// Status returns the quota the client of r has left with every limiter
+func (l *RateLimits) Status(r *http.Request) []RateLimitStatus {
+	client := ClientIP(r)
+	statuses := make([]RateLimitStatus, 0, len(l.limiters))
+	for _, limiter := range l.limiters {
+		statuses = append(statuses, limiter.Status(client))
+	}
+	return statuses
+}
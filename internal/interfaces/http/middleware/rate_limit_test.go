@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fixedClock is a clock that only moves when a test advances it
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) RecordRequest(ctx context.Context, path string, method string, statusCode int, duration float64) {
+}
+func (nopMetrics) IncrementCounter(name string, labels map[string]string)                {}
+func (nopMetrics) ObserveValue(name string, value float64, labels map[string]string)     {}
+func (nopMetrics) ObserveHistogram(name string, value float64, labels map[string]string) {}
+
+func TestRateLimiterWindow(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := NewRateLimiter("test", 2, time.Minute, clock, nopMetrics{}, zap.NewNop())
+	handler := limiter.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("203.0.113.1:4000").Code)
+	clock.now = clock.now.Add(30 * time.Second)
+	w := serve("203.0.113.1:4000")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, "31", w.Header().Get(RateLimitResetHeader))
+
+	w = serve("203.0.113.1:4000")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "31", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, serve("203.0.113.2:4000").Code)
+
+	// The window started with the first request, so it resets a minute later
+	clock.now = clock.now.Add(29 * time.Second)
+	assert.Equal(t, http.StatusTooManyRequests, serve("203.0.113.1:4000").Code)
+	clock.now = clock.now.Add(time.Second)
+	assert.Equal(t, 2, limiter.Status("203.0.113.1").Remaining)
+	assert.Equal(t, http.StatusOK, serve("203.0.113.1:4000").Code)
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// RecoveryMiddleware converts handler panics into 500 responses
+type RecoveryMiddleware struct {
+	logger         *zap.Logger
+	metricsService services.MetricsService
+}
+
+// NewRecoveryMiddleware creates a new recovery middleware
+func NewRecoveryMiddleware(logger *zap.Logger, metricsService services.MetricsService) *RecoveryMiddleware {
+	return &RecoveryMiddleware{
+		logger:         logger,
+		metricsService: metricsService,
+	}
+}
+
+// Recover recovers from panics raised by downstream handlers, logs them with
+// the stack trace and responds with a structured 500 error
+func (m *RecoveryMiddleware) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			// http.ErrAbortHandler is used to deliberately abort a response
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			requestID := GetRequestID(r.Context())
+			m.logger.Error("panic recovered",
+				zap.String("panic", fmt.Sprint(rec)),
+				zap.String("request_id", requestID),
+				zap.String("path", r.URL.Path),
+				zap.String("method", r.Method),
+				zap.ByteString("stack", debug.Stack()),
+			)
+
+			m.metricsService.IncrementCounter("http_panics_total", map[string]string{
+				"path":   r.URL.Path,
+				"method": r.Method,
+			})
+
+			if err := WriteError(w, r, http.StatusInternalServerError, "internal_error", "internal server error"); err != nil {
+				m.logger.Error("failed to encode response", zap.Error(err))
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
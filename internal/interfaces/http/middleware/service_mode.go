@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"go.uber.org/zap"
+)
+
+// ServiceMode controls which requests the service accepts
+type ServiceMode string
+
+const (
+	// ModeNormal accepts all requests
+	ModeNormal ServiceMode = "normal"
+	// ModeReadOnly rejects mutations except authentication
+	ModeReadOnly ServiceMode = "read_only"
+	// ModeMaintenance rejects all API requests with a static response
+	ModeMaintenance ServiceMode = "maintenance"
+)
+
+// ParseServiceMode validates a service mode name, treating empty as normal
+func ParseServiceMode(value string) (ServiceMode, error) {
+	switch mode := ServiceMode(strings.ToLower(value)); mode {
+	case "":
+		return ModeNormal, nil
+	case ModeNormal, ModeReadOnly, ModeMaintenance:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown service mode: %s", value)
+	}
+}
+
+const (
+	// modeCacheKey holds the service mode shared by all replicas
+	modeCacheKey = "service_mode"
+	// modeSyncInterval is how often replicas load the shared service mode
+	modeSyncInterval = 5 * time.Second
+)
+
+// ModeController holds the runtime service mode and rejects requests that
+// the current mode does not allow. With a cache, the mode is shared by all
+// replicas: switching it on one reaches the others within seconds.
+type ModeController struct {
+	mutex          sync.RWMutex
+	mode           ServiceMode
+	message        string
+	since          time.Time
+	cache          services.CacheService
+	exemptPaths    []string
+	authPaths      []string
+	metricsService services.MetricsService
+	logger         *zap.Logger
+}
+
+// ModeStatus describes the current service mode
+type ModeStatus struct {
+	Mode    ServiceMode `json:"mode"`
+	Message string      `json:"message,omitempty"`
+	Since   time.Time   `json:"since"`
+}
+
+// NewModeController creates a mode controller starting in the given mode
+// until a mode is shared through cache, which may be nil to keep the mode
+// per instance. Requests to exemptPaths are always served so that health
+// checks and the mode endpoint keep working; authPaths stay writable in
+// read-only mode.
+func NewModeController(
+	mode ServiceMode,
+	message string,
+	cache services.CacheService,
+	exemptPaths []string,
+	authPaths []string,
+	metricsService services.MetricsService,
+	logger *zap.Logger,
+) *ModeController {
+	return &ModeController{
+		mode:           mode,
+		message:        message,
+		since:          time.Now().UTC(),
+		cache:          cache,
+		exemptPaths:    exemptPaths,
+		authPaths:      authPaths,
+		metricsService: metricsService,
+		logger:         logger,
+	}
+}
+
+// Status returns the current service mode
+func (c *ModeController) Status() ModeStatus {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return ModeStatus{Mode: c.mode, Message: c.message, Since: c.since}
+}
+
+// SetMode switches the service mode at runtime, sharing it with the other
+// replicas first
+func (c *ModeController) SetMode(ctx context.Context, mode ServiceMode, message string) error {
+	status := ModeStatus{Mode: mode, Message: message, Since: time.Now().UTC()}
+	if c.cache != nil {
+		if err := c.cache.Set(ctx, modeCacheKey, status, 0); err != nil {
+			return fmt.Errorf("failed to share service mode: %w", err)
+		}
+	}
+	c.apply(status)
+	return nil
+}
+
+// Sync loads the service mode shared by the replicas. The mode this
+// instance started in is kept until one is shared.
+func (c *ModeController) Sync(ctx context.Context) error {
+	if c.cache == nil {
+		return nil
+	}
+	var status ModeStatus
+	if err := c.cache.Get(ctx, modeCacheKey, &status); err != nil {
+		if errors.Is(err, services.ErrCacheKeyNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to load service mode: %w", err)
+	}
+	if _, err := ParseServiceMode(string(status.Mode)); err != nil || status.Mode == "" {
+		return fmt.Errorf("invalid shared service mode: %q", status.Mode)
+	}
+	if current := c.Status(); current.Mode != status.Mode || current.Message != status.Message || !current.Since.Equal(status.Since) {
+		c.apply(status)
+	}
+	return nil
+}
+
+// Watch loads the shared service mode every few seconds until ctx is
+// cancelled
+func (c *ModeController) Watch(ctx context.Context) {
+	if c.cache == nil {
+		return
+	}
+	if err := c.Sync(ctx); err != nil {
+		c.logger.Error("failed to sync service mode", zap.Error(err))
+	}
+
+	ticker := time.NewTicker(modeSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Sync(ctx); err != nil {
+				c.logger.Error("failed to sync service mode", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (c *ModeController) apply(status ModeStatus) {
+	c.mutex.Lock()
+	previous := c.mode
+	c.mode = status.Mode
+	c.message = status.Message
+	c.since = status.Since
+	c.mutex.Unlock()
+
+	c.logger.Warn("service mode changed",
+		zap.String("from", string(previous)),
+		zap.String("to", string(status.Mode)))
+}
+
+// Enforce rejects requests not allowed in the current mode with a 503
+func (c *ModeController) Enforce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := c.Status()
+		if status.Mode == ModeNormal || hasPathPrefix(r.URL.Path, c.exemptPaths) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if status.Mode == ModeReadOnly && (isSafeMethod(r.Method) || hasPathPrefix(r.URL.Path, c.authPaths)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		c.metricsService.IncrementCounter("http_rejected_by_mode_total", map[string]string{
+			"mode":   string(status.Mode),
+			"method": r.Method,
+		})
+
+		message := status.Message
+		if message == "" {
+			message = "the service is temporarily unavailable"
+		}
+		errorText := "service is in maintenance mode"
+		if status.Mode == ModeReadOnly {
+			errorText = "service is in read-only mode"
+		}
+
+		w.Header().Set("Retry-After", "120")
+		if err := writeErrorBody(w, http.StatusServiceUnavailable, struct {
+			ErrorEnvelope
+			Mode    string `json:"mode"`
+			Message string `json:"message"`
+		}{
+			ErrorEnvelope: NewErrorEnvelope(r, "service_"+string(status.Mode), errorText),
+			Mode:          string(status.Mode),
+			Message:       message,
+		}); err != nil {
+			c.logger.Error("failed to encode response", zap.Error(err))
+		}
+	})
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
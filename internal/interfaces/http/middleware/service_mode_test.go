@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestModeControllerSharedMode(t *testing.T) {
+	ctx := context.Background()
+	cache := memory.NewCacheService()
+	first := NewModeController(ModeNormal, "", cache, nil, nil, nil, zap.NewNop())
+	second := NewModeController(ModeNormal, "", cache, nil, nil, nil, zap.NewNop())
+
+	// Nothing shared yet: the configured mode is kept
+	require.NoError(t, second.Sync(ctx))
+	assert.Equal(t, ModeNormal, second.Status().Mode)
+
+	require.NoError(t, first.SetMode(ctx, ModeMaintenance, "upgrading"))
+	assert.Equal(t, ModeMaintenance, first.Status().Mode)
+
+	require.NoError(t, second.Sync(ctx))
+	status := second.Status()
+	assert.Equal(t, ModeMaintenance, status.Mode)
+	assert.Equal(t, "upgrading", status.Message)
+	assert.True(t, first.Status().Since.Equal(status.Since))
+}
+
+func TestModeControllerWithoutCache(t *testing.T) {
+	ctx := context.Background()
+	controller := NewModeController(ModeReadOnly, "", nil, nil, nil, nil, zap.NewNop())
+	require.NoError(t, controller.Sync(ctx))
+	assert.Equal(t, ModeReadOnly, controller.Status().Mode)
+
+	require.NoError(t, controller.SetMode(ctx, ModeNormal, ""))
+	assert.Equal(t, ModeNormal, controller.Status().Mode)
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	// AccessTokenCookie is the cookie carrying the access token in cookie mode
+	AccessTokenCookie = "access_token"
+	// RefreshTokenCookie is the cookie carrying the refresh token in cookie mode
+	RefreshTokenCookie = "refresh_token"
+)
+
+var (
+	// ErrMissingToken is returned when a request carries no access token
+	ErrMissingToken = errors.New("missing authorization header")
+	// ErrInvalidAuthorizationHeader is returned for a malformed Authorization header
+	ErrInvalidAuthorizationHeader = errors.New("invalid authorization header")
+)
+
+// AccessToken extracts the access token from the Authorization bearer header,
+// falling back to the access token cookie
+func AccessToken(r *http.Request) (string, error) {
+	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			return "", ErrInvalidAuthorizationHeader
+		}
+		return parts[1], nil
+	}
+
+	if cookie, err := r.Cookie(AccessTokenCookie); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+
+	return "", ErrMissingToken
+}
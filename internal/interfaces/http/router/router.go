@@ -1,49 +1,195 @@
 package router
 
 import (
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/mibrahim2344/identity-service/docs"
+	"github.com/mibrahim2344/identity-service/internal/domain/models"
 	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/adminui"
 	"github.com/mibrahim2344/identity-service/internal/interfaces/http/handlers"
 	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/zap"
 )
 
+// Config represents router configuration
+type Config struct {
+	VerifyEmailRedirect handlers.VerifyEmailRedirectConfig
+	Cookies             handlers.CookieConfig
+	Avatars             handlers.AvatarConfig
+	CORS                middleware.CORSConfig
+	Mode                middleware.ServiceMode
+	MaintenanceMessage  string
+	RequestTimeout      time.Duration // deadline of request contexts; 0 disables
+	// PublicProfileRateLimit is the number of public profile requests a
+	// client IP may make per minute; 0 disables the limit
+	PublicProfileRateLimit int
+	PublicProfileMaxAge    time.Duration // Cache-Control max-age of public profiles
+	// VerificationResendRateLimit is the number of verification email
+	// resends a client IP may request per hour; 0 disables the limit
+	VerificationResendRateLimit int
+	// OAuthLoginURL is where the authorization endpoint sends users without
+	// a session; empty redirects back to the client with login_required
+	OAuthLoginURL string
+	SocialLogin   handlers.SocialLoginConfig
+	// TrustedProxies are the proxies whose forwarding headers name the
+	// client IP; empty ignores forwarding headers and uses the peer address
+	TrustedProxies []*net.IPNet
+	IPRules        middleware.IPRules // admit client IPs to all routes
+	RouteIPRules   []middleware.RouteIPRules
+	// CountryHeader names the header a CDN or proxy sets to the client's
+	// ISO country code, e.g. CF-IPCountry; empty leaves countries unknown
+	CountryHeader string
+	// Tracing records requests as OpenTelemetry spans exported by the
+	// global tracer provider
+	Tracing bool
+	// SoftRateLimit is the number of requests a client IP may make per
+	// minute before it is told to slow down by the RateLimit headers of
+	// every response; requests over it are still served. 0 disables it.
+	SoftRateLimit int
+	// OpenMetrics serves /metrics in the OpenMetrics format to scrapers that
+	// accept it, which is required to expose exemplars
+	OpenMetrics bool
+	// APIKeyRoutes are the path prefixes of authenticated routes that
+	// accept service account API keys in the X-API-Key header
+	APIKeyRoutes []string
+	// AdminUI serves the embedded admin console at /admin/
+	AdminUI bool
+	// RuntimeConfig is the effective configuration served at
+	// /admin/config, with secrets masked; nil disables the endpoint
+	RuntimeConfig map[string]any
+}
+
 // Router handles all routing logic
 type Router struct {
-	userService    services.UserService
-	tokenService   services.TokenService
-	metricsService services.MetricsService
-	logger         *zap.Logger
+	config          Config
+	userService     services.UserService
+	tokenService    services.TokenService
+	oauthService    services.OAuthService    // nil disables the OAuth 2.0 / OpenID Connect provider
+	auditLogService services.AuditLogService // nil disables the audit log endpoints
+	tenantSettings  services.TenantSettingsService
+	federation      services.FederationService // nil disables social login
+	mfaPolicies     services.MFAPolicyService
+	webhooks        services.NotificationWebhookService // nil disables notification webhooks
+	moderation      services.ModerationService          // nil disables abuse reports
+	apiKeys         services.APIKeyService              // nil disables service accounts
+	cacheAdmin      services.CacheAdminService          // nil disables the cache admin endpoints
+	roles           services.RoleService
+	organizations   services.OrganizationService
+	sso             services.SSOService       // nil disables SSO connections
+	bootstrap       services.BootstrapService // nil disables the first-run setup
+	modeCache       services.CacheService     // nil keeps the service mode per instance
+	modeController  *middleware.ModeController
+	metricsService  services.MetricsService
+	logger          *zap.Logger
 }
 
 // NewRouter creates a new router instance
 func NewRouter(
+	config Config,
 	userService services.UserService,
 	tokenService services.TokenService,
+	oauthService services.OAuthService,
+	auditLogService services.AuditLogService,
+	tenantSettings services.TenantSettingsService,
+	federation services.FederationService,
+	mfaPolicies services.MFAPolicyService,
+	webhooks services.NotificationWebhookService,
+	moderation services.ModerationService,
+	apiKeys services.APIKeyService,
+	cacheAdmin services.CacheAdminService,
+	roles services.RoleService,
+	organizations services.OrganizationService,
+	sso services.SSOService,
+	bootstrap services.BootstrapService,
+	modeCache services.CacheService,
 	metricsService services.MetricsService,
 	logger *zap.Logger,
 ) *Router {
 	return &Router{
-		userService:    userService,
-		tokenService:   tokenService,
-		metricsService: metricsService,
-		logger:         logger,
+		config:          config,
+		userService:     userService,
+		tokenService:    tokenService,
+		oauthService:    oauthService,
+		auditLogService: auditLogService,
+		tenantSettings:  tenantSettings,
+		federation:      federation,
+		mfaPolicies:     mfaPolicies,
+		webhooks:        webhooks,
+		moderation:      moderation,
+		apiKeys:         apiKeys,
+		cacheAdmin:      cacheAdmin,
+		roles:           roles,
+		organizations:   organizations,
+		sso:             sso,
+		bootstrap:       bootstrap,
+		modeCache:       modeCache,
+		metricsService:  metricsService,
+		logger:          logger,
 	}
 }
 
+// ModeController returns the controller of the service mode, once the
+// routes are set up
+func (r *Router) ModeController() *middleware.ModeController {
+	return r.modeController
+}
+
 // Setup sets up all routes and middleware
 func (r *Router) Setup() http.Handler {
 	r.logger.Info("Setting up router...")
 	router := mux.NewRouter()
 
-	// Apply CORS middleware
-	r.logger.Debug("Applying CORS middleware...")
-	router.Use(middleware.CORSMiddleware([]string{"*"}))
+	// Assign request IDs, trace requests, recover from handler panics,
+	// capture event metadata and bound the request context
+	r.logger.Debug("Applying request ID, recovery, event metadata and deadline middleware...")
+	recoveryMiddleware := middleware.NewRecoveryMiddleware(r.logger, r.metricsService)
+	router.Use(middleware.RequestID)
+	if r.config.Tracing {
+		router.Use(middleware.Tracing)
+	}
+	router.Use(recoveryMiddleware.Recover)
+	router.Use(middleware.ResolveClientIP(r.config.TrustedProxies))
+	router.Use(middleware.EventMetadata)
+	router.Use(middleware.ResolveClientCountry(r.config.CountryHeader, r.config.TrustedProxies))
+	router.Use(middleware.RequestDeadline(r.config.RequestTimeout))
+
+	// Admit client IPs by the allow and deny rules
+	r.logger.Debug("Applying IP filter middleware...")
+	ipFilter := middleware.NewIPFilter(r.config.IPRules, r.config.RouteIPRules, []string{"/health"}, r.metricsService, r.logger)
+	router.Use(ipFilter.Filter)
+
+	// Apply read-only and maintenance mode enforcement
+	r.logger.Debug("Applying service mode middleware...")
+	mode := r.config.Mode
+	if mode == "" {
+		mode = middleware.ModeNormal
+	}
+	modeController := middleware.NewModeController(
+		mode,
+		r.config.MaintenanceMessage,
+		r.modeCache,
+		[]string{"/health", "/metrics", "/swagger/", "/.well-known/", "/api/v1/admin/mode"},
+		[]string{"/api/v1/auth/login", "/api/v1/auth/mfa/verify", "/api/v1/auth/passkey/", "/api/v1/auth/magic-link/verify", "/api/v1/auth/refresh", "/api/v1/auth/logout", services.OAuthTokenPath, services.OAuthIntrospectPath, services.OAuthIntrospectBatchPath, services.OAuthRevokePath},
+		r.metricsService,
+		r.logger,
+	)
+	router.Use(modeController.Enforce)
+	r.modeController = modeController
+
+	// Advertise quotas on every response; the limits of individual routes
+	// are added to rateLimits as they are set up
+	rateLimits := &middleware.RateLimits{}
+	if r.config.SoftRateLimit > 0 {
+		r.logger.Debug("Applying soft rate limit middleware...")
+		router.Use(rateLimits.Add(middleware.NewSoftRateLimiter("soft", r.config.SoftRateLimit, time.Minute, services.SystemClock, r.metricsService, r.logger)).Limit)
+	}
 
 	// Health check
 	r.logger.Debug("Setting up health check endpoint...")
@@ -55,6 +201,29 @@ func (r *Router) Setup() http.Handler {
 		}
 	}).Methods(http.MethodGet)
 
+	// Public signing keys
+	r.logger.Debug("Setting up JWKS endpoint...")
+	router.Handle(services.JWKSPath, handlers.NewJWKSHandler(r.tokenService, r.metricsService, r.logger)).Methods(http.MethodGet)
+
+	// OAuth 2.0 / OpenID Connect provider
+	authOptions := []middleware.AuthOption{middleware.WithRoles(r.roles)}
+	if r.apiKeys != nil {
+		authOptions = append(authOptions, middleware.WithAPIKeys(r.apiKeys, r.config.APIKeyRoutes))
+	}
+	authMiddleware := middleware.NewAuthMiddleware(r.tokenService, r.metricsService, r.logger, authOptions...)
+	var oauthHandler *handlers.OAuthHandler
+	if r.oauthService != nil {
+		r.logger.Debug("Setting up OAuth 2.0 / OpenID Connect provider routes...")
+		oauthHandler = handlers.NewOAuthHandler(r.oauthService, r.config.OAuthLoginURL, r.metricsService, r.logger)
+		router.HandleFunc(services.OIDCDiscoveryPath, oauthHandler.Discovery).Methods(http.MethodGet)
+		router.Handle(services.OAuthAuthorizePath, authMiddleware.Identify(http.HandlerFunc(oauthHandler.Authorize))).Methods(http.MethodGet)
+		router.HandleFunc(services.OAuthTokenPath, oauthHandler.Token).Methods(http.MethodPost)
+		router.HandleFunc(services.OAuthIntrospectPath, oauthHandler.Introspect).Methods(http.MethodPost)
+		router.HandleFunc(services.OAuthIntrospectBatchPath, oauthHandler.IntrospectBatch).Methods(http.MethodPost)
+		router.HandleFunc(services.OAuthRevokePath, oauthHandler.Revoke).Methods(http.MethodPost)
+		router.Handle(services.OAuthUserInfoPath, authMiddleware.AuthenticateOAuthClient(http.HandlerFunc(oauthHandler.UserInfo))).Methods(http.MethodGet, http.MethodPost)
+	}
+
 	// API v1 routes
 	r.logger.Debug("Setting up API v1 routes...")
 	v1 := router.PathPrefix("/api/v1").Subrouter()
@@ -62,25 +231,237 @@ func (r *Router) Setup() http.Handler {
 	// Auth routes
 	r.logger.Debug("Setting up auth routes...")
 	auth := v1.PathPrefix("/auth").Subrouter()
-	userHandler := handlers.NewUserHandler(r.userService, r.metricsService, r.logger)
+	userHandler := handlers.NewUserHandler(r.userService, r.metricsService, r.logger,
+		handlers.WithVerifyEmailRedirect(r.config.VerifyEmailRedirect),
+		handlers.WithCookies(r.config.Cookies),
+		handlers.WithAvatars(r.config.Avatars),
+		handlers.WithPublicProfileMaxAge(r.config.PublicProfileMaxAge),
+		handlers.WithSocialLogin(r.federation, r.config.SocialLogin),
+		handlers.WithSSO(r.sso))
 	auth.HandleFunc("/register", userHandler.Register).Methods(http.MethodPost)
 	auth.HandleFunc("/login", userHandler.Login).Methods(http.MethodPost)
+	auth.HandleFunc("/login/confirm", userHandler.ConfirmLogin).Methods(http.MethodPost)
 	auth.HandleFunc("/refresh", userHandler.RefreshToken).Methods(http.MethodPost)
+	auth.HandleFunc("/logout", userHandler.Logout).Methods(http.MethodPost)
 	auth.HandleFunc("/forgot-password", userHandler.RequestPasswordReset).Methods(http.MethodPost)
+	auth.HandleFunc("/forgot-password/recovery-email", userHandler.RequestRecoveryPasswordReset).Methods(http.MethodPost)
 	auth.HandleFunc("/reset-password", userHandler.ResetPassword).Methods(http.MethodPost)
 	auth.HandleFunc("/verify-email", userHandler.VerifyEmail).Methods(http.MethodGet)
+	var resendVerification http.Handler = http.HandlerFunc(userHandler.ResendVerificationEmail)
+	if r.config.VerificationResendRateLimit > 0 {
+		limiter := rateLimits.Add(middleware.NewRateLimiter("verification_resend", r.config.VerificationResendRateLimit, time.Hour, services.SystemClock, r.metricsService, r.logger))
+		resendVerification = limiter.Limit(resendVerification)
+	}
+	auth.Handle("/verify-email/resend", resendVerification).Methods(http.MethodPost)
+	auth.HandleFunc("/recovery-email/verify", userHandler.VerifyRecoveryEmail).Methods(http.MethodGet)
+	auth.HandleFunc("/mfa/verify", userHandler.VerifyMFA).Methods(http.MethodPost)
+	auth.HandleFunc("/mfa/enroll", userHandler.EnrollMFAChallenge).Methods(http.MethodPost)
+	auth.HandleFunc("/passkey/begin", userHandler.BeginPasskeyLogin).Methods(http.MethodPost)
+	auth.HandleFunc("/passkey/finish", userHandler.FinishPasskeyLogin).Methods(http.MethodPost)
+	auth.HandleFunc("/magic-link", userHandler.RequestMagicLink).Methods(http.MethodPost)
+	auth.HandleFunc("/magic-link/verify", userHandler.VerifyMagicLink).Methods(http.MethodGet)
+	auth.HandleFunc("/security-questions", userHandler.GetRecoveryQuestions).Methods(http.MethodPost)
+	auth.HandleFunc("/security-questions/recover", userHandler.RecoverWithSecurityQuestions).Methods(http.MethodPost)
+	if r.federation != nil {
+		auth.HandleFunc("/oauth/{provider}/login", userHandler.SocialLogin).Methods(http.MethodGet)
+		auth.HandleFunc("/oauth/{provider}/callback", userHandler.SocialLoginCallback).Methods(http.MethodGet)
+	}
+	if r.sso != nil {
+		auth.HandleFunc("/sso/discover", userHandler.DiscoverSSO).Methods(http.MethodPost)
+		auth.HandleFunc("/sso/{connection}/login", userHandler.SSOLogin).Methods(http.MethodGet)
+		auth.HandleFunc("/sso/{connection}/callback", userHandler.SSOLoginCallback).Methods(http.MethodGet)
+	}
+
+	// Public user routes
+	r.logger.Debug("Setting up public user routes...")
+	var publicProfile http.Handler = http.HandlerFunc(userHandler.GetPublicProfile)
+	if r.config.PublicProfileRateLimit > 0 {
+		limiter := rateLimits.Add(middleware.NewRateLimiter("public_profile", r.config.PublicProfileRateLimit, time.Minute, services.SystemClock, r.metricsService, r.logger))
+		publicProfile = limiter.Limit(publicProfile)
+	}
+	v1.Handle("/users/{id}/public", publicProfile).Methods(http.MethodGet)
+	// Deactivated users cannot sign in, so restoring authenticates with credentials
+	v1.HandleFunc("/users/me/restore", userHandler.RestoreAccount).Methods(http.MethodPost)
+	if r.bootstrap != nil {
+		// Authenticated by the one-time bootstrap token instead of a user
+		bootstrapHandler := handlers.NewBootstrapHandler(r.bootstrap, r.metricsService, r.logger)
+		v1.HandleFunc("/bootstrap", bootstrapHandler.Setup).Methods(http.MethodPost)
+	}
 
 	// Protected routes
 	r.logger.Debug("Setting up protected routes...")
 	protected := v1.PathPrefix("/").Subrouter()
-	authMiddleware := middleware.NewAuthMiddleware(r.tokenService, r.metricsService, r.logger)
 	protected.Use(authMiddleware.Authenticate)
 
 	// User routes
 	r.logger.Debug("Setting up user routes...")
 	users := protected.PathPrefix("/users").Subrouter()
 	users.HandleFunc("/me", userHandler.GetUser).Methods(http.MethodGet)
+	users.HandleFunc("/me", userHandler.ReplaceProfile).Methods(http.MethodPut)
+	users.HandleFunc("/me", userHandler.UpdateProfile).Methods(http.MethodPatch)
+	users.HandleFunc("/me", userHandler.DeleteAccount).Methods(http.MethodDelete)
 	users.HandleFunc("/me/password", userHandler.ChangePassword).Methods(http.MethodPut)
+	users.HandleFunc("/me/profile", userHandler.UpdateProfile).Methods(http.MethodPatch)
+	users.HandleFunc("/me/profile/completeness", userHandler.GetProfileCompleteness).Methods(http.MethodGet)
+	users.HandleFunc("/me/consent", userHandler.GetConsent).Methods(http.MethodGet)
+	users.HandleFunc("/me/consent", userHandler.UpdateConsent).Methods(http.MethodPut)
+	rateLimitHandler := handlers.NewRateLimitHandler(rateLimits, r.metricsService, r.logger)
+	users.HandleFunc("/me/rate-limits", rateLimitHandler.GetRateLimits).Methods(http.MethodGet)
+	users.HandleFunc("/me/recovery-email", userHandler.SetRecoveryEmail).Methods(http.MethodPut)
+	users.HandleFunc("/me/recovery-email", userHandler.RemoveRecoveryEmail).Methods(http.MethodDelete)
+	users.HandleFunc("/me/security-questions", userHandler.GetSecurityQuestions).Methods(http.MethodGet)
+	users.HandleFunc("/me/security-questions", userHandler.SetSecurityQuestions).Methods(http.MethodPut)
+	users.HandleFunc("/me/security-questions", userHandler.RemoveSecurityQuestions).Methods(http.MethodDelete)
+	users.HandleFunc("/me/mfa", userHandler.GetMFAStatus).Methods(http.MethodGet)
+	users.HandleFunc("/me/mfa/totp", userHandler.BeginTOTPEnrollment).Methods(http.MethodPost)
+	users.HandleFunc("/me/mfa/totp", userHandler.DisableTOTP).Methods(http.MethodDelete)
+	users.HandleFunc("/me/mfa/totp/confirm", userHandler.ConfirmTOTPEnrollment).Methods(http.MethodPost)
+	users.HandleFunc("/me/passkeys", userHandler.ListPasskeys).Methods(http.MethodGet)
+	users.HandleFunc("/me/passkeys", userHandler.FinishPasskeyRegistration).Methods(http.MethodPost)
+	users.HandleFunc("/me/passkeys/registration", userHandler.BeginPasskeyRegistration).Methods(http.MethodPost)
+	users.HandleFunc("/me/passkeys/{passkeyId}", userHandler.DeletePasskey).Methods(http.MethodDelete)
+	users.HandleFunc("/me/identities", userHandler.ListIdentities).Methods(http.MethodGet)
+	users.HandleFunc("/me/identities/{identityId}", userHandler.UnlinkIdentity).Methods(http.MethodDelete)
+	if r.federation != nil {
+		users.HandleFunc("/me/identities/{provider}", userHandler.LinkIdentity).Methods(http.MethodPost)
+	}
+	users.HandleFunc("/me/audience-tokens", userHandler.MintAudienceToken).Methods(http.MethodPost)
+	var webhookHandler *handlers.NotificationWebhookHandler
+	if r.webhooks != nil {
+		webhookHandler = handlers.NewNotificationWebhookHandler(r.webhooks, r.metricsService, r.logger)
+		users.HandleFunc("/me/webhooks", webhookHandler.ListWebhooks).Methods(http.MethodGet)
+		users.HandleFunc("/me/webhooks", webhookHandler.RegisterWebhook).Methods(http.MethodPost)
+		users.HandleFunc("/me/webhooks/{webhookId}", webhookHandler.DeleteWebhook).Methods(http.MethodDelete)
+	}
+	var moderationHandler *handlers.ModerationHandler
+	if r.moderation != nil {
+		moderationHandler = handlers.NewModerationHandler(r.moderation, r.metricsService, r.logger)
+		users.HandleFunc("/{id}/reports", moderationHandler.ReportAccount).Methods(http.MethodPost)
+	}
+
+	// Organization routes; what members may do depends on their organization role
+	r.logger.Debug("Setting up organization routes...")
+	organizationHandler := handlers.NewOrganizationHandler(r.organizations, r.sso, r.metricsService, r.logger)
+	organizations := protected.PathPrefix("/organizations").Subrouter()
+	organizations.HandleFunc("", organizationHandler.ListOrganizations).Methods(http.MethodGet)
+	organizations.HandleFunc("", organizationHandler.CreateOrganization).Methods(http.MethodPost)
+	organizations.HandleFunc("/invitations", organizationHandler.ListInvitations).Methods(http.MethodGet)
+	organizations.HandleFunc("/{id}", organizationHandler.GetOrganization).Methods(http.MethodGet)
+	organizations.HandleFunc("/{id}/members", organizationHandler.ListMembers).Methods(http.MethodGet)
+	organizations.HandleFunc("/{id}/members", organizationHandler.InviteMember).Methods(http.MethodPost)
+	organizations.HandleFunc("/{id}/members/{userId}", organizationHandler.RemoveMember).Methods(http.MethodDelete)
+	organizations.HandleFunc("/{id}/invitation", organizationHandler.DeclineInvitation).Methods(http.MethodDelete)
+	organizations.HandleFunc("/{id}/invitation/accept", organizationHandler.AcceptInvitation).Methods(http.MethodPost)
+	organizations.HandleFunc("/{id}/domain-join-policy", organizationHandler.SetDomainJoinPolicy).Methods(http.MethodPut)
+	organizations.HandleFunc("/{id}/domains", organizationHandler.ListDomains).Methods(http.MethodGet)
+	organizations.HandleFunc("/{id}/domains", organizationHandler.AddDomain).Methods(http.MethodPost)
+	organizations.HandleFunc("/{id}/domains/{domain}", organizationHandler.RemoveDomain).Methods(http.MethodDelete)
+	organizations.HandleFunc("/{id}/domains/{domain}/verify", organizationHandler.VerifyDomain).Methods(http.MethodPost)
+	if r.sso != nil {
+		organizations.HandleFunc("/{id}/sso-connections", organizationHandler.ListSSOConnections).Methods(http.MethodGet)
+		organizations.HandleFunc("/{id}/sso-connections", organizationHandler.CreateSSOConnection).Methods(http.MethodPost)
+		organizations.HandleFunc("/{id}/sso-connections/{connectionId}", organizationHandler.GetSSOConnection).Methods(http.MethodGet)
+		organizations.HandleFunc("/{id}/sso-connections/{connectionId}", organizationHandler.UpdateSSOConnection).Methods(http.MethodPut)
+		organizations.HandleFunc("/{id}/sso-connections/{connectionId}", organizationHandler.DeleteSSOConnection).Methods(http.MethodDelete)
+		organizations.HandleFunc("/{id}/sso-connections/{connectionId}/domains", organizationHandler.AddSSODomain).Methods(http.MethodPost)
+		organizations.HandleFunc("/{id}/sso-connections/{connectionId}/domains/{domain}", organizationHandler.RemoveSSODomain).Methods(http.MethodDelete)
+		organizations.HandleFunc("/{id}/sso-connections/{connectionId}/domains/{domain}/verify", organizationHandler.VerifySSODomain).Methods(http.MethodPost)
+	}
+
+	// Admin routes
+	r.logger.Debug("Setting up admin routes...")
+	// Each admin route requires the permission covering it
+	admin := protected.PathPrefix("/admin").Subrouter()
+	requires := func(permission models.Permission, handler http.HandlerFunc) http.Handler {
+		return authMiddleware.RequirePermission(permission)(handler)
+	}
+	adminHandler := handlers.NewAdminHandler(r.userService, r.tokenService, r.metricsService, r.logger)
+	admin.Handle("/users/search", requires(models.PermissionUsersRead, adminHandler.SearchUsers)).Methods(http.MethodGet)
+	admin.Handle("/users/{id}/username-history", requires(models.PermissionUsersRead, adminHandler.GetUsernameHistory)).Methods(http.MethodGet)
+	admin.Handle("/users/{id}/status", requires(models.PermissionUsersWrite, adminHandler.UpdateUserStatus)).Methods(http.MethodPut)
+	admin.Handle("/users/{id}/organization", requires(models.PermissionUsersWrite, adminHandler.SetUserOrganization)).Methods(http.MethodPut)
+	admin.Handle("/users/{id}/mfa", requires(models.PermissionUsersWrite, adminHandler.ResetUserMFA)).Methods(http.MethodDelete)
+	admin.Handle("/users/{id}/password-reset", requires(models.PermissionUsersWrite, adminHandler.RequestUserPasswordReset)).Methods(http.MethodPost)
+	admin.Handle("/users/{id}/email-verification", requires(models.PermissionUsersRead, adminHandler.GetEmailVerification)).Methods(http.MethodGet)
+	admin.Handle("/users/{id}/permissions", requires(models.PermissionUsersRead, adminHandler.GetPermissions)).Methods(http.MethodGet)
+	admin.Handle("/users/{id}/risk", requires(models.PermissionUsersRead, adminHandler.GetAccountRisk)).Methods(http.MethodGet)
+	admin.Handle("/users/{id}/risk", requires(models.PermissionUsersWrite, adminHandler.ResetAccountRisk)).Methods(http.MethodDelete)
+	admin.Handle("/users/{id}/purge-approvals", requires(models.PermissionUsersPurge, adminHandler.ApproveUserPurge)).Methods(http.MethodPost)
+	admin.Handle("/users/{id}/purge", requires(models.PermissionUsersPurge, adminHandler.PurgeUser)).Methods(http.MethodPost)
+	admin.Handle("/users/{id}/restore", requires(models.PermissionUsersWrite, adminHandler.RestoreUser)).Methods(http.MethodPost)
+	roleHandler := handlers.NewRoleHandler(r.roles, r.metricsService, r.logger)
+	admin.Handle("/users/{id}/role", requires(models.PermissionRolesWrite, roleHandler.AssignUserRole)).Methods(http.MethodPut)
+	admin.Handle("/permissions", requires(models.PermissionRolesRead, roleHandler.ListPermissions)).Methods(http.MethodGet)
+	admin.Handle("/roles", requires(models.PermissionRolesRead, roleHandler.ListRoles)).Methods(http.MethodGet)
+	admin.Handle("/roles", requires(models.PermissionRolesWrite, roleHandler.CreateRole)).Methods(http.MethodPost)
+	admin.Handle("/roles/{name}", requires(models.PermissionRolesRead, roleHandler.GetRole)).Methods(http.MethodGet)
+	admin.Handle("/roles/{name}", requires(models.PermissionRolesWrite, roleHandler.DeleteRole)).Methods(http.MethodDelete)
+	admin.Handle("/roles/{name}/permissions", requires(models.PermissionRolesWrite, roleHandler.SetRolePermissions)).Methods(http.MethodPut)
+	admin.Handle("/roles/{name}/permissions/{permission}", requires(models.PermissionRolesWrite, roleHandler.GrantPermission)).Methods(http.MethodPut)
+	admin.Handle("/roles/{name}/permissions/{permission}", requires(models.PermissionRolesWrite, roleHandler.RevokePermission)).Methods(http.MethodDelete)
+	if webhookHandler != nil {
+		admin.Handle("/users/{id}/webhooks", requires(models.PermissionUsersRead, webhookHandler.ListWebhooks)).Methods(http.MethodGet)
+		admin.Handle("/users/{id}/webhooks", requires(models.PermissionUsersWrite, webhookHandler.RegisterWebhook)).Methods(http.MethodPost)
+		admin.Handle("/users/{id}/webhooks/{webhookId}", requires(models.PermissionUsersWrite, webhookHandler.DeleteWebhook)).Methods(http.MethodDelete)
+	}
+	if moderationHandler != nil {
+		admin.Handle("/users/{id}/flags", requires(models.PermissionUsersWrite, moderationHandler.FlagAccount)).Methods(http.MethodPost)
+		admin.Handle("/flags", requires(models.PermissionUsersRead, moderationHandler.ListFlags)).Methods(http.MethodGet)
+		admin.Handle("/flags/{flagId}", requires(models.PermissionUsersWrite, moderationHandler.ReviewFlag)).Methods(http.MethodPut)
+	}
+	if r.apiKeys != nil {
+		serviceAccountHandler := handlers.NewServiceAccountHandler(r.apiKeys, r.metricsService, r.logger)
+		admin.Handle("/service-accounts", requires(models.PermissionServiceAccountsWrite, serviceAccountHandler.CreateServiceAccount)).Methods(http.MethodPost)
+		admin.Handle("/service-accounts/{id}/api-keys", requires(models.PermissionServiceAccountsWrite, serviceAccountHandler.ListAPIKeys)).Methods(http.MethodGet)
+		admin.Handle("/service-accounts/{id}/api-keys", requires(models.PermissionServiceAccountsWrite, serviceAccountHandler.CreateAPIKey)).Methods(http.MethodPost)
+		admin.Handle("/service-accounts/{id}/api-keys/{keyId}", requires(models.PermissionServiceAccountsWrite, serviceAccountHandler.RevokeAPIKey)).Methods(http.MethodDelete)
+	}
+	if r.cacheAdmin != nil {
+		cacheAdminHandler := handlers.NewCacheAdminHandler(r.cacheAdmin, r.metricsService, r.logger)
+		admin.Handle("/cache/stats", requires(models.PermissionCacheAdmin, cacheAdminHandler.GetStats)).Methods(http.MethodGet)
+		admin.Handle("/cache/invalidate", requires(models.PermissionCacheAdmin, cacheAdminHandler.Invalidate)).Methods(http.MethodPost)
+	}
+	admin.Handle("/credential-breaches", requires(models.PermissionCredentialBreachesWrite, adminHandler.ImportBreachedCredentials)).Methods(http.MethodPost)
+	admin.Handle("/signing-keys", requires(models.PermissionSigningKeysRead, adminHandler.ListSigningKeys)).Methods(http.MethodGet)
+	admin.Handle("/signing-keys/{type}/rotate", requires(models.PermissionSigningKeysRotate, adminHandler.RotateSigningKey)).Methods(http.MethodPost)
+	if oauthHandler != nil {
+		admin.Handle("/oauth/clients", requires(models.PermissionOAuthClientsWrite, oauthHandler.ListClients)).Methods(http.MethodGet)
+		admin.Handle("/oauth/clients", requires(models.PermissionOAuthClientsWrite, oauthHandler.RegisterClient)).Methods(http.MethodPost)
+		admin.Handle("/oauth/clients/{clientId}", requires(models.PermissionOAuthClientsWrite, oauthHandler.DeleteClient)).Methods(http.MethodDelete)
+		admin.Handle("/oauth/clients/{clientId}", requires(models.PermissionOAuthClientsWrite, oauthHandler.UpdateClient)).Methods(http.MethodPatch)
+		admin.Handle("/oauth/clients/{clientId}/disable", requires(models.PermissionOAuthClientsWrite, oauthHandler.DisableClient)).Methods(http.MethodPost)
+		admin.Handle("/oauth/clients/{clientId}/enable", requires(models.PermissionOAuthClientsWrite, oauthHandler.EnableClient)).Methods(http.MethodPost)
+		admin.Handle("/oauth/clients/{clientId}/secret", requires(models.PermissionOAuthClientsWrite, oauthHandler.RegenerateClientSecret)).Methods(http.MethodPost)
+	}
+	tenantSettingsHandler := handlers.NewTenantSettingsHandler(r.tenantSettings, r.metricsService, r.logger)
+	admin.Handle("/organizations/{id}/settings", requires(models.PermissionOrganizationsWrite, tenantSettingsHandler.GetSettings)).Methods(http.MethodGet)
+	admin.Handle("/organizations/{id}/settings", requires(models.PermissionOrganizationsWrite, tenantSettingsHandler.SaveSettings)).Methods(http.MethodPut)
+	admin.Handle("/organizations/{id}/settings", requires(models.PermissionOrganizationsWrite, tenantSettingsHandler.DeleteSettings)).Methods(http.MethodDelete)
+	mfaPolicyHandler := handlers.NewMFAPolicyHandler(r.mfaPolicies, r.metricsService, r.logger)
+	admin.Handle("/mfa-policies", requires(models.PermissionMFAPoliciesWrite, mfaPolicyHandler.ListPolicies)).Methods(http.MethodGet)
+	admin.Handle("/mfa-policies", requires(models.PermissionMFAPoliciesWrite, mfaPolicyHandler.CreatePolicy)).Methods(http.MethodPost)
+	admin.Handle("/mfa-policies/{id}", requires(models.PermissionMFAPoliciesWrite, mfaPolicyHandler.GetPolicy)).Methods(http.MethodGet)
+	admin.Handle("/mfa-policies/{id}", requires(models.PermissionMFAPoliciesWrite, mfaPolicyHandler.UpdatePolicy)).Methods(http.MethodPut)
+	admin.Handle("/mfa-policies/{id}", requires(models.PermissionMFAPoliciesWrite, mfaPolicyHandler.DeletePolicy)).Methods(http.MethodDelete)
+	if r.auditLogService != nil {
+		auditLogHandler := handlers.NewAuditLogHandler(r.auditLogService, r.metricsService, r.logger)
+		admin.Handle("/audit-log", requires(models.PermissionAuditLogRead, auditLogHandler.List)).Methods(http.MethodGet)
+		admin.Handle("/audit-log/verify", requires(models.PermissionAuditLogRead, auditLogHandler.Verify)).Methods(http.MethodGet)
+	}
+	modeHandler := handlers.NewModeHandler(modeController, r.metricsService, r.logger)
+	admin.Handle("/mode", requires(models.PermissionServiceModeRead, modeHandler.GetMode)).Methods(http.MethodGet)
+	admin.Handle("/mode", requires(models.PermissionServiceModeWrite, modeHandler.SetMode)).Methods(http.MethodPut)
+	if r.config.RuntimeConfig != nil {
+		configHandler := handlers.NewConfigHandler(r.config.RuntimeConfig, r.metricsService, r.logger)
+		admin.Handle("/config", requires(models.PermissionConfigRead, configHandler.GetConfig)).Methods(http.MethodGet)
+	}
+
+	// Admin console; its API calls are authorized like any other
+	if r.config.AdminUI {
+		r.logger.Debug("Setting up admin UI...")
+		router.Handle("/admin", http.RedirectHandler(adminui.Path, http.StatusMovedPermanently)).Methods(http.MethodGet)
+		router.PathPrefix(adminui.Path).Handler(adminui.Handler()).Methods(http.MethodGet, http.MethodHead)
+	}
 
 	// Swagger documentation
 	docs.SwaggerInfo.BasePath = "/api/v1"
@@ -98,7 +479,10 @@ func (r *Router) Setup() http.Handler {
 	})
 
 	// Metrics endpoint
-	router.Handle("/metrics", promhttp.Handler())
+	router.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+			EnableOpenMetrics: r.config.OpenMetrics,
+		})))
 
 	// Not found handler
 	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -109,6 +493,8 @@ func (r *Router) Setup() http.Handler {
 		}
 	})
 
+	// Answer CORS preflights around the router: mux runs middleware only for
+	// matched routes, and the routes do not match OPTIONS requests
 	r.logger.Info("Router setup completed successfully")
-	return router
+	return middleware.CORSMiddleware(r.config.CORS)(router)
 }
@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/mibrahim2344/identity-service/internal/domain/services"
+	"github.com/mibrahim2344/identity-service/internal/infrastructure/lifecycle"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/handlers"
+	"github.com/mibrahim2344/identity-service/internal/interfaces/http/middleware"
 	"github.com/mibrahim2344/identity-service/internal/interfaces/http/router"
 	"go.uber.org/zap"
 )
@@ -18,60 +22,126 @@ type Config struct {
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 	MaxHeaderBytes int
-	AllowedOrigins []string
-	AllowedMethods []string
-	AllowedHeaders []string
+	Router         router.Config // Router.RequestTimeout defaults to WriteTimeout
 }
 
-// Server represents the HTTP server
+// Server represents the HTTP server. It starts listening before the
+// application is mounted so that liveness and readiness probes can report
+// startup progress while dependencies are still initializing.
 type Server struct {
-	config         Config
-	userService    services.UserService
-	tokenService   services.TokenService
-	metricsService services.MetricsService
-	logger         *zap.Logger
-	httpServer     *http.Server
-	router         *router.Router
+	config     Config
+	tracker    *lifecycle.Tracker
+	logger     *zap.Logger
+	httpServer *http.Server
+	router     *router.Router
+	readiness  http.Handler
+	liveness   http.Handler
+	app        atomic.Value // http.Handler, set by Mount
 }
 
-// NewServer creates a new server instance
+// NewServer creates a new server instance. Readiness probes check the
+// dependencies registered with health.
 func NewServer(
 	config Config,
+	tracker *lifecycle.Tracker,
+	health *lifecycle.Health,
+	logger *zap.Logger,
+) *Server {
+	if config.Router.RequestTimeout == 0 {
+		config.Router.RequestTimeout = config.WriteTimeout
+	}
+
+	s := &Server{
+		config:    config,
+		tracker:   tracker,
+		logger:    logger,
+		readiness: handlers.NewReadinessHandler(tracker, health, logger),
+		liveness:  handlers.NewLivenessHandler(logger),
+	}
+	s.httpServer = &http.Server{
+		Addr:           fmt.Sprintf("%s:%d", config.Host, config.Port),
+		Handler:        s,
+		ReadTimeout:    config.ReadTimeout,
+		WriteTimeout:   config.WriteTimeout,
+		MaxHeaderBytes: config.MaxHeaderBytes,
+	}
+	return s
+}
+
+// Mount sets up all routes with the application services, after which the
+// server handles API requests. oauthService, auditLogService, federation,
+// webhooks, moderation, apiKeys, sso and bootstrap may be nil; modeCache
+// shares the service mode between instances and may be nil as well.
+func (s *Server) Mount(
 	userService services.UserService,
 	tokenService services.TokenService,
+	oauthService services.OAuthService,
+	auditLogService services.AuditLogService,
+	tenantSettings services.TenantSettingsService,
+	federation services.FederationService,
+	mfaPolicies services.MFAPolicyService,
+	webhooks services.NotificationWebhookService,
+	moderation services.ModerationService,
+	apiKeys services.APIKeyService,
+	cacheAdmin services.CacheAdminService,
+	roles services.RoleService,
+	organizations services.OrganizationService,
+	sso services.SSOService,
+	bootstrap services.BootstrapService,
+	modeCache services.CacheService,
 	metricsService services.MetricsService,
-	logger *zap.Logger,
-) *Server {
-	return &Server{
-		config:         config,
-		userService:    userService,
-		tokenService:   tokenService,
-		metricsService: metricsService,
-		logger:         logger,
+) {
+	s.logger.Info("Setting up routes...")
+	s.router = router.NewRouter(s.config.Router, userService, tokenService, oauthService, auditLogService, tenantSettings, federation, mfaPolicies, webhooks, moderation, apiKeys, cacheAdmin, roles, organizations, sso, bootstrap, modeCache, metricsService, s.logger)
+	s.app.Store(s.router.Setup())
+}
+
+// ModeController returns the controller of the service mode, or nil until
+// the server is mounted
+func (s *Server) ModeController() *middleware.ModeController {
+	if s.router == nil {
+		return nil
+	}
+	return s.router.ModeController()
+}
+
+// ServeHTTP serves liveness and readiness probes and, once mounted, the
+// application routes
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/readyz":
+		s.readiness.ServeHTTP(w, r)
+		return
+	case "/healthz":
+		s.liveness.ServeHTTP(w, r)
+		return
+	}
+
+	if app, ok := s.app.Load().(http.Handler); ok {
+		app.ServeHTTP(w, r)
+		return
+	}
+
+	// Still starting: the process is alive but cannot serve requests yet
+	if r.URL.Path == "/health" {
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("OK")); err != nil {
+			s.logger.Error("failed to write response", zap.Error(err))
+		}
+		return
+	}
+
+	if err := middleware.WriteError(w, r, http.StatusServiceUnavailable, "service_starting", "service is starting"); err != nil {
+		s.logger.Error("failed to encode response", zap.Error(err))
 	}
 }
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
-	s.logger.Info("Setting up routes...")
-	s.router = router.NewRouter(s.userService, s.tokenService, s.metricsService, s.logger)
-	handler := s.router.Setup()
-	
-	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
-	s.logger.Info("Starting HTTP server", 
-		zap.String("address", addr),
+	s.logger.Info("Starting HTTP server",
+		zap.String("address", s.httpServer.Addr),
 		zap.Int("port", s.config.Port),
 	)
-	
-	s.httpServer = &http.Server{
-		Addr:           addr,
-		Handler:        handler,
-		ReadTimeout:    s.config.ReadTimeout,
-		WriteTimeout:   s.config.WriteTimeout,
-		MaxHeaderBytes: s.config.MaxHeaderBytes,
-	}
-
-	s.logger.Info("Server is listening", zap.String("address", addr))
 	return s.httpServer.ListenAndServe()
 }
 
@@ -84,4 +154,4 @@ func (s *Server) Stop(ctx context.Context) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_username_changes_old_username;
+DROP INDEX IF EXISTS idx_username_changes_user_id;
+DROP TABLE IF EXISTS username_changes;
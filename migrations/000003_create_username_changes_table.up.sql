@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS username_changes (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    old_username VARCHAR(255) NOT NULL,
+    new_username VARCHAR(255) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reserved_until TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_username_changes_user_id ON username_changes(user_id, changed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_username_changes_old_username ON username_changes(LOWER(old_username));
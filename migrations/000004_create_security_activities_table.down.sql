@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_security_activities_user_id;
+DROP TABLE IF EXISTS security_activities;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS security_activities (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    device_id VARCHAR(255),
+    user_agent TEXT,
+    ip_address VARCHAR(64),
+    session_id VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_security_activities_user_id ON security_activities(user_id, type, created_at);
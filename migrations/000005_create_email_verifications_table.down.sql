@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_email_verifications_user_id;
+DROP INDEX IF EXISTS idx_email_verifications_token_hash;
+DROP TABLE IF EXISTS email_verifications;
@@ -0,0 +1,5 @@
+-- Fails if a deleted user's email has been registered again
+DROP INDEX IF EXISTS idx_users_email_active;
+DROP INDEX IF EXISTS idx_users_username_active;
+
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id UUID PRIMARY KEY,
+    client_id VARCHAR(64) NOT NULL,
+    secret_hash VARCHAR(64),
+    name VARCHAR(255) NOT NULL,
+    redirect_uris JSONB NOT NULL DEFAULT '[]',
+    grant_types JSONB NOT NULL DEFAULT '[]',
+    scopes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oauth_clients_client_id ON oauth_clients(client_id);
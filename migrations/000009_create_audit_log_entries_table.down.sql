@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS audit_log_entries_append_only ON audit_log_entries;
+DROP FUNCTION IF EXISTS reject_audit_log_change();
+DROP TABLE IF EXISTS audit_log_entries;
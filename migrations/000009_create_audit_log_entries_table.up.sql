@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS audit_log_entries (
+    sequence BIGINT PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    actor_id VARCHAR(255),
+    payload TEXT NOT NULL,
+    previous_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- The log is append-only; the hash chain reveals changes made by anyone
+-- able to bypass this trigger
+CREATE OR REPLACE FUNCTION reject_audit_log_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_entries_append_only
+    BEFORE UPDATE OR DELETE ON audit_log_entries
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
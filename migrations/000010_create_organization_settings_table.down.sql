@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS organization_settings;
+DROP INDEX IF EXISTS idx_users_organization_id;
+ALTER TABLE users DROP COLUMN IF EXISTS organization_id;
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS organization_id UUID;
+CREATE INDEX IF NOT EXISTS idx_users_organization_id ON users(organization_id);
+
+CREATE TABLE IF NOT EXISTS organization_settings (
+    organization_id UUID PRIMARY KEY,
+    password_policy JSONB,
+    access_token_ttl_seconds INTEGER,
+    refresh_token_ttl_seconds INTEGER,
+    require_mfa BOOLEAN,
+    email_templates JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Users whose credentials were found in a breach must reset their password
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+ALTER TABLE organization_settings DROP COLUMN IF EXISTS session_limit_action;
+ALTER TABLE organization_settings DROP COLUMN IF EXISTS max_sessions;
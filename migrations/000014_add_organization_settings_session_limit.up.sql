@@ -0,0 +1,3 @@
+-- Organizations may override the concurrent session limit of their users
+ALTER TABLE organization_settings ADD COLUMN IF NOT EXISTS max_sessions INTEGER;
+ALTER TABLE organization_settings ADD COLUMN IF NOT EXISTS session_limit_action VARCHAR(20);
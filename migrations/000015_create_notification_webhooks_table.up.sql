@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS notification_webhooks (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    event_types JSONB,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_webhooks_user_id ON notification_webhooks(user_id);
@@ -0,0 +1 @@
+ALTER TABLE organization_settings DROP COLUMN IF EXISTS access_policy;
@@ -0,0 +1,2 @@
+-- Organizations may restrict the countries and hours their users log in from
+ALTER TABLE organization_settings ADD COLUMN IF NOT EXISTS access_policy JSONB;
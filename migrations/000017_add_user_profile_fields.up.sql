@@ -0,0 +1,3 @@
+-- Optional profile fields users fill in after registration
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_number VARCHAR(32);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(35);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS account_flags (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL,
+    reported_by UUID NOT NULL,
+    by_admin BOOLEAN NOT NULL DEFAULT false,
+    status VARCHAR(20) NOT NULL,
+    reviewed_by UUID,
+    review_note TEXT,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_flags_user_id ON account_flags(user_id);
+-- The review queue lists flags by status, oldest first
+CREATE INDEX IF NOT EXISTS idx_account_flags_status_created_at ON account_flags(status, created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_recovery_email_lower;
+ALTER TABLE users DROP COLUMN IF EXISTS recovery_email_verified_at;
+ALTER TABLE users DROP COLUMN IF EXISTS recovery_email;
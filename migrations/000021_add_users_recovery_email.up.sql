@@ -0,0 +1,6 @@
+-- Verified second address password resets can be sent to
+ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_email VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Recovery requests look users up by their recovery email case-insensitively
+CREATE INDEX IF NOT EXISTS idx_users_recovery_email_lower ON users(LOWER(recovery_email)) WHERE recovery_email IS NOT NULL AND deleted_at IS NULL;
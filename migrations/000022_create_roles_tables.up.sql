@@ -0,0 +1,42 @@
+-- Roles users are assigned and the permissions each grants
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(50) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+-- The built-in roles start with the permissions they had when hardcoded
+INSERT INTO roles (name, description, created_at, updated_at) VALUES
+    ('admin', 'Administers users and the service', NOW(), NOW()),
+    ('user', 'Manages their own account', NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('user', 'profile:read'),
+    ('user', 'profile:write'),
+    ('admin', 'profile:read'),
+    ('admin', 'profile:write'),
+    ('admin', 'users:read'),
+    ('admin', 'users:write'),
+    ('admin', 'users:purge'),
+    ('admin', 'roles:read'),
+    ('admin', 'roles:write'),
+    ('admin', 'service_accounts:write'),
+    ('admin', 'oauth_clients:write'),
+    ('admin', 'organizations:write'),
+    ('admin', 'mfa_policies:write'),
+    ('admin', 'cache:admin'),
+    ('admin', 'audit_log:read'),
+    ('admin', 'credential_breaches:write'),
+    ('admin', 'signing_keys:read'),
+    ('admin', 'signing_keys:rotate'),
+    ('admin', 'service_mode:read'),
+    ('admin', 'service_mode:write')
+ON CONFLICT (role, permission) DO NOTHING;
@@ -0,0 +1,39 @@
+-- Organizations users belong to. Until now organizations were only IDs on
+-- users and organization_settings.
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Users may be members of several organizations, each with a role scoped
+-- to it. users.organization_id remains the organization whose settings
+-- apply and which tokens name as the tenant.
+CREATE TABLE IF NOT EXISTS organization_memberships (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member',
+    invited_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_memberships_user_id ON organization_memberships(user_id);
+
+-- Existing organizations become records and their users members
+INSERT INTO organizations (id, name, created_at, updated_at)
+SELECT organization_id, organization_id::text, NOW(), NOW()
+FROM (
+    SELECT organization_id FROM users WHERE organization_id IS NOT NULL
+    UNION
+    SELECT organization_id FROM organization_settings
+) existing
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO organization_memberships (organization_id, user_id, role, created_at)
+SELECT organization_id, id, 'member', NOW()
+FROM users
+WHERE organization_id IS NOT NULL
+ON CONFLICT (organization_id, user_id) DO NOTHING;
@@ -0,0 +1,9 @@
+-- Answers to security questions users chose for account recovery, stored
+-- only as slow hashes of the normalized answers
+CREATE TABLE IF NOT EXISTS knowledge_factors (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    question VARCHAR(255) NOT NULL,
+    answer_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, question)
+);
@@ -0,0 +1,33 @@
+-- Single sign-on connections organizations configure with their own
+-- identity providers
+CREATE TABLE IF NOT EXISTS sso_connections (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    protocol VARCHAR(20) NOT NULL,
+    issuer TEXT NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    client_secret TEXT NOT NULL,
+    auth_url TEXT NOT NULL,
+    token_url TEXT NOT NULL,
+    user_info_url TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sso_connections_organization_id ON sso_connections(organization_id);
+
+-- Email domains whose users sign in through a connection. Any connection
+-- may claim a domain, but only one may prove it owns it.
+CREATE TABLE IF NOT EXISTS sso_domains (
+    connection_id UUID NOT NULL REFERENCES sso_connections(id) ON DELETE CASCADE,
+    domain VARCHAR(253) NOT NULL,
+    verification_token VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (connection_id, domain)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sso_domains_verified_domain ON sso_domains(domain) WHERE verified_at IS NOT NULL;
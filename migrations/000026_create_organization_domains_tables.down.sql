@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS organization_invitations;
+DROP TABLE IF EXISTS organization_domains;
+ALTER TABLE organizations DROP COLUMN IF EXISTS domain_join_policy;
@@ -0,0 +1,27 @@
+-- What happens to users whose verified email is in one of the
+-- organization's domains: off, invite or join
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS domain_join_policy VARCHAR(20) NOT NULL DEFAULT 'off';
+
+-- Email domains organizations claim. Any organization may claim a domain,
+-- but only one may prove it owns it.
+CREATE TABLE IF NOT EXISTS organization_domains (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    domain VARCHAR(253) NOT NULL,
+    verification_token VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (organization_id, domain)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organization_domains_verified_domain ON organization_domains(domain) WHERE verified_at IS NOT NULL;
+
+-- Invitations users accept to become members
+CREATE TABLE IF NOT EXISTS organization_invitations (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_user_id ON organization_invitations(user_id);
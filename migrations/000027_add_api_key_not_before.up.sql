@@ -0,0 +1,3 @@
+-- API keys can be provisioned ahead of time and only authenticate requests
+-- from their activation on
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS not_before TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,4 @@
+-- Admins may read the effective configuration at /admin/config
+INSERT INTO role_permissions (role, permission)
+SELECT name, 'config:read' FROM roles WHERE name = 'admin'
+ON CONFLICT (role, permission) DO NOTHING;
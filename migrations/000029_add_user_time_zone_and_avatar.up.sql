@@ -0,0 +1,3 @@
+-- Profile fields that do not count towards profile completeness
+ALTER TABLE users ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url VARCHAR(2048);
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS marketing_opt_out;
@@ -0,0 +1,2 @@
+-- Users who opt out of marketing have marketing-relevant events about them suppressed
+ALTER TABLE users ADD COLUMN IF NOT EXISTS marketing_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+-- Accounts awaiting their purge are deleted right away, as before deactivation
+UPDATE users SET status = 'deleted', deleted_at = COALESCE(deleted_at, deactivated_at)
+WHERE status = 'deactivated';
+DROP INDEX IF EXISTS idx_users_deactivated_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deactivated_at;
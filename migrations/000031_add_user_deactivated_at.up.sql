@@ -0,0 +1,4 @@
+-- Deleting an account deactivates it; the purge job deletes it permanently
+-- once the grace period after deactivated_at has passed
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_users_deactivated_at ON users (deactivated_at) WHERE deactivated_at IS NOT NULL;
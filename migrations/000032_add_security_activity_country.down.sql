@@ -0,0 +1,2 @@
+DELETE FROM security_activities WHERE type = 'login_confirmed';
+ALTER TABLE security_activities DROP COLUMN IF EXISTS country;
@@ -0,0 +1,3 @@
+-- The country a login came from, as geolocated by the CDN or proxy, so that
+-- logins from new countries can be detected
+ALTER TABLE security_activities ADD COLUMN IF NOT EXISTS country VARCHAR(2);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_security_activities_device_id;
+DROP INDEX IF EXISTS idx_security_activities_ip_address;
+DELETE FROM security_activities WHERE type = 'registration';
+ALTER TABLE users DROP COLUMN IF EXISTS verification_required;
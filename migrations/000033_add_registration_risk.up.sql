@@ -0,0 +1,6 @@
+-- Risky registrations must verify their email before they can sign in
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_required BOOLEAN NOT NULL DEFAULT false;
+
+-- Registrations are counted per IP address and device for risk scoring
+CREATE INDEX IF NOT EXISTS idx_security_activities_ip_address ON security_activities(ip_address, type, created_at);
+CREATE INDEX IF NOT EXISTS idx_security_activities_device_id ON security_activities(device_id, type, created_at);
@@ -0,0 +1,9 @@
+-- Versions of the signing keys of each token type. The newest version signs
+-- tokens; the one before it still validates tokens it signed.
+CREATE TABLE IF NOT EXISTS signing_keys (
+    token_type VARCHAR(20) NOT NULL,
+    version INTEGER NOT NULL,
+    key_material BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (token_type, version)
+);
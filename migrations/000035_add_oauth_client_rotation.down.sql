@@ -0,0 +1,3 @@
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS previous_secret_expires_at;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS previous_secret_hash;
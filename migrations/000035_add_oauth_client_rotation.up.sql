@@ -0,0 +1,6 @@
+-- Rotated client secrets keep working for an overlap window
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS previous_secret_hash VARCHAR(64);
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS previous_secret_expires_at TIMESTAMP WITH TIME ZONE;
+
+-- Disabled clients cannot authenticate or sign users in
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP WITH TIME ZONE;
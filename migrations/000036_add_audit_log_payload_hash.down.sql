@@ -0,0 +1 @@
+ALTER TABLE audit_log_entries DROP COLUMN IF EXISTS payload_hash;
@@ -0,0 +1,3 @@
+-- Payloads are stored with personal data redacted; the hash ties an entry
+-- to the event as published
+ALTER TABLE audit_log_entries ADD COLUMN IF NOT EXISTS payload_hash VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Organizations may turn monthly security summaries on or off for their users
+ALTER TABLE organization_settings ADD COLUMN IF NOT EXISTS security_summaries BOOLEAN;
@@ -0,0 +1,11 @@
+// Package migrations embeds the versioned SQL migrations of the database
+// schema, so that the service binary can apply them without the source tree.
+// Each version has an .up.sql file and an .down.sql file rolling it back.
+package migrations
+
+import "embed"
+
+// Files holds the migration files
+//
+//go:embed *.sql
+var Files embed.FS